@@ -0,0 +1,54 @@
+// Command genvectors regenerates the golden fixtures of the proof/interop package: canonical
+// bytes, signed documents for every signature suite, and DID Keys (see interop.GenerateFixtures).
+//
+// The fixtures are golden, so a changed fixture means that documents signed by an earlier
+// version no longer verify. The command refuses to run without -update, so that fixtures are
+// never rewritten by accident; review the diff before committing it. Run it from the module root:
+//
+//	go run ./cmd/genvectors -update
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/workdaycredentials/ledger-common/proof/interop"
+)
+
+func main() {
+	update := flag.Bool("update", false, "write the generated fixtures")
+	dir := flag.String("dir", filepath.Join("proof", "interop", "fixtures"), "fixtures directory")
+	flag.Parse()
+
+	if !*update {
+		fmt.Fprintln(os.Stderr, "genvectors rewrites the golden fixtures; run with -update to confirm")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	files, err := interop.GenerateFixtures()
+	if err != nil {
+		log.Fatal(err)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(*dir, name)
+		if existing, err := ioutil.ReadFile(path); err == nil && bytes.Equal(existing, files[name]) {
+			continue
+		}
+		if err := ioutil.WriteFile(path, files[name], 0644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("updated", path)
+	}
+}
@@ -0,0 +1,133 @@
+package credential
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"io"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util/multibase"
+)
+
+// Digest algorithms of attachments, named as in the multihash table
+// (https://github.com/multiformats/multicodec).
+const (
+	SHA256DigestAlgorithm = "sha2-256"
+	SHA512DigestAlgorithm = "sha2-512"
+)
+
+// ErrAttachmentDigestMismatch is returned by VerifyAttachment when the content does not match the
+// attachment's digest.
+var ErrAttachmentDigestMismatch = errors.New("attachment content does not match its digest")
+
+// digestAlgorithms are the hash functions of the supported digest algorithms.
+var digestAlgorithms = map[string]func() hash.Hash{
+	SHA256DigestAlgorithm: sha256.New,
+	SHA512DigestAlgorithm: sha512.New,
+}
+
+// Attachment references an external artifact of a credential, such as a PDF, without embedding
+// it. The digest of the content is covered by the credential's proof, so that the artifact that is
+// fetched from the URI can be checked with VerifyAttachment.
+type Attachment struct {
+	// ID identifies the attachment in the credential.
+	ID        string `json:"id"`
+	MediaType string `json:"mediaType"`
+	// URI is the absolute URI that the content is fetched from.
+	URI string `json:"uri"`
+	// DigestAlgorithm is the algorithm of the digest. Empty means SHA256DigestAlgorithm.
+	DigestAlgorithm string `json:"digestAlgorithm,omitempty"`
+	// Digest is the multibase encoded digest of the content.
+	Digest string `json:"digestMultibase"`
+}
+
+// NewAttachment returns an attachment with the SHA-256 digest of the content, encoded as
+// base58btc multibase.
+func NewAttachment(id, mediaType, uri string, content io.Reader) (*Attachment, error) {
+	digest, err := digestContent(SHA256DigestAlgorithm, content)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := multibase.Encode(multibase.Base58BTC, digest)
+	if err != nil {
+		return nil, err
+	}
+	attachment := &Attachment{ID: id, MediaType: mediaType, URI: uri, Digest: encoded}
+	if err := attachment.validate(); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// VerifyAttachment streams the content through the attachment's digest algorithm, and returns
+// ErrAttachmentDigestMismatch if the digest differs. The content may be empty.
+func VerifyAttachment(att Attachment, content io.Reader) error {
+	expected, err := att.digest()
+	if err != nil {
+		return err
+	}
+	digest, err := digestContent(att.algorithm(), content)
+	if err != nil {
+		return err
+	}
+	if string(digest) != string(expected) {
+		return errors.Wrapf(ErrAttachmentDigestMismatch, "attachment<%s>", att.ID)
+	}
+	return nil
+}
+
+// validate checks that the attachment has an ID, a media type, an absolute URI and a well-formed
+// digest of a supported algorithm.
+func (a Attachment) validate() error {
+	if a.ID == "" {
+		return errors.New("attachment ID is required")
+	}
+	if a.MediaType == "" {
+		return errors.Errorf("attachment<%s> media type is required", a.ID)
+	}
+	if u, err := url.Parse(a.URI); err != nil || !u.IsAbs() {
+		return errors.Errorf("attachment<%s> URI<%s> must be absolute", a.ID, a.URI)
+	}
+	_, err := a.digest()
+	return err
+}
+
+// algorithm returns the digest algorithm, defaulting to SHA-256.
+func (a Attachment) algorithm() string {
+	if a.DigestAlgorithm == "" {
+		return SHA256DigestAlgorithm
+	}
+	return a.DigestAlgorithm
+}
+
+// digest decodes the digest, and checks its length against the algorithm.
+func (a Attachment) digest() ([]byte, error) {
+	newHash, ok := digestAlgorithms[a.algorithm()]
+	if !ok {
+		return nil, errors.Errorf("attachment<%s> has unsupported digest algorithm<%s>", a.ID, a.DigestAlgorithm)
+	}
+	_, digest, err := multibase.Decode(a.Digest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "attachment<%s> digest", a.ID)
+	}
+	if len(digest) != newHash().Size() {
+		return nil, errors.Errorf("attachment<%s> digest has invalid length<%d>", a.ID, len(digest))
+	}
+	return digest, nil
+}
+
+// digestContent returns the digest of the content with the algorithm.
+func digestContent(algorithm string, content io.Reader) ([]byte, error) {
+	newHash, ok := digestAlgorithms[algorithm]
+	if !ok {
+		return nil, errors.Errorf("unsupported digest algorithm<%s>", algorithm)
+	}
+	h := newHash()
+	if _, err := io.Copy(h, content); err != nil {
+		return nil, errors.Wrap(err, "failed to read attachment content")
+	}
+	return h.Sum(nil), nil
+}
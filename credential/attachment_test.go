@@ -0,0 +1,87 @@
+package credential
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/multibase"
+)
+
+func TestAttachments(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, signer := didKeySigner(t, 1)
+	subjectDID, _ := didKeySigner(t, 2)
+	payslip := []byte("%PDF-1.4 payslip")
+	photo := bytes.Repeat([]byte{0xff, 0xd8}, 100000)
+
+	payslipAttachment, err := NewAttachment("payslip", "application/pdf", "https://example.com/payslips/1.pdf", bytes.NewReader(payslip))
+	require.NoError(t, err)
+	photoAttachment, err := NewAttachment("photo", "image/jpeg", "https://example.com/photos/1.jpg", bytes.NewReader(photo))
+	require.NoError(t, err)
+	emptyAttachment, err := NewAttachment("empty", "text/plain", "https://example.com/empty.txt", bytes.NewReader(nil))
+	require.NoError(t, err)
+
+	cred := &Credential{
+		IssuerDID:    issuerDID,
+		SubjectDID:   subjectDID,
+		IssuanceDate: "2020-01-01T00:00:00Z",
+		Claims:       map[string]interface{}{"employer": "Workday"},
+		Attachments:  []Attachment{*payslipAttachment, *photoAttachment, *emptyAttachment},
+	}
+	require.NoError(t, Issue(cred, signer, proof.JCSEdSignatureType))
+	require.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}))
+
+	assert.NoError(t, VerifyAttachment(cred.Attachments[0], bytes.NewReader(payslip)))
+	assert.NoError(t, VerifyAttachment(cred.Attachments[1], bytes.NewReader(photo)))
+	assert.NoError(t, VerifyAttachment(cred.Attachments[2], strings.NewReader("")))
+	err = VerifyAttachment(cred.Attachments[0], bytes.NewReader(photo))
+	assert.Equal(t, ErrAttachmentDigestMismatch, errors.Cause(err))
+	err = VerifyAttachment(cred.Attachments[2], strings.NewReader(" "))
+	assert.Equal(t, ErrAttachmentDigestMismatch, errors.Cause(err))
+
+	t.Run("Altered digest", func(t *testing.T) {
+		altered := *cred
+		altered.Attachments = append([]Attachment(nil), cred.Attachments...)
+		altered.Attachments[0].Digest = photoAttachment.Digest
+		assert.Error(t, VerifyCredential(ctx, &altered, did.KeyResolver{}))
+	})
+
+	t.Run("Digest algorithm", func(t *testing.T) {
+		digest := sha512.Sum512(payslip)
+		encoded, err := multibase.Encode(multibase.Base64URL, digest[:])
+		require.NoError(t, err)
+		attachment := Attachment{
+			ID:              "payslip",
+			MediaType:       "application/pdf",
+			URI:             "https://example.com/payslips/1.pdf",
+			DigestAlgorithm: SHA512DigestAlgorithm,
+			Digest:          encoded,
+		}
+		assert.NoError(t, attachment.validate())
+		assert.NoError(t, VerifyAttachment(attachment, bytes.NewReader(payslip)))
+
+		attachment.DigestAlgorithm = "md5"
+		assert.Error(t, VerifyAttachment(attachment, bytes.NewReader(payslip)))
+		attachment.DigestAlgorithm = SHA256DigestAlgorithm
+		assert.Error(t, VerifyAttachment(attachment, bytes.NewReader(payslip)))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		duplicate := *cred
+		duplicate.Attachments = []Attachment{*payslipAttachment, *payslipAttachment}
+		assert.Error(t, duplicate.Validate())
+		_, err := NewAttachment("payslip", "application/pdf", "payslips/1.pdf", bytes.NewReader(payslip))
+		assert.Error(t, err)
+		_, err = NewAttachment("", "application/pdf", "https://example.com/payslips/1.pdf", bytes.NewReader(payslip))
+		assert.Error(t, err)
+	})
+}
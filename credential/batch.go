@@ -0,0 +1,160 @@
+package credential
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// IssueBatchOptions configures IssueBatch.
+type IssueBatchOptions struct {
+	// Workers is the number of credentials signed concurrently. Defaults to the number of CPUs.
+	Workers int
+	// SignatureType is the signature suite of the proofs. Defaults to JCSEdSignatureType.
+	SignatureType proof.SignatureType
+	// Now returns the created timestamp of each proof, and the issuance date of each credential if
+	// the template does not have one. It is called once per credential. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// SubjectClaims are the subject and claims of one credential issued by IssueBatch.
+type SubjectClaims struct {
+	SubjectDID string
+	// Claims are added to the claims of the template, and replace the template's claims of the
+	// same name.
+	Claims map[string]interface{}
+}
+
+// BatchIssueError is returned by IssueBatch when some of the credentials could not be issued.
+type BatchIssueError struct {
+	// Errs are the errors of the batch in input order; the error is nil for issued credentials, and
+	// a *proof.BatchItemError otherwise.
+	Errs []error
+}
+
+func (e *BatchIssueError) Error() string {
+	failed := 0
+	for _, err := range e.Errs {
+		if err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("failed to issue %d of %d credentials", failed, len(e.Errs))
+}
+
+// IssueBatch issues a credential for each subject from the template, signing them across a pool of
+// workers. Each credential has the template's issuer, type, validity window, schema and status,
+// the subject's DID and claims, a new UUID URN as its ID, and its own proof nonce. The signature
+// suite is looked up once and shared by the workers, and each worker reuses one buffer for the
+// payloads that it signs (see proof.WithBuffer). The credentials are returned in input order.
+// A credential that cannot be issued does not abort the batch: it is left empty, and a
+// *BatchIssueError with the error of each subject is returned along with the other credentials.
+// The error of a subject is a *proof.BatchItemError with the ID that its credential would have had.
+// If the context is cancelled, the remaining credentials are not issued, and the context's error
+// is returned instead.
+func IssueBatch(ctx context.Context, template Credential, subjects []SubjectClaims, signer proof.Signer, opts IssueBatchOptions) ([]Credential, error) {
+	if template.ID != "" {
+		return nil, errors.Errorf("batch template cannot have an ID<%s>", template.ID)
+	}
+	if did.ExtractDIDFromKeyRef(signer.ID()) != template.IssuerDID {
+		return nil, ErrIssuerKeyMismatch
+	}
+	sigType := opts.SignatureType
+	if sigType == "" {
+		sigType = proof.JCSEdSignatureType
+	}
+	suite, err := newestSuite(sigType)
+	if err != nil {
+		return nil, err
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(subjects) {
+		workers = len(subjects)
+	}
+	template.Proof = nil
+	template.ClaimProofs = nil
+	template.Binding = ""
+
+	credentials := make([]Credential, len(subjects))
+	errs := make([]error, len(subjects))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			var buf bytes.Buffer
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				id := util.NewURNUUID()
+				cred, err := issueBatchItem(template, id, subjects[i], suite, signer, now(), &buf)
+				if err != nil {
+					errs[i] = &proof.BatchItemError{ID: id, Err: errors.Wrapf(err, "subject<%s>", subjects[i].SubjectDID)}
+					continue
+				}
+				credentials[i] = *cred
+			}
+		}()
+	}
+
+	for i := range subjects {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return credentials, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return credentials, &BatchIssueError{Errs: errs}
+		}
+	}
+	return credentials, nil
+}
+
+// issueBatchItem issues the subject's credential with the ID from the template, with created as
+// the proof's created timestamp. The payload is encoded in the worker's buffer.
+func issueBatchItem(template Credential, id string, subject SubjectClaims, suite proof.SignatureSuite, signer proof.Signer, created time.Time, buf *bytes.Buffer) (*Credential, error) {
+	cred := template
+	cred.ID = id
+	cred.SubjectDID = subject.SubjectDID
+	cred.Claims = make(map[string]interface{}, len(template.Claims)+len(subject.Claims))
+	for name, value := range template.Claims {
+		cred.Claims[name] = value
+	}
+	for name, value := range subject.Claims {
+		cred.Claims[name] = value
+	}
+	if cred.IssuanceDate == "" {
+		cred.IssuanceDate = util.FormatCanonicalTime(created)
+	}
+	if err := cred.Validate(); err != nil {
+		return nil, err
+	}
+	clock := func() time.Time { return created }
+	if err := proof.SignWithOptions(suite, &cred, signer, proof.WithClock(clock), proof.WithBuffer(buf)); err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
@@ -0,0 +1,119 @@
+package credential
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestIssueBatch(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, signer := didKeySigner(t, 1)
+	subjectDID, otherSigner := didKeySigner(t, 2)
+	template := Credential{
+		IssuerDID: issuerDID,
+		Type:      []string{"PayrollCredential"},
+		Claims:    map[string]interface{}{"employer": "Workday", "period": "2020-01"},
+	}
+	created := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	tick := 0
+	opts := IssueBatchOptions{Workers: 1, Now: func() time.Time {
+		tick++
+		return created.Add(time.Duration(tick) * time.Second)
+	}}
+
+	subjects := []SubjectClaims{
+		{SubjectDID: subjectDID, Claims: map[string]interface{}{"salary": "1000"}},
+		{SubjectDID: "not a DID"},
+		{SubjectDID: issuerDID, Claims: map[string]interface{}{"period": "2020-02"}},
+	}
+	credentials, err := IssueBatch(ctx, template, subjects, signer, opts)
+	require.Len(t, credentials, len(subjects))
+	batchErr, ok := err.(*BatchIssueError)
+	require.True(t, ok, err)
+	assert.NoError(t, batchErr.Errs[0])
+	itemErr, ok := batchErr.Errs[1].(*proof.BatchItemError)
+	require.True(t, ok, batchErr.Errs[1])
+	assert.NoError(t, util.ValidateURNUUID(itemErr.ID))
+	assert.NoError(t, batchErr.Errs[2])
+	assert.Empty(t, credentials[1].ID)
+
+	first, third := credentials[0], credentials[2]
+	for _, cred := range []Credential{first, third} {
+		assert.NoError(t, VerifyCredential(ctx, &cred, did.KeyResolver{}))
+	}
+	assert.Equal(t, subjectDID, first.SubjectDID)
+	assert.Equal(t, map[string]interface{}{"employer": "Workday", "period": "2020-01", "salary": "1000"}, first.Claims)
+	assert.Equal(t, "2020-02", third.Claims["period"])
+	assert.Equal(t, "2020-01-31T00:00:01Z", first.IssuanceDate)
+	assert.Equal(t, "2020-01-31T00:00:01Z", first.Proof.Created)
+	assert.Equal(t, "2020-01-31T00:00:03Z", third.Proof.Created)
+	assert.NotEqual(t, first.ID, third.ID)
+	assert.NotEqual(t, first.Proof.Nonce, third.Proof.Nonce)
+	assert.Nil(t, template.Proof)
+
+	t.Run("Unique IDs and nonces", func(t *testing.T) {
+		subjects := make([]SubjectClaims, 100)
+		for i := range subjects {
+			subjects[i] = SubjectClaims{SubjectDID: subjectDID}
+		}
+		credentials, err := IssueBatch(ctx, template, subjects, signer, IssueBatchOptions{})
+		require.NoError(t, err)
+		ids, nonces := make(map[string]bool), make(map[string]bool)
+		for _, cred := range credentials {
+			ids[cred.ID] = true
+			nonces[cred.Proof.Nonce] = true
+		}
+		assert.Len(t, ids, len(subjects))
+		assert.Len(t, nonces, len(subjects))
+	})
+
+	t.Run("Invalid template", func(t *testing.T) {
+		_, err := IssueBatch(ctx, template, subjects, otherSigner, IssueBatchOptions{})
+		assert.Equal(t, ErrIssuerKeyMismatch, err)
+		withID := template
+		withID.ID = "urn:uuid:1"
+		_, err = IssueBatch(ctx, withID, subjects, signer, IssueBatchOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		credentials, err := IssueBatch(ctx, template, subjects, signer, IssueBatchOptions{})
+		assert.Equal(t, context.Canceled, err)
+		for _, cred := range credentials {
+			assert.Empty(t, cred.ID)
+		}
+	})
+}
+
+func BenchmarkIssueBatch(b *testing.B) {
+	issuerDID, signer := didKeySigner(b, 1)
+	subjectDID, _ := didKeySigner(b, 2)
+	template := Credential{
+		IssuerDID:    issuerDID,
+		IssuanceDate: "2020-01-31T00:00:00Z",
+		Claims:       map[string]interface{}{"employer": "Workday", "period": "2020-01"},
+	}
+	subjects := make([]SubjectClaims, 10000)
+	for i := range subjects {
+		subjects[i] = SubjectClaims{SubjectDID: subjectDID, Claims: map[string]interface{}{"salary": i}}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		if _, err := IssueBatch(context.Background(), template, subjects, signer, IssueBatchOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(subjects)*b.N)/time.Since(start).Seconds(), "credentials/s")
+}
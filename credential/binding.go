@@ -0,0 +1,79 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ErrHolderBindingMismatch is returned by VerifyPresentation when a bound credential is presented
+// with another key than its confirmation key.
+var ErrHolderBindingMismatch = errcode.New(errcode.SignatureInvalid, "presentation is not signed by the confirmation key of a bound credential")
+
+// Confirmation binds a credential to a key of its subject, so that only the holder of the key can
+// present it (see IssueBound and VerifyPresentation).
+type Confirmation struct {
+	// KeyRef is the reference of the subject's key, e.g. the key of a did:key DID, or
+	// "did:work:abcd#key-1" for a key of the subject's DID Document.
+	KeyRef string `json:"kid"`
+}
+
+// validate checks that the confirmation key belongs to the subject.
+func (c Confirmation) validate(subjectDID string) error {
+	if !strings.Contains(c.KeyRef, "#") {
+		return errors.Errorf("confirmation key<%s> is not a key reference", c.KeyRef)
+	}
+	if did.ExtractDIDFromKeyRef(c.KeyRef) != subjectDID {
+		return errors.Errorf("confirmation key<%s> does not belong to subject<%s>", c.KeyRef, subjectDID)
+	}
+	return nil
+}
+
+// IssueBound issues the credential like Issue, bound to the subject's key that signed the
+// possession response. The response must answer a challenge of the issuer (see
+// did.NewPossessionChallenge), and is checked with did.VerifyPossession, which resolves the
+// issuer's and subject's DIDs with the resolver.
+func IssueBound(ctx context.Context, cred *Credential, signer proof.Signer, sigType proof.SignatureType, response *did.PossessionResponse, resolver did.Resolver, opts ...did.PossessionOption) error {
+	if err := did.VerifyPossession(ctx, response, resolver, cred.SubjectDID, cred.IssuerDID, opts...); err != nil {
+		return err
+	}
+	cred.Confirmation = &Confirmation{KeyRef: response.GetProof().GetVerificationMethod()}
+	return Issue(cred, signer, sigType)
+}
+
+// checkHolderBindings checks that every bound credential among the documents of the presentation
+// is presented with its confirmation key. A credential that has been transferred (see
+// TransferCredential) must instead be presented by the holder that its transfer chain ends at,
+// whether it is bound or not. Transfers are the TransferProof documents of the presentation, in
+// order. Other documents, and credentials without a confirmation or transfers, are not bound.
+func checkHolderBindings(ctx context.Context, p *Presentation, resolver did.Resolver) error {
+	transfers := make(map[string][]TransferProof)
+	for _, data := range p.Documents {
+		var transfer TransferProof
+		if err := json.Unmarshal(data, &transfer); err == nil && transfer.Type == TransferType {
+			transfers[transfer.CredentialID] = append(transfers[transfer.CredentialID], transfer)
+		}
+	}
+	for i, data := range p.Documents {
+		var cred Credential
+		if err := json.Unmarshal(data, &cred); err != nil || cred.IssuerDID == "" {
+			continue
+		}
+		if chain, ok := transfers[cred.ID]; ok {
+			if err := VerifyTransferChain(ctx, cred, chain, p.HolderDID, resolver); err != nil {
+				return errors.Wrapf(err, "document<%d>", i)
+			}
+			continue
+		}
+		if cred.Confirmation != nil && cred.Confirmation.KeyRef != p.Proof.GetVerificationMethod() {
+			return errors.Wrapf(ErrHolderBindingMismatch, "document<%d>", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestHolderBinding(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, issuerSigner := didKeySigner(t, 1)
+	holderDID, holderSigner := didKeySigner(t, 2)
+	_, otherSigner := didKeySigner(t, 3)
+	newCredential := func() *Credential {
+		return &Credential{
+			IssuerDID:    issuerDID,
+			SubjectDID:   holderDID,
+			IssuanceDate: "2020-01-01T00:00:00Z",
+			Claims:       map[string]interface{}{"employer": "Workday"},
+		}
+	}
+	respond := func(t *testing.T, holderSigner proof.Signer) *did.PossessionResponse {
+		challenge, err := did.NewPossessionChallenge(issuerDID, time.Minute, issuerSigner)
+		require.NoError(t, err)
+		response, err := did.RespondToChallenge(*challenge, holderSigner)
+		require.NoError(t, err)
+		return response
+	}
+	present := func(t *testing.T, cred *Credential, holderSigner proof.Signer) error {
+		data, err := json.Marshal(cred)
+		require.NoError(t, err)
+		request := NewPresentationRequest("verifier.example.com", time.Minute)
+		response, err := request.Respond([]json.RawMessage{data}, holderSigner)
+		require.NoError(t, err)
+		return request.Verify(ctx, response, did.KeyResolver{})
+	}
+
+	bound := newCredential()
+	require.NoError(t, IssueBound(ctx, bound, issuerSigner, proof.JCSEdSignatureType, respond(t, holderSigner), did.KeyResolver{}))
+	require.NotNil(t, bound.Confirmation)
+	assert.Equal(t, holderSigner.ID(), bound.Confirmation.KeyRef)
+	require.NoError(t, VerifyCredential(ctx, bound, did.KeyResolver{}))
+
+	t.Run("Possession of another DID", func(t *testing.T) {
+		err := IssueBound(ctx, newCredential(), issuerSigner, proof.JCSEdSignatureType, respond(t, otherSigner), did.KeyResolver{})
+		assert.Equal(t, did.ErrHolderMismatch, err)
+	})
+
+	t.Run("Presentation", func(t *testing.T) {
+		assert.NoError(t, present(t, bound, holderSigner))
+		err := present(t, bound, otherSigner)
+		assert.Equal(t, ErrHolderBindingMismatch, errors.Cause(err))
+	})
+
+	t.Run("Unbound", func(t *testing.T) {
+		unbound := newCredential()
+		require.NoError(t, Issue(unbound, issuerSigner, proof.JCSEdSignatureType))
+		assert.NoError(t, present(t, unbound, holderSigner))
+		assert.NoError(t, present(t, unbound, otherSigner))
+	})
+
+	t.Run("Tampered confirmation", func(t *testing.T) {
+		tampered := *bound
+		tampered.Confirmation = &Confirmation{KeyRef: otherSigner.ID()}
+		assert.Error(t, tampered.Validate())
+		tampered.SubjectDID = did.ExtractDIDFromKeyRef(otherSigner.ID())
+		assert.NoError(t, tampered.Validate())
+		assert.Error(t, VerifyCredential(ctx, &tampered, did.KeyResolver{}))
+	})
+
+	t.Run("W3C", func(t *testing.T) {
+		token, err := IssueJWT(*bound, issuerSigner)
+		require.NoError(t, err)
+		cred, err := VerifyJWT(ctx, token, did.KeyResolver{})
+		require.NoError(t, err)
+		assert.Equal(t, bound.Confirmation, cred.Confirmation)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"gopkg.in/go-playground/validator.v9"
 
 	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
 )
 
 // Builder is used to construct signed Verifiable Credential.
@@ -14,12 +15,23 @@ type Builder struct {
 	SubjectDID string `validate:"required"`
 	// Data is a map of claims that adhere to the schema referenced in the Metadata.
 	Data map[string]interface{}
-	// Metadata is information about the credential.
+	// Metadata is information about the credential. If the metadata has no ID, the credential is
+	// given a random "urn:uuid:" ID.
 	Metadata *Metadata `validate:"required"`
 	// Signer has the ability to generate a digital signature for a provided signature type.
 	Signer proof.Signer `validate:"required"`
 	// SignatureType specifies the suite used to generate the credential signature
 	SignatureType proof.SignatureType `validate:"required"`
+	// ProofVersion is the optional proof model version of the credential signature. Defaults to V2.
+	ProofVersion proof.ModelVersion
+}
+
+// WithAgreed returns a copy of the builder that signs with the signature suite and proof model
+// version agreed with the credential's recipient (see proof.Negotiate).
+func (b Builder) WithAgreed(agreed proof.Agreed) Builder {
+	b.SignatureType = agreed.SignatureType
+	b.ProofVersion = agreed.ProofVersion
+	return b
 }
 
 // Build returns a signed Verifiable Credential using the current state of the builder.
@@ -28,11 +40,20 @@ func (b Builder) Build() (*VerifiableCredential, error) {
 		return nil, err
 	}
 
-	suite, err := proof.SignatureSuites().GetSuiteForCredentials(b.SignatureType, proof.V2)
+	version := b.ProofVersion
+	if version == 0 {
+		version = proof.V2
+	}
+	suite, err := proof.SignatureSuites().GetSuiteForCredentials(b.SignatureType, version)
 	if err != nil {
 		return nil, err
 	}
 
+	metadata := *b.Metadata
+	if metadata.ID == "" {
+		metadata.ID = util.NewURNUUID()
+	}
+
 	// The "id" attribute is added if missing from the claim data.
 	var credSubjects = map[string]interface{}{SubjectIDAttribute: b.SubjectDID}
 	for k, v := range b.Data {
@@ -44,7 +65,7 @@ func (b Builder) Build() (*VerifiableCredential, error) {
 	for k, v := range credSubjects {
 		credential := &VerifiableCredential{
 			UnsignedVerifiableCredential: UnsignedVerifiableCredential{
-				Metadata:          *b.Metadata,
+				Metadata:          metadata,
 				CredentialSubject: map[string]interface{}{k: v},
 			},
 		}
@@ -56,7 +77,7 @@ func (b Builder) Build() (*VerifiableCredential, error) {
 
 	cred := &VerifiableCredential{
 		UnsignedVerifiableCredential: UnsignedVerifiableCredential{
-			Metadata:          *b.Metadata,
+			Metadata:          metadata,
 			CredentialSubject: credSubjects,
 			ClaimProofs:       claimProofs,
 		},
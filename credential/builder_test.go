@@ -14,6 +14,7 @@ import (
 
 	"github.com/workdaycredentials/ledger-common/did"
 	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
 )
 
 func TestCredentialBuilder_BuildCredential(t *testing.T) {
@@ -111,3 +112,55 @@ func TestCredentialBuilder_BuildCredential(t *testing.T) {
 		assert.Len(t, validationErrs, 4)
 	})
 }
+
+func TestCredentialBuilder_GeneratesID(t *testing.T) {
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	issuerDID := did.GenerateDID(privKey.Public().(ed25519.PublicKey))
+	signer, err := proof.NewEd25519Signer(privKey, did.GenerateKeyID(issuerDID, did.InitialKey))
+	require.NoError(t, err)
+
+	metadata := NewMetadataWithTimestamp("", issuerDID, "schemaID", time.Now())
+	builder := Builder{
+		SubjectDID:    uuid.New().String(),
+		Data:          map[string]interface{}{"pet": "fido"},
+		Metadata:      &metadata,
+		Signer:        signer,
+		SignatureType: proof.JCSEdSignatureType,
+	}
+
+	cred, err := builder.Build()
+	require.NoError(t, err)
+	assert.NoError(t, util.ValidateURNUUID(cred.ID))
+	assert.NoError(t, VerifyClaim(cred, "pet", privKey.Public().(ed25519.PublicKey)))
+	// The builder's metadata is not modified, so each credential gets its own ID.
+	assert.Empty(t, metadata.ID)
+	other, err := builder.Build()
+	require.NoError(t, err)
+	assert.NotEqual(t, cred.ID, other.ID)
+}
+
+func TestCredentialBuilder_WithAgreed(t *testing.T) {
+	_, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	issuerDID := did.GenerateDID(privKey.Public().(ed25519.PublicKey))
+	signer, err := proof.NewEd25519Signer(privKey, did.GenerateKeyID(issuerDID, did.InitialKey))
+	require.NoError(t, err)
+
+	metadata := NewMetadataWithTimestamp("", issuerDID, "schemaID", time.Now())
+	builder := Builder{
+		SubjectDID: uuid.New().String(),
+		Data:       map[string]interface{}{"pet": "fido"},
+		Metadata:   &metadata,
+		Signer:     signer,
+	}.WithAgreed(proof.Agreed{SignatureType: proof.Ed25519SignatureType, ProofVersion: proof.V1})
+
+	cred, err := builder.Build()
+	require.NoError(t, err)
+	for _, p := range cred.ClaimProofs {
+		assert.NotEmpty(t, p.Creator)
+		assert.Empty(t, p.VerificationMethod)
+	}
+	assert.NotEmpty(t, cred.Proof.Creator)
+	assert.NoError(t, VerifyClaim(cred, "pet", privKey.Public().(ed25519.PublicKey)))
+}
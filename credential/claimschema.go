@@ -0,0 +1,240 @@
+package credential
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ClaimType is the JSON Schema type of a claim.
+type ClaimType string
+
+const (
+	StringClaim  ClaimType = "string"
+	NumberClaim  ClaimType = "number"
+	BooleanClaim ClaimType = "boolean"
+)
+
+// ClaimFormat is the JSON Schema format of a string claim.
+type ClaimFormat string
+
+const (
+	// DateFormat is a full date, e.g. "2020-01-31".
+	DateFormat ClaimFormat = "date"
+	// DateTimeFormat is an RFC 3339 date and time, e.g. "2020-01-31T12:00:00Z".
+	DateTimeFormat ClaimFormat = "date-time"
+)
+
+// claimSchemaIDRx matches schema IDs: "<author DID>;id=<resource ID>;version=<version>".
+var claimSchemaIDRx = regexp.MustCompile(`^(did:[a-z0-9]+:[^;\s]+);id=([^;\s]+);version=([^;\s]+)$`)
+
+// ClaimSchema describes the claims that credentials of a type may carry. It is signed by its
+// author, and identified by an ID that embeds the author's DID and the schema version (see
+// ClaimSchemaID), so that it can be fetched with a SchemaResolver, and its proof checked against
+// the author's DID Document.
+type ClaimSchema struct {
+	ID           string          `json:"id"`
+	AuthorDID    string          `json:"author"`
+	Name         string          `json:"name"`
+	Version      string          `json:"version"`
+	Body         ClaimSchemaBody `json:"schema"`
+	*proof.Proof `json:"proof,omitempty"`
+}
+
+// ClaimSchemaBody is the subset of JSON Schema that describes the claims of a credential: an
+// object with typed properties.
+type ClaimSchemaBody struct {
+	Properties map[string]ClaimProperty `json:"properties"`
+	Required   []string                 `json:"required,omitempty"`
+	// AdditionalProperties allows claims that are not declared in Properties if nil or true. Set it
+	// to false for strict validation.
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+}
+
+// ClaimProperty describes a claim.
+type ClaimProperty struct {
+	Type ClaimType `json:"type"`
+	// Format is the optional format of a string claim, e.g. DateFormat.
+	Format      ClaimFormat `json:"format,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+func (s *ClaimSchema) GetProof() *proof.Proof {
+	return s.Proof
+}
+
+func (s *ClaimSchema) SetProof(p *proof.Proof) {
+	s.Proof = p
+}
+
+func (s *ClaimSchema) GetID() string {
+	return s.ID
+}
+
+// SchemaResolver fetches claim schemas by ID, e.g. from the ledger.
+type SchemaResolver interface {
+	ResolveSchema(ctx context.Context, id string) (*ClaimSchema, error)
+}
+
+// SchemaResolverFunc adapts an ordinary function into a SchemaResolver.
+type SchemaResolverFunc func(ctx context.Context, id string) (*ClaimSchema, error)
+
+func (f SchemaResolverFunc) ResolveSchema(ctx context.Context, id string) (*ClaimSchema, error) {
+	return f(ctx, id)
+}
+
+// ClaimSchemaID returns the ID of a version of a schema, in the format of ledger schema IDs:
+// "<author DID>;id=<resource ID>;version=<version>", e.g.
+// "did:work:abcd;id=17de181feb67447da4e78259d92d0240;version=1.0". All versions of a schema share
+// its resource ID; a new resource ID is generated if it is empty.
+func ClaimSchemaID(authorDID, resourceID, version string) string {
+	if resourceID == "" {
+		resourceID = util.NewUUID().String()
+	}
+	return fmt.Sprintf("%s;id=%s;version=%s", authorDID, resourceID, version)
+}
+
+// ParseClaimSchemaID returns the author DID, resource ID and version of the schema ID. The version
+// must be a semantic version (see ParseSchemaVersion).
+func ParseClaimSchemaID(id string) (authorDID, resourceID, version string, err error) {
+	matches := claimSchemaIDRx.FindStringSubmatch(id)
+	if matches == nil {
+		return "", "", "", errors.Errorf("invalid schema ID<%s>", id)
+	}
+	if _, err := ParseSchemaVersion(matches[3]); err != nil {
+		return "", "", "", errors.Wrapf(err, "invalid schema ID<%s>", id)
+	}
+	return matches[1], matches[2], matches[3], nil
+}
+
+// Validate checks that the ID embeds the author DID and version of the schema, and that the body
+// only uses the supported subset of JSON Schema.
+func (s *ClaimSchema) Validate() error {
+	authorDID, _, version, err := ParseClaimSchemaID(s.ID)
+	if err != nil {
+		return err
+	}
+	if authorDID != s.AuthorDID || version != s.Version {
+		return errors.Errorf("schema ID<%s> does not match the author<%s> and version<%s>", s.ID, s.AuthorDID, s.Version)
+	}
+	if err := did.ValidateDID(s.AuthorDID); err != nil {
+		return errors.Wrap(err, "invalid schema author")
+	}
+	for name, property := range s.Body.Properties {
+		switch {
+		case property.Type != StringClaim && property.Type != NumberClaim && property.Type != BooleanClaim:
+			return errors.Errorf("schema<%s> property<%s> has unsupported type<%s>", s.ID, name, property.Type)
+		case property.Format != "" && (property.Type != StringClaim || (property.Format != DateFormat && property.Format != DateTimeFormat)):
+			return errors.Errorf("schema<%s> property<%s> has unsupported format<%s>", s.ID, name, property.Format)
+		}
+	}
+	for _, name := range s.Body.Required {
+		if _, ok := s.Body.Properties[name]; !ok {
+			return errors.Errorf("schema<%s> requires undeclared property<%s>", s.ID, name)
+		}
+	}
+	return nil
+}
+
+// SignClaimSchema validates the schema and signs it with the author's key. The newest proof model
+// version of the signature type is used.
+func SignClaimSchema(schema *ClaimSchema, signer proof.Signer, sigType proof.SignatureType) error {
+	if err := schema.Validate(); err != nil {
+		return err
+	}
+	if did.ExtractDIDFromKeyRef(signer.ID()) != schema.AuthorDID {
+		return errcode.New(errcode.SignatureInvalid, "schema is not signed by a key of its author")
+	}
+	suite, err := newestSuite(sigType)
+	if err != nil {
+		return err
+	}
+	return suite.Sign(schema, signer)
+}
+
+// VerifyClaimSchema validates the schema, and checks that its proof verifies with a key of the
+// author's DID Document that is authorized to make proofs.
+func VerifyClaimSchema(ctx context.Context, schema *ClaimSchema, resolver did.Resolver) error {
+	if err := schema.Validate(); err != nil {
+		return err
+	}
+	if schema.Proof.IsEmpty() {
+		return errcode.Errorf(errcode.MalformedProof, "schema<%s> does not have a proof", schema.ID)
+	}
+	if did.ExtractDIDFromKeyRef(schema.Proof.GetVerificationMethod()) != schema.AuthorDID {
+		return errcode.New(errcode.SignatureInvalid, "schema is not signed by a key of its author")
+	}
+	return proof.VerifyWithResolver(ctx, schema, did.AsVerifierResolver(resolver))
+}
+
+// ResolveClaimSchema fetches the schema with the schema resolver, and verifies it (see
+// VerifyClaimSchema). The resolved schema must have the requested ID.
+func ResolveClaimSchema(ctx context.Context, id string, schemaResolver SchemaResolver, resolver did.Resolver) (*ClaimSchema, error) {
+	schema, err := schemaResolver.ResolveSchema(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schema.ID != id {
+		return nil, errors.Errorf("resolved schema<%s>, expected schema<%s>", schema.ID, id)
+	}
+	if err := VerifyClaimSchema(ctx, schema, resolver); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+type validateClaimsOptions struct {
+	policy CompatibilityPolicy
+}
+
+// ValidateClaimsOption configures ValidateClaims.
+type ValidateClaimsOption func(*validateClaimsOptions)
+
+// WithSchemaCompatibility makes ValidateClaims check that the credential's SchemaID is a version
+// of the schema that is compatible under the policy. By default, the credential's schema ID is not
+// checked.
+func WithSchemaCompatibility(policy CompatibilityPolicy) ValidateClaimsOption {
+	return func(o *validateClaimsOptions) {
+		o.policy = policy
+	}
+}
+
+// ValidateClaims checks the claims of the credential against the schema: every required claim
+// must be present, and every declared claim must have the declared type (see ClaimValue); claims
+// are not coerced, so numbers given as strings are rejected (see ClaimSchema.Coerce). Claims that
+// the schema does not declare are rejected if the schema does not allow additional properties.
+// The claims are checked in name order, and the first failure is returned.
+func ValidateClaims(cred Credential, schema ClaimSchema, opts ...ValidateClaimsOption) error {
+	var options validateClaimsOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.policy != "" {
+		compatible, err := schema.IsCompatibleWith(cred.SchemaID, options.policy)
+		if err != nil {
+			return err
+		}
+		if !compatible {
+			return errors.Errorf("credential schema<%s> is not compatible with schema<%s> under policy<%s>", cred.SchemaID, schema.ID, options.policy)
+		}
+	}
+
+	for _, name := range schema.Body.Required {
+		if _, ok := cred.Claims[name]; !ok {
+			return errors.Errorf("claim<%s> is required by schema<%s>", name, schema.ID)
+		}
+	}
+	for _, name := range sortedClaimNames(cred.Claims) {
+		if _, err := schema.claimValue(name, cred.Claims[name], false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestClaimSchema(t *testing.T) {
+	ctx := context.Background()
+	authorDID, signer := didKeySigner(t, 1)
+	id := ClaimSchemaID(authorDID, "", "1.0")
+	schema := &ClaimSchema{
+		ID:        id,
+		AuthorDID: authorDID,
+		Name:      "Employment",
+		Version:   "1.0",
+		Body: ClaimSchemaBody{
+			Properties: map[string]ClaimProperty{
+				"employer":  {Type: StringClaim},
+				"salary":    {Type: NumberClaim},
+				"manager":   {Type: BooleanClaim},
+				"startDate": {Type: StringClaim, Format: DateFormat},
+			},
+			Required: []string{"employer", "startDate"},
+		},
+	}
+
+	t.Run("ID", func(t *testing.T) {
+		author, resourceID, version, err := ParseClaimSchemaID(id)
+		require.NoError(t, err)
+		assert.Equal(t, authorDID, author)
+		assert.NotEmpty(t, resourceID)
+		assert.Equal(t, "1.0", version)
+		assert.Equal(t, author+";id="+resourceID+";version=2.0", ClaimSchemaID(author, resourceID, "2.0"))
+
+		for _, invalid := range []string{"", authorDID, authorDID + ";id=abc", authorDID + ";id=abc;version=1", "did:work:abc;id=a b;version=1.0"} {
+			_, _, _, err := ParseClaimSchemaID(invalid)
+			assert.Error(t, err, invalid)
+		}
+	})
+
+	t.Run("Sign and verify", func(t *testing.T) {
+		signed := *schema
+		require.NoError(t, SignClaimSchema(&signed, signer, proof.JCSEdSignatureType))
+		assert.NoError(t, VerifyClaimSchema(ctx, &signed, did.KeyResolver{}))
+
+		data, err := json.Marshal(signed)
+		require.NoError(t, err)
+		schemaResolver := SchemaResolverFunc(func(ctx context.Context, id string) (*ClaimSchema, error) {
+			var resolved ClaimSchema
+			return &resolved, json.Unmarshal(data, &resolved)
+		})
+		resolved, err := ResolveClaimSchema(ctx, id, schemaResolver, did.KeyResolver{})
+		require.NoError(t, err)
+		assert.Equal(t, signed.Body, resolved.Body)
+		_, err = ResolveClaimSchema(ctx, ClaimSchemaID(authorDID, "", "1.0"), schemaResolver, did.KeyResolver{})
+		assert.Error(t, err)
+
+		tampered := signed
+		tampered.Body.Required = nil
+		assert.Error(t, VerifyClaimSchema(ctx, &tampered, did.KeyResolver{}))
+
+		_, otherSigner := didKeySigner(t, 2)
+		assert.Error(t, SignClaimSchema(schema, otherSigner, proof.JCSEdSignatureType))
+	})
+
+	t.Run("Invalid schemas", func(t *testing.T) {
+		for name, modify := range map[string]func(s *ClaimSchema){
+			"version mismatch": func(s *ClaimSchema) { s.Version = "2.0" },
+			"author mismatch":  func(s *ClaimSchema) { s.AuthorDID = "did:work:other" },
+			"unsupported type": func(s *ClaimSchema) { s.Body.Properties = map[string]ClaimProperty{"a": {Type: "object"}} },
+			"unsupported format": func(s *ClaimSchema) {
+				s.Body.Properties = map[string]ClaimProperty{"a": {Type: NumberClaim, Format: DateFormat}}
+			},
+			"undeclared required": func(s *ClaimSchema) { s.Body.Required = []string{"undeclared"} },
+		} {
+			invalid := *schema
+			modify(&invalid)
+			assert.Error(t, invalid.Validate(), name)
+		}
+	})
+}
+
+func TestValidateClaims(t *testing.T) {
+	schema := ClaimSchema{
+		ID: "did:work:abc;id=employment;version=1.0",
+		Body: ClaimSchemaBody{
+			Properties: map[string]ClaimProperty{
+				"employer":  {Type: StringClaim},
+				"salary":    {Type: NumberClaim},
+				"manager":   {Type: BooleanClaim},
+				"startDate": {Type: StringClaim, Format: DateFormat},
+				"updated":   {Type: StringClaim, Format: DateTimeFormat},
+			},
+			Required: []string{"employer", "startDate"},
+		},
+	}
+	valid := func() Credential {
+		return Credential{Claims: map[string]interface{}{
+			"employer":  "Workday",
+			"salary":    json.Number("100000"),
+			"manager":   true,
+			"startDate": "2020-01-31",
+			"updated":   "2020-02-01T12:00:00Z",
+		}}
+	}
+	assert.NoError(t, ValidateClaims(valid(), schema))
+
+	t.Run("Missing required claim", func(t *testing.T) {
+		cred := valid()
+		delete(cred.Claims, "startDate")
+		assert.EqualError(t, ValidateClaims(cred, schema), "claim<startDate> is required by schema<did:work:abc;id=employment;version=1.0>")
+		delete(cred.Claims, "salary")
+		cred.Claims["startDate"] = "2020-01-31"
+		assert.NoError(t, ValidateClaims(cred, schema))
+	})
+
+	t.Run("Wrongly typed claim", func(t *testing.T) {
+		for name, value := range map[string]interface{}{
+			"employer":  42,
+			"salary":    "100000",
+			"manager":   "true",
+			"startDate": "31/01/2020",
+			"updated":   "2020-02-01",
+		} {
+			cred := valid()
+			cred.Claims[name] = value
+			assert.Error(t, ValidateClaims(cred, schema), name)
+		}
+		cred := valid()
+		cred.Claims["salary"] = "100000"
+		assert.EqualError(t, ValidateClaims(cred, schema), "claim<salary> is not a number")
+		cred = valid()
+		cred.Claims["startDate"] = "2020-01-31T00:00:00Z"
+		assert.EqualError(t, ValidateClaims(cred, schema), "claim<startDate> is not a date")
+		cred = valid()
+		cred.Claims["salary"] = 100000.5
+		assert.NoError(t, ValidateClaims(cred, schema))
+	})
+
+	t.Run("Number claim", func(t *testing.T) {
+		for _, value := range []interface{}{
+			json.Number("100000"), json.Number("-42"), 100000, int64(-42), uint64(7),
+			json.Number("100000.5"), json.Number("-0.25"), json.Number("1e-7"), 100000.5, float32(0.5),
+		} {
+			cred := valid()
+			cred.Claims["salary"] = value
+			assert.NoError(t, ValidateClaims(cred, schema), value)
+		}
+	})
+
+	t.Run("Extra claim", func(t *testing.T) {
+		cred := valid()
+		cred.Claims["nickname"] = "Al"
+		assert.NoError(t, ValidateClaims(cred, schema))
+
+		allowed := true
+		schema.Body.AdditionalProperties = &allowed
+		assert.NoError(t, ValidateClaims(cred, schema))
+
+		strict := false
+		schema.Body.AdditionalProperties = &strict
+		assert.EqualError(t, ValidateClaims(cred, schema), "claim<nickname> is not declared by schema<did:work:abc;id=employment;version=1.0>")
+		assert.NoError(t, ValidateClaims(valid(), schema))
+	})
+
+	t.Run("Schema compatibility", func(t *testing.T) {
+		cred := valid()
+		cred.SchemaID = "did:work:abc;id=employment;version=1.3"
+		assert.NoError(t, ValidateClaims(cred, schema))
+		assert.NoError(t, ValidateClaims(cred, schema, WithSchemaCompatibility(SameMajorVersion)))
+		assert.Error(t, ValidateClaims(cred, schema, WithSchemaCompatibility(ExactVersion)))
+		cred.SchemaID = "did:work:abc;id=employment;version=2.0"
+		assert.Error(t, ValidateClaims(cred, schema, WithSchemaCompatibility(SameMajorVersion)))
+		assert.NoError(t, ValidateClaims(cred, schema, WithSchemaCompatibility(AnyVersion)))
+		cred.SchemaID = "did:work:abc;id=employment;version=1.x"
+		assert.Error(t, ValidateClaims(cred, schema, WithSchemaCompatibility(AnyVersion)))
+	})
+}
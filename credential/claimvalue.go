@@ -0,0 +1,198 @@
+package credential
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// jsonNumberRx matches the JSON number grammar (RFC 8259 Section 6), which has no leading zeros,
+// so that values such as "0123" are not mistaken for numbers.
+var jsonNumberRx = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// dateLayout is the layout of DateFormat claims.
+const dateLayout = "2006-01-02"
+
+// ClaimValue is a claim value of the type and format that a ClaimProperty declares, or an untyped
+// JSON value for claims that the schema does not declare. It marshals to the canonical JSON form
+// of the value (see Value), so that equal values always marshal the same way.
+type ClaimValue struct {
+	// Type is the type of the value; it is empty for untyped values.
+	Type   ClaimType
+	Format ClaimFormat
+	// value is a string, a canonical json.Number, a bool, a time.Time for dates and date-times, or
+	// any JSON value if the value is untyped.
+	value interface{}
+}
+
+// Value returns the JSON value of the claim, for Credential.Claims: a string, a json.Number in its
+// JCS form (e.g. "100000" for "1e5" or "100000.0"), or a bool. Dates are "2006-01-02" strings, and
+// date-times are RFC 3339 strings in UTC, without trailing zeros in the fraction of a second.
+func (v ClaimValue) Value() interface{} {
+	if t, ok := v.value.(time.Time); ok {
+		if v.Format == DateFormat {
+			return t.Format(dateLayout)
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return v.value
+}
+
+// Time returns the time of a date or date-time value. The bool is false for other values.
+func (v ClaimValue) Time() (time.Time, bool) {
+	t, ok := v.value.(time.Time)
+	return t, ok
+}
+
+func (v ClaimValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Value())
+}
+
+// Coerce converts the claims to the types that the schema declares, e.g. for claims that an
+// upstream system sends as strings: numbers may be given as strings in JSON number syntax (values
+// with leading zeros, such as "0123", are rejected as ambiguous), booleans as "true" or "false",
+// and dates and date-times as strings or time.Time values. Claims that the schema does not declare
+// are kept untyped, unless the schema does not allow additional properties. Required claims are
+// not checked (see ValidateClaims). Returns an error for the first claim, in name order, that
+// cannot be coerced.
+func (s ClaimSchema) Coerce(claims map[string]interface{}) (map[string]ClaimValue, error) {
+	values := make(map[string]ClaimValue, len(claims))
+	for _, name := range sortedClaimNames(claims) {
+		value, err := s.claimValue(name, claims[name], true)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// ClaimValues returns the JSON values of the typed claims (see ClaimValue.Value), e.g. to issue a
+// credential from coerced claims.
+func ClaimValues(values map[string]ClaimValue) map[string]interface{} {
+	claims := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		claims[name] = value.Value()
+	}
+	return claims
+}
+
+// claimValue returns the typed value of the claim. Unless coerce is set, the value must already
+// have the declared JSON type.
+func (s ClaimSchema) claimValue(name string, value interface{}, coerce bool) (ClaimValue, error) {
+	property, ok := s.Body.Properties[name]
+	if !ok {
+		if additional := s.Body.AdditionalProperties; additional != nil && !*additional {
+			return ClaimValue{}, errors.Errorf("claim<%s> is not declared by schema<%s>", name, s.ID)
+		}
+		return ClaimValue{value: value}, nil
+	}
+	typed, ok := property.typed(value, coerce)
+	if !ok {
+		expected := string(property.Type)
+		if property.Format != "" {
+			expected = string(property.Format)
+		}
+		if coerce {
+			return ClaimValue{}, errors.Errorf("claim<%s> value<%v> cannot be coerced to a %s", name, value, expected)
+		}
+		return ClaimValue{}, errors.Errorf("claim<%s> is not a %s", name, expected)
+	}
+	return ClaimValue{Type: property.Type, Format: property.Format, value: typed}, nil
+}
+
+// typed returns the value as the property's type and format. Unless coerce is set, numbers and
+// booleans given as strings are rejected.
+func (p ClaimProperty) typed(value interface{}, coerce bool) (interface{}, bool) {
+	switch p.Type {
+	case StringClaim:
+		if t, ok := value.(time.Time); ok && p.Format != "" {
+			return t, true
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		switch p.Format {
+		case DateFormat:
+			t, err := time.Parse(dateLayout, s)
+			return t, err == nil
+		case DateTimeFormat:
+			t, err := util.ParseRFC3339Lenient(s)
+			return t, err == nil
+		}
+		return s, true
+	case NumberClaim:
+		if s, ok := value.(string); ok && coerce {
+			return canonicalNumber(s)
+		}
+		return numberValue(value)
+	case BooleanClaim:
+		if s, ok := value.(string); ok && coerce {
+			b, err := strconv.ParseBool(s)
+			return b, err == nil && (s == "true" || s == "false")
+		}
+		b, ok := value.(bool)
+		return b, ok
+	}
+	return nil, false
+}
+
+// numberValue returns the number in its canonical form.
+func numberValue(value interface{}) (interface{}, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		return canonicalNumber(string(n))
+	case float64:
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, false
+		}
+		return canonicalNumber(strconv.FormatFloat(n, 'g', -1, 64))
+	case float32:
+		return numberValue(float64(n))
+	case int:
+		return canonicalNumber(strconv.FormatInt(int64(n), 10))
+	case int32:
+		return canonicalNumber(strconv.FormatInt(int64(n), 10))
+	case int64:
+		return canonicalNumber(strconv.FormatInt(n, 10))
+	case uint:
+		return canonicalNumber(strconv.FormatUint(uint64(n), 10))
+	case uint32:
+		return canonicalNumber(strconv.FormatUint(uint64(n), 10))
+	case uint64:
+		return canonicalNumber(strconv.FormatUint(n, 10))
+	}
+	return nil, false
+}
+
+// canonicalNumber returns the JCS form of a number in JSON syntax (see util.CanonicalMarshalRaw).
+// JCS only accepts an object or an array as the top-level value, so the number is canonicalized
+// as the element of an array.
+func canonicalNumber(s string) (interface{}, bool) {
+	if !jsonNumberRx.MatchString(s) {
+		return nil, false
+	}
+	canonical, err := util.CanonicalMarshalRaw([]byte("[" + s + "]"))
+	if err != nil || len(canonical) < 2 {
+		return nil, false
+	}
+	return json.Number(canonical[1 : len(canonical)-1]), true
+}
+
+// sortedClaimNames returns the names of the claims in order.
+func sortedClaimNames(claims map[string]interface{}) []string {
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
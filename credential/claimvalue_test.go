@@ -0,0 +1,122 @@
+package credential
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestCoerceClaims(t *testing.T) {
+	schema := ClaimSchema{
+		ID: "did:work:abc;id=employment;version=1.0",
+		Body: ClaimSchemaBody{
+			Properties: map[string]ClaimProperty{
+				"employeeID": {Type: StringClaim},
+				"salary":     {Type: NumberClaim},
+				"manager":    {Type: BooleanClaim},
+				"startDate":  {Type: StringClaim, Format: DateFormat},
+				"updated":    {Type: StringClaim, Format: DateTimeFormat},
+			},
+		},
+	}
+	coerce := func(t *testing.T, name string, value interface{}) ClaimValue {
+		values, err := schema.Coerce(map[string]interface{}{name: value})
+		require.NoError(t, err, value)
+		return values[name]
+	}
+
+	t.Run("String", func(t *testing.T) {
+		value := coerce(t, "employeeID", "0123")
+		assert.Equal(t, StringClaim, value.Type)
+		assert.Equal(t, "0123", value.Value())
+		_, err := schema.Coerce(map[string]interface{}{"employeeID": 123})
+		assert.Error(t, err)
+	})
+
+	t.Run("Number", func(t *testing.T) {
+		for _, value := range []interface{}{"100000", "100000.0", "1e5", json.Number("100000.00"), 100000, 100000.0, int64(100000)} {
+			assert.Equal(t, json.Number("100000"), coerce(t, "salary", value).Value(), value)
+		}
+		assert.Equal(t, json.Number("-0.5"), coerce(t, "salary", "-0.5").Value())
+		assert.Equal(t, json.Number("42"), coerce(t, "salary", 42).Value())
+		assert.Equal(t, json.Number("1.25"), coerce(t, "salary", json.Number("1.250")).Value())
+		assert.Equal(t, json.Number("1e-7"), coerce(t, "salary", 0.0000001).Value())
+		for _, value := range []interface{}{"0123", "", " 1", "1,000", "0x10", "NaN", "1.", true} {
+			_, err := schema.Coerce(map[string]interface{}{"salary": value})
+			assert.Error(t, err, value)
+		}
+	})
+
+	t.Run("Boolean", func(t *testing.T) {
+		assert.Equal(t, true, coerce(t, "manager", "true").Value())
+		assert.Equal(t, false, coerce(t, "manager", false).Value())
+		for _, value := range []interface{}{"True", "1", "yes", 1} {
+			_, err := schema.Coerce(map[string]interface{}{"manager": value})
+			assert.Error(t, err, value)
+		}
+	})
+
+	t.Run("Date", func(t *testing.T) {
+		value := coerce(t, "startDate", "2020-01-31")
+		assert.Equal(t, "2020-01-31", value.Value())
+		date, ok := value.Time()
+		assert.True(t, ok)
+		assert.Equal(t, time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), date)
+		assert.Equal(t, "2020-01-31", coerce(t, "startDate", date).Value())
+		for _, value := range []interface{}{"31/01/2020", "2020-01-31T00:00:00Z", "2020-02-30", 20200131} {
+			_, err := schema.Coerce(map[string]interface{}{"startDate": value})
+			assert.Error(t, err, value)
+		}
+	})
+
+	t.Run("Date-time", func(t *testing.T) {
+		assert.Equal(t, "2020-02-01T11:00:00Z", coerce(t, "updated", "2020-02-01T12:00:00+01:00").Value())
+		assert.Equal(t, "2020-02-01T12:00:00.5Z", coerce(t, "updated", "2020-02-01T12:00:00.500Z").Value())
+		_, err := schema.Coerce(map[string]interface{}{"updated": "2020-02-01"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Undeclared", func(t *testing.T) {
+		value := coerce(t, "address", map[string]interface{}{"city": "Pleasanton"})
+		assert.Empty(t, value.Type)
+		strict := schema
+		additional := false
+		strict.Body.AdditionalProperties = &additional
+		_, err := strict.Coerce(map[string]interface{}{"address": "Pleasanton"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Deterministic issuance", func(t *testing.T) {
+		issuerDID, signer := didKeySigner(t, 1)
+		subjectDID, _ := didKeySigner(t, 2)
+		issue := func(claims map[string]interface{}) []byte {
+			values, err := schema.Coerce(claims)
+			require.NoError(t, err)
+			cred := Credential{
+				IssuerDID:    issuerDID,
+				SubjectDID:   subjectDID,
+				IssuanceDate: "2020-01-01T00:00:00Z",
+				Claims:       ClaimValues(values),
+			}
+			require.NoError(t, ValidateClaims(cred, schema))
+			require.NoError(t, Issue(&cred, signer, proof.JCSEdSignatureType))
+			cred.ID, cred.Proof = "", nil
+			canonical, err := util.CanonicalMarshal(cred)
+			require.NoError(t, err)
+			return canonical
+		}
+		a := issue(map[string]interface{}{"salary": "1e5", "manager": "true", "startDate": "2020-01-31", "updated": "2020-02-01T12:00:00+01:00"})
+		b := issue(map[string]interface{}{"salary": 100000, "manager": true, "startDate": "2020-01-31", "updated": "2020-02-01T11:00:00.000Z"})
+		assert.Equal(t, string(a), string(b))
+
+		data, err := json.Marshal(map[string]ClaimValue{"salary": coerce(t, "salary", "1e5")})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"salary": 100000}`, string(data))
+	})
+}
@@ -0,0 +1,177 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ErrUnsatisfiedConstraint is returned by Match and ValidateSubmission when no credential
+// satisfies an input constraint of the request.
+var ErrUnsatisfiedConstraint = errors.New("no credential satisfies the input constraint")
+
+// InputConstraint describes a credential that a PresentationRequest asks for. A credential
+// satisfies the constraint if it has a version of one of the schemas that the policy accepts, is
+// issued by one of the issuers, and discloses every required claim. Empty schemas or issuers accept
+// any.
+type InputConstraint struct {
+	// ID identifies the constraint in the request.
+	ID string `json:"id"`
+	// SchemaIDs are the accepted schemas of the credential (see Credential.SchemaID).
+	SchemaIDs []string `json:"schemas,omitempty"`
+	// SchemaPolicy decides which versions of the accepted schemas are accepted. The default is
+	// ExactVersion.
+	SchemaPolicy CompatibilityPolicy `json:"schemaPolicy,omitempty"`
+	// IssuerDIDs are the accepted issuers of the credential.
+	IssuerDIDs []string `json:"issuers,omitempty"`
+	// RequiredClaims are the names of the claims that the credential must disclose.
+	RequiredClaims []string `json:"claims,omitempty"`
+}
+
+// CandidateSubmission is a credential that satisfies an input constraint of a request.
+type CandidateSubmission struct {
+	// InputID is the ID of the satisfied constraint.
+	InputID    string
+	Credential Credential
+}
+
+// SignPresentationRequest validates the input constraints of the request, and signs it with the
+// verifier's key. The verifier is the DID of the signer's key, and the preferred signature suite
+// for the key is used (see proof.Capabilities).
+func SignPresentationRequest(request *PresentationRequest, verifierSigner proof.Signer) error {
+	if request.Challenge == "" {
+		return errors.New("presentation challenge is required")
+	}
+	if err := validateInputs(request.Inputs); err != nil {
+		return err
+	}
+	capabilities := proof.Capabilities()
+	agreed, err := proof.Negotiate(capabilities.ForKeyType(verifierSigner.Type()), capabilities)
+	if err != nil {
+		return err
+	}
+	request.VerifierDID = did.ExtractDIDFromKeyRef(verifierSigner.ID())
+	return agreed.Sign(request, verifierSigner)
+}
+
+// VerifyPresentationRequest validates the input constraints of the request, and checks that it is
+// signed with a key of the verifier's DID Document that is authorized to make proofs. The holder
+// should verify a request before answering it.
+func VerifyPresentationRequest(ctx context.Context, request *PresentationRequest, resolver did.Resolver) error {
+	if err := validateInputs(request.Inputs); err != nil {
+		return err
+	}
+	if request.Proof.IsEmpty() {
+		return errcode.New(errcode.MalformedProof, "presentation request does not have a proof")
+	}
+	if did.ExtractDIDFromKeyRef(request.Proof.GetVerificationMethod()) != request.VerifierDID {
+		return errcode.New(errcode.SignatureInvalid, "presentation request is not signed by a key of its verifier")
+	}
+	return proof.VerifyWithResolver(ctx, request, did.AsVerifierResolver(resolver))
+}
+
+// validateInputs checks that every constraint has a unique ID, and well-formed schema IDs and
+// issuer DIDs.
+func validateInputs(inputs []InputConstraint) error {
+	ids := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		if input.ID == "" {
+			return errors.New("input constraint ID is required")
+		}
+		if ids[input.ID] {
+			return errors.Errorf("duplicate input constraint<%s>", input.ID)
+		}
+		ids[input.ID] = true
+		if input.SchemaPolicy != "" {
+			if err := input.SchemaPolicy.validate(); err != nil {
+				return errors.Wrapf(err, "input constraint<%s>", input.ID)
+			}
+		}
+		for _, id := range input.SchemaIDs {
+			if _, _, _, err := ParseClaimSchemaID(id); err != nil {
+				return errors.Wrapf(err, "input constraint<%s>", input.ID)
+			}
+		}
+		for _, issuer := range input.IssuerDIDs {
+			if err := did.ValidateDID(issuer); err != nil {
+				return errors.Wrapf(err, "input constraint<%s> issuer", input.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// Match returns the credentials that satisfy each input constraint of the request, e.g. from a
+// holder's wallet, in the order of the constraints, and then of the credentials. A credential may
+// satisfy several constraints, and a constraint may be satisfied by several credentials, from
+// which the holder chooses. Returns ErrUnsatisfiedConstraint if no credential satisfies one of
+// the constraints. The credentials' proofs are not checked.
+func Match(request PresentationRequest, credentials []Credential) ([]CandidateSubmission, error) {
+	var candidates []CandidateSubmission
+	for _, input := range request.Inputs {
+		matched := false
+		for _, cred := range credentials {
+			if input.satisfiedBy(cred) {
+				candidates = append(candidates, CandidateSubmission{InputID: input.ID, Credential: cred})
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, errors.Wrapf(ErrUnsatisfiedConstraint, "constraint<%s>", input.ID)
+		}
+	}
+	return candidates, nil
+}
+
+// ValidateSubmission checks that the credentials in the presentation satisfy every input
+// constraint of the request. It only evaluates the constraints, and is run by the verifier after
+// the proofs are checked with VerifyPresentation. Documents that are not credentials are ignored.
+// Returns ErrUnsatisfiedConstraint if no credential satisfies one of the constraints.
+func ValidateSubmission(request PresentationRequest, presentation *Presentation) error {
+	credentials := make([]Credential, 0, len(presentation.Documents))
+	for _, data := range presentation.Documents {
+		var cred Credential
+		if err := json.Unmarshal(data, &cred); err != nil || cred.IssuerDID == "" {
+			continue
+		}
+		credentials = append(credentials, cred)
+	}
+	_, err := Match(request, credentials)
+	return err
+}
+
+// satisfiedBy returns true if the credential satisfies the constraint.
+func (c InputConstraint) satisfiedBy(cred Credential) bool {
+	if len(c.SchemaIDs) > 0 && !c.acceptsSchema(cred.SchemaID) {
+		return false
+	}
+	if len(c.IssuerDIDs) > 0 && !containsString(c.IssuerDIDs, cred.IssuerDID) {
+		return false
+	}
+	for _, name := range c.RequiredClaims {
+		if _, ok := cred.Claims[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// acceptsSchema returns true if the schema is compatible with one of the accepted schemas under
+// the constraint's policy. Malformed schema IDs are not accepted.
+func (c InputConstraint) acceptsSchema(schemaID string) bool {
+	policy := c.SchemaPolicy
+	if policy == "" {
+		policy = ExactVersion
+	}
+	for _, id := range c.SchemaIDs {
+		if compatible, err := schemaIDsCompatible(id, schemaID, policy); err == nil && compatible {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestInputConstraints(t *testing.T) {
+	ctx := context.Background()
+	issuerA, signerA := didKeySigner(t, 1)
+	issuerB, signerB := didKeySigner(t, 2)
+	_, unacceptedSigner := didKeySigner(t, 3)
+	holderDID, holderSigner := didKeySigner(t, 4)
+	_, verifierSigner := didKeySigner(t, 5)
+	schemaID := ClaimSchemaID(issuerA, "", "1.0")
+
+	issue := func(t *testing.T, signer proof.Signer, schemaID string, claims map[string]interface{}) Credential {
+		cred := Credential{
+			IssuerDID:    did.ExtractDIDFromKeyRef(signer.ID()),
+			SubjectDID:   holderDID,
+			IssuanceDate: "2020-01-01T00:00:00Z",
+			SchemaID:     schemaID,
+			Claims:       claims,
+		}
+		require.NoError(t, Issue(&cred, signer, proof.JCSEdSignatureType))
+		return cred
+	}
+	employment := map[string]interface{}{"employer": "Workday", "title": "Engineer"}
+	credA := issue(t, signerA, schemaID, employment)
+	credB := issue(t, signerB, schemaID, employment)
+	credUnaccepted := issue(t, unacceptedSigner, schemaID, employment)
+	credOtherSchema := issue(t, signerA, ClaimSchemaID(issuerA, "", "2.0"), employment)
+	credMissingClaim := issue(t, signerB, schemaID, map[string]interface{}{"employer": "Workday"})
+
+	request := NewPresentationRequest("verifier.example.com", time.Minute)
+	request.Inputs = []InputConstraint{{
+		ID:             "employment",
+		SchemaIDs:      []string{schemaID},
+		IssuerDIDs:     []string{issuerA, issuerB},
+		RequiredClaims: []string{"employer", "title"},
+	}}
+
+	t.Run("Signed request", func(t *testing.T) {
+		signed := request
+		require.NoError(t, SignPresentationRequest(&signed, verifierSigner))
+		data, err := json.Marshal(signed)
+		require.NoError(t, err)
+		var decoded PresentationRequest
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.NoError(t, VerifyPresentationRequest(ctx, &decoded, did.KeyResolver{}))
+
+		decoded.Inputs = []InputConstraint{{ID: "employment"}}
+		assert.Error(t, VerifyPresentationRequest(ctx, &decoded, did.KeyResolver{}))
+		unsigned := request
+		assert.Error(t, VerifyPresentationRequest(ctx, &unsigned, did.KeyResolver{}))
+
+		for _, inputs := range [][]InputConstraint{
+			{{ID: ""}},
+			{{ID: "a"}, {ID: "a"}},
+			{{ID: "a", SchemaIDs: []string{"schema-1"}}},
+			{{ID: "a", IssuerDIDs: []string{"issuer"}}},
+		} {
+			invalid := request
+			invalid.Inputs = inputs
+			assert.Error(t, SignPresentationRequest(&invalid, verifierSigner))
+		}
+	})
+
+	t.Run("Multiple matches", func(t *testing.T) {
+		candidates, err := Match(request, []Credential{credA, credUnaccepted, credOtherSchema, credB, credMissingClaim})
+		require.NoError(t, err)
+		require.Len(t, candidates, 2)
+		assert.Equal(t, "employment", candidates[0].InputID)
+		assert.Equal(t, credA.ID, candidates[0].Credential.ID)
+		assert.Equal(t, credB.ID, candidates[1].Credential.ID)
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		_, err := Match(request, []Credential{credOtherSchema, credMissingClaim})
+		assert.Equal(t, ErrUnsatisfiedConstraint, errors.Cause(err))
+		_, err = Match(request, nil)
+		assert.Equal(t, ErrUnsatisfiedConstraint, errors.Cause(err))
+	})
+
+	t.Run("Unaccepted issuer", func(t *testing.T) {
+		_, err := Match(request, []Credential{credUnaccepted})
+		assert.Equal(t, ErrUnsatisfiedConstraint, errors.Cause(err))
+	})
+
+	t.Run("Schema policy", func(t *testing.T) {
+		_, resourceID, _, err := ParseClaimSchemaID(schemaID)
+		require.NoError(t, err)
+		credMinor := issue(t, signerA, ClaimSchemaID(issuerA, resourceID, "1.3"), employment)
+		_, err = Match(request, []Credential{credMinor})
+		assert.Equal(t, ErrUnsatisfiedConstraint, errors.Cause(err))
+
+		sameMajor := request
+		sameMajor.Inputs = []InputConstraint{request.Inputs[0]}
+		sameMajor.Inputs[0].SchemaPolicy = SameMajorVersion
+		candidates, err := Match(sameMajor, []Credential{credMinor, credOtherSchema})
+		require.NoError(t, err)
+		require.Len(t, candidates, 1)
+		assert.Equal(t, credMinor.ID, candidates[0].Credential.ID)
+
+		invalid := sameMajor
+		invalid.Inputs = []InputConstraint{sameMajor.Inputs[0]}
+		invalid.Inputs[0].SchemaPolicy = "major"
+		assert.Error(t, SignPresentationRequest(&invalid, verifierSigner))
+	})
+
+	t.Run("Submission", func(t *testing.T) {
+		submit := func(t *testing.T, creds ...Credential) *PresentationResponse {
+			docs := make([]json.RawMessage, len(creds))
+			for i, cred := range creds {
+				data, err := json.Marshal(cred)
+				require.NoError(t, err)
+				docs[i] = data
+			}
+			response, err := request.Respond(docs, holderSigner)
+			require.NoError(t, err)
+			require.NoError(t, request.Verify(ctx, response, did.KeyResolver{}))
+			return response
+		}
+
+		response := submit(t, credB)
+		assert.NoError(t, ValidateSubmission(request, response.Presentation))
+
+		response = submit(t, credUnaccepted, credMissingClaim)
+		assert.Equal(t, ErrUnsatisfiedConstraint, errors.Cause(ValidateSubmission(request, response.Presentation)))
+	})
+}
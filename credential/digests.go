@@ -0,0 +1,143 @@
+package credential
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+	"github.com/workdaycredentials/ledger-common/util/multibase"
+)
+
+// HMACSHA256DigestAlgorithm is the algorithm of ClaimDigests.
+const HMACSHA256DigestAlgorithm = "HMAC-SHA256"
+
+// MinClaimSaltSize is the minimum size of the salt of ClaimDigests.
+const MinClaimSaltSize = 16
+
+// ClaimDigests are salted digests of the claims of a credential, so that the claim values can be
+// checked later (see VerifyClaimValue) without being stored. The salt is kept apart from the
+// digests, e.g. with the holder, since the digests of guessable values can be brute forced with
+// the salt. The issuer can attest to the digests with SignClaimDigests.
+type ClaimDigests struct {
+	CredentialID string `json:"credentialId"`
+	IssuerDID    string `json:"issuer"`
+	Algorithm    string `json:"algorithm"`
+	// Digests are the multibase encoded digests of the claims, by claim name.
+	Digests      map[string]string `json:"digests"`
+	*proof.Proof `json:"proof,omitempty"`
+}
+
+func (d *ClaimDigests) GetProof() *proof.Proof {
+	return d.Proof
+}
+
+func (d *ClaimDigests) SetProof(p *proof.Proof) {
+	d.Proof = p
+}
+
+// GetID returns the ID of the credential whose claims are digested.
+func (d *ClaimDigests) GetID() string {
+	return d.CredentialID
+}
+
+// NewClaimSalt returns a random salt of MinClaimSaltSize bytes for DigestClaims.
+func NewClaimSalt() ([]byte, error) {
+	salt := make([]byte, MinClaimSaltSize)
+	if _, err := io.ReadFull(util.RandReader(), salt); err != nil {
+		return nil, errors.Wrap(err, "failed to generate claim salt")
+	}
+	return salt, nil
+}
+
+// DigestClaims returns the HMAC-SHA256 digest of each claim of the credential, keyed by the salt,
+// which should be unique to the credential (see NewClaimSalt). Each digest covers the claim's name
+// and its canonical JSON value (see util.CanonicalMarshal), so that equal values, e.g. 1 and 1.0,
+// or objects with different key orders, have the same digest.
+func DigestClaims(cred Credential, salt []byte) (ClaimDigests, error) {
+	if len(salt) < MinClaimSaltSize {
+		return ClaimDigests{}, errors.Errorf("claim salt must have at least %d bytes", MinClaimSaltSize)
+	}
+	digests := ClaimDigests{
+		CredentialID: cred.ID,
+		IssuerDID:    cred.IssuerDID,
+		Algorithm:    HMACSHA256DigestAlgorithm,
+		Digests:      make(map[string]string, len(cred.Claims)),
+	}
+	for name, value := range cred.Claims {
+		digest, err := claimDigest(name, value, salt)
+		if err != nil {
+			return ClaimDigests{}, err
+		}
+		encoded, err := multibase.Encode(multibase.Base58BTC, digest)
+		if err != nil {
+			return ClaimDigests{}, err
+		}
+		digests.Digests[name] = encoded
+	}
+	return digests, nil
+}
+
+// VerifyClaimValue returns true if the value is the value of the claim that the digests were made
+// of with the salt. Returns an error if the digests do not have the claim.
+func VerifyClaimValue(digests ClaimDigests, claim string, value interface{}, salt []byte) (bool, error) {
+	if digests.Algorithm != HMACSHA256DigestAlgorithm {
+		return false, errors.Errorf("unsupported claim digest algorithm<%s>", digests.Algorithm)
+	}
+	encoded, ok := digests.Digests[claim]
+	if !ok {
+		return false, errors.Errorf("credential<%s> does not have a digest of claim<%s>", digests.CredentialID, claim)
+	}
+	_, expected, err := multibase.Decode(encoded)
+	if err != nil {
+		return false, errors.Wrapf(err, "claim<%s> digest", claim)
+	}
+	digest, err := claimDigest(claim, value, salt)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal(digest, expected), nil
+}
+
+// SignClaimDigests signs the digests with the issuer's key, so that the issuer attests to them.
+// The preferred signature suite for the key is used (see proof.Capabilities).
+func SignClaimDigests(digests *ClaimDigests, signer proof.Signer) error {
+	if did.ExtractDIDFromKeyRef(signer.ID()) != digests.IssuerDID {
+		return ErrIssuerKeyMismatch
+	}
+	capabilities := proof.Capabilities()
+	agreed, err := proof.Negotiate(capabilities.ForKeyType(signer.Type()), capabilities)
+	if err != nil {
+		return err
+	}
+	return agreed.Sign(digests, signer)
+}
+
+// VerifyClaimDigests checks that the digests are signed with a key of the issuer's DID Document
+// that is authorized to make proofs.
+func VerifyClaimDigests(ctx context.Context, digests *ClaimDigests, resolver did.Resolver) error {
+	if digests.Proof.IsEmpty() {
+		return errcode.Errorf(errcode.MalformedProof, "credential<%s> claim digests do not have a proof", digests.CredentialID)
+	}
+	if did.ExtractDIDFromKeyRef(digests.Proof.GetVerificationMethod()) != digests.IssuerDID {
+		return ErrIssuerKeyMismatch
+	}
+	return proof.VerifyWithResolver(ctx, digests, did.AsVerifierResolver(resolver))
+}
+
+// claimDigest returns the HMAC-SHA256 of the canonical JSON of the claim's name and value.
+func claimDigest(name string, value interface{}, salt []byte) ([]byte, error) {
+	canonical, err := util.CanonicalMarshal([]interface{}{name, value})
+	if err != nil {
+		return nil, errors.Wrapf(err, "claim<%s>", name)
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(canonical)
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,72 @@
+package credential
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestClaimDigests(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, signer := didKeySigner(t, 1)
+	subjectDID, otherSigner := didKeySigner(t, 2)
+	cred := Credential{
+		IssuerDID:    issuerDID,
+		SubjectDID:   subjectDID,
+		IssuanceDate: "2020-01-01T00:00:00Z",
+		Claims: map[string]interface{}{
+			"employer": "Workday",
+			"salary":   json.Number("100000.0"),
+			"address":  map[string]interface{}{"city": "Pleasanton", "zip": "94588"},
+		},
+	}
+	require.NoError(t, Issue(&cred, signer, proof.JCSEdSignatureType))
+	salt, err := NewClaimSalt()
+	require.NoError(t, err)
+	digests, err := DigestClaims(cred, salt)
+	require.NoError(t, err)
+	assert.Equal(t, cred.ID, digests.CredentialID)
+	assert.Len(t, digests.Digests, 3)
+
+	verify := func(claim string, value interface{}) bool {
+		valid, err := VerifyClaimValue(digests, claim, value, salt)
+		require.NoError(t, err)
+		return valid
+	}
+	assert.True(t, verify("employer", "Workday"))
+	assert.False(t, verify("employer", "workday"))
+	assert.True(t, verify("salary", 100000))
+	assert.True(t, verify("salary", json.Number("1e5")))
+	assert.False(t, verify("salary", "100000"))
+	assert.True(t, verify("address", json.RawMessage(`{"zip": "94588", "city": "Pleasanton"}`)))
+	// Digests cannot be moved between claims.
+	assert.False(t, verify("salary", "Workday"))
+
+	valid, err := VerifyClaimValue(digests, "employer", "Workday", bytes.Repeat([]byte{1}, MinClaimSaltSize))
+	require.NoError(t, err)
+	assert.False(t, valid)
+	_, err = VerifyClaimValue(digests, "title", "Engineer", salt)
+	assert.Error(t, err)
+	_, err = DigestClaims(cred, salt[:MinClaimSaltSize-1])
+	assert.Error(t, err)
+
+	t.Run("Attested", func(t *testing.T) {
+		assert.Equal(t, ErrIssuerKeyMismatch, SignClaimDigests(&digests, otherSigner))
+		require.NoError(t, SignClaimDigests(&digests, signer))
+		data, err := json.Marshal(digests)
+		require.NoError(t, err)
+		var decoded ClaimDigests
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.NoError(t, VerifyClaimDigests(ctx, &decoded, did.KeyResolver{}))
+
+		decoded.Digests["employer"] = decoded.Digests["salary"]
+		assert.Error(t, VerifyClaimDigests(ctx, &decoded, did.KeyResolver{}))
+	})
+}
@@ -0,0 +1,479 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+var (
+	// ErrNotYetValid is returned by VerifyCredential before the credential's issuance date.
+	ErrNotYetValid = errcode.New(errcode.Expired, "credential is not yet valid")
+	// ErrExpired is returned by VerifyCredential after the credential's expiration date.
+	ErrExpired = errcode.New(errcode.Expired, "credential has expired")
+	// ErrIssuerKeyMismatch is returned when a credential is not signed with a key of its issuer.
+	ErrIssuerKeyMismatch = errcode.New(errcode.SignatureInvalid, "credential is not signed by a key of its issuer")
+	// ErrInconsistentTimestamps is matched by InconsistentTimestampsError.
+	ErrInconsistentTimestamps = errcode.New(errcode.MalformedProof, "credential issuance date is inconsistent with its proof")
+)
+
+// InconsistentTimestampsError is returned by VerifyCredential when the created time of the
+// credential's proof is not within the window of its issuance date (see WithTimestampConsistency).
+// It matches ErrInconsistentTimestamps (see errors.Is).
+type InconsistentTimestampsError struct {
+	IssuanceDate string
+	ProofCreated string
+}
+
+func (e InconsistentTimestampsError) Error() string {
+	return fmt.Sprintf("credential issuance date<%s> is inconsistent with proof created<%s>", e.IssuanceDate, e.ProofCreated)
+}
+
+func (e InconsistentTimestampsError) Is(target error) bool {
+	return target == ErrInconsistentTimestamps
+}
+
+// ErrorCode returns errcode.MalformedProof (see errcode.CodeOf).
+func (e InconsistentTimestampsError) ErrorCode() errcode.Code {
+	return errcode.MalformedProof
+}
+
+// Credential is a signed set of claims about a subject, made by an issuer. It is a simpler
+// alternative to VerifiableCredential for documents that are exchanged between services, without
+// claim proofs or a schema.
+//
+// Dates are kept as RFC 3339 strings, and claims are decoded with util.UnmarshalUseNumber, so that
+// a credential marshals back to the same canonical JSON after a round trip, and its proof still
+// verifies.
+type Credential struct {
+	ID   string   `json:"id"`
+	Type []string `json:"type,omitempty"`
+	// IssuerDID is the DID of the issuer, whose key signs the credential.
+	IssuerDID string `json:"issuer"`
+	// SubjectDID is the DID of the subject of the claims, e.g. a did:key DID of a holder.
+	SubjectDID string `json:"subject"`
+	// IssuanceDate is the RFC 3339 time from which the credential is valid.
+	IssuanceDate string `json:"issuanceDate"`
+	// ExpirationDate is the optional RFC 3339 time from which the credential is no longer valid.
+	ExpirationDate string                 `json:"expirationDate,omitempty"`
+	Claims         map[string]interface{} `json:"claims,omitempty"`
+	// Binding is a random value that is signed with every claim, together with the ID, so that
+	// claims cannot be moved between credentials, even if an ID is reused. It is generated by
+	// IssueWithClaimProofs.
+	Binding string `json:"binding,omitempty"`
+	// ClaimProofs are the proofs of the individual claims, by claim name, for selective disclosure
+	// (see IssueWithClaimProofs and Redact).
+	ClaimProofs map[string]ClaimProof `json:"claimProofs,omitempty"`
+	// SchemaID is the optional ID of the ClaimSchema that the claims adhere to (see ClaimSchemaID).
+	SchemaID string `json:"schema,omitempty"`
+	// Status is the optional entry of the credential in its issuer's RevocationList.
+	Status *CredentialStatus `json:"credentialStatus,omitempty"`
+	// Confirmation optionally binds the credential to a key of its subject (see IssueBound).
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+	// Attachments reference external artifacts of the credential by digest (see VerifyAttachment).
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// W3CExtras are the W3C properties of a credential from FromW3C that it does not model, which
+	// ToW3C writes back as they are.
+	W3CExtras    map[string]json.RawMessage `json:"w3cExtras,omitempty"`
+	*proof.Proof `json:"proof,omitempty"`
+}
+
+// ClaimProof is the proof of a single claim of a Credential.
+type ClaimProof struct {
+	// Salt is a random value that is signed with the claim, so that a claim's value cannot be
+	// confirmed by guessing it and checking the signature.
+	Salt         string `json:"salt"`
+	*proof.Proof `json:"proof"`
+}
+
+// claimDocument is what the proof of a claim signs: the credential with only that claim and its
+// salt, without the other claim proofs.
+type claimDocument struct {
+	Credential
+	Salt string `json:"claimSalt"`
+}
+
+func (c *Credential) claimDocument(name, salt string) *claimDocument {
+	doc := &claimDocument{Credential: *c, Salt: salt}
+	doc.Claims = map[string]interface{}{name: c.Claims[name]}
+	doc.ClaimProofs = nil
+	doc.Proof = nil
+	return doc
+}
+
+func (c *Credential) GetProof() *proof.Proof {
+	return c.Proof
+}
+
+func (c *Credential) SetProof(p *proof.Proof) {
+	c.Proof = p
+}
+
+func (c *Credential) GetID() string {
+	return c.ID
+}
+
+// UnmarshalJSON decodes the credential, keeping claim numbers exactly as they are written.
+func (c *Credential) UnmarshalJSON(data []byte) error {
+	type credential Credential
+	return util.UnmarshalUseNumber(data, (*credential)(c))
+}
+
+// Validate checks that the issuer and subject are valid DIDs, and that the validity window is
+// well formed: the issuance date is required, and the expiration date must not be before it.
+func (c *Credential) Validate() error {
+	if err := did.ValidateDID(c.IssuerDID); err != nil {
+		return errors.Wrap(err, "invalid credential issuer")
+	}
+	if err := did.ValidateDID(c.SubjectDID); err != nil {
+		return errors.Wrap(err, "invalid credential subject")
+	}
+	if c.Confirmation != nil {
+		if err := c.Confirmation.validate(c.SubjectDID); err != nil {
+			return err
+		}
+	}
+	ids := make(map[string]bool, len(c.Attachments))
+	for _, attachment := range c.Attachments {
+		if err := attachment.validate(); err != nil {
+			return err
+		}
+		if ids[attachment.ID] {
+			return errors.Errorf("credential<%s> has duplicate attachment<%s>", c.ID, attachment.ID)
+		}
+		ids[attachment.ID] = true
+	}
+	_, _, err := c.validityWindow()
+	return err
+}
+
+// validityWindow returns the issuance and expiration dates. The expiration date is zero if the
+// credential does not expire.
+func (c *Credential) validityWindow() (issuance, expiration time.Time, err error) {
+	if c.IssuanceDate == "" {
+		return issuance, expiration, errors.Errorf("credential<%s> does not have an issuance date", c.ID)
+	}
+	if issuance, err = util.ParseRFC3339Lenient(c.IssuanceDate); err != nil {
+		return issuance, expiration, errors.Wrap(err, "invalid credential issuance date")
+	}
+	if c.ExpirationDate == "" {
+		return issuance, expiration, nil
+	}
+	if expiration, err = util.ParseRFC3339Lenient(c.ExpirationDate); err != nil {
+		return issuance, expiration, errors.Wrap(err, "invalid credential expiration date")
+	}
+	if expiration.Before(issuance) {
+		return issuance, expiration, errors.Errorf("credential<%s> expires before it is issued", c.ID)
+	}
+	return issuance, expiration, nil
+}
+
+// Issue validates the credential and signs it with the issuer's key. A UUID URN is generated if
+// the credential does not have an ID, and the issuance date is set to the proof's created time if
+// the credential does not have one (see WithIssueClock). The newest proof model version of the
+// signature type is used.
+func Issue(cred *Credential, signer proof.Signer, sigType proof.SignatureType, opts ...IssueOption) error {
+	options := newIssueOptions(opts)
+	suite, err := prepareIssue(cred, signer, sigType, options)
+	if err != nil {
+		return err
+	}
+	return options.sign(suite, cred, signer)
+}
+
+type issueOptions struct {
+	withoutDocumentProof bool
+	now                  func() time.Time
+	// created is the time of the proofs, from now.
+	created time.Time
+}
+
+// IssueOption configures Issue and IssueWithClaimProofs.
+type IssueOption func(*issueOptions)
+
+func newIssueOptions(opts []IssueOption) *issueOptions {
+	options := &issueOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(options)
+	}
+	options.created = options.now()
+	return options
+}
+
+// sign signs the provable with the suite, with the proof created at the issuance time.
+func (o *issueOptions) sign(suite proof.SignatureSuite, provable proof.Provable, signer proof.Signer) error {
+	clock := func() time.Time { return o.created }
+	return proof.SignWithOptions(suite, provable, signer, proof.WithClock(clock))
+}
+
+// WithIssueClock sets the clock that the proofs' created time, and the issuance date of a
+// credential that does not have one, are taken from. The clock is read once, so that both are the
+// same. The default is time.Now.
+func WithIssueClock(now func() time.Time) IssueOption {
+	return func(o *issueOptions) {
+		o.now = now
+	}
+}
+
+// WithoutDocumentProof only signs the individual claims, for credentials that are only ever
+// disclosed with Redact.
+func WithoutDocumentProof() IssueOption {
+	return func(o *issueOptions) {
+		o.withoutDocumentProof = true
+	}
+}
+
+// IssueWithClaimProofs issues the credential like Issue, and also signs each claim individually,
+// so that the holder can disclose some of the claims only (see Redact and VerifyDisclosed). Each
+// claim proof signs the claim's name, value and a random salt, along with the rest of the
+// credential: the ID, a random Binding value, the issuer, the subject and the validity window.
+func IssueWithClaimProofs(cred *Credential, signer proof.Signer, sigType proof.SignatureType, opts ...IssueOption) error {
+	options := newIssueOptions(opts)
+	suite, err := prepareIssue(cred, signer, sigType, options)
+	if err != nil {
+		return err
+	}
+	if cred.Binding == "" {
+		cred.Binding = util.NewID("")
+	}
+
+	claimProofs := make(map[string]ClaimProof, len(cred.Claims))
+	for name := range cred.Claims {
+		doc := cred.claimDocument(name, util.NewID(""))
+		if err := options.sign(suite, doc, signer); err != nil {
+			return err
+		}
+		claimProofs[name] = ClaimProof{Salt: doc.Salt, Proof: doc.Proof}
+	}
+	cred.ClaimProofs = claimProofs
+
+	if options.withoutDocumentProof {
+		return nil
+	}
+	return options.sign(suite, cred, signer)
+}
+
+// prepareIssue sets the issuance date if needed, validates the credential, generates its ID if
+// needed, and returns the suite that signs it.
+func prepareIssue(cred *Credential, signer proof.Signer, sigType proof.SignatureType, options *issueOptions) (proof.SignatureSuite, error) {
+	if cred.IssuanceDate == "" {
+		cred.IssuanceDate = util.FormatCanonicalTime(options.created)
+	}
+	if err := cred.Validate(); err != nil {
+		return nil, err
+	}
+	if did.ExtractDIDFromKeyRef(signer.ID()) != cred.IssuerDID {
+		return nil, ErrIssuerKeyMismatch
+	}
+	suite, err := newestSuite(sigType)
+	if err != nil {
+		return nil, err
+	}
+	if cred.ID == "" {
+		cred.ID = util.NewURNUUID()
+	}
+	return suite, nil
+}
+
+// Redact returns a disclosure of the credential that only contains the named claims and their
+// proofs, for VerifyDisclosed. The disclosure does not have a document proof, since the redacted
+// document no longer matches it.
+func (c *Credential) Redact(claimNames ...string) (*Credential, error) {
+	disclosure := *c
+	disclosure.Claims = make(map[string]interface{}, len(claimNames))
+	disclosure.ClaimProofs = make(map[string]ClaimProof, len(claimNames))
+	disclosure.Proof = nil
+	for _, name := range claimNames {
+		value, ok := c.Claims[name]
+		if !ok {
+			return nil, errors.Errorf("credential<%s> does not have claim<%s>", c.ID, name)
+		}
+		claimProof, ok := c.ClaimProofs[name]
+		if !ok {
+			return nil, errors.Errorf("credential<%s> does not have a proof of claim<%s>", c.ID, name)
+		}
+		disclosure.Claims[name] = value
+		disclosure.ClaimProofs[name] = claimProof
+	}
+	return &disclosure, nil
+}
+
+type verifyOptions struct {
+	// proofOpts are passed to the proof package, and set the time policy (see
+	// proof.NewVerifyPolicy).
+	proofOpts     []proof.VerifyOption
+	now           func() time.Time
+	skew          time.Duration
+	statusChecker CredentialStatusChecker
+	// timestampWindow is negative if timestamps are not checked.
+	timestampWindow time.Duration
+}
+
+func newVerifyOptions(opts []VerifyOption) verifyOptions {
+	options := verifyOptions{timestampWindow: -1}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	policy := proof.NewVerifyPolicy(options.proofOpts...)
+	options.now, options.skew = policy.Now, policy.ClockSkew
+	return options
+}
+
+// VerifyOption configures VerifyCredential.
+type VerifyOption func(*verifyOptions)
+
+// WithProofOptions applies the verification options of the proof package, so that one policy
+// covers both the proofs and the credential. The clock and clock skew (see proof.WithVerifyClock
+// and proof.WithClockSkew) set the time that the validity window of the credential is checked
+// against.
+func WithProofOptions(opts ...proof.VerifyOption) VerifyOption {
+	return func(o *verifyOptions) {
+		o.proofOpts = append(o.proofOpts, opts...)
+	}
+}
+
+// WithClock sets the clock that the validity window of the credential is checked against. The
+// default is time.Now. It is the same as WithProofOptions(proof.WithVerifyClock(now)).
+func WithClock(now func() time.Time) VerifyOption {
+	return WithProofOptions(proof.WithVerifyClock(now))
+}
+
+// WithStatusChecker makes VerifyCredential check that the credential has not been revoked. By
+// default, revocation is not checked.
+func WithStatusChecker(checker CredentialStatusChecker) VerifyOption {
+	return func(o *verifyOptions) {
+		o.statusChecker = checker
+	}
+}
+
+// WithTimestampConsistency makes VerifyCredential check that the created time of the credential's
+// proof is within the window of its issuance date, before or after, so that a credential is not
+// backdated, or signed long after it was issued. Issue sets both from the same clock. By default,
+// the timestamps are not checked.
+func WithTimestampConsistency(window time.Duration) VerifyOption {
+	return func(o *verifyOptions) {
+		o.timestampWindow = window
+	}
+}
+
+// VerifyCredential checks that the credential is valid at the current time, and that its proof
+// verifies with a key of the issuer's DID Document that is authorized to make proofs. If a status
+// checker is set (see WithStatusChecker), ErrCredentialRevoked is returned for a revoked
+// credential, and if timestamps are checked (see WithTimestampConsistency), an
+// InconsistentTimestampsError is returned for a proof that is not created around the issuance
+// date. The resolver must be able to resolve the issuer's DID, e.g. did.KeyResolver for
+// did:key issuers.
+func VerifyCredential(ctx context.Context, cred *Credential, resolver did.Resolver, opts ...VerifyOption) error {
+	options := newVerifyOptions(opts)
+
+	if err := cred.Validate(); err != nil {
+		return err
+	}
+	if cred.Proof.IsEmpty() {
+		return errcode.Errorf(errcode.MalformedProof, "credential<%s> does not have a proof", cred.ID)
+	}
+	if err := cred.verifyIssuerProof(ctx, cred, resolver, options.proofOpts...); err != nil {
+		return err
+	}
+	if err := cred.checkValidAt(options.now(), options.skew); err != nil {
+		return err
+	}
+	if options.timestampWindow >= 0 {
+		if err := cred.checkTimestamps(options.timestampWindow); err != nil {
+			return err
+		}
+	}
+	if options.statusChecker != nil {
+		return cred.checkStatus(ctx, options.statusChecker, resolver)
+	}
+	return nil
+}
+
+// VerifyDisclosed verifies a disclosure made with Redact: it checks that the credential is valid
+// at the current time, and that the proof of every disclosed claim verifies with a key of the
+// issuer. The claim proofs bind each claim to the credential's ID and Binding, so claims that are
+// taken from another credential are rejected.
+func VerifyDisclosed(ctx context.Context, disclosure *Credential, resolver did.Resolver, opts ...VerifyOption) error {
+	options := newVerifyOptions(opts)
+
+	if err := disclosure.Validate(); err != nil {
+		return err
+	}
+	if disclosure.Binding == "" {
+		return errcode.Errorf(errcode.MalformedProof, "credential<%s> does not have a binding", disclosure.ID)
+	}
+	if len(disclosure.Claims) == 0 {
+		return errors.Errorf("credential<%s> does not disclose any claims", disclosure.ID)
+	}
+	for name := range disclosure.Claims {
+		claimProof, ok := disclosure.ClaimProofs[name]
+		if !ok || claimProof.Proof.IsEmpty() {
+			return errcode.Errorf(errcode.MalformedProof, "credential<%s> does not have a proof of claim<%s>", disclosure.ID, name)
+		}
+		doc := disclosure.claimDocument(name, claimProof.Salt)
+		doc.Proof = claimProof.Proof
+		if err := disclosure.verifyIssuerProof(ctx, doc, resolver, options.proofOpts...); err != nil {
+			return errors.Wrapf(err, "claim<%s>", name)
+		}
+	}
+	return disclosure.checkValidAt(options.now(), options.skew)
+}
+
+// verifyIssuerProof verifies the proof of the provable, which must be made with a key of the
+// credential's issuer.
+func (c *Credential) verifyIssuerProof(ctx context.Context, provable proof.Provable, resolver did.Resolver, opts ...proof.VerifyOption) error {
+	if did.ExtractDIDFromKeyRef(provable.GetProof().GetVerificationMethod()) != c.IssuerDID {
+		return ErrIssuerKeyMismatch
+	}
+	return proof.VerifyWithResolver(ctx, provable, did.AsVerifierResolver(resolver), opts...)
+}
+
+// checkValidAt checks that the time is within the validity window of the credential, widened by
+// the clock skew.
+func (c *Credential) checkValidAt(now time.Time, skew time.Duration) error {
+	issuance, expiration, err := c.validityWindow()
+	if err != nil {
+		return err
+	}
+	if now.Before(issuance.Add(-skew)) {
+		return ErrNotYetValid
+	}
+	if !expiration.IsZero() && !now.Before(expiration.Add(skew)) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// checkTimestamps checks that the proof is created within the window of the issuance date.
+func (c *Credential) checkTimestamps(window time.Duration) error {
+	issuance, err := util.ParseRFC3339Lenient(c.IssuanceDate)
+	if err != nil {
+		return errors.Wrapf(err, "invalid issuance date<%s>", c.IssuanceDate)
+	}
+	created, err := c.Proof.CreatedTime()
+	if err != nil {
+		return errors.Wrapf(err, "invalid proof created<%s>", c.Proof.Created)
+	}
+	if diff := created.Sub(issuance); diff > window || diff < -window {
+		return InconsistentTimestampsError{IssuanceDate: c.IssuanceDate, ProofCreated: c.Proof.Created}
+	}
+	return nil
+}
+
+// newestSuite returns the signature suite for the newest proof model version that supports the
+// signature type.
+func newestSuite(sigType proof.SignatureType) (proof.SignatureSuite, error) {
+	suite, err := proof.SignatureSuites().GetSuite(sigType, proof.V2)
+	if err != nil {
+		return proof.SignatureSuites().GetSuite(sigType, proof.V1)
+	}
+	return suite, nil
+}
@@ -0,0 +1,304 @@
+package credential
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// didKeySigner returns a did:key DID and a signer for the key of its DID Document.
+func didKeySigner(t testing.TB, seed byte) (string, proof.Signer) {
+	privKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+	didKey := did.GenerateDIDKey(privKey.Public().(ed25519.PublicKey))
+	signer, err := proof.NewEd25519Signer(privKey, did.GenerateKeyID(didKey, strings.TrimPrefix(didKey, did.KeyDIDMethod)))
+	require.NoError(t, err)
+	return didKey, signer
+}
+
+func TestIssueAndVerifyCredential(t *testing.T) {
+	issuerDID, signer := didKeySigner(t, 1)
+	subjectDID, _ := didKeySigner(t, 2)
+	issued := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := issued.Add(24 * time.Hour)
+	at := func(t time.Time) VerifyOption {
+		return WithClock(func() time.Time { return t })
+	}
+	ctx := context.Background()
+
+	newCredential := func() *Credential {
+		return &Credential{
+			Type:           []string{"EmploymentCredential"},
+			IssuerDID:      issuerDID,
+			SubjectDID:     subjectDID,
+			IssuanceDate:   util.FormatCanonicalTime(issued),
+			ExpirationDate: util.FormatCanonicalTime(expires),
+			Claims: map[string]interface{}{
+				"employer": "Workday",
+				"salary":   json.Number("123456789012345678901"),
+				"position": map[string]interface{}{"title": "Engineer", "level": 3},
+			},
+		}
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		cred := newCredential()
+		require.NoError(t, Issue(cred, signer, proof.JCSEdSignatureType))
+		assert.NoError(t, util.ValidateURNUUID(cred.ID))
+		assert.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued)))
+
+		data, err := json.Marshal(cred)
+		require.NoError(t, err)
+		var decoded Credential
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, json.Number("123456789012345678901"), decoded.Claims["salary"])
+		assert.NoError(t, VerifyCredential(ctx, &decoded, did.KeyResolver{}, at(issued)))
+
+		decoded.Claims["employer"] = "Acme"
+		assert.Error(t, VerifyCredential(ctx, &decoded, did.KeyResolver{}, at(issued)))
+	})
+
+	t.Run("Validity window", func(t *testing.T) {
+		cred := newCredential()
+		require.NoError(t, Issue(cred, signer, proof.Ed25519SignatureType))
+		assert.Equal(t, ErrNotYetValid, VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued.Add(-time.Second))))
+		assert.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}, at(expires.Add(-time.Second))))
+		assert.Equal(t, ErrExpired, VerifyCredential(ctx, cred, did.KeyResolver{}, at(expires)))
+
+		cred = newCredential()
+		cred.ExpirationDate = ""
+		require.NoError(t, Issue(cred, signer, proof.WorkEdSignatureType))
+		assert.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}, at(expires.AddDate(10, 0, 0))))
+	})
+
+	t.Run("Invalid credentials", func(t *testing.T) {
+		cred := newCredential()
+		cred.ExpirationDate = util.FormatCanonicalTime(issued.Add(-time.Second))
+		assert.EqualError(t, Issue(cred, signer, proof.JCSEdSignatureType), "credential<> expires before it is issued")
+
+		cred = newCredential()
+		cred.IssuanceDate = "January 1, 2020"
+		assert.Error(t, Issue(cred, signer, proof.JCSEdSignatureType))
+
+		cred = newCredential()
+		cred.SubjectDID = "did:key:invalid"
+		assert.Error(t, Issue(cred, signer, proof.JCSEdSignatureType))
+
+		cred = newCredential()
+		assert.Error(t, Issue(cred, signer, proof.SignatureType("Unknown")))
+		assert.Empty(t, cred.Proof)
+
+		cred = newCredential()
+		assert.Error(t, VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued)))
+	})
+
+	t.Run("Issuer key", func(t *testing.T) {
+		otherDID, otherSigner := didKeySigner(t, 3)
+		cred := newCredential()
+		assert.Equal(t, ErrIssuerKeyMismatch, Issue(cred, otherSigner, proof.JCSEdSignatureType))
+
+		// Signed by another DID and presented as the issuer's.
+		cred.IssuerDID = otherDID
+		require.NoError(t, Issue(cred, otherSigner, proof.JCSEdSignatureType))
+		cred.IssuerDID = issuerDID
+		assert.Equal(t, ErrIssuerKeyMismatch, VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued)))
+
+		// A key reference of the issuer that is not in its DID Document.
+		cred = newCredential()
+		privKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+		unknownSigner, err := proof.NewEd25519Signer(privKey, did.GenerateKeyID(issuerDID, "unknown"))
+		require.NoError(t, err)
+		require.NoError(t, Issue(cred, unknownSigner, proof.JCSEdSignatureType))
+		err = VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued))
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrIssuerKeyMismatch))
+	})
+
+	t.Run("Timestamp consistency", func(t *testing.T) {
+		clock := WithIssueClock(func() time.Time { return issued })
+		consistent := WithTimestampConsistency(time.Minute)
+
+		// The issuance date is taken from the clock of the proof.
+		cred := newCredential()
+		cred.IssuanceDate = ""
+		require.NoError(t, Issue(cred, signer, proof.JCSEdSignatureType, clock))
+		assert.Equal(t, util.FormatCanonicalTime(issued), cred.IssuanceDate)
+		assert.Equal(t, cred.IssuanceDate, cred.Proof.Created)
+		assert.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued), consistent))
+
+		// A proof created long after the issuance date.
+		cred = newCredential()
+		late := WithIssueClock(func() time.Time { return issued.Add(time.Hour) })
+		require.NoError(t, Issue(cred, signer, proof.JCSEdSignatureType, late))
+		assert.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued)))
+		err := VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued), consistent)
+		assert.True(t, errors.Is(err, ErrInconsistentTimestamps))
+		assert.Equal(t, InconsistentTimestampsError{
+			IssuanceDate: util.FormatCanonicalTime(issued),
+			ProofCreated: util.FormatCanonicalTime(issued.Add(time.Hour)),
+		}, err)
+		assert.Equal(t, errcode.MalformedProof, errcode.CodeOf(err))
+		assert.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued), WithTimestampConsistency(time.Hour)))
+
+		// An issuance date after the proof is created.
+		cred = newCredential()
+		cred.IssuanceDate = util.FormatCanonicalTime(issued.Add(time.Hour))
+		require.NoError(t, Issue(cred, signer, proof.JCSEdSignatureType, clock))
+		err = VerifyCredential(ctx, cred, did.KeyResolver{}, at(issued.Add(time.Hour)), consistent)
+		assert.True(t, errors.Is(err, ErrInconsistentTimestamps))
+	})
+}
+
+func TestSelectiveDisclosure(t *testing.T) {
+	issuerDID, signer := didKeySigner(t, 1)
+	subjectDID, _ := didKeySigner(t, 2)
+	issued := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := WithClock(func() time.Time { return issued })
+	ctx := context.Background()
+
+	issue := func(t *testing.T, id string, claims map[string]interface{}, opts ...IssueOption) *Credential {
+		cred := &Credential{
+			ID:           id,
+			IssuerDID:    issuerDID,
+			SubjectDID:   subjectDID,
+			IssuanceDate: util.FormatCanonicalTime(issued),
+			Claims:       claims,
+		}
+		require.NoError(t, IssueWithClaimProofs(cred, signer, proof.JCSEdSignatureType, opts...))
+		return cred
+	}
+
+	t.Run("Disclose some claims", func(t *testing.T) {
+		cred := issue(t, "", map[string]interface{}{"name": "Alice", "salary": 100000, "manager": true})
+		assert.NotEmpty(t, cred.Binding)
+		assert.Len(t, cred.ClaimProofs, 3)
+		assert.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}, at))
+		assert.NoError(t, VerifyDisclosed(ctx, cred, did.KeyResolver{}, at))
+
+		disclosure, err := cred.Redact("name", "manager")
+		require.NoError(t, err)
+		assert.Nil(t, disclosure.Proof)
+		assert.Equal(t, map[string]interface{}{"name": "Alice", "manager": true}, disclosure.Claims)
+		assert.Len(t, cred.Claims, 3)
+
+		// The disclosure survives a round trip.
+		data, err := json.Marshal(disclosure)
+		require.NoError(t, err)
+		var decoded Credential
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.NoError(t, VerifyDisclosed(ctx, &decoded, did.KeyResolver{}, at))
+		assert.Error(t, VerifyCredential(ctx, &decoded, did.KeyResolver{}, at))
+
+		decoded.Claims["manager"] = false
+		assert.Error(t, VerifyDisclosed(ctx, &decoded, did.KeyResolver{}, at))
+
+		_, err = cred.Redact("age")
+		assert.EqualError(t, err, "credential<"+cred.ID+"> does not have claim<age>")
+		empty, err := cred.Redact()
+		require.NoError(t, err)
+		assert.Error(t, VerifyDisclosed(ctx, empty, did.KeyResolver{}, at))
+	})
+
+	t.Run("Without document proof", func(t *testing.T) {
+		cred := issue(t, "", map[string]interface{}{"name": "Alice"}, WithoutDocumentProof())
+		assert.Nil(t, cred.Proof)
+		assert.NoError(t, VerifyDisclosed(ctx, cred, did.KeyResolver{}, at))
+	})
+
+	t.Run("Disclosed envelope is bound", func(t *testing.T) {
+		cred := issue(t, "", map[string]interface{}{"name": "Alice"})
+		otherSubject, _ := didKeySigner(t, 3)
+		disclosure, err := cred.Redact("name")
+		require.NoError(t, err)
+		disclosure.SubjectDID = otherSubject
+		assert.Error(t, VerifyDisclosed(ctx, disclosure, did.KeyResolver{}, at))
+
+		disclosure, err = cred.Redact("name")
+		require.NoError(t, err)
+		delete(disclosure.ClaimProofs, "name")
+		assert.Error(t, VerifyDisclosed(ctx, disclosure, did.KeyResolver{}, at))
+	})
+
+	// The holder of two credentials from the same issuer tries to combine their claims into one
+	// disclosure, e.g. to claim the salary of one job along with the position of another.
+	t.Run("Claims cannot be mixed across credentials", func(t *testing.T) {
+		first := issue(t, "", map[string]interface{}{"position": "Engineer", "salary": 100000})
+		second := issue(t, "", map[string]interface{}{"position": "Director", "salary": 250000})
+
+		disclosure, err := first.Redact("position")
+		require.NoError(t, err)
+		disclosure.Claims["salary"] = second.Claims["salary"]
+		disclosure.ClaimProofs["salary"] = second.ClaimProofs["salary"]
+		err = VerifyDisclosed(ctx, disclosure, did.KeyResolver{}, at)
+		assert.Equal(t, errcode.SignatureInvalid, errcode.CodeOf(err), err)
+
+		// Moving the other claim into the second credential's envelope fails as well.
+		disclosure, err = second.Redact("salary")
+		require.NoError(t, err)
+		disclosure.Claims["position"] = first.Claims["position"]
+		disclosure.ClaimProofs["position"] = first.ClaimProofs["position"]
+		err = VerifyDisclosed(ctx, disclosure, did.KeyResolver{}, at)
+		assert.Equal(t, errcode.SignatureInvalid, errcode.CodeOf(err), err)
+
+		// Even if the issuer reuses a credential ID, the binding keeps the claims apart.
+		first = issue(t, "urn:example:1", map[string]interface{}{"position": "Engineer", "salary": 100000})
+		second = issue(t, "urn:example:1", map[string]interface{}{"position": "Director", "salary": 250000})
+		assert.NotEqual(t, first.Binding, second.Binding)
+		disclosure, err = first.Redact("position")
+		require.NoError(t, err)
+		disclosure.Claims["salary"] = second.Claims["salary"]
+		disclosure.ClaimProofs["salary"] = second.ClaimProofs["salary"]
+		err = VerifyDisclosed(ctx, disclosure, did.KeyResolver{}, at)
+		assert.Equal(t, errcode.SignatureInvalid, errcode.CodeOf(err), err)
+	})
+}
+
+// The envelope types of this package that have an identifier of their own.
+var (
+	_ proof.Identifiable = (*Credential)(nil)
+	_ proof.Identifiable = (*VerifiableCredential)(nil)
+	_ proof.Identifiable = (*ClaimSchema)(nil)
+	_ proof.Identifiable = (*ClaimDigests)(nil)
+	_ proof.Identifiable = (*IssuerMetadata)(nil)
+	_ proof.Identifiable = (*Presentation)(nil)
+	_ proof.Identifiable = (*PresentationRequest)(nil)
+	_ proof.Identifiable = (*Revocation)(nil)
+	_ proof.Identifiable = (*RevocationList)(nil)
+)
+
+func TestIDOf(t *testing.T) {
+	tests := []struct {
+		provable proof.Provable
+		id       string
+	}{
+		{&Revocation{CredentialID: "urn:uuid:1"}, "urn:uuid:1"},
+		{&ClaimDigests{CredentialID: "urn:uuid:1"}, "urn:uuid:1"},
+		{&IssuerMetadata{IssuerDID: "did:example:issuer"}, "did:example:issuer"},
+		{&Presentation{Challenge: "challenge"}, "challenge"},
+		{&PresentationRequest{Challenge: "challenge"}, "challenge"},
+	}
+	for _, test := range tests {
+		id, ok := proof.IDOf(test.provable)
+		assert.True(t, ok)
+		assert.Equal(t, test.id, id)
+	}
+
+	// A transfer has no identifier of its own: the credential ID is shared by every transfer of
+	// the credential.
+	_, ok := proof.IDOf(&TransferProof{CredentialID: "urn:uuid:1"})
+	assert.False(t, ok)
+	_, ok = proof.IDOf(&Revocation{})
+	assert.False(t, ok)
+}
@@ -0,0 +1,132 @@
+package credential
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// IssuerMetadata describes an issuer to relying parties: who it is, and which credentials it
+// issues. It is signed with a key of the issuer's DID Document, so that it can be fetched from
+// anywhere with a MetadataResolver and checked with VerifyIssuerMetadata.
+type IssuerMetadata struct {
+	IssuerDID string `json:"issuer"`
+	// Name is the display name of the issuer.
+	Name    string `json:"name"`
+	LogoURI string `json:"logo,omitempty"`
+	// SchemaIDs are the IDs of the claim schemas of the credentials that the issuer issues (see
+	// ClaimSchemaID).
+	SchemaIDs    []string         `json:"schemas,omitempty"`
+	Services     []did.ServiceDef `json:"service,omitempty"`
+	*proof.Proof `json:"proof,omitempty"`
+}
+
+func (m *IssuerMetadata) GetProof() *proof.Proof {
+	return m.Proof
+}
+
+func (m *IssuerMetadata) SetProof(p *proof.Proof) {
+	m.Proof = p
+}
+
+// GetID returns the DID of the issuer, which has one set of metadata.
+func (m *IssuerMetadata) GetID() string {
+	return m.IssuerDID
+}
+
+// MetadataResolver fetches the metadata of issuers by DID, e.g. from a well-known URL of the
+// issuer.
+type MetadataResolver interface {
+	ResolveMetadata(ctx context.Context, issuerDID string) (*IssuerMetadata, error)
+}
+
+// MetadataResolverFunc adapts an ordinary function into a MetadataResolver.
+type MetadataResolverFunc func(ctx context.Context, issuerDID string) (*IssuerMetadata, error)
+
+func (f MetadataResolverFunc) ResolveMetadata(ctx context.Context, issuerDID string) (*IssuerMetadata, error) {
+	return f(ctx, issuerDID)
+}
+
+// Validate checks that the issuer is a valid DID, the name is set, the logo is an absolute URI,
+// every schema ID is well-formed and every service has an ID, type and endpoint.
+func (m *IssuerMetadata) Validate() error {
+	if err := did.ValidateDID(m.IssuerDID); err != nil {
+		return errors.Wrap(err, "invalid issuer")
+	}
+	if m.Name == "" {
+		return errors.Errorf("issuer<%s> metadata does not have a name", m.IssuerDID)
+	}
+	if m.LogoURI != "" {
+		if u, err := url.Parse(m.LogoURI); err != nil || !u.IsAbs() {
+			return errors.Errorf("invalid issuer logo URI<%s>", m.LogoURI)
+		}
+	}
+	for _, id := range m.SchemaIDs {
+		if _, _, _, err := ParseClaimSchemaID(id); err != nil {
+			return err
+		}
+	}
+	for _, service := range m.Services {
+		if service.ID == "" || service.Type == "" || service.ServiceEndpoint == "" {
+			return errors.Errorf("issuer<%s> service<%s> must have an ID, type and endpoint", m.IssuerDID, service.ID)
+		}
+	}
+	return nil
+}
+
+// IssueIssuerMetadata validates the metadata and signs it with the signer's key, which must belong
+// to the issuer DID. The preferred signature suite for the key is used (see proof.Capabilities).
+func IssueIssuerMetadata(metadata IssuerMetadata, signer proof.Signer) (*IssuerMetadata, error) {
+	metadata.Proof = nil
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+	if did.ExtractDIDFromKeyRef(signer.ID()) != metadata.IssuerDID {
+		return nil, errcode.New(errcode.SignatureInvalid, "issuer metadata is not signed by a key of its issuer")
+	}
+	capabilities := proof.Capabilities()
+	agreed, err := proof.Negotiate(capabilities.ForKeyType(signer.Type()), capabilities)
+	if err != nil {
+		return nil, err
+	}
+	if err := agreed.Sign(&metadata, signer); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// VerifyIssuerMetadata validates the metadata, and checks that its proof verifies with a key of
+// the issuer's DID Document that is authorized to make proofs.
+func VerifyIssuerMetadata(ctx context.Context, metadata *IssuerMetadata, resolver did.Resolver) error {
+	if err := metadata.Validate(); err != nil {
+		return err
+	}
+	if metadata.Proof.IsEmpty() {
+		return errcode.Errorf(errcode.MalformedProof, "issuer<%s> metadata does not have a proof", metadata.IssuerDID)
+	}
+	if did.ExtractDIDFromKeyRef(metadata.Proof.GetVerificationMethod()) != metadata.IssuerDID {
+		return errcode.New(errcode.SignatureInvalid, "issuer metadata is not signed by a key of its issuer")
+	}
+	return proof.VerifyWithResolver(ctx, metadata, did.AsVerifierResolver(resolver))
+}
+
+// ResolveIssuerMetadata fetches the issuer's metadata with the metadata resolver, and verifies it
+// (see VerifyIssuerMetadata). The resolved metadata must be for the requested issuer.
+func ResolveIssuerMetadata(ctx context.Context, issuerDID string, metadataResolver MetadataResolver, resolver did.Resolver) (*IssuerMetadata, error) {
+	metadata, err := metadataResolver.ResolveMetadata(ctx, issuerDID)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.IssuerDID != issuerDID {
+		return nil, errors.Errorf("resolved metadata of issuer<%s>, expected issuer<%s>", metadata.IssuerDID, issuerDID)
+	}
+	if err := VerifyIssuerMetadata(ctx, metadata, resolver); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
@@ -0,0 +1,87 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+)
+
+func TestIssuerMetadata(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, signer := didKeySigner(t, 1)
+	otherDID, otherSigner := didKeySigner(t, 2)
+	metadata := IssuerMetadata{
+		IssuerDID: issuerDID,
+		Name:      "Workday",
+		LogoURI:   "https://example.com/logo.png",
+		SchemaIDs: []string{ClaimSchemaID(issuerDID, "", "1.0")},
+		Services:  []did.ServiceDef{{ID: issuerDID + "#issue", Type: "CredentialIssuance", ServiceEndpoint: "https://example.com/issue"}},
+	}
+
+	signed, err := IssueIssuerMetadata(metadata, signer)
+	require.NoError(t, err)
+
+	t.Run("Round trip", func(t *testing.T) {
+		data, err := json.Marshal(signed)
+		require.NoError(t, err)
+		metadataResolver := MetadataResolverFunc(func(ctx context.Context, issuerDID string) (*IssuerMetadata, error) {
+			var resolved IssuerMetadata
+			return &resolved, json.Unmarshal(data, &resolved)
+		})
+		resolved, err := ResolveIssuerMetadata(ctx, issuerDID, metadataResolver, did.KeyResolver{})
+		require.NoError(t, err)
+		assert.Equal(t, metadata.SchemaIDs, resolved.SchemaIDs)
+		assert.Equal(t, metadata.Services, resolved.Services)
+
+		_, err = ResolveIssuerMetadata(ctx, otherDID, metadataResolver, did.KeyResolver{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		tampered := *signed
+		tampered.Name = "Someone else"
+		assert.Error(t, VerifyIssuerMetadata(ctx, &tampered, did.KeyResolver{}))
+
+		tampered = *signed
+		tampered.SchemaIDs = append([]string{ClaimSchemaID(issuerDID, "", "2.0")}, signed.SchemaIDs...)
+		assert.Error(t, VerifyIssuerMetadata(ctx, &tampered, did.KeyResolver{}))
+
+		tampered = *signed
+		tampered.Proof = nil
+		assert.Error(t, VerifyIssuerMetadata(ctx, &tampered, did.KeyResolver{}))
+	})
+
+	t.Run("Signed by another DID", func(t *testing.T) {
+		_, err := IssueIssuerMetadata(metadata, otherSigner)
+		assert.Error(t, err)
+
+		other := metadata
+		other.IssuerDID = otherDID
+		other.SchemaIDs = nil
+		otherSigned, err := IssueIssuerMetadata(other, otherSigner)
+		require.NoError(t, err)
+		otherSigned.IssuerDID = issuerDID
+		assert.Error(t, VerifyIssuerMetadata(ctx, otherSigned, did.KeyResolver{}))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, invalid := range map[string]func(m *IssuerMetadata){
+			"issuer":    func(m *IssuerMetadata) { m.IssuerDID = "xyz" },
+			"name":      func(m *IssuerMetadata) { m.Name = "" },
+			"logo":      func(m *IssuerMetadata) { m.LogoURI = "logo.png" },
+			"schema ID": func(m *IssuerMetadata) { m.SchemaIDs = []string{issuerDID + ";id=abc"} },
+			"service":   func(m *IssuerMetadata) { m.Services = []did.ServiceDef{{ID: issuerDID + "#issue"}} },
+		} {
+			m := metadata
+			invalid(&m)
+			assert.Error(t, m.Validate(), name)
+			_, err := IssueIssuerMetadata(m, signer)
+			assert.Error(t, err, name)
+		}
+	})
+}
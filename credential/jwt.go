@@ -0,0 +1,158 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// JWTType is the typ header of credentials issued as JWTs.
+const JWTType = "JWT"
+
+// jwtClaims are the claims of a credential issued as a JWT: the registered claims carry the
+// issuer, subject, validity window and ID, and the vc claim the rest of the W3C credential (see
+// ToW3C).
+type jwtClaims struct {
+	Issuer    string                     `json:"iss"`
+	Subject   string                     `json:"sub,omitempty"`
+	NotBefore *int64                     `json:"nbf"`
+	Expires   *int64                     `json:"exp,omitempty"`
+	ID        string                     `json:"jti,omitempty"`
+	VC        map[string]json.RawMessage `json:"vc"`
+}
+
+// IssueJWT validates the credential and issues it as a JWT (VC-JWT), signed with the issuer's
+// key. The issuer, subject, issuance and expiration dates and ID are the iss, sub, nbf, exp and
+// jti claims; dates are kept to the second. The JWS algorithm follows the signer's key type (see
+// proof.JWSAlgorithm). A UUID URN is generated if the credential does not have an ID.
+func IssueJWT(cred Credential, signer proof.Signer) (string, error) {
+	if err := cred.Validate(); err != nil {
+		return "", err
+	}
+	if did.ExtractDIDFromKeyRef(signer.ID()) != cred.IssuerDID {
+		return "", ErrIssuerKeyMismatch
+	}
+	issuance, expiration, err := cred.validityWindow()
+	if err != nil {
+		return "", err
+	}
+	if cred.ID == "" {
+		cred.ID = util.NewURNUUID()
+	}
+
+	properties, err := cred.w3cProperties(nil)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range []string{"id", "issuer", "issuanceDate", "expirationDate"} {
+		delete(properties, name)
+	}
+	subject, ok := properties["credentialSubject"].(map[string]interface{})
+	if !ok {
+		return "", errors.Errorf("credential<%s> does not have a credentialSubject object", cred.ID)
+	}
+	delete(subject, SubjectIDAttribute)
+	vc, err := json.Marshal(properties)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwtClaims{Issuer: cred.IssuerDID, Subject: cred.SubjectDID, ID: cred.ID}
+	nbf := issuance.Unix()
+	claims.NotBefore = &nbf
+	if !expiration.IsZero() {
+		exp := expiration.Unix()
+		claims.Expires = &exp
+	}
+	if err := json.Unmarshal(vc, &claims.VC); err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return proof.SignJWS(payload, JWTType, signer)
+}
+
+// VerifyJWT verifies a credential issued as a JWT, and returns the credential. The issuer's DID
+// Document is resolved from the iss claim, and the JWS must be signed with the key that the kid
+// header references, which must belong to the issuer and be authorized to make proofs. The
+// credential must be valid at the current time, and ErrExpired or ErrNotYetValid is returned
+// otherwise; the clock and clock skew are those of the proof package's verification options (see
+// WithProofOptions), which also apply to the JWS. If a status checker is set (see WithStatusChecker),
+// ErrCredentialRevoked is returned for a revoked credential.
+func VerifyJWT(ctx context.Context, token string, resolver did.Resolver, opts ...VerifyOption) (*Credential, error) {
+	options := newVerifyOptions(opts)
+
+	jws, err := proof.ParseJWS(token)
+	if err != nil {
+		return nil, err
+	}
+	var claims jwtClaims
+	if err := util.UnmarshalUseNumber(jws.Payload, &claims); err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid JWT claims")
+	}
+	if claims.Issuer == "" || claims.NotBefore == nil || claims.VC == nil {
+		return nil, errcode.New(errcode.MalformedProof, "JWT credential must have iss, nbf and vc claims")
+	}
+	if did.ExtractDIDFromKeyRef(jws.Header.Kid) != claims.Issuer {
+		return nil, ErrIssuerKeyMismatch
+	}
+	if err := proof.VerifyJWSWithResolver(ctx, jws, did.AsVerifierResolver(resolver), options.proofOpts...); err != nil {
+		return nil, err
+	}
+
+	cred, err := claims.credential()
+	if err != nil {
+		return nil, err
+	}
+	if err := cred.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cred.checkValidAt(options.now(), options.skew); err != nil {
+		return nil, err
+	}
+	if options.statusChecker != nil {
+		if err := cred.checkStatus(ctx, options.statusChecker, resolver); err != nil {
+			return nil, err
+		}
+	}
+	return cred, nil
+}
+
+// credential reconstructs the credential from the registered claims and the vc claim.
+func (c jwtClaims) credential() (*Credential, error) {
+	properties := make(map[string]interface{}, len(c.VC)+4)
+	for name, value := range c.VC {
+		properties[name] = value
+	}
+	properties["issuer"] = c.Issuer
+	properties["issuanceDate"] = util.FormatCanonicalTime(time.Unix(*c.NotBefore, 0))
+	if c.Expires != nil {
+		properties["expirationDate"] = util.FormatCanonicalTime(time.Unix(*c.Expires, 0))
+	}
+	if c.ID != "" {
+		properties["id"] = c.ID
+	}
+	var subject map[string]interface{}
+	if err := util.UnmarshalUseNumber(c.VC["credentialSubject"], &subject); err != nil || subject == nil {
+		return nil, errors.Errorf("JWT credential<%s> does not have a credentialSubject object", c.ID)
+	}
+	if c.Subject != "" {
+		subject[SubjectIDAttribute] = c.Subject
+	}
+	properties["credentialSubject"] = subject
+
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return nil, err
+	}
+	return FromW3C(data)
+}
@@ -0,0 +1,130 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestJWT(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, signer := didKeySigner(t, 1)
+	subjectDID, otherSigner := didKeySigner(t, 2)
+	issued := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := issued.Add(24 * time.Hour)
+	at := func(t time.Time) VerifyOption {
+		return WithClock(func() time.Time { return t })
+	}
+	cred := Credential{
+		ID:             "urn:uuid:" + util.NewUUID().String(),
+		Type:           []string{"EmploymentCredential"},
+		IssuerDID:      issuerDID,
+		SubjectDID:     subjectDID,
+		IssuanceDate:   util.FormatCanonicalTime(issued),
+		ExpirationDate: util.FormatCanonicalTime(expires),
+		Claims:         map[string]interface{}{"employer": "Workday", "salary": json.Number("100000")},
+		Status:         &CredentialStatus{ListID: "https://example.com/status/1", Index: 3},
+	}
+
+	token, err := IssueJWT(cred, signer)
+	require.NoError(t, err)
+
+	t.Run("Verify", func(t *testing.T) {
+		jws, err := proof.ParseJWS(token)
+		require.NoError(t, err)
+		assert.Equal(t, proof.EdDSAAlgorithm, jws.Header.Alg)
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(jws.Payload, &claims))
+		assert.Equal(t, issuerDID, claims["iss"])
+		assert.Equal(t, subjectDID, claims["sub"])
+		assert.Equal(t, float64(issued.Unix()), claims["nbf"])
+		assert.Equal(t, float64(expires.Unix()), claims["exp"])
+		assert.Equal(t, cred.ID, claims["jti"])
+		assert.Contains(t, claims, "vc")
+
+		verified, err := VerifyJWT(ctx, token, did.KeyResolver{}, at(issued.Add(time.Hour)))
+		require.NoError(t, err)
+		assert.Equal(t, cred.ID, verified.ID)
+		assert.Equal(t, []string{Type, "EmploymentCredential"}, verified.Type)
+		assert.Equal(t, cred.IssuerDID, verified.IssuerDID)
+		assert.Equal(t, cred.SubjectDID, verified.SubjectDID)
+		assert.Equal(t, cred.IssuanceDate, verified.IssuanceDate)
+		assert.Equal(t, cred.ExpirationDate, verified.ExpirationDate)
+		assert.Equal(t, cred.Claims, verified.Claims)
+		assert.Equal(t, cred.Status, verified.Status)
+	})
+
+	t.Run("Validity window", func(t *testing.T) {
+		_, err := VerifyJWT(ctx, token, did.KeyResolver{}, at(expires))
+		assert.Equal(t, ErrExpired, err)
+		_, err = VerifyJWT(ctx, token, did.KeyResolver{}, at(issued.Add(-time.Minute)))
+		assert.Equal(t, ErrNotYetValid, err)
+
+		skew := WithProofOptions(proof.WithClockSkew(5 * time.Minute))
+		_, err = VerifyJWT(ctx, token, did.KeyResolver{}, at(expires.Add(time.Minute)), skew)
+		assert.NoError(t, err)
+		_, err = VerifyJWT(ctx, token, did.KeyResolver{}, at(issued.Add(-time.Minute)), skew)
+		assert.NoError(t, err)
+		_, err = VerifyJWT(ctx, token, did.KeyResolver{}, at(expires.Add(time.Hour)), skew)
+		assert.Equal(t, ErrExpired, err)
+
+		// The clock and skew of the proof package's options are one policy.
+		policy := WithProofOptions(proof.WithVerifyClock(func() time.Time { return expires.Add(time.Minute) }), proof.WithClockSkew(5*time.Minute))
+		_, err = VerifyJWT(ctx, token, did.KeyResolver{}, policy)
+		assert.NoError(t, err)
+		_, err = VerifyJWT(ctx, token, did.KeyResolver{}, WithProofOptions(proof.WithVerifyClock(func() time.Time { return expires })))
+		assert.Equal(t, ErrExpired, err)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		payload, err := util.B64URLDecode(parts[1])
+		require.NoError(t, err)
+		payload = []byte(strings.Replace(string(payload), "Workday", "Acme", 1))
+		tampered := parts[0] + "." + util.B64URLEncode(payload) + "." + parts[2]
+		_, err = VerifyJWT(ctx, tampered, did.KeyResolver{}, at(issued))
+		assert.Error(t, err)
+	})
+
+	t.Run("Signed by another DID", func(t *testing.T) {
+		_, err := IssueJWT(cred, otherSigner)
+		assert.Equal(t, ErrIssuerKeyMismatch, err)
+
+		jws, err := proof.ParseJWS(token)
+		require.NoError(t, err)
+		forged, err := proof.SignJWS(jws.Payload, JWTType, otherSigner)
+		require.NoError(t, err)
+		_, err = VerifyJWT(ctx, forged, did.KeyResolver{}, at(issued))
+		assert.Equal(t, ErrIssuerKeyMismatch, err)
+	})
+
+	t.Run("ES256K", func(t *testing.T) {
+		key, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		secpDID, err := did.GenerateDIDKeySecp256k1(key.PubKey().ToECDSA())
+		require.NoError(t, err)
+		secpSigner, err := proof.NewSecp256k1Signer(key.ToECDSA(), did.GenerateKeyID(secpDID, strings.TrimPrefix(secpDID, did.KeyDIDMethod)))
+		require.NoError(t, err)
+
+		secpCred := cred
+		secpCred.IssuerDID = secpDID
+		secpToken, err := IssueJWT(secpCred, secpSigner)
+		require.NoError(t, err)
+		jws, err := proof.ParseJWS(secpToken)
+		require.NoError(t, err)
+		assert.Equal(t, proof.ES256KAlgorithm, jws.Header.Alg)
+		verified, err := VerifyJWT(ctx, secpToken, did.KeyResolver{}, at(issued))
+		require.NoError(t, err)
+		assert.Equal(t, secpDID, verified.IssuerDID)
+	})
+}
@@ -37,6 +37,10 @@ func (v *VerifiableCredential) SetProof(p *proof.Proof) {
 	v.Proof = p
 }
 
+func (v *VerifiableCredential) GetID() string {
+	return v.ID
+}
+
 // IsEmpty returns true if the credential is nil or contains no data.
 func (v *VerifiableCredential) IsEmpty() bool {
 	if v == nil {
@@ -267,7 +271,7 @@ func (c *VersionedCreds) UnmarshalJSON(data []byte) error {
 		}
 	}
 	c.UnsignedVerifiableCredential = UnsignedVerifiableCredential{}
-	return json.Unmarshal(data, &c.UnsignedVerifiableCredential)
+	return util.UnmarshalUseNumber(data, &c.UnsignedVerifiableCredential)
 }
 
 // IncorrectCredError is returned when attempting to unmarshal a verifiable credential into a model
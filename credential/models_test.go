@@ -1,13 +1,20 @@
 package credential
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/mr-tron/base58"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
 
+	"github.com/workdaycredentials/ledger-common/proof"
 	"github.com/workdaycredentials/ledger-common/util"
 )
 
@@ -84,3 +91,56 @@ func TestNewMetadataWithTimestamp(t *testing.T) {
 		})
 	}
 }
+
+// TestLargeNumberSignatures checks that credentials signed by an implementation that preserves
+// the lexical form of numbers still verify after a round trip through our models.
+func TestLargeNumberSignatures(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	metadata := NewMetadataWithTimestamp("id1", "did:work:issuer", "schema1", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	const placeholder = `"number"`
+	unsigned, err := json.Marshal(UnsignedVerifiableCredential{
+		Metadata:          metadata,
+		CredentialSubject: map[string]interface{}{SubjectIDAttribute: "did:work:subject", "sequence": "number"},
+	})
+	require.NoError(t, err)
+
+	for _, number := range []string{"9223372036854775807", "1234567890123456789", "-12345678901234567890", "1.5E-7", "1E+30"} {
+		t.Run(number, func(t *testing.T) {
+			// The external implementation signs the legacy, non-canonical encoding of the
+			// credential: the base64 encoded JSON and the nonce.
+			unsignedJSON := strings.Replace(string(unsigned), placeholder, number, 1)
+			nonce := "0b4d4c1d-6a52-4e2f-9fd0-5a3b8f1c2d3e"
+			toSign := base64.StdEncoding.EncodeToString([]byte(unsignedJSON)) + "." + nonce
+			p := proof.Proof{
+				Created:            "2020-01-01T00:00:00Z",
+				VerificationMethod: "did:work:issuer#key-1",
+				Nonce:              nonce,
+				SignatureValue:     base58.Encode(ed25519.Sign(privKey, []byte(toSign))),
+				Type:               proof.WorkEdSignatureType,
+			}
+			proofJSON, err := json.Marshal(p)
+			require.NoError(t, err)
+			signedJSON := unsignedJSON[:len(unsignedJSON)-1] + `,"proof":` + string(proofJSON) + "}"
+
+			suite, err := proof.SignatureSuites().GetSuiteForCredentialsProof(&p)
+			require.NoError(t, err)
+			verifier := &proof.Ed25519Verifier{PubKey: pubKey}
+
+			var cred VerifiableCredential
+			require.NoError(t, util.UnmarshalUseNumber([]byte(signedJSON), &cred))
+			assert.Equal(t, json.Number(number), cred.CredentialSubject["sequence"])
+			assert.NoError(t, suite.Verify(&cred, verifier))
+
+			var versioned VersionedCreds
+			require.NoError(t, json.Unmarshal([]byte(unsignedJSON), &versioned))
+			assert.Equal(t, json.Number(number), versioned.CredentialSubject["sequence"])
+
+			// Decoding the number into a float64 changes the signed bytes.
+			var lossy VerifiableCredential
+			require.NoError(t, json.Unmarshal([]byte(signedJSON), &lossy))
+			assert.Error(t, suite.Verify(&lossy, verifier))
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+var (
+	// ErrChallengeMismatch is returned when a presentation was not made for the verifier's
+	// challenge, e.g. because it is replayed from an earlier request.
+	ErrChallengeMismatch = errcode.New(errcode.Replay, "presentation challenge does not match the request")
+	// ErrChallengeExpired is returned when a presentation is verified after its request expired.
+	ErrChallengeExpired = errcode.New(errcode.Expired, "presentation challenge has expired")
+	// ErrDomainMismatch is returned when a presentation was made for another verifier.
+	ErrDomainMismatch = errcode.New(errcode.Replay, "presentation domain does not match the request")
+	// ErrHolderKeyMismatch is returned when a presentation is not signed with a key of its holder.
+	ErrHolderKeyMismatch = errcode.New(errcode.SignatureInvalid, "presentation is not signed by a key of its holder")
+)
+
+// Presentation is a bundle of signed documents, such as credentials, that a holder counter-signs
+// in response to a verifier's challenge. The documents are kept as raw JSON, so that they can be
+// of any type, and keep verifying with their own proofs.
+type Presentation struct {
+	// HolderDID is the DID of the holder, whose key signs the presentation.
+	HolderDID string `json:"holder"`
+	// Challenge is the verifier's challenge (see PresentationRequest).
+	Challenge string `json:"challenge"`
+	// Domain identifies the verifier that the presentation is made for.
+	Domain string `json:"domain,omitempty"`
+	// Created is the RFC 3339 time that the presentation was made.
+	Created      string            `json:"created"`
+	Documents    []json.RawMessage `json:"documents"`
+	*proof.Proof `json:"proof,omitempty"`
+}
+
+func (p *Presentation) GetProof() *proof.Proof {
+	return p.Proof
+}
+
+func (p *Presentation) SetProof(pr *proof.Proof) {
+	p.Proof = pr
+}
+
+// GetID returns the challenge that the presentation answers (see PresentationRequest.GetID).
+func (p *Presentation) GetID() string {
+	return p.Challenge
+}
+
+// PresentationRequest is a verifier's request for a Presentation. The holder signs the challenge,
+// a fresh random value, and the domain, which identifies the verifier, so that presentations can
+// neither be replayed, nor used with another verifier. The request may describe the credentials
+// that it asks for (see InputConstraint), and be signed by the verifier (see
+// SignPresentationRequest), so that the holder knows who is asking.
+type PresentationRequest struct {
+	Challenge string `json:"challenge"`
+	Domain    string `json:"domain,omitempty"`
+	// Expires is the optional RFC 3339 time after which the challenge is stale.
+	Expires string `json:"expires,omitempty"`
+	// VerifierDID is the DID of the verifier, whose key signs the request.
+	VerifierDID  string            `json:"verifier,omitempty"`
+	Inputs       []InputConstraint `json:"inputs,omitempty"`
+	*proof.Proof `json:"proof,omitempty"`
+}
+
+func (r *PresentationRequest) GetProof() *proof.Proof {
+	return r.Proof
+}
+
+func (r *PresentationRequest) SetProof(p *proof.Proof) {
+	r.Proof = p
+}
+
+// GetID returns the challenge, which is fresh for each request.
+func (r *PresentationRequest) GetID() string {
+	return r.Challenge
+}
+
+// PresentationResponse is a holder's response to a PresentationRequest.
+type PresentationResponse struct {
+	Presentation *Presentation `json:"presentation"`
+}
+
+// NewPresentationRequest returns a request with a random challenge for the verifier's domain. The
+// challenge expires after the time to live, or never if it is zero.
+func NewPresentationRequest(domain string, ttl time.Duration) PresentationRequest {
+	request := PresentationRequest{Challenge: util.NewID(""), Domain: domain}
+	if ttl > 0 {
+		request.Expires = util.FormatCanonicalTime(time.Now().Add(ttl))
+	}
+	return request
+}
+
+// Respond presents the signed documents in response to the request (see CreatePresentation).
+func (r PresentationRequest) Respond(docs []json.RawMessage, holderSigner proof.Signer) (*PresentationResponse, error) {
+	presentation, err := CreatePresentation(docs, holderSigner, r.Challenge, r.Domain)
+	if err != nil {
+		return nil, err
+	}
+	return &PresentationResponse{Presentation: presentation}, nil
+}
+
+// Verify verifies the response to the request (see VerifyPresentation).
+func (r PresentationRequest) Verify(ctx context.Context, response *PresentationResponse, resolver did.Resolver, opts ...VerifyOption) error {
+	if response.Presentation == nil {
+		return errors.New("presentation response does not have a presentation")
+	}
+	return VerifyPresentation(ctx, response.Presentation, r, resolver, opts...)
+}
+
+// CreatePresentation bundles the signed documents, and signs the bundle with the holder's key,
+// along with the verifier's challenge and domain. The holder is the DID of the signer's key, and
+// the preferred signature suite for the key is used (see proof.Capabilities).
+func CreatePresentation(docs []json.RawMessage, holderSigner proof.Signer, challenge, domain string) (*Presentation, error) {
+	if challenge == "" {
+		return nil, errors.New("presentation challenge is required")
+	}
+	for i, data := range docs {
+		doc, err := proof.ParseDocument(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "document<%d>", i)
+		}
+		if doc.GetProof().IsEmpty() {
+			return nil, errors.Errorf("document<%d> is not signed", i)
+		}
+	}
+
+	capabilities := proof.Capabilities()
+	agreed, err := proof.Negotiate(capabilities.ForKeyType(holderSigner.Type()), capabilities)
+	if err != nil {
+		return nil, err
+	}
+	presentation := &Presentation{
+		HolderDID: did.ExtractDIDFromKeyRef(holderSigner.ID()),
+		Challenge: challenge,
+		Domain:    domain,
+		Created:   util.FormatCanonicalTime(time.Now()),
+		Documents: docs,
+	}
+	if err := agreed.Sign(presentation, holderSigner); err != nil {
+		return nil, err
+	}
+	return presentation, nil
+}
+
+// VerifyPresentation verifies that the presentation was made for the request, before the request
+// expired, that it is signed by a key of the holder, and that the proof of every document in it
+// verifies. Returns ErrChallengeMismatch, ErrChallengeExpired or ErrDomainMismatch if the
+// presentation was not made for the request. A bound credential (see IssueBound) must be presented
+// with its confirmation key, and ErrHolderBindingMismatch is returned otherwise; a transferred
+// credential must be presented by the holder that its transfer chain ends at, with the transfers
+// among the documents (see TransferCredential). The resolver must be able to resolve the DIDs of
+// the holder and of the documents' signers.
+func VerifyPresentation(ctx context.Context, p *Presentation, request PresentationRequest, resolver did.Resolver, opts ...VerifyOption) error {
+	options := newVerifyOptions(opts)
+
+	if p.Challenge != request.Challenge || request.Challenge == "" {
+		return ErrChallengeMismatch
+	}
+	if request.Expires != "" {
+		expires, err := util.ParseRFC3339Lenient(request.Expires)
+		if err != nil {
+			return errors.Wrap(err, "invalid presentation request expiry")
+		}
+		if !options.now().Before(expires) {
+			return ErrChallengeExpired
+		}
+	}
+	if p.Domain != request.Domain {
+		return ErrDomainMismatch
+	}
+
+	if p.Proof.IsEmpty() {
+		return errcode.New(errcode.MalformedProof, "presentation does not have a proof")
+	}
+	if did.ExtractDIDFromKeyRef(p.Proof.GetVerificationMethod()) != p.HolderDID {
+		return ErrHolderKeyMismatch
+	}
+	verifierResolver := did.AsVerifierResolver(resolver)
+	if err := proof.VerifyWithResolver(ctx, p, verifierResolver); err != nil {
+		return err
+	}
+
+	for i, data := range p.Documents {
+		doc, err := proof.ParseDocument(data)
+		if err != nil {
+			return errors.Wrapf(err, "document<%d>", i)
+		}
+		if err := proof.VerifyWithResolver(ctx, doc, verifierResolver); err != nil {
+			return errors.Wrapf(err, "document<%d>", i)
+		}
+	}
+	return checkHolderBindings(ctx, p, resolver)
+}
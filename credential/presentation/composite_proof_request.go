@@ -13,6 +13,7 @@ import (
 	"github.com/workdaycredentials/ledger-common/did"
 	"github.com/workdaycredentials/ledger-common/ledger"
 	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
 	"github.com/workdaycredentials/ledger-common/util/canonical"
 )
 
@@ -85,6 +86,7 @@ func (p *ProofRequestHolder) FulfillCriteria(criteria *CriteriaHolder, credentia
 	if err != nil {
 		return err
 	}
+	defer util.Zeroize(keyBytes)
 	signingKey := ed25519.PrivateKey(keyBytes)
 
 	creds, err := ExtractCreds(credentialsB64Enc)
@@ -140,6 +142,7 @@ func (p *ProofRequestHolder) GenerateProofResponse(signingKeyRefB64Enc string, s
 	if err != nil {
 		return "", err
 	}
+	defer util.Zeroize(keyBytes)
 	signingKey := ed25519.PrivateKey(keyBytes)
 
 	var fulfilledCriterion []FulfilledCriterion
@@ -271,7 +274,7 @@ func (c *CriteriaHolder) CanFulfill(credentialB64Enc string) bool {
 	var signedV1Credential credential.VerifiableCredential
 	decodedCredAsString := string(decodeCredential)
 
-	if err = json.Unmarshal(decodeCredential, &signedV1Credential); err != nil {
+	if err = util.UnmarshalUseNumber(decodeCredential, &signedV1Credential); err != nil {
 		logrus.WithField("credV1", decodedCredAsString).WithError(err).Warn("error marshaling V1 Credential")
 		return false
 	}
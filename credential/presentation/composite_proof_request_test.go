@@ -17,6 +17,13 @@ import (
 	"github.com/workdaycredentials/ledger-common/util/canonical"
 )
 
+// The envelope types of this package that have an identifier of their own.
+var (
+	_ proof.Identifiable = (*Presentation)(nil)
+	_ proof.Identifiable = (*CompositeProofRequestInstanceChallenge)(nil)
+	_ proof.Identifiable = (*CompositeProofResponseSubmission)(nil)
+)
+
 func TestExtractVerifierFromProofRequest(t *testing.T) {
 	proofReqStruct := &ProofRequestHolder{}
 	b64Enc := base64.StdEncoding
@@ -34,6 +34,10 @@ func (p *Presentation) SetProof(pr *proof.Proof) {
 	p.Proof[0] = pr
 }
 
+func (p *Presentation) GetID() string {
+	return p.ID
+}
+
 // UnsignedPresentation is a set of Verifiable Credentials that will be returned in response to a
 // Proof Request.  Each claim in a Verifiable Credential is signed using a Claim Proof; however,
 // the overall set of credentials is unsigned.
@@ -141,6 +145,10 @@ func (c *CompositeProofRequestInstanceChallenge) SetProof(p *proof.Proof) {
 	c.Proof = p
 }
 
+func (c *CompositeProofRequestInstanceChallenge) GetID() string {
+	return c.ProofRequestInstanceID
+}
+
 type CompositeProofRequest struct {
 	ProofReqRespMetadata
 	Description string      `json:"description"`
@@ -193,6 +201,10 @@ func (c *CompositeProofResponseSubmission) SetProof(pr *proof.Proof) {
 	c.Proof[0] = pr
 }
 
+func (c *CompositeProofResponseSubmission) GetID() string {
+	return c.ID
+}
+
 // FulfilledCriterion holds a request Criterion and the list of all Proof Presentations that
 // fulfilled that Criterion.
 type FulfilledCriterion struct {
@@ -43,6 +43,7 @@ func GenerateProof(b64Credential, b64KeyReference, b64SigningKey, b64Presentatio
 	if err != nil {
 		return "", err
 	}
+	defer util.Zeroize(keyBytes)
 
 	presentationID, err := b64Enc.DecodeString(b64PresentationID)
 	if err != nil {
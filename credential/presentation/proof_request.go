@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
 	"github.com/workdaycredentials/ledger-common/credential"
@@ -131,7 +130,7 @@ func ProofRespMetadata() ProofReqRespMetadata {
 	return ProofReqRespMetadata{
 		Type:         util.ProofResponseTypeReference_v1_0,
 		ModelVersion: util.Version_1_0,
-		ID:           uuid.New().String(),
+		ID:           util.NewUUID().String(),
 	}
 }
 
@@ -143,7 +142,7 @@ func FulfillCriterionForVCs(criterion Criterion, variables map[string]interface{
 	for _, cred := range submittedV1Credentials {
 		filteredCred := cred
 		stripUnrequestedAttributesFromCredential(criterion, &filteredCred)
-		uid := uuid.New().String()
+		uid := util.NewUUID().String()
 		presentation, err := GeneratePresentationFromVC(filteredCred, signer, proof.JCSEdSignatureType, uid)
 		if err != nil {
 			return nil, err
@@ -178,7 +177,7 @@ func decodeAndUnmarshal(b64Encoded string, v interface{}) error {
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(bytes, &v)
+	return util.UnmarshalUseNumber(bytes, &v)
 }
 
 // stripUnrequestedAttributesFromCredential removes any claim attributes and proofs from the credential that were not
@@ -244,12 +243,12 @@ func validCredentialExpiry(cred credential.UnsignedVerifiableCredential) error {
 		return nil
 	}
 	now := time.Now().UTC()
-	credExp, err := time.Parse(time.RFC3339, cred.ExpirationDate)
+	credExp, err := util.ParseRFC3339Lenient(cred.ExpirationDate)
 	if err != nil {
 		return err
 	}
 	if now.After(credExp) {
-		return fmt.Errorf("credential <%s> has expired. expiry: <%s>. current time: <%s>", cred.ID, cred.ExpirationDate, now.Format(time.RFC3339))
+		return fmt.Errorf("credential <%s> has expired. expiry: <%s>. current time: <%s>", cred.ID, cred.ExpirationDate, util.FormatCanonicalTime(now))
 	}
 	return nil
 }
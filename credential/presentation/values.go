@@ -1,6 +1,7 @@
 package presentation
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -70,6 +71,13 @@ func asValue(source string, i interface{}) (result value) {
 		result = value{Err: v}
 	case float64:
 		result = value{Number: &v}
+	case json.Number:
+		number, err := v.Float64()
+		if err != nil {
+			result = value{Err: errors.Wrapf(err, "invalid number in condition evaluation")}
+		} else {
+			result = value{Number: &number}
+		}
 	default:
 		result = value{Err: errors.Errorf("unknown type in condition evaluation: %T", i)}
 	}
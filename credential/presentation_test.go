@@ -0,0 +1,116 @@
+package credential
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestPresentation(t *testing.T) {
+	ctx := context.Background()
+	holderDID, holderSigner := didKeySigner(t, 1)
+	edIssuerDID, edSigner := didKeySigner(t, 2)
+
+	privateKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{3}, 32))
+	secpIssuerDID, err := did.GenerateDIDKeySecp256k1(privateKey.PubKey().ToECDSA())
+	require.NoError(t, err)
+	secpSigner, err := proof.NewSecp256k1Signer(privateKey.ToECDSA(), did.GenerateKeyID(secpIssuerDID, strings.TrimPrefix(secpIssuerDID, did.KeyDIDMethod)))
+	require.NoError(t, err)
+
+	// Two credentials about the holder, signed with different suites.
+	issue := func(issuerDID string, signer proof.Signer, sigType proof.SignatureType, claims map[string]interface{}) json.RawMessage {
+		cred := &Credential{
+			IssuerDID:    issuerDID,
+			SubjectDID:   holderDID,
+			IssuanceDate: util.FormatCanonicalTime(time.Now()),
+			Claims:       claims,
+		}
+		require.NoError(t, Issue(cred, signer, sigType))
+		data, err := json.Marshal(cred)
+		require.NoError(t, err)
+		return data
+	}
+	docs := []json.RawMessage{
+		issue(edIssuerDID, edSigner, proof.JCSEdSignatureType, map[string]interface{}{"employer": "Workday"}),
+		issue(secpIssuerDID, secpSigner, proof.EcdsaSecp256k1SignatureType, map[string]interface{}{"degree": "BSc"}),
+	}
+
+	request := NewPresentationRequest("verifier.example.com", time.Minute)
+	response, err := request.Respond(docs, holderSigner)
+	require.NoError(t, err)
+	presentation := response.Presentation
+	assert.Equal(t, holderDID, presentation.HolderDID)
+	assert.Equal(t, proof.JCSEdSignatureType, presentation.Proof.Type)
+
+	t.Run("Round trip", func(t *testing.T) {
+		data, err := json.Marshal(response)
+		require.NoError(t, err)
+		var decoded PresentationResponse
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.NoError(t, request.Verify(ctx, &decoded, did.KeyResolver{}))
+
+		var embedded Credential
+		require.NoError(t, json.Unmarshal(decoded.Presentation.Documents[1], &embedded))
+		assert.Equal(t, proof.EcdsaSecp256k1SignatureType, embedded.Proof.Type)
+		assert.NoError(t, VerifyCredential(ctx, &embedded, did.KeyResolver{}))
+	})
+
+	t.Run("Challenge", func(t *testing.T) {
+		other := request
+		other.Challenge = NewPresentationRequest(request.Domain, 0).Challenge
+		assert.Equal(t, ErrChallengeMismatch, VerifyPresentation(ctx, presentation, other, did.KeyResolver{}))
+
+		stale := WithClock(func() time.Time { return time.Now().Add(time.Hour) })
+		assert.Equal(t, ErrChallengeExpired, VerifyPresentation(ctx, presentation, request, did.KeyResolver{}, stale))
+
+		other = request
+		other.Domain = "attacker.example.com"
+		assert.Equal(t, ErrDomainMismatch, VerifyPresentation(ctx, presentation, other, did.KeyResolver{}))
+
+		// The challenge is signed: changing it to match another request breaks the proof.
+		tampered := *presentation
+		tampered.Challenge = other.Challenge
+		assert.Error(t, VerifyPresentation(ctx, &tampered, other, did.KeyResolver{}))
+
+		_, err := CreatePresentation(docs, holderSigner, "", "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Holder", func(t *testing.T) {
+		tampered := *presentation
+		tampered.HolderDID = edIssuerDID
+		assert.Equal(t, ErrHolderKeyMismatch, VerifyPresentation(ctx, &tampered, request, did.KeyResolver{}))
+	})
+
+	t.Run("Embedded documents", func(t *testing.T) {
+		var cred Credential
+		require.NoError(t, json.Unmarshal(docs[1], &cred))
+		cred.Claims["degree"] = "PhD"
+		forged, err := json.Marshal(cred)
+		require.NoError(t, err)
+
+		// The holder counter-signs a forged document: the holder's proof verifies, the document's
+		// does not.
+		forgedPresentation, err := CreatePresentation([]json.RawMessage{docs[0], forged}, holderSigner, request.Challenge, request.Domain)
+		require.NoError(t, err)
+		err = VerifyPresentation(ctx, forgedPresentation, request, did.KeyResolver{})
+		require.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "document<1>"), err.Error())
+
+		_, err = CreatePresentation([]json.RawMessage{json.RawMessage(`{"unsigned":true}`)}, holderSigner, request.Challenge, request.Domain)
+		assert.EqualError(t, err, "document<0> is not signed")
+		_, err = CreatePresentation([]json.RawMessage{json.RawMessage(`[]`)}, holderSigner, request.Challenge, request.Domain)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,210 @@
+package credential
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ErrCredentialRevoked is returned by VerifyCredential when the status checker reports that the
+// credential has been revoked by its issuer.
+var ErrCredentialRevoked = errcode.New(errcode.Deactivated, "credential has been revoked")
+
+// Revocation is an issuer's signed statement that a credential it issued is no longer valid.
+type Revocation struct {
+	CredentialID string `json:"credentialId"`
+	// IssuerDID is the DID of the issuer of the credential, whose key signs the revocation.
+	IssuerDID string `json:"issuer"`
+	// ReasonCode is the issuer's reason for the revocation. Its meaning is up to the issuer.
+	ReasonCode int `json:"reason,omitempty"`
+	// Revoked is the RFC 3339 time that the credential was revoked.
+	Revoked      string `json:"revoked"`
+	*proof.Proof `json:"proof,omitempty"`
+}
+
+func (r *Revocation) GetProof() *proof.Proof {
+	return r.Proof
+}
+
+func (r *Revocation) SetProof(p *proof.Proof) {
+	r.Proof = p
+}
+
+// GetID returns the ID of the revoked credential, which identifies the revocation since a
+// credential is only revoked once.
+func (r *Revocation) GetID() string {
+	return r.CredentialID
+}
+
+// CredentialStatusChecker reports whether credentials have been revoked. Status returns the
+// issuer's Revocation if the credential is revoked and the checker has it.
+type CredentialStatusChecker interface {
+	Status(ctx context.Context, credentialID string) (revoked bool, rev *Revocation, err error)
+}
+
+type revocationOptions struct {
+	receiptCredential Credential
+	receipt           *did.Receipt
+}
+
+// RevocationOption configures IssueRevocation.
+type RevocationOption func(*revocationOptions)
+
+// WithRevocationReceipt makes IssueRevocation sign a did.RevocationReceipt of the revoked
+// credential with the issuer's key, and store it in the receipt. The credential must be the one
+// that is revoked; its fingerprint is recorded in the receipt (see VerifyRevocationReceipt).
+func WithRevocationReceipt(cred Credential, receipt *did.Receipt) RevocationOption {
+	return func(o *revocationOptions) {
+		o.receiptCredential = cred
+		o.receipt = receipt
+	}
+}
+
+// IssueRevocation revokes the credential with the signer's key. The issuer is the DID of the
+// signer's key, which must be the issuer of the credential, and the preferred signature suite for
+// the key is used (see proof.Capabilities).
+func IssueRevocation(signer proof.Signer, credentialID string, reason int, opts ...RevocationOption) (*Revocation, error) {
+	var options revocationOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if credentialID == "" {
+		return nil, errors.New("credential ID is required")
+	}
+	if options.receipt != nil && options.receiptCredential.ID != credentialID {
+		return nil, errors.Errorf("receipt credential<%s> is not the revoked credential<%s>", options.receiptCredential.ID, credentialID)
+	}
+	capabilities := proof.Capabilities()
+	agreed, err := proof.Negotiate(capabilities.ForKeyType(signer.Type()), capabilities)
+	if err != nil {
+		return nil, err
+	}
+	revoked := time.Now()
+	rev := &Revocation{
+		CredentialID: credentialID,
+		IssuerDID:    did.ExtractDIDFromKeyRef(signer.ID()),
+		ReasonCode:   reason,
+		Revoked:      util.FormatCanonicalTime(revoked),
+	}
+	if err := agreed.Sign(rev, signer); err != nil {
+		return nil, err
+	}
+	if options.receipt != nil {
+		fingerprint, err := options.receiptCredential.Fingerprint()
+		if err != nil {
+			return nil, err
+		}
+		receipt, err := did.SignReceipt(did.RevocationReceipt, credentialID, fingerprint, revoked, signer)
+		if err != nil {
+			return nil, err
+		}
+		*options.receipt = *receipt
+	}
+	return rev, nil
+}
+
+// VerifyRevocationReceipt checks that the receipt records the revocation of the credential by its
+// issuer (see did.VerifyReceipt). Returns did.ErrReceiptMismatch if the receipt is for another
+// credential, or for another version of it.
+func VerifyRevocationReceipt(ctx context.Context, receipt did.Receipt, cred Credential, resolver did.Resolver) error {
+	if receipt.Kind != did.RevocationReceipt || receipt.TargetID != cred.ID {
+		return errors.Wrapf(did.ErrReceiptMismatch, "receipt is not for the revocation of credential<%s>", cred.ID)
+	}
+	fingerprint, err := cred.Fingerprint()
+	if err != nil {
+		return err
+	}
+	if receipt.TargetFingerprint != fingerprint {
+		return errors.Wrapf(did.ErrReceiptMismatch, "credential<%s> fingerprint", cred.ID)
+	}
+	if did.ExtractDIDFromKeyRef(receipt.Actor) != cred.IssuerDID {
+		return ErrIssuerKeyMismatch
+	}
+	return did.VerifyReceipt(ctx, receipt, resolver)
+}
+
+// VerifyRevocation checks that the revocation is signed with a key of the issuer's DID Document
+// that is authorized to make proofs.
+func VerifyRevocation(rev *Revocation, issuerDoc did.DIDDoc) error {
+	if rev.CredentialID == "" {
+		return errors.New("revocation does not have a credential ID")
+	}
+	if _, err := util.ParseRFC3339Lenient(rev.Revoked); err != nil {
+		return errors.Wrapf(err, "invalid revocation time<%s>", rev.Revoked)
+	}
+	if rev.IssuerDID != issuerDoc.ID {
+		return errors.Errorf("revocation issuer<%s> does not match DID Document<%s>", rev.IssuerDID, issuerDoc.ID)
+	}
+	if rev.Proof.IsEmpty() {
+		return errcode.Errorf(errcode.MalformedProof, "revocation of credential<%s> does not have a proof", rev.CredentialID)
+	}
+	if did.ExtractDIDFromKeyRef(rev.Proof.GetVerificationMethod()) != rev.IssuerDID {
+		return ErrIssuerKeyMismatch
+	}
+	return did.VerifyProofForOperation(rev, issuerDoc.UnsignedDIDDoc, did.ProofOperation)
+}
+
+// checkStatus consults the status checker, and returns ErrCredentialRevoked if the credential has
+// been revoked. A revocation returned by the checker must be for the credential, and verify with
+// a key of the credential's issuer; otherwise the error is returned, rather than trusting it. The
+// credential is passed to the checker in the context (see ContextWithCredential).
+func (c *Credential) checkStatus(ctx context.Context, checker CredentialStatusChecker, resolver did.Resolver) error {
+	revoked, rev, err := checker.Status(ContextWithCredential(ctx, c), c.ID)
+	if err != nil {
+		return errors.Wrapf(err, "checking status of credential<%s>", c.ID)
+	}
+	if !revoked {
+		return nil
+	}
+	if rev != nil {
+		if rev.CredentialID != c.ID {
+			return errors.Errorf("revocation is for credential<%s>, expected credential<%s>", rev.CredentialID, c.ID)
+		}
+		result, err := did.ResolveWithOptions(ctx, resolver, c.IssuerDID, did.ResolutionOptions{AcceptDeactivated: true})
+		if err != nil {
+			return err
+		}
+		if err := VerifyRevocation(rev, *result.DIDDoc); err != nil {
+			return errors.Wrapf(err, "revocation of credential<%s>", c.ID)
+		}
+	}
+	return ErrCredentialRevoked
+}
+
+// MemoryStatusChecker is an in-memory CredentialStatusChecker, intended for tests and small
+// services that do not have a ledger. It is safe for concurrent use.
+type MemoryStatusChecker struct {
+	mu          sync.RWMutex
+	revocations map[string]*Revocation
+}
+
+// NewMemoryStatusChecker returns a MemoryStatusChecker without revocations.
+func NewMemoryStatusChecker() *MemoryStatusChecker {
+	return &MemoryStatusChecker{revocations: make(map[string]*Revocation)}
+}
+
+// Revoke records the revocation, after verifying it against the issuer's DID Document (see
+// VerifyRevocation).
+func (m *MemoryStatusChecker) Revoke(rev *Revocation, issuerDoc did.DIDDoc) error {
+	if err := VerifyRevocation(rev, issuerDoc); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.revocations[rev.CredentialID] = rev
+	return nil
+}
+
+func (m *MemoryStatusChecker) Status(_ context.Context, credentialID string) (bool, *Revocation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rev, ok := m.revocations[credentialID]
+	return ok, rev, nil
+}
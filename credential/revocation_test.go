@@ -0,0 +1,136 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestRevocation(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, signer := didKeySigner(t, 1)
+	subjectDID, _ := didKeySigner(t, 2)
+	issuerDoc, err := did.ResolveDIDKey(issuerDID)
+	require.NoError(t, err)
+
+	issue := func(t *testing.T) *Credential {
+		cred := &Credential{
+			IssuerDID:    issuerDID,
+			SubjectDID:   subjectDID,
+			IssuanceDate: util.FormatCanonicalTime(time.Now().Add(-time.Hour)),
+			Claims:       map[string]interface{}{"employer": "Workday"},
+		}
+		require.NoError(t, Issue(cred, signer, proof.JCSEdSignatureType))
+		return cred
+	}
+
+	t.Run("Revoke", func(t *testing.T) {
+		cred := issue(t)
+		checker := NewMemoryStatusChecker()
+		assert.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}, WithStatusChecker(checker)))
+
+		rev, err := IssueRevocation(signer, cred.ID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, issuerDID, rev.IssuerDID)
+		assert.NoError(t, VerifyRevocation(rev, *issuerDoc))
+		require.NoError(t, checker.Revoke(rev, *issuerDoc))
+
+		revoked, stored, err := checker.Status(ctx, cred.ID)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		assert.Equal(t, rev, stored)
+
+		assert.Equal(t, ErrCredentialRevoked, VerifyCredential(ctx, cred, did.KeyResolver{}, WithStatusChecker(checker)))
+		assert.NoError(t, VerifyCredential(ctx, cred, did.KeyResolver{}))
+		assert.NoError(t, VerifyCredential(ctx, issue(t), did.KeyResolver{}, WithStatusChecker(checker)))
+
+		_, err = IssueRevocation(signer, "", 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("Receipt", func(t *testing.T) {
+		cred := issue(t)
+		var receipt did.Receipt
+		_, err := IssueRevocation(signer, "urn:example:1", 0, WithRevocationReceipt(*cred, &receipt))
+		assert.Error(t, err)
+		_, err = IssueRevocation(signer, cred.ID, 0, WithRevocationReceipt(*cred, &receipt))
+		require.NoError(t, err)
+		assert.Equal(t, did.RevocationReceipt, receipt.Kind)
+
+		data, err := json.Marshal(receipt)
+		require.NoError(t, err)
+		var decoded did.Receipt
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.NoError(t, VerifyRevocationReceipt(ctx, decoded, *cred, did.KeyResolver{}))
+
+		other := issue(t)
+		err = VerifyRevocationReceipt(ctx, decoded, *other, did.KeyResolver{})
+		assert.Equal(t, did.ErrReceiptMismatch, errors.Cause(err))
+		altered := *cred
+		altered.Claims = map[string]interface{}{"employer": "Other"}
+		err = VerifyRevocationReceipt(ctx, decoded, altered, did.KeyResolver{})
+		assert.Equal(t, did.ErrReceiptMismatch, errors.Cause(err))
+		tampered := decoded
+		tampered.Timestamp = "2020-01-01T00:00:00Z"
+		assert.Error(t, VerifyRevocationReceipt(ctx, tampered, *cred, did.KeyResolver{}))
+	})
+
+	t.Run("Invalid revocations", func(t *testing.T) {
+		rev, err := IssueRevocation(signer, "urn:example:1", 0)
+		require.NoError(t, err)
+		tampered := *rev
+		tampered.ReasonCode = 1
+		assert.Error(t, VerifyRevocation(&tampered, *issuerDoc))
+
+		// Signed by another DID.
+		otherDID, otherSigner := didKeySigner(t, 3)
+		otherDoc, err := did.ResolveDIDKey(otherDID)
+		require.NoError(t, err)
+		rev, err = IssueRevocation(otherSigner, "urn:example:1", 0)
+		require.NoError(t, err)
+		assert.NoError(t, VerifyRevocation(rev, *otherDoc))
+		assert.Error(t, VerifyRevocation(rev, *issuerDoc))
+		rev.IssuerDID = issuerDID
+		assert.Equal(t, ErrIssuerKeyMismatch, VerifyRevocation(rev, *issuerDoc))
+
+		// Signed with a key reference of the issuer that is not in its DID Document.
+		privKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+		unknownSigner, err := proof.NewEd25519Signer(privKey, did.GenerateKeyID(issuerDID, "unknown"))
+		require.NoError(t, err)
+		rev, err = IssueRevocation(unknownSigner, "urn:example:1", 0)
+		require.NoError(t, err)
+		assert.Equal(t, issuerDID, rev.IssuerDID)
+		assert.Error(t, VerifyRevocation(rev, *issuerDoc))
+		assert.Error(t, NewMemoryStatusChecker().Revoke(rev, *issuerDoc))
+	})
+
+	t.Run("Forged revocation from checker", func(t *testing.T) {
+		cred := issue(t)
+		_, otherSigner := didKeySigner(t, 3)
+		forged, err := IssueRevocation(otherSigner, cred.ID, 0)
+		require.NoError(t, err)
+		forged.IssuerDID = issuerDID
+		checker := statusCheckerFunc(func(ctx context.Context, id string) (bool, *Revocation, error) {
+			return true, forged, nil
+		})
+		err = VerifyCredential(ctx, cred, did.KeyResolver{}, WithStatusChecker(checker))
+		require.Error(t, err)
+		assert.NotEqual(t, ErrCredentialRevoked, err)
+	})
+}
+
+type statusCheckerFunc func(ctx context.Context, id string) (bool, *Revocation, error)
+
+func (f statusCheckerFunc) Status(ctx context.Context, id string) (bool, *Revocation, error) {
+	return f(ctx, id)
+}
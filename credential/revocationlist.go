@@ -0,0 +1,245 @@
+package credential
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// RevocationPurpose is the purpose of revocation lists.
+const RevocationPurpose = "revocation"
+
+var (
+	// ErrIndexOutOfRange is returned for an index that is not in the revocation list.
+	ErrIndexOutOfRange = errors.New("index is out of range of the revocation list")
+	// ErrCorruptRevocationList is returned when the bitstring of a revocation list cannot be
+	// decoded or decompressed.
+	ErrCorruptRevocationList = errors.New("revocation list is corrupt")
+	// ErrRevocationListIssuer is returned when a revocation list is not signed by the issuer of
+	// the credential that refers to it.
+	ErrRevocationListIssuer = errcode.New(errcode.SignatureInvalid, "revocation list is not signed by the credential's issuer")
+)
+
+// CredentialStatus refers to the entry of a credential in a RevocationList.
+type CredentialStatus struct {
+	// ListID is the ID of the revocation list, e.g. a URL or DID URL.
+	ListID string `json:"revocationListCredential"`
+	Index  int    `json:"revocationListIndex"`
+}
+
+// RevocationList is an issuer's signed bitstring, in which each bit is the revocation status of a
+// credential that refers to it (see CredentialStatus). It lets high-volume issuers publish the
+// status of many credentials in one small document, without revealing which credential a
+// verifier checks.
+type RevocationList struct {
+	ID string `json:"id"`
+	// IssuerDID is the DID of the issuer, whose key signs the list.
+	IssuerDID string `json:"issuer"`
+	Purpose   string `json:"purpose"`
+	// EncodedList is the gzip-compressed, base64-encoded bitstring. The first bit is the most
+	// significant bit of the first byte.
+	EncodedList  string `json:"encodedList"`
+	*proof.Proof `json:"proof,omitempty"`
+}
+
+func (l *RevocationList) GetProof() *proof.Proof {
+	return l.Proof
+}
+
+func (l *RevocationList) SetProof(p *proof.Proof) {
+	l.Proof = p
+}
+
+func (l *RevocationList) GetID() string {
+	return l.ID
+}
+
+// NewRevocationList returns a revocation list of the given size in bits, rounded up to whole
+// bytes, in which no credential is revoked. The list is signed by the signer, whose DID is the
+// issuer.
+func NewRevocationList(id string, size int, signer proof.Signer) (*RevocationList, error) {
+	if size <= 0 {
+		return nil, errors.New("revocation list size must be positive")
+	}
+	list := &RevocationList{
+		ID:        id,
+		IssuerDID: did.ExtractDIDFromKeyRef(signer.ID()),
+		Purpose:   RevocationPurpose,
+	}
+	if err := list.encode(make([]byte, (size+7)/8)); err != nil {
+		return nil, err
+	}
+	return list, list.sign(signer)
+}
+
+// Size returns the number of bits in the list.
+func (l *RevocationList) Size() (int, error) {
+	bits, err := l.decode()
+	if err != nil {
+		return 0, err
+	}
+	return len(bits) * 8, nil
+}
+
+// IsRevoked returns the revocation status at the index.
+func (l *RevocationList) IsRevoked(index int) (bool, error) {
+	bits, err := l.decode()
+	if err != nil {
+		return false, err
+	}
+	if index < 0 || index >= len(bits)*8 {
+		return false, errors.Wrapf(ErrIndexOutOfRange, "index<%d>", index)
+	}
+	return bits[index/8]&(0x80>>uint(index%8)) != 0, nil
+}
+
+// SetRevoked revokes the credential at the index, and signs the list again with the signer, which
+// must be a key of the list's issuer.
+func (l *RevocationList) SetRevoked(index int, signer proof.Signer) error {
+	if did.ExtractDIDFromKeyRef(signer.ID()) != l.IssuerDID {
+		return ErrRevocationListIssuer
+	}
+	bits, err := l.decode()
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(bits)*8 {
+		return errors.Wrapf(ErrIndexOutOfRange, "index<%d>", index)
+	}
+	bits[index/8] |= 0x80 >> uint(index%8)
+	if err := l.encode(bits); err != nil {
+		return err
+	}
+	return l.sign(signer)
+}
+
+func (l *RevocationList) sign(signer proof.Signer) error {
+	capabilities := proof.Capabilities()
+	agreed, err := proof.Negotiate(capabilities.ForKeyType(signer.Type()), capabilities)
+	if err != nil {
+		return err
+	}
+	l.Proof = nil
+	return agreed.Sign(l, signer)
+}
+
+func (l *RevocationList) encode(bits []byte) error {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(bits); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	l.EncodedList = base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	return nil
+}
+
+func (l *RevocationList) decode() ([]byte, error) {
+	compressed, err := base64.RawURLEncoding.DecodeString(l.EncodedList)
+	if err != nil {
+		return nil, errors.Wrap(ErrCorruptRevocationList, err.Error())
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, errors.Wrap(ErrCorruptRevocationList, err.Error())
+	}
+	bits, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(ErrCorruptRevocationList, err.Error())
+	}
+	return bits, nil
+}
+
+// VerifyRevocationList checks that the list is signed with a key of the issuer's DID Document
+// that is authorized to make proofs.
+func VerifyRevocationList(list *RevocationList, issuerDoc did.DIDDoc) error {
+	if list.IssuerDID != issuerDoc.ID {
+		return ErrRevocationListIssuer
+	}
+	if list.Purpose != RevocationPurpose {
+		return errors.Errorf("unsupported revocation list purpose<%s>", list.Purpose)
+	}
+	if list.Proof.IsEmpty() {
+		return errcode.Errorf(errcode.MalformedProof, "revocation list<%s> does not have a proof", list.ID)
+	}
+	if did.ExtractDIDFromKeyRef(list.Proof.GetVerificationMethod()) != list.IssuerDID {
+		return ErrRevocationListIssuer
+	}
+	return did.VerifyProofForOperation(list, issuerDoc.UnsignedDIDDoc, did.ProofOperation)
+}
+
+// RevocationListFetcher fetches revocation lists by ID, e.g. over HTTP.
+type RevocationListFetcher interface {
+	FetchRevocationList(ctx context.Context, id string) (*RevocationList, error)
+}
+
+// RevocationListFetcherFunc adapts an ordinary function into a RevocationListFetcher.
+type RevocationListFetcherFunc func(ctx context.Context, id string) (*RevocationList, error)
+
+func (f RevocationListFetcherFunc) FetchRevocationList(ctx context.Context, id string) (*RevocationList, error) {
+	return f(ctx, id)
+}
+
+type credentialKey struct{}
+
+// ContextWithCredential returns a context that carries the credential whose status is checked.
+// VerifyCredential passes it to CredentialStatusChecker.Status, so that checkers can use the
+// credential's Status and issuer.
+func ContextWithCredential(ctx context.Context, cred *Credential) context.Context {
+	return context.WithValue(ctx, credentialKey{}, cred)
+}
+
+// CredentialFromContext returns the credential whose status the context passed to
+// CredentialStatusChecker.Status asks for (see ContextWithCredential).
+func CredentialFromContext(ctx context.Context) (*Credential, bool) {
+	cred, ok := ctx.Value(credentialKey{}).(*Credential)
+	return cred, ok
+}
+
+// StatusListChecker is a CredentialStatusChecker for credentials that refer to a RevocationList.
+// It fetches the list, verifies it against the DID Document of the credential's issuer, and
+// checks the credential's bit. The credential must be in the context (see ContextWithCredential).
+// Revoked credentials do not have a Revocation.
+type StatusListChecker struct {
+	Fetcher  RevocationListFetcher
+	Resolver did.Resolver
+}
+
+func (c StatusListChecker) Status(ctx context.Context, credentialID string) (bool, *Revocation, error) {
+	cred, ok := CredentialFromContext(ctx)
+	if !ok || cred.ID != credentialID {
+		return false, nil, errors.Errorf("credential<%s> is not in the context", credentialID)
+	}
+	if cred.Status == nil {
+		return false, nil, errors.Errorf("credential<%s> does not have a status", credentialID)
+	}
+	list, err := c.Fetcher.FetchRevocationList(ctx, cred.Status.ListID)
+	if err != nil {
+		return false, nil, err
+	}
+	if list.ID != cred.Status.ListID {
+		return false, nil, errors.Errorf("fetched revocation list<%s>, expected revocation list<%s>", list.ID, cred.Status.ListID)
+	}
+	if list.IssuerDID != cred.IssuerDID {
+		return false, nil, ErrRevocationListIssuer
+	}
+	result, err := did.ResolveWithOptions(ctx, c.Resolver, list.IssuerDID, did.ResolutionOptions{AcceptDeactivated: true})
+	if err != nil {
+		return false, nil, err
+	}
+	if err := VerifyRevocationList(list, *result.DIDDoc); err != nil {
+		return false, nil, err
+	}
+	revoked, err := list.IsRevoked(cred.Status.Index)
+	return revoked, nil, err
+}
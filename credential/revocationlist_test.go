@@ -0,0 +1,163 @@
+package credential
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestRevocationList(t *testing.T) {
+	_, signer := didKeySigner(t, 1)
+	list, err := NewRevocationList("https://issuer.example.com/revocations/1", 100, signer)
+	require.NoError(t, err)
+	size, err := list.Size()
+	require.NoError(t, err)
+	assert.Equal(t, 104, size)
+
+	require.NoError(t, list.SetRevoked(0, signer))
+	require.NoError(t, list.SetRevoked(42, signer))
+	for index, expected := range map[int]bool{0: true, 1: false, 41: false, 42: true, 103: false} {
+		revoked, err := list.IsRevoked(index)
+		require.NoError(t, err)
+		assert.Equal(t, expected, revoked, index)
+	}
+
+	// Bits are numbered from the most significant bit of the first byte.
+	bits, err := list.decode()
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x80), bits[0])
+	assert.Equal(t, byte(0x20), bits[5])
+
+	for _, index := range []int{-1, 104} {
+		_, err = list.IsRevoked(index)
+		assert.Equal(t, ErrIndexOutOfRange, errors.Cause(err))
+		assert.Equal(t, ErrIndexOutOfRange, errors.Cause(list.SetRevoked(index, signer)))
+	}
+
+	_, otherSigner := didKeySigner(t, 2)
+	assert.Equal(t, ErrRevocationListIssuer, list.SetRevoked(1, otherSigner))
+
+	_, err = NewRevocationList("empty", 0, signer)
+	assert.Error(t, err)
+}
+
+func TestStatusListChecker(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, signer := didKeySigner(t, 1)
+	subjectDID, _ := didKeySigner(t, 2)
+	const listID = "https://issuer.example.com/revocations/1"
+
+	lists := make(map[string]json.RawMessage)
+	publish := func(t *testing.T, list *RevocationList) {
+		data, err := json.Marshal(list)
+		require.NoError(t, err)
+		lists[list.ID] = data
+	}
+	checker := StatusListChecker{
+		Fetcher: RevocationListFetcherFunc(func(ctx context.Context, id string) (*RevocationList, error) {
+			data, ok := lists[id]
+			if !ok {
+				return nil, errors.Errorf("revocation list<%s> not found", id)
+			}
+			var list RevocationList
+			return &list, json.Unmarshal(data, &list)
+		}),
+		Resolver: did.KeyResolver{},
+	}
+	issue := func(t *testing.T, index int) *Credential {
+		cred := &Credential{
+			IssuerDID:    issuerDID,
+			SubjectDID:   subjectDID,
+			IssuanceDate: util.FormatCanonicalTime(time.Now().Add(-time.Hour)),
+			Claims:       map[string]interface{}{"employer": "Workday"},
+			Status:       &CredentialStatus{ListID: listID, Index: index},
+		}
+		require.NoError(t, Issue(cred, signer, proof.JCSEdSignatureType))
+		return cred
+	}
+	verify := func(cred *Credential) error {
+		return VerifyCredential(ctx, cred, did.KeyResolver{}, WithStatusChecker(checker))
+	}
+
+	list, err := NewRevocationList(listID, 1024, signer)
+	require.NoError(t, err)
+	require.NoError(t, list.SetRevoked(7, signer))
+	publish(t, list)
+
+	t.Run("Revoked", func(t *testing.T) {
+		assert.NoError(t, verify(issue(t, 6)))
+		assert.Equal(t, ErrCredentialRevoked, verify(issue(t, 7)))
+
+		revoked, rev, err := checker.Status(ContextWithCredential(ctx, issue(t, 7)), "")
+		assert.Error(t, err)
+		assert.False(t, revoked)
+		assert.Nil(t, rev)
+	})
+
+	t.Run("Index out of range", func(t *testing.T) {
+		assert.Equal(t, ErrIndexOutOfRange, errors.Cause(verify(issue(t, 1024))))
+	})
+
+	t.Run("Wrong issuer", func(t *testing.T) {
+		_, otherSigner := didKeySigner(t, 3)
+		other, err := NewRevocationList(listID, 1024, otherSigner)
+		require.NoError(t, err)
+		publish(t, other)
+		defer publish(t, list)
+		assert.Equal(t, ErrRevocationListIssuer, errors.Cause(verify(issue(t, 6))))
+
+		// Signed by another DID, and presented as the issuer's.
+		other.IssuerDID = issuerDID
+		publish(t, other)
+		assert.Equal(t, ErrRevocationListIssuer, errors.Cause(verify(issue(t, 6))))
+	})
+
+	t.Run("Tampered list", func(t *testing.T) {
+		tampered := *list
+		require.NoError(t, tampered.encode(make([]byte, 128)))
+		publish(t, &tampered)
+		defer publish(t, list)
+		err := verify(issue(t, 7))
+		require.Error(t, err)
+		assert.NotEqual(t, ErrCredentialRevoked, errors.Cause(err))
+	})
+
+	t.Run("Corrupted compression", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(make([]byte, 128))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		truncated := base64.RawURLEncoding.EncodeToString(buf.Bytes()[:buf.Len()-4])
+
+		for _, encoded := range []string{"not base64!", base64.RawURLEncoding.EncodeToString([]byte("not gzip")), truncated} {
+			corrupt, err := NewRevocationList(listID, 1024, signer)
+			require.NoError(t, err)
+			corrupt.EncodedList = encoded
+			require.NoError(t, corrupt.sign(signer))
+			publish(t, corrupt)
+			assert.Equal(t, ErrCorruptRevocationList, errors.Cause(verify(issue(t, 6))), encoded)
+		}
+		publish(t, list)
+	})
+
+	t.Run("Missing status", func(t *testing.T) {
+		cred := issue(t, 0)
+		cred.Status = nil
+		cred.Proof = nil
+		require.NoError(t, Issue(cred, signer, proof.JCSEdSignatureType))
+		assert.Error(t, verify(cred))
+	})
+}
@@ -0,0 +1,104 @@
+package credential
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// schemaVersionRx matches "<major>.<minor>" and "<major>.<minor>.<patch>" versions, without
+// leading zeros.
+var schemaVersionRx = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)(?:\.(0|[1-9]\d*))?$`)
+
+// SchemaVersion is the semantic version of a ClaimSchema. Claims that are added in a minor version
+// are optional, so that credentials of the same major version share a compatible shape.
+type SchemaVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseSchemaVersion parses a "<major>.<minor>" or "<major>.<minor>.<patch>" schema version, e.g.
+// "1.2". The patch defaults to 0.
+func ParseSchemaVersion(version string) (SchemaVersion, error) {
+	matches := schemaVersionRx.FindStringSubmatch(version)
+	if matches == nil {
+		return SchemaVersion{}, errors.Errorf("invalid schema version<%s>", version)
+	}
+	var parsed SchemaVersion
+	for i, component := range []*int{&parsed.Major, &parsed.Minor, &parsed.Patch} {
+		if matches[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return SchemaVersion{}, errors.Wrapf(err, "invalid schema version<%s>", version)
+		}
+		*component = n
+	}
+	return parsed, nil
+}
+
+// CompatibilityPolicy decides whether a credential of one version of a schema satisfies another
+// version of the schema. Versions of different schemas are never compatible.
+type CompatibilityPolicy string
+
+const (
+	// ExactVersion only accepts the same version, e.g. 1.2 for 1.2.
+	ExactVersion CompatibilityPolicy = "exact"
+	// SameMajorVersion accepts any version with the same major version, e.g. 1.0 or 1.3 for 1.2.
+	SameMajorVersion CompatibilityPolicy = "same-major"
+	// AnyVersion accepts any version of the schema.
+	AnyVersion CompatibilityPolicy = "any"
+)
+
+// validate checks that the policy is known.
+func (p CompatibilityPolicy) validate() error {
+	switch p {
+	case ExactVersion, SameMajorVersion, AnyVersion:
+		return nil
+	}
+	return errors.Errorf("unknown schema compatibility policy<%s>", p)
+}
+
+// IsCompatibleWith returns true if the schema and the schema with the other ID are versions of
+// the same schema, by the same author, that are compatible under the policy. Returns an error if
+// either ID or version is malformed, or the policy is unknown.
+func (s *ClaimSchema) IsCompatibleWith(otherID string, policy CompatibilityPolicy) (bool, error) {
+	return schemaIDsCompatible(s.ID, otherID, policy)
+}
+
+// schemaIDsCompatible returns true if the schema IDs are versions of the same schema that are
+// compatible under the policy.
+func schemaIDsCompatible(id, otherID string, policy CompatibilityPolicy) (bool, error) {
+	if err := policy.validate(); err != nil {
+		return false, err
+	}
+	author, resourceID, version, err := ParseClaimSchemaID(id)
+	if err != nil {
+		return false, err
+	}
+	otherAuthor, otherResourceID, otherVersion, err := ParseClaimSchemaID(otherID)
+	if err != nil {
+		return false, err
+	}
+	if author != otherAuthor || resourceID != otherResourceID {
+		return false, nil
+	}
+	v, err := ParseSchemaVersion(version)
+	if err != nil {
+		return false, err
+	}
+	other, err := ParseSchemaVersion(otherVersion)
+	if err != nil {
+		return false, err
+	}
+	switch policy {
+	case ExactVersion:
+		return v == other, nil
+	case SameMajorVersion:
+		return v.Major == other.Major, nil
+	}
+	return true, nil
+}
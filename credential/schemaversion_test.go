@@ -0,0 +1,68 @@
+package credential
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchemaVersion(t *testing.T) {
+	for version, expected := range map[string]SchemaVersion{
+		"1.0":     {Major: 1},
+		"1.2":     {Major: 1, Minor: 2},
+		"10.20.3": {Major: 10, Minor: 20, Patch: 3},
+		"0.1":     {Minor: 1},
+	} {
+		parsed, err := ParseSchemaVersion(version)
+		require.NoError(t, err, version)
+		assert.Equal(t, expected, parsed, version)
+	}
+	for _, invalid := range []string{"", "1", "1.x", "v1.0", "01.0", "1.0.0.0", "1.-1", "1.0-beta"} {
+		_, err := ParseSchemaVersion(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSchemaCompatibility(t *testing.T) {
+	author := "did:work:abc"
+	schema := ClaimSchema{ID: ClaimSchemaID(author, "employment", "1.2")}
+	tests := []struct {
+		name                  string
+		otherID               string
+		exact, major, anyWise bool
+	}{
+		{"Same version", ClaimSchemaID(author, "employment", "1.2"), true, true, true},
+		{"Same version with patch", ClaimSchemaID(author, "employment", "1.2.0"), true, true, true},
+		{"Patch mismatch", ClaimSchemaID(author, "employment", "1.2.1"), false, true, true},
+		{"Minor mismatch", ClaimSchemaID(author, "employment", "1.0"), false, true, true},
+		{"Major mismatch", ClaimSchemaID(author, "employment", "2.2"), false, false, true},
+		{"Other schema", ClaimSchemaID(author, "payroll", "1.2"), false, false, false},
+		{"Other author", ClaimSchemaID("did:work:def", "employment", "1.2"), false, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for policy, expected := range map[CompatibilityPolicy]bool{
+				ExactVersion:     test.exact,
+				SameMajorVersion: test.major,
+				AnyVersion:       test.anyWise,
+			} {
+				compatible, err := schema.IsCompatibleWith(test.otherID, policy)
+				require.NoError(t, err, policy)
+				assert.Equal(t, expected, compatible, policy)
+			}
+		})
+	}
+
+	t.Run("Malformed", func(t *testing.T) {
+		_, err := schema.IsCompatibleWith(author+";id=employment;version=1.x", AnyVersion)
+		assert.Error(t, err)
+		_, err = schema.IsCompatibleWith(author+";id=employment;version=01.2", AnyVersion)
+		assert.Error(t, err)
+		_, err = schema.IsCompatibleWith(ClaimSchemaID(author, "employment", "1.2"), "major")
+		assert.Error(t, err)
+		malformed := ClaimSchema{ID: author + ";id=employment;version=1.2-beta"}
+		_, err = malformed.IsCompatibleWith(schema.ID, AnyVersion)
+		assert.Error(t, err)
+	})
+}
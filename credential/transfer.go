@@ -0,0 +1,143 @@
+package credential
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// TransferType is the type of TransferProof documents.
+const TransferType = "CredentialTransfer"
+
+var (
+	// ErrInvalidTransfer is returned by VerifyTransferChain when a transfer is not made by the
+	// holder of the credential at the time, or not for the credential.
+	ErrInvalidTransfer = errcode.New(errcode.SignatureInvalid, "credential transfer is not signed by the holder of the credential")
+	// ErrTransferCycle is returned by VerifyTransferChain when a credential is transferred back to
+	// one of its earlier holders.
+	ErrTransferCycle = errors.New("credential transfer chain has a cycle")
+)
+
+// TransferProof records that the holder of a credential handed it over to another DID. The first
+// holder of a credential is its subject; each transfer is signed by the holder at the time, so
+// that the chain of transfers can be audited (see VerifyTransferChain).
+type TransferProof struct {
+	Type         string `json:"type"`
+	CredentialID string `json:"credentialId"`
+	// Fingerprint is the fingerprint of the transferred credential (see Credential.Fingerprint).
+	Fingerprint string `json:"fingerprint"`
+	// FromDID is the DID of the holder that makes the transfer.
+	FromDID string `json:"from"`
+	// ToDID is the DID of the new holder.
+	ToDID string `json:"to"`
+	// Created is the RFC 3339 time of the transfer.
+	Created      string `json:"created"`
+	*proof.Proof `json:"proof,omitempty"`
+}
+
+func (t *TransferProof) GetProof() *proof.Proof {
+	return t.Proof
+}
+
+func (t *TransferProof) SetProof(p *proof.Proof) {
+	t.Proof = p
+}
+
+// Fingerprint returns a commitment to the signed credential, including its proof: the SHA-256
+// multihash of its canonical JSON as a base58 multibase string (see
+// util.DigestCanonicalMultibase).
+func (c Credential) Fingerprint() (string, error) {
+	return util.DigestCanonicalMultibase(c)
+}
+
+// TransferCredential transfers the credential from its current holder, the DID of the signer's
+// key, to the new holder. The preferred signature suite for the key is used (see
+// proof.Capabilities).
+func TransferCredential(cred Credential, toDID string, holderSigner proof.Signer) (*TransferProof, error) {
+	if err := did.ValidateDID(toDID); err != nil {
+		return nil, errors.Wrap(err, "invalid credential transfer recipient")
+	}
+	fingerprint, err := cred.Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	transfer := &TransferProof{
+		Type:         TransferType,
+		CredentialID: cred.ID,
+		Fingerprint:  fingerprint,
+		FromDID:      did.ExtractDIDFromKeyRef(holderSigner.ID()),
+		ToDID:        toDID,
+		Created:      util.FormatCanonicalTime(time.Now()),
+	}
+	capabilities := proof.Capabilities()
+	agreed, err := proof.Negotiate(capabilities.ForKeyType(holderSigner.Type()), capabilities)
+	if err != nil {
+		return nil, err
+	}
+	if err := agreed.Sign(transfer, holderSigner); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// VerifyTransferChain checks that the transfers, in order, hand the credential over from its
+// subject to the holder DID: each transfer must be for the credential, made no earlier than the
+// previous one, and signed by the holder at the time with a key of its DID Document that is
+// authorized to make proofs. Returns ErrInvalidTransfer if a transfer is made by another DID or for
+// another credential, and ErrTransferCycle if the credential comes back to an earlier holder. The
+// credential's own proof is not checked.
+func VerifyTransferChain(ctx context.Context, cred Credential, transfers []TransferProof, holderDID string, resolver did.Resolver) error {
+	terminal, err := transferChainHolder(ctx, cred, transfers, resolver)
+	if err != nil {
+		return err
+	}
+	if terminal != holderDID {
+		return errors.Errorf("credential<%s> is transferred to DID<%s>, not DID<%s>", cred.ID, terminal, holderDID)
+	}
+	return nil
+}
+
+// transferChainHolder verifies the transfers (see VerifyTransferChain), and returns the DID that
+// the credential ends at.
+func transferChainHolder(ctx context.Context, cred Credential, transfers []TransferProof, resolver did.Resolver) (string, error) {
+	fingerprint, err := cred.Fingerprint()
+	if err != nil {
+		return "", err
+	}
+	holder := cred.SubjectDID
+	holders := map[string]bool{holder: true}
+	var previous time.Time
+	verifierResolver := did.AsVerifierResolver(resolver)
+	for i := range transfers {
+		transfer := transfers[i]
+		if transfer.Type != TransferType || transfer.CredentialID != cred.ID || transfer.Fingerprint != fingerprint {
+			return "", errors.Wrapf(ErrInvalidTransfer, "transfer<%d> is not for credential<%s>", i, cred.ID)
+		}
+		if transfer.FromDID != holder || transfer.Proof.IsEmpty() ||
+			did.ExtractDIDFromKeyRef(transfer.Proof.GetVerificationMethod()) != holder {
+			return "", errors.Wrapf(ErrInvalidTransfer, "transfer<%d> is not made by holder<%s>", i, holder)
+		}
+		created, err := util.ParseRFC3339Lenient(transfer.Created)
+		if err != nil {
+			return "", errors.Wrapf(err, "transfer<%d> has an invalid time", i)
+		}
+		if created.Before(previous) {
+			return "", errors.Errorf("transfer<%d> is made before the previous transfer", i)
+		}
+		if holders[transfer.ToDID] {
+			return "", errors.Wrapf(ErrTransferCycle, "transfer<%d> to DID<%s>", i, transfer.ToDID)
+		}
+		if err := proof.VerifyWithResolver(ctx, &transfer, verifierResolver); err != nil {
+			return "", errors.Wrapf(err, "transfer<%d>", i)
+		}
+		holder, previous = transfer.ToDID, created
+		holders[holder] = true
+	}
+	return holder, nil
+}
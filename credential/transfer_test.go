@@ -0,0 +1,99 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestTransferChain(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, issuerSigner := didKeySigner(t, 1)
+	aliceDID, aliceSigner := didKeySigner(t, 2)
+	bobDID, bobSigner := didKeySigner(t, 3)
+	carolDID, carolSigner := didKeySigner(t, 4)
+
+	challenge, err := did.NewPossessionChallenge(issuerDID, time.Minute, issuerSigner)
+	require.NoError(t, err)
+	response, err := did.RespondToChallenge(*challenge, aliceSigner)
+	require.NoError(t, err)
+	cred := Credential{
+		IssuerDID:    issuerDID,
+		SubjectDID:   aliceDID,
+		Type:         []string{"OfferLetterCredential"},
+		IssuanceDate: "2020-01-01T00:00:00Z",
+		Claims:       map[string]interface{}{"employer": "Workday"},
+	}
+	require.NoError(t, IssueBound(ctx, &cred, issuerSigner, proof.JCSEdSignatureType, response, did.KeyResolver{}))
+
+	toBob, err := TransferCredential(cred, bobDID, aliceSigner)
+	require.NoError(t, err)
+	toCarol, err := TransferCredential(cred, carolDID, bobSigner)
+	require.NoError(t, err)
+	chain := []TransferProof{*toBob, *toCarol}
+	assert.NoError(t, VerifyTransferChain(ctx, cred, chain, carolDID, did.KeyResolver{}))
+	assert.NoError(t, VerifyTransferChain(ctx, cred, chain[:1], bobDID, did.KeyResolver{}))
+	assert.NoError(t, VerifyTransferChain(ctx, cred, nil, aliceDID, did.KeyResolver{}))
+	assert.Error(t, VerifyTransferChain(ctx, cred, chain, bobDID, did.KeyResolver{}))
+
+	t.Run("Signed by a non-holder", func(t *testing.T) {
+		byCarol, err := TransferCredential(cred, bobDID, carolSigner)
+		require.NoError(t, err)
+		err = VerifyTransferChain(ctx, cred, []TransferProof{*byCarol}, bobDID, did.KeyResolver{})
+		assert.Equal(t, ErrInvalidTransfer, errors.Cause(err))
+
+		forged := *toBob
+		forged.FromDID = carolDID
+		err = VerifyTransferChain(ctx, cred, []TransferProof{forged}, bobDID, did.KeyResolver{})
+		assert.Equal(t, ErrInvalidTransfer, errors.Cause(err))
+		err = VerifyTransferChain(ctx, cred, []TransferProof{*toCarol}, carolDID, did.KeyResolver{})
+		assert.Equal(t, ErrInvalidTransfer, errors.Cause(err))
+
+		tampered := *toBob
+		tampered.ToDID = carolDID
+		assert.Error(t, VerifyTransferChain(ctx, cred, []TransferProof{tampered}, carolDID, did.KeyResolver{}))
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		backToAlice, err := TransferCredential(cred, aliceDID, bobSigner)
+		require.NoError(t, err)
+		err = VerifyTransferChain(ctx, cred, []TransferProof{*toBob, *backToAlice}, aliceDID, did.KeyResolver{})
+		assert.Equal(t, ErrTransferCycle, errors.Cause(err))
+	})
+
+	t.Run("Other credential", func(t *testing.T) {
+		other := cred
+		other.Claims = map[string]interface{}{"employer": "Other"}
+		err := VerifyTransferChain(ctx, other, chain, carolDID, did.KeyResolver{})
+		assert.Equal(t, ErrInvalidTransfer, errors.Cause(err))
+	})
+
+	t.Run("Presentation", func(t *testing.T) {
+		present := func(holderSigner proof.Signer, docs ...interface{}) error {
+			raw := make([]json.RawMessage, len(docs))
+			for i, doc := range docs {
+				data, err := json.Marshal(doc)
+				require.NoError(t, err)
+				raw[i] = data
+			}
+			request := NewPresentationRequest("verifier.example.com", time.Minute)
+			response, err := request.Respond(raw, holderSigner)
+			require.NoError(t, err)
+			return request.Verify(ctx, response, did.KeyResolver{})
+		}
+		assert.NoError(t, present(aliceSigner, cred))
+		assert.NoError(t, present(carolSigner, cred, toBob, toCarol))
+		err := present(carolSigner, cred)
+		assert.Equal(t, ErrHolderBindingMismatch, errors.Cause(err))
+		assert.Error(t, present(bobSigner, cred, toBob, toCarol))
+		assert.Error(t, present(aliceSigner, cred, toBob, toCarol))
+	})
+}
@@ -0,0 +1,307 @@
+package credential
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// W3CStatusType is the type of the W3C credentialStatus that a CredentialStatus maps to.
+const W3CStatusType = "RevocationList2020Status"
+
+// ErrW3CProofNotPreserved is returned by ToW3C when the credential's proof does not survive the
+// mapping to the W3C data model, and no signer is given to re-sign it.
+var ErrW3CProofNotPreserved = errcode.New(errcode.UnsupportedSuite, "credential proof does not survive the W3C mapping")
+
+// W3CPreservedSuites returns the signature types of W3C proofs that survive FromW3C and ToW3C
+// without re-signing. Every suite signs the exact properties of the document that it is made
+// over, so the proof of a Credential, which covers "subject" and "claims", never survives; only a
+// proof over the W3C form does. Since a credential does not keep the W3C formatting, such a proof
+// survives only if its suite always canonicalizes with JCS. WorkEd25519Signature2020 and
+// Ed25519VerificationKey2018 proofs may have been made over non-canonical JSON (see
+// proof.SignatureSuites), so they are not preserved.
+func W3CPreservedSuites() []proof.SignatureType {
+	return []proof.SignatureType{proof.JCSEdSignatureType, proof.EcdsaSecp256k1SignatureType}
+}
+
+// W3COption configures ToW3C.
+type W3COption func(*w3cOptions)
+
+type w3cOptions struct {
+	signer   proof.Signer
+	contexts []string
+}
+
+// WithW3CSigner re-signs the W3C credential with the signer's key, which must belong to the
+// issuer. The preferred signature suite for the key is used (see proof.Capabilities).
+func WithW3CSigner(signer proof.Signer) W3COption {
+	return func(o *w3cOptions) {
+		o.signer = signer
+	}
+}
+
+// WithW3CContext adds @context values after the W3C base context. It has no effect on credentials
+// from FromW3C, whose @context is kept.
+func WithW3CContext(contexts ...string) W3COption {
+	return func(o *w3cOptions) {
+		o.contexts = append(o.contexts, contexts...)
+	}
+}
+
+// w3cSchema is the W3C form of a credential's schema ID.
+type w3cSchema struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// w3cStatus is the W3C form of a CredentialStatus.
+type w3cStatus struct {
+	ID             string `json:"id"`
+	Type           string `json:"type"`
+	ListIndex      string `json:"revocationListIndex"`
+	ListCredential string `json:"revocationListCredential"`
+}
+
+// ToW3C returns the credential in the W3C Verifiable Credentials 1.1 data model: the subject DID
+// and claims make up the credentialSubject, and binding and claim proofs are kept as extension
+// properties. W3C properties of a credential from FromW3C are written back as they were.
+//
+// With WithW3CSigner, the W3C credential is re-signed. Otherwise, a W3C proof from FromW3C is kept
+// if its suite is one of W3CPreservedSuites, and ErrW3CProofNotPreserved is returned if it is not,
+// or if the credential has a proof of its own.
+func ToW3C(cred Credential, opts ...W3COption) ([]byte, error) {
+	var options w3cOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	properties, err := cred.w3cProperties(options.contexts)
+	if err != nil {
+		return nil, err
+	}
+
+	w3cProof, hasW3CProof := cred.W3CExtras["proof"]
+	switch {
+	case options.signer != nil:
+		return signW3C(properties, options.signer, cred.IssuerDID)
+	case hasW3CProof:
+		var p proof.Proof
+		if err := json.Unmarshal(w3cProof, &p); err != nil {
+			return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid W3C proof")
+		}
+		if !isW3CPreserved(p.Type) {
+			return nil, errors.Wrapf(ErrW3CProofNotPreserved, "signature type<%s>", p.Type)
+		}
+		properties["proof"] = w3cProof
+	case !cred.Proof.IsEmpty():
+		return nil, errors.Wrapf(ErrW3CProofNotPreserved, "credential<%s> must be re-signed", cred.ID)
+	}
+	return json.Marshal(properties)
+}
+
+// FromW3C reads a W3C Verifiable Credential with a single credentialSubject. Properties that a
+// Credential does not model, along with the original @context, an issuer object, the
+// credentialSchema, the credentialStatus and the proof, are kept in W3CExtras for ToW3C. The proof is over the W3C
+// form, and so is not the credential's Proof.
+func FromW3C(data []byte) (*Credential, error) {
+	var properties map[string]json.RawMessage
+	if err := util.UnmarshalUseNumber(data, &properties); err != nil {
+		return nil, errors.Wrap(err, "invalid W3C credential")
+	}
+	take := func(name string, v interface{}) error {
+		raw, ok := properties[name]
+		if !ok {
+			return nil
+		}
+		delete(properties, name)
+		return errors.Wrapf(util.UnmarshalUseNumber(raw, v), "invalid W3C credential %s", name)
+	}
+
+	var context []interface{}
+	if err := json.Unmarshal(properties["@context"], &context); err != nil || len(context) == 0 || context[0] != W3Context {
+		return nil, errors.Errorf("W3C credential @context must start with %s", W3Context)
+	}
+
+	cred := new(Credential)
+	var subject map[string]interface{}
+	for name, v := range map[string]interface{}{
+		"id":                &cred.ID,
+		"type":              &cred.Type,
+		"issuanceDate":      &cred.IssuanceDate,
+		"expirationDate":    &cred.ExpirationDate,
+		"binding":           &cred.Binding,
+		"claimProofs":       &cred.ClaimProofs,
+		"cnf":               &cred.Confirmation,
+		"attachments":       &cred.Attachments,
+		"credentialSubject": &subject,
+	} {
+		if err := take(name, v); err != nil {
+			return nil, err
+		}
+	}
+	if !containsString(cred.Type, Type) {
+		return nil, errors.Errorf("W3C credential<%s> is not a %s", cred.ID, Type)
+	}
+	if subject == nil {
+		return nil, errors.Errorf("W3C credential<%s> does not have a credentialSubject", cred.ID)
+	}
+
+	if issuer := properties["issuer"]; bytes.HasPrefix(bytes.TrimSpace(issuer), []byte("{")) {
+		var object struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(issuer, &object); err != nil {
+			return nil, errors.Wrap(err, "invalid W3C credential issuer")
+		}
+		cred.IssuerDID = object.ID
+	} else if err := take("issuer", &cred.IssuerDID); err != nil {
+		return nil, err
+	}
+
+	if id, ok := subject[SubjectIDAttribute]; ok {
+		if cred.SubjectDID, ok = id.(string); !ok {
+			return nil, errors.Errorf("W3C credential<%s> subject ID is not a string", cred.ID)
+		}
+		delete(subject, SubjectIDAttribute)
+	}
+	if len(subject) > 0 {
+		cred.Claims = subject
+	}
+
+	if raw, ok := properties["credentialSchema"]; ok {
+		var schema w3cSchema
+		if err := json.Unmarshal(raw, &schema); err == nil {
+			cred.SchemaID = schema.ID
+		}
+	}
+	if raw, ok := properties["credentialStatus"]; ok {
+		var status w3cStatus
+		if err := json.Unmarshal(raw, &status); err == nil && status.Type == W3CStatusType {
+			index, err := strconv.Atoi(status.ListIndex)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid W3C credential status index<%s>", status.ListIndex)
+			}
+			cred.Status = &CredentialStatus{ListID: status.ListCredential, Index: index}
+		}
+	}
+
+	if len(properties) > 0 {
+		cred.W3CExtras = properties
+	}
+	return cred, nil
+}
+
+// w3cProperties returns the properties of the W3C form of the credential, without a proof.
+// Modeled properties take precedence over W3CExtras, except for the ones that a Credential only
+// keeps part of: @context, issuer, credentialSchema and credentialStatus.
+func (c Credential) w3cProperties(contexts []string) (map[string]interface{}, error) {
+	properties := make(map[string]interface{}, len(c.W3CExtras)+10)
+	for name, value := range c.W3CExtras {
+		if name != "proof" {
+			properties[name] = value
+		}
+	}
+
+	subject := make(map[string]interface{}, len(c.Claims)+1)
+	for name, value := range c.Claims {
+		subject[name] = value
+	}
+	if c.SubjectDID != "" {
+		if _, ok := subject[SubjectIDAttribute]; ok {
+			return nil, errors.Errorf("credential<%s> claim<%s> conflicts with the W3C subject ID", c.ID, SubjectIDAttribute)
+		}
+		subject[SubjectIDAttribute] = c.SubjectDID
+	}
+	properties["credentialSubject"] = subject
+	properties["type"] = w3cTypes(c.Type)
+	properties["issuanceDate"] = c.IssuanceDate
+	for name, value := range map[string]string{"id": c.ID, "expirationDate": c.ExpirationDate, "binding": c.Binding} {
+		if value != "" {
+			properties[name] = value
+		}
+	}
+	if len(c.ClaimProofs) > 0 {
+		properties["claimProofs"] = c.ClaimProofs
+	}
+	if c.Confirmation != nil {
+		properties["cnf"] = c.Confirmation
+	}
+	if len(c.Attachments) > 0 {
+		properties["attachments"] = c.Attachments
+	}
+
+	if _, ok := properties["@context"]; !ok {
+		properties["@context"] = append([]string{W3Context}, contexts...)
+	}
+	if _, ok := properties["issuer"]; !ok {
+		properties["issuer"] = c.IssuerDID
+	}
+	if _, ok := properties["credentialSchema"]; !ok && c.SchemaID != "" {
+		properties["credentialSchema"] = w3cSchema{ID: c.SchemaID, Type: SchemaType}
+	}
+	if _, ok := properties["credentialStatus"]; !ok && c.Status != nil {
+		properties["credentialStatus"] = w3cStatus{
+			ID:             c.Status.ListID + "#" + strconv.Itoa(c.Status.Index),
+			Type:           W3CStatusType,
+			ListIndex:      strconv.Itoa(c.Status.Index),
+			ListCredential: c.Status.ListID,
+		}
+	}
+	return properties, nil
+}
+
+// signW3C signs the W3C properties with the issuer's key, and returns the signed credential.
+func signW3C(properties map[string]interface{}, signer proof.Signer, issuerDID string) ([]byte, error) {
+	if did.ExtractDIDFromKeyRef(signer.ID()) != issuerDID {
+		return nil, ErrIssuerKeyMismatch
+	}
+	unsigned, err := json.Marshal(properties)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := proof.ParseDocument(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	capabilities := proof.Capabilities()
+	agreed, err := proof.Negotiate(capabilities.ForKeyType(signer.Type()), capabilities)
+	if err != nil {
+		return nil, err
+	}
+	if err := agreed.Sign(doc, signer); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// w3cTypes returns the types, with the W3C VerifiableCredential type first if it is missing.
+func w3cTypes(types []string) []string {
+	if containsString(types, Type) {
+		return types
+	}
+	return append([]string{Type}, types...)
+}
+
+func isW3CPreserved(sigType proof.SignatureType) bool {
+	for _, preserved := range W3CPreservedSuites() {
+		if sigType == preserved {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
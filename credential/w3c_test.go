@@ -0,0 +1,153 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestW3CFixtures(t *testing.T) {
+	read := func(t *testing.T, name string) []byte {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "w3c", name))
+		require.NoError(t, err)
+		return data
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		for _, name := range []string{"example-1.jsonld", "example-4.jsonld"} {
+			data := read(t, name)
+			cred, err := FromW3C(data)
+			require.NoError(t, err, name)
+			exported, err := ToW3C(*cred)
+			require.NoError(t, err, name)
+			diff, err := util.JSONDiff(data, exported)
+			require.NoError(t, err)
+			assert.Empty(t, diff, name)
+		}
+	})
+
+	t.Run("Mapping", func(t *testing.T) {
+		cred, err := FromW3C(read(t, "example-4.jsonld"))
+		require.NoError(t, err)
+		assert.Equal(t, "http://example.edu/credentials/1872", cred.ID)
+		assert.Equal(t, []string{Type, "UniversityDegreeCredential"}, cred.Type)
+		assert.Equal(t, "did:example:76e12ec712ebc6f1c221ebfeb1f", cred.IssuerDID)
+		assert.Equal(t, "did:example:ebfeb1f712ebc6f1c276e12ec21", cred.SubjectDID)
+		assert.Equal(t, "2020-01-01T19:23:24Z", cred.ExpirationDate)
+		assert.Equal(t, json.Number("3.80"), cred.Claims["gpa"])
+		assert.Contains(t, cred.Claims, "degree")
+		assert.Equal(t, &CredentialStatus{ListID: "https://example.edu/status/24", Index: 94567}, cred.Status)
+		assert.Contains(t, cred.W3CExtras, "evidence")
+		assert.Nil(t, cred.Proof)
+
+		// The credential survives a round trip through its own JSON.
+		data, err := json.Marshal(cred)
+		require.NoError(t, err)
+		var decoded Credential
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, cred.Claims, decoded.Claims)
+		expected, err := ToW3C(*cred)
+		require.NoError(t, err)
+		actual, err := ToW3C(decoded)
+		require.NoError(t, err)
+		diff, err := util.JSONDiff(expected, actual)
+		require.NoError(t, err)
+		assert.Empty(t, diff)
+	})
+
+	t.Run("Proof that does not survive", func(t *testing.T) {
+		cred, err := FromW3C(read(t, "example-proof.jsonld"))
+		require.NoError(t, err)
+		assert.Nil(t, cred.Proof)
+		assert.Contains(t, cred.W3CExtras, "proof")
+		_, err = ToW3C(*cred)
+		assert.Equal(t, ErrW3CProofNotPreserved, errors.Cause(err))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, invalid := range []string{
+			`[]`,
+			`{"type": ["VerifiableCredential"], "credentialSubject": {}}`,
+			`{"@context": ["https://example.com"], "type": ["VerifiableCredential"], "credentialSubject": {}}`,
+			`{"@context": ["https://www.w3.org/2018/credentials/v1"], "type": ["AlumniCredential"], "credentialSubject": {}}`,
+			`{"@context": ["https://www.w3.org/2018/credentials/v1"], "type": ["VerifiableCredential"]}`,
+			`{"@context": ["https://www.w3.org/2018/credentials/v1"], "type": ["VerifiableCredential"], "credentialSubject": [{}, {}]}`,
+			`{"@context": ["https://www.w3.org/2018/credentials/v1"], "type": ["VerifiableCredential"], "credentialSubject": {"id": 1}}`,
+		} {
+			_, err := FromW3C([]byte(invalid))
+			assert.Error(t, err, invalid)
+		}
+	})
+}
+
+func TestW3C(t *testing.T) {
+	ctx := context.Background()
+	issuerDID, signer := didKeySigner(t, 1)
+	subjectDID, otherSigner := didKeySigner(t, 2)
+	cred := &Credential{
+		IssuerDID:    issuerDID,
+		SubjectDID:   subjectDID,
+		Type:         []string{"EmploymentCredential"},
+		IssuanceDate: "2020-01-01T00:00:00Z",
+		Claims:       map[string]interface{}{"employer": "Workday", "salary": json.Number("100000")},
+		SchemaID:     ClaimSchemaID(issuerDID, "", "1.0"),
+		Status:       &CredentialStatus{ListID: "https://example.com/status/1", Index: 7},
+	}
+	require.NoError(t, Issue(cred, signer, proof.JCSEdSignatureType))
+	verifyW3C := func(data []byte) error {
+		doc, err := proof.ParseDocument(data)
+		if err != nil {
+			return err
+		}
+		return proof.VerifyWithResolver(ctx, doc, did.AsVerifierResolver(did.KeyResolver{}))
+	}
+
+	_, err := ToW3C(*cred)
+	assert.Equal(t, ErrW3CProofNotPreserved, errors.Cause(err))
+	_, err = ToW3C(*cred, WithW3CSigner(otherSigner))
+	assert.Equal(t, ErrIssuerKeyMismatch, err)
+
+	exported, err := ToW3C(*cred, WithW3CSigner(signer), WithW3CContext("https://example.com/employment/v1"))
+	require.NoError(t, err)
+	require.NoError(t, verifyW3C(exported))
+	var properties map[string]interface{}
+	require.NoError(t, json.Unmarshal(exported, &properties))
+	assert.Equal(t, []interface{}{W3Context, "https://example.com/employment/v1"}, properties["@context"])
+	assert.Equal(t, []interface{}{Type, "EmploymentCredential"}, properties["type"])
+	assert.Equal(t, map[string]interface{}{"id": subjectDID, "employer": "Workday", "salary": 100000.0}, properties["credentialSubject"])
+	assert.Equal(t, W3CStatusType, properties["credentialStatus"].(map[string]interface{})["type"])
+	assert.Equal(t, map[string]interface{}{"id": cred.SchemaID, "type": SchemaType}, properties["credentialSchema"])
+
+	// The W3C proof survives the inverse mapping, since it is made with a preserved suite.
+	imported, err := FromW3C(exported)
+	require.NoError(t, err)
+	assert.Equal(t, cred.ID, imported.ID)
+	assert.Equal(t, cred.Claims, imported.Claims)
+	assert.Equal(t, cred.SchemaID, imported.SchemaID)
+	assert.Equal(t, cred.Status, imported.Status)
+	reexported, err := ToW3C(*imported)
+	require.NoError(t, err)
+	assert.NoError(t, verifyW3C(reexported))
+
+	imported.Claims["salary"] = json.Number("200000")
+	tampered, err := ToW3C(*imported)
+	require.NoError(t, err)
+	assert.Error(t, verifyW3C(tampered))
+
+	t.Run("Subject ID claim", func(t *testing.T) {
+		conflicting := *cred
+		conflicting.Claims = map[string]interface{}{SubjectIDAttribute: "someone else"}
+		_, err := ToW3C(conflicting, WithW3CSigner(signer))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,82 @@
+package did
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// ParseAdminDID parses the admin DID from the ledger value stored under AdminDIDKey, which is a
+// JSON encoded AdminDID.
+func ParseAdminDID(ledgerValue []byte) (string, error) {
+	var adminDID AdminDID
+	if err := json.Unmarshal(ledgerValue, &adminDID); err != nil {
+		return "", fmt.Errorf("invalid admin DID ledger value: %v", err)
+	}
+	if err := ValidateDID(adminDID.ID); err != nil {
+		return "", err
+	}
+	return adminDID.ID, nil
+}
+
+// IsAdminAuthorized returns true if the proof was created with one of the admin DID Document's
+// public keys. The signature itself is not verified; see VerifyDIDDocProof and AsVerifier.
+// An error is returned if the proof is missing or its verification method is malformed.
+func IsAdminAuthorized(adminDoc DIDDoc, p *proof.Proof) (bool, error) {
+	if p.IsEmpty() {
+		return false, fmt.Errorf("proof cannot be empty")
+	}
+	return isAdminKey(adminDoc, p.GetVerificationMethod())
+}
+
+// SignAsAdmin signs the provable with the signer on behalf of the admin. Refuses to sign if the
+// signer's key is not one of the admin DID Document's public keys, since the signature would not
+// be accepted as an admin signature. Ed25519 signers create JcsEd25519Signature2020 proofs, and
+// secp256k1 signers create EcdsaSecp256k1Signature2019 proofs.
+func SignAsAdmin(provable proof.Provable, signer proof.Signer, adminDoc DIDDoc) error {
+	authorized, err := isAdminKey(adminDoc, signer.ID())
+	if err != nil {
+		return err
+	}
+	if !authorized {
+		return fmt.Errorf("signer key is not an admin key: %s", signer.ID())
+	}
+
+	suite, err := suiteForSigner(signer)
+	if err != nil {
+		return err
+	}
+	return suite.Sign(provable, signer)
+}
+
+// suiteForSigner returns the signature suite used for the signer's key type: JCS Ed25519 proofs
+// for Ed25519 signers, and EcdsaSecp256k1Signature2019 proofs for secp256k1 signers.
+func suiteForSigner(signer proof.Signer) (proof.SignatureSuite, error) {
+	switch signer.Type() {
+	case proof.Ed25519KeyType, proof.WorkEdKeyType:
+		return proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	case proof.EcdsaSecp256k1KeyType:
+		return proof.SignatureSuites().GetSuite(proof.EcdsaSecp256k1SignatureType, proof.V1)
+	}
+	return nil, fmt.Errorf("unsupported signer key type: %s", signer.Type())
+}
+
+// isAdminKey returns true if the key reference belongs to the admin DID and is one of the admin
+// DID Document's public keys.
+func isAdminKey(adminDoc DIDDoc, keyRef string) (bool, error) {
+	id, fragment, err := ParseKeyRef(keyRef)
+	if err != nil {
+		return false, err
+	}
+	if !Equal(id, adminDoc.ID) {
+		return false, nil
+	}
+	for _, keyDef := range adminDoc.PublicKey {
+		adminID, adminFragment, err := ParseKeyRef(keyDef.ID)
+		if err == nil && adminFragment == fragment && Equal(adminID, id) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,115 @@
+package did
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestParseAdminDID(t *testing.T) {
+	t.Run("Ledger value", func(t *testing.T) {
+		ledgerValue, err := json.Marshal(AdminDID{ID: testWorkDID})
+		require.NoError(t, err)
+		id, err := ParseAdminDID(ledgerValue)
+		require.NoError(t, err)
+		assert.Equal(t, testWorkDID, id)
+	})
+
+	t.Run("Invalid ledger values", func(t *testing.T) {
+		for _, value := range []string{"", `"did:work:6sYe1y3zXhmyrBkgHgAgaq"`, `{}`, `{"id":"not a did"}`, `{"id":"did:work:abc"}`} {
+			_, err := ParseAdminDID([]byte(value))
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestAdminAuthorization(t *testing.T) {
+	adminDoc, privateKeys, err := GenerateDIDDocWithKeys([]KeyMaterial{
+		{PrivateKey: issuerPrivKey, Purposes: []KeyPurpose{AuthenticationPurpose}},
+		{Purposes: []KeyPurpose{RecoveryPurpose}},
+	}, 0, proof.JCSEdSignatureType)
+	require.NoError(t, err)
+	otherDoc, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+
+	t.Run("Sign and authorize", func(t *testing.T) {
+		for i, keyDef := range adminDoc.PublicKey {
+			signer, err := AsSigner(keyDef, privateKeys[i])
+			require.NoError(t, err)
+
+			doc := DIDDoc{UnsignedDIDDoc: otherDoc.UnsignedDIDDoc}
+			require.NoError(t, SignAsAdmin(&doc, signer, *adminDoc))
+			assert.Equal(t, proof.JCSEdSignatureType, doc.Proof.Type)
+			assert.NoError(t, VerifyDIDDocProof(doc, adminDoc.UnsignedDIDDoc))
+
+			authorized, err := IsAdminAuthorized(*adminDoc, doc.Proof)
+			require.NoError(t, err)
+			assert.True(t, authorized)
+		}
+	})
+
+	t.Run("Refuses non-admin signer", func(t *testing.T) {
+		signer, err := proof.NewEd25519Signer(otherKey, otherDoc.PublicKey[0].ID)
+		require.NoError(t, err)
+		doc := DIDDoc{UnsignedDIDDoc: otherDoc.UnsignedDIDDoc}
+		assert.Error(t, SignAsAdmin(&doc, signer, *adminDoc))
+		assert.Nil(t, doc.Proof)
+
+		// Right DID, but a key that isn't published.
+		signer, err = proof.NewEd25519Signer(otherKey, adminDoc.ID+"#key-3")
+		require.NoError(t, err)
+		assert.Error(t, SignAsAdmin(&doc, signer, *adminDoc))
+
+		signer, err = proof.NewEd25519Signer(otherKey, "not a key ref")
+		require.NoError(t, err)
+		assert.Error(t, SignAsAdmin(&doc, signer, *adminDoc))
+	})
+
+	t.Run("Unauthorized proofs", func(t *testing.T) {
+		authorized, err := IsAdminAuthorized(*adminDoc, otherDoc.Proof)
+		require.NoError(t, err)
+		assert.False(t, authorized)
+
+		authorized, err = IsAdminAuthorized(*adminDoc, &proof.Proof{Type: proof.JCSEdSignatureType, VerificationMethod: adminDoc.ID + "#key-3"})
+		require.NoError(t, err)
+		assert.False(t, authorized)
+
+		_, err = IsAdminAuthorized(*adminDoc, nil)
+		assert.Error(t, err)
+		_, err = IsAdminAuthorized(*adminDoc, &proof.Proof{Type: proof.JCSEdSignatureType, VerificationMethod: adminDoc.ID})
+		assert.Error(t, err)
+	})
+
+	t.Run("secp256k1 admin key", func(t *testing.T) {
+		privateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		keyDef := KeyDef{
+			ID:              adminDoc.ID + "#key-3",
+			Type:            proof.EcdsaSecp256k1KeyType,
+			Controller:      adminDoc.ID,
+			PublicKeyBase58: base58.Encode(privateKey.PubKey().SerializeCompressed()),
+		}
+		secp256k1Admin := *adminDoc
+		secp256k1Admin.PublicKey = append(append([]KeyDef(nil), adminDoc.PublicKey...), keyDef)
+
+		signer, err := AsSigner(keyDef, privateKey.Serialize())
+		require.NoError(t, err)
+		doc := DIDDoc{UnsignedDIDDoc: otherDoc.UnsignedDIDDoc}
+		require.NoError(t, SignAsAdmin(&doc, signer, secp256k1Admin))
+		assert.Equal(t, proof.EcdsaSecp256k1SignatureType, doc.Proof.Type)
+		assert.NoError(t, VerifyDIDDocProof(doc, secp256k1Admin.UnsignedDIDDoc))
+	})
+
+	t.Run("Deactivated admin", func(t *testing.T) {
+		deactivated := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: adminDoc.ID}}
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, adminDoc.PublicKey[0].ID)
+		require.NoError(t, err)
+		doc := DIDDoc{UnsignedDIDDoc: otherDoc.UnsignedDIDDoc}
+		assert.Error(t, SignAsAdmin(&doc, signer, deactivated))
+	})
+}
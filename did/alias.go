@@ -0,0 +1,93 @@
+package did
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ErrAliasNotConfirmed is returned by VerifyAlias when the DID Documents do not list each other as
+// alsoKnownAs.
+var ErrAliasNotConfirmed = errcode.New(errcode.ResolutionFailed, "alias is not confirmed by both DID Documents")
+
+// AddAlias adds the alias to the alsoKnownAs property of the DID Document, and signs the updated
+// document with UpdateDIDDoc. The signer's key must be from the current version of the document.
+func AddAlias(current DIDDoc, alias string, signer proof.Signer, sigType proof.SignatureType) (*DIDDoc, error) {
+	if current.hasAlias(alias) {
+		return nil, fmt.Errorf("DID<%s> already has alias: %s", current.ID, alias)
+	}
+	updated := current.Clone().UnsignedDIDDoc
+	updated.AlsoKnownAs = append(updated.AlsoKnownAs, alias)
+	return UpdateDIDDoc(current, updated, signer, sigType)
+}
+
+// VerifyAlias resolves the DID Documents of both DIDs, and returns ErrAliasNotConfirmed unless
+// each document lists the other DID as alsoKnownAs. A DID cannot be confirmed as the alias of a
+// deactivated DID.
+func VerifyAlias(ctx context.Context, resolver Resolver, a, b string) error {
+	docA, err := resolveActive(ctx, resolver, a)
+	if err != nil {
+		return err
+	}
+	docB, err := resolveActive(ctx, resolver, b)
+	if err != nil {
+		return err
+	}
+	if !docA.hasAlias(b) {
+		return errors.Wrapf(ErrAliasNotConfirmed, "DID<%s> does not list %s", a, b)
+	}
+	if !docB.hasAlias(a) {
+		return errors.Wrapf(ErrAliasNotConfirmed, "DID<%s> does not list %s", b, a)
+	}
+	return nil
+}
+
+func resolveActive(ctx context.Context, resolver Resolver, did string) (*DIDDoc, error) {
+	result, err := resolver.Resolve(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if result.DocumentMetadata.Deactivated {
+		return nil, DeactivatedError{Result: result}
+	}
+	return result.DIDDoc, nil
+}
+
+// hasAlias returns true if the alias is listed as alsoKnownAs. DIDs are compared after
+// normalization.
+func (u *UnsignedDIDDoc) hasAlias(alias string) bool {
+	for _, known := range u.AlsoKnownAs {
+		if known == alias || Equal(known, alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAlsoKnownAs checks that the alsoKnownAs entries are absolute URIs, other than the
+// document's own DID, without duplicates.
+func (u *UnsignedDIDDoc) validateAlsoKnownAs() error {
+	seen := make(map[string]bool, len(u.AlsoKnownAs))
+	for _, alias := range u.AlsoKnownAs {
+		if parsed, err := url.Parse(alias); err != nil || parsed.Scheme == "" {
+			return fmt.Errorf("DID<%s> alsoKnownAs entry is not a URI: %s", u.ID, alias)
+		}
+		key := alias
+		if normalized, err := Normalize(alias); err == nil {
+			key = normalized
+		}
+		if seen[key] {
+			return fmt.Errorf("DID<%s> has duplicate alsoKnownAs entry: %s", u.ID, alias)
+		}
+		seen[key] = true
+		if Equal(alias, u.ID) {
+			return fmt.Errorf("DID<%s> cannot be an alias of itself", u.ID)
+		}
+	}
+	return nil
+}
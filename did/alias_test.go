@@ -0,0 +1,89 @@
+package did
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestAlias(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	workDoc, workKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	otherDoc, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	require.NoError(t, registry.Put(*workDoc))
+	require.NoError(t, registry.Put(*otherDoc))
+	workSigner, err := proof.NewEd25519Signer(workKey, workDoc.PublicKey[0].ID)
+	require.NoError(t, err)
+	otherSigner, err := proof.NewEd25519Signer(otherKey, otherDoc.PublicKey[0].ID)
+	require.NoError(t, err)
+
+	t.Run("Not confirmed until both documents list each other", func(t *testing.T) {
+		err := VerifyAlias(ctx, registry, workDoc.ID, otherDoc.ID)
+		assert.True(t, errors.Is(err, ErrAliasNotConfirmed))
+
+		updated, err := AddAlias(*workDoc, otherDoc.ID, workSigner, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		require.NoError(t, registry.Put(*updated))
+		err = VerifyAlias(ctx, registry, workDoc.ID, otherDoc.ID)
+		assert.True(t, errors.Is(err, ErrAliasNotConfirmed))
+
+		updated, err = AddAlias(*otherDoc, workDoc.ID, otherSigner, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		require.NoError(t, registry.Put(*updated))
+		assert.NoError(t, VerifyAlias(ctx, registry, workDoc.ID, otherDoc.ID))
+		assert.NoError(t, VerifyAlias(ctx, registry, otherDoc.ID, workDoc.ID))
+
+		_, err = AddAlias(*updated, workDoc.ID, otherSigner, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Signer must be from the current version", func(t *testing.T) {
+		_, err := AddAlias(*workDoc, "did:web:example.com", otherSigner, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown DID", func(t *testing.T) {
+		assert.Error(t, VerifyAlias(ctx, registry, workDoc.ID, GenerateDID(issuerPubKey)))
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		updated, err := AddAlias(*workDoc, "did:web:example.com", workSigner, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		jsonBytes, err := json.Marshal(updated)
+		require.NoError(t, err)
+		assert.Contains(t, string(jsonBytes), `"alsoKnownAs":["did:web:example.com"]`)
+
+		var parsed DIDDoc
+		require.NoError(t, json.Unmarshal(jsonBytes, &parsed))
+		assert.Equal(t, updated.AlsoKnownAs, parsed.AlsoKnownAs)
+		assert.NoError(t, VerifyDIDDocProof(parsed, workDoc.UnsignedDIDDoc))
+
+		// Documents signed without the property are unaffected.
+		jsonBytes, err = json.Marshal(workDoc)
+		require.NoError(t, err)
+		assert.NotContains(t, string(jsonBytes), "alsoKnownAs")
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		for _, aliases := range [][]string{
+			{""},
+			{"example.com"},
+			{workDoc.ID},
+			{"did:web:example.com", "did:web:example.com"},
+		} {
+			doc := DIDDoc{UnsignedDIDDoc: workDoc.Clone().UnsignedDIDDoc}
+			doc.AlsoKnownAs = aliases
+			assert.Error(t, doc.Validate(), aliases)
+		}
+		doc := DIDDoc{UnsignedDIDDoc: workDoc.Clone().UnsignedDIDDoc}
+		doc.AlsoKnownAs = []string{"did:web:example.com", "https://example.com/alice"}
+		assert.NoError(t, doc.Validate())
+	})
+}
@@ -0,0 +1,80 @@
+package did
+
+import (
+	"context"
+	"errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// VerifyDocsOptions configures VerifyDocs.
+type VerifyDocsOptions struct {
+	// Workers is the number of documents verified concurrently. Defaults to the number of CPUs.
+	Workers int
+}
+
+// DocVerifyResult is the outcome of verifying one DID Document with VerifyDocs.
+type DocVerifyResult struct {
+	// ID is the DID of the document.
+	ID string
+	// Valid is true if the document passed validation and, unless it is deactivated, its
+	// self-signature was verified.
+	Valid bool
+	// Deactivated is true if the document is deactivated (see UnsignedDIDDoc.Status). The proof of
+	// a deactivated document is signed by a key from the previous version, so it is not verified.
+	Deactivated bool
+	// Err is the reason the document is not valid.
+	Err error
+}
+
+// errSkipProof marks documents whose proof is not verified by VerifyDocs.
+var errSkipProof = errors.New("skip proof verification")
+
+// VerifyDocs validates the DID Documents (see Validate) and verifies their self-signatures (see
+// VerifyDIDDocProof) across a pool of workers. Signature verification is skipped for documents that
+// fail validation. The results are in input order. If the context is cancelled, the context's error
+// is returned along with the results, and documents that were not verified have the context's error
+// as their result.
+func VerifyDocs(ctx context.Context, docs []DIDDoc, opts VerifyDocsOptions) ([]DocVerifyResult, error) {
+	items := make([]proof.BatchItem, len(docs))
+	for i := range docs {
+		doc := &docs[i]
+		items[i] = proof.BatchItem{
+			Provable: doc,
+			GetVerifier: func() (proof.Verifier, error) {
+				if err := doc.Validate(); err != nil {
+					return nil, err
+				}
+				if doc.IsDeactivated() {
+					return nil, errSkipProof
+				}
+				if doc.Proof.IsEmpty() {
+					return nil, errors.New("did doc proof cannot be empty")
+				}
+				keyDef, err := GetProofCreatorKeyDef(*doc)
+				if err != nil {
+					return nil, err
+				}
+				return AsVerifier(*keyDef)
+			},
+		}
+	}
+
+	errs := proof.VerifyBatch(ctx, items, opts.Workers)
+	results := make([]DocVerifyResult, len(docs))
+	for i, err := range errs {
+		// The ID is in the result.
+		if itemErr, ok := err.(*proof.BatchItemError); ok {
+			err = itemErr.Err
+		}
+		result := DocVerifyResult{ID: docs[i].ID, Deactivated: docs[i].IsDeactivated()}
+		switch err {
+		case nil, errSkipProof:
+			result.Valid = true
+		default:
+			result.Err = err
+		}
+		results[i] = result
+	}
+	return results, ctx.Err()
+}
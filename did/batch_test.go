@@ -0,0 +1,65 @@
+package did
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestVerifyDocs(t *testing.T) {
+	docs := make([]DIDDoc, 6)
+	for i := range docs {
+		doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		docs[i] = *doc
+	}
+	active, activeKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	deactivated, err := DeactivateDIDDoc(*active, activeKey)
+	require.NoError(t, err)
+	docs[1] = *deactivated
+	docs[2].PublicKey[0].Controller = "did:work:other"
+	docs[3].ID = "not a DID"
+	docs[4].Proof = nil
+
+	results, err := VerifyDocs(context.Background(), docs, VerifyDocsOptions{Workers: 3})
+	require.NoError(t, err)
+	require.Len(t, results, len(docs))
+	for i, result := range results {
+		assert.Equal(t, docs[i].ID, result.ID)
+	}
+	assert.True(t, results[0].Valid)
+	assert.NoError(t, results[0].Err)
+	assert.True(t, results[1].Valid)
+	assert.True(t, results[1].Deactivated)
+	for _, i := range []int{2, 3, 4} {
+		assert.False(t, results[i].Valid, i)
+		assert.Error(t, results[i].Err, i)
+	}
+	assert.True(t, results[5].Valid)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = VerifyDocs(ctx, docs, VerifyDocsOptions{})
+	assert.Equal(t, context.Canceled, err)
+	for _, result := range results {
+		assert.False(t, result.Valid)
+		assert.Equal(t, context.Canceled, result.Err)
+	}
+}
+
+func BenchmarkVerifyDocs(b *testing.B) {
+	docs := make([]DIDDoc, 10000)
+	for i := range docs {
+		doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		docs[i] = *doc
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := VerifyDocs(context.Background(), docs, VerifyDocsOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package did
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultCacheTTL is how long resolved DID Documents are cached when no TTL is configured.
+	DefaultCacheTTL = 5 * time.Minute
+	// DefaultCacheMaxEntries is the cache capacity when no maximum is configured.
+	DefaultCacheMaxEntries = 1000
+)
+
+// CacheOptions configures a CachingResolver.
+type CacheOptions struct {
+	// TTL is how long an active DID Document is cached. Defaults to DefaultCacheTTL.
+	TTL time.Duration
+	// DeactivatedTTL is how long a deactivated DID Document is cached. Deactivation is permanent,
+	// so this is typically longer than TTL. Defaults to TTL.
+	DeactivatedTTL time.Duration
+	// MaxEntries is the maximum number of cached DIDs. The least recently used entry is evicted
+	// when the cache is full. Defaults to DefaultCacheMaxEntries.
+	MaxEntries int
+	// Now returns the current time. Defaults to time.Now; intended for tests.
+	Now func() time.Time
+}
+
+// CachingResolver is a Resolver that caches the results of another Resolver. Concurrent lookups
+// for the same uncached DID are coalesced into a single call to the inner Resolver, which is not
+// canceled when one of the callers gives up: each caller waits for as long as its own context
+// allows, and the result is cached for the next lookup. The call is canceled once every caller has
+// given up, so that a lookup that hangs is not joined by later callers. Every caller gets its own
+// copy of the result, so modifying a resolved DID Document does not affect the cache.
+// Errors are never cached. Past versions of DID Documents requested with ResolveWithOptions are
+// cached separately from the current version. The keys of cached DID Documents are indexed (see DocIndex), so that
+// verifiers resolved through the cache (see AsVerifierResolver) are built once per key.
+type CachingResolver struct {
+	inner Resolver
+	opts  CacheOptions
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List
+	inflight map[string]*inflightCall
+
+	hits   uint64
+	misses uint64
+}
+
+type cacheEntry struct {
+	key string
+	// did is the normalized DID (see cacheDID).
+	did     string
+	result  *ResolutionResult
+	expires time.Time
+}
+
+type inflightCall struct {
+	// did is the normalized DID (see cacheDID).
+	did    string
+	done   chan struct{}
+	result *ResolutionResult
+	err    error
+
+	// waiters is the number of callers waiting for the result, guarded by the resolver's lock.
+	waiters int
+	cancel  context.CancelFunc
+	// invalidated is set, under the resolver's lock, when the DID is invalidated while the call is
+	// in flight. The result may predate the invalidation, so it is not cached.
+	invalidated bool
+}
+
+// NewCachingResolver wraps the inner Resolver with a TTL based LRU cache.
+func NewCachingResolver(inner Resolver, opts CacheOptions) *CachingResolver {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+	if opts.DeactivatedTTL <= 0 {
+		opts.DeactivatedTTL = opts.TTL
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultCacheMaxEntries
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &CachingResolver{
+		inner:    inner,
+		opts:     opts,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		inflight: make(map[string]*inflightCall),
+	}
+}
+
+// Resolve returns the cached result for the DID if there is an unexpired one, otherwise it
+// resolves the DID with the inner Resolver and caches the result.
+func (c *CachingResolver) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	return c.ResolveWithOptions(ctx, did, ResolutionOptions{AcceptDeactivated: true})
+}
+
+// ResolveWithOptions is the same as Resolve, for the version of the DID Document selected by the
+// options (see ResolveWithOptions). Each requested version is cached under its own entry.
+func (c *CachingResolver) ResolveWithOptions(ctx context.Context, did string, opts ResolutionOptions) (*ResolutionResult, error) {
+	result, err := c.resolve(ctx, did, opts)
+	if err != nil {
+		return nil, err
+	}
+	if result.DocumentMetadata.Deactivated && !opts.AcceptDeactivated {
+		return nil, DeactivatedError{Result: result}
+	}
+	return result, nil
+}
+
+func (c *CachingResolver) resolve(ctx context.Context, did string, opts ResolutionOptions) (*ResolutionResult, error) {
+	key := cacheKey(did, opts)
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if c.opts.Now().Before(entry.expires) {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+			atomic.AddUint64(&c.hits, 1)
+			return entry.result.clone(), nil
+		}
+		c.remove(elem)
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	call, ok := c.inflight[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(detachedContext{ctx})
+		call = &inflightCall{did: cacheDID(did), done: make(chan struct{}), cancel: cancel}
+		c.inflight[key] = call
+		go c.resolveInflight(callCtx, key, did, opts, call)
+	}
+	call.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+		return call.result.clone(), nil
+	case <-ctx.Done():
+		c.abandon(key, call)
+		return nil, ctx.Err()
+	}
+}
+
+// abandon is called when a caller stops waiting for the in-flight call. The last caller to give up
+// cancels the call, and removes it so that the next lookup starts a new one.
+func (c *CachingResolver) abandon(key string, call *inflightCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	if c.inflight[key] == call {
+		delete(c.inflight, key)
+	}
+	call.cancel()
+}
+
+// resolveInflight resolves the DID with the inner Resolver for the in-flight call, and caches the
+// result.
+func (c *CachingResolver) resolveInflight(ctx context.Context, key, did string, opts ResolutionOptions, call *inflightCall) {
+	defer call.cancel()
+	// Deactivated documents are cached, and refused by ResolveWithOptions as requested.
+	innerOpts := ResolutionOptions{VersionID: opts.VersionID, VersionTime: opts.VersionTime, AcceptDeactivated: true}
+	result, err := ResolveWithOptions(ctx, c.inner, did, innerOpts)
+	if err == nil && result != nil && result.DIDDoc != nil {
+		// Cached results are used many times, so index the document's keys. The result is copied
+		// since the inner resolver may share it.
+		result = result.clone()
+		result.index = NewDocIndex(*result.DIDDoc)
+	}
+
+	c.mu.Lock()
+	// An abandoned call may have been replaced by a newer one.
+	if c.inflight[key] == call {
+		delete(c.inflight, key)
+	}
+	if err == nil && result != nil && !call.invalidated {
+		c.add(key, call.did, result)
+	}
+	c.mu.Unlock()
+	call.result, call.err = result, err
+	close(call.done)
+}
+
+// detachedContext carries the values of its parent context, but is not canceled with it, so that a
+// resolution shared by several callers is not canceled by the caller that started it.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// cacheKey identifies the cache entry for a version of a DID Document. DIDs are normalized, so that
+// every form of a DID shares its entry.
+func cacheKey(did string, opts ResolutionOptions) string {
+	did = cacheDID(did)
+	if !opts.versioned() {
+		return did
+	}
+	return did + "?versionId=" + opts.VersionID + "&versionTime=" + opts.VersionTime.UTC().Format(time.RFC3339Nano)
+}
+
+// cacheDID returns the normalized form of a DID (see Normalize). DID Keys with the legacy single
+// byte Ed25519 codec are also rewritten in the current form (see GenerateLegacyDIDKey). DIDs that
+// cannot be normalized are returned unchanged.
+func cacheDID(did string) string {
+	normalized, err := Normalize(did)
+	if err != nil {
+		return did
+	}
+	if strings.HasPrefix(normalized, KeyDIDMethod) {
+		if codec, keyBytes, err := decodeDIDKey(normalized); err == nil {
+			return KeyDIDMethod + multicodecEncode(codec, keyBytes)
+		}
+	}
+	return normalized
+}
+
+// Invalidate removes every cached version of the DID from the cache, so that the next Resolve goes
+// to the inner Resolver. The DID may be given in any of its forms (see Normalize). Lookups of the DID that are in flight still return their result to the
+// callers that are waiting for it, but the result is not cached, and later lookups do not join
+// them.
+func (c *CachingResolver) Invalidate(did string) {
+	did = cacheDID(did)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, elem := range c.entries {
+		if elem.Value.(*cacheEntry).did == did {
+			c.remove(elem)
+		}
+	}
+	for key, call := range c.inflight {
+		if call.did == did {
+			call.invalidated = true
+			delete(c.inflight, key)
+		}
+	}
+}
+
+// Hits returns the number of lookups that were served from the cache.
+func (c *CachingResolver) Hits() uint64 {
+	return atomic.LoadUint64(&c.hits)
+}
+
+// Misses returns the number of lookups that were not served from the cache.
+func (c *CachingResolver) Misses() uint64 {
+	return atomic.LoadUint64(&c.misses)
+}
+
+// Len returns the number of cached DID Document versions, including expired entries that have not been evicted.
+func (c *CachingResolver) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// add must be called with the lock held.
+func (c *CachingResolver) add(key, did string, result *ResolutionResult) {
+	ttl := c.opts.TTL
+	if result.DocumentMetadata.Deactivated {
+		ttl = c.opts.DeactivatedTTL
+	}
+	entry := &cacheEntry{key: key, did: did, result: result, expires: c.opts.Now().Add(ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.opts.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove must be called with the lock held.
+func (c *CachingResolver) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
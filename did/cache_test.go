@@ -0,0 +1,383 @@
+package did
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+// countingResolver resolves did:key DIDs and counts the calls it receives.
+type countingResolver struct {
+	calls       int32
+	release     chan struct{}
+	deactivated bool
+	err         error
+}
+
+func (r *countingResolver) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	atomic.AddInt32(&r.calls, 1)
+	if r.release != nil {
+		<-r.release
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	result, err := KeyResolver{}.Resolve(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	result.DocumentMetadata.Deactivated = r.deactivated
+	return result, nil
+}
+
+func (r *countingResolver) Calls() int {
+	return int(atomic.LoadInt32(&r.calls))
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestCachingResolver(t *testing.T) {
+	ctx := context.Background()
+	did := didKeyVectors[0].did
+
+	t.Run("Caches results until the TTL expires", func(t *testing.T) {
+		inner := &countingResolver{}
+		clock := &fakeClock{now: time.Now()}
+		cache := NewCachingResolver(inner, CacheOptions{TTL: time.Minute, Now: clock.Now})
+
+		first, err := cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		second, err := cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, inner.Calls())
+		assert.Equal(t, uint64(1), cache.Hits())
+		assert.Equal(t, uint64(1), cache.Misses())
+
+		clock.Advance(time.Minute)
+		_, err = cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.Calls())
+		assert.Equal(t, uint64(2), cache.Misses())
+	})
+
+	t.Run("Deactivated documents use their own TTL", func(t *testing.T) {
+		inner := &countingResolver{deactivated: true}
+		clock := &fakeClock{now: time.Now()}
+		cache := NewCachingResolver(inner, CacheOptions{TTL: time.Minute, DeactivatedTTL: time.Hour, Now: clock.Now})
+
+		_, err := cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		clock.Advance(30 * time.Minute)
+		_, err = cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		assert.Equal(t, 1, inner.Calls())
+
+		clock.Advance(30 * time.Minute)
+		_, err = cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.Calls())
+	})
+
+	t.Run("Invalidate", func(t *testing.T) {
+		inner := &countingResolver{}
+		cache := NewCachingResolver(inner, CacheOptions{})
+
+		_, err := cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		cache.Invalidate(did)
+		_, err = cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.Calls())
+	})
+
+	t.Run("Invalidate during an in-flight lookup", func(t *testing.T) {
+		inner := &countingResolver{release: make(chan struct{})}
+		cache := NewCachingResolver(inner, CacheOptions{})
+
+		stale := make(chan error, 1)
+		go func() {
+			_, err := cache.Resolve(ctx, did)
+			stale <- err
+		}()
+		assert.Eventually(t, func() bool { return inner.Calls() == 1 }, time.Second, time.Millisecond)
+		cache.Invalidate(did)
+
+		// A lookup after the invalidation does not join the stale one.
+		fresh := make(chan error, 1)
+		go func() {
+			_, err := cache.Resolve(ctx, did)
+			fresh <- err
+		}()
+		assert.Eventually(t, func() bool { return inner.Calls() == 2 }, time.Second, time.Millisecond)
+
+		close(inner.release)
+		assert.NoError(t, <-stale)
+		assert.NoError(t, <-fresh)
+		assert.Equal(t, 1, cache.Len())
+
+		// Only the fresh result was cached.
+		_, err := cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.Calls())
+
+		// Without a fresh lookup, the stale result is not cached at all.
+		inner = &countingResolver{release: make(chan struct{})}
+		cache = NewCachingResolver(inner, CacheOptions{})
+		go func() {
+			_, err := cache.Resolve(ctx, did)
+			stale <- err
+		}()
+		assert.Eventually(t, func() bool { return inner.Calls() == 1 }, time.Second, time.Millisecond)
+		cache.Invalidate(did)
+		close(inner.release)
+		assert.NoError(t, <-stale)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("Invalidate any form of the DID", func(t *testing.T) {
+		publicKey, _, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		didKey := GenerateDIDKey(publicKey)
+		legacyDIDKey := GenerateLegacyDIDKey(publicKey)
+		inner := &countingResolver{}
+		cache := NewCachingResolver(inner, CacheOptions{})
+
+		// The forms of the DID share a cache entry.
+		_, err = cache.Resolve(ctx, didKey)
+		require.NoError(t, err)
+		_, err = cache.Resolve(ctx, "DID:KEY:"+strings.TrimPrefix(legacyDIDKey, KeyDIDMethod))
+		require.NoError(t, err)
+		assert.Equal(t, 1, inner.Calls())
+		assert.Equal(t, 1, cache.Len())
+
+		cache.Invalidate(legacyDIDKey)
+		assert.Equal(t, 0, cache.Len())
+
+		// An in-flight lookup is not cached when another form of the DID is invalidated.
+		inner = &countingResolver{release: make(chan struct{})}
+		cache = NewCachingResolver(inner, CacheOptions{})
+		stale := make(chan error, 1)
+		go func() {
+			_, err := cache.Resolve(ctx, legacyDIDKey)
+			stale <- err
+		}()
+		assert.Eventually(t, func() bool { return inner.Calls() == 1 }, time.Second, time.Millisecond)
+		cache.Invalidate(didKey)
+		close(inner.release)
+		assert.NoError(t, <-stale)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("Evicts the least recently used entry", func(t *testing.T) {
+		inner := &countingResolver{}
+		cache := NewCachingResolver(inner, CacheOptions{MaxEntries: 2})
+
+		for _, vector := range didKeyVectors[:2] {
+			_, err := cache.Resolve(ctx, vector.did)
+			require.NoError(t, err)
+		}
+		// Touch the first entry so that the second is the least recently used.
+		_, err := cache.Resolve(ctx, didKeyVectors[0].did)
+		require.NoError(t, err)
+		_, err = cache.Resolve(ctx, didKeyVectors[2].did)
+		require.NoError(t, err)
+		assert.Equal(t, 2, cache.Len())
+		assert.Equal(t, 3, inner.Calls())
+
+		_, err = cache.Resolve(ctx, didKeyVectors[0].did)
+		require.NoError(t, err)
+		assert.Equal(t, 3, inner.Calls())
+		_, err = cache.Resolve(ctx, didKeyVectors[1].did)
+		require.NoError(t, err)
+		assert.Equal(t, 4, inner.Calls())
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		inner := &countingResolver{err: errors.New("ledger unavailable")}
+		cache := NewCachingResolver(inner, CacheOptions{})
+
+		_, err := cache.Resolve(ctx, did)
+		assert.Error(t, err)
+		_, err = cache.Resolve(ctx, did)
+		assert.Error(t, err)
+		assert.Equal(t, 2, inner.Calls())
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("Concurrent lookups are coalesced", func(t *testing.T) {
+		const callers = 50
+		inner := &countingResolver{release: make(chan struct{})}
+		cache := NewCachingResolver(inner, CacheOptions{})
+
+		var wg sync.WaitGroup
+		results := make([]*ResolutionResult, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				result, err := cache.Resolve(ctx, did)
+				assert.NoError(t, err)
+				results[i] = result
+			}(i)
+		}
+
+		// Wait for every caller to be either waiting on the in-flight call or the inner resolver.
+		assert.Eventually(t, func() bool {
+			return cache.Misses()+cache.Hits() == callers
+		}, time.Second, time.Millisecond)
+		close(inner.release)
+		wg.Wait()
+
+		assert.Equal(t, 1, inner.Calls())
+		for _, result := range results {
+			assert.Equal(t, results[0], result)
+		}
+	})
+
+	t.Run("Waiting callers stop when their context is done", func(t *testing.T) {
+		inner := &countingResolver{release: make(chan struct{})}
+		cache := NewCachingResolver(inner, CacheOptions{})
+
+		leader := make(chan error, 1)
+		go func() {
+			_, err := cache.Resolve(ctx, did)
+			leader <- err
+		}()
+		assert.Eventually(t, func() bool { return inner.Calls() == 1 }, time.Second, time.Millisecond)
+
+		waiterCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := cache.Resolve(waiterCtx, did)
+		assert.Equal(t, context.Canceled, err)
+
+		close(inner.release)
+		assert.NoError(t, <-leader)
+		assert.Equal(t, 1, inner.Calls())
+	})
+
+	t.Run("The caller that starts a lookup cannot cancel it for others", func(t *testing.T) {
+		release := make(chan struct{})
+		var calls int32
+		inner := ResolverFunc(func(ctx context.Context, did string) (*ResolutionResult, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return KeyResolver{}.Resolve(ctx, did)
+		})
+		cache := NewCachingResolver(inner, CacheOptions{})
+
+		leaderCtx, cancel := context.WithCancel(ctx)
+		leader := make(chan error, 1)
+		go func() {
+			_, err := cache.Resolve(leaderCtx, did)
+			leader <- err
+		}()
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+		waiter := make(chan error, 1)
+		go func() {
+			_, err := cache.Resolve(ctx, did)
+			waiter <- err
+		}()
+		assert.Eventually(t, func() bool { return cache.Misses() == 2 }, time.Second, time.Millisecond)
+
+		cancel()
+		assert.Equal(t, context.Canceled, <-leader)
+		close(release)
+		assert.NoError(t, <-waiter)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		assert.Equal(t, 1, cache.Len())
+	})
+
+	t.Run("A lookup is canceled once every caller has given up", func(t *testing.T) {
+		var calls int32
+		canceled := make(chan struct{}, 2)
+		inner := ResolverFunc(func(ctx context.Context, did string) (*ResolutionResult, error) {
+			if atomic.AddInt32(&calls, 1) > 1 {
+				return KeyResolver{}.Resolve(ctx, did)
+			}
+			// The first lookup hangs until it is canceled.
+			<-ctx.Done()
+			canceled <- struct{}{}
+			return nil, ctx.Err()
+		})
+		cache := NewCachingResolver(inner, CacheOptions{})
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := cache.Resolve(timeoutCtx, did)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("the abandoned lookup was not canceled")
+		}
+
+		result, err := cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		assert.Equal(t, did, result.DIDDoc.ID)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Callers get their own copy of the result", func(t *testing.T) {
+		inner := &countingResolver{}
+		cache := NewCachingResolver(inner, CacheOptions{})
+
+		first, err := cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		first.DIDDoc.PublicKey[0].PublicKeyBase58 = "tampered"
+		first.DIDDoc.Authentication = nil
+		second, err := cache.Resolve(ctx, did)
+		require.NoError(t, err)
+		assert.NotEqual(t, "tampered", second.DIDDoc.PublicKey[0].PublicKeyBase58)
+		assert.NotEmpty(t, second.DIDDoc.Authentication)
+		assert.Equal(t, 1, inner.Calls())
+	})
+
+	t.Run("Concurrent access to different DIDs", func(t *testing.T) {
+		inner := &countingResolver{}
+		cache := NewCachingResolver(inner, CacheOptions{MaxEntries: 2})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				vector := didKeyVectors[i%len(didKeyVectors)]
+				if i%10 == 0 {
+					cache.Invalidate(vector.did)
+				}
+				result, err := cache.Resolve(ctx, vector.did)
+				assert.NoError(t, err)
+				assert.Equal(t, vector.did, result.DIDDoc.ID)
+			}(i)
+		}
+		wg.Wait()
+		assert.True(t, cache.Len() <= 2)
+	})
+}
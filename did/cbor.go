@@ -0,0 +1,506 @@
+package did
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CBOR (RFC 8949) encoding of DID Documents. Documents are converted to and from their JSON form,
+// so the CBOR encoding carries exactly the same properties, including unmodeled properties (see
+// UnsignedDIDDoc.Extras). Proofs are computed over canonical JSON, so a document decoded from
+// CBOR can be verified as usual, e.g. with VerifyDIDDocProof.
+//
+// The encoding is deterministic (RFC 8949 Section 4.2): integers and lengths use their shortest
+// form, indefinite lengths are not used, and map keys are sorted by their encoded bytes.
+//
+// Numbers never lose precision, so that proofs over properties that are not canonicalized, such
+// as unmodeled properties, still verify. Integers are encoded as CBOR integers, or as bignums
+// (tags 2 and 3) beyond 64 bits. Other numbers are encoded as 64-bit floats if that keeps their
+// JSON text, e.g. 1.5, and as decimal fractions (tag 4) otherwise, e.g. 1.50. Integers and
+// decimals decode to their original text, except for the sign of a negative zero decimal such as
+// -0.0; numbers with an exponent decode to the same value.
+
+// MarshalCBOR returns the deterministic CBOR encoding of the DID Document.
+func (d DIDDoc) MarshalCBOR() ([]byte, error) {
+	return jsonToCBOR(d)
+}
+
+// UnmarshalCBOR decodes a CBOR encoded DID Document. See MarshalCBOR.
+func (d *DIDDoc) UnmarshalCBOR(data []byte) error {
+	return cborToJSON(data, d)
+}
+
+// MarshalCBOR returns the deterministic CBOR encoding of the key definition.
+func (k KeyDef) MarshalCBOR() ([]byte, error) {
+	return jsonToCBOR(k)
+}
+
+// UnmarshalCBOR decodes a CBOR encoded key definition. See MarshalCBOR.
+func (k *KeyDef) UnmarshalCBOR(data []byte) error {
+	return cborToJSON(data, k)
+}
+
+const (
+	cborUnsigned byte = 0 << 5
+	cborNegative byte = 1 << 5
+	cborBytes    byte = 2 << 5
+	cborText     byte = 3 << 5
+	cborArray    byte = 4 << 5
+	cborMap      byte = 5 << 5
+	cborTag      byte = 6 << 5
+	cborSimple   byte = 7 << 5
+
+	cborPositiveBignum  = 2
+	cborNegativeBignum  = 3
+	cborDecimalFraction = 4
+
+	cborFalse   = cborSimple | 20
+	cborTrue    = cborSimple | 21
+	cborNull    = cborSimple | 22
+	cborFloat16 = cborSimple | 25
+	cborFloat32 = cborSimple | 26
+	cborFloat64 = cborSimple | 27
+
+	// cborMaxDepth bounds the nesting of decoded arrays and maps.
+	cborMaxDepth = 64
+	// cborMaxLeadingZeros bounds the size of decoded decimal fractions: smaller fractions decode
+	// in exponent form, e.g. 5e-100 rather than 0.000…05.
+	cborMaxLeadingZeros = 64
+)
+
+// jsonNumberRx splits a JSON number into its sign, integer digits, fraction digits and exponent.
+var jsonNumberRx = regexp.MustCompile(`^(-?)(0|[1-9][0-9]*)(?:\.([0-9]+))?(?:[eE]([+-]?[0-9]+))?$`)
+
+func jsonToCBOR(v interface{}) ([]byte, error) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := encodeCBOR(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func cborToJSON(data []byte, v interface{}) error {
+	d := cborDecoder{data: data}
+	value, err := d.decode(0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("cbor: unexpected data after value")
+	}
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonBytes, v)
+}
+
+func encodeCBOR(buf *bytes.Buffer, v interface{}) error {
+	switch value := v.(type) {
+	case nil:
+		buf.WriteByte(cborNull)
+	case bool:
+		if value {
+			buf.WriteByte(cborTrue)
+		} else {
+			buf.WriteByte(cborFalse)
+		}
+	case string:
+		writeCBORHead(buf, cborText, uint64(len(value)))
+		buf.WriteString(value)
+	case json.Number:
+		return encodeCBORNumber(buf, value)
+	case []interface{}:
+		writeCBORHead(buf, cborArray, uint64(len(value)))
+		for _, elem := range value {
+			if err := encodeCBOR(buf, elem); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		type entry struct {
+			key   []byte
+			value interface{}
+		}
+		entries := make([]entry, 0, len(value))
+		for k, elem := range value {
+			var key bytes.Buffer
+			writeCBORHead(&key, cborText, uint64(len(k)))
+			key.WriteString(k)
+			entries = append(entries, entry{key: key.Bytes(), value: elem})
+		}
+		sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+		writeCBORHead(buf, cborMap, uint64(len(entries)))
+		for _, e := range entries {
+			buf.Write(e.key)
+			if err := encodeCBOR(buf, e.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cbor: unsupported type %T", v)
+	}
+	return nil
+}
+
+// encodeCBORNumber encodes a JSON number without losing precision (see the encoding above).
+func encodeCBORNumber(buf *bytes.Buffer, n json.Number) error {
+	parts := jsonNumberRx.FindStringSubmatch(string(n))
+	if parts == nil {
+		return fmt.Errorf("cbor: invalid number %q", n)
+	}
+	sign, integer, fraction, exponent := parts[1], parts[2], parts[3], parts[4]
+	negativeZero := sign == "-" && strings.Trim(integer+fraction, "0") == ""
+	if fraction == "" && exponent == "" && !negativeZero {
+		i, _ := new(big.Int).SetString(sign+integer, 10)
+		writeCBORInteger(buf, i)
+		return nil
+	}
+	if f, ok := exactFloat(string(n)); ok {
+		buf.WriteByte(cborFloat64)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+		return nil
+	}
+
+	exp := int64(0)
+	if exponent != "" {
+		var err error
+		if exp, err = strconv.ParseInt(exponent, 10, 32); err != nil {
+			return fmt.Errorf("cbor: exponent of number %q out of range", n)
+		}
+	}
+	exp -= int64(len(fraction))
+	if exp < math.MinInt32 {
+		return fmt.Errorf("cbor: exponent of number %q out of range", n)
+	}
+	mantissa, _ := new(big.Int).SetString(sign+integer+fraction, 10)
+	writeCBORHead(buf, cborTag, cborDecimalFraction)
+	writeCBORHead(buf, cborArray, 2)
+	writeCBORInteger(buf, big.NewInt(exp))
+	writeCBORInteger(buf, mantissa)
+	return nil
+}
+
+// exactFloat returns the number as a 64-bit float if the float formats to the same text.
+func exactFloat(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil && strconv.FormatFloat(f, 'g', -1, 64) == s
+}
+
+// writeCBORInteger writes an integer in its shortest form: a CBOR integer if it fits in 64 bits,
+// and a bignum otherwise.
+func writeCBORInteger(buf *bytes.Buffer, i *big.Int) {
+	major, tag, n := cborUnsigned, uint64(cborPositiveBignum), i
+	if i.Sign() < 0 {
+		// Negative integers are encoded as -1 - n.
+		major, tag, n = cborNegative, cborNegativeBignum, new(big.Int).Sub(new(big.Int).Neg(i), big.NewInt(1))
+	}
+	if n.IsUint64() {
+		writeCBORHead(buf, major, n.Uint64())
+		return
+	}
+	writeCBORHead(buf, cborTag, tag)
+	magnitude := n.Bytes()
+	writeCBORHead(buf, cborBytes, uint64(len(magnitude)))
+	buf.Write(magnitude)
+}
+
+// writeCBORHead writes the initial byte and argument of a data item in its shortest form.
+func writeCBORHead(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(major | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(major | 27)
+		_ = binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+// cborDecoder decodes the subset of CBOR that maps onto JSON: integers, bignums, floats, decimal
+// fractions, text strings, arrays, maps with text keys, booleans and null.
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.New("cbor: maximum nesting depth exceeded")
+	}
+	if d.pos >= len(d.data) {
+		return nil, errors.New("cbor: unexpected end of data")
+	}
+	initial := d.data[d.pos]
+	major, info := initial&0xe0, initial&0x1f
+	if major == cborSimple {
+		return d.decodeSimple(info)
+	}
+	d.pos++
+	n, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		return json.Number(strconv.FormatUint(n, 10)), nil
+	case cborNegative:
+		return json.Number(negativeInteger(n).String()), nil
+	case cborText:
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, errors.New("cbor: unexpected end of data")
+		}
+		s := string(d.data[d.pos : d.pos+int(n)])
+		d.pos += int(n)
+		return s, nil
+	case cborArray:
+		// Every item is at least one byte, which bounds the allocation.
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, errors.New("cbor: unexpected end of data")
+		}
+		values := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case cborMap:
+		if n > uint64(len(d.data)-d.pos)/2 {
+			return nil, errors.New("cbor: unexpected end of data")
+		}
+		values := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, errors.New("cbor: map keys must be text strings")
+			}
+			if _, exists := values[k]; exists {
+				return nil, fmt.Errorf("cbor: duplicate map key %q", k)
+			}
+			if values[k], err = d.decode(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case cborTag:
+		switch n {
+		case cborPositiveBignum, cborNegativeBignum:
+			i, err := d.bignum(n)
+			if err != nil {
+				return nil, err
+			}
+			return json.Number(i.String()), nil
+		case cborDecimalFraction:
+			return d.decimalFraction()
+		}
+		return nil, fmt.Errorf("cbor: unsupported tag %d", n)
+	}
+	return nil, fmt.Errorf("cbor: unsupported major type %d", major>>5)
+}
+
+// negativeInteger returns the value -1 - n of a CBOR negative integer.
+func negativeInteger(n uint64) *big.Int {
+	i := new(big.Int).SetUint64(n)
+	return i.Sub(i.Neg(i), big.NewInt(1))
+}
+
+// bignum reads the byte string of a bignum with the tag.
+func (d *cborDecoder) bignum(tag uint64) (*big.Int, error) {
+	if d.pos >= len(d.data) || d.data[d.pos]&0xe0 != cborBytes {
+		return nil, errors.New("cbor: bignum must be a byte string")
+	}
+	info := d.data[d.pos] & 0x1f
+	d.pos++
+	n, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errors.New("cbor: unexpected end of data")
+	}
+	i := new(big.Int).SetBytes(d.data[d.pos : d.pos+int(n)])
+	d.pos += int(n)
+	if tag == cborNegativeBignum {
+		i.Sub(i.Neg(i), big.NewInt(1))
+	}
+	return i, nil
+}
+
+// integer reads an integer or a bignum.
+func (d *cborDecoder) integer() (*big.Int, error) {
+	if d.pos >= len(d.data) {
+		return nil, errors.New("cbor: unexpected end of data")
+	}
+	major, info := d.data[d.pos]&0xe0, d.data[d.pos]&0x1f
+	d.pos++
+	n, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case major == cborUnsigned:
+		return new(big.Int).SetUint64(n), nil
+	case major == cborNegative:
+		return negativeInteger(n), nil
+	case major == cborTag && (n == cborPositiveBignum || n == cborNegativeBignum):
+		return d.bignum(n)
+	}
+	return nil, errors.New("cbor: expected an integer")
+}
+
+// decimalFraction reads the exponent and mantissa of a decimal fraction, and returns the number in
+// decimal form, e.g. 1.50. The number is returned in exponent form if the exponent is positive,
+// e.g. 15e2, or if the decimal form would be encoded as a float, e.g. 25e-4 rather than 0.0025, so
+// that the number encodes to the same decimal fraction again.
+func (d *cborDecoder) decimalFraction() (interface{}, error) {
+	if d.pos >= len(d.data) || d.data[d.pos] != cborArray|2 {
+		return nil, errors.New("cbor: decimal fraction must be an array of two integers")
+	}
+	d.pos++
+	exponent, err := d.integer()
+	if err != nil {
+		return nil, err
+	}
+	if !exponent.IsInt64() || exponent.Int64() < math.MinInt32 || exponent.Int64() > math.MaxInt32 {
+		return nil, errors.New("cbor: decimal fraction exponent out of range")
+	}
+	mantissa, err := d.integer()
+	if err != nil {
+		return nil, err
+	}
+	exp := int(exponent.Int64())
+	sign := ""
+	if mantissa.Sign() < 0 {
+		sign = "-"
+	}
+	digits := new(big.Int).Abs(mantissa).String()
+	var decimal string
+	switch {
+	case exp >= 0:
+	case -exp < len(digits):
+		point := len(digits) + exp
+		decimal = sign + digits[:point] + "." + digits[point:]
+	case -exp-len(digits) <= cborMaxLeadingZeros:
+		decimal = sign + "0." + strings.Repeat("0", -exp-len(digits)) + digits
+	}
+	if _, isFloat := exactFloat(decimal); decimal != "" && !isFloat {
+		return json.Number(decimal), nil
+	}
+	// Floats are formatted with a lowercase exponent, so an uppercase one is never a float.
+	exponentForm := sign + digits + "e" + strconv.Itoa(exp)
+	if _, isFloat := exactFloat(exponentForm); isFloat {
+		exponentForm = sign + digits + "E" + strconv.Itoa(exp)
+	}
+	return json.Number(exponentForm), nil
+}
+
+func (d *cborDecoder) decodeSimple(info byte) (interface{}, error) {
+	d.pos++
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22:
+		return nil, nil
+	case 25, 26, 27:
+		size := 2 << (info - 25)
+		if size > len(d.data)-d.pos {
+			return nil, errors.New("cbor: unexpected end of data")
+		}
+		raw := d.data[d.pos : d.pos+size]
+		d.pos += size
+		var f float64
+		switch size {
+		case 2:
+			f = float16ToFloat64(binary.BigEndian.Uint16(raw))
+		case 4:
+			f = float64(math.Float32frombits(binary.BigEndian.Uint32(raw)))
+		default:
+			f = math.Float64frombits(binary.BigEndian.Uint64(raw))
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, errors.New("cbor: non-finite numbers are not supported")
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	}
+	return nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+}
+
+// argument reads the argument of a data item. Indefinite lengths are rejected.
+func (d *cborDecoder) argument(info byte) (uint64, error) {
+	if info < 24 {
+		return uint64(info), nil
+	}
+	if info > 27 {
+		return 0, errors.New("cbor: indefinite lengths are not supported")
+	}
+	size := 1 << (info - 24)
+	if size > len(d.data)-d.pos {
+		return 0, errors.New("cbor: unexpected end of data")
+	}
+	var n uint64
+	for _, b := range d.data[d.pos : d.pos+size] {
+		n = n<<8 | uint64(b)
+	}
+	d.pos += size
+	return n, nil
+}
+
+// float16ToFloat64 converts an IEEE 754 half-precision float.
+func float16ToFloat64(h uint16) float64 {
+	exponent := int(h>>10) & 0x1f
+	mantissa := float64(h & 0x3ff)
+	var f float64
+	switch exponent {
+	case 0:
+		f = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
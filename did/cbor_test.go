@@ -0,0 +1,176 @@
+package did
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestDIDDocCBOR(t *testing.T) {
+	t.Run("Round trip keeps the proof valid", func(t *testing.T) {
+		keys := []KeyMaterial{
+			{PrivateKey: issuerPrivKey, Purposes: []KeyPurpose{AuthenticationPurpose, AssertionMethodPurpose}},
+			{Purposes: []KeyPurpose{RecoveryPurpose}},
+			{Purposes: []KeyPurpose{KeyAgreementPurpose}},
+		}
+		generated, _, err := GenerateDIDDocWithKeys(keys, 0, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+
+		for name, doc := range map[string]DIDDoc{"generated": *generated, "extended": parseExtendedDIDDoc(t)} {
+			encoded, err := doc.MarshalCBOR()
+			require.NoError(t, err, name)
+
+			var decoded DIDDoc
+			require.NoError(t, decoded.UnmarshalCBOR(encoded), name)
+			assert.NoError(t, VerifyDIDDocProof(decoded, decoded.UnsignedDIDDoc), name)
+			assert.True(t, ContentEquals(doc, decoded), name)
+			assert.Equal(t, doc.Extras, decoded.Extras, name)
+
+			// Deterministic: re-encoding the decoded document gives the same bytes.
+			reencoded, err := decoded.MarshalCBOR()
+			require.NoError(t, err, name)
+			assert.Equal(t, encoded, reencoded, name)
+
+			jsonBytes, err := json.Marshal(doc)
+			require.NoError(t, err, name)
+			t.Logf("%s DID Document: %d bytes JSON, %d bytes CBOR", name, len(jsonBytes), len(encoded))
+			assert.Less(t, len(encoded), len(jsonBytes), name)
+		}
+	})
+
+	t.Run("Key definition", func(t *testing.T) {
+		keyDef := KeyDef{
+			ID:              testWorkDID + "#key-1",
+			Type:            proof.Ed25519KeyType,
+			Controller:      testWorkDID,
+			PublicKeyBase58: "4CcKDtU1JNGi8U4D8Rv9CHzfmF7xzaxEAPFA54eQjRHF",
+			Extras:          map[string]json.RawMessage{"vendor:slot": json.RawMessage(`-3`)},
+		}
+		encoded, err := keyDef.MarshalCBOR()
+		require.NoError(t, err)
+		var decoded KeyDef
+		require.NoError(t, decoded.UnmarshalCBOR(encoded))
+		assert.Equal(t, keyDef, decoded)
+	})
+
+	t.Run("Deterministic encoding", func(t *testing.T) {
+		encoded, err := jsonToCBOR(map[string]interface{}{"bb": 1, "a": -500, "c": []interface{}{true, nil, 1.5}})
+		require.NoError(t, err)
+		// Map keys are ordered by their encoded bytes, so shorter keys come first.
+		assert.Equal(t, "a3"+"6161"+"3901f3"+"6163"+"83f5f6fb3ff8000000000000"+"626262"+"01", hex.EncodeToString(encoded))
+	})
+
+	t.Run("Numbers keep their precision", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			"9223372036854775807":                 "9223372036854775807",
+			"9223372036854775808":                 "9223372036854775808",  // 2^63
+			"18446744073709551615":                "18446744073709551615", // 2^64-1
+			"18446744073709551616":                "18446744073709551616", // 2^64
+			"-9223372036854775809":                "-9223372036854775809",
+			"-18446744073709551616":               "-18446744073709551616", // -2^64
+			"-18446744073709551617":               "-18446744073709551617",
+			"123456789012345678901234567890":      "123456789012345678901234567890",
+			"-0":                                  "-0",
+			"1.5":                                 "1.5",
+			"1.50":                                "1.50",
+			"-0.05":                               "-0.05",
+			"0.1000000000000000000001":            "0.1000000000000000000001",
+			"1e5":                                 "1e5",
+			"1.5E+3":                              "15e2",
+			"2.5e-3":                              "25e-4",
+			"5e-100":                              "5e-100",
+			"0." + strings.Repeat("0", 70) + "5":  "5E-71",
+			"0." + strings.Repeat("0", 70) + "50": "50e-72",
+		} {
+			encoded, err := jsonToCBOR(json.Number(input))
+			require.NoError(t, err, input)
+			d := cborDecoder{data: encoded}
+			value, err := d.decode(0)
+			require.NoError(t, err, input)
+			assert.Equal(t, json.Number(expected), value, input)
+
+			// Deterministic: the decoded number encodes to the same bytes.
+			reencoded, err := jsonToCBOR(value)
+			require.NoError(t, err, input)
+			assert.Equal(t, encoded, reencoded, input)
+		}
+
+		for input, expected := range map[string]string{
+			"9223372036854775808":   "1b8000000000000000",
+			"18446744073709551615":  "1bffffffffffffffff",
+			"18446744073709551616":  "c249010000000000000000",
+			"-18446744073709551617": "c349010000000000000000",
+			"1.50":                  "c482211896",
+		} {
+			encoded, err := jsonToCBOR(json.Number(input))
+			require.NoError(t, err, input)
+			assert.Equal(t, expected, hex.EncodeToString(encoded), input)
+		}
+
+		keyDef := KeyDef{
+			ID:              testWorkDID + "#key-1",
+			Type:            proof.Ed25519KeyType,
+			Controller:      testWorkDID,
+			PublicKeyBase58: "4CcKDtU1JNGi8U4D8Rv9CHzfmF7xzaxEAPFA54eQjRHF",
+			Extras: map[string]json.RawMessage{
+				"vendor:serial":  json.RawMessage(`18446744073709551615`),
+				"vendor:counter": json.RawMessage(`9223372036854775808`),
+				"vendor:weight":  json.RawMessage(`0.1000000000000000000001`),
+			},
+		}
+		encoded, err := keyDef.MarshalCBOR()
+		require.NoError(t, err)
+		var decoded KeyDef
+		require.NoError(t, decoded.UnmarshalCBOR(encoded))
+		assert.Equal(t, keyDef, decoded)
+	})
+
+	t.Run("Malformed input", func(t *testing.T) {
+		for name, input := range map[string]string{
+			"empty":              "",
+			"truncated":          "a16161",
+			"trailing data":      "a0a0",
+			"indefinite length":  "bf",
+			"byte string":        "40",
+			"non-text key":       "a10101",
+			"duplicate key":      "a2616101616102",
+			"huge array":         "9bffffffffffffffff",
+			"huge map":           "bbffffffffffffffff",
+			"non-finite float":   "f97c00",
+			"unsupported simple": "f7",
+			"unsupported tag":    "c074",
+			"bignum text":        "c261",
+			"truncated bignum":   "c24201",
+			"short fraction":     "c48121",
+			"fraction exponent":  "c4821b000000010000000001",
+		} {
+			data, err := hex.DecodeString(input)
+			require.NoError(t, err, name)
+			var doc DIDDoc
+			assert.Error(t, doc.UnmarshalCBOR(data), name)
+		}
+	})
+
+	t.Run("Half precision floats", func(t *testing.T) {
+		for input, expected := range map[string]string{"f93c00": "1", "f9c400": "-4", "f90001": "5.960464477539063e-08", "f93e00": "1.5"} {
+			data, err := hex.DecodeString(input)
+			require.NoError(t, err)
+			d := cborDecoder{data: data}
+			value, err := d.decode(0)
+			require.NoError(t, err)
+			assert.Equal(t, json.Number(expected), value, input)
+		}
+	})
+}
+
+func parseExtendedDIDDoc(t *testing.T) DIDDoc {
+	var doc DIDDoc
+	require.NoError(t, json.Unmarshal([]byte(extendedDIDDocJSON), &doc))
+	return doc
+}
@@ -0,0 +1,203 @@
+package did
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+var (
+	// ErrNonceReuse is returned when the proof of a DID Document version has the same nonce as the
+	// proof of an earlier version. A replayed version cannot otherwise be told apart from a
+	// legitimate update.
+	ErrNonceReuse = errcode.New(errcode.Replay, "proof nonce has already been used by the DID")
+	// ErrMissingNonce is returned when the proof of a DID Document version has no nonce, unless
+	// AllowMissingNonces is given to VerifyUpdateChain or NewMemoryRegistry.
+	ErrMissingNonce = errcode.New(errcode.MalformedProof, "proof has no nonce")
+)
+
+// NonceHistory records the proof nonces of the versions of each DID Document. Ledger-backed
+// deployments can implement it over their own storage; MemoryNonceHistory keeps the nonces in
+// memory.
+type NonceHistory interface {
+	// Seen returns true if the nonce has been recorded for the DID.
+	Seen(did, nonce string) (bool, error)
+	// Record adds the nonce to the DID's history.
+	Record(did, nonce string) error
+}
+
+// MemoryNonceHistory is an in-memory NonceHistory. DIDs are compared after normalization (see
+// Normalize). It is safe for concurrent use.
+type MemoryNonceHistory struct {
+	mu     sync.RWMutex
+	nonces map[string]map[string]struct{}
+}
+
+// NewMemoryNonceHistory returns an empty MemoryNonceHistory.
+func NewMemoryNonceHistory() *MemoryNonceHistory {
+	return &MemoryNonceHistory{nonces: make(map[string]map[string]struct{})}
+}
+
+func (h *MemoryNonceHistory) Seen(did, nonce string) (bool, error) {
+	key, err := Normalize(did)
+	if err != nil {
+		return false, err
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, seen := h.nonces[key][nonce]
+	return seen, nil
+}
+
+func (h *MemoryNonceHistory) Record(did, nonce string) error {
+	key, err := Normalize(did)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.nonces[key] == nil {
+		h.nonces[key] = make(map[string]struct{})
+	}
+	h.nonces[key][nonce] = struct{}{}
+	return nil
+}
+
+// pendingNonceHistory records nonces without adding them to the underlying history until they
+// are committed. Nonces are seen if they are in either.
+type pendingNonceHistory struct {
+	history NonceHistory
+	pending *MemoryNonceHistory
+	records [][2]string
+}
+
+func newPendingNonceHistory(history NonceHistory) *pendingNonceHistory {
+	return &pendingNonceHistory{history: history, pending: NewMemoryNonceHistory()}
+}
+
+func (h *pendingNonceHistory) Seen(did, nonce string) (bool, error) {
+	if seen, err := h.pending.Seen(did, nonce); err != nil || seen {
+		return seen, err
+	}
+	return h.history.Seen(did, nonce)
+}
+
+func (h *pendingNonceHistory) Record(did, nonce string) error {
+	if err := h.pending.Record(did, nonce); err != nil {
+		return err
+	}
+	h.records = append(h.records, [2]string{did, nonce})
+	return nil
+}
+
+// commit adds the pending nonces to the underlying history, in the order they were recorded.
+func (h *pendingNonceHistory) commit() error {
+	for _, record := range h.records {
+		if err := h.history.Record(record[0], record[1]); err != nil {
+			return err
+		}
+	}
+	h.records = nil
+	return nil
+}
+
+// ChainOption configures VerifyUpdateChain.
+type ChainOption func(*chainOptions)
+
+type chainOptions struct {
+	history            NonceHistory
+	allowMissingNonces bool
+}
+
+// WithNonceHistory checks the nonces of the chain against the history, and records the nonces of
+// the versions once the whole chain has been verified, so that a chain that fails verification
+// leaves the history unchanged. By default, nonces are only checked against the other versions
+// in the chain.
+func WithNonceHistory(history NonceHistory) ChainOption {
+	return func(o *chainOptions) {
+		o.history = history
+	}
+}
+
+// AllowMissingNonces accepts versions whose proof has no nonce, as written by some legacy
+// clients. Each such version is reported as a warning in the ChainResult instead of failing
+// with ErrMissingNonce.
+func AllowMissingNonces() ChainOption {
+	return func(o *chainOptions) {
+		o.allowMissingNonces = true
+	}
+}
+
+// ChainResult is the outcome of a successful VerifyUpdateChain.
+type ChainResult struct {
+	// Warnings describes problems that did not fail verification, such as missing nonces.
+	Warnings []string
+}
+
+// VerifyUpdateChain verifies the versions of a DID Document, oldest first, as the ledger would
+// have accepted them: the first version must be self-signed, each later version must have the
+// same DID and be signed by a key from the version before it that may authorize the change (see
+// UpdateOperationFor), and no version may follow a deactivated one. The proof nonce of every version must differ from the nonces of the earlier
+// versions, otherwise ErrNonceReuse is returned.
+func VerifyUpdateChain(versions []DIDDoc, opts ...ChainOption) (*ChainResult, error) {
+	options := chainOptions{history: NewMemoryNonceHistory()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("update chain is empty")
+	}
+
+	history := newPendingNonceHistory(options.history)
+	var result ChainResult
+	for i, doc := range versions {
+		signingDoc := doc.UnsignedDIDDoc
+		if i > 0 {
+			previous := versions[i-1]
+			if !Equal(doc.ID, previous.ID) {
+				return nil, fmt.Errorf("version %d DID<%s> does not match DID<%s>", i, doc.ID, previous.ID)
+			}
+			if previous.IsDeactivated() {
+				return nil, errors.Wrapf(ErrDeactivated, "version %d of DID<%s>", i, doc.ID)
+			}
+			signingDoc = previous.UnsignedDIDDoc
+		}
+		if err := VerifyDIDDocProofForOperation(doc, signingDoc, UpdateOperationFor(signingDoc, doc.UnsignedDIDDoc)); err != nil {
+			return nil, errors.Wrapf(err, "version %d of DID<%s>", i, doc.ID)
+		}
+		warning, err := checkNonce(history, doc, options.allowMissingNonces)
+		if err != nil {
+			return nil, errors.Wrapf(err, "version %d", i)
+		}
+		if warning != "" {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("version %d: %s", i, warning))
+		}
+	}
+	if err := history.commit(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// checkNonce fails if the nonce of the document's proof is in the history, and otherwise records
+// it. A missing nonce is returned as a warning if allowed.
+func checkNonce(history NonceHistory, doc DIDDoc, allowMissing bool) (warning string, err error) {
+	nonce := doc.Proof.Nonce
+	if nonce == "" {
+		if !allowMissing {
+			return "", errors.Wrapf(ErrMissingNonce, "DID<%s>", doc.ID)
+		}
+		return fmt.Sprintf("proof of DID<%s> has no nonce", doc.ID), nil
+	}
+	seen, err := history.Seen(doc.ID, nonce)
+	if err != nil {
+		return "", err
+	}
+	if seen {
+		return "", errors.Wrapf(ErrNonceReuse, "DID<%s> nonce<%s>", doc.ID, nonce)
+	}
+	return "", history.Record(doc.ID, nonce)
+}
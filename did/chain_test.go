@@ -0,0 +1,141 @@
+package did
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// signWithNonce replaces the proof on the DID Document with a signature from the given key, using
+// the given proof nonce.
+func signWithNonce(t *testing.T, doc *DIDDoc, key ed25519.PrivateKey, keyID, nonce string) {
+	signer, err := proof.NewEd25519Signer(key, keyID)
+	require.NoError(t, err)
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+	doc.Proof = nil
+	require.NoError(t, proof.SignWithOptions(suite, doc, signer, proof.WithNonce(func() string { return nonce })))
+}
+
+func TestVerifyUpdateChain(t *testing.T) {
+	genesis, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	keyID := genesis.PublicKey[0].ID
+	update := func(nonce string) DIDDoc {
+		doc := genesis.Clone()
+		doc.Service = []ServiceDef{{ID: GenerateKeyID(genesis.ID, "hub"), Type: "hub", ServiceEndpoint: "https://example.com/" + nonce}}
+		signWithNonce(t, &doc, privateKey, keyID, nonce)
+		return doc
+	}
+
+	t.Run("Valid chain", func(t *testing.T) {
+		result, err := VerifyUpdateChain([]DIDDoc{*genesis, update("nonce-1"), update("nonce-2")})
+		require.NoError(t, err)
+		assert.Empty(t, result.Warnings)
+	})
+
+	t.Run("Replayed nonce", func(t *testing.T) {
+		_, err := VerifyUpdateChain([]DIDDoc{*genesis, update("nonce-1"), update("nonce-1")})
+		assert.True(t, errors.Is(err, ErrNonceReuse))
+
+		_, err = VerifyUpdateChain([]DIDDoc{*genesis, update(genesis.Proof.Nonce)})
+		assert.True(t, errors.Is(err, ErrNonceReuse))
+	})
+
+	t.Run("Nonce history", func(t *testing.T) {
+		history := NewMemoryNonceHistory()
+		_, err := VerifyUpdateChain([]DIDDoc{*genesis, update("nonce-1")}, WithNonceHistory(history))
+		require.NoError(t, err)
+		seen, err := history.Seen(genesis.ID, "nonce-1")
+		require.NoError(t, err)
+		assert.True(t, seen)
+
+		// A version replayed later is caught by the history.
+		_, err = VerifyUpdateChain([]DIDDoc{update("nonce-2"), update("nonce-1")}, WithNonceHistory(history))
+		assert.True(t, errors.Is(err, ErrNonceReuse))
+	})
+
+	t.Run("Nonce history is unchanged by a chain that fails", func(t *testing.T) {
+		other, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		forged := genesis.Clone()
+		signWithNonce(t, &forged, otherKey, other.PublicKey[0].ID, "nonce-2")
+		history := NewMemoryNonceHistory()
+		_, err := VerifyUpdateChain([]DIDDoc{*genesis, update("nonce-1"), forged}, WithNonceHistory(history))
+		require.Error(t, err)
+		for _, nonce := range []string{genesis.Proof.Nonce, "nonce-1"} {
+			seen, err := history.Seen(genesis.ID, nonce)
+			require.NoError(t, err)
+			assert.False(t, seen, nonce)
+		}
+
+		// The valid part of the chain can be verified again.
+		_, err = VerifyUpdateChain([]DIDDoc{*genesis, update("nonce-1")}, WithNonceHistory(history))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Missing nonce", func(t *testing.T) {
+		legacy := update("")
+		_, err := VerifyUpdateChain([]DIDDoc{*genesis, legacy})
+		assert.True(t, errors.Is(err, ErrMissingNonce))
+
+		result, err := VerifyUpdateChain([]DIDDoc{*genesis, legacy}, AllowMissingNonces())
+		require.NoError(t, err)
+		require.Len(t, result.Warnings, 1)
+		assert.Contains(t, result.Warnings[0], "version 1")
+	})
+
+	t.Run("Version not signed by the previous version", func(t *testing.T) {
+		other, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		forged := genesis.Clone()
+		signWithNonce(t, &forged, otherKey, other.PublicKey[0].ID, "nonce-1")
+		_, err := VerifyUpdateChain([]DIDDoc{*genesis, forged})
+		assert.Error(t, err)
+
+		_, err = VerifyUpdateChain([]DIDDoc{*genesis, *other})
+		assert.Error(t, err)
+	})
+
+	t.Run("Version after deactivation", func(t *testing.T) {
+		signer, err := proof.NewEd25519Signer(privateKey, keyID)
+		require.NoError(t, err)
+		deactivated, err := DeactivateDIDDocGeneric(signer, proof.JCSEdSignatureType, genesis.ID)
+		require.NoError(t, err)
+		_, err = VerifyUpdateChain([]DIDDoc{*genesis, *deactivated})
+		require.NoError(t, err)
+
+		_, err = VerifyUpdateChain([]DIDDoc{*genesis, *deactivated, update("nonce-1")})
+		assert.True(t, errors.Is(err, ErrDeactivated))
+	})
+
+	t.Run("Empty chain", func(t *testing.T) {
+		_, err := VerifyUpdateChain(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestMemoryRegistryNonceReuse(t *testing.T) {
+	registry := NewMemoryRegistry()
+	doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	require.NoError(t, registry.Put(*doc))
+
+	updated := doc.Clone()
+	updated.AlsoKnownAs = []string{"https://example.com/alice"}
+	signWithNonce(t, &updated, privateKey, doc.PublicKey[0].ID, "nonce-1")
+	require.NoError(t, registry.Put(updated))
+
+	// Replaying the first version, with its original proof, is rejected.
+	err := registry.Put(*doc)
+	assert.True(t, errors.Is(err, ErrNonceReuse))
+
+	replayed := doc.Clone()
+	signWithNonce(t, &replayed, privateKey, doc.PublicKey[0].ID, "nonce-1")
+	assert.True(t, errors.Is(registry.Put(replayed), ErrNonceReuse))
+
+	missing := doc.Clone()
+	signWithNonce(t, &missing, privateKey, doc.PublicKey[0].ID, "")
+	assert.True(t, errors.Is(registry.Put(missing), ErrMissingNonce))
+}
@@ -1,16 +1,22 @@
 package did
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
-	"strings"
+	"time"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/mr-tron/base58"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/ed25519"
 
 	"github.com/workdaycredentials/ledger-common/proof"
 	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+	"github.com/workdaycredentials/ledger-common/util/multicodec"
 )
 
 const (
@@ -22,23 +28,19 @@ const (
 	IssuerDIDMethod = "did:work:"
 	KeyDIDMethod    = "did:key:"
 
-	// Codec for Ed25519 multi-format
-	// https://github.com/multiformats/multicodec
-	Ed25519Codec byte = 0xed
+	// Codec for Ed25519 multi-format. See multicodec.Ed25519Pub.
+	Ed25519Codec = byte(multicodec.Ed25519Pub)
 
 	// SchemaContext is the JSON-LD @context value that points to the W3C DID v1 context.
 	// Workday has chosen not to use JSON-LD for DID Documents.
 	//
 	// Deprecated: This field is kept for historical purposes only. New documents should exclude it.
 	SchemaContext = "https://w3id.org/did/v1"
-)
 
-// ExtractDIDFromKeyRef parses a key reference in the form of DID#keyID and returns the DID.
-// If the key reference doesn't contain a hash "#" symbol, the entire key reference is returned.
-func ExtractDIDFromKeyRef(keyRef string) string {
-	s := strings.Split(keyRef, "#")
-	return s[0]
-}
+	// DIDContext is the JSON-LD @context value of the W3C DID Core v1 specification. Some relying
+	// parties require it, even though Workday does not use JSON-LD. See MarshalWithContext.
+	DIDContext = "https://www.w3.org/ns/did/v1"
+)
 
 // GenerateDID generates a Decentralized ID in the form of "did:work:<id>" based on an Ed25519
 // public key. Workday's DID method uses the first 16 bytes of the public key as a unique random
@@ -47,21 +49,25 @@ func GenerateDID(publicKey ed25519.PublicKey) string {
 	return IssuerDIDMethod + base58.Encode(publicKey[0:16])
 }
 
-// TODO consider making keyref a type
-// type KeyRef string
-//
-// func (k *KeyRef) GetDID() string {
-// 	s := strings.Split(keyRef, "#")
-// 	return s[0]		return s[0]
-// }
-//
-// func (k *KeyRef) GetID() string {
-// 	s := strings.Split(keyRef, "#")
-// 	return s[1]		return s[1]
-// }
-// GenerateKeyID builds a fully qualified key reference given a DID and a key fragment
-func GenerateKeyID(did, fragment string) string {
-	return fmt.Sprintf("%s#%s", did, fragment)
+// GenerateDIDV2 generates a Decentralized ID in the form of "did:work:<id>" based on an Ed25519
+// public key. Unlike GenerateDID, the identifier is the first 16 bytes of the SHA-256 hash of the
+// full public key, so the DID does not reveal any of the key.
+func GenerateDIDV2(publicKey ed25519.PublicKey) string {
+	hash := sha256.Sum256(publicKey)
+	return IssuerDIDMethod + base58.Encode(hash[:16])
+}
+
+// MatchesDID returns true if the DID was generated from the public key by either GenerateDID or
+// GenerateDIDV2.
+func MatchesDID(publicKey ed25519.PublicKey, did string) bool {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return constantTimeEqual(GenerateDID(publicKey), did) || constantTimeEqual(GenerateDIDV2(publicKey), did)
+}
+
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
 }
 
 // GenerateDIDFromB64PubKey converts a base64 encoded Ed25519 public key into a Decentralized ID.
@@ -79,25 +85,75 @@ func GenerateDIDFromB64PubKey(edBase64PubKey string) (string, error) {
 // given DID Document.  This assumes that DID Documents are self-signed, which is always the case
 // in Workday. Returns an error if the public key is not found.
 func GetProofCreatorKeyDef(didDoc DIDDoc) (*KeyDef, error) {
-	var publicKey KeyDef
-	for _, keyDef := range didDoc.PublicKey {
-		if keyDef.ID == didDoc.Proof.GetVerificationMethod() {
-			publicKey = keyDef
-		}
+	if err := didDoc.checkProofKeyRef(); err != nil {
+		return nil, err
+	}
+	publicKey, err := didDoc.ResolveKeyRef(didDoc.Proof.GetVerificationMethod())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find public key")
 	}
-	if publicKey.PublicKeyBase58 == "" {
-		return nil, errors.New("could not find public key")
+	if !publicKey.hasKeyMaterial() {
+		return nil, errcode.New(errcode.KeyNotFound, "could not find public key")
 	}
+	return publicKey, nil
+}
+
+// VerifyDIDDocProof verifies the Proof on the DID Document using a public key from the signing
+// DID Document. For a new DID Document the signing document is the document itself; for an update
+// it is the previous version, so that only the holder of a current key can make changes.
+// Returns ErrForeignKeyReference if the proof references a key that is not from the signing
+// document.
+func VerifyDIDDocProof(doc DIDDoc, signingDoc UnsignedDIDDoc) error {
+	return verifyDIDDocProof(doc, signingDoc, signingDoc.ResolveKeyRef)
+}
 
-	return &publicKey, nil
+// VerifyDIDDocProofForOperation is the same as VerifyDIDDocProof, except that the key must be
+// permitted to authorize the operation (see UnsignedDIDDoc.AuthorizedKey and UpdateOperationFor).
+func VerifyDIDDocProofForOperation(doc DIDDoc, signingDoc UnsignedDIDDoc, op Operation) error {
+	return verifyDIDDocProof(doc, signingDoc, func(ref string) (*KeyDef, error) {
+		return signingDoc.AuthorizedKey(ref, op)
+	})
+}
+
+func verifyDIDDocProof(doc DIDDoc, signingDoc UnsignedDIDDoc, resolveKeyRef func(ref string) (*KeyDef, error)) error {
+	if doc.Proof.IsEmpty() {
+		return errcode.New(errcode.MalformedProof, "did doc proof cannot be empty")
+	}
+	if err := doc.checkProofKeyRefOwner(signingDoc.ID); err != nil {
+		return err
+	}
+	keyDef, err := resolveKeyRef(doc.Proof.GetVerificationMethod())
+	if err != nil {
+		return errors.Wrap(err, "could not find public key")
+	}
+	verifier, err := AsVerifier(*keyDef)
+	if err != nil {
+		return err
+	}
+	suite, err := proof.SignatureSuites().GetSuiteForProof(doc.Proof)
+	if err != nil {
+		return err
+	}
+	return suite.Verify(&doc, verifier)
 }
 
 // GenerateDIDKey generates a non-registry based Decentralized DID in the form of "did:key:<id>" based on an Ed25519
 // public key. The DID Key Method expands a cryptographic public key into a DID Document.
+// The key is prefixed with the Ed25519 multicodec as an unsigned varint (0xed, 0x01), as per the
+// specification, so the DID matches those generated by other implementations.
 // Note: As of May 2020, the DID Key method is still in unofficial draft (https://w3c-ccg.github.io/did-method-key)
 func GenerateDIDKey(publicKey ed25519.PublicKey) string {
+	return KeyDIDMethod + multicodecEncode(uint64(Ed25519Codec), publicKey)
+}
+
+// GenerateLegacyDIDKey generates a DID Key with the single byte Ed25519 codec prefix (0xed) that
+// GenerateDIDKey used to write. Extraction and resolution accept both forms.
+//
+// Deprecated: Only use this while migrating systems that compare against previously issued DID
+// Keys. New DID Keys should be generated with GenerateDIDKey.
+func GenerateLegacyDIDKey(publicKey ed25519.PublicKey) string {
 	pk := append([]byte{Ed25519Codec}, publicKey...)
-	return KeyDIDMethod + "z" + base58.Encode(pk)
+	return KeyDIDMethod + encodeBase58BTC(pk)
 }
 
 // GenerateDIDKeyFromB64PubKey converts a base64 encoded Ed25519 public key into a DID Key.
@@ -110,60 +166,122 @@ func GenerateDIDKeyFromB64PubKey(edBase64PubKey string) (did string, err error)
 	return GenerateDIDKey(decodedPubKey), nil
 }
 
-// ExtractEdPublicKeyFromDID extracts an Ed25519 Public Key from a DID Key.
+// ExtractEdPublicKeyFromDID extracts an Ed25519 Public Key from a DID Key. Both the spec-compliant
+// varint codec prefix and the legacy single byte prefix are accepted (see GenerateLegacyDIDKey).
+// Returns an error wrapping ErrMalformedDIDKey if the DID cannot be decoded or the key is not 32
+// bytes, or ErrUnsupportedKeyCodec if the DID Key is not an Ed25519 key.
 func ExtractEdPublicKeyFromDID(did string) (key ed25519.PublicKey, err error) {
-	prefix := KeyDIDMethod + "z"
-	if !strings.HasPrefix(did, prefix) {
-		err = fmt.Errorf("DID<%s> format not supported", did)
-		return
-	}
-	decodedKey, err := base58.Decode(did[len(prefix):])
+	codec, keyBytes, err := decodeDIDKey(did)
 	if err != nil {
-		return nil, errors.New("cannot decode DID")
+		return nil, err
 	}
-
-	codec := decodedKey[0]
-	if codec == Ed25519Codec {
-		return decodedKey[1:], nil
+	if codec != uint64(Ed25519Codec) {
+		return nil, errors.Wrapf(ErrUnsupportedKeyCodec, "DID<%s> is not an Ed25519 DID Key", did)
+	}
+	if len(keyBytes) != ed25519.PublicKeySize {
+		return nil, errors.Wrapf(ErrMalformedDIDKey, "key cannot be extracted from DID<%s>", did)
 	}
-	err = fmt.Errorf("key cannot be extracted from DID<%s>", did)
-	return
+	return keyBytes, nil
 }
 
 // DeactivateDIDDoc creates a deactivated DID Document.
 // Returns an error if the Signer fails to generate the digital signature.
-// Uses the same signature type as is on the provided DID Doc
-func DeactivateDIDDoc(doc DIDDoc, key ed25519.PrivateKey) (*DIDDoc, error) {
-	signer, err := proof.NewEd25519Signer(key, doc.PublicKey[0].ID)
+// Uses the same signature type as is on the provided DID Doc. The document is signed with the
+// public key that matches the private key, which may be the recovery key, defaulting to the first
+// public key. With WithReceipt, a DeactivationReceipt of the document is also signed with the key.
+func DeactivateDIDDoc(doc DIDDoc, key ed25519.PrivateKey, opts ...ReceiptOption) (*DIDDoc, error) {
+	var options receiptOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if len(doc.PublicKey) == 0 {
+		return nil, errors.New("did doc has no public keys")
+	}
+	keyDef := doc.PublicKey[0]
+	if len(key) == ed25519.PrivateKeySize {
+		for _, candidate := range doc.PublicKey {
+			if checkSignerPublicKey(candidate, &proof.Ed25519Signer{PrivateKey: key}) == nil {
+				keyDef = candidate
+				break
+			}
+		}
+	}
+	if _, _, err := ParseKeyRef(keyDef.ID); err != nil {
+		return nil, err
+	}
+	signer, err := proof.NewEd25519Signer(key, keyDef.ID)
 	if err != nil {
 		return nil, err
 	}
-	return DeactivateDIDDocGeneric(signer, doc.Proof.Type, doc.ID)
+	deactivated, err := DeactivateDIDDocGeneric(signer, doc.Proof.Type, doc.ID)
+	if err != nil || options.receipt == nil {
+		return deactivated, err
+	}
+	fingerprint, err := Fingerprint(doc)
+	if err != nil {
+		return nil, err
+	}
+	deactivatedAt, err := util.ParseRFC3339Lenient(deactivated.DeactivatedAt)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := SignReceipt(DeactivationReceipt, doc.ID, fingerprint, deactivatedAt, signer)
+	if err != nil {
+		return nil, err
+	}
+	*options.receipt = *receipt
+	return deactivated, nil
 }
 
-// DeactivateDIDDocGeneric creates a deactivated DID Document.
+// DeactivateDIDDocGeneric creates a deactivated DID Document. The document has an explicit
+// deactivated status and deactivation time, both of which are covered by the proof. The options
+// are applied to the proof (see proof.SignWithOptions); the deactivation time is the proof's
+// created time, which is taken from the clock of proof.WithClock if given.
 // Returns an error if the Signer fails to generate the digital signature.
-func DeactivateDIDDocGeneric(signer proof.Signer, signatureType proof.SignatureType, did string) (*DIDDoc, error) {
-	doc := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: did}}
+func DeactivateDIDDocGeneric(signer proof.Signer, signatureType proof.SignatureType, did string, opts ...proof.ProofOption) (*DIDDoc, error) {
+	options := proof.ProofOptions{Now: time.Now}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	deactivatedAt := options.Now()
+	doc := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{
+		ID:            did,
+		DIDStatus:     StatusDeactivated,
+		DeactivatedAt: util.FormatCanonicalTime(deactivatedAt),
+	}}
 	suite, err := proof.SignatureSuites().GetSuite(signatureType, proof.V2)
 	if err != nil {
 		return nil, err
 	}
-	err = suite.Sign(&doc, signer)
+	opts = append(opts, proof.WithClock(func() time.Time { return deactivatedAt }))
+	err = proof.SignWithOptions(suite, &doc, signer, opts...)
 	return &doc, err
 }
 
 // AsVerifier builds a verifier given a key definition that can be used to verify
 // signed objects by the key in the definition
 func AsVerifier(keyDef KeyDef) (proof.Verifier, error) {
+	if keyDef.hasAlternativeKeyMaterial() {
+		normalized, err := keyDef.Normalize()
+		if err != nil {
+			return nil, err
+		}
+		return AsVerifier(normalized)
+	}
 	keyType := keyDef.Type
 	switch keyType {
 	case proof.EcdsaSecp256k1KeyType:
-		pubKey, err := util.ExtractPublicKeyFromBase58Der(keyDef.PublicKeyBase58)
+		pubKey, err := extractSecp256k1PublicKey(keyDef.PublicKeyBase58)
 		if err != nil {
 			return nil, err
 		}
 		return &proof.Secp256K1Verifier{PublicKey: pubKey}, nil
+	case proof.EcdsaSecp256r1KeyType:
+		pubKey, err := base58.Decode(keyDef.PublicKeyBase58)
+		if err != nil {
+			return nil, err
+		}
+		return &proof.P256Verifier{PublicKey: pubKey}, nil
 	case proof.WorkEdKeyType:
 		fallthrough
 	case proof.Ed25519KeyType:
@@ -175,3 +293,68 @@ func AsVerifier(keyDef KeyDef) (proof.Verifier, error) {
 	}
 	return nil, fmt.Errorf("unknown key type: %s", keyType)
 }
+
+// AsSigner builds a signer from a private key and the key definition of its published public key.
+// Ed25519 private keys may be either the 32 byte seed or the 64 byte private key, and secp256k1
+// private keys are the 32 byte scalar. An error is returned if the private key does not
+// correspond to the public key, since proofs would not verify against the published key.
+func AsSigner(keyDef KeyDef, privateKey []byte) (proof.Signer, error) {
+	normalized, err := keyDef.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	var signer proof.Signer
+	var publicKey []byte
+	switch normalized.Type {
+	case proof.Ed25519KeyType:
+		var key ed25519.PrivateKey
+		switch len(privateKey) {
+		case ed25519.SeedSize:
+			key = ed25519.NewKeyFromSeed(privateKey)
+		case ed25519.PrivateKeySize:
+			key = ed25519.NewKeyFromSeed(privateKey[:ed25519.SeedSize])
+		default:
+			return nil, errors.Errorf("invalid Ed25519 private key length: %d", len(privateKey))
+		}
+		publicKey = key.Public().(ed25519.PublicKey)
+		signer, err = proof.NewEd25519Signer(key, keyDef.ID)
+	case proof.EcdsaSecp256k1KeyType:
+		if len(privateKey) != btcec.PrivKeyBytesLen {
+			return nil, errors.Errorf("invalid secp256k1 private key length: %d", len(privateKey))
+		}
+		key, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+		publicKey = pubKey.SerializeCompressed()
+		signer, err = proof.NewSecp256k1Signer(key.ToECDSA(), keyDef.ID)
+	default:
+		return nil, errors.Errorf("unknown key type: %s", keyDef.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := base58.Decode(normalized.PublicKeyBase58)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(publicKey, expected) {
+		return nil, errors.Errorf("private key does not match public key: %s", keyDef.ID)
+	}
+	return signer, nil
+}
+
+// extractSecp256k1PublicKey accepts either a raw SEC1 encoded key (as used by DID Keys) or a DER
+// encoded key (as used by Workday DID Documents).
+func extractSecp256k1PublicKey(encodedBase58 string) ([]byte, error) {
+	decoded, err := base58.Decode(encodedBase58)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(decoded) == btcec.PubKeyBytesLenCompressed && (decoded[0] == 0x02 || decoded[0] == 0x03):
+		return decoded, nil
+	case len(decoded) == btcec.PubKeyBytesLenUncompressed && decoded[0] == 0x04:
+		return decoded, nil
+	}
+	return util.ExtractPublicKeyFromBase58Der(encodedBase58)
+}
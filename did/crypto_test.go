@@ -2,10 +2,14 @@ package did
 
 import (
 	"encoding/base64"
-	"fmt"
+	"encoding/binary"
+	"errors"
 	"strings"
 	"testing"
+	"testing/quick"
+	"time"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/mr-tron/base58"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -54,6 +58,26 @@ func TestGetDIDFromPubKey(t *testing.T) {
 	assert.Equal(t, "did:work:6sYe1y3zXhmyrBkgHgAgaq", did)
 }
 
+func TestGenerateDIDV2(t *testing.T) {
+	did := GenerateDIDV2(issuerPubKey)
+	assert.Equal(t, "did:work:2tUZPaKNmzirkA1ZJE24mA", did)
+	assert.NotEqual(t, GenerateDID(issuerPubKey), did)
+	assert.NoError(t, ValidateWorkDID(did))
+}
+
+func TestMatchesDID(t *testing.T) {
+	otherPubKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	assert.True(t, MatchesDID(issuerPubKey, GenerateDID(issuerPubKey)))
+	assert.True(t, MatchesDID(issuerPubKey, GenerateDIDV2(issuerPubKey)))
+	assert.False(t, MatchesDID(otherPubKey, GenerateDID(issuerPubKey)))
+	assert.False(t, MatchesDID(otherPubKey, GenerateDIDV2(issuerPubKey)))
+	assert.False(t, MatchesDID(issuerPubKey, GenerateDIDKey(issuerPubKey)))
+	assert.False(t, MatchesDID(issuerPubKey[:16], GenerateDID(issuerPubKey)))
+	assert.False(t, MatchesDID(nil, ""))
+}
+
 func TestExtractAuthorDID(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -115,11 +139,32 @@ func TestDIDKey(t *testing.T) {
 
 	t.Run("GenerateDIDKey()", func(t *testing.T) {
 		did := GenerateDIDKey(issuerPubKey)
-		expectedDIDKeyLen := 55
-		assert.True(t, strings.HasPrefix(did, "did:key:z"))
+		expectedDIDKeyLen := 56
+		assert.True(t, strings.HasPrefix(did, "did:key:z6Mk"))
 		assert.Len(t, did, expectedDIDKeyLen)
 	})
 
+	t.Run("Specification vectors", func(t *testing.T) {
+		for _, vector := range didKeyVectors {
+			publicKey, err := base58.Decode(vector.publicKeyBase58)
+			require.NoError(t, err)
+			assert.Equal(t, vector.did, GenerateDIDKey(publicKey))
+
+			extractedKey, err := ExtractEdPublicKeyFromDID(vector.did)
+			require.NoError(t, err)
+			assert.Equal(t, ed25519.PublicKey(publicKey), extractedKey)
+		}
+	})
+
+	t.Run("Legacy codec", func(t *testing.T) {
+		did := GenerateLegacyDIDKey(issuerPubKey)
+		assert.Equal(t, "did:key:z2DTcg9rqdBTZ2qK1eCy1zQ3c6GzHdZYugdnTKE4NrK8Acd", did)
+		assert.NotEqual(t, GenerateDIDKey(issuerPubKey), did)
+		extractedKey, err := ExtractEdPublicKeyFromDID(did)
+		require.NoError(t, err)
+		assert.Equal(t, issuerPubKey, extractedKey)
+	})
+
 	t.Run("GenerateDIDKeyFromB64PubKey()", func(t *testing.T) {
 		key := base64.StdEncoding.EncodeToString(issuerPubKey)
 		didKeyFromB64, err := GenerateDIDKeyFromB64PubKey(key)
@@ -131,28 +176,30 @@ func TestDIDKey(t *testing.T) {
 }
 
 func TestExtractEdPublicKeyFromDID(t *testing.T) {
-	t.Run("Wrong DID Method", func(t *testing.T) {
-		did := "did:work:12345678"
-		_, err := ExtractEdPublicKeyFromDID(did)
-		assert.Error(t, err)
-		expectedErr := fmt.Errorf("DID<%s> format not supported", did)
-		assert.Equal(t, expectedErr, err)
-	})
-
-	t.Run("Improper Multiformat", func(t *testing.T) {
-		did := "did:key:x12345678"
-		_, err := ExtractEdPublicKeyFromDID(did)
-		assert.Error(t, err)
-		expectedErr := fmt.Errorf("DID<%s> format not supported", did)
-		assert.Equal(t, expectedErr, err)
+	t.Run("Malformed", func(t *testing.T) {
+		for _, did := range []string{
+			"did:work:12345678",
+			"did:key:x12345678",
+			"did:key:",
+			"did:key:z",
+			"did:key:z0OIl",
+			"did:key:z2",
+			"did:key:z" + base58.Encode([]byte{0xed}),
+			"did:key:z" + base58.Encode([]byte{0xed, 0x01}),
+			"did:key:z" + base58.Encode([]byte{0xed, 0x01, 0x01, 0x02}),
+			"did:key:z" + base58.Encode(append([]byte{0xed, 0x01}, issuerPubKey[:30]...)),
+			"did:key:z" + base58.Encode([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}),
+		} {
+			_, err := ExtractEdPublicKeyFromDID(did)
+			assert.True(t, errors.Is(err, ErrMalformedDIDKey), did)
+		}
 	})
 
-	t.Run("Can't Extract Key", func(t *testing.T) {
-		did := "did:key:z12345678"
-		_, err := ExtractEdPublicKeyFromDID(did)
-		assert.Error(t, err)
-		expectedErr := fmt.Errorf("key cannot be extracted from DID<%s>", did)
-		assert.Equal(t, expectedErr, err)
+	t.Run("Unsupported codec", func(t *testing.T) {
+		for _, did := range []string{"did:key:z12345678", secp256k1DIDKeyVectors[0].did} {
+			_, err := ExtractEdPublicKeyFromDID(did)
+			assert.True(t, errors.Is(err, ErrUnsupportedKeyCodec), did)
+		}
 	})
 
 	t.Run("Happy Path", func(t *testing.T) {
@@ -162,6 +209,25 @@ func TestExtractEdPublicKeyFromDID(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, expectedPK, actualPK)
 	})
+
+	t.Run("Never panics", func(t *testing.T) {
+		extract := func(payload []byte, codec uint64, suffix string) bool {
+			prefix := make([]byte, binary.MaxVarintLen64)
+			n := binary.PutUvarint(prefix, codec%0x1300)
+			for _, did := range []string{
+				"did:key:z" + base58.Encode(payload),
+				"did:key:z" + base58.Encode(append(prefix[:n], payload...)),
+				"did:key:z" + suffix,
+				suffix,
+			} {
+				_, _ = ExtractEdPublicKeyFromDID(did)
+				_, _, _ = ExtractPublicKeyFromDIDKey(did)
+				_, _ = ResolveDIDKey(did)
+			}
+			return true
+		}
+		assert.NoError(t, quick.Check(extract, &quick.Config{MaxCount: 2000}))
+	})
 }
 
 func TestDeactivateDIDDoc(t *testing.T) {
@@ -203,6 +269,58 @@ func TestDeactivateDIDDoc(t *testing.T) {
 		err = suite.Verify(deactivated, verifier)
 		assert.NoError(t, err)
 	})
+
+	t.Run("Using generic method with a clock", func(t *testing.T) {
+		doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		signer, err := proof.NewEd25519Signer(privateKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		deactivatedAt := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+		deactivate := func() *DIDDoc {
+			deactivated, err := DeactivateDIDDocGeneric(signer, proof.JCSEdSignatureType, doc.ID,
+				proof.WithClock(func() time.Time { return deactivatedAt }),
+				proof.WithNonce(func() string { return "fixed-nonce" }))
+			require.NoError(t, err)
+			return deactivated
+		}
+
+		deactivated := deactivate()
+		assert.Equal(t, "2020-06-01T12:00:00Z", deactivated.DeactivatedAt)
+		assert.Equal(t, deactivated.DeactivatedAt, deactivated.Proof.Created)
+		assert.NoError(t, VerifyDIDDocProof(*deactivated, doc.UnsignedDIDDoc))
+		// Ed25519 signatures are deterministic, so the whole document is reproducible.
+		assert.Equal(t, deactivated, deactivate())
+	})
+
+	t.Run("Explicit status", func(t *testing.T) {
+		doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		assert.False(t, doc.IsDeactivated())
+		assert.Equal(t, StatusActive, doc.Status())
+
+		deactivated, err := DeactivateDIDDoc(*doc, privateKey)
+		require.NoError(t, err)
+		assert.Equal(t, StatusDeactivated, deactivated.DIDStatus)
+		assert.NotEmpty(t, deactivated.DeactivatedAt)
+		assert.True(t, deactivated.IsDeactivated())
+		assert.NoError(t, deactivated.Validate())
+
+		// The status is covered by the proof.
+		assert.NoError(t, VerifyDIDDocProof(*deactivated, doc.UnsignedDIDDoc))
+		tampered := *deactivated
+		tampered.DIDStatus = StatusActive
+		assert.Error(t, VerifyDIDDocProof(tampered, doc.UnsignedDIDDoc))
+	})
+
+	t.Run("Legacy deactivation", func(t *testing.T) {
+		legacy := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: testWorkDID}}
+		assert.True(t, legacy.IsDeactivated())
+		assert.Equal(t, StatusDeactivated, legacy.Status())
+	})
+
+	t.Run("Deactivated status with keys", func(t *testing.T) {
+		doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		doc.DIDStatus = StatusDeactivated
+		assert.Error(t, doc.Validate())
+	})
 }
 
 func TestVerifySecp256k1DIDDoc(t *testing.T) {
@@ -256,4 +374,67 @@ func TestVerifySecp256k1DIDDoc(t *testing.T) {
 		Proof:          &p,
 	}
 	assert.Error(t, suite.Verify(&badDoc, verifier))
-}
\ No newline at end of file
+}
+
+func TestAsSigner(t *testing.T) {
+	keyID := testWorkDID + "#key-1"
+
+	t.Run("Ed25519", func(t *testing.T) {
+		keyDef := KeyDef{ID: keyID, Type: proof.WorkEdKeyType, Controller: testWorkDID, PublicKeyBase58: base58.Encode(issuerPubKey)}
+		for _, privateKey := range [][]byte{keySeed, issuerPrivKey} {
+			signer, err := AsSigner(keyDef, privateKey)
+			require.NoError(t, err)
+			assert.Equal(t, keyID, signer.ID())
+			signAndVerify(t, keyDef, signer, proof.JCSEdSignatureType, proof.V2)
+		}
+
+		multibase, err := EncodePublicKeyMultibase(issuerPubKey, proof.Ed25519VerificationKey2020KeyType)
+		require.NoError(t, err)
+		keyDef = KeyDef{ID: keyID, Type: proof.Ed25519VerificationKey2020KeyType, PublicKeyMultibase: multibase}
+		signer, err := AsSigner(keyDef, keySeed)
+		require.NoError(t, err)
+		signAndVerify(t, keyDef, signer, proof.WorkEdSignatureType, proof.V2)
+	})
+
+	t.Run("secp256k1", func(t *testing.T) {
+		privateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		publicKey := privateKey.PubKey()
+		for _, encoded := range [][]byte{publicKey.SerializeCompressed(), publicKey.SerializeUncompressed()} {
+			keyDef := KeyDef{ID: keyID, Type: proof.EcdsaSecp256k1KeyType, Controller: testWorkDID, PublicKeyBase58: base58.Encode(encoded)}
+			signer, err := AsSigner(keyDef, privateKey.Serialize())
+			require.NoError(t, err)
+			assert.Equal(t, keyID, signer.ID())
+			signAndVerify(t, keyDef, signer, proof.EcdsaSecp256k1SignatureType, proof.V1)
+		}
+	})
+
+	t.Run("Mismatched private key", func(t *testing.T) {
+		rotatedKey := ed25519.NewKeyFromSeed([]byte("abcdefghijklmnopqrstuvwxyz123456"))
+		keyDef := KeyDef{ID: keyID, Type: proof.Ed25519KeyType, PublicKeyBase58: base58.Encode(issuerPubKey)}
+		_, err := AsSigner(keyDef, rotatedKey)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "private key does not match public key")
+
+		secp256k1Key, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		otherKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		keyDef = KeyDef{ID: keyID, Type: proof.EcdsaSecp256k1KeyType, PublicKeyBase58: base58.Encode(secp256k1Key.PubKey().SerializeCompressed())}
+		_, err = AsSigner(keyDef, otherKey.Serialize())
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		keyDef := KeyDef{ID: keyID, Type: proof.Ed25519KeyType, PublicKeyBase58: base58.Encode(issuerPubKey)}
+		_, err := AsSigner(keyDef, keySeed[:31])
+		assert.Error(t, err)
+
+		keyDef.Type = proof.X25519KeyType
+		_, err = AsSigner(keyDef, keySeed)
+		assert.Error(t, err)
+
+		_, err = AsSigner(KeyDef{ID: keyID, Type: proof.Ed25519KeyType}, keySeed)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,153 @@
+package did
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// DecodeMode selects how much DecodeDoc checks the key material of a DID Document.
+type DecodeMode int
+
+const (
+	// Lenient decodes the document as json.Unmarshal does. Key material is only decoded when the
+	// key is used, e.g. by AsVerifier.
+	Lenient DecodeMode = iota
+	// Strict additionally checks the key material of every key while decoding (see
+	// KeyDef.ValidateEncoding).
+	Strict
+	// StrictFields additionally rejects properties that are not modeled, at any depth, including
+	// in the proof, rather than preserving them as extras (see util.StrictUnmarshal). Use it for
+	// documents from untrusted resolvers.
+	StrictFields
+)
+
+// base58Alphabet is the bitcoin base58 alphabet, as used by publicKeyBase58.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// DecodeDoc reads a JSON DID Document. In Strict mode, corrupt key material is rejected up front,
+// with an error that names the offending field, e.g. "publicKey[2].publicKeyBase58: invalid
+// base58 character at offset 14". In StrictFields mode, unknown properties are also rejected,
+// with a util.UnknownFieldError that names the property and its path. The document is not
+// otherwise validated; see Validate.
+func DecodeDoc(r io.Reader, mode DecodeMode) (*DIDDoc, error) {
+	var doc DIDDoc
+	if mode >= StrictFields {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := util.StrictUnmarshal(data, &doc); err != nil {
+			return nil, errcode.Wrap(errcode.DIDMalformed, err, "invalid DID Document")
+		}
+	} else if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, errcode.Wrap(errcode.DIDMalformed, err, "invalid DID Document")
+	}
+	if mode >= Strict {
+		if err := doc.validateKeyEncodings(); err != nil {
+			return nil, err
+		}
+	}
+	return &doc, nil
+}
+
+func (u *UnsignedDIDDoc) validateKeyEncodings() error {
+	for _, field := range []struct {
+		name string
+		keys []KeyDef
+	}{{"publicKey", u.PublicKey}, {"keyAgreement", u.KeyAgreement}} {
+		for i, key := range field.keys {
+			if err := key.ValidateEncoding(); err != nil {
+				return fmt.Errorf("%s[%d].%v", field.name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateEncoding checks that each encoding of the key material that is present decodes, and
+// that the decoded key has the right length for the key type: 32 bytes for Ed25519 and X25519
+// keys, and a compressed (33 bytes), uncompressed (65 bytes) or DER encoded point for secp256k1
+// keys. Errors are prefixed with the JSON name of the offending field.
+func (k KeyDef) ValidateEncoding() error {
+	if k.PublicKeyBase58 != "" {
+		if err := validateBase58Key(k.Type, k.PublicKeyBase58); err != nil {
+			return fmt.Errorf("publicKeyBase58: %v", err)
+		}
+	}
+	encodings := []struct {
+		field   string
+		present bool
+		decode  func() ([]byte, proof.KeyType, error)
+	}{
+		{"publicKeyMultibase", k.PublicKeyMultibase != "", k.decodeMultibase},
+		{"publicKeyJwk", k.PublicKeyJWK != nil, k.decodeJWK},
+		{"publicKeyBase64", k.PublicKeyBase64 != "", k.decodeBase64},
+		{"publicKeyHex", k.PublicKeyHex != "", k.decodeHex},
+	}
+	for _, encoding := range encodings {
+		if !encoding.present {
+			continue
+		}
+		publicKey, keyType, err := encoding.decode()
+		if err == nil {
+			err = validateKeyLength(keyType, publicKey)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", encoding.field, err)
+		}
+	}
+	return nil
+}
+
+// validateBase58Key checks the characters of a publicKeyBase58 value before decoding it, so that
+// invisible characters and other alphabets (e.g. base64) are reported by position.
+func validateBase58Key(keyType proof.KeyType, encoded string) error {
+	for offset, c := range encoded {
+		if !strings.ContainsRune(base58Alphabet, c) {
+			return fmt.Errorf("invalid base58 character at offset %d", offset)
+		}
+	}
+	publicKey, err := base58.Decode(encoded)
+	if err != nil {
+		return err
+	}
+	if normalizeKeyType(keyType) == proof.EcdsaSecp256k1KeyType {
+		switch {
+		case len(publicKey) == btcec.PubKeyBytesLenCompressed && (publicKey[0] == 0x02 || publicKey[0] == 0x03):
+		case len(publicKey) == btcec.PubKeyBytesLenUncompressed && publicKey[0] == 0x04:
+		default:
+			// Not a SEC1 point, so the key must be DER encoded.
+			der := publicKey
+			if publicKey, err = util.ExtractPublicKeyFromBase58Der(encoded); err != nil {
+				return fmt.Errorf("secp256k1 public key must be a 33 or 65 byte point or DER encoded, found %d bytes", len(der))
+			}
+		}
+		if _, err := btcec.ParsePubKey(publicKey, btcec.S256()); err != nil {
+			return fmt.Errorf("invalid secp256k1 public key: %v", err)
+		}
+		return nil
+	}
+	return validateKeyLength(keyType, publicKey)
+}
+
+// validateKeyLength checks the length of a raw Ed25519 or X25519 public key. Other key types are
+// checked by their decoders.
+func validateKeyLength(keyType proof.KeyType, publicKey []byte) error {
+	switch normalizeKeyType(keyType) {
+	case proof.Ed25519KeyType, proof.X25519KeyType:
+		if len(publicKey) != 32 {
+			return fmt.Errorf("%s public key must be 32 bytes, found %d", keyType, len(publicKey))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package did
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestDecodeDoc(t *testing.T) {
+	secp256k1Key, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	der, err := base64.StdEncoding.DecodeString(secp256k1DERKeyB64)
+	require.NoError(t, err)
+
+	// A document with keys in each supported form, so that a corrupted key is never the first.
+	doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	doc.PublicKey = append(doc.PublicKey,
+		KeyDef{ID: doc.ID + "#key-2", Type: proof.EcdsaSecp256k1KeyType, PublicKeyBase58: base58.Encode(secp256k1Key.PubKey().SerializeCompressed())},
+		KeyDef{ID: doc.ID + "#key-3", Type: proof.EcdsaSecp256k1KeyType, PublicKeyBase58: base58.Encode(secp256k1Key.PubKey().SerializeUncompressed())},
+		KeyDef{ID: doc.ID + "#key-4", Type: proof.EcdsaSecp256k1KeyType, PublicKeyBase58: base58.Encode(der)},
+		KeyDef{ID: doc.ID + "#key-5", Type: proof.Ed25519KeyType, PublicKeyHex: hex.EncodeToString(issuerPubKey)},
+	)
+	doc.KeyAgreement = []KeyDef{{ID: doc.ID + "#enc-1", Type: proof.X25519KeyType, PublicKeyBase58: base58.Encode(issuerPubKey)}}
+
+	decode := func(mode DecodeMode, modify func(*DIDDoc)) (*DIDDoc, error) {
+		modified := doc.Clone()
+		if modify != nil {
+			modify(&modified)
+		}
+		docJSON, err := json.Marshal(modified)
+		require.NoError(t, err)
+		return DecodeDoc(bytes.NewReader(docJSON), mode)
+	}
+
+	t.Run("Valid key material", func(t *testing.T) {
+		for _, mode := range []DecodeMode{Lenient, Strict, StrictFields} {
+			decoded, err := decode(mode, nil)
+			require.NoError(t, err)
+			assert.Equal(t, doc, decoded)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		modify   func(*DIDDoc)
+		expected string
+	}{
+		{
+			name: "Zero-width space",
+			modify: func(d *DIDDoc) {
+				encoded := d.PublicKey[0].PublicKeyBase58
+				d.PublicKey[0].PublicKeyBase58 = encoded[:14] + "\u200b" + encoded[14:]
+			},
+			expected: "publicKey[0].publicKeyBase58: invalid base58 character at offset 14",
+		},
+		{
+			name: "Base64 instead of base58",
+			modify: func(d *DIDDoc) {
+				d.PublicKey[0].PublicKeyBase58 = base64.StdEncoding.EncodeToString(issuerPubKey)
+			},
+			expected: "publicKey[0].publicKeyBase58: invalid base58 character",
+		},
+		{
+			name:     "Truncated Ed25519 key",
+			modify:   func(d *DIDDoc) { d.PublicKey[0].PublicKeyBase58 = base58.Encode(issuerPubKey[:24]) },
+			expected: "publicKey[0].publicKeyBase58: Ed25519VerificationKey2018 public key must be 32 bytes, found 24",
+		},
+		{
+			name:     "Truncated secp256k1 key",
+			modify:   func(d *DIDDoc) { d.PublicKey[2].PublicKeyBase58 = base58.Encode(der[:20]) },
+			expected: "publicKey[2].publicKeyBase58: secp256k1 public key must be a 33 or 65 byte point or DER encoded, found 20 bytes",
+		},
+		{
+			name: "secp256k1 point not on the curve",
+			modify: func(d *DIDDoc) {
+				d.PublicKey[1].PublicKeyBase58 = base58.Encode(append([]byte{0x02}, make([]byte, 32)...))
+			},
+			expected: "publicKey[1].publicKeyBase58: invalid secp256k1 public key",
+		},
+		{
+			name:     "Legacy hex encoding",
+			modify:   func(d *DIDDoc) { d.PublicKey[4].PublicKeyHex = hex.EncodeToString(issuerPubKey[:31]) },
+			expected: "publicKey[4].publicKeyHex: Ed25519VerificationKey2018 public key must be 32 bytes, found 31",
+		},
+		{
+			name:     "Key agreement key",
+			modify:   func(d *DIDDoc) { d.KeyAgreement[0].PublicKeyBase58 += "0" },
+			expected: "keyAgreement[0].publicKeyBase58: invalid base58 character at offset",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decode(Lenient, test.modify)
+			assert.NoError(t, err)
+
+			for _, mode := range []DecodeMode{Strict, StrictFields} {
+				_, err = decode(mode, test.modify)
+				require.Error(t, err)
+				assert.True(t, strings.HasPrefix(err.Error(), test.expected), err.Error())
+			}
+		})
+	}
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		_, err := DecodeDoc(strings.NewReader(`{"id":`), Strict)
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeDocStrictFields(t *testing.T) {
+	doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	doc.Context = []string{DIDContext}
+	docJSON, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	decoded, err := DecodeDoc(bytes.NewReader(docJSON), StrictFields)
+	require.NoError(t, err)
+	assert.Equal(t, doc, decoded)
+
+	tests := []struct {
+		name     string
+		modify   func(map[string]interface{})
+		expected util.UnknownFieldError
+		// caseFolded properties are rejected in every mode, since they would set a modeled field.
+		caseFolded bool
+	}{
+		{
+			name:     "Top level",
+			modify:   func(m map[string]interface{}) { m["controller"] = "did:example:123" },
+			expected: util.UnknownFieldError{Field: "controller", Path: "$"},
+		},
+		{
+			name: "Public key",
+			modify: func(m map[string]interface{}) {
+				m["publicKey"].([]interface{})[0].(map[string]interface{})["revoked"] = true
+			},
+			expected: util.UnknownFieldError{Field: "revoked", Path: "$.publicKey[0]"},
+		},
+		{
+			name: "Service",
+			modify: func(m map[string]interface{}) {
+				m["service"] = []interface{}{map[string]interface{}{"id": "#svc", "type": "Example", "serviceEndpoint": "https://example.com", "priority": 1}}
+			},
+			expected: util.UnknownFieldError{Field: "priority", Path: "$.service[0]"},
+		},
+		{
+			name: "Proof",
+			modify: func(m map[string]interface{}) {
+				m["proof"].(map[string]interface{})["proofPurpose"] = "assertionMethod"
+			},
+			expected: util.UnknownFieldError{Field: "proofPurpose", Path: "$.proof"},
+		},
+		{
+			name:       "Property in the wrong case",
+			modify:     func(m map[string]interface{}) { m["ID"] = "did:work:smuggled" },
+			expected:   util.UnknownFieldError{Field: "ID", Path: "$"},
+			caseFolded: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var m map[string]interface{}
+			require.NoError(t, json.Unmarshal(docJSON, &m))
+			test.modify(m)
+			modified, err := json.Marshal(m)
+			require.NoError(t, err)
+
+			_, err = DecodeDoc(bytes.NewReader(modified), Strict)
+			if test.caseFolded {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			_, err = DecodeDoc(bytes.NewReader(modified), StrictFields)
+			var unknownField util.UnknownFieldError
+			require.True(t, errors.As(err, &unknownField), err)
+			assert.Equal(t, test.expected, unknownField)
+			assert.Contains(t, err.Error(), test.expected.Error())
+		})
+	}
+}
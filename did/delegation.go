@@ -0,0 +1,307 @@
+package did
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+const (
+	// DelegationType is the type of a DelegationDoc.
+	DelegationType = "Delegation"
+	// DelegationRevocationType is the type of a DelegationRevocation.
+	DelegationRevocationType = "DelegationRevocation"
+)
+
+var (
+	// ErrDelegationNotValid is returned when a delegation is verified outside of its validity window.
+	ErrDelegationNotValid = errcode.New(errcode.Expired, "delegation is not valid at this time")
+	// ErrDelegationRevoked is returned when a delegation has been revoked by the delegator.
+	ErrDelegationRevoked = errcode.New(errcode.Deactivated, "delegation has been revoked")
+)
+
+// DelegationDoc states that the delegate DID may act on behalf of the delegator DID, for example a
+// guardian acting on behalf of a dependent. The delegation is limited to the listed actions and to
+// the validity window, and is signed by one of the delegator's keys.
+type DelegationDoc struct {
+	Type      string `json:"type"`
+	Delegator string `json:"delegator"`
+	Delegate  string `json:"delegate"`
+	// Actions are the actions the delegate may take on behalf of the delegator. Their meaning is
+	// defined by the application.
+	Actions []string `json:"actions"`
+	// ValidFrom is the datetime (RFC3339) from which the delegation is valid.
+	ValidFrom string `json:"validFrom"`
+	// ValidUntil is the optional datetime (RFC3339) at which the delegation expires.
+	ValidUntil string `json:"validUntil,omitempty"`
+	// RevocationRef is an optional reference to where revocations of the delegation (see
+	// DelegationRevocation) are published.
+	RevocationRef string       `json:"revocationRef,omitempty"`
+	Proof         *proof.Proof `json:"proof,omitempty"`
+}
+
+func (d *DelegationDoc) GetProof() *proof.Proof {
+	return d.Proof
+}
+
+func (d *DelegationDoc) SetProof(p *proof.Proof) {
+	d.Proof = p
+}
+
+// GetID returns the fingerprint of the delegation (see Fingerprint), or an empty string if it
+// cannot be computed.
+func (d *DelegationDoc) GetID() string {
+	fingerprint, err := d.Fingerprint()
+	if err != nil {
+		return ""
+	}
+	return fingerprint
+}
+
+// Allows returns true if the delegation includes the action.
+func (d DelegationDoc) Allows(action string) bool {
+	for _, a := range d.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Fingerprint returns a commitment to the signed delegation, including its proof, which is used by
+// DelegationRevocation to reference the delegation. See FingerprintWithProof.
+func (d DelegationDoc) Fingerprint() (string, error) {
+	jsonBytes, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	return fingerprint(jsonBytes)
+}
+
+// validate statically checks the delegation, without verifying the proof.
+func (d DelegationDoc) validate() error {
+	if d.Type != DelegationType {
+		return fmt.Errorf("delegation type must be %s: %s", DelegationType, d.Type)
+	}
+	if err := ValidateDID(d.Delegator); err != nil {
+		return errors.Wrap(err, "invalid delegator")
+	}
+	if err := ValidateDID(d.Delegate); err != nil {
+		return errors.Wrap(err, "invalid delegate")
+	}
+	if Equal(d.Delegator, d.Delegate) {
+		return fmt.Errorf("DID<%s> cannot delegate to itself", d.Delegator)
+	}
+	if len(d.Actions) == 0 {
+		return errors.New("delegation must allow at least one action")
+	}
+	validFrom, validUntil, err := d.window()
+	if err != nil {
+		return err
+	}
+	if !validUntil.IsZero() && !validUntil.After(validFrom) {
+		return errors.New("delegation must expire after it becomes valid")
+	}
+	return nil
+}
+
+// window parses the validity window. validUntil is zero if the delegation does not expire.
+func (d DelegationDoc) window() (validFrom, validUntil time.Time, err error) {
+	if validFrom, err = util.ParseRFC3339Lenient(d.ValidFrom); err != nil {
+		return validFrom, validUntil, errors.Wrap(err, "invalid validFrom")
+	}
+	if d.ValidUntil != "" {
+		if validUntil, err = util.ParseRFC3339Lenient(d.ValidUntil); err != nil {
+			return validFrom, validUntil, errors.Wrap(err, "invalid validUntil")
+		}
+	}
+	return validFrom, validUntil, nil
+}
+
+// DelegationRevocation revokes a DelegationDoc. It references the delegation by its fingerprint,
+// and must be signed by one of the delegator's keys.
+type DelegationRevocation struct {
+	Type      string `json:"type"`
+	Delegator string `json:"delegator"`
+	// Delegation is the fingerprint of the revoked delegation (see DelegationDoc.Fingerprint).
+	Delegation string `json:"delegation"`
+	// Revoked is the datetime (RFC3339) when the delegation was revoked.
+	Revoked string       `json:"revoked"`
+	Proof   *proof.Proof `json:"proof,omitempty"`
+}
+
+func (r *DelegationRevocation) GetProof() *proof.Proof {
+	return r.Proof
+}
+
+func (r *DelegationRevocation) SetProof(p *proof.Proof) {
+	r.Proof = p
+}
+
+// GetID returns the fingerprint of the revoked delegation, which identifies the revocation since a
+// delegation is only revoked once.
+func (r *DelegationRevocation) GetID() string {
+	return r.Delegation
+}
+
+// DelegationVerifier issues, verifies and revokes delegations, resolving the delegator's and
+// delegate's DID Documents with the Resolver.
+type DelegationVerifier struct {
+	Resolver Resolver
+	// Now returns the current time. Defaults to time.Now; intended for tests.
+	Now func() time.Time
+}
+
+// Issue signs the delegation with the signer, whose key must be one of the delegator's current
+// keys. Recovery keys cannot sign delegations. The delegate's DID must resolve to an active DID
+// Document. If the type is not set, it is set to DelegationType.
+func (v DelegationVerifier) Issue(ctx context.Context, delegation DelegationDoc, signer proof.Signer) (*DelegationDoc, error) {
+	if delegation.Type == "" {
+		delegation.Type = DelegationType
+	}
+	delegation.Proof = nil
+	if err := delegation.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := v.resolveActive(ctx, delegation.Delegate); err != nil {
+		return nil, err
+	}
+	if err := v.sign(ctx, &delegation, delegation.Delegator, signer); err != nil {
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+// Verify checks that the delegation is well formed, is signed by one of the delegator's current
+// keys, and is within its validity window. Both DIDs must resolve to active DID Documents.
+// ErrDelegationRevoked is returned if any of the revocations is a valid revocation of the
+// delegation; revocations of other delegations are ignored.
+func (v DelegationVerifier) Verify(ctx context.Context, delegation DelegationDoc, revocations ...DelegationRevocation) error {
+	if err := delegation.validate(); err != nil {
+		return err
+	}
+	if _, err := v.resolveActive(ctx, delegation.Delegate); err != nil {
+		return err
+	}
+	if err := v.verify(ctx, &delegation, delegation.Delegator); err != nil {
+		return err
+	}
+
+	validFrom, validUntil, err := delegation.window()
+	if err != nil {
+		return err
+	}
+	now := v.now()
+	if now.Before(validFrom) || (!validUntil.IsZero() && !now.Before(validUntil)) {
+		return errors.Wrapf(ErrDelegationNotValid, "valid from %s until %s", delegation.ValidFrom, delegation.ValidUntil)
+	}
+
+	fingerprint, err := delegation.Fingerprint()
+	if err != nil {
+		return err
+	}
+	for _, revocation := range revocations {
+		if revocation.Delegation != fingerprint {
+			continue
+		}
+		if err := v.verifyRevocation(ctx, revocation, delegation.Delegator); err != nil {
+			return errors.Wrap(err, "invalid revocation")
+		}
+		return errors.Wrapf(ErrDelegationRevoked, "revoked at %s", revocation.Revoked)
+	}
+	return nil
+}
+
+// Revoke creates a revocation of the delegation, signed by the signer, whose key must be one of
+// the delegator's current keys.
+func (v DelegationVerifier) Revoke(ctx context.Context, delegation DelegationDoc, signer proof.Signer) (*DelegationRevocation, error) {
+	fingerprint, err := delegation.Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	revocation := DelegationRevocation{
+		Type:       DelegationRevocationType,
+		Delegator:  delegation.Delegator,
+		Delegation: fingerprint,
+		Revoked:    util.FormatCanonicalTime(v.now()),
+	}
+	if err := v.sign(ctx, &revocation, delegation.Delegator, signer); err != nil {
+		return nil, err
+	}
+	return &revocation, nil
+}
+
+func (v DelegationVerifier) verifyRevocation(ctx context.Context, revocation DelegationRevocation, delegator string) error {
+	if revocation.Type != DelegationRevocationType {
+		return fmt.Errorf("revocation type must be %s: %s", DelegationRevocationType, revocation.Type)
+	}
+	if !Equal(revocation.Delegator, delegator) {
+		return fmt.Errorf("revocation delegator<%s> does not match DID<%s>", revocation.Delegator, delegator)
+	}
+	return v.verify(ctx, &revocation, delegator)
+}
+
+// sign signs the provable on behalf of the DID, checking that the signer's key is one of the DID's
+// current keys so that the proof can be verified.
+func (v DelegationVerifier) sign(ctx context.Context, provable proof.Provable, did string, signer proof.Signer) error {
+	if !Equal(ExtractDIDFromKeyRef(signer.ID()), did) {
+		return errcode.Errorf(errcode.KeyNotFound, "signer key<%s> does not belong to DID<%s>", signer.ID(), did)
+	}
+	doc, err := v.resolveActive(ctx, did)
+	if err != nil {
+		return err
+	}
+	keyDef, err := doc.AuthorizedKey(signer.ID(), ProofOperation)
+	if err != nil {
+		return err
+	}
+	if err := checkSignerPublicKey(*keyDef, signer); err != nil {
+		return err
+	}
+	suite, err := suiteForSigner(signer)
+	if err != nil {
+		return err
+	}
+	return suite.Sign(provable, signer)
+}
+
+// verify verifies the proof on the provable against the current keys of the DID.
+func (v DelegationVerifier) verify(ctx context.Context, provable proof.Provable, did string) error {
+	p := provable.GetProof()
+	if p.IsEmpty() {
+		return errcode.New(errcode.MalformedProof, "proof cannot be empty")
+	}
+	if !Equal(ExtractDIDFromKeyRef(p.GetVerificationMethod()), did) {
+		return errcode.Errorf(errcode.KeyNotFound, "proof key<%s> does not belong to DID<%s>", p.GetVerificationMethod(), did)
+	}
+	doc, err := v.resolveActive(ctx, did)
+	if err != nil {
+		return err
+	}
+	return VerifyProofForOperation(provable, doc.UnsignedDIDDoc, ProofOperation)
+}
+
+func (v DelegationVerifier) resolveActive(ctx context.Context, did string) (*DIDDoc, error) {
+	result, err := v.Resolver.Resolve(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if result.DocumentMetadata.Deactivated {
+		return nil, errcode.Errorf(errcode.Deactivated, "DID<%s> has been deactivated", did)
+	}
+	return result.DIDDoc, nil
+}
+
+func (v DelegationVerifier) now() time.Time {
+	if v.Now == nil {
+		return time.Now()
+	}
+	return v.Now()
+}
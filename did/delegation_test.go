@@ -0,0 +1,157 @@
+package did
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestDelegation(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	dependentDoc, dependentKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	guardianDoc, guardianKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	require.NoError(t, registry.Put(*dependentDoc))
+	require.NoError(t, registry.Put(*guardianDoc))
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	verifier := DelegationVerifier{Resolver: registry, Now: func() time.Time { return now }}
+	dependentSigner, err := proof.NewEd25519Signer(dependentKey, dependentDoc.PublicKey[0].ID)
+	require.NoError(t, err)
+	guardianSigner, err := proof.NewEd25519Signer(guardianKey, guardianDoc.PublicKey[0].ID)
+	require.NoError(t, err)
+
+	unsigned := DelegationDoc{
+		Delegator:  dependentDoc.ID,
+		Delegate:   guardianDoc.ID,
+		Actions:    []string{"present", "accept"},
+		ValidFrom:  "2020-01-01T00:00:00Z",
+		ValidUntil: "2021-01-01T00:00:00Z",
+	}
+	delegation, err := verifier.Issue(ctx, unsigned, dependentSigner)
+	require.NoError(t, err)
+
+	t.Run("Issue and verify", func(t *testing.T) {
+		assert.Equal(t, DelegationType, delegation.Type)
+		assert.Equal(t, dependentDoc.PublicKey[0].ID, delegation.Proof.GetVerificationMethod())
+		assert.True(t, delegation.Allows("present"))
+		assert.False(t, delegation.Allows("issue"))
+		assert.NoError(t, verifier.Verify(ctx, *delegation))
+	})
+
+	t.Run("Refuses to issue with delegate's key", func(t *testing.T) {
+		_, err := verifier.Issue(ctx, unsigned, guardianSigner)
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid delegations", func(t *testing.T) {
+		invalid := map[string]func(d *DelegationDoc){
+			"Self delegation": func(d *DelegationDoc) { d.Delegate = d.Delegator },
+			"No actions":      func(d *DelegationDoc) { d.Actions = nil },
+			"Bad validFrom":   func(d *DelegationDoc) { d.ValidFrom = "yesterday" },
+			"Empty window":    func(d *DelegationDoc) { d.ValidUntil = d.ValidFrom },
+			"Unknown DID":     func(d *DelegationDoc) { d.Delegate = GenerateDID(issuerPubKey) },
+		}
+		for name, mutate := range invalid {
+			d := unsigned
+			mutate(&d)
+			_, err := verifier.Issue(ctx, d, dependentSigner)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		tampered := *delegation
+		tampered.Actions = append([]string{"issue"}, tampered.Actions...)
+		assert.Error(t, verifier.Verify(ctx, tampered))
+
+		tampered = *delegation
+		tampered.Delegate = GenerateDID(issuerPubKey)
+		assert.Error(t, verifier.Verify(ctx, tampered))
+	})
+
+	t.Run("Validity window", func(t *testing.T) {
+		for _, at := range []time.Time{
+			time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		} {
+			at := at
+			v := DelegationVerifier{Resolver: registry, Now: func() time.Time { return at }}
+			assert.True(t, errors.Is(v.Verify(ctx, *delegation), ErrDelegationNotValid), at)
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		revocation, err := verifier.Revoke(ctx, *delegation, dependentSigner)
+		require.NoError(t, err)
+		fingerprint, err := delegation.Fingerprint()
+		require.NoError(t, err)
+		assert.Equal(t, fingerprint, revocation.Delegation)
+		assert.Equal(t, "2020-06-01T12:00:00Z", revocation.Revoked)
+
+		err = verifier.Verify(ctx, *delegation, *revocation)
+		assert.True(t, errors.Is(err, ErrDelegationRevoked))
+
+		other, err := verifier.Issue(ctx, DelegationDoc{
+			Delegator: dependentDoc.ID,
+			Delegate:  guardianDoc.ID,
+			Actions:   []string{"present"},
+			ValidFrom: "2020-01-01T00:00:00Z",
+		}, dependentSigner)
+		require.NoError(t, err)
+		assert.NoError(t, verifier.Verify(ctx, *other, *revocation))
+	})
+
+	t.Run("Revocation must be signed by delegator", func(t *testing.T) {
+		_, err := verifier.Revoke(ctx, *delegation, guardianSigner)
+		assert.Error(t, err)
+
+		forged, err := verifier.Revoke(ctx, *delegation, dependentSigner)
+		require.NoError(t, err)
+		forged.Revoked = "2020-01-01T00:00:00Z"
+		err = verifier.Verify(ctx, *delegation, *forged)
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrDelegationRevoked))
+	})
+}
+
+// The envelope types of this package that have an identifier of their own.
+var (
+	_ proof.Identifiable = (*DIDDoc)(nil)
+	_ proof.Identifiable = (*DelegationDoc)(nil)
+	_ proof.Identifiable = (*DelegationRevocation)(nil)
+	_ proof.Identifiable = (*PossessionChallenge)(nil)
+	_ proof.Identifiable = (*PossessionResponse)(nil)
+)
+
+func TestIDOf(t *testing.T) {
+	delegation := DelegationDoc{Type: DelegationType, Delegator: testWorkDID, Delegate: testWorkDID, Actions: []string{"sign"}}
+	fingerprint, err := delegation.Fingerprint()
+	require.NoError(t, err)
+	challenge := PossessionChallenge{Audience: testWorkDID, Nonce: "nonce"}
+	tests := []struct {
+		provable proof.Provable
+		id       string
+	}{
+		{&delegation, fingerprint},
+		{&DelegationRevocation{Delegation: fingerprint}, fingerprint},
+		{&challenge, "nonce"},
+		{&PossessionResponse{Holder: testWorkDID, Challenge: challenge}, "nonce"},
+	}
+	for _, test := range tests {
+		id, ok := proof.IDOf(test.provable)
+		assert.True(t, ok)
+		assert.Equal(t, test.id, id)
+	}
+
+	// A receipt has no identifier of its own: the target ID is shared by the receipts of every
+	// operation on the target.
+	_, ok := proof.IDOf(&Receipt{TargetID: testWorkDID})
+	assert.False(t, ok)
+}
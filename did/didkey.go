@@ -0,0 +1,272 @@
+package did
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+	"github.com/workdaycredentials/ledger-common/util/multibase"
+	"github.com/workdaycredentials/ledger-common/util/multicodec"
+)
+
+const (
+	// Codec for X25519 multi-format. See multicodec.X25519Pub.
+	X25519Codec = byte(multicodec.X25519Pub)
+
+	// Codec for secp256k1 multi-format. See multicodec.Secp256k1Pub.
+	Secp256k1Codec = byte(multicodec.Secp256k1Pub)
+
+	// Codec for P-256 multi-format. Unlike the other key codecs it does not fit in a single byte,
+	// so it is always written as an unsigned varint (0x80, 0x24). See multicodec.P256Pub.
+	P256Codec = uint64(multicodec.P256Pub)
+)
+
+var (
+	// ErrMalformedDIDKey is returned when a DID Key cannot be decoded, or when the encoded public
+	// key is not valid for its codec.
+	ErrMalformedDIDKey = errcode.New(errcode.DIDMalformed, "malformed DID Key")
+
+	// ErrUnsupportedKeyCodec is returned when a DID Key encodes a type of key that is not
+	// supported, or not supported by the caller.
+	ErrUnsupportedKeyCodec = errcode.New(errcode.DIDMalformed, "unsupported DID Key codec")
+)
+
+// GenerateDIDKeySecp256k1 generates a DID Key in the form of "did:key:<id>" based on a secp256k1
+// public key. The key is encoded in compressed SEC1 form, as required by the DID Key Method.
+func GenerateDIDKeySecp256k1(publicKey *ecdsa.PublicKey) (string, error) {
+	if publicKey == nil || publicKey.Curve != btcec.S256() {
+		return "", errors.New("must have valid secp256k1 public key")
+	}
+	compressed := (*btcec.PublicKey)(publicKey).SerializeCompressed()
+	return KeyDIDMethod + multicodecEncode(uint64(Secp256k1Codec), compressed), nil
+}
+
+// GenerateDIDKeyP256 generates a DID Key in the form of "did:key:<id>" based on a P-256 public
+// key. The key is encoded in compressed SEC1 form, as required by the DID Key Method.
+func GenerateDIDKeyP256(publicKey *ecdsa.PublicKey) (string, error) {
+	if publicKey == nil || publicKey.Curve != elliptic.P256() {
+		return "", errors.New("must have valid P-256 public key")
+	}
+	return KeyDIDMethod + multicodecEncode(P256Codec, compressP256(publicKey)), nil
+}
+
+// ExtractPublicKeyFromDIDKey extracts the public key from a DID Key along with the type of the key.
+// Ed25519 keys are returned as raw 32 byte keys, and secp256k1 and P-256 keys are returned in
+// compressed SEC1 form. The key type is determined by the multicodec rather than the key length,
+// since secp256k1 and P-256 compressed keys are the same size.
+func ExtractPublicKeyFromDIDKey(didKey string) ([]byte, proof.KeyType, error) {
+	codec, keyBytes, err := decodeDIDKey(didKey)
+	if err != nil {
+		return nil, "", err
+	}
+	keyType, ok := multicodecKeyType(codec, keyBytes)
+	if !ok {
+		if supportedKeyCodec(codec) {
+			return nil, "", errors.Wrapf(ErrMalformedDIDKey, "key cannot be extracted from DID<%s>", didKey)
+		}
+		return nil, "", errors.Wrapf(ErrUnsupportedKeyCodec, "key cannot be extracted from DID<%s>", didKey)
+	}
+	return keyBytes, keyType, nil
+}
+
+// supportedKeyCodec returns true if public keys with the multicodec can be extracted from DID Keys.
+func supportedKeyCodec(codec uint64) bool {
+	switch codec {
+	case uint64(Ed25519Codec), uint64(Secp256k1Codec), P256Codec:
+		return true
+	}
+	return false
+}
+
+// multicodecKeyType returns the key type for a multicodec encoded public key. Returns false if the
+// codec is not supported or the key is not valid for the codec.
+func multicodecKeyType(codec uint64, keyBytes []byte) (proof.KeyType, bool) {
+	switch codec {
+	case uint64(Ed25519Codec):
+		if len(keyBytes) == ed25519.PublicKeySize {
+			return proof.Ed25519KeyType, true
+		}
+	case uint64(Secp256k1Codec):
+		if len(keyBytes) == btcec.PubKeyBytesLenCompressed {
+			if _, err := btcec.ParsePubKey(keyBytes, btcec.S256()); err == nil {
+				return proof.EcdsaSecp256k1KeyType, true
+			}
+		}
+	case P256Codec:
+		if _, err := decompressP256(keyBytes); err == nil {
+			return proof.EcdsaSecp256r1KeyType, true
+		}
+	}
+	return "", false
+}
+
+// ResolveDIDKey expands a did:key identifier into the DID Document defined by the DID Key Method.
+// The document contains a single verification method, referenced by both authentication and
+// assertionMethod. Ed25519 documents also contain an X25519 key agreement key derived from the
+// Ed25519 public key. The verification method fragments are the multibase encoded keys, as per
+// the specification.
+//
+// DID Key Documents are generated rather than registered, and are therefore never signed.
+// Both the spec-compliant varint multicodec prefix and the legacy single byte prefix written by
+// GenerateLegacyDIDKey are accepted.
+// See https://w3c-ccg.github.io/did-method-key
+func ResolveDIDKey(didKey string) (*DIDDoc, error) {
+	keyBytes, keyType, err := ExtractPublicKeyFromDIDKey(didKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID := GenerateKeyID(didKey, strings.TrimPrefix(didKey, KeyDIDMethod))
+	doc := DIDDoc{
+		UnsignedDIDDoc: UnsignedDIDDoc{
+			ID: didKey,
+			PublicKey: []KeyDef{{
+				ID:              keyID,
+				Type:            keyType,
+				Controller:      didKey,
+				PublicKeyBase58: base58.Encode(keyBytes),
+			}},
+			Authentication:  []string{keyID},
+			AssertionMethod: []string{keyID},
+		},
+	}
+
+	if keyType == proof.Ed25519KeyType {
+		x25519Key, err := util.Ed25519PublicToX25519(keyBytes)
+		if err != nil {
+			return nil, errors.Wrapf(ErrMalformedDIDKey, "DID<%s>: %v", didKey, err)
+		}
+		doc.KeyAgreement = []KeyDef{{
+			ID:              GenerateKeyID(didKey, multicodecEncode(uint64(X25519Codec), x25519Key)),
+			Type:            proof.X25519KeyType,
+			Controller:      didKey,
+			PublicKeyBase58: base58.Encode(x25519Key),
+		}}
+	}
+	return &doc, nil
+}
+
+// EncryptionKeyForDID returns the X25519 key agreement public key of an Ed25519 DID Key. The key is
+// derived from the Ed25519 public key, and is the same key that ResolveDIDKey lists under
+// keyAgreement.
+func EncryptionKeyForDID(didKey string) (x25519Pub []byte, err error) {
+	keyBytes, keyType, err := ExtractPublicKeyFromDIDKey(didKey)
+	if err != nil {
+		return nil, err
+	}
+	if keyType != proof.Ed25519KeyType {
+		return nil, fmt.Errorf("DID<%s> does not have an encryption key", didKey)
+	}
+	return util.Ed25519PublicToX25519(keyBytes)
+}
+
+// VerifierForKeyRef returns a Verifier for a DID Key key reference without resolving the DID,
+// since the public key is encoded in the DID itself. The fragment must be the key's own id, as
+// listed by ResolveDIDKey. ErrResolverRequired is returned for references to any other method.
+func VerifierForKeyRef(keyRef string) (proof.Verifier, error) {
+	if !strings.HasPrefix(keyRef, KeyDIDMethod) {
+		return nil, errors.Wrapf(ErrResolverRequired, "key<%s>", keyRef)
+	}
+	didKey, fragment, _ := splitKeyRef(keyRef)
+	if fragment != strings.TrimPrefix(didKey, KeyDIDMethod) {
+		return nil, errcode.Errorf(errcode.KeyNotFound, "key<%s> is not the verification key of DID<%s>", keyRef, didKey)
+	}
+	keyBytes, keyType, err := ExtractPublicKeyFromDIDKey(didKey)
+	if err != nil {
+		return nil, err
+	}
+	return AsVerifier(KeyDef{
+		ID:              keyRef,
+		Type:            keyType,
+		Controller:      didKey,
+		PublicKeyBase58: base58.Encode(keyBytes),
+	})
+}
+
+// decodeDIDKey splits a did:key identifier into its multicodec and raw key bytes. Errors wrap
+// ErrMalformedDIDKey. The key bytes are not validated against the codec.
+func decodeDIDKey(didKey string) (codec uint64, keyBytes []byte, err error) {
+	if !strings.HasPrefix(didKey, KeyDIDMethod) {
+		return 0, nil, errors.Wrapf(ErrMalformedDIDKey, "DID<%s> format not supported", didKey)
+	}
+	base, decoded, err := multibase.Decode(didKey[len(KeyDIDMethod):])
+	if errors.Is(err, multibase.ErrUnsupportedBase) || (err == nil && base != multibase.Base58BTC) {
+		return 0, nil, errors.Wrapf(ErrMalformedDIDKey, "DID<%s> format not supported", didKey)
+	}
+	if err != nil || len(decoded) == 0 {
+		return 0, nil, errors.Wrapf(ErrMalformedDIDKey, "cannot decode DID<%s>", didKey)
+	}
+
+	// GenerateDIDKey historically wrote the Ed25519 codec as a single byte rather than as an
+	// unsigned varint (0xed, 0x01). See GenerateLegacyDIDKey.
+	c, keyBytes, err := multicodec.Uvarint(decoded, true)
+	if err != nil {
+		return 0, nil, errors.Wrapf(ErrMalformedDIDKey, "key cannot be extracted from DID<%s>: %v", didKey, err)
+	}
+	return uint64(c), keyBytes, nil
+}
+
+// multicodecEncode returns the base58 multibase encoding of the key prefixed by the varint codec.
+func multicodecEncode(codec uint64, keyBytes []byte) string {
+	return encodeBase58BTC(multicodec.PutUvarint(multicodec.Codec(codec), keyBytes))
+}
+
+// encodeBase58BTC returns the base58btc multibase encoding of the data.
+func encodeBase58BTC(data []byte) string {
+	// Encoding cannot fail for a supported base.
+	encoded, _ := multibase.Encode(multibase.Base58BTC, data)
+	return encoded
+}
+
+// compressP256 encodes a P-256 public key as a compressed SEC1 point: a parity byte for y followed
+// by the 32 byte big-endian x coordinate.
+func compressP256(publicKey *ecdsa.PublicKey) []byte {
+	byteLen := (publicKey.Curve.Params().BitSize + 7) / 8
+	compressed := make([]byte, 1+byteLen)
+	compressed[0] = 0x02 | byte(publicKey.Y.Bit(0))
+	xBytes := publicKey.X.Bytes()
+	copy(compressed[1+byteLen-len(xBytes):], xBytes)
+	return compressed
+}
+
+// decompressP256 decodes a compressed SEC1 point into a P-256 public key, solving
+// y² = x³ - 3x + b for y. An error is returned if the point is not on the curve.
+func decompressP256(compressed []byte) (*ecdsa.PublicKey, error) {
+	curve := elliptic.P256()
+	params := curve.Params()
+	byteLen := (params.BitSize + 7) / 8
+	if len(compressed) != 1+byteLen || (compressed[0] != 0x02 && compressed[0] != 0x03) {
+		return nil, errors.New("invalid compressed P-256 public key")
+	}
+	x := new(big.Int).SetBytes(compressed[1:])
+	if x.Cmp(params.P) >= 0 {
+		return nil, errors.New("invalid compressed P-256 public key")
+	}
+
+	x3 := new(big.Int).Mul(x, x)
+	x3.Mul(x3, x)
+	threeX := new(big.Int).Lsh(x, 1)
+	threeX.Add(threeX, x)
+	ySquared := new(big.Int).Sub(x3, threeX)
+	ySquared.Add(ySquared, params.B)
+	ySquared.Mod(ySquared, params.P)
+
+	y := new(big.Int).ModSqrt(ySquared, params.P)
+	if y == nil {
+		return nil, errors.New("P-256 public key is not on the curve")
+	}
+	if y.Bit(0) != uint(compressed[0]&1) {
+		y.Sub(params.P, y)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
@@ -0,0 +1,520 @@
+package did
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	mathrand "math/rand"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/multicodec"
+)
+
+// didKeyVectors are Ed25519 test vectors from the DID Key Method specification.
+// See https://w3c-ccg.github.io/did-method-key
+var didKeyVectors = []struct {
+	did             string
+	publicKeyBase58 string
+	keyAgreementID  string
+	x25519Base58    string
+}{
+	{
+		did:             "did:key:z6MkpTHR8VNsBxYAAWHut2Geadd9jSwuBV8xRoAnwWsdvktH",
+		publicKeyBase58: "B12NYF8RrR3h41TDCTJojY59usg3mbtbjnFs7Eud1Y6u",
+		keyAgreementID:  "z6LSbysY2xFMRpGMhb7tFTLMpeuPRaqaWM1yECx2AtzE3KCc",
+		x25519Base58:    "JhNWeSVLMYccCk7iopQW4guaSJTojqpMEELgSLhKwRr",
+	},
+	{
+		did:             "did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp",
+		publicKeyBase58: "4zvwRjXUKGfvwnParsHAS3HuSVzV5cA4McphgmoCtajS",
+		keyAgreementID:  "z6LShs9GGnqk85isEBzzshkuVWrVKsRp24GnDuHk8QWkARMW",
+		x25519Base58:    "7By6kV2t2d188odEM4ExAve1UithKT6dLva4dwsDT3ak",
+	},
+	{
+		did:             "did:key:z6MkjchhfUsD6mmvni8mCdXHw216Xrm9bQe2mBH1P5RDjVJG",
+		publicKeyBase58: "6ASf5EcmmEHTgDJ4X4ZT5vT6iHVJBXPg5AN5YoTCpGWt",
+		keyAgreementID:  "z6LSrHyXiPBhUbvPUtyUCdf32sniiMGPTAesgHrtEa4FePtr",
+		x25519Base58:    "FcoNC5NqP9CePWbhfz95iHaEsCjGkZUioK9Ck7Qiw286",
+	},
+	{
+		did:             "did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK",
+		publicKeyBase58: "48GdbJyVULjHDaBNS6ct9oAGtckZUS5v8asrPzvZ7R1w",
+		keyAgreementID:  "z6LSj72tK8brWgZja8NLRwPigth2T9QRiG1uH9oKZuKjdh9p",
+		x25519Base58:    "8RrinpnzRDqzUjzZuHsmNJUYbzsK1eqkQB5e5SgCvKP4",
+	},
+}
+
+func TestResolveDIDKey(t *testing.T) {
+	for _, vector := range didKeyVectors {
+		t.Run(vector.did, func(t *testing.T) {
+			doc, err := ResolveDIDKey(vector.did)
+			require.NoError(t, err)
+
+			keyID := vector.did + "#" + vector.did[len(KeyDIDMethod):]
+			assert.Equal(t, vector.did, doc.ID)
+			assert.Nil(t, doc.Proof)
+			assert.Equal(t, []KeyDef{{
+				ID:              keyID,
+				Type:            proof.Ed25519KeyType,
+				Controller:      vector.did,
+				PublicKeyBase58: vector.publicKeyBase58,
+			}}, doc.PublicKey)
+			assert.Equal(t, []string{keyID}, doc.Authentication)
+			assert.Equal(t, []string{keyID}, doc.AssertionMethod)
+			assert.Equal(t, []KeyDef{{
+				ID:              vector.did + "#" + vector.keyAgreementID,
+				Type:            proof.X25519KeyType,
+				Controller:      vector.did,
+				PublicKeyBase58: vector.x25519Base58,
+			}}, doc.KeyAgreement)
+		})
+	}
+
+	t.Run("Legacy single byte codec", func(t *testing.T) {
+		didKey := GenerateLegacyDIDKey(issuerPubKey)
+		doc, err := ResolveDIDKey(didKey)
+		require.NoError(t, err)
+		require.Len(t, doc.PublicKey, 1)
+		assert.Equal(t, base58.Encode(issuerPubKey), doc.PublicKey[0].PublicKeyBase58)
+		assert.Equal(t, GenerateKeyID(didKey, didKey[len(KeyDIDMethod):]), doc.PublicKey[0].ID)
+		assert.Len(t, doc.KeyAgreement, 1)
+	})
+
+	t.Run("Resolved key verifies signatures", func(t *testing.T) {
+		doc, err := ResolveDIDKey(GenerateDIDKey(issuerPubKey))
+		require.NoError(t, err)
+
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+		require.NoError(t, err)
+		signed := &DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: "did:work:test"}}
+		require.NoError(t, suite.Sign(signed, signer))
+
+		verifier, err := AsVerifier(doc.PublicKey[0])
+		require.NoError(t, err)
+		assert.NoError(t, suite.Verify(signed, verifier))
+	})
+
+	t.Run("Unsupported DIDs", func(t *testing.T) {
+		for _, bad := range []string{
+			"did:work:6sYe1y3zXhmyrBkgHgAgaq",
+			"did:key:x12345678",
+			"did:key:z",
+			"did:key:z12345678",
+		} {
+			_, err := ResolveDIDKey(bad)
+			assert.Error(t, err, bad)
+		}
+	})
+}
+
+func TestEncryptionKeyForDID(t *testing.T) {
+	for _, vector := range didKeyVectors {
+		t.Run(vector.did, func(t *testing.T) {
+			x25519Pub, err := EncryptionKeyForDID(vector.did)
+			require.NoError(t, err)
+			assert.Equal(t, vector.x25519Base58, base58.Encode(x25519Pub))
+		})
+	}
+
+	t.Run("Ed25519 to X25519 known answers", func(t *testing.T) {
+		for _, vector := range didKeyVectors {
+			edPub, err := base58.Decode(vector.publicKeyBase58)
+			require.NoError(t, err)
+			x25519Pub, err := util.Ed25519PublicToX25519(edPub)
+			require.NoError(t, err)
+			assert.Equal(t, vector.x25519Base58, base58.Encode(x25519Pub))
+			assert.Equal(t, vector.keyAgreementID, multicodecEncode(uint64(X25519Codec), x25519Pub))
+		}
+	})
+
+	t.Run("Low order key", func(t *testing.T) {
+		// The Ed25519 point of order 8 has no usable X25519 key.
+		lowOrder, err := hex.DecodeString("c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a")
+		require.NoError(t, err)
+		didKey := KeyDIDMethod + multicodecEncode(uint64(Ed25519Codec), lowOrder)
+		_, err = EncryptionKeyForDID(didKey)
+		assert.EqualError(t, err, "invalid Ed25519 public key: low order point")
+		_, err = ResolveDIDKey(didKey)
+		assert.True(t, errors.Is(err, ErrMalformedDIDKey))
+	})
+
+	t.Run("Non-Ed25519 key", func(t *testing.T) {
+		_, err := EncryptionKeyForDID(secp256k1DIDKeyVectors[0].did)
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid DID", func(t *testing.T) {
+		_, err := EncryptionKeyForDID("did:work:6sYe1y3zXhmyrBkgHgAgaq")
+		assert.Error(t, err)
+	})
+}
+
+// secp256k1DIDKeyVectors are secp256k1 test vectors from the DID Key Method specification.
+var secp256k1DIDKeyVectors = []struct {
+	did             string
+	publicKeyBase58 string
+}{
+	{
+		did:             "did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme",
+		publicKeyBase58: "23o6Sau8NxxzXcgSc3PLcNxrzrZpbLeBn1izfv3jbKhuv",
+	},
+	{
+		did:             "did:key:zQ3shtxV1FrJfhqE1dvxYRcCknWNjHc3c5X1y3ZSoPDi2aur2",
+		publicKeyBase58: "291KzQhqCPC18PqH83XKhxv1HdqrdnxyS7dh15t2uNRzJ",
+	},
+	{
+		did:             "did:key:zQ3shZc2QzApp2oymGvQbzP8eKheVshBHbU4ZYjeXqwSKEn6N",
+		publicKeyBase58: "oesQ92MLiAkt2pjBcJFbW7H4DvzKJv22cotjYbmC2JEe",
+	},
+}
+
+func TestDIDKeySecp256k1(t *testing.T) {
+	privateKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	publicKey := privateKey.PubKey()
+
+	t.Run("Round trip", func(t *testing.T) {
+		didKey, err := GenerateDIDKeySecp256k1(publicKey.ToECDSA())
+		require.NoError(t, err)
+		assert.True(t, len(didKey) > len("did:key:zQ3s"))
+		assert.Equal(t, "did:key:zQ3s", didKey[:len("did:key:zQ3s")])
+
+		keyBytes, keyType, err := ExtractPublicKeyFromDIDKey(didKey)
+		require.NoError(t, err)
+		assert.Equal(t, proof.EcdsaSecp256k1KeyType, keyType)
+		assert.Equal(t, publicKey.SerializeCompressed(), keyBytes)
+	})
+
+	t.Run("Resolved key verifies a secp256k1 proof", func(t *testing.T) {
+		didKey, err := GenerateDIDKeySecp256k1(publicKey.ToECDSA())
+		require.NoError(t, err)
+		doc, err := ResolveDIDKey(didKey)
+		require.NoError(t, err)
+		require.Len(t, doc.PublicKey, 1)
+		assert.Equal(t, proof.EcdsaSecp256k1KeyType, doc.PublicKey[0].Type)
+		assert.Empty(t, doc.KeyAgreement)
+
+		signer, err := proof.NewSecp256k1Signer(privateKey.ToECDSA(), doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		suite, err := proof.SignatureSuites().GetSuite(proof.EcdsaSecp256k1SignatureType, proof.V1)
+		require.NoError(t, err)
+		signed := &DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: "did:work:test"}}
+		require.NoError(t, suite.Sign(signed, signer))
+
+		verifier, err := AsVerifier(doc.PublicKey[0])
+		require.NoError(t, err)
+		assert.NoError(t, suite.Verify(signed, verifier))
+
+		signed.ID = "did:work:tampered"
+		assert.Error(t, suite.Verify(signed, verifier))
+	})
+
+	t.Run("Specification vectors", func(t *testing.T) {
+		for _, vector := range secp256k1DIDKeyVectors {
+			doc, err := ResolveDIDKey(vector.did)
+			require.NoError(t, err, vector.did)
+			require.Len(t, doc.PublicKey, 1)
+			assert.Equal(t, proof.EcdsaSecp256k1KeyType, doc.PublicKey[0].Type)
+			assert.Equal(t, vector.publicKeyBase58, doc.PublicKey[0].PublicKeyBase58)
+
+			_, err = AsVerifier(doc.PublicKey[0])
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("Invalid public key", func(t *testing.T) {
+		_, err := GenerateDIDKeySecp256k1(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Ed25519 extraction", func(t *testing.T) {
+		keyBytes, keyType, err := ExtractPublicKeyFromDIDKey(GenerateDIDKey(issuerPubKey))
+		require.NoError(t, err)
+		assert.Equal(t, proof.Ed25519KeyType, keyType)
+		assert.Equal(t, []byte(issuerPubKey), keyBytes)
+	})
+}
+
+// p256DIDKeyVectors are P-256 test vectors from the DID Key Method specification.
+var p256DIDKeyVectors = []struct {
+	did             string
+	publicKeyBase58 string
+}{
+	{
+		did:             "did:key:zDnaerDaTF5BXEavCrfRZEk316dpbLsfPDZ3WJ5hRTPFU2169",
+		publicKeyBase58: "23FF9c3MrW7NkEW6uNDvdSKQMJ4YFTBXNMEPytZfYeE33",
+	},
+	{
+		did:             "did:key:zDnaerx9CtbPJ1q36T5Ln5wYt3MQYeGRG5ehnPAmxcf5mDZpv",
+		publicKeyBase58: "23youFZZdHMVdpv28DRSWP2zJbTJ8KHBeSKUX3qVqqnmp",
+	},
+}
+
+func TestDIDKeyP256(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("Round trip", func(t *testing.T) {
+		didKey, err := GenerateDIDKeyP256(&privateKey.PublicKey)
+		require.NoError(t, err)
+		assert.Equal(t, "did:key:zDn", didKey[:len("did:key:zDn")])
+
+		keyBytes, keyType, err := ExtractPublicKeyFromDIDKey(didKey)
+		require.NoError(t, err)
+		assert.Equal(t, proof.EcdsaSecp256r1KeyType, keyType)
+
+		publicKey, err := decompressP256(keyBytes)
+		require.NoError(t, err)
+		assert.Equal(t, privateKey.X, publicKey.X)
+		assert.Equal(t, privateKey.Y, publicKey.Y)
+	})
+
+	t.Run("Signatures verify", func(t *testing.T) {
+		didKey, err := GenerateDIDKeyP256(&privateKey.PublicKey)
+		require.NoError(t, err)
+		doc, err := ResolveDIDKey(didKey)
+		require.NoError(t, err)
+		signer, err := proof.NewCryptoSigner(privateKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+
+		verifier, err := AsVerifier(doc.PublicKey[0])
+		require.NoError(t, err)
+		assert.Equal(t, proof.EcdsaSecp256r1KeyType, verifier.Type())
+		signature, err := signer.Sign([]byte("payload"))
+		require.NoError(t, err)
+		valid, err := verifier.Verify([]byte("payload"), signature)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		token, err := proof.SignJWS([]byte(`{"iss":"`+didKey+`"}`), "JWT", signer)
+		require.NoError(t, err)
+		jws, err := proof.ParseJWS(token)
+		require.NoError(t, err)
+		assert.Equal(t, proof.ES256Algorithm, jws.Header.Alg)
+		assert.NoError(t, jws.Verify(verifier))
+	})
+
+	t.Run("Specification vectors", func(t *testing.T) {
+		for _, vector := range p256DIDKeyVectors {
+			doc, err := ResolveDIDKey(vector.did)
+			require.NoError(t, err, vector.did)
+			require.Len(t, doc.PublicKey, 1)
+			assert.Equal(t, proof.EcdsaSecp256r1KeyType, doc.PublicKey[0].Type)
+			assert.Equal(t, vector.publicKeyBase58, doc.PublicKey[0].PublicKeyBase58)
+			assert.Empty(t, doc.KeyAgreement)
+
+			// Re-encoding the decompressed key must reproduce the identifier.
+			keyBytes, err := base58.Decode(vector.publicKeyBase58)
+			require.NoError(t, err)
+			publicKey, err := decompressP256(keyBytes)
+			require.NoError(t, err)
+			didKey, err := GenerateDIDKeyP256(publicKey)
+			require.NoError(t, err)
+			assert.Equal(t, vector.did, didKey)
+		}
+	})
+
+	t.Run("Codec distinguishes curves", func(t *testing.T) {
+		// This compressed key is a valid point on both secp256k1 and P-256, so only the codec
+		// can tell them apart.
+		keyBytes, err := base58.Decode(secp256k1DIDKeyVectors[2].publicKeyBase58)
+		require.NoError(t, err)
+
+		_, keyType, err := ExtractPublicKeyFromDIDKey(secp256k1DIDKeyVectors[2].did)
+		require.NoError(t, err)
+		assert.Equal(t, proof.EcdsaSecp256k1KeyType, keyType)
+
+		_, keyType, err = ExtractPublicKeyFromDIDKey(KeyDIDMethod + multicodecEncode(P256Codec, keyBytes))
+		require.NoError(t, err)
+		assert.Equal(t, proof.EcdsaSecp256r1KeyType, keyType)
+	})
+
+	t.Run("Invalid public key", func(t *testing.T) {
+		_, err := GenerateDIDKeyP256(nil)
+		assert.Error(t, err)
+
+		secp256k1Key, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		_, err = GenerateDIDKeyP256(secp256k1Key.PubKey().ToECDSA())
+		assert.Error(t, err)
+
+		offCurve := append([]byte{0x02}, make([]byte, 32)...)
+		offCurve[32] = 0x01
+		_, _, err = ExtractPublicKeyFromDIDKey(KeyDIDMethod + multicodecEncode(P256Codec, offCurve))
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifierForKeyRef(t *testing.T) {
+	// Counts resolutions, to check that DID Key references never reach the resolver.
+	workDoc, workKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	var resolved int
+	resolver := AsVerifierResolver(ResolverFunc(func(ctx context.Context, did string) (*ResolutionResult, error) {
+		resolved++
+		if did != workDoc.ID {
+			return nil, ErrNotFound
+		}
+		return &ResolutionResult{DIDDoc: workDoc}, nil
+	}))
+
+	sign := func(signer proof.Signer, sigType proof.SignatureType, version proof.ModelVersion) *proof.GenericProvable {
+		suite, err := proof.SignatureSuites().GetSuite(sigType, version)
+		require.NoError(t, err)
+		provable := &proof.GenericProvable{JSONData: `{"hello":"world"}`}
+		require.NoError(t, suite.Sign(provable, signer))
+		return provable
+	}
+
+	t.Run("Ed25519 DID Key", func(t *testing.T) {
+		resolved = 0
+		didKey := GenerateDIDKey(workKey.Public().(ed25519.PublicKey))
+		keyRef := GenerateKeyID(didKey, didKey[len(KeyDIDMethod):])
+		signer, err := proof.NewEd25519Signer(workKey, keyRef)
+		require.NoError(t, err)
+		provable := sign(signer, proof.JCSEdSignatureType, proof.V2)
+
+		verifier, err := VerifierForKeyRef(keyRef)
+		require.NoError(t, err)
+		assert.Equal(t, proof.Ed25519KeyType, verifier.Type())
+		assert.NoError(t, proof.VerifyWithResolver(context.Background(), provable, resolver))
+		assert.Equal(t, 0, resolved)
+	})
+
+	t.Run("Secp256k1 DID Key", func(t *testing.T) {
+		resolved = 0
+		privateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		didKey, err := GenerateDIDKeySecp256k1(privateKey.PubKey().ToECDSA())
+		require.NoError(t, err)
+		keyRef := GenerateKeyID(didKey, didKey[len(KeyDIDMethod):])
+		signer, err := proof.NewSecp256k1Signer(privateKey.ToECDSA(), keyRef)
+		require.NoError(t, err)
+		provable := sign(signer, proof.EcdsaSecp256k1SignatureType, proof.V1)
+
+		verifier, err := VerifierForKeyRef(keyRef)
+		require.NoError(t, err)
+		assert.Equal(t, proof.EcdsaSecp256k1KeyType, verifier.Type())
+		assert.NoError(t, proof.VerifyWithResolver(context.Background(), provable, resolver))
+		assert.Equal(t, 0, resolved)
+	})
+
+	t.Run("Every key codec", func(t *testing.T) {
+		// X25519 keys only agree keys, so they cannot verify proofs.
+		for _, codec := range multicodec.PublicKeyCodecs() {
+			keyType, _ := multicodec.KeyTypeForCodec(codec)
+			t.Run(keyType, func(t *testing.T) {
+				var (
+					keyBytes []byte
+					signer   crypto.Signer
+				)
+				switch proof.KeyType(keyType) {
+				case proof.Ed25519KeyType:
+					publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+					require.NoError(t, err)
+					keyBytes, signer = publicKey, privateKey
+				case proof.EcdsaSecp256k1KeyType:
+					privateKey, err := btcec.NewPrivateKey(btcec.S256())
+					require.NoError(t, err)
+					keyBytes, signer = privateKey.PubKey().SerializeCompressed(), privateKey.ToECDSA()
+				case proof.EcdsaSecp256r1KeyType:
+					privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+					require.NoError(t, err)
+					keyBytes, signer = elliptic.MarshalCompressed(elliptic.P256(), privateKey.X, privateKey.Y), privateKey
+				case proof.X25519KeyType:
+					keyBytes = make([]byte, 32)
+					keyBytes[0] = 9
+				default:
+					t.Fatalf("no test key for codec 0x%x", codec)
+				}
+				didKey := KeyDIDMethod + multicodecEncode(uint64(codec), keyBytes)
+				keyRef := GenerateKeyID(didKey, didKey[len(KeyDIDMethod):])
+				verifier, err := VerifierForKeyRef(keyRef)
+				if signer == nil {
+					assert.True(t, errors.Is(err, ErrUnsupportedKeyCodec))
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, proof.KeyType(keyType), verifier.Type())
+
+				cryptoSigner, err := proof.NewCryptoSigner(signer, keyRef)
+				require.NoError(t, err)
+				signature, err := cryptoSigner.Sign([]byte("payload"))
+				require.NoError(t, err)
+				valid, err := verifier.Verify([]byte("payload"), signature)
+				require.NoError(t, err)
+				assert.True(t, valid)
+			})
+		}
+	})
+
+	t.Run("did:work key takes the resolver path", func(t *testing.T) {
+		resolved = 0
+		keyRef := workDoc.PublicKey[0].ID
+		_, err := VerifierForKeyRef(keyRef)
+		assert.True(t, errors.Is(err, ErrResolverRequired))
+
+		signer, err := proof.NewEd25519Signer(workKey, keyRef)
+		require.NoError(t, err)
+		assert.NoError(t, proof.VerifyWithResolver(context.Background(), sign(signer, proof.JCSEdSignatureType, proof.V2), resolver))
+		assert.Equal(t, 1, resolved)
+	})
+
+	t.Run("Fragment must be the DID Key", func(t *testing.T) {
+		didKey := didKeyVectors[0].did
+		_, err := VerifierForKeyRef(didKey + "#key-1")
+		assert.Error(t, err)
+		_, err = VerifierForKeyRef(didKey)
+		assert.Error(t, err)
+		_, err = VerifierForKeyRef(didKeyVectors[0].keyAgreementID)
+		assert.Error(t, err)
+	})
+
+	t.Run("Trusted set does not take the fast path", func(t *testing.T) {
+		didKey := GenerateDIDKey(workKey.Public().(ed25519.PublicKey))
+		keyRef := GenerateKeyID(didKey, didKey[len(KeyDIDMethod):])
+		trusted, err := NewTrustedSet()
+		require.NoError(t, err)
+		_, err = trusted.ResolveVerifier(context.Background(), keyRef)
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+// TestMalformedDIDKeys checks that DID Key parsing fails cleanly rather than panicking on
+// truncated and corrupted input.
+func TestMalformedDIDKeys(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	valid := append([]byte{0xed, 0x01}, publicKey...)
+
+	random := mathrand.New(mathrand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		corrupted := append([]byte{}, valid[:random.Intn(len(valid)+1)]...)
+		for j := random.Intn(3); j > 0 && len(corrupted) > 0; j-- {
+			corrupted[random.Intn(len(corrupted))] = byte(random.Intn(256))
+		}
+		didKey := KeyDIDMethod + "z" + base58.Encode(corrupted)
+		assert.NotPanics(t, func() {
+			_, _, _ = ExtractPublicKeyFromDIDKey(didKey)
+			_, _ = ExtractEdPublicKeyFromDID(didKey)
+			_, _ = ResolveDIDKey(didKey)
+		}, didKey)
+	}
+
+	// A non-minimal varint is not a valid codec.
+	_, _, err = ExtractPublicKeyFromDIDKey(KeyDIDMethod + "z" + base58.Encode(append([]byte{0xed, 0x81, 0x00}, publicKey...)))
+	assert.True(t, errors.Is(err, ErrMalformedDIDKey))
+}
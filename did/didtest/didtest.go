@@ -0,0 +1,178 @@
+// Package didtest generates DID Document and proof fixtures for tests. Fixtures are built with the
+// same code paths as production documents, so they stay in sync as document formats evolve.
+// Given a seed, fixtures are deterministic: the same seed always yields the same keys, DIDs and
+// proofs. The only exception is the signature value of secp256k1 proofs, as ECDSA signing is
+// randomized.
+package didtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/google/uuid"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// Created is the proof creation time of seeded fixtures, unless Options.Created is set.
+var Created = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Payload is the JSON content of provables generated by NewSignedProvable.
+const Payload = `{"hello":"world"}`
+
+// DIDDocSignatureTypes lists the signature types that DID Documents can be signed with.
+var DIDDocSignatureTypes = []proof.SignatureType{
+	proof.JCSEdSignatureType,
+	proof.WorkEdSignatureType,
+	proof.Ed25519SignatureType,
+}
+
+// SignatureTypes lists every signature type supported by NewSignedProvable.
+var SignatureTypes = append(append([]proof.SignatureType{}, DIDDocSignatureTypes...), proof.EcdsaSecp256k1SignatureType)
+
+// Options configures NewSignedDIDDoc.
+type Options struct {
+	// Seed makes the fixture deterministic. Any length is accepted. If nil, random keys and
+	// nonces are used.
+	Seed []byte
+	// SignatureType defaults to JCSEdSignatureType.
+	SignatureType proof.SignatureType
+	// ProofVersion selects how the proof references the signing key: V1 proofs use the "creator"
+	// field, and V2 proofs use "verificationMethod". Defaults to V2. JCSEdSignatureType only
+	// has V2 proofs.
+	ProofVersion proof.ModelVersion
+	// Created is the proof creation time. Defaults to Created for seeded fixtures, and the
+	// current time otherwise.
+	Created time.Time
+}
+
+// NewKeyPair returns an Ed25519 key pair. The key pair is derived from the SHA-256 digest of the
+// seed, or generated randomly if the seed is nil.
+func NewKeyPair(t testing.TB, seed []byte) (ed25519.PublicKey, ed25519.PrivateKey) {
+	if seed == nil {
+		publicKey, privateKey, err := ed25519.GenerateKey(util.RandReader())
+		require.NoError(t, err)
+		return publicKey, privateKey
+	}
+	digest := sha256.Sum256(seed)
+	defer util.Zeroize(digest[:])
+	privateKey := ed25519.NewKeyFromSeed(digest[:])
+	return privateKey.Public().(ed25519.PublicKey), privateKey
+}
+
+// NewSecp256k1KeyPair returns a secp256k1 private key. The key is derived from the SHA-256 digest
+// of the seed, or generated randomly if the seed is nil.
+func NewSecp256k1KeyPair(t testing.TB, seed []byte) *btcec.PrivateKey {
+	if seed == nil {
+		privateKey, err := ecdsa.GenerateKey(btcec.S256(), util.RandReader())
+		require.NoError(t, err)
+		return (*btcec.PrivateKey)(privateKey)
+	}
+	digest := sha256.Sum256(seed)
+	defer util.Zeroize(digest[:])
+	privateKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), digest[:])
+	return privateKey
+}
+
+// NewSignedDIDDoc returns a self-signed did:work DID Document with a single Ed25519 key, listed
+// under authentication, and its private key. The document passes Validate and its proof
+// verifies against itself.
+func NewSignedDIDDoc(t testing.TB, opts Options) (*did.DIDDoc, ed25519.PrivateKey) {
+	publicKey, privateKey := NewKeyPair(t, opts.Seed)
+	id := did.GenerateDID(publicKey)
+	keyID, err := did.NewKeyID(id, did.InitialKey)
+	require.NoError(t, err)
+	doc := did.DIDDoc{
+		UnsignedDIDDoc: did.UnsignedDIDDoc{
+			ID: id,
+			PublicKey: []did.KeyDef{{
+				ID:              keyID,
+				Type:            proof.Ed25519KeyType,
+				Controller:      id,
+				PublicKeyBase58: base58.Encode(publicKey),
+			}},
+			Authentication: []string{keyID},
+		},
+	}
+
+	sigType := opts.SignatureType
+	if sigType == "" {
+		sigType = proof.JCSEdSignatureType
+	}
+	version := opts.ProofVersion
+	if version == 0 {
+		version = proof.V2
+	}
+	signer, err := proof.NewEd25519Signer(privateKey, keyID)
+	require.NoError(t, err)
+	suite, err := proof.SignatureSuites().GetSuite(sigType, version)
+	require.NoError(t, err)
+	require.NoError(t, proof.SignWithOptions(suite, &doc, signer, signOptions(opts.Seed, opts.Created, id)...))
+	return &doc, privateKey
+}
+
+// NewSignedProvable returns a provable containing Payload, signed with the given signature type by
+// a DID Key, and a Verifier for the signing key. The proof's key reference can be resolved with
+// did.KeyResolver or did.VerifierForKeyRef. The seed is used as for NewKeyPair.
+func NewSignedProvable(t testing.TB, sigType proof.SignatureType, seed []byte) (*proof.GenericProvable, proof.Verifier) {
+	var (
+		signer  proof.Signer
+		didKey  string
+		version = proof.V2
+		err     error
+	)
+	if sigType == proof.EcdsaSecp256k1SignatureType {
+		// secp256k1 proofs only exist in the V1 format.
+		version = proof.V1
+		privateKey := NewSecp256k1KeyPair(t, seed)
+		didKey, err = did.GenerateDIDKeySecp256k1(privateKey.PubKey().ToECDSA())
+		require.NoError(t, err)
+		signer, err = proof.NewSecp256k1Signer(privateKey.ToECDSA(), didKeyRef(didKey))
+	} else {
+		publicKey, privateKey := NewKeyPair(t, seed)
+		didKey = did.GenerateDIDKey(publicKey)
+		signer, err = proof.NewEd25519Signer(privateKey, didKeyRef(didKey))
+	}
+	require.NoError(t, err)
+
+	suite, err := proof.SignatureSuites().GetSuite(sigType, version)
+	require.NoError(t, err)
+	provable := &proof.GenericProvable{JSONData: Payload}
+	require.NoError(t, proof.SignWithOptions(suite, provable, signer, signOptions(seed, time.Time{}, didKey)...))
+
+	verifier, err := did.VerifierForKeyRef(signer.ID())
+	require.NoError(t, err)
+	return provable, verifier
+}
+
+// didKeyRef returns the key reference of a DID Key's verification method.
+func didKeyRef(didKey string) string {
+	return did.GenerateKeyID(didKey, didKey[len(did.KeyDIDMethod):])
+}
+
+// signOptions pins the proof's creation time and nonce for seeded fixtures. The nonce is derived
+// from the signer's DID.
+func signOptions(seed []byte, created time.Time, id string) []proof.ProofOption {
+	if seed == nil {
+		if created.IsZero() {
+			return nil
+		}
+		return []proof.ProofOption{proof.WithClock(func() time.Time { return created })}
+	}
+	if created.IsZero() {
+		created = Created
+	}
+	nonce := uuid.NewSHA1(uuid.NameSpaceURL, []byte(id)).String()
+	return []proof.ProofOption{
+		proof.WithClock(func() time.Time { return created }),
+		proof.WithNonce(func() string { return nonce }),
+	}
+}
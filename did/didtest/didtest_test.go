@@ -0,0 +1,74 @@
+package didtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestNewKeyPair(t *testing.T) {
+	publicKey, privateKey := NewKeyPair(t, []byte("alice"))
+	samePublicKey, samePrivateKey := NewKeyPair(t, []byte("alice"))
+	assert.Equal(t, publicKey, samePublicKey)
+	assert.Equal(t, privateKey, samePrivateKey)
+
+	otherPublicKey, _ := NewKeyPair(t, []byte("bob"))
+	assert.NotEqual(t, publicKey, otherPublicKey)
+
+	randomPublicKey, _ := NewKeyPair(t, nil)
+	assert.NotEqual(t, publicKey, randomPublicKey)
+}
+
+func TestNewSignedDIDDoc(t *testing.T) {
+	for _, sigType := range DIDDocSignatureTypes {
+		for _, version := range []proof.ModelVersion{proof.V1, proof.V2} {
+			if sigType == proof.JCSEdSignatureType && version == proof.V1 {
+				continue
+			}
+			opts := Options{Seed: []byte("alice"), SignatureType: sigType, ProofVersion: version}
+			doc, privateKey := NewSignedDIDDoc(t, opts)
+			assert.NoError(t, doc.Validate(), sigType)
+			assert.NoError(t, did.VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc), sigType)
+			assert.Equal(t, sigType, doc.Proof.Type)
+			assert.Equal(t, version, doc.Proof.ModelVersion())
+			assert.Equal(t, doc.PublicKey[0].ID, doc.Proof.GetVerificationMethod())
+			assert.Equal(t, did.GenerateDID(privateKey.Public().(ed25519.PublicKey)), doc.ID)
+
+			same, _ := NewSignedDIDDoc(t, opts)
+			assert.Equal(t, doc, same, "seeded fixtures must be deterministic")
+		}
+	}
+
+	t.Run("Random without a seed", func(t *testing.T) {
+		a, _ := NewSignedDIDDoc(t, Options{})
+		b, _ := NewSignedDIDDoc(t, Options{})
+		assert.NotEqual(t, a.ID, b.ID)
+		assert.NoError(t, a.Validate())
+	})
+}
+
+func TestNewSignedProvable(t *testing.T) {
+	resolver := did.AsVerifierResolver(did.KeyResolver{})
+	for _, sigType := range SignatureTypes {
+		provable, verifier := NewSignedProvable(t, sigType, []byte("alice"))
+		assert.Equal(t, sigType, provable.Proof.Type)
+
+		suite, err := proof.SignatureSuites().GetSuiteForProof(provable.Proof)
+		require.NoError(t, err)
+		assert.NoError(t, suite.Verify(provable, verifier), sigType)
+		assert.NoError(t, proof.VerifyWithResolver(context.Background(), provable, resolver), sigType)
+
+		same, _ := NewSignedProvable(t, sigType, []byte("alice"))
+		if sigType == proof.EcdsaSecp256k1SignatureType {
+			// ECDSA signatures are randomized.
+			same.Proof.SignatureValue = provable.Proof.SignatureValue
+		}
+		assert.Equal(t, provable.Proof, same.Proof, sigType)
+	}
+}
@@ -0,0 +1,226 @@
+package did
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// TestErrorCodes checks that the primary failure modes of the exported functions have stable
+// error codes, and that the sentinel errors still match with errors.Is.
+func TestErrorCodes(t *testing.T) {
+	ctx := context.Background()
+	doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	keyID := doc.PublicKey[0].ID
+
+	registry := NewMemoryRegistry()
+	require.NoError(t, registry.Put(*doc))
+	deactivatedDoc, deactivatedKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	require.NoError(t, registry.Put(*deactivatedDoc))
+	deactivation, err := DeactivateDIDDoc(*deactivatedDoc, deactivatedKey)
+	require.NoError(t, err)
+	require.NoError(t, registry.Deactivate(*deactivation))
+
+	other, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	require.NoError(t, registry.Put(*other))
+	unknown, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	tampered := doc.Clone()
+	tampered.Service = []ServiceDef{{ID: doc.ID + "#hub", Type: "hub", ServiceEndpoint: "https://example.com"}}
+	unsigned := doc.Clone()
+	unsigned.Proof = nil
+	foreign := doc.Clone()
+	foreign.Proof = other.Proof.Clone()
+	replayed := doc.Clone()
+	replayed.Service = tampered.Service
+	signWithNonce(t, &replayed, privateKey, keyID, doc.Proof.Nonce)
+
+	provable := func(signed *DIDDoc, verificationMethod string) proof.Provable {
+		p := signed.Clone()
+		p.Proof.VerificationMethod = verificationMethod
+		return &p
+	}
+
+	now := time.Now().UTC()
+	delegation := DelegationDoc{
+		Type:       DelegationType,
+		Delegator:  doc.ID,
+		Delegate:   other.ID,
+		Actions:    []string{"sign"},
+		ValidFrom:  now.Add(-2 * time.Hour).Format(time.RFC3339),
+		ValidUntil: now.Add(-time.Hour).Format(time.RFC3339),
+	}
+	signer, err := proof.NewEd25519Signer(privateKey, keyID)
+	require.NoError(t, err)
+	delegationVerifier := DelegationVerifier{Resolver: registry}
+
+	tests := []struct {
+		name     string
+		err      func() error
+		code     errcode.Code
+		sentinel error
+	}{
+		{
+			name: "ValidateDIDSyntax",
+			err:  func() error { return ValidateDIDSyntax("did:Work") },
+			code: errcode.DIDMalformed,
+		},
+		{
+			name: "ValidateWorkDID",
+			err:  func() error { return ValidateWorkDID("did:work:0OIl") },
+			code: errcode.DIDMalformed,
+		},
+		{
+			name: "Normalize",
+			err: func() error {
+				_, err := Normalize("work:123")
+				return err
+			},
+			code: errcode.DIDMalformed,
+		},
+		{
+			name: "ParseKeyRef",
+			err: func() error {
+				_, _, err := ParseKeyRef(doc.ID)
+				return err
+			},
+			code: errcode.DIDMalformed,
+		},
+		{
+			name: "ExtractPublicKeyFromDIDKey",
+			err: func() error {
+				_, _, err := ExtractPublicKeyFromDIDKey("did:key:z0OIl")
+				return err
+			},
+			code:     errcode.DIDMalformed,
+			sentinel: ErrMalformedDIDKey,
+		},
+		{
+			name: "ResolveLongFormDID",
+			err: func() error {
+				_, err := ResolveLongFormDID(doc.ID)
+				return err
+			},
+			code:     errcode.DIDMalformed,
+			sentinel: ErrInvalidLongFormDID,
+		},
+		{
+			name: "ParseDIDDoc",
+			err: func() error {
+				_, err := ParseDIDDoc([]byte(`{"id":`), DefaultLimits)
+				return err
+			},
+			code: errcode.DIDMalformed,
+		},
+		{
+			name: "ResolveKeyRef",
+			err: func() error {
+				_, err := doc.ResolveKeyRef(doc.ID + "#missing")
+				return err
+			},
+			code: errcode.KeyNotFound,
+		},
+		{
+			name: "VerifyDIDDocProof missing proof",
+			err:  func() error { return VerifyDIDDocProof(unsigned, unsigned.UnsignedDIDDoc) },
+			code: errcode.MalformedProof,
+		},
+		{
+			name: "VerifyDIDDocProof invalid signature",
+			err:  func() error { return VerifyDIDDocProof(tampered, tampered.UnsignedDIDDoc) },
+			code: errcode.SignatureInvalid,
+		},
+		{
+			name:     "VerifyDIDDocProof foreign key",
+			err:      func() error { return VerifyDIDDocProof(foreign, foreign.UnsignedDIDDoc) },
+			code:     errcode.KeyNotFound,
+			sentinel: ErrForeignKeyReference,
+		},
+		{
+			name: "VerifyProofForOperation",
+			err: func() error {
+				return VerifyProofForOperation(provable(doc, doc.ID+"#missing"), doc.UnsignedDIDDoc, ProofOperation)
+			},
+			code: errcode.KeyNotFound,
+		},
+		{
+			name: "VerifierForKeyRef",
+			err: func() error {
+				_, err := VerifierForKeyRef(keyID)
+				return err
+			},
+			code:     errcode.ResolutionFailed,
+			sentinel: ErrResolverRequired,
+		},
+		{
+			name: "Resolve not found",
+			err: func() error {
+				_, err := registry.Resolve(ctx, unknown.ID)
+				return err
+			},
+			code:     errcode.ResolutionFailed,
+			sentinel: ErrNotFound,
+		},
+		{
+			name: "Resolve unsupported method",
+			err: func() error {
+				_, err := NewMethodRegistry().Resolve(ctx, "did:example:123")
+				return err
+			},
+			code:     errcode.ResolutionFailed,
+			sentinel: ErrMethodNotSupported,
+		},
+		{
+			name: "VerifyWithResolver missing key",
+			err: func() error {
+				return proof.VerifyWithResolver(ctx, provable(doc, doc.ID+"#missing"), AsVerifierResolver(registry))
+			},
+			code: errcode.KeyNotFound,
+		},
+		{
+			name: "VerifyWithResolver deactivated",
+			err: func() error {
+				return proof.VerifyWithResolver(ctx, deactivatedDoc, AsVerifierResolver(registry))
+			},
+			code:     errcode.Deactivated,
+			sentinel: ErrDeactivated,
+		},
+		{
+			name: "VerifyUpdateChain",
+			err: func() error {
+				_, err := VerifyUpdateChain([]DIDDoc{*doc, replayed})
+				return err
+			},
+			code:     errcode.Replay,
+			sentinel: ErrNonceReuse,
+		},
+		{
+			name: "DelegationVerifier expired",
+			err: func() error {
+				issued, err := delegationVerifier.Issue(ctx, delegation, signer)
+				if err != nil {
+					return err
+				}
+				return delegationVerifier.Verify(ctx, *issued)
+			},
+			code:     errcode.Expired,
+			sentinel: ErrDelegationNotValid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.err()
+			require.Error(t, err)
+			assert.Equal(t, test.code, errcode.CodeOf(err), err.Error())
+			if test.sentinel != nil {
+				assert.True(t, errors.Is(err, test.sentinel), err.Error())
+			}
+		})
+	}
+}
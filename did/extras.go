@@ -0,0 +1,233 @@
+package did
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+const (
+	contextProperty         = "@context"
+	publicKeyBase58Property = "publicKeyBase58"
+)
+
+// The field types below have the same fields as the models, but not their JSON methods, which
+// would otherwise recurse.
+type (
+	keyDefFields         KeyDef
+	unsignedDIDDocFields UnsignedDIDDoc
+	didDocFields         struct {
+		unsignedDIDDocFields
+		Proof *proof.Proof `json:"proof,omitempty"`
+	}
+)
+
+// MarshalJSON omits an empty publicKeyBase58 only if the key is in another encoding. Before other
+// encodings were supported, publicKeyBase58 was always marshaled, and the signed bytes of those
+// documents must not change.
+func (k KeyDef) MarshalJSON() ([]byte, error) {
+	extras := k.Extras
+	if k.PublicKeyBase58 == "" && !k.hasAlternativeKeyMaterial() {
+		extras = make(map[string]json.RawMessage, len(k.Extras)+1)
+		for name, value := range k.Extras {
+			extras[name] = value
+		}
+		extras[publicKeyBase58Property] = json.RawMessage(`""`)
+	}
+	return marshalWithExtras(keyDefFields(k), extras)
+}
+
+func (k *KeyDef) UnmarshalJSON(data []byte) error {
+	var fields keyDefFields
+	extras, err := unmarshalWithExtras(data, &fields)
+	if err != nil {
+		return err
+	}
+	*k = KeyDef(fields)
+	k.Extras = extras
+	return nil
+}
+
+func (u UnsignedDIDDoc) MarshalJSON() ([]byte, error) {
+	fields, extras, err := u.contextFields()
+	if err != nil {
+		return nil, err
+	}
+	return marshalWithExtras(fields, extras)
+}
+
+func (u *UnsignedDIDDoc) UnmarshalJSON(data []byte) error {
+	data, context, err := splitContextArray(data)
+	if err != nil {
+		return err
+	}
+	var fields unsignedDIDDocFields
+	extras, err := unmarshalWithExtras(data, &fields)
+	if err != nil {
+		return err
+	}
+	*u = UnsignedDIDDoc(fields)
+	u.Context = context
+	u.Extras = extras
+	return nil
+}
+
+// MarshalJSON is required because DIDDoc would otherwise be marshaled by the promoted
+// UnsignedDIDDoc method, without the proof.
+func (d DIDDoc) MarshalJSON() ([]byte, error) {
+	fields, extras, err := d.contextFields()
+	if err != nil {
+		return nil, err
+	}
+	return marshalWithExtras(didDocFields{unsignedDIDDocFields: fields, Proof: d.Proof}, extras)
+}
+
+func (d *DIDDoc) UnmarshalJSON(data []byte) error {
+	data, context, err := splitContextArray(data)
+	if err != nil {
+		return err
+	}
+	var fields didDocFields
+	extras, err := unmarshalWithExtras(data, &fields)
+	if err != nil {
+		return err
+	}
+	d.UnsignedDIDDoc = UnsignedDIDDoc(fields.unsignedDIDDocFields)
+	d.Context = context
+	d.Extras = extras
+	d.Proof = fields.Proof
+	return nil
+}
+
+// MarshalWithContext marshals the DID Document with the @context set to the given values, e.g.
+// DIDContext. The @context of a signed document cannot be changed, since it is covered by the
+// proof; set Context before signing instead.
+func (d DIDDoc) MarshalWithContext(values ...string) ([]byte, error) {
+	if len(values) == 0 {
+		return nil, errors.New("at least one context value is required")
+	}
+	if d.Proof != nil && !reflect.DeepEqual(d.Context, values) {
+		return nil, errors.New("cannot change the context of a signed DID Document")
+	}
+	d.SchemaContext = ""
+	d.Context = values
+	return json.Marshal(d)
+}
+
+// contextFields returns the fields and extras to marshal, with the Context array (if any) written
+// as the @context property in place of SchemaContext.
+func (u UnsignedDIDDoc) contextFields() (unsignedDIDDocFields, map[string]json.RawMessage, error) {
+	fields := unsignedDIDDocFields(u)
+	if len(u.Context) == 0 {
+		return fields, u.Extras, nil
+	}
+	context, err := json.Marshal(u.Context)
+	if err != nil {
+		return fields, nil, err
+	}
+	extras := make(map[string]json.RawMessage, len(u.Extras)+1)
+	for name, value := range u.Extras {
+		extras[name] = value
+	}
+	extras[contextProperty] = context
+	fields.SchemaContext = ""
+	return fields, extras, nil
+}
+
+// splitContextArray removes the @context property from the JSON object if it is an array, and
+// returns its values. String contexts are left in place to be unmarshaled into SchemaContext.
+func splitContextArray(data []byte) ([]byte, []string, error) {
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return nil, nil, err
+	}
+	raw, ok := properties[contextProperty]
+	if !ok || !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		return data, nil, nil
+	}
+	var context []string
+	if err := json.Unmarshal(raw, &context); err != nil {
+		return nil, nil, fmt.Errorf("invalid @context: %v", err)
+	}
+	delete(properties, contextProperty)
+	data, err := json.Marshal(properties)
+	return data, context, err
+}
+
+// marshalWithExtras marshals the value and merges in the extra properties. Modeled properties
+// take precedence over extras with the same name.
+func marshalWithExtras(v interface{}, extras map[string]json.RawMessage) ([]byte, error) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil || len(extras) == 0 {
+		return jsonBytes, err
+	}
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(jsonBytes, &properties); err != nil {
+		return nil, err
+	}
+	for name, value := range extras {
+		if _, ok := properties[name]; !ok {
+			properties[name] = value
+		}
+	}
+	return json.Marshal(properties)
+}
+
+// unmarshalWithExtras unmarshals the data into the struct pointed to by v, and returns any
+// properties that do not map to one of its fields. Properties whose name differs from a field's
+// only in case are rejected: json.Unmarshal would set the field from them, so that the document
+// would no longer marshal to the bytes that were signed.
+func unmarshalWithExtras(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return nil, err
+	}
+	names := jsonFieldNames(reflect.TypeOf(v).Elem())
+	for name := range names {
+		delete(properties, name)
+	}
+	for property := range properties {
+		for name := range names {
+			if strings.EqualFold(property, name) {
+				return nil, fmt.Errorf("property<%s> differs from property<%s> only in case", property, name)
+			}
+		}
+	}
+	if len(properties) == 0 {
+		return nil, nil
+	}
+	return properties, nil
+}
+
+// jsonFieldNames returns the JSON property names of the struct's fields, including the fields of
+// untagged embedded structs.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embedded := range jsonFieldNames(field.Type) {
+				names[embedded] = true
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
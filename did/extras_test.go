@@ -0,0 +1,229 @@
+package did
+
+import (
+	"encoding/json"
+	"testing"
+
+	jcs "github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// extendedDIDDocJSON is a DID Document with vendor extensions, signed over its JCS canonical form
+// by another implementation.
+const extendedDIDDocJSON = `{
+  "@context": "https://w3id.org/did/v1",
+  "id": "did:work:6sYe1y3zXhmyrBkgHgAgaq",
+  "alsoKnownAs": ["https://issuer.example.com"],
+  "publicKey": [{
+    "id": "did:work:6sYe1y3zXhmyrBkgHgAgaq#key-1",
+    "type": "Ed25519VerificationKey2018",
+    "controller": "did:work:6sYe1y3zXhmyrBkgHgAgaq",
+    "publicKeyBase58": "4CcKDtU1JNGi8U4D8Rv9CHzfmF7xzaxEAPFA54eQjRHF",
+    "vendor:hsm": {"slot": 3, "label": "issuer signing"}
+  }],
+  "authentication": ["did:work:6sYe1y3zXhmyrBkgHgAgaq#key-1"],
+  "service": [],
+  "vendor:updated": "2020-06-01T00:00:00Z",
+  "proof": {
+    "created": "2020-06-01T00:00:00Z",
+    "verificationMethod": "did:work:6sYe1y3zXhmyrBkgHgAgaq#key-1",
+    "nonce": "4b1f0e3a-5c1d-4f7e-9a2b-6c8d0e1f2a3b",
+    "type": "JcsEd25519Signature2020",
+    "signatureValue": "4gvhW5x6aucdRfHe4EiHjsFQRaVRtmTyy9WDQwW4hCppupCW4QtFP3oUeuES1aLHVDJ9W72AVxPza8YEwSrp1RSu"
+  }
+}`
+
+func TestExtras(t *testing.T) {
+	t.Run("Unknown properties are retained", func(t *testing.T) {
+		var doc DIDDoc
+		require.NoError(t, json.Unmarshal([]byte(extendedDIDDocJSON), &doc))
+		assert.Equal(t, testWorkDID, doc.ID)
+		assert.NotNil(t, doc.Proof)
+		assert.Equal(t, []string{"https://issuer.example.com"}, doc.AlsoKnownAs)
+		assert.Equal(t, map[string]json.RawMessage{
+			"vendor:updated": json.RawMessage(`"2020-06-01T00:00:00Z"`),
+		}, doc.Extras)
+		require.Len(t, doc.PublicKey, 1)
+		assert.JSONEq(t, `{"slot": 3, "label": "issuer signing"}`, string(doc.PublicKey[0].Extras["vendor:hsm"]))
+	})
+
+	t.Run("Proof verifies after round trip", func(t *testing.T) {
+		var doc DIDDoc
+		require.NoError(t, json.Unmarshal([]byte(extendedDIDDocJSON), &doc))
+		assert.NoError(t, VerifyDIDDocProof(doc, doc.UnsignedDIDDoc))
+
+		docBytes, err := json.Marshal(doc)
+		require.NoError(t, err)
+		expected, err := jcs.Transform([]byte(extendedDIDDocJSON))
+		require.NoError(t, err)
+		actual, err := jcs.Transform(docBytes)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual))
+
+		var roundTripped DIDDoc
+		require.NoError(t, json.Unmarshal(docBytes, &roundTripped))
+		roundTrippedBytes, err := json.Marshal(roundTripped)
+		require.NoError(t, err)
+		assert.Equal(t, string(docBytes), string(roundTrippedBytes))
+		assert.NoError(t, VerifyDIDDocProof(roundTripped, roundTripped.UnsignedDIDDoc))
+	})
+
+	t.Run("Extensions are covered by the proof", func(t *testing.T) {
+		var doc DIDDoc
+		require.NoError(t, json.Unmarshal([]byte(extendedDIDDocJSON), &doc))
+		doc.Extras["vendor:updated"] = json.RawMessage(`"2021-01-01T00:00:00Z"`)
+		assert.Error(t, VerifyDIDDocProof(doc, doc.UnsignedDIDDoc))
+
+		require.NoError(t, json.Unmarshal([]byte(extendedDIDDocJSON), &doc))
+		delete(doc.PublicKey[0].Extras, "vendor:hsm")
+		assert.Error(t, VerifyDIDDocProof(doc, doc.UnsignedDIDDoc))
+	})
+
+	t.Run("Documents without extensions are unchanged", func(t *testing.T) {
+		var doc DIDDoc
+		require.NoError(t, json.Unmarshal([]byte(seedDIDDocJSON), &doc))
+		assert.Nil(t, doc.Extras)
+		assert.Nil(t, doc.PublicKey[0].Extras)
+
+		docBytes, err := json.Marshal(doc)
+		require.NoError(t, err)
+		assert.Equal(t, seedDIDDocJSON, string(docBytes))
+
+		unsignedBytes, err := json.Marshal(doc.UnsignedDIDDoc)
+		require.NoError(t, err)
+		assert.NotContains(t, string(unsignedBytes), "proof")
+	})
+
+	t.Run("Modeled properties take precedence", func(t *testing.T) {
+		keyDef := KeyDef{
+			ID:     "key",
+			Type:   "Ed25519VerificationKey2018",
+			Extras: map[string]json.RawMessage{"id": json.RawMessage(`"other"`), "extra": json.RawMessage(`true`)},
+		}
+		keyBytes, err := json.Marshal(keyDef)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"key","type":"Ed25519VerificationKey2018","publicKeyBase58":"","extra":true}`, string(keyBytes))
+	})
+
+	t.Run("Empty publicKeyBase58 is kept unless another encoding is set", func(t *testing.T) {
+		keyBytes, err := json.Marshal(KeyDef{ID: "key", Type: proof.Ed25519KeyType})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"key","type":"Ed25519VerificationKey2018","publicKeyBase58":""}`, string(keyBytes))
+
+		keyBytes, err = json.Marshal(KeyDef{ID: "key", Type: "Ed25519VerificationKey2020", PublicKeyMultibase: "z6Mk"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"key","type":"Ed25519VerificationKey2020","publicKeyMultibase":"z6Mk"}`, string(keyBytes))
+
+		var keyDef KeyDef
+		require.NoError(t, json.Unmarshal([]byte(`{"id":"key","type":"Ed25519VerificationKey2018","publicKeyBase58":""}`), &keyDef))
+		assert.Empty(t, keyDef.Extras)
+	})
+
+	t.Run("Properties that differ from a modeled property only in case", func(t *testing.T) {
+		var doc DIDDoc
+		err := json.Unmarshal([]byte(`{"id":"did:work:abc","Id":"did:work:xyz"}`), &doc)
+		assert.EqualError(t, err, "property<Id> differs from property<id> only in case")
+
+		var keyDef KeyDef
+		err = json.Unmarshal([]byte(`{"id":"key","PublicKeyBase58":"smuggled"}`), &keyDef)
+		assert.EqualError(t, err, "property<PublicKeyBase58> differs from property<publicKeyBase58> only in case")
+
+		withKey := `{"id":"did:work:abc","publicKey":[{"id":"key","ID":"other"}]}`
+		assert.Error(t, json.Unmarshal([]byte(withKey), &doc))
+	})
+
+	t.Run("Malformed JSON", func(t *testing.T) {
+		var doc DIDDoc
+		assert.Error(t, json.Unmarshal([]byte(`{"id":1}`), &doc))
+		assert.Error(t, json.Unmarshal([]byte(`[]`), &doc))
+	})
+}
+
+func TestContext(t *testing.T) {
+	t.Run("String and array contexts", func(t *testing.T) {
+		var doc UnsignedDIDDoc
+		require.NoError(t, json.Unmarshal([]byte(`{"@context":"https://w3id.org/did/v1","id":"did:work:abc"}`), &doc))
+		assert.Equal(t, SchemaContext, doc.SchemaContext)
+		assert.Nil(t, doc.Context)
+
+		contextJSON := `{"@context":["https://www.w3.org/ns/did/v1","https://example.com/v1"],"id":"did:work:abc","publicKey":null,"authentication":null,"service":null}`
+		require.NoError(t, json.Unmarshal([]byte(contextJSON), &doc))
+		assert.Empty(t, doc.SchemaContext)
+		assert.Equal(t, []string{DIDContext, "https://example.com/v1"}, doc.Context)
+		assert.Nil(t, doc.Extras)
+
+		docBytes, err := json.Marshal(doc)
+		require.NoError(t, err)
+		assert.JSONEq(t, contextJSON, string(docBytes))
+
+		assert.Error(t, json.Unmarshal([]byte(`{"@context":[1,2],"id":"did:work:abc"}`), &doc))
+		assert.Error(t, json.Unmarshal([]byte(`{"@context":{},"id":"did:work:abc"}`), &doc))
+	})
+
+	t.Run("Signed with context", func(t *testing.T) {
+		doc, _, err := GenerateDIDDocWithKeys([]KeyMaterial{{PrivateKey: issuerPrivKey}}, 0, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		unsigned := DIDDoc{UnsignedDIDDoc: doc.UnsignedDIDDoc}
+		unsigned.Context = []string{DIDContext}
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+		require.NoError(t, err)
+		require.NoError(t, suite.Sign(&unsigned, signer))
+
+		docBytes, err := json.Marshal(unsigned)
+		require.NoError(t, err)
+		assert.Contains(t, string(docBytes), `"@context":["https://www.w3.org/ns/did/v1"]`)
+
+		var parsed DIDDoc
+		require.NoError(t, json.Unmarshal(docBytes, &parsed))
+		assert.Equal(t, []string{DIDContext}, parsed.Context)
+		assert.NoError(t, VerifyDIDDocProof(parsed, parsed.UnsignedDIDDoc))
+
+		// The context is covered by the proof.
+		parsed.Context = nil
+		assert.Error(t, VerifyDIDDocProof(parsed, parsed.UnsignedDIDDoc))
+
+		withContext, err := unsigned.MarshalWithContext(DIDContext)
+		require.NoError(t, err)
+		assert.Equal(t, string(docBytes), string(withContext))
+	})
+
+	t.Run("Signed without context", func(t *testing.T) {
+		doc, _, err := GenerateDIDDocWithKeys([]KeyMaterial{{PrivateKey: issuerPrivKey}}, 0, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		docBytes, err := json.Marshal(doc)
+		require.NoError(t, err)
+		assert.NotContains(t, string(docBytes), "@context")
+
+		var parsed DIDDoc
+		require.NoError(t, json.Unmarshal(docBytes, &parsed))
+		assert.NoError(t, VerifyDIDDocProof(parsed, parsed.UnsignedDIDDoc))
+
+		_, err = doc.MarshalWithContext(DIDContext)
+		assert.Error(t, err)
+
+		unsigned := DIDDoc{UnsignedDIDDoc: doc.UnsignedDIDDoc}
+		withContext, err := unsigned.MarshalWithContext(DIDContext, "https://example.com/v1")
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(withContext, &parsed))
+		assert.Equal(t, []string{DIDContext, "https://example.com/v1"}, parsed.Context)
+		assert.Nil(t, doc.Context)
+
+		_, err = unsigned.MarshalWithContext()
+		assert.Error(t, err)
+	})
+
+	t.Run("Legacy string context still verifies", func(t *testing.T) {
+		doc, _ := GenerateDIDDocWithContext(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		docBytes, err := json.Marshal(doc)
+		require.NoError(t, err)
+		var parsed DIDDoc
+		require.NoError(t, json.Unmarshal(docBytes, &parsed))
+		assert.Equal(t, SchemaContext, parsed.SchemaContext)
+		assert.NoError(t, VerifyDIDDocProof(parsed, parsed.UnsignedDIDDoc))
+	})
+}
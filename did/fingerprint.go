@@ -0,0 +1,64 @@
+package did
+
+import (
+	"encoding/json"
+
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// MarshalCanonical marshals the DID Document, including its proof, canonicalized using JCS, as used
+// by the signature suites. Unlike MarshalJSON, the output is byte-for-byte stable: properties are
+// sorted, so two documents with the same content always marshal the same way, including after a
+// round trip through UnmarshalJSON.
+func (d DIDDoc) MarshalCanonical() ([]byte, error) {
+	return util.CanonicalMarshal(d)
+}
+
+// Fingerprint returns a commitment to the contents of a DID Document, excluding the proof, so
+// that the fingerprint of a document is the same before and after it is signed. The SHA-256
+// multihash of the canonical document (see MarshalCanonical) is returned as a base58 multibase
+// string.
+func Fingerprint(doc DIDDoc) (string, error) {
+	doc.Proof = nil
+	return FingerprintWithProof(doc)
+}
+
+// FingerprintWithProof returns a commitment to the full signed DID Document, including the proof
+// and its signature. See Fingerprint.
+func FingerprintWithProof(doc DIDDoc) (string, error) {
+	return util.DigestCanonicalMultibase(doc)
+}
+
+// ContentEquals returns true if the two DID Documents have the same content, ignoring their proofs
+// and the record of any previous proof (see UpgradeProof). The documents are compared as JSON
+// (see util.JSONEquals), so the order of JSON properties does not matter. Absent, null and empty
+// values (e.g. a nil and an empty list of services) are treated as equal. Returns false if either
+// document cannot be marshaled.
+func ContentEquals(a, b DIDDoc) bool {
+	contentA, err := prunedContent(a)
+	if err != nil {
+		return false
+	}
+	contentB, err := prunedContent(b)
+	if err != nil {
+		return false
+	}
+	equal, err := util.JSONEquals(contentA, contentB)
+	return err == nil && equal
+}
+
+// prunedContent returns the JSON of the unsigned document, without empty values.
+func prunedContent(doc DIDDoc) ([]byte, error) {
+	doc.PreviousProof = ""
+	jsonBytes, err := json.Marshal(doc.UnsignedDIDDoc)
+	if err != nil {
+		return nil, err
+	}
+	return util.NormalizeJSON(jsonBytes, util.NormalizeOptions{RemoveNulls: true, RemoveEmptyCollections: true})
+}
+
+// fingerprint returns the SHA-256 multihash of the canonical JSON as a base58 multibase string
+// (see util.DigestCanonicalMultibase).
+func fingerprint(jsonBytes []byte) (string, error) {
+	return util.DigestCanonicalMultibase(json.RawMessage(jsonBytes))
+}
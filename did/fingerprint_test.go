@@ -0,0 +1,249 @@
+package did
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	jcs "github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestFingerprint(t *testing.T) {
+	doc, _, err := GenerateDIDDocFromSeed(keySeed, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	t.Run("Multihash of canonical document", func(t *testing.T) {
+		fingerprint, err := Fingerprint(*doc)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(fingerprint, "z"))
+
+		unsigned, err := json.Marshal(doc.UnsignedDIDDoc)
+		require.NoError(t, err)
+		canonical, err := jcs.Transform(unsigned)
+		require.NoError(t, err)
+		digest := sha256.Sum256(canonical)
+		assert.Equal(t, "z"+base58.Encode(append([]byte{0x12, 0x20}, digest[:]...)), fingerprint)
+	})
+
+	t.Run("Proof is excluded", func(t *testing.T) {
+		signed, err := Fingerprint(*doc)
+		require.NoError(t, err)
+		unsigned, err := Fingerprint(DIDDoc{UnsignedDIDDoc: doc.UnsignedDIDDoc})
+		require.NoError(t, err)
+		assert.Equal(t, signed, unsigned)
+		assert.NotNil(t, doc.Proof)
+
+		withProof, err := FingerprintWithProof(*doc)
+		require.NoError(t, err)
+		assert.NotEqual(t, signed, withProof)
+
+		resigned := *doc
+		resignedProof := *doc.Proof
+		resignedProof.SignatureValue = base58.Encode([]byte("different signature"))
+		resigned.Proof = &resignedProof
+		resignedFingerprint, err := FingerprintWithProof(resigned)
+		require.NoError(t, err)
+		assert.NotEqual(t, withProof, resignedFingerprint)
+	})
+
+	t.Run("Invariant under field reordering", func(t *testing.T) {
+		docJSON, err := json.Marshal(doc)
+		require.NoError(t, err)
+		var fields map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(docJSON, &fields))
+
+		// Write the fields in reverse order, with insignificant whitespace.
+		keys := []string{"proof", "service", "authentication", "publicKey", "id"}
+		var reordered strings.Builder
+		reordered.WriteString("{\n")
+		for i, key := range keys {
+			if i > 0 {
+				reordered.WriteString(",\n")
+			}
+			reordered.WriteString(`  "` + key + `" : ` + string(fields[key]))
+		}
+		reordered.WriteString("\n}")
+
+		var parsed DIDDoc
+		require.NoError(t, json.Unmarshal([]byte(reordered.String()), &parsed))
+		for _, fn := range []func(DIDDoc) (string, error){Fingerprint, FingerprintWithProof} {
+			expected, err := fn(*doc)
+			require.NoError(t, err)
+			actual, err := fn(parsed)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		}
+	})
+
+	t.Run("Changes with key material", func(t *testing.T) {
+		original, err := Fingerprint(*doc)
+		require.NoError(t, err)
+
+		changes := map[string]func(*DIDDoc){
+			"public key": func(d *DIDDoc) { d.PublicKey[0].PublicKeyBase58 = base58.Encode(make([]byte, 32)) },
+			"key type":   func(d *DIDDoc) { d.PublicKey[0].Type = proof.WorkEdKeyType },
+			"key id":     func(d *DIDDoc) { d.PublicKey[0].ID = d.ID + "#key-2" },
+			"controller": func(d *DIDDoc) { d.PublicKey[0].Controller = "did:work:other" },
+			"added key": func(d *DIDDoc) {
+				d.PublicKey = append(d.PublicKey, KeyDef{ID: d.ID + "#key-2", Type: proof.Ed25519KeyType, PublicKeyBase58: base58.Encode(issuerPubKey)})
+			},
+			"key agreement": func(d *DIDDoc) {
+				d.KeyAgreement = []KeyDef{{ID: d.ID + "#key-2", Type: proof.X25519KeyType, PublicKeyBase58: base58.Encode(issuerPubKey)}}
+			},
+		}
+		for name, change := range changes {
+			modified := *doc
+			modified.PublicKey = append([]KeyDef(nil), doc.PublicKey...)
+			change(&modified)
+			fingerprint, err := Fingerprint(modified)
+			require.NoError(t, err)
+			assert.NotEqual(t, original, fingerprint, name)
+		}
+
+		unchanged, err := Fingerprint(*doc)
+		require.NoError(t, err)
+		assert.Equal(t, original, unchanged)
+	})
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	seeded, _, err := GenerateDIDDocFromSeed(keySeed, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	docJSON, err := json.Marshal(seeded)
+	require.NoError(t, err)
+
+	// Unmodeled properties are kept in a map, so their marshaled order is not otherwise fixed.
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(docJSON, &fields))
+	for _, key := range []string{"zeta", "alpha", "mu", "beta", "omega", "gamma"} {
+		fields[key] = map[string]interface{}{"b": key, "a": []interface{}{key, 1}}
+	}
+	docJSON, err = json.Marshal(fields)
+	require.NoError(t, err)
+	var doc DIDDoc
+	require.NoError(t, json.Unmarshal(docJSON, &doc))
+	require.Len(t, doc.Extras, 6)
+
+	canonical, err := doc.MarshalCanonical()
+	require.NoError(t, err)
+	assert.Contains(t, string(canonical), `"proof":`)
+	expected, err := jcs.Transform(docJSON)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(canonical))
+
+	t.Run("Stable across marshal calls", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			actual, err := doc.MarshalCanonical()
+			require.NoError(t, err)
+			require.Equal(t, canonical, actual)
+		}
+	})
+
+	t.Run("Stable across a round trip", func(t *testing.T) {
+		var parsed DIDDoc
+		require.NoError(t, json.Unmarshal(canonical, &parsed))
+		actual, err := parsed.MarshalCanonical()
+		require.NoError(t, err)
+		assert.Equal(t, canonical, actual)
+	})
+
+	t.Run("Fingerprint is the digest of the canonical document", func(t *testing.T) {
+		fingerprint, err := FingerprintWithProof(doc)
+		require.NoError(t, err)
+		digest := sha256.Sum256(canonical)
+		assert.Equal(t, "z"+base58.Encode(append([]byte{0x12, 0x20}, digest[:]...)), fingerprint)
+	})
+}
+
+func TestContentEquals(t *testing.T) {
+	doc, _, err := GenerateDIDDocFromSeed(keySeed, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	doc.Extras = map[string]json.RawMessage{"vendor:aliases": json.RawMessage(`["https://example.com"]`)}
+
+	t.Run("Different proof and field order", func(t *testing.T) {
+		resigned := doc.Clone()
+		resigned.Proof = &proof.Proof{Type: proof.JCSEdSignatureType, VerificationMethod: "did:work:other#key-1", SignatureValue: "abc"}
+
+		// Round trip through a map to reorder the JSON properties.
+		jsonBytes, err := json.Marshal(resigned)
+		require.NoError(t, err)
+		var properties map[string]interface{}
+		require.NoError(t, json.Unmarshal(jsonBytes, &properties))
+		reordered, err := json.Marshal(properties)
+		require.NoError(t, err)
+		require.NotEqual(t, string(jsonBytes), string(reordered))
+		var parsed DIDDoc
+		require.NoError(t, json.Unmarshal(reordered, &parsed))
+
+		assert.True(t, ContentEquals(*doc, resigned))
+		assert.True(t, ContentEquals(*doc, parsed))
+	})
+
+	t.Run("Absent and empty values", func(t *testing.T) {
+		a := doc.Clone()
+		b := doc.Clone()
+		a.Service = nil
+		b.Service = []ServiceDef{}
+		b.AssertionMethod = []string{}
+		b.Extras["empty"] = json.RawMessage(`{}`)
+		assert.True(t, ContentEquals(a, b))
+	})
+
+	t.Run("Different controller", func(t *testing.T) {
+		changed := doc.Clone()
+		changed.PublicKey[0].Controller = "did:work:other"
+		assert.False(t, ContentEquals(*doc, changed))
+	})
+}
+
+func TestClone(t *testing.T) {
+	doc, _, err := GenerateDIDDocFromSeed(keySeed, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	doc.PublicKey[0].PublicKeyJWK = &JWK{Kty: "OKP"}
+	doc.PublicKey[0].Extras = map[string]json.RawMessage{"x": json.RawMessage(`1`)}
+	original, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	clone := doc.Clone()
+	assert.Equal(t, *doc, clone)
+	clone.PublicKey[0].Controller = "did:work:other"
+	clone.PublicKey[0].PublicKeyJWK.Kty = "EC"
+	clone.PublicKey[0].Extras["x"] = json.RawMessage(`2`)
+	clone.Authentication[0] = "changed"
+	clone.Proof.SignatureValue = "changed"
+
+	after, err := json.Marshal(doc)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(original), string(after))
+
+	t.Run("Key definition", func(t *testing.T) {
+		key := doc.PublicKey[0].Clone()
+		assert.Equal(t, doc.PublicKey[0], key)
+		key.PublicKeyJWK.Kty = "EC"
+		key.Extras["x"] = json.RawMessage(`3`)
+		assert.Equal(t, "OKP", doc.PublicKey[0].PublicKeyJWK.Kty)
+		assert.Equal(t, json.RawMessage(`1`), doc.PublicKey[0].Extras["x"])
+	})
+
+	t.Run("Copy of a signed document verifies", func(t *testing.T) {
+		signed, _, err := GenerateDIDDocFromSeed(keySeed, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		provable, err := proof.CopyProvable(signed)
+		require.NoError(t, err)
+		copied, ok := provable.(*DIDDoc)
+		require.True(t, ok)
+		assert.Equal(t, signed, copied)
+		assert.NoError(t, VerifyDIDDocProof(*copied, copied.UnsignedDIDDoc))
+
+		copied.Proof.SignatureValue = "changed"
+		assert.Error(t, VerifyDIDDocProof(*copied, copied.UnsignedDIDDoc))
+		assert.NoError(t, VerifyDIDDocProof(*signed, signed.UnsignedDIDDoc))
+	})
+}
@@ -0,0 +1,233 @@
+package did
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// KeyPurpose is the verification relationship of a key in a DID Document.
+type KeyPurpose int
+
+const (
+	// AuthenticationPurpose keys are listed under authentication.
+	AuthenticationPurpose KeyPurpose = iota
+	// AssertionMethodPurpose keys are listed under assertionMethod, and are used to issue credentials.
+	AssertionMethodPurpose
+	// RecoveryPurpose keys are listed under recovery, and are held offline to update the DID
+	// Document if the other keys are lost. Recovery keys cannot have any other purpose, and there
+	// can be at most one.
+	RecoveryPurpose
+	// KeyAgreementPurpose keys are published as X25519 keys under keyAgreement. The X25519 key is
+	// derived from the Ed25519 key. Key agreement keys cannot have any other purpose.
+	KeyAgreementPurpose
+)
+
+// KeyMaterial describes a key to include in a generated DID Document. See GenerateDIDDocWithKeys.
+type KeyMaterial struct {
+	// PrivateKey is an optional Ed25519 private key. A random key is generated if not specified.
+	PrivateKey ed25519.PrivateKey
+	// Fragment is an optional key reference fragment. Defaults to "key-N", where N is the
+	// one-based position of the key.
+	Fragment string
+	// Purposes are the verification relationships of the key.
+	Purposes []KeyPurpose
+}
+
+// GenerateDIDDocWithKeys generates a did:work DID Document containing all of the keys, self-signed
+// by the key at signingIndex. The DID is derived from the signing key. The private keys are
+// returned in the same order as the key material.
+func GenerateDIDDocWithKeys(keys []KeyMaterial, signingIndex int, sigType proof.SignatureType) (*DIDDoc, []ed25519.PrivateKey, error) {
+	if signingIndex < 0 || signingIndex >= len(keys) {
+		return nil, nil, fmt.Errorf("signing index %d out of range for %d keys", signingIndex, len(keys))
+	}
+
+	privateKeys := make([]ed25519.PrivateKey, len(keys))
+	for i, key := range keys {
+		switch {
+		case key.PrivateKey == nil:
+			_, privateKey, _, err := util.GenerateEd25519KeyPair(nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			privateKeys[i] = privateKey
+		case len(key.PrivateKey) == ed25519.PrivateKeySize:
+			privateKeys[i] = key.PrivateKey
+		default:
+			return nil, nil, fmt.Errorf("invalid private key for key %d", i+1)
+		}
+	}
+
+	id := GenerateDID(privateKeys[signingIndex].Public().(ed25519.PublicKey))
+	doc := UnsignedDIDDoc{ID: id}
+	fragments := make(map[string]bool, len(keys))
+	var signingKeyRef string
+	for i, key := range keys {
+		fragment := key.Fragment
+		if fragment == "" {
+			fragment = fmt.Sprintf("key-%d", i+1)
+		}
+		if fragments[fragment] {
+			return nil, nil, fmt.Errorf("duplicate key fragment: %s", fragment)
+		}
+		fragments[fragment] = true
+
+		keyRef, err := NewKeyID(id, fragment)
+		if err != nil {
+			return nil, nil, err
+		}
+		publicKey := privateKeys[i].Public().(ed25519.PublicKey)
+
+		if hasPurpose(key.Purposes, KeyAgreementPurpose) {
+			if len(key.Purposes) > 1 {
+				return nil, nil, fmt.Errorf("key agreement key cannot have other purposes: %s", fragment)
+			}
+			if i == signingIndex {
+				return nil, nil, fmt.Errorf("key agreement key cannot sign the DID Document: %s", fragment)
+			}
+			x25519Key, err := util.Ed25519PublicToX25519(publicKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			doc.KeyAgreement = append(doc.KeyAgreement, KeyDef{
+				ID:              keyRef,
+				Type:            proof.X25519KeyType,
+				Controller:      id,
+				PublicKeyBase58: base58.Encode(x25519Key),
+			})
+			continue
+		}
+
+		doc.PublicKey = append(doc.PublicKey, KeyDef{
+			ID:              keyRef,
+			Type:            proof.Ed25519KeyType,
+			Controller:      id,
+			PublicKeyBase58: base58.Encode(publicKey),
+		})
+		for _, purpose := range key.Purposes {
+			switch purpose {
+			case AuthenticationPurpose:
+				doc.Authentication = append(doc.Authentication, keyRef)
+			case AssertionMethodPurpose:
+				doc.AssertionMethod = append(doc.AssertionMethod, keyRef)
+			case RecoveryPurpose:
+				doc.Recovery = append(doc.Recovery, keyRef)
+			default:
+				return nil, nil, fmt.Errorf("unknown key purpose %d: %s", purpose, fragment)
+			}
+		}
+		if i == signingIndex {
+			signingKeyRef = keyRef
+		}
+	}
+
+	signer, err := proof.NewEd25519Signer(privateKeys[signingIndex], signingKeyRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	signed, err := SignDIDDoc(doc, signer, sigType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signed, privateKeys, nil
+}
+
+// NewIdentity generates a random Ed25519 key and returns its did:work DID, along with a DID
+// Document that lists the key for authentication and assertion, self-signed with a
+// JcsEd25519Signature2020 proof. The key is generated from util.RandReader.
+func NewIdentity() (string, *DIDDoc, ed25519.PrivateKey, error) {
+	doc, privateKeys, err := GenerateDIDDocWithKeys([]KeyMaterial{{
+		Purposes: []KeyPurpose{AuthenticationPurpose, AssertionMethodPurpose},
+	}}, 0, proof.JCSEdSignatureType)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return doc.ID, doc, privateKeys[0], nil
+}
+
+func hasPurpose(purposes []KeyPurpose, purpose KeyPurpose) bool {
+	for _, p := range purposes {
+		if p == purpose {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateDIDDocForKey generates a did:work DID Document for an existing Ed25519 key pair, such as
+// one generated by an HSM. The DID is derived from the public key, and the document is self-signed
+// by the signer, whose key ID must be a key reference for the derived DID (see GenerateDID).
+// Use GenerateDIDDocForKeyWithDID for other key types.
+func GenerateDIDDocForKey(pub crypto.PublicKey, signer proof.Signer, sigType proof.SignatureType) (*DIDDoc, error) {
+	edKey, ok := pub.(ed25519.PublicKey)
+	if !ok || len(edKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("DID can only be derived from an Ed25519 public key, found %T", pub)
+	}
+	return GenerateDIDDocForKeyWithDID(GenerateDID(edKey), pub, signer, sigType)
+}
+
+// GenerateDIDDocForKeyWithDID generates a DID Document with the given DID for an existing key
+// pair. Ed25519 (ed25519.PublicKey) and secp256k1 (*ecdsa.PublicKey or *btcec.PublicKey) keys are
+// supported. The document contains the single public key, with the signer's key ID, and is
+// self-signed by the signer. Returns an error if the signer's key is not the public key.
+func GenerateDIDDocForKeyWithDID(id string, pub crypto.PublicKey, signer proof.Signer, sigType proof.SignatureType) (*DIDDoc, error) {
+	keyDef, err := keyDefForPublicKey(signer.ID(), id, pub)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := signer.(interface{ Public() crypto.PublicKey }); ok {
+		signerKeyDef, err := keyDefForPublicKey(signer.ID(), id, s.Public())
+		if err != nil || signerKeyDef.PublicKeyBase58 != keyDef.PublicKeyBase58 {
+			return nil, fmt.Errorf("signer public key does not match public key: %s", keyDef.ID)
+		}
+	}
+
+	doc, err := SignDIDDoc(UnsignedDIDDoc{
+		ID:             id,
+		PublicKey:      []KeyDef{keyDef},
+		Authentication: []string{keyDef.ID},
+	}, signer, sigType)
+	if err != nil {
+		return nil, err
+	}
+	// Remote signers can't be checked up front, so check that the proof verifies instead.
+	if err := VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc); err != nil {
+		return nil, fmt.Errorf("signer public key does not match public key %s: %v", keyDef.ID, err)
+	}
+	return doc, nil
+}
+
+// keyDefForPublicKey creates a key definition for an Ed25519 or secp256k1 public key.
+func keyDefForPublicKey(keyID, controller string, pub crypto.PublicKey) (KeyDef, error) {
+	keyDef := KeyDef{ID: keyID, Controller: controller}
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return KeyDef{}, fmt.Errorf("invalid Ed25519 public key: %s", keyID)
+		}
+		keyDef.Type = proof.Ed25519KeyType
+		keyDef.PublicKeyBase58 = base58.Encode(key)
+		return keyDef, nil
+	case *ecdsa.PublicKey:
+		if key == nil || key.Curve != btcec.S256() {
+			return KeyDef{}, fmt.Errorf("unsupported ECDSA public key: %s", keyID)
+		}
+		return keyDefForPublicKey(keyID, controller, (*btcec.PublicKey)(key))
+	case *btcec.PublicKey:
+		der, err := secp256k1PublicKeyDER(key)
+		if err != nil {
+			return KeyDef{}, err
+		}
+		keyDef.Type = proof.EcdsaSecp256k1KeyType
+		keyDef.PublicKeyBase58 = base58.Encode(der)
+		return keyDef, nil
+	}
+	return KeyDef{}, fmt.Errorf("unsupported public key type %T: %s", pub, keyID)
+}
@@ -0,0 +1,231 @@
+package did
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestGenerateDIDDocWithKeys(t *testing.T) {
+	provisioningKeys := func() []KeyMaterial {
+		return []KeyMaterial{
+			{PrivateKey: issuerPrivKey, Purposes: []KeyPurpose{AuthenticationPurpose, AssertionMethodPurpose}},
+			{Purposes: []KeyPurpose{RecoveryPurpose}},
+			{Purposes: []KeyPurpose{KeyAgreementPurpose}},
+		}
+	}
+
+	t.Run("Signing, recovery and key agreement keys", func(t *testing.T) {
+		for _, sigType := range []proof.SignatureType{proof.JCSEdSignatureType, proof.WorkEdSignatureType} {
+			doc, privateKeys, err := GenerateDIDDocWithKeys(provisioningKeys(), 0, sigType)
+			require.NoError(t, err)
+			require.Len(t, privateKeys, 3)
+			assert.Equal(t, issuerPrivKey, privateKeys[0])
+
+			id := GenerateDID(issuerPubKey)
+			assert.Equal(t, id, doc.ID)
+			require.Len(t, doc.PublicKey, 2)
+			assert.Equal(t, id+"#key-1", doc.PublicKey[0].ID)
+			assert.Equal(t, id+"#key-2", doc.PublicKey[1].ID)
+			assert.Equal(t, base58.Encode(privateKeys[1].Public().(ed25519.PublicKey)), doc.PublicKey[1].PublicKeyBase58)
+			require.Len(t, doc.KeyAgreement, 1)
+			assert.Equal(t, id+"#key-3", doc.KeyAgreement[0].ID)
+			assert.Equal(t, proof.X25519KeyType, doc.KeyAgreement[0].Type)
+			assert.Equal(t, []string{id + "#key-1"}, doc.Authentication)
+			assert.Equal(t, []string{id + "#key-1"}, doc.AssertionMethod)
+
+			assert.Equal(t, sigType, doc.Proof.Type)
+			assert.Equal(t, id+"#key-1", doc.Proof.GetVerificationMethod())
+			assert.NoError(t, doc.Validate())
+			assert.NoError(t, VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc))
+		}
+	})
+
+	t.Run("Signing with a later key", func(t *testing.T) {
+		keys := provisioningKeys()
+		keys[1].Fragment = "recovery"
+		doc, privateKeys, err := GenerateDIDDocWithKeys(keys, 1, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		assert.Equal(t, GenerateDID(privateKeys[1].Public().(ed25519.PublicKey)), doc.ID)
+		assert.Equal(t, doc.ID+"#recovery", doc.Proof.GetVerificationMethod())
+		assert.NoError(t, doc.Validate())
+		assert.NoError(t, VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc))
+	})
+
+	t.Run("Fragment collision", func(t *testing.T) {
+		keys := provisioningKeys()
+		keys[0].Fragment = "key-2"
+		_, _, err := GenerateDIDDocWithKeys(keys, 0, proof.JCSEdSignatureType)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate key fragment")
+	})
+
+	t.Run("Signing index out of range", func(t *testing.T) {
+		for _, index := range []int{-1, 3} {
+			_, _, err := GenerateDIDDocWithKeys(provisioningKeys(), index, proof.JCSEdSignatureType)
+			assert.Error(t, err)
+		}
+		_, _, err := GenerateDIDDocWithKeys(nil, 0, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid key material", func(t *testing.T) {
+		_, _, err := GenerateDIDDocWithKeys(provisioningKeys(), 2, proof.JCSEdSignatureType)
+		assert.Error(t, err, "key agreement key cannot sign")
+
+		keys := provisioningKeys()
+		keys[2].Purposes = append(keys[2].Purposes, AuthenticationPurpose)
+		_, _, err = GenerateDIDDocWithKeys(keys, 0, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+
+		keys = provisioningKeys()
+		keys[1].Fragment = "bad fragment"
+		_, _, err = GenerateDIDDocWithKeys(keys, 0, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+
+		keys = provisioningKeys()
+		keys[1].PrivateKey = issuerPrivKey[:32]
+		_, _, err = GenerateDIDDocWithKeys(keys, 0, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+
+		_, _, err = GenerateDIDDocWithKeys(provisioningKeys(), 0, proof.EcdsaSecp256k1SignatureType)
+		assert.Error(t, err)
+	})
+}
+
+// remoteSigner simulates a key held in an HSM, which exposes the public key and signs on request.
+type remoteSigner struct {
+	crypto.Signer
+}
+
+func TestGenerateDIDDocForKey(t *testing.T) {
+	t.Run("Ed25519 with external signer", func(t *testing.T) {
+		id := GenerateDID(issuerPubKey)
+		signer, err := proof.NewCryptoSigner(remoteSigner{issuerPrivKey}, id+"#"+InitialKey)
+		require.NoError(t, err)
+		doc, err := GenerateDIDDocForKey(issuerPubKey, signer, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		assert.Equal(t, id, doc.ID)
+		assert.Equal(t, []KeyDef{{
+			ID:              id + "#" + InitialKey,
+			Type:            proof.Ed25519KeyType,
+			Controller:      id,
+			PublicKeyBase58: base58.Encode(issuerPubKey),
+		}}, doc.PublicKey)
+		assert.Equal(t, []string{id + "#" + InitialKey}, doc.Authentication)
+		assert.NoError(t, doc.Validate())
+		assert.NoError(t, VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc))
+	})
+
+	t.Run("secp256k1 with explicit DID", func(t *testing.T) {
+		privateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		id := GenerateDIDV2(issuerPubKey)
+		signer, err := proof.NewCryptoSigner(privateKey.ToECDSA(), id+"#"+InitialKey)
+		require.NoError(t, err)
+		assert.Equal(t, proof.EcdsaSecp256k1KeyType, signer.Type())
+
+		for _, pub := range []crypto.PublicKey{&privateKey.ToECDSA().PublicKey, privateKey.PubKey()} {
+			doc, err := GenerateDIDDocForKeyWithDID(id, pub, signer, proof.EcdsaSecp256k1SignatureType)
+			require.NoError(t, err)
+			assert.Equal(t, id, doc.ID)
+			assert.Equal(t, proof.EcdsaSecp256k1KeyType, doc.PublicKey[0].Type)
+			assert.NoError(t, VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc))
+
+			// The key is published in DER form, which KeyDefFromPEM also produces.
+			der, err := base58.Decode(doc.PublicKey[0].PublicKeyBase58)
+			require.NoError(t, err)
+			fromPEM, err := KeyDefFromPEM(doc.PublicKey[0].ID, id, encodePEM(pemPublicKeyType, der))
+			require.NoError(t, err)
+			assert.Equal(t, doc.PublicKey[0], fromPEM)
+		}
+
+		_, err = GenerateDIDDocForKey(privateKey.PubKey(), signer, proof.EcdsaSecp256k1SignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Signer does not match public key", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		id := GenerateDID(issuerPubKey)
+
+		// The public key is checked up front when the signer exposes it.
+		signer, err := proof.NewCryptoSigner(otherKey, id+"#"+InitialKey)
+		require.NoError(t, err)
+		_, err = GenerateDIDDocForKey(issuerPubKey, signer, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+
+		// Otherwise the proof must verify.
+		_, err = GenerateDIDDocForKey(issuerPubKey, opaqueSigner{signer}, proof.JCSEdSignatureType)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "signer public key does not match")
+
+		// The key reference must belong to the DID.
+		signer, err = proof.NewCryptoSigner(issuerPrivKey, testWorkDID+"x#"+InitialKey)
+		require.NoError(t, err)
+		_, err = GenerateDIDDocForKey(issuerPubKey, signer, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Unsupported keys", func(t *testing.T) {
+		p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+		_, err = proof.NewCryptoSigner(p384Key, testWorkDID+"#"+InitialKey)
+		assert.Error(t, err)
+
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, testWorkDID+"#"+InitialKey)
+		require.NoError(t, err)
+		_, err = GenerateDIDDocForKeyWithDID(testWorkDID, &p256Key.PublicKey, signer, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+		_, err = GenerateDIDDocForKey(issuerPubKey[:16], signer, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+	})
+}
+
+// opaqueSigner hides the public key of a signer, as is the case for most remote signers.
+type opaqueSigner struct {
+	signer proof.Signer
+}
+
+func (s opaqueSigner) ID() string                         { return s.signer.ID() }
+func (s opaqueSigner) Type() proof.KeyType                { return s.signer.Type() }
+func (s opaqueSigner) Sign(toSign []byte) ([]byte, error) { return s.signer.Sign(toSign) }
+
+func TestNewIdentity(t *testing.T) {
+	id, doc, privateKey, err := NewIdentity()
+	require.NoError(t, err)
+	assert.Equal(t, GenerateDID(privateKey.Public().(ed25519.PublicKey)), id)
+	assert.Equal(t, id, doc.ID)
+	assert.NoError(t, doc.Validate())
+	assert.NoError(t, VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc))
+	assert.Equal(t, []string{id + "#key-1"}, doc.Authentication)
+	assert.Equal(t, []string{id + "#key-1"}, doc.AssertionMethod)
+
+	t.Run("Injected randomness", func(t *testing.T) {
+		// The key seed, followed by the proof nonce.
+		restore := util.SetRandReader(bytes.NewReader(append(append([]byte{}, keySeed...), make([]byte, 16)...)))
+		defer restore()
+		id, _, privateKey, err := NewIdentity()
+		require.NoError(t, err)
+		assert.Equal(t, issuerPrivKey, privateKey)
+		assert.Equal(t, GenerateDID(issuerPubKey), id)
+
+		// The seed has been used up, so there is no randomness left for another identity.
+		_, _, _, err = NewIdentity()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,144 @@
+package did
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// DocIndex is a lookup index over the public keys of a DID Document, for services that verify many
+// proofs against the same document. Key references are resolved with a map lookup instead of a scan
+// of the document's keys, and each key's Verifier is built once, on first use, and then reused.
+// The index is built from a copy of the document, so later changes to the document are not
+// reflected. It is safe for concurrent use.
+type DocIndex struct {
+	doc UnsignedDIDDoc
+	// keys maps the qualified, normalized key ID to the position of the key in doc.PublicKey, or
+	// to -1 if more than one key has the ID.
+	keys     map[string]int
+	recovery map[string]bool
+
+	mu        sync.RWMutex
+	verifiers map[int]proof.Verifier
+}
+
+// NewDocIndex builds an index over the public keys of the DID Document.
+func NewDocIndex(doc DIDDoc) *DocIndex {
+	clone := doc.Clone()
+	x := &DocIndex{
+		doc:       clone.UnsignedDIDDoc,
+		keys:      make(map[string]int, len(clone.PublicKey)),
+		recovery:  make(map[string]bool, len(clone.Recovery)),
+		verifiers: make(map[int]proof.Verifier, len(clone.PublicKey)),
+	}
+	for i, keyDef := range x.doc.PublicKey {
+		id, ok := x.qualify(keyDef.ID)
+		if !ok {
+			continue
+		}
+		if _, exists := x.keys[id]; exists {
+			x.keys[id] = -1
+			continue
+		}
+		x.keys[id] = i
+	}
+	for _, ref := range x.doc.Recovery {
+		if id, ok := x.qualify(ref); ok {
+			x.recovery[id] = true
+		}
+	}
+	return x
+}
+
+// ResolveKeyRef returns the public key referenced by the key reference, with the same semantics as
+// UnsignedDIDDoc.ResolveKeyRef.
+func (x *DocIndex) ResolveKeyRef(ref string) (*KeyDef, error) {
+	i, err := x.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	keyDef := x.doc.PublicKey[i]
+	return &keyDef, nil
+}
+
+// AuthorizedVerifier returns the Verifier for the public key referenced by the key reference,
+// provided that the key may be used for the operation (see UnsignedDIDDoc.AuthorizedKey).
+func (x *DocIndex) AuthorizedVerifier(ref string, op Operation) (proof.Verifier, error) {
+	i, err := x.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	if !op.recoveryPermitted() && x.recovery[x.keyID(i)] {
+		return nil, errors.Wrap(ErrRecoveryKeyNotPermitted, ref)
+	}
+
+	x.mu.RLock()
+	verifier, ok := x.verifiers[i]
+	x.mu.RUnlock()
+	if ok {
+		return verifier, nil
+	}
+	verifier, err = AsVerifier(x.doc.PublicKey[i])
+	if err != nil {
+		return nil, err
+	}
+	x.mu.Lock()
+	x.verifiers[i] = verifier
+	x.mu.Unlock()
+	return verifier, nil
+}
+
+// VerifyProof verifies the proof on the provable using a key from the indexed document, which must
+// be permitted to authorize the operation. It is equivalent to VerifyProofForOperation.
+func (x *DocIndex) VerifyProof(provable proof.Provable, op Operation) error {
+	p := provable.GetProof()
+	if p.IsEmpty() {
+		return errcode.New(errcode.MalformedProof, "proof cannot be empty")
+	}
+	verifier, err := x.AuthorizedVerifier(p.GetVerificationMethod(), op)
+	if err != nil {
+		return err
+	}
+	suite, err := proof.SignatureSuites().GetSuiteForProof(p)
+	if err != nil {
+		return err
+	}
+	return suite.Verify(provable, verifier)
+}
+
+func (x *DocIndex) lookup(ref string) (int, error) {
+	id, ok := x.qualify(ref)
+	if !ok {
+		return 0, fmt.Errorf("key reference<%s> must have a fragment", ref)
+	}
+	i, ok := x.keys[id]
+	if !ok {
+		return 0, errcode.Errorf(errcode.KeyNotFound, "key<%s> not found in DID Document", ref)
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("key reference<%s> matches more than one key", ref)
+	}
+	return i, nil
+}
+
+func (x *DocIndex) keyID(i int) string {
+	id, _ := x.qualify(x.doc.PublicKey[i].ID)
+	return id
+}
+
+// qualify returns the fully qualified key ID with the DID normalized, resolving relative
+// references against the document's DID.
+func (x *DocIndex) qualify(ref string) (string, bool) {
+	did, fragment, err := x.doc.qualifyKeyRef(ref)
+	if err != nil {
+		return "", false
+	}
+	if normalized, err := Normalize(did); err == nil {
+		did = normalized
+	}
+	return GenerateKeyID(did, fragment), true
+}
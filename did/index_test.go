@@ -0,0 +1,140 @@
+package did
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// tenKeyDoc generates a DID Document with ten keys, the last of which is the recovery key, and a
+// provable signed by the ninth key.
+func tenKeyDoc(t testing.TB) (*DIDDoc, *proof.GenericProvable) {
+	keys := make([]KeyMaterial, 10)
+	for i := range keys {
+		keys[i].Purposes = []KeyPurpose{AssertionMethodPurpose}
+	}
+	keys[9].Purposes = []KeyPurpose{RecoveryPurpose}
+	doc, privateKeys, err := GenerateDIDDocWithKeys(keys, 0, proof.JCSEdSignatureType)
+	require.NoError(t, err)
+
+	signer, err := proof.NewEd25519Signer(privateKeys[8], doc.PublicKey[8].ID)
+	require.NoError(t, err)
+	provable := &proof.GenericProvable{JSONData: "claim"}
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+	require.NoError(t, suite.Sign(provable, signer))
+	return doc, provable
+}
+
+func TestDocIndex(t *testing.T) {
+	doc, provable := tenKeyDoc(t)
+	index := NewDocIndex(*doc)
+
+	t.Run("Resolves the same keys as the document", func(t *testing.T) {
+		for _, ref := range []string{"key-9", "#key-9", doc.ID + "#key-9", doc.ID + "#key-1", "#missing", "", doc.ID} {
+			expected, expectedErr := doc.ResolveKeyRef(ref)
+			actual, err := index.ResolveKeyRef(ref)
+			assert.Equal(t, expected, actual, ref)
+			assert.Equal(t, expectedErr == nil, err == nil, ref)
+		}
+	})
+
+	t.Run("Verifies proofs", func(t *testing.T) {
+		assert.NoError(t, index.VerifyProof(provable, ProofOperation))
+		tampered := *provable
+		tampered.JSONData = "tampered"
+		assert.Error(t, index.VerifyProof(&tampered, ProofOperation))
+	})
+
+	t.Run("Verifiers are reused", func(t *testing.T) {
+		first, err := index.AuthorizedVerifier("key-9", ProofOperation)
+		require.NoError(t, err)
+		second, err := index.AuthorizedVerifier(doc.ID+"#key-9", ProofOperation)
+		require.NoError(t, err)
+		assert.True(t, first == second)
+	})
+
+	t.Run("Recovery key", func(t *testing.T) {
+		_, err := index.AuthorizedVerifier("key-10", ProofOperation)
+		assert.True(t, errors.Is(err, ErrRecoveryKeyNotPermitted))
+		_, err = index.AuthorizedVerifier("key-10", UpdateOperation)
+		assert.True(t, errors.Is(err, ErrRecoveryKeyNotPermitted))
+		_, err = index.AuthorizedVerifier("key-10", RotateOperation)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Ambiguous key", func(t *testing.T) {
+		ambiguous := doc.Clone()
+		key := ambiguous.PublicKey[0]
+		key.ID = "#key-1"
+		ambiguous.PublicKey = append(ambiguous.PublicKey, key)
+		_, err := NewDocIndex(ambiguous).ResolveKeyRef("key-1")
+		assert.Error(t, err)
+	})
+
+	t.Run("Independent of later changes", func(t *testing.T) {
+		changed := doc.Clone()
+		index := NewDocIndex(changed)
+		changed.PublicKey[8].PublicKeyBase58 = doc.PublicKey[0].PublicKeyBase58
+		assert.NoError(t, index.VerifyProof(provable, ProofOperation))
+	})
+
+	t.Run("Concurrent use", func(t *testing.T) {
+		index := NewDocIndex(*doc)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := index.AuthorizedVerifier(fmt.Sprintf("key-%d", i+1), ProofOperation)
+				assert.NoError(t, err)
+				assert.NoError(t, index.VerifyProof(provable, ProofOperation))
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+func TestCachingResolverIndexesKeys(t *testing.T) {
+	doc, provable := tenKeyDoc(t)
+	registry := NewMemoryRegistry()
+	require.NoError(t, registry.Put(*doc))
+	cache := NewCachingResolver(registry, CacheOptions{})
+
+	result, err := cache.Resolve(context.Background(), doc.ID)
+	require.NoError(t, err)
+	require.NotNil(t, result.index)
+	assert.NoError(t, proof.VerifyWithResolver(context.Background(), provable, AsVerifierResolver(cache)))
+}
+
+func BenchmarkVerifyProof(b *testing.B) {
+	doc, provable := tenKeyDoc(b)
+
+	b.Run("Without index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000; j++ {
+				if err := VerifyProofForOperation(provable, doc.UnsignedDIDDoc, ProofOperation); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("With index", func(b *testing.B) {
+		index := NewDocIndex(*doc)
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000; j++ {
+				if err := index.VerifyProof(provable, ProofOperation); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package did
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// JWK is a public JSON Web Key, as used by publicKeyJwk verification methods.
+// Only the parameters needed for the supported key types are modeled.
+// See https://tools.ietf.org/html/rfc7517 and https://tools.ietf.org/html/rfc8037
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set, as defined in RFC 7517 Section 5.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+const (
+	jwkKeyTypeOKP = "OKP"
+	jwkKeyTypeEC  = "EC"
+
+	jwkCurveEd25519   = "Ed25519"
+	jwkCurveX25519    = "X25519"
+	jwkCurveSecp256k1 = "secp256k1"
+
+	jwkUseEncryption = "enc"
+
+	secp256k1CoordinateSize = 32
+)
+
+// PublicKey decodes the JWK and returns the raw public key along with the key type used in
+// base58 key definitions. Ed25519 keys are returned as raw 32 byte keys, and secp256k1 keys are
+// returned in uncompressed SEC1 form.
+func (j *JWK) PublicKey() ([]byte, proof.KeyType, error) {
+	switch {
+	case j.Kty == jwkKeyTypeOKP && j.Crv == jwkCurveEd25519:
+		x, err := util.B64URLDecode(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, "", fmt.Errorf("invalid Ed25519 JWK")
+		}
+		return x, proof.Ed25519KeyType, nil
+	case j.Kty == jwkKeyTypeEC && j.Crv == jwkCurveSecp256k1:
+		x, errX := util.B64URLDecode(j.X)
+		y, errY := util.B64URLDecode(j.Y)
+		if errX != nil || errY != nil || len(x) != secp256k1CoordinateSize || len(y) != secp256k1CoordinateSize {
+			return nil, "", fmt.Errorf("invalid secp256k1 JWK")
+		}
+		uncompressed := append(append([]byte{0x04}, x...), y...)
+		if _, err := btcec.ParsePubKey(uncompressed, btcec.S256()); err != nil {
+			return nil, "", fmt.Errorf("invalid secp256k1 JWK: %v", err)
+		}
+		return uncompressed, proof.EcdsaSecp256k1KeyType, nil
+	}
+	return nil, "", fmt.Errorf("unsupported JWK key type: %s %s", j.Kty, j.Crv)
+}
+
+// ToJWK returns the key definition as a marshaled public JWK, with the kid set to the key ID.
+// Ed25519 keys are exported as OKP/Ed25519, X25519 key agreement keys as OKP/X25519 for encryption,
+// and secp256k1 keys as EC/secp256k1.
+func (k KeyDef) ToJWK() ([]byte, error) {
+	jwk, err := k.jwk()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jwk)
+}
+
+// DocToJWKSet returns all of the public keys and key agreement keys of a DID Document as a
+// marshaled JWK Set.
+func DocToJWKSet(doc DIDDoc) ([]byte, error) {
+	set := JWKSet{Keys: make([]JWK, 0, len(doc.PublicKey)+len(doc.KeyAgreement))}
+	for _, keyDefs := range [][]KeyDef{doc.PublicKey, doc.KeyAgreement} {
+		for _, keyDef := range keyDefs {
+			jwk, err := keyDef.jwk()
+			if err != nil {
+				return nil, err
+			}
+			set.Keys = append(set.Keys, *jwk)
+		}
+	}
+	return json.Marshal(set)
+}
+
+func (k KeyDef) jwk() (*JWK, error) {
+	if k.Type == proof.X25519KeyType {
+		publicKey, err := k.GetDecodedPublicKey()
+		if err != nil || len(publicKey) != curve25519.PointSize {
+			return nil, fmt.Errorf("invalid X25519 public key: %s", k.ID)
+		}
+		return &JWK{
+			Kty: jwkKeyTypeOKP,
+			Crv: jwkCurveX25519,
+			X:   util.B64URLEncode(publicKey),
+			Use: jwkUseEncryption,
+			Kid: k.ID,
+		}, nil
+	}
+
+	publicKey, keyType, err := k.decode()
+	if err != nil {
+		return nil, err
+	}
+	switch keyType {
+	case proof.Ed25519KeyType:
+		return &JWK{
+			Kty: jwkKeyTypeOKP,
+			Crv: jwkCurveEd25519,
+			X:   util.B64URLEncode(publicKey),
+			Kid: k.ID,
+		}, nil
+	case proof.EcdsaSecp256k1KeyType:
+		parsed, err := btcec.ParsePubKey(publicKey, btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+		uncompressed := parsed.SerializeUncompressed()
+		return &JWK{
+			Kty: jwkKeyTypeEC,
+			Crv: jwkCurveSecp256k1,
+			X:   util.B64URLEncode(uncompressed[1 : 1+secp256k1CoordinateSize]),
+			Y:   util.B64URLEncode(uncompressed[1+secp256k1CoordinateSize:]),
+			Kid: k.ID,
+		}, nil
+	}
+	return nil, fmt.Errorf("key type %s cannot be exported as a JWK: %s", k.Type, k.ID)
+}
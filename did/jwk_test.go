@@ -0,0 +1,260 @@
+package did
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// RFC 8037 Appendix A: Ed25519 public key (A.2) and the JWS signature over the example payload (A.4).
+const (
+	rfc8037PublicJWK      = `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	rfc8037PublicKeyHex   = "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+	rfc8037SigningInput   = "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc"
+	rfc8037JWSSignature   = "hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg"
+	secp256k1PublicJWK    = `{"kty":"EC","crv":"secp256k1","x":"skkOL4FWlPT6lvfNRen0TU6d6LtzbAnSuTZv0j5Ey1U","y":"_Y4_kwepHJJPEFQa0iAdQ_N8NluwAQJyZC58qJjQlDs"}`
+	secp256k1MessageB64   = "e2RhdGE6bG92ZWx5IGpzb259Cg=="
+	secp256k1DERKeyB64    = "MFYwEAYHKoZIzj0CAQYFK4EEAAoDQgAEskkOL4FWlPT6lvfNRen0TU6d6LtzbAnSuTZv0j5Ey1X9jj+TB6kckk8QVBrSIB1D83w2W7ABAnJkLnyomNCUOw=="
+	secp256k1SignatureB64 = "MEUCICeE0BmEF/oFBU1zD0oHowDBslrQQDxlTXG84rjBR60BAiEAzYzkalSiCg6p0v72Z3YXWSexEyj4Lo+TbsFsgnxD0J8="
+)
+
+// RFC 8037 Appendix A: JWK thumbprint of the Ed25519 key (A.3), and Bob's X25519 public key (A.6).
+const (
+	rfc8037Thumbprint    = "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+	rfc8037X25519KeyHex  = "de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f"
+	rfc8037X25519PublicX = "3p7bfXt9wbTTW2HC7OQ1Nz-DQ8hbeGdNrfx-FG-IK08"
+)
+
+func jwkKeyDef(t *testing.T, keyType proof.KeyType, jwkJSON string) KeyDef {
+	var jwk JWK
+	require.NoError(t, json.Unmarshal([]byte(jwkJSON), &jwk))
+	return KeyDef{ID: testWorkDID + "#key-1", Type: keyType, Controller: testWorkDID, PublicKeyJWK: &jwk}
+}
+
+func TestJWKKeyDef(t *testing.T) {
+	t.Run("Ed25519 JWK verifies RFC 8037 signature", func(t *testing.T) {
+		for _, keyType := range []proof.KeyType{proof.JSONWebKey2020KeyType, proof.Ed25519KeyType} {
+			keyDef := jwkKeyDef(t, keyType, rfc8037PublicJWK)
+			verifier, err := AsVerifier(keyDef)
+			require.NoError(t, err)
+
+			signature, err := base64.RawURLEncoding.DecodeString(rfc8037JWSSignature)
+			require.NoError(t, err)
+			valid, err := verifier.Verify([]byte(rfc8037SigningInput), signature)
+			require.NoError(t, err)
+			assert.True(t, valid)
+
+			valid, err = verifier.Verify([]byte(rfc8037SigningInput+"x"), signature)
+			require.NoError(t, err)
+			assert.False(t, valid)
+		}
+	})
+
+	t.Run("secp256k1 JWK verifies signature", func(t *testing.T) {
+		keyDef := jwkKeyDef(t, proof.EcdsaSecp256k1KeyType, secp256k1PublicJWK)
+		verifier, err := AsVerifier(keyDef)
+		require.NoError(t, err)
+
+		message, err := base64.StdEncoding.DecodeString(secp256k1MessageB64)
+		require.NoError(t, err)
+		signature, err := base64.StdEncoding.DecodeString(secp256k1SignatureB64)
+		require.NoError(t, err)
+		valid, err := verifier.Verify(message, signature)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("Padded coordinates", func(t *testing.T) {
+		padded := `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo="}`
+		publicKey, _, err := jwkKeyDef(t, proof.Ed25519KeyType, padded).PublicKeyJWK.PublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, rfc8037PublicKeyHex, hex.EncodeToString(publicKey))
+
+		// Partial padding must not silently truncate the key.
+		partial := `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHUR="}`
+		_, err = AsVerifier(jwkKeyDef(t, proof.Ed25519KeyType, partial))
+		assert.Error(t, err)
+	})
+
+	t.Run("Key type must match JWK", func(t *testing.T) {
+		_, err := AsVerifier(jwkKeyDef(t, proof.EcdsaSecp256k1KeyType, rfc8037PublicJWK))
+		assert.Error(t, err)
+	})
+
+	t.Run("Unsupported and malformed JWKs", func(t *testing.T) {
+		for _, bad := range []string{
+			`{"kty":"RSA","n":"abc","e":"AQAB"}`,
+			`{"kty":"OKP","crv":"X25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`,
+			`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg"}`,
+			`{"kty":"EC","crv":"secp256k1","x":"skkOL4FWlPT6lvfNRen0TU6d6LtzbAnSuTZv0j5Ey1U","y":"AAAAkwepHJJPEFQa0iAdQ_N8NluwAQJyZC58qJjQlDs"}`,
+		} {
+			_, err := AsVerifier(jwkKeyDef(t, proof.JSONWebKey2020KeyType, bad))
+			assert.Error(t, err, bad)
+		}
+	})
+
+	t.Run("Marshaling", func(t *testing.T) {
+		keyDef := jwkKeyDef(t, proof.JSONWebKey2020KeyType, rfc8037PublicJWK)
+		keyBytes, err := json.Marshal(keyDef)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"did:work:6sYe1y3zXhmyrBkgHgAgaq#key-1","type":"JsonWebKey2020","controller":"did:work:6sYe1y3zXhmyrBkgHgAgaq","publicKeyJwk":`+rfc8037PublicJWK+`}`, string(keyBytes))
+
+		base58Bytes, err := json.Marshal(KeyDef{ID: "id", Type: proof.Ed25519KeyType, PublicKeyBase58: "abc"})
+		require.NoError(t, err)
+		assert.NotContains(t, string(base58Bytes), "publicKeyJwk")
+	})
+
+	t.Run("Proof creator key", func(t *testing.T) {
+		keyDef := jwkKeyDef(t, proof.JSONWebKey2020KeyType, rfc8037PublicJWK)
+		doc := DIDDoc{
+			UnsignedDIDDoc: UnsignedDIDDoc{ID: testWorkDID, PublicKey: []KeyDef{keyDef}},
+			Proof:          &proof.Proof{VerificationMethod: keyDef.ID},
+		}
+		found, err := GetProofCreatorKeyDef(doc)
+		require.NoError(t, err)
+		assert.Equal(t, keyDef, *found)
+
+		decoded, err := found.GetDecodedPublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, rfc8037PublicKeyHex, hex.EncodeToString(decoded))
+	})
+
+	t.Run("Validation of both representations", func(t *testing.T) {
+		publicKey, err := hex.DecodeString(rfc8037PublicKeyHex)
+		require.NoError(t, err)
+
+		keyDef := jwkKeyDef(t, proof.Ed25519KeyType, rfc8037PublicJWK)
+		keyDef.PublicKeyBase58 = base58.Encode(publicKey)
+		doc := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: testWorkDID, PublicKey: []KeyDef{keyDef}}}
+		assert.NoError(t, doc.Validate())
+
+		doc.PublicKey[0].PublicKeyBase58 = base58.Encode(issuerPubKey)
+		assert.Error(t, doc.Validate())
+
+		secp256k1Key := jwkKeyDef(t, proof.EcdsaSecp256k1KeyType, secp256k1PublicJWK)
+		derKey, err := base64.StdEncoding.DecodeString(secp256k1DERKeyB64)
+		require.NoError(t, err)
+		secp256k1Key.PublicKeyBase58 = base58.Encode(derKey)
+		doc.PublicKey = []KeyDef{secp256k1Key}
+		assert.NoError(t, doc.Validate())
+	})
+}
+
+// jwkThumbprint computes the RFC 7638 thumbprint of a JWK, which depends on the exact member
+// names and encodings that JOSE libraries expect.
+func jwkThumbprint(t *testing.T, jwk JWK) string {
+	var canonical string
+	switch jwk.Kty {
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, jwk.Crv, jwk.Kty, jwk.X)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s","y":"%s"}`, jwk.Crv, jwk.Kty, jwk.X, jwk.Y)
+	default:
+		t.Fatalf("unexpected key type: %s", jwk.Kty)
+	}
+	digest := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+func TestJWKExport(t *testing.T) {
+	publicKey, err := hex.DecodeString(rfc8037PublicKeyHex)
+	require.NoError(t, err)
+	edKey := KeyDef{ID: testWorkDID + "#key-1", Type: proof.WorkEdKeyType, Controller: testWorkDID, PublicKeyBase58: base58.Encode(publicKey)}
+
+	t.Run("Ed25519 matches RFC 8037", func(t *testing.T) {
+		jwkBytes, err := edKey.ToJWK()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","kid":"`+edKey.ID+`"}`, string(jwkBytes))
+
+		var jwk JWK
+		require.NoError(t, json.Unmarshal(jwkBytes, &jwk))
+		assert.Equal(t, rfc8037Thumbprint, jwkThumbprint(t, jwk))
+
+		// The exported key imports cleanly and verifies the RFC 8037 signature.
+		verifier, err := AsVerifier(KeyDef{ID: jwk.Kid, Type: proof.JSONWebKey2020KeyType, PublicKeyJWK: &jwk})
+		require.NoError(t, err)
+		signature, err := base64.RawURLEncoding.DecodeString(rfc8037JWSSignature)
+		require.NoError(t, err)
+		valid, err := verifier.Verify([]byte(rfc8037SigningInput), signature)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("secp256k1 round trip", func(t *testing.T) {
+		var expected JWK
+		require.NoError(t, json.Unmarshal([]byte(secp256k1PublicJWK), &expected))
+		expected.Kid = testWorkDID + "#key-2"
+		derKey, err := base64.StdEncoding.DecodeString(secp256k1DERKeyB64)
+		require.NoError(t, err)
+
+		// DER, compressed and JWK encoded keys all export to the same JWK.
+		compressed, err := jwkKeyDef(t, proof.EcdsaSecp256k1KeyType, secp256k1PublicJWK).Normalize()
+		require.NoError(t, err)
+		for _, keyDef := range []KeyDef{
+			{ID: expected.Kid, Type: proof.EcdsaSecp256k1KeyType, PublicKeyBase58: base58.Encode(derKey)},
+			{ID: expected.Kid, Type: proof.EcdsaSecp256k1KeyType, PublicKeyBase58: compressed.PublicKeyBase58},
+			{ID: expected.Kid, Type: proof.JSONWebKey2020KeyType, PublicKeyJWK: &expected},
+		} {
+			jwkBytes, err := keyDef.ToJWK()
+			require.NoError(t, err)
+			var jwk JWK
+			require.NoError(t, json.Unmarshal(jwkBytes, &jwk))
+			assert.Equal(t, expected, jwk)
+		}
+	})
+
+	t.Run("X25519 key agreement", func(t *testing.T) {
+		x25519Key, err := hex.DecodeString(rfc8037X25519KeyHex)
+		require.NoError(t, err)
+		keyDef := KeyDef{ID: testWorkDID + "#enc-1", Type: proof.X25519KeyType, PublicKeyBase58: base58.Encode(x25519Key)}
+		jwkBytes, err := keyDef.ToJWK()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"kty":"OKP","crv":"X25519","x":"`+rfc8037X25519PublicX+`","use":"enc","kid":"`+keyDef.ID+`"}`, string(jwkBytes))
+
+		keyDef.PublicKeyBase58 = base58.Encode(x25519Key[:31])
+		_, err = keyDef.ToJWK()
+		assert.Error(t, err)
+	})
+
+	t.Run("Unsupported key type", func(t *testing.T) {
+		_, err := KeyDef{ID: "key", Type: proof.EcdsaSecp256r1KeyType, PublicKeyBase58: base58.Encode(publicKey)}.ToJWK()
+		assert.Error(t, err)
+	})
+
+	t.Run("DID Key document", func(t *testing.T) {
+		vector := didKeyVectors[0]
+		doc, err := ResolveDIDKey(vector.did)
+		require.NoError(t, err)
+		setBytes, err := DocToJWKSet(*doc)
+		require.NoError(t, err)
+
+		var set JWKSet
+		require.NoError(t, json.Unmarshal(setBytes, &set))
+		require.Len(t, set.Keys, 2)
+		assert.Equal(t, doc.PublicKey[0].ID, set.Keys[0].Kid)
+		assert.Equal(t, jwkCurveEd25519, set.Keys[0].Crv)
+		assert.Empty(t, set.Keys[0].Use)
+		assert.Equal(t, doc.KeyAgreement[0].ID, set.Keys[1].Kid)
+		assert.Equal(t, jwkCurveX25519, set.Keys[1].Crv)
+		assert.Equal(t, jwkUseEncryption, set.Keys[1].Use)
+
+		x25519Key, err := base64.RawURLEncoding.DecodeString(set.Keys[1].X)
+		require.NoError(t, err)
+		assert.Equal(t, vector.x25519Base58, base58.Encode(x25519Key))
+	})
+
+	t.Run("Empty document", func(t *testing.T) {
+		setBytes, err := DocToJWKSet(DIDDoc{})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"keys":[]}`, string(setBytes))
+	})
+}
@@ -0,0 +1,181 @@
+package did
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// Normalize returns a copy of the key definition in the canonical internal representation,
+// regardless of how the key was encoded: the key is publicKeyBase58 encoded (Ed25519 keys raw,
+// secp256k1 keys in compressed SEC1 form), and the type is the corresponding base58 key type
+// (e.g. Ed25519VerificationKey2020 becomes Ed25519VerificationKey2018). If the key definition
+// contains more than one encoding, they must all be the same key.
+// This simplifies comparing keys published by different implementations.
+func (k KeyDef) Normalize() (KeyDef, error) {
+	publicKey, keyType, err := k.decodeAll()
+	if err != nil {
+		return KeyDef{}, err
+	}
+	return KeyDef{
+		ID:              k.ID,
+		Type:            keyType,
+		Controller:      k.Controller,
+		PublicKeyBase58: base58.Encode(publicKey),
+	}, nil
+}
+
+// decode returns the canonical public key and key type from the preferred encoding.
+func (k KeyDef) decode() ([]byte, proof.KeyType, error) {
+	decoders := k.decoders()
+	if len(decoders) == 0 {
+		return nil, "", fmt.Errorf("key has no public key: %s", k.ID)
+	}
+	return decoders[0]()
+}
+
+// decodeAll decodes every encoding that is present and returns an error if they are not all the
+// same key.
+func (k KeyDef) decodeAll() ([]byte, proof.KeyType, error) {
+	decoders := k.decoders()
+	if len(decoders) == 0 {
+		return nil, "", fmt.Errorf("key has no public key: %s", k.ID)
+	}
+
+	var first []byte
+	var firstType proof.KeyType
+	for i, decode := range decoders {
+		publicKey, keyType, err := decode()
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid public key %s: %v", k.ID, err)
+		}
+		if i == 0 {
+			first, firstType = publicKey, keyType
+		} else if !bytes.Equal(first, publicKey) {
+			return nil, "", fmt.Errorf("public key encodings are different keys: %s", k.ID)
+		}
+	}
+	return first, firstType, nil
+}
+
+// decoders returns a decoder for each encoding that is present, in order of preference.
+func (k KeyDef) decoders() []func() ([]byte, proof.KeyType, error) {
+	var decoders []func() ([]byte, proof.KeyType, error)
+	if k.PublicKeyBase58 != "" {
+		decoders = append(decoders, k.decodeBase58)
+	}
+	if k.PublicKeyMultibase != "" {
+		decoders = append(decoders, k.decodeMultibase)
+	}
+	if k.PublicKeyJWK != nil {
+		decoders = append(decoders, k.decodeJWK)
+	}
+	if k.PublicKeyBase64 != "" {
+		decoders = append(decoders, k.decodeBase64)
+	}
+	if k.PublicKeyHex != "" {
+		decoders = append(decoders, k.decodeHex)
+	}
+	return decoders
+}
+
+func (k KeyDef) decodeBase58() ([]byte, proof.KeyType, error) {
+	keyType := normalizeKeyType(k.Type)
+	if keyType == proof.EcdsaSecp256k1KeyType {
+		publicKey, err := extractSecp256k1PublicKey(k.PublicKeyBase58)
+		if err != nil {
+			return nil, "", err
+		}
+		return compressSecp256k1(publicKey, keyType)
+	}
+	publicKey, err := base58.Decode(k.PublicKeyBase58)
+	if err != nil || len(publicKey) == 0 {
+		return nil, "", fmt.Errorf("invalid public key: %s", k.ID)
+	}
+	return publicKey, keyType, nil
+}
+
+// decodeBase64 decodes the legacy publicKeyBase64 encoding. Both the standard and the URL safe
+// alphabets are accepted, with or without padding.
+func (k KeyDef) decodeBase64() ([]byte, proof.KeyType, error) {
+	encoded := strings.TrimRight(k.PublicKeyBase64, "=")
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(encoded, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	publicKey, err := encoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid publicKeyBase64: %s", k.ID)
+	}
+	return decodeRawPublicKey(k, publicKey)
+}
+
+// decodeHex decodes the legacy publicKeyHex encoding.
+func (k KeyDef) decodeHex() ([]byte, proof.KeyType, error) {
+	publicKey, err := hex.DecodeString(strings.TrimPrefix(k.PublicKeyHex, "0x"))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid publicKeyHex: %s", k.ID)
+	}
+	return decodeRawPublicKey(k, publicKey)
+}
+
+// decodeRawPublicKey returns the canonical form of a raw public key of the key definition's type.
+func decodeRawPublicKey(k KeyDef, publicKey []byte) ([]byte, proof.KeyType, error) {
+	keyType := normalizeKeyType(k.Type)
+	if len(publicKey) == 0 {
+		return nil, "", fmt.Errorf("invalid public key: %s", k.ID)
+	}
+	if keyType == proof.EcdsaSecp256k1KeyType {
+		return compressSecp256k1(publicKey, keyType)
+	}
+	return publicKey, keyType, nil
+}
+
+func (k KeyDef) decodeMultibase() ([]byte, proof.KeyType, error) {
+	publicKey, keyType, err := DecodePublicKeyMultibase(k.PublicKeyMultibase)
+	if err != nil {
+		return nil, "", err
+	}
+	if normalizeKeyType(k.Type) != keyType {
+		return nil, "", fmt.Errorf("key type %s does not match publicKeyMultibase", k.Type)
+	}
+	return publicKey, keyType, nil
+}
+
+func (k KeyDef) decodeJWK() ([]byte, proof.KeyType, error) {
+	publicKey, keyType, err := k.PublicKeyJWK.PublicKey()
+	if err != nil {
+		return nil, "", err
+	}
+	if k.Type != proof.JSONWebKey2020KeyType && normalizeKeyType(k.Type) != keyType {
+		return nil, "", fmt.Errorf("key type %s does not match JWK", k.Type)
+	}
+	if keyType == proof.EcdsaSecp256k1KeyType {
+		return compressSecp256k1(publicKey, keyType)
+	}
+	return publicKey, keyType, nil
+}
+
+func compressSecp256k1(publicKey []byte, keyType proof.KeyType) ([]byte, proof.KeyType, error) {
+	parsed, err := btcec.ParsePubKey(publicKey, btcec.S256())
+	if err != nil {
+		return nil, "", err
+	}
+	return parsed.SerializeCompressed(), keyType, nil
+}
+
+// normalizeKeyType maps key types onto the equivalent base58 key type.
+func normalizeKeyType(keyType proof.KeyType) proof.KeyType {
+	switch keyType {
+	case proof.WorkEdKeyType, proof.Ed25519VerificationKey2020KeyType:
+		return proof.Ed25519KeyType
+	}
+	return keyType
+}
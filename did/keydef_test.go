@@ -0,0 +1,236 @@
+package did
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/multibase"
+)
+
+// RFC 8032 Section 7.1, test 1: Ed25519 private key seed and the multibase form of its public key.
+const (
+	rfc8032SeedHex            = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	rfc8032PublicKeyMultibase = "z6MktwupdmLXVVqTzCw4i46r4uGyosGXRnR3XjN4Zq7oMMsw"
+)
+
+func multibaseKeyDef(keyType proof.KeyType, multibase string) KeyDef {
+	return KeyDef{ID: testWorkDID + "#key-1", Type: keyType, Controller: testWorkDID, PublicKeyMultibase: multibase}
+}
+
+func TestPublicKeyMultibase(t *testing.T) {
+	t.Run("DID Key vectors decode", func(t *testing.T) {
+		for _, vector := range didKeyVectors {
+			keyDef := multibaseKeyDef(proof.Ed25519VerificationKey2020KeyType, strings.TrimPrefix(vector.did, KeyDIDMethod))
+			normalized, err := keyDef.Normalize()
+			require.NoError(t, err)
+			assert.Equal(t, proof.Ed25519KeyType, normalized.Type)
+			assert.Equal(t, vector.publicKeyBase58, normalized.PublicKeyBase58)
+			assert.Empty(t, normalized.PublicKeyMultibase)
+
+			decoded, err := keyDef.GetDecodedPublicKey()
+			require.NoError(t, err)
+			assert.Equal(t, vector.publicKeyBase58, base58.Encode(decoded))
+		}
+		for _, vector := range secp256k1DIDKeyVectors {
+			keyDef := multibaseKeyDef(proof.EcdsaSecp256k1KeyType, strings.TrimPrefix(vector.did, KeyDIDMethod))
+			normalized, err := keyDef.Normalize()
+			require.NoError(t, err)
+			assert.Equal(t, vector.publicKeyBase58, normalized.PublicKeyBase58)
+		}
+	})
+
+	t.Run("Encode round trip", func(t *testing.T) {
+		for _, vector := range didKeyVectors {
+			publicKey, err := base58.Decode(vector.publicKeyBase58)
+			require.NoError(t, err)
+			multibase, err := EncodePublicKeyMultibase(publicKey, proof.Ed25519VerificationKey2020KeyType)
+			require.NoError(t, err)
+			assert.Equal(t, strings.TrimPrefix(vector.did, KeyDIDMethod), multibase)
+		}
+
+		_, err := EncodePublicKeyMultibase(issuerPubKey[:16], proof.Ed25519KeyType)
+		assert.Error(t, err)
+		_, err = EncodePublicKeyMultibase(issuerPubKey, proof.X25519KeyType)
+		assert.Error(t, err)
+	})
+
+	t.Run("RFC 8032 key verifies Ed25519 suite proofs", func(t *testing.T) {
+		seed, err := hex.DecodeString(rfc8032SeedHex)
+		require.NoError(t, err)
+		privateKey := ed25519.NewKeyFromSeed(seed)
+		keyDef := multibaseKeyDef(proof.Ed25519VerificationKey2020KeyType, rfc8032PublicKeyMultibase)
+		verifier, err := AsVerifier(keyDef)
+		require.NoError(t, err)
+
+		for _, signatureType := range []proof.SignatureType{proof.JCSEdSignatureType, proof.WorkEdSignatureType} {
+			t.Run(string(signatureType), func(t *testing.T) {
+				signer, err := proof.NewEd25519Signer(privateKey, keyDef.ID)
+				require.NoError(t, err)
+				suite, err := proof.SignatureSuites().GetSuite(signatureType, proof.V2)
+				require.NoError(t, err)
+
+				doc := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: testWorkDID, PublicKey: []KeyDef{keyDef}}}
+				require.NoError(t, suite.Sign(&doc, signer))
+				assert.NoError(t, suite.Verify(&doc, verifier))
+
+				doc.PublicKey[0].Controller = "did:work:other"
+				assert.Error(t, suite.Verify(&doc, verifier))
+			})
+		}
+	})
+
+	t.Run("Normalized encodings are equal", func(t *testing.T) {
+		publicKey, err := hex.DecodeString(rfc8037PublicKeyHex)
+		require.NoError(t, err)
+		fromBase58, err := KeyDef{ID: "key", Type: proof.WorkEdKeyType, PublicKeyBase58: base58.Encode(publicKey)}.Normalize()
+		require.NoError(t, err)
+		fromJWK, err := jwkKeyDef(t, proof.JSONWebKey2020KeyType, rfc8037PublicJWK).Normalize()
+		require.NoError(t, err)
+		fromMultibase, err := multibaseKeyDef(proof.Ed25519VerificationKey2020KeyType, rfc8032PublicKeyMultibase).Normalize()
+		require.NoError(t, err)
+
+		assert.Equal(t, fromBase58.PublicKeyBase58, fromJWK.PublicKeyBase58)
+		assert.Equal(t, fromBase58.PublicKeyBase58, fromMultibase.PublicKeyBase58)
+		assert.Equal(t, fromBase58.Type, fromJWK.Type)
+		assert.Equal(t, fromBase58.Type, fromMultibase.Type)
+	})
+
+	t.Run("Key type must match multicodec", func(t *testing.T) {
+		_, err := AsVerifier(multibaseKeyDef(proof.EcdsaSecp256k1KeyType, rfc8032PublicKeyMultibase))
+		assert.Error(t, err)
+	})
+
+	t.Run("Malformed multibase", func(t *testing.T) {
+		for _, bad := range []string{
+			"",
+			"u7QHXWpgBgrEKt9VL_tPJZAc6DuFy89qmIyWvAhpo9wdRGg",
+			"z",
+			"z0OIl",
+			"z" + base58.Encode(append([]byte{0xed, 0x01}, issuerPubKey[:31]...)),
+			"z" + base58.Encode(append([]byte{0xed}, issuerPubKey...)),
+		} {
+			_, _, err := DecodePublicKeyMultibase(bad)
+			assert.Error(t, err, bad)
+		}
+
+		_, _, err := DecodePublicKeyMultibase("m7QHXWpgBgrEKt9VL")
+		assert.True(t, errors.Is(err, multibase.ErrUnsupportedBase))
+		assert.Contains(t, err.Error(), "prefix 'm'")
+	})
+
+	t.Run("Validation of multiple representations", func(t *testing.T) {
+		keyDef := jwkKeyDef(t, proof.Ed25519VerificationKey2020KeyType, rfc8037PublicJWK)
+		keyDef.PublicKeyMultibase = rfc8032PublicKeyMultibase
+		doc := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: testWorkDID, PublicKey: []KeyDef{keyDef}}}
+		assert.NoError(t, doc.Validate())
+
+		doc.PublicKey[0].PublicKeyMultibase = strings.TrimPrefix(didKeyVectors[0].did, KeyDIDMethod)
+		assert.Error(t, doc.Validate())
+	})
+}
+
+func TestLegacyKeyEncodings(t *testing.T) {
+	seed, err := hex.DecodeString(rfc8032SeedHex)
+	require.NoError(t, err)
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	keyID := testWorkDID + "#key-1"
+
+	signer, err := proof.NewEd25519Signer(privateKey, keyID)
+	require.NoError(t, err)
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+	provable := &proof.GenericProvable{JSONData: "claim"}
+	require.NoError(t, suite.Sign(provable, signer))
+
+	fixtures := map[string]KeyDef{
+		"publicKeyBase58":            {PublicKeyBase58: base58.Encode(publicKey)},
+		"publicKeyBase64":            {PublicKeyBase64: "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="},
+		"publicKeyBase64 unpadded":   {PublicKeyBase64: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		"publicKeyHex":               {PublicKeyHex: "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"},
+		"publicKeyHex with prefix":   {PublicKeyHex: "0xd75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"},
+		"publicKeyBase58 and Base64": {PublicKeyBase58: base58.Encode(publicKey), PublicKeyBase64: "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="},
+	}
+	for name, keyDef := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			keyDef.ID = keyID
+			keyDef.Type = proof.Ed25519KeyType
+
+			normalized, err := keyDef.Normalize()
+			require.NoError(t, err)
+			assert.Equal(t, base58.Encode(publicKey), normalized.PublicKeyBase58)
+			assert.Empty(t, normalized.PublicKeyBase64)
+			assert.Empty(t, normalized.PublicKeyHex)
+
+			verifier, err := AsVerifier(keyDef)
+			require.NoError(t, err)
+			assert.NoError(t, suite.Verify(provable, verifier))
+
+			doc := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: testWorkDID, PublicKey: []KeyDef{keyDef}}}
+			assert.NoError(t, doc.Validate())
+		})
+	}
+
+	t.Run("Proof creator key", func(t *testing.T) {
+		doc := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: testWorkDID, PublicKey: []KeyDef{{
+			ID:           keyID,
+			Type:         proof.Ed25519KeyType,
+			PublicKeyHex: hex.EncodeToString(publicKey),
+		}}}}
+		require.NoError(t, suite.Sign(&doc, signer))
+		keyDef, err := GetProofCreatorKeyDef(doc)
+		require.NoError(t, err)
+		assert.Equal(t, doc.PublicKey[0], *keyDef)
+		assert.NoError(t, VerifyDIDDocProof(doc, doc.UnsignedDIDDoc))
+	})
+
+	t.Run("secp256k1", func(t *testing.T) {
+		privateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		keyDef := KeyDef{
+			ID:           keyID,
+			Type:         proof.EcdsaSecp256k1KeyType,
+			PublicKeyHex: hex.EncodeToString(privateKey.PubKey().SerializeUncompressed()),
+		}
+		normalized, err := keyDef.Normalize()
+		require.NoError(t, err)
+		assert.Equal(t, base58.Encode(privateKey.PubKey().SerializeCompressed()), normalized.PublicKeyBase58)
+	})
+
+	t.Run("Encodings must agree", func(t *testing.T) {
+		keyDef := KeyDef{
+			ID:              keyID,
+			Type:            proof.Ed25519KeyType,
+			PublicKeyBase58: base58.Encode(issuerPubKey),
+			PublicKeyHex:    hex.EncodeToString(publicKey),
+		}
+		_, err := keyDef.Normalize()
+		assert.Error(t, err)
+		_, err = AsVerifier(keyDef)
+		assert.Error(t, err)
+		doc := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: testWorkDID, PublicKey: []KeyDef{keyDef}}}
+		assert.Error(t, doc.Validate())
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, keyDef := range []KeyDef{
+			{PublicKeyBase64: "not base64!"},
+			{PublicKeyHex: "d75a98zz"},
+			{PublicKeyHex: "0x"},
+		} {
+			keyDef.ID = keyID
+			keyDef.Type = proof.Ed25519KeyType
+			_, err := AsVerifier(keyDef)
+			assert.Error(t, err)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package did
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ParseKeyRef splits a fully qualified key reference in the form of DID#fragment into its DID and
+// fragment. Returns an error if the DID is not valid (see ValidateDID) or if the fragment is
+// missing or empty.
+func ParseKeyRef(keyRef string) (did, fragment string, err error) {
+	did, fragment, ok := splitKeyRef(keyRef)
+	if !ok {
+		return "", "", errcode.Errorf(errcode.DIDMalformed, "key reference<%s> must have a fragment", keyRef)
+	}
+	if err := validateKeyRefParts(did, fragment); err != nil {
+		return "", "", errcode.Errorf(errcode.DIDMalformed, "invalid key reference<%s>: %v", keyRef, err)
+	}
+	return did, fragment, nil
+}
+
+// NewKeyID builds a fully qualified key reference given a DID and a key fragment. Unlike
+// GenerateKeyID, an error is returned if the DID is not valid or the fragment is empty.
+func NewKeyID(did, fragment string) (string, error) {
+	if err := validateKeyRefParts(did, fragment); err != nil {
+		return "", err
+	}
+	return did + "#" + fragment, nil
+}
+
+// MustKeyRef is the same as NewKeyID, except that it panics on error. It is intended for
+// initializing key references from constants.
+func MustKeyRef(did, fragment string) string {
+	keyRef, err := NewKeyID(did, fragment)
+	if err != nil {
+		panic(err)
+	}
+	return keyRef
+}
+
+// ExtractDIDFromKeyRef parses a key reference in the form of DID#keyID and returns the DID.
+// If the key reference doesn't contain a hash "#" symbol, the entire key reference is returned.
+// Use ParseKeyRef to reject malformed key references.
+func ExtractDIDFromKeyRef(keyRef string) string {
+	did, _, _ := splitKeyRef(keyRef)
+	return did
+}
+
+// GenerateKeyID builds a fully qualified key reference given a DID and a key fragment.
+// The inputs are not validated; use NewKeyID to reject malformed key references.
+func GenerateKeyID(did, fragment string) string {
+	return did + "#" + fragment
+}
+
+// ResolveKeyRef returns the public key referenced by a key reference, which may be a bare fragment
+// ("key-1"), a relative reference ("#key-1"), or fully qualified ("did:work:abc#key-1"). Relative
+// references, including relative key IDs in the document, are resolved against the document's
+// DID, and DIDs are compared after normalization (see Equal). Returns an error if no key, or more
+// than one key, matches.
+func (u *UnsignedDIDDoc) ResolveKeyRef(ref string) (*KeyDef, error) {
+	did, fragment, err := u.qualifyKeyRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	var found *KeyDef
+	for i := range u.PublicKey {
+		keyDID, keyFragment, err := u.qualifyKeyRef(u.PublicKey[i].ID)
+		if err != nil || keyFragment != fragment || !Equal(keyDID, did) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("key reference<%s> matches more than one key", ref)
+		}
+		keyDef := u.PublicKey[i]
+		found = &keyDef
+	}
+	if found == nil {
+		return nil, errcode.Errorf(errcode.KeyNotFound, "key<%s> not found in DID Document", ref)
+	}
+	return found, nil
+}
+
+// qualifyKeyRef returns the DID and fragment of a key reference, using the document's DID for
+// relative references.
+func (u *UnsignedDIDDoc) qualifyKeyRef(ref string) (did, fragment string, err error) {
+	switch {
+	case strings.HasPrefix(ref, "#"):
+		did, fragment = u.ID, ref[1:]
+	case !strings.Contains(ref, "#") && !strings.HasPrefix(ref, "did:"):
+		did, fragment = u.ID, ref
+	default:
+		var ok bool
+		if did, fragment, ok = splitKeyRef(ref); !ok {
+			return "", "", fmt.Errorf("key reference<%s> must have a fragment", ref)
+		}
+	}
+	if fragment == "" {
+		return "", "", fmt.Errorf("key reference<%s> must have a fragment", ref)
+	}
+	return did, fragment, nil
+}
+
+// splitKeyRef splits the key reference at the first "#". The returned bool is false if there is
+// no "#", in which case the whole key reference is returned as the DID.
+func splitKeyRef(keyRef string) (did, fragment string, ok bool) {
+	i := strings.Index(keyRef, "#")
+	if i < 0 {
+		return keyRef, "", false
+	}
+	return keyRef[:i], keyRef[i+1:], true
+}
+
+func validateKeyRefParts(did, fragment string) error {
+	if err := ValidateDID(did); err != nil {
+		return err
+	}
+	if fragment == "" {
+		return fmt.Errorf("key fragment cannot be empty")
+	}
+	if strings.ContainsAny(fragment, "# \t\r\n") {
+		return fmt.Errorf("key fragment<%s> contains invalid characters", fragment)
+	}
+	return nil
+}
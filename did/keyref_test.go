@@ -0,0 +1,149 @@
+package did
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+const testWorkDID = "did:work:6sYe1y3zXhmyrBkgHgAgaq"
+
+func TestParseKeyRef(t *testing.T) {
+	t.Run("Valid key references", func(t *testing.T) {
+		did, fragment, err := ParseKeyRef(testWorkDID + "#key-1")
+		require.NoError(t, err)
+		assert.Equal(t, testWorkDID, did)
+		assert.Equal(t, "key-1", fragment)
+
+		doc, err := ResolveDIDKey(didKeyVectors[0].did)
+		require.NoError(t, err)
+		did, fragment, err = ParseKeyRef(doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, didKeyVectors[0].did, did)
+		assert.Equal(t, didKeyVectors[0].did[len(KeyDIDMethod):], fragment)
+	})
+
+	t.Run("Invalid key references", func(t *testing.T) {
+		for _, bad := range []string{
+			"",
+			"key-1",
+			"#key-1",
+			testWorkDID,
+			testWorkDID + "#",
+			testWorkDID + "#key#1",
+			"did:work:short#key-1",
+			"id:work:6sYe1y3zXhmyrBkgHgAgaq#key-1",
+		} {
+			_, _, err := ParseKeyRef(bad)
+			assert.Error(t, err, bad)
+		}
+	})
+}
+
+func TestNewKeyID(t *testing.T) {
+	keyID, err := NewKeyID(testWorkDID, InitialKey)
+	require.NoError(t, err)
+	assert.Equal(t, testWorkDID+"#key-1", keyID)
+	assert.Equal(t, GenerateKeyID(testWorkDID, InitialKey), keyID)
+
+	_, err = NewKeyID(testWorkDID, "")
+	assert.Error(t, err)
+	_, err = NewKeyID("", InitialKey)
+	assert.Error(t, err)
+	_, err = NewKeyID(testWorkDID, "#key-1")
+	assert.Error(t, err)
+
+	assert.Equal(t, testWorkDID+"#key-1", MustKeyRef(testWorkDID, InitialKey))
+	assert.Panics(t, func() { MustKeyRef(testWorkDID, "") })
+}
+
+func TestLenientKeyRefs(t *testing.T) {
+	// The lenient functions are kept for compatibility and do not validate.
+	assert.Equal(t, testWorkDID, ExtractDIDFromKeyRef(testWorkDID+"#key-1"))
+	assert.Equal(t, "key-1", ExtractDIDFromKeyRef("key-1"))
+	assert.Equal(t, testWorkDID+"#", GenerateKeyID(testWorkDID, ""))
+}
+
+func TestDeactivateDIDDocRejectsMalformedKeyRef(t *testing.T) {
+	doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	doc.PublicKey[0].ID = InitialKey
+	_, err := DeactivateDIDDoc(*doc, privateKey)
+	assert.Error(t, err)
+
+	_, err = DeactivateDIDDoc(DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: doc.ID}}, privateKey)
+	assert.Error(t, err)
+}
+
+func TestResolveKeyRef(t *testing.T) {
+	doc := UnsignedDIDDoc{
+		ID: testWorkDID,
+		PublicKey: []KeyDef{
+			{ID: testWorkDID + "#key-1", Type: proof.Ed25519KeyType, PublicKeyBase58: "key1"},
+			{ID: "#key-2", Type: proof.Ed25519KeyType, PublicKeyBase58: "key2"},
+		},
+	}
+
+	t.Run("Reference forms", func(t *testing.T) {
+		for ref, expected := range map[string]string{
+			"key-1":                                 "key1",
+			"#key-1":                                "key1",
+			testWorkDID + "#key-1":                  "key1",
+			"DID:WORK:6sYe1y3zXhmyrBkgHgAgaq#key-1": "key1",
+			"key-2":                                 "key2",
+			"#key-2":                                "key2",
+			testWorkDID + "#key-2":                  "key2",
+		} {
+			keyDef, err := doc.ResolveKeyRef(ref)
+			require.NoError(t, err, ref)
+			assert.Equal(t, expected, keyDef.PublicKeyBase58, ref)
+		}
+	})
+
+	t.Run("Foreign DID does not match", func(t *testing.T) {
+		for _, ref := range []string{"did:work:other#key-1", "did:work:6sYe1y3zXhmyrBkgHgAgaQ#key-1", "did:key:z6Mk#key-2"} {
+			_, err := doc.ResolveKeyRef(ref)
+			assert.Error(t, err, ref)
+		}
+	})
+
+	t.Run("Missing and malformed references", func(t *testing.T) {
+		for _, ref := range []string{"", "#", "key-3", testWorkDID, testWorkDID + "#"} {
+			_, err := doc.ResolveKeyRef(ref)
+			assert.Error(t, err, ref)
+		}
+	})
+
+	t.Run("Ambiguous fragment", func(t *testing.T) {
+		ambiguous := doc
+		ambiguous.PublicKey = append(append([]KeyDef(nil), doc.PublicKey...), KeyDef{ID: "key-1", PublicKeyBase58: "other"})
+		_, err := ambiguous.ResolveKeyRef("key-1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "more than one key")
+	})
+
+	t.Run("Proof creator with relative reference", func(t *testing.T) {
+		signed, _, err := GenerateDIDDocFromSeed(keySeed, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		for _, ref := range []string{"key-1", "#key-1"} {
+			relative := *signed
+			p := *signed.Proof
+			p.VerificationMethod = ref
+			relative.Proof = &p
+			keyDef, err := GetProofCreatorKeyDef(relative)
+			require.NoError(t, err)
+			assert.Equal(t, signed.PublicKey[0], *keyDef)
+		}
+
+		foreign := *signed
+		p := *signed.Proof
+		p.VerificationMethod = "did:work:other#key-1"
+		foreign.Proof = &p
+		_, err = GetProofCreatorKeyDef(foreign)
+		assert.Error(t, err)
+		assert.Error(t, VerifyDIDDocProof(foreign, foreign.UnsignedDIDDoc))
+	})
+}
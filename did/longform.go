@@ -0,0 +1,146 @@
+package did
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// Long-form did:work DIDs carry their genesis DID Document, so that they can be resolved before
+// the document has been anchored on the ledger, similar to Sidetree long-form DIDs. A long-form DID
+// has the form:
+//
+//   did:work:<id>?initial-state=<base64url encoded genesis document>
+//
+// The genesis document is the DID Document without its DID: the id property is omitted, and key
+// and service IDs, key references and controllers are relative to the DID. It is canonicalized
+// with JCS. The identifier is the base58 encoding of the first 16 bytes of the SHA-256 hash of the
+// genesis document, so the DID commits to the entire document and cannot be used with another.
+// The long and short forms of a DID Normalize to the same DID.
+
+// ErrInvalidLongFormDID is returned by ResolveLongFormDID when the DID is malformed, or does not
+// match its genesis document.
+var ErrInvalidLongFormDID = errcode.New(errcode.DIDMalformed, "invalid long-form DID")
+
+const longFormParam = "?initial-state="
+
+// GenerateLongFormDID returns the long-form DID for the genesis DID Document. The document's DID
+// is replaced by the one derived from the document's content, so references to the document's ID
+// should be relative (e.g. "#key-1") or use the document's current ID. The document must not have
+// any other content that depends on its DID.
+func GenerateLongFormDID(doc UnsignedDIDDoc) (string, error) {
+	genesis, err := canonicalGenesis(doc)
+	if err != nil {
+		return "", err
+	}
+	return longFormDID(genesis) + longFormParam + base64.RawURLEncoding.EncodeToString(genesis), nil
+}
+
+// ResolveLongFormDID reconstructs the DID Document from a long-form DID (see GenerateLongFormDID).
+// The identifier must be derived from the embedded genesis document, and the reconstructed
+// document must pass Validate. Returns ErrInvalidLongFormDID if the DID has been tampered with.
+func ResolveLongFormDID(s string) (*DIDDoc, error) {
+	i := strings.Index(s, longFormParam)
+	if i < 0 || !strings.HasPrefix(s, IssuerDIDMethod) {
+		return nil, errors.Wrapf(ErrInvalidLongFormDID, "DID<%s> is not a long-form did:work DID", s)
+	}
+	did, encoded := s[:i], s[i+len(longFormParam):]
+	if base64.RawURLEncoding.DecodedLen(len(encoded)) > DefaultLimits.MaxSize {
+		return nil, errors.Wrapf(ErrInvalidLongFormDID, "DID<%s> genesis document exceeds %d bytes", did, DefaultLimits.MaxSize)
+	}
+	genesis, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidLongFormDID, "DID<%s> genesis document is not base64url encoded", did)
+	}
+
+	var doc UnsignedDIDDoc
+	if err := json.Unmarshal(genesis, &doc); err != nil {
+		return nil, errors.Wrapf(ErrInvalidLongFormDID, "DID<%s> genesis document: %v", did, err)
+	}
+	if doc.ID != "" {
+		return nil, errors.Wrapf(ErrInvalidLongFormDID, "DID<%s> genesis document must not have an ID", did)
+	}
+	// The genesis document must be in canonical form, so that each document has only one DID.
+	canonical, err := canonicalGenesis(doc)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(canonical, genesis) || longFormDID(genesis) != did {
+		return nil, errors.Wrapf(ErrInvalidLongFormDID, "DID<%s> does not match its genesis document", did)
+	}
+
+	resolved := DIDDoc{UnsignedDIDDoc: qualifyGenesis(doc, did)}
+	if err := resolved.Validate(); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// IsLongFormDID returns true if the DID carries a genesis document (see GenerateLongFormDID).
+func IsLongFormDID(did string) bool {
+	return strings.HasPrefix(did, IssuerDIDMethod) && strings.Contains(did, longFormParam)
+}
+
+func longFormDID(genesis []byte) string {
+	hash := sha256.Sum256(genesis)
+	return IssuerDIDMethod + base58.Encode(hash[:workDIDLength])
+}
+
+// canonicalGenesis returns the JCS canonical JSON of the document, with references to its DID made
+// relative.
+func canonicalGenesis(doc UnsignedDIDDoc) ([]byte, error) {
+	genesis := (DIDDoc{UnsignedDIDDoc: doc}).Clone().UnsignedDIDDoc
+	relative := func(ref string) string {
+		if did, fragment, ok := splitKeyRef(ref); ok && did != "" && did == doc.ID {
+			return "#" + fragment
+		}
+		return ref
+	}
+	mapGenesisRefs(&genesis, relative, doc.ID, "")
+	genesis.ID = ""
+
+	return util.CanonicalMarshal(genesis)
+}
+
+// qualifyGenesis returns the genesis document with its references qualified with the DID.
+func qualifyGenesis(genesis UnsignedDIDDoc, did string) UnsignedDIDDoc {
+	doc := (DIDDoc{UnsignedDIDDoc: genesis}).Clone().UnsignedDIDDoc
+	qualify := func(ref string) string {
+		if strings.HasPrefix(ref, "#") {
+			return did + ref
+		}
+		return ref
+	}
+	mapGenesisRefs(&doc, qualify, "", did)
+	doc.ID = did
+	return doc
+}
+
+// mapGenesisRefs applies the mapping to the key and service IDs and key references of the
+// document, and replaces the "from" controller with the "to" controller.
+func mapGenesisRefs(doc *UnsignedDIDDoc, mapping func(string) string, from, to string) {
+	for _, keys := range [][]KeyDef{doc.PublicKey, doc.KeyAgreement} {
+		for i := range keys {
+			keys[i].ID = mapping(keys[i].ID)
+			if keys[i].Controller == from {
+				keys[i].Controller = to
+			}
+		}
+	}
+	for _, refs := range [][]string{doc.Authentication, doc.AssertionMethod, doc.Recovery} {
+		for i := range refs {
+			refs[i] = mapping(refs[i])
+		}
+	}
+	for i := range doc.Service {
+		doc.Service[i].ID = mapping(doc.Service[i].ID)
+	}
+}
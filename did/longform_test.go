@@ -0,0 +1,103 @@
+package did
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestLongFormDID(t *testing.T) {
+	doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	genesis := doc.Clone().UnsignedDIDDoc
+	genesis.Authentication = []string{genesis.PublicKey[0].ID}
+	genesis.Service = []ServiceDef{{ID: genesis.ID + "#schema", Type: "schema", ServiceEndpoint: "https://example.com/schemas"}}
+
+	longForm, err := GenerateLongFormDID(genesis)
+	require.NoError(t, err)
+	assert.True(t, IsLongFormDID(longForm))
+	assert.False(t, IsLongFormDID(doc.ID))
+
+	t.Run("Resolve", func(t *testing.T) {
+		resolved, err := ResolveLongFormDID(longForm)
+		require.NoError(t, err)
+		shortForm := longForm[:strings.Index(longForm, "?")]
+		assert.Equal(t, shortForm, resolved.ID)
+		assert.NoError(t, ValidateWorkDID(resolved.ID))
+		assert.NotEqual(t, doc.ID, resolved.ID)
+
+		require.Len(t, resolved.PublicKey, 1)
+		assert.Equal(t, shortForm+"#key-1", resolved.PublicKey[0].ID)
+		assert.Equal(t, shortForm, resolved.PublicKey[0].Controller)
+		assert.Equal(t, genesis.PublicKey[0].PublicKeyBase58, resolved.PublicKey[0].PublicKeyBase58)
+		assert.Equal(t, []string{shortForm + "#key-1"}, resolved.Authentication)
+		assert.Equal(t, shortForm+"#schema", resolved.Service[0].ID)
+
+		normalized, err := Normalize(longForm)
+		require.NoError(t, err)
+		assert.Equal(t, shortForm, normalized)
+		assert.True(t, Equal(longForm, resolved.ID))
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		// The same content with relative references has the same DID.
+		relative := genesis
+		relative.ID = ""
+		relative.PublicKey = []KeyDef{genesis.PublicKey[0]}
+		relative.PublicKey[0].ID = "#key-1"
+		relative.PublicKey[0].Controller = ""
+		relative.Authentication = []string{"#key-1"}
+		relative.Service = []ServiceDef{{ID: "#schema", Type: "schema", ServiceEndpoint: "https://example.com/schemas"}}
+		again, err := GenerateLongFormDID(relative)
+		require.NoError(t, err)
+		assert.Equal(t, longForm, again)
+
+		resolved, err := ResolveLongFormDID(longForm)
+		require.NoError(t, err)
+		fromResolved, err := GenerateLongFormDID(resolved.UnsignedDIDDoc)
+		require.NoError(t, err)
+		assert.Equal(t, longForm, fromResolved)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		i := strings.Index(longForm, "=") + 1
+		did, encoded := longForm[:i], longForm[i:]
+		payload, err := base64.RawURLEncoding.DecodeString(encoded)
+		require.NoError(t, err)
+
+		otherDoc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		otherLongForm, err := GenerateLongFormDID(otherDoc.UnsignedDIDDoc)
+		require.NoError(t, err)
+
+		tampered := map[string]string{
+			"Swapped key": did + base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(
+				string(payload), genesis.PublicKey[0].PublicKeyBase58, otherDoc.PublicKey[0].PublicKeyBase58, 1))),
+			"Changed service": did + base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(
+				string(payload), "example.com", "example.org", 1))),
+			"Non-canonical":          did + base64.RawURLEncoding.EncodeToString(append([]byte(" "), payload...)),
+			"Other genesis document": did + otherLongForm[strings.Index(otherLongForm, "=")+1:],
+			"Other DID":              otherDoc.ID + longForm[strings.Index(longForm, "?"):],
+			"Truncated":              longForm[:len(longForm)-4],
+			"Not base64url":          did + "!!!",
+			"Short form":             doc.ID,
+		}
+		for name, s := range tampered {
+			_, err := ResolveLongFormDID(s)
+			assert.True(t, errors.Is(err, ErrInvalidLongFormDID), name)
+		}
+	})
+
+	t.Run("Genesis must be valid", func(t *testing.T) {
+		invalid := genesis
+		invalid.Authentication = []string{"#missing"}
+		longForm, err := GenerateLongFormDID(invalid)
+		require.NoError(t, err)
+		_, err = ResolveLongFormDID(longForm)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,236 @@
+package did
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MemoryRegistry is an in-memory DID registry, intended for tests and small services that do not
+// have a ledger. It enforces the same update authorization rules as the ledger: a new DID Document
+// must be self-signed, and an update or deactivation must be signed by a key from the current
+// version of the document. As with VerifyUpdateChain, the proof nonce of each version must not
+// have been used by an earlier version of the document. Every version is kept, so that past
+// versions can be resolved with ResolveWithOptions. It is safe for concurrent use.
+type MemoryRegistry struct {
+	mu                 sync.RWMutex
+	records            map[string]*memoryRecord
+	nonces             NonceHistory
+	allowMissingNonces bool
+	// now returns the current time; replaced in tests.
+	now func() time.Time
+}
+
+// memoryRecord holds every version of a DID Document, oldest first.
+type memoryRecord struct {
+	versions []memoryVersion
+}
+
+type memoryVersion struct {
+	doc         DIDDoc
+	deactivated bool
+	// created is when the version's proof was created, or when it was stored if the proof has no
+	// created time.
+	created time.Time
+}
+
+func (r *memoryRecord) current() *memoryVersion {
+	return &r.versions[len(r.versions)-1]
+}
+
+// NewMemoryRegistry returns an empty MemoryRegistry. The nonce options of VerifyUpdateChain apply
+// to the documents that are stored: WithNonceHistory sets the history that tracks proof nonces,
+// and AllowMissingNonces accepts documents from legacy clients whose proof has no nonce. By
+// default, every proof must have a nonce, and nonces are tracked in memory.
+func NewMemoryRegistry(opts ...ChainOption) *MemoryRegistry {
+	options := chainOptions{history: NewMemoryNonceHistory()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &MemoryRegistry{
+		records:            make(map[string]*memoryRecord),
+		nonces:             options.history,
+		allowMissingNonces: options.allowMissingNonces,
+		now:                time.Now,
+	}
+}
+
+// NewMemoryRegistryWithNonceHistory returns an empty MemoryRegistry that tracks proof nonces in
+// the given history.
+func NewMemoryRegistryWithNonceHistory(history NonceHistory) *MemoryRegistry {
+	return NewMemoryRegistry(WithNonceHistory(history))
+}
+
+// Put adds a new DID Document or updates an existing one. New documents must be signed by one of
+// their own keys. Updates must be signed by a key from the stored version. The recovery key may
+// only sign updates that rotate the keys (see UpdateOperationFor), and cannot sign new documents.
+// Deactivated DIDs cannot be updated.
+func (r *MemoryRegistry) Put(doc DIDDoc) error {
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	if len(doc.PublicKey) == 0 {
+		return errors.New("did doc must have public keys; use Deactivate to deactivate a DID")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, exists := r.records[doc.ID]
+	if !exists {
+		if err := VerifyDIDDocProofForOperation(doc, doc.UnsignedDIDDoc, UpdateOperation); err != nil {
+			return errors.Wrap(err, "new did doc must be self-signed")
+		}
+		if _, err := checkNonce(r.nonces, doc, r.allowMissingNonces); err != nil {
+			return err
+		}
+		r.records[doc.ID] = &memoryRecord{versions: []memoryVersion{{doc: doc.Clone(), created: r.proofCreated(doc)}}}
+		return nil
+	}
+
+	current := record.current()
+	if current.deactivated {
+		return errors.Wrapf(ErrDeactivated, "DID<%s>", doc.ID)
+	}
+	created, err := r.checkCreated(current, doc)
+	if err != nil {
+		return err
+	}
+	op := UpdateOperationFor(current.doc.UnsignedDIDDoc, doc.UnsignedDIDDoc)
+	if err := VerifyDIDDocProofForOperation(doc, current.doc.UnsignedDIDDoc, op); err != nil {
+		return errors.Wrap(err, "did doc update must be signed by a key from the current version")
+	}
+	if _, err := checkNonce(r.nonces, doc, r.allowMissingNonces); err != nil {
+		return err
+	}
+	record.versions = append(record.versions, memoryVersion{doc: doc.Clone(), created: created})
+	return nil
+}
+
+// Deactivate replaces an existing DID Document with a deactivated one (see DeactivateDIDDoc).
+// The deactivated document must pass Validate, have the deactivated status, and be signed by a
+// key from the stored version that may authorize the deactivation (see DeactivateOperation).
+// Deactivation is permanent.
+func (r *MemoryRegistry) Deactivate(doc DIDDoc) error {
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	if doc.DIDStatus != StatusDeactivated {
+		return errors.Errorf("deactivated did doc must have status<%s>, got<%s>", StatusDeactivated, doc.DIDStatus)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, exists := r.records[doc.ID]
+	if !exists {
+		return errors.Wrapf(ErrNotFound, "DID<%s>", doc.ID)
+	}
+	current := record.current()
+	if current.deactivated {
+		return errors.Wrapf(ErrDeactivated, "DID<%s>", doc.ID)
+	}
+	created, err := r.checkCreated(current, doc)
+	if err != nil {
+		return err
+	}
+	if err := VerifyDIDDocProofForOperation(doc, current.doc.UnsignedDIDDoc, DeactivateOperation); err != nil {
+		return errors.Wrap(err, "did doc deactivation must be signed by a key from the current version")
+	}
+	if _, err := checkNonce(r.nonces, doc, r.allowMissingNonces); err != nil {
+		return err
+	}
+	record.versions = append(record.versions, memoryVersion{doc: doc.Clone(), deactivated: true, created: created})
+	return nil
+}
+
+// proofCreated returns the time at which the document's proof was created, or the current time if
+// the proof has no valid created time.
+func (r *MemoryRegistry) proofCreated(doc DIDDoc) time.Time {
+	if created, err := doc.Proof.CreatedTime(); err == nil {
+		return created.UTC()
+	}
+	return r.now().UTC()
+}
+
+// checkCreated returns the creation time of the new version of a document (see proofCreated),
+// which must not be before that of the current version, so that the versions are in the order in
+// which they were created.
+func (r *MemoryRegistry) checkCreated(current *memoryVersion, doc DIDDoc) (time.Time, error) {
+	created := r.proofCreated(doc)
+	if created.Before(current.created) {
+		return time.Time{}, errors.Errorf("did doc proof created at %s is before the current version of DID<%s>, created at %s",
+			created.Format(time.RFC3339), doc.ID, current.created.Format(time.RFC3339))
+	}
+	return created, nil
+}
+
+// Resolve returns the current version of the DID Document.
+func (r *MemoryRegistry) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	return r.ResolveWithOptions(ctx, did, ResolutionOptions{AcceptDeactivated: true})
+}
+
+// ResolveWithOptions returns the version of the DID Document selected by the options. Versions
+// are numbered from 1. A version is current at a VersionTime from the time its proof was created,
+// so that a proof is verified with the version that was current when it was created (see
+// proof.AsOfProofCreated). ErrNotFound is returned if there is no such version.
+func (r *MemoryRegistry) ResolveWithOptions(ctx context.Context, did string, opts ResolutionOptions) (*ResolutionResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	record, exists := r.records[did]
+	if !exists {
+		return nil, errors.Wrapf(ErrNotFound, "DID<%s>", did)
+	}
+
+	index := len(record.versions) - 1
+	switch {
+	case opts.VersionID != "":
+		versionID, err := strconv.Atoi(opts.VersionID)
+		if err != nil || versionID < 1 || versionID > len(record.versions) {
+			return nil, errors.Wrapf(ErrNotFound, "DID<%s> version<%s>", did, opts.VersionID)
+		}
+		index = versionID - 1
+	case !opts.VersionTime.IsZero():
+		for index >= 0 && record.versions[index].created.After(opts.VersionTime) {
+			index--
+		}
+		if index < 0 {
+			return nil, errors.Wrapf(ErrNotFound, "DID<%s> at %s", did, opts.VersionTime.Format(time.RFC3339))
+		}
+	}
+
+	version := record.versions[index]
+	doc := version.doc.Clone()
+	result := &ResolutionResult{
+		DIDDoc: &doc,
+		DocumentMetadata: DocumentMetadata{
+			Deactivated: version.deactivated,
+			Status:      doc.Status(),
+			VersionID:   strconv.Itoa(index + 1),
+			Created:     record.versions[0].created,
+			Updated:     version.created,
+			Retrieved:   time.Now().UTC(),
+		},
+	}
+	if version.deactivated && !opts.AcceptDeactivated {
+		return nil, DeactivatedError{Result: result}
+	}
+	return result, nil
+}
+
+// List returns the current version of every DID Document in the registry, ordered by DID.
+func (r *MemoryRegistry) List() []DIDDoc {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	docs := make([]DIDDoc, 0, len(r.records))
+	for _, record := range r.records {
+		docs = append(docs, record.current().doc.Clone())
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs
+}
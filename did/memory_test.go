@@ -0,0 +1,255 @@
+package did
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// signDIDDoc replaces the proof on the DID Document with a signature from the given key.
+func signDIDDoc(t *testing.T, doc *DIDDoc, key ed25519.PrivateKey, keyID string) {
+	signer, err := proof.NewEd25519Signer(key, keyID)
+	require.NoError(t, err)
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+	doc.Proof = nil
+	require.NoError(t, suite.Sign(doc, signer))
+}
+
+func TestMemoryRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Put and resolve", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		require.NoError(t, registry.Put(*doc))
+
+		result, err := registry.Resolve(ctx, doc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, doc, result.DIDDoc)
+		assert.Equal(t, "1", result.DocumentMetadata.VersionID)
+		assert.False(t, result.DocumentMetadata.Deactivated)
+		assert.False(t, result.DocumentMetadata.Created.IsZero())
+		assert.Equal(t, result.DocumentMetadata.Created, result.DocumentMetadata.Updated)
+
+		_, err = registry.Resolve(ctx, "did:work:unknown")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("New doc must be self-signed", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		_, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		signDIDDoc(t, doc, otherKey, doc.PublicKey[0].ID)
+		assert.Error(t, registry.Put(*doc))
+
+		doc.Proof = nil
+		assert.Error(t, registry.Put(*doc))
+	})
+
+	t.Run("Update signed by the current key", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		require.NoError(t, registry.Put(*doc))
+
+		// Rotate to a new key, authorized by the old key.
+		newPublicKey, _, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		updated := doc.Clone()
+		updated.PublicKey = []KeyDef{{
+			ID:              GenerateKeyID(doc.ID, "key-2"),
+			Type:            proof.Ed25519KeyType,
+			Controller:      doc.ID,
+			PublicKeyBase58: base58.Encode(newPublicKey),
+		}}
+		signDIDDoc(t, &updated, privateKey, doc.PublicKey[0].ID)
+		require.NoError(t, registry.Put(updated))
+
+		result, err := registry.Resolve(ctx, doc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, &updated, result.DIDDoc)
+		assert.Equal(t, "2", result.DocumentMetadata.VersionID)
+		assert.False(t, result.DocumentMetadata.Updated.Before(result.DocumentMetadata.Created))
+
+		// The old key has been rotated out and can no longer authorize updates.
+		signDIDDoc(t, doc, privateKey, doc.PublicKey[0].ID)
+		assert.Error(t, registry.Put(*doc))
+	})
+
+	t.Run("ID collision", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		require.NoError(t, registry.Put(*doc))
+
+		// An attacker's self-signed document claiming the same DID is rejected.
+		attacker, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		attacker.ID = doc.ID
+		assert.Error(t, registry.Put(*attacker))
+	})
+
+	t.Run("Deactivate", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		require.NoError(t, registry.Put(*doc))
+
+		_, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		forged, err := DeactivateDIDDoc(*doc, otherKey)
+		require.NoError(t, err)
+		assert.Error(t, registry.Deactivate(*forged))
+		assert.Error(t, registry.Deactivate(*doc))
+
+		deactivated, err := DeactivateDIDDoc(*doc, privateKey)
+		require.NoError(t, err)
+		require.NoError(t, registry.Deactivate(*deactivated))
+
+		result, err := registry.Resolve(ctx, doc.ID)
+		require.NoError(t, err)
+		assert.True(t, result.DocumentMetadata.Deactivated)
+		assert.Equal(t, "2", result.DocumentMetadata.VersionID)
+		assert.Empty(t, result.DIDDoc.PublicKey)
+
+		assert.True(t, errors.Is(registry.Put(*doc), ErrDeactivated))
+		assert.True(t, errors.Is(registry.Deactivate(*deactivated), ErrDeactivated))
+
+		signer, err := proof.NewEd25519Signer(privateKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		provable := &proof.GenericProvable{JSONData: "claim"}
+		require.NoError(t, SignAsAdmin(provable, signer, *doc))
+		err = proof.VerifyWithResolver(ctx, provable, AsVerifierResolver(registry))
+		var deactivatedErr DeactivatedError
+		require.True(t, errors.As(err, &deactivatedErr))
+		assert.Equal(t, result.DIDDoc, deactivatedErr.Result.DIDDoc)
+	})
+
+	t.Run("Deactivation must be valid", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		require.NoError(t, registry.Put(*doc))
+
+		// A legacy deactivation, without the deactivated status.
+		legacy := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: doc.ID}}
+		signDIDDoc(t, &legacy, privateKey, doc.PublicKey[0].ID)
+		assert.Error(t, registry.Deactivate(legacy))
+
+		// The deactivated status on a document that still has keys.
+		withKeys := doc.Clone()
+		withKeys.DIDStatus = StatusDeactivated
+		signDIDDoc(t, &withKeys, privateKey, doc.PublicKey[0].ID)
+		assert.Error(t, registry.Deactivate(withKeys))
+
+		result, err := registry.Resolve(ctx, doc.ID)
+		require.NoError(t, err)
+		assert.False(t, result.DocumentMetadata.Deactivated)
+	})
+
+	t.Run("Deactivate with recovery key", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		doc, privateKeys, err := GenerateDIDDocWithKeys([]KeyMaterial{
+			{Purposes: []KeyPurpose{AuthenticationPurpose, AssertionMethodPurpose}},
+			{Purposes: []KeyPurpose{RecoveryPurpose}},
+		}, 0, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		require.NoError(t, registry.Put(*doc))
+
+		deactivated, err := DeactivateDIDDoc(*doc, privateKeys[1])
+		require.NoError(t, err)
+		assert.Equal(t, doc.Recovery[0], deactivated.Proof.GetVerificationMethod())
+		require.NoError(t, registry.Deactivate(*deactivated))
+	})
+
+	t.Run("Missing nonces", func(t *testing.T) {
+		doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		keyID := doc.PublicKey[0].ID
+		signWithNonce(t, doc, privateKey, keyID, "")
+		updated := doc.Clone()
+		updated.Service = []ServiceDef{{ID: doc.ID + "#service-1", Type: "Test", ServiceEndpoint: "https://example.com"}}
+		signWithNonce(t, &updated, privateKey, keyID, "")
+		deactivated, err := DeactivateDIDDoc(*doc, privateKey)
+		require.NoError(t, err)
+		signWithNonce(t, deactivated, privateKey, keyID, "")
+
+		// The registry is strict by default.
+		strict := NewMemoryRegistry()
+		assert.True(t, errors.Is(strict.Put(*doc), ErrMissingNonce))
+
+		legacy := NewMemoryRegistry(AllowMissingNonces())
+		require.NoError(t, legacy.Put(*doc))
+		require.NoError(t, legacy.Put(updated))
+		require.NoError(t, legacy.Deactivate(*deactivated))
+		result, err := legacy.Resolve(ctx, doc.ID)
+		require.NoError(t, err)
+		assert.True(t, result.DocumentMetadata.Deactivated)
+		assert.Equal(t, "3", result.DocumentMetadata.VersionID)
+	})
+
+	t.Run("Stored docs are not shared with callers", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		require.NoError(t, registry.Put(*doc))
+
+		doc.PublicKey[0].PublicKeyBase58 = "tampered"
+		result, err := registry.Resolve(ctx, doc.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, "tampered", result.DIDDoc.PublicKey[0].PublicKeyBase58)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		assert.Empty(t, registry.List())
+
+		var ids []string
+		for i := 0; i < 3; i++ {
+			doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+			require.NoError(t, registry.Put(*doc))
+			ids = append(ids, doc.ID)
+		}
+		docs := registry.List()
+		require.Len(t, docs, 3)
+		for _, doc := range docs {
+			assert.Contains(t, ids, doc.ID)
+		}
+		assert.True(t, docs[0].ID < docs[1].ID && docs[1].ID < docs[2].ID)
+	})
+
+	t.Run("Verifies proofs through the registry", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		require.NoError(t, registry.Put(*doc))
+
+		provable := &proof.GenericProvable{JSONData: `{"hello":"world"}`}
+		signer, err := proof.NewEd25519Signer(privateKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+		require.NoError(t, err)
+		require.NoError(t, suite.Sign(provable, signer))
+
+		assert.NoError(t, proof.VerifyWithResolver(ctx, provable, AsVerifierResolver(registry)))
+	})
+
+	t.Run("Concurrent use", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+				assert.NoError(t, registry.Put(*doc), fmt.Sprint(i))
+				_, err := registry.Resolve(ctx, doc.ID)
+				assert.NoError(t, err)
+				_ = registry.List()
+			}(i)
+		}
+		wg.Wait()
+		assert.Len(t, registry.List(), 20)
+	})
+}
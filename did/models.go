@@ -1,6 +1,7 @@
 package did
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
@@ -13,11 +14,35 @@ import (
 // UnsignedDIDDoc is a W3C compliant DID Document without an embedded Proof.
 type UnsignedDIDDoc struct {
 	// Deprecated: left here for backward compatibility. All new DID Docs should exclude this property.
-	SchemaContext  string       `json:"@context,omitempty"`
-	ID             string       `json:"id"`
-	PublicKey      []KeyDef     `json:"publicKey"`
-	Authentication []string     `json:"authentication"`
-	Service        []ServiceDef `json:"service"`
+	SchemaContext string `json:"@context,omitempty"`
+	// Context is the @context when it is an array of values, as written by other DID
+	// implementations and by MarshalWithContext. It takes precedence over SchemaContext, and must
+	// be set before the document is signed so that it is covered by the proof.
+	Context         []string     `json:"-"`
+	ID              string       `json:"id"`
+	PublicKey       []KeyDef     `json:"publicKey"`
+	Authentication  []string     `json:"authentication"`
+	AssertionMethod []string     `json:"assertionMethod,omitempty"`
+	KeyAgreement    []KeyDef     `json:"keyAgreement,omitempty"`
+	Service         []ServiceDef `json:"service"`
+	// AlsoKnownAs lists other identifiers (URIs) of the DID subject, such as a did:web DID. See
+	// VerifyAlias.
+	AlsoKnownAs []string `json:"alsoKnownAs,omitempty"`
+	// Recovery references the document's recovery key, if any. A recovery key can authorize key
+	// rotation and deactivation of the DID Document, but not any other proof (see Operation).
+	Recovery []string `json:"recovery,omitempty"`
+	// DIDStatus is the explicit status of the document. It is set by DeactivateDIDDocGeneric so
+	// that the deactivation is covered by the proof. Use Status to read the effective status.
+	DIDStatus DocStatus `json:"status,omitempty"`
+	// DeactivatedAt is the datetime (RFC3339) when the DID was deactivated.
+	DeactivatedAt string `json:"deactivatedAt,omitempty"`
+	// PreviousProof is the fingerprint of the proof that the document carried before it was
+	// re-signed by UpgradeProof.
+	PreviousProof string `json:"previousProof,omitempty"`
+	// Extras holds properties that are not otherwise modeled, such as extensions added by other
+	// DID implementations. They are preserved when round tripping JSON, so that proofs over them
+	// can still be verified.
+	Extras map[string]json.RawMessage `json:"-"`
 }
 
 func (u *UnsignedDIDDoc) IsEmpty() bool {
@@ -27,6 +52,32 @@ func (u *UnsignedDIDDoc) IsEmpty() bool {
 	return reflect.DeepEqual(u, &UnsignedDIDDoc{})
 }
 
+// DocStatus is the status of a DID Document.
+type DocStatus string
+
+const (
+	StatusActive      DocStatus = "active"
+	StatusDeactivated DocStatus = "deactivated"
+)
+
+// Status returns the status of the document. The explicit status is used if present. Otherwise,
+// documents without public keys, authentication keys or services are considered deactivated, as
+// that is how DIDs were deactivated before the status was introduced.
+func (u *UnsignedDIDDoc) Status() DocStatus {
+	if u.DIDStatus != "" {
+		return u.DIDStatus
+	}
+	if len(u.PublicKey) == 0 && len(u.Authentication) == 0 && len(u.Service) == 0 {
+		return StatusDeactivated
+	}
+	return StatusActive
+}
+
+// IsDeactivated returns true if the document's status is deactivated (see Status).
+func (u *UnsignedDIDDoc) IsDeactivated() bool {
+	return u.Status() == StatusDeactivated
+}
+
 func (u *UnsignedDIDDoc) GetPublicKey(keyID string) *KeyDef {
 	for _, pubKey := range u.PublicKey {
 		if pubKey.ID == keyID {
@@ -49,6 +100,54 @@ func (d *DIDDoc) IsEmpty() bool {
 	return reflect.DeepEqual(d, &DIDDoc{})
 }
 
+// Clone returns a deep copy of the DID Document that shares no slices, maps or pointers with the
+// original, so that either can be modified safely.
+func (d DIDDoc) Clone() DIDDoc {
+	c := d
+	c.Context = cloneStrings(d.Context)
+	c.AlsoKnownAs = cloneStrings(d.AlsoKnownAs)
+	c.PublicKey = cloneKeyDefs(d.PublicKey)
+	c.Authentication = cloneStrings(d.Authentication)
+	c.AssertionMethod = cloneStrings(d.AssertionMethod)
+	c.KeyAgreement = cloneKeyDefs(d.KeyAgreement)
+	if d.Service != nil {
+		c.Service = append([]ServiceDef{}, d.Service...)
+	}
+	c.Recovery = cloneStrings(d.Recovery)
+	c.Extras = cloneExtras(d.Extras)
+	c.Proof = d.Proof.Clone()
+	return c
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func cloneKeyDefs(keys []KeyDef) []KeyDef {
+	if keys == nil {
+		return nil
+	}
+	c := make([]KeyDef, len(keys))
+	for i, key := range keys {
+		c[i] = key.Clone()
+	}
+	return c
+}
+
+func cloneExtras(extras map[string]json.RawMessage) map[string]json.RawMessage {
+	if extras == nil {
+		return nil
+	}
+	c := make(map[string]json.RawMessage, len(extras))
+	for k, v := range extras {
+		c[k] = append(json.RawMessage{}, v...)
+	}
+	return c
+}
+
 func (d *DIDDoc) GetProof() *proof.Proof {
 	return d.Proof
 }
@@ -57,12 +156,37 @@ func (d *DIDDoc) SetProof(p *proof.Proof) {
 	d.Proof = p
 }
 
-// KeyDef represents a DID public key
+func (d *DIDDoc) GetID() string {
+	return d.ID
+}
+
+// KeyDef represents a DID public key. The key material is either base58 encoded, multibase
+// encoded, or a JWK. Historical documents may instead carry the legacy base64 or hex encodings.
+// See Normalize. An empty publicKeyBase58 is marshaled unless another encoding is set (see
+// MarshalJSON).
 type KeyDef struct {
-	ID              string        `json:"id"`
-	Type            proof.KeyType `json:"type"`
-	Controller      string        `json:"controller,omitempty"`
-	PublicKeyBase58 string        `json:"publicKeyBase58"`
+	ID                 string        `json:"id"`
+	Type               proof.KeyType `json:"type"`
+	Controller         string        `json:"controller,omitempty"`
+	PublicKeyBase58    string        `json:"publicKeyBase58,omitempty"`
+	PublicKeyMultibase string        `json:"publicKeyMultibase,omitempty"`
+	PublicKeyJWK       *JWK          `json:"publicKeyJwk,omitempty"`
+	PublicKeyBase64    string        `json:"publicKeyBase64,omitempty"`
+	PublicKeyHex       string        `json:"publicKeyHex,omitempty"`
+	// Extras holds properties that are not otherwise modeled. See UnsignedDIDDoc.
+	Extras map[string]json.RawMessage `json:"-"`
+}
+
+// Clone returns a deep copy of the key definition that shares no maps or pointers with the
+// original.
+func (k KeyDef) Clone() KeyDef {
+	c := k
+	if k.PublicKeyJWK != nil {
+		jwk := *k.PublicKeyJWK
+		c.PublicKeyJWK = &jwk
+	}
+	c.Extras = cloneExtras(k.Extras)
+	return c
 }
 
 func (k *KeyDef) IsEmpty() bool {
@@ -72,10 +196,27 @@ func (k *KeyDef) IsEmpty() bool {
 	return reflect.DeepEqual(k, &KeyDef{})
 }
 
+// GetDecodedPublicKey returns the raw public key. The base58 encoded key takes precedence over
+// the other encodings, if more than one is present.
 func (k *KeyDef) GetDecodedPublicKey() ([]byte, error) {
+	if k.PublicKeyBase58 == "" && k.hasKeyMaterial() {
+		publicKey, _, err := k.decode()
+		return publicKey, err
+	}
 	return base58.Decode(k.PublicKeyBase58)
 }
 
+// hasKeyMaterial returns true if the key definition contains a public key in any encoding.
+func (k *KeyDef) hasKeyMaterial() bool {
+	return k.PublicKeyBase58 != "" || k.hasAlternativeKeyMaterial()
+}
+
+// hasAlternativeKeyMaterial returns true if the key definition contains a public key in an
+// encoding other than base58.
+func (k *KeyDef) hasAlternativeKeyMaterial() bool {
+	return k.PublicKeyMultibase != "" || k.PublicKeyJWK != nil || k.PublicKeyBase64 != "" || k.PublicKeyHex != ""
+}
+
 func (k *KeyDef) GetKeyFragment() (string, error) {
 	split := strings.Split(k.ID, "#")
 	if len(split) != 2 {
@@ -0,0 +1,50 @@
+package did
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/multibase"
+	"github.com/workdaycredentials/ledger-common/util/multicodec"
+)
+
+// EncodePublicKeyMultibase encodes a raw public key as a base58 multibase value with a multicodec
+// prefix, as used by publicKeyMultibase. Ed25519 keys must be raw 32 byte keys, and secp256k1 keys
+// must be in compressed SEC1 form.
+func EncodePublicKeyMultibase(publicKey []byte, keyType proof.KeyType) (string, error) {
+	codec, ok := multicodec.CodecForKeyType(string(normalizeKeyType(keyType)))
+	if !ok || codec == multicodec.X25519Pub {
+		return "", fmt.Errorf("unsupported key type: %s", keyType)
+	}
+	if actual, ok := multicodecKeyType(uint64(codec), publicKey); !ok || actual != normalizeKeyType(keyType) {
+		return "", fmt.Errorf("invalid %s public key", keyType)
+	}
+	return multicodecEncode(uint64(codec), publicKey), nil
+}
+
+// DecodePublicKeyMultibase decodes a publicKeyMultibase value into the raw public key and its
+// type, as determined by the multicodec prefix. Only the base58btc ("z") multibase encoding is
+// supported.
+func DecodePublicKeyMultibase(encoded string) ([]byte, proof.KeyType, error) {
+	base, decoded, err := multibase.Decode(encoded)
+	if errors.Is(err, multibase.ErrUnsupportedBase) {
+		return nil, "", err
+	}
+	if err == nil && base != multibase.Base58BTC {
+		return nil, "", errors.Wrapf(multibase.ErrUnsupportedBase, "prefix %q for public key", rune(base))
+	}
+	if err != nil || len(decoded) == 0 {
+		return nil, "", fmt.Errorf("invalid multibase public key: %s", encoded)
+	}
+	codec, publicKey, err := multicodec.Uvarint(decoded, false)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid multicodec prefix: %s", encoded)
+	}
+	keyType, ok := multicodecKeyType(uint64(codec), publicKey)
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported multicodec public key: %s", encoded)
+	}
+	return publicKey, keyType, nil
+}
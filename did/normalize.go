@@ -0,0 +1,110 @@
+package did
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// Normalize returns the canonical form of a DID so that DIDs can be compared as strings.
+// Any path, query or fragment (e.g. a key reference's "#key-1") is removed, the "did" scheme and
+// method name are lowercased, percent-encoded unreserved characters are decoded, and the remaining
+// percent-encodings use uppercase hex digits.
+//
+// The method-specific identifier is case-sensitive unless the method says otherwise:
+//   - did:work and did:key identifiers are base58 encoded and therefore case-sensitive.
+//   - did:web identifiers start with a domain name, which is case-insensitive and is lowercased.
+//     The path segments that follow are case-sensitive.
+//   - All other methods are treated as case-sensitive.
+func Normalize(s string) (string, error) {
+	if i := strings.IndexAny(s, "/?#"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 {
+		return "", errcode.Errorf(errcode.DIDMalformed, "invalid DID: %s", s)
+	}
+	scheme, method, id := strings.ToLower(parts[0]), strings.ToLower(parts[1]), parts[2]
+
+	if method == didMethod(WebDIDMethod) {
+		segments := strings.SplitN(id, ":", 2)
+		segments[0] = strings.ToLower(segments[0])
+		id = strings.Join(segments, ":")
+	}
+	id, err := normalizePercentEncoding(id)
+	if err != nil {
+		return "", errcode.Errorf(errcode.DIDMalformed, "invalid DID: %s", s)
+	}
+
+	did := scheme + ":" + method + ":" + id
+	if err := ValidateDIDSyntax(did); err != nil {
+		return "", err
+	}
+	return did, nil
+}
+
+// Equal returns true if both strings refer to the same DID after normalization. Strings that are
+// not valid DIDs are never equal.
+func Equal(a, b string) bool {
+	normalizedA, err := Normalize(a)
+	if err != nil {
+		return false
+	}
+	normalizedB, err := Normalize(b)
+	if err != nil {
+		return false
+	}
+	return normalizedA == normalizedB
+}
+
+// ExtractNormalizedDIDFromKeyRef is the same as ExtractDIDFromKeyRef, except that the DID is
+// normalized (see Normalize).
+func ExtractNormalizedDIDFromKeyRef(keyRef string) (string, error) {
+	return Normalize(ExtractDIDFromKeyRef(keyRef))
+}
+
+// normalizePercentEncoding decodes percent-encoded unreserved characters, which must not be
+// encoded, and uppercases the hex digits of all other percent-encodings (RFC 3986 section 6.2.2).
+func normalizePercentEncoding(s string) (string, error) {
+	if !strings.Contains(s, "%") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			return "", fmt.Errorf("invalid percent-encoding in %s", s)
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String(), nil
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
+
+func isUnreserved(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
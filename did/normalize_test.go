@@ -0,0 +1,58 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"did:work unchanged", "did:work:6sYe1y3zXhmyrBkgHgAgaq", "did:work:6sYe1y3zXhmyrBkgHgAgaq"},
+		{"fragment removed", "did:work:6sYe1y3zXhmyrBkgHgAgaq#key-1", "did:work:6sYe1y3zXhmyrBkgHgAgaq"},
+		{"path and query removed", "did:work:6sYe1y3zXhmyrBkgHgAgaq/path?query=1", "did:work:6sYe1y3zXhmyrBkgHgAgaq"},
+		{"scheme and method lowercased", "DID:Work:6sYe1y3zXhmyrBkgHgAgaq", "did:work:6sYe1y3zXhmyrBkgHgAgaq"},
+		{"did:key is case-sensitive", didKeyVectors[0].did, didKeyVectors[0].did},
+		{"did:web host lowercased", "did:web:Example.COM", "did:web:example.com"},
+		{"did:web path is case-sensitive", "did:web:Example.com:Users:Alice", "did:web:example.com:Users:Alice"},
+		{"did:web port encoding uppercased", "did:web:example.com%3a8443", "did:web:example.com%3A8443"},
+		{"unreserved characters decoded", "did:web:example.com:%7Ealice", "did:web:example.com:~alice"},
+		{"other methods are case-sensitive", "did:example:ABC", "did:example:ABC"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := Normalize(test.input)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	for _, bad := range []string{"", "did:work", "did:work:", "#key-1", "did:web:example.com%3", "did:web:example.com%zz"} {
+		_, err := Normalize(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal("did:work:6sYe1y3zXhmyrBkgHgAgaq", "did:work:6sYe1y3zXhmyrBkgHgAgaq#key-1"))
+	assert.True(t, Equal("did:web:example.com%3A8443", "did:web:EXAMPLE.com%3a8443"))
+	assert.True(t, Equal("did:web:example.com:%7Ealice", "did:web:example.com:~alice"))
+	assert.False(t, Equal("did:work:6sYe1y3zXhmyrBkgHgAgaq", "did:work:6SYE1Y3ZXHMYRBKGHGAGAQ"))
+	assert.False(t, Equal("did:web:example.com:alice", "did:web:example.com:Alice"))
+	assert.False(t, Equal("did:key:z6MkpTHR8VNsBxYAAWHut2Geadd9jSwuBV8xRoAnwWsdvktH", "did:key:z6mkpthr8vnsbxyaawhut2geadd9jswubv8xroanwwsdvkth"))
+	assert.False(t, Equal("", ""))
+	assert.False(t, Equal("not-a-did", "not-a-did"))
+}
+
+func TestExtractNormalizedDIDFromKeyRef(t *testing.T) {
+	did, err := ExtractNormalizedDIDFromKeyRef("DID:web:Example.com#key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "did:web:example.com", did)
+
+	_, err = ExtractNormalizedDIDFromKeyRef("key-1")
+	assert.Error(t, err)
+}
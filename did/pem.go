@@ -0,0 +1,130 @@
+package did
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+const (
+	pemPublicKeyType   = "PUBLIC KEY"
+	pemCertificateType = "CERTIFICATE"
+)
+
+var (
+	oidPublicKeyEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+	oidPublicKeyECDSA   = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidPublicKeyRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidCurveSecp256k1   = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// subjectPublicKeyInfo is the ASN.1 SubjectPublicKeyInfo structure defined in RFC 5280. The
+// standard library can't be used to parse secp256k1 keys, since it only supports the NIST curves.
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// KeyDefFromPEM builds a key definition from a PEM encoded public key (SPKI) or X.509 certificate.
+// Ed25519 keys are base58 encoded as raw 32 byte keys, and secp256k1 keys are base58 encoded in
+// DER form, as used by Workday DID Documents. Other algorithms are not supported.
+func KeyDefFromPEM(id, controller string, pemBytes []byte) (KeyDef, error) {
+	block, rest := pem.Decode(pemBytes)
+	if block == nil {
+		return KeyDef{}, fmt.Errorf("no PEM data found for key: %s", id)
+	}
+	if len(rest) > 0 {
+		if next, _ := pem.Decode(rest); next != nil {
+			return KeyDef{}, fmt.Errorf("expected a single PEM block for key: %s", id)
+		}
+	}
+
+	switch block.Type {
+	case pemPublicKeyType:
+		return keyDefFromSPKI(id, controller, block.Bytes)
+	case pemCertificateType:
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return KeyDef{}, fmt.Errorf("invalid certificate for key %s: %v", id, err)
+		}
+		return KeyDefFromCertificate(id, controller, cert)
+	}
+	return KeyDef{}, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+}
+
+// KeyDefFromCertificate builds a key definition from the public key of an X.509 certificate.
+// The certificate itself is not verified. See KeyDefFromPEM for the supported algorithms.
+func KeyDefFromCertificate(id, controller string, cert *x509.Certificate) (KeyDef, error) {
+	if cert == nil {
+		return KeyDef{}, fmt.Errorf("certificate required for key: %s", id)
+	}
+	return keyDefFromSPKI(id, controller, cert.RawSubjectPublicKeyInfo)
+}
+
+func keyDefFromSPKI(id, controller string, der []byte) (KeyDef, error) {
+	var spki subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil || len(rest) > 0 {
+		return KeyDef{}, fmt.Errorf("invalid public key info for key: %s", id)
+	}
+	keyDef := KeyDef{ID: id, Controller: controller}
+	algorithm := spki.Algorithm.Algorithm
+	publicKey := spki.PublicKey.RightAlign()
+
+	switch {
+	case algorithm.Equal(oidPublicKeyEd25519):
+		if len(publicKey) != ed25519.PublicKeySize {
+			return KeyDef{}, fmt.Errorf("invalid Ed25519 public key: %s", id)
+		}
+		keyDef.Type = proof.Ed25519KeyType
+		keyDef.PublicKeyBase58 = base58.Encode(publicKey)
+	case algorithm.Equal(oidPublicKeyECDSA):
+		var curve asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &curve); err != nil {
+			return KeyDef{}, fmt.Errorf("invalid ECDSA parameters for key: %s", id)
+		}
+		if !curve.Equal(oidCurveSecp256k1) {
+			return KeyDef{}, fmt.Errorf("unsupported ECDSA curve %s for key: %s", curve, id)
+		}
+		if _, err := btcec.ParsePubKey(publicKey, btcec.S256()); err != nil {
+			return KeyDef{}, fmt.Errorf("invalid secp256k1 public key %s: %v", id, err)
+		}
+		// Re-encode the key so that the DER form is canonical.
+		canonical, err := asn1.Marshal(spki)
+		if err != nil {
+			return KeyDef{}, err
+		}
+		keyDef.Type = proof.EcdsaSecp256k1KeyType
+		keyDef.PublicKeyBase58 = base58.Encode(canonical)
+	case algorithm.Equal(oidPublicKeyRSA):
+		return KeyDef{}, fmt.Errorf("RSA keys are not supported: %s", id)
+	default:
+		return KeyDef{}, fmt.Errorf("unsupported public key algorithm %s for key: %s", algorithm, id)
+	}
+	return keyDef, nil
+}
+
+// secp256k1PublicKeyDER encodes a secp256k1 public key in DER (SPKI) form, as used by Workday DID
+// Documents.
+func secp256k1PublicKeyDER(publicKey *btcec.PublicKey) ([]byte, error) {
+	curve, err := asn1.Marshal(oidCurveSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+	uncompressed := publicKey.SerializeUncompressed()
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: curve},
+		},
+		PublicKey: asn1.BitString{Bytes: uncompressed, BitLength: 8 * len(uncompressed)},
+	})
+}
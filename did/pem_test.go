@@ -0,0 +1,165 @@
+package did
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func encodePEM(blockType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func secp256k1PEM(t *testing.T, publicKey *btcec.PublicKey) []byte {
+	der, err := asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: mustMarshalASN1(t, oidCurveSecp256k1)},
+		},
+		PublicKey: asn1.BitString{Bytes: publicKey.SerializeUncompressed(), BitLength: 8 * btcec.PubKeyBytesLenUncompressed},
+	})
+	require.NoError(t, err)
+	return encodePEM(pemPublicKeyType, der)
+}
+
+func mustMarshalASN1(t *testing.T, value interface{}) []byte {
+	der, err := asn1.Marshal(value)
+	require.NoError(t, err)
+	return der
+}
+
+// signAndVerify signs a DID Document with the signer and verifies it using the key definition.
+func signAndVerify(t *testing.T, keyDef KeyDef, signer proof.Signer, signatureType proof.SignatureType, version proof.ModelVersion) {
+	suite, err := proof.SignatureSuites().GetSuite(signatureType, version)
+	require.NoError(t, err)
+	doc := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: testWorkDID, PublicKey: []KeyDef{keyDef}}}
+	require.NoError(t, suite.Sign(&doc, signer))
+
+	verifier, err := AsVerifier(keyDef)
+	require.NoError(t, err)
+	assert.NoError(t, suite.Verify(&doc, verifier))
+}
+
+func TestKeyDefFromPEM(t *testing.T) {
+	keyID := testWorkDID + "#key-1"
+
+	t.Run("Ed25519 public key", func(t *testing.T) {
+		der, err := x509.MarshalPKIXPublicKey(issuerPubKey)
+		require.NoError(t, err)
+		keyDef, err := KeyDefFromPEM(keyID, testWorkDID, encodePEM(pemPublicKeyType, der))
+		require.NoError(t, err)
+		assert.Equal(t, KeyDef{
+			ID:              keyID,
+			Type:            proof.Ed25519KeyType,
+			Controller:      testWorkDID,
+			PublicKeyBase58: base58.Encode(issuerPubKey),
+		}, keyDef)
+
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, keyID)
+		require.NoError(t, err)
+		signAndVerify(t, keyDef, signer, proof.JCSEdSignatureType, proof.V2)
+	})
+
+	t.Run("Ed25519 certificate", func(t *testing.T) {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "issuer"},
+			NotBefore:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:     time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, issuerPubKey, issuerPrivKey)
+		require.NoError(t, err)
+
+		keyDef, err := KeyDefFromPEM(keyID, testWorkDID, encodePEM(pemCertificateType, der))
+		require.NoError(t, err)
+		assert.Equal(t, base58.Encode(issuerPubKey), keyDef.PublicKeyBase58)
+
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		fromCert, err := KeyDefFromCertificate(keyID, testWorkDID, cert)
+		require.NoError(t, err)
+		assert.Equal(t, keyDef, fromCert)
+
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, keyID)
+		require.NoError(t, err)
+		signAndVerify(t, fromCert, signer, proof.WorkEdSignatureType, proof.V2)
+	})
+
+	t.Run("secp256k1 public key", func(t *testing.T) {
+		privateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		keyDef, err := KeyDefFromPEM(keyID, testWorkDID, secp256k1PEM(t, privateKey.PubKey()))
+		require.NoError(t, err)
+		assert.Equal(t, proof.EcdsaSecp256k1KeyType, keyDef.Type)
+
+		signer, err := proof.NewSecp256k1Signer(privateKey.ToECDSA(), keyID)
+		require.NoError(t, err)
+		signAndVerify(t, keyDef, signer, proof.EcdsaSecp256k1SignatureType, proof.V1)
+	})
+
+	t.Run("secp256k1 DER matches existing encoding", func(t *testing.T) {
+		der, err := base64.StdEncoding.DecodeString(secp256k1DERKeyB64)
+		require.NoError(t, err)
+		keyDef, err := KeyDefFromPEM(keyID, testWorkDID, encodePEM(pemPublicKeyType, der))
+		require.NoError(t, err)
+		assert.Equal(t, base58.Encode(der), keyDef.PublicKeyBase58)
+
+		jwkKey, err := jwkKeyDef(t, proof.EcdsaSecp256k1KeyType, secp256k1PublicJWK).Normalize()
+		require.NoError(t, err)
+		normalized, err := keyDef.Normalize()
+		require.NoError(t, err)
+		assert.Equal(t, jwkKey.PublicKeyBase58, normalized.PublicKeyBase58)
+	})
+
+	t.Run("Unsupported algorithms", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		rsaDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		require.NoError(t, err)
+		_, err = KeyDefFromPEM(keyID, testWorkDID, encodePEM(pemPublicKeyType, rsaDER))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "RSA keys are not supported")
+
+		p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		p256DER, err := x509.MarshalPKIXPublicKey(&p256Key.PublicKey)
+		require.NoError(t, err)
+		_, err = KeyDefFromPEM(keyID, testWorkDID, encodePEM(pemPublicKeyType, p256DER))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported ECDSA curve")
+	})
+
+	t.Run("Malformed input", func(t *testing.T) {
+		der, err := x509.MarshalPKIXPublicKey(issuerPubKey)
+		require.NoError(t, err)
+		for name, input := range map[string][]byte{
+			"not PEM":         []byte("not a key"),
+			"private key":     encodePEM("PRIVATE KEY", der),
+			"truncated":       encodePEM(pemPublicKeyType, der[:len(der)-1]),
+			"bad certificate": encodePEM(pemCertificateType, der),
+			"multiple blocks": append(encodePEM(pemPublicKeyType, der), encodePEM(pemPublicKeyType, der)...),
+		} {
+			_, err := KeyDefFromPEM(keyID, testWorkDID, input)
+			assert.Error(t, err, name)
+		}
+
+		_, err = KeyDefFromCertificate(keyID, testWorkDID, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,183 @@
+package did
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+var (
+	// ErrPossessionExpired is returned by VerifyPossession when the challenge has expired.
+	ErrPossessionExpired = errcode.New(errcode.Expired, "possession challenge has expired")
+	// ErrAudienceMismatch is returned by VerifyPossession when the response answers another
+	// verifier's challenge.
+	ErrAudienceMismatch = errcode.New(errcode.Replay, "possession challenge is for another audience")
+	// ErrHolderMismatch is returned by VerifyPossession when the response is not made by the
+	// expected DID.
+	ErrHolderMismatch = errcode.New(errcode.SignatureInvalid, "possession response is not signed by the expected DID")
+)
+
+// PossessionChallenge is a verifier's signed challenge to prove control of a DID, e.g. before a
+// credential is issued to it. The verifier does not need to keep the challenge: it comes back in
+// the response, and its proof shows that the verifier made it.
+type PossessionChallenge struct {
+	// Audience is the DID of the verifier, whose key signs the challenge.
+	Audience string `json:"audience"`
+	Nonce    string `json:"nonce"`
+	// Expires is the RFC 3339 time after which the challenge cannot be answered.
+	Expires string       `json:"expires"`
+	Proof   *proof.Proof `json:"proof,omitempty"`
+}
+
+func (c *PossessionChallenge) GetProof() *proof.Proof {
+	return c.Proof
+}
+
+func (c *PossessionChallenge) SetProof(p *proof.Proof) {
+	c.Proof = p
+}
+
+// GetID returns the nonce, which is fresh for each challenge.
+func (c *PossessionChallenge) GetID() string {
+	return c.Nonce
+}
+
+// PossessionResponse is the holder's answer to a PossessionChallenge. The holder signs the whole
+// challenge, so that the response is bound to its nonce and audience.
+type PossessionResponse struct {
+	// Holder is the DID whose control is proven.
+	Holder    string              `json:"holder"`
+	Challenge PossessionChallenge `json:"challenge"`
+	Proof     *proof.Proof        `json:"proof,omitempty"`
+}
+
+func (r *PossessionResponse) GetProof() *proof.Proof {
+	return r.Proof
+}
+
+func (r *PossessionResponse) SetProof(p *proof.Proof) {
+	r.Proof = p
+}
+
+// GetID returns the nonce of the challenge that the response answers.
+func (r *PossessionResponse) GetID() string {
+	return r.Challenge.Nonce
+}
+
+// PossessionOption configures VerifyPossession.
+type PossessionOption func(*possessionOptions)
+
+type possessionOptions struct {
+	now func() time.Time
+}
+
+// WithPossessionClock sets the clock that the challenge's expiry is checked against. The default
+// is time.Now.
+func WithPossessionClock(now func() time.Time) PossessionOption {
+	return func(o *possessionOptions) {
+		o.now = now
+	}
+}
+
+// NewPossessionChallenge returns a challenge with a random nonce that expires after the time to
+// live, signed by the verifier, whose key must belong to the audience DID.
+func NewPossessionChallenge(audienceDID string, ttl time.Duration, verifierSigner proof.Signer) (*PossessionChallenge, error) {
+	if ttl <= 0 {
+		return nil, errors.New("possession challenge time to live must be positive")
+	}
+	if !Equal(ExtractDIDFromKeyRef(verifierSigner.ID()), audienceDID) {
+		return nil, errcode.Errorf(errcode.KeyNotFound, "signer key<%s> does not belong to DID<%s>", verifierSigner.ID(), audienceDID)
+	}
+	challenge := &PossessionChallenge{
+		Audience: audienceDID,
+		Nonce:    util.NewID(""),
+		Expires:  util.FormatCanonicalTime(time.Now().Add(ttl)),
+	}
+	suite, err := suiteForSigner(verifierSigner)
+	if err != nil {
+		return nil, err
+	}
+	if err := suite.Sign(challenge, verifierSigner); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// RespondToChallenge signs the challenge with the holder's key. The holder is the DID of the key.
+func RespondToChallenge(challenge PossessionChallenge, holderSigner proof.Signer) (*PossessionResponse, error) {
+	if challenge.Proof.IsEmpty() {
+		return nil, errcode.New(errcode.MalformedProof, "possession challenge is not signed")
+	}
+	response := &PossessionResponse{
+		Holder:    ExtractDIDFromKeyRef(holderSigner.ID()),
+		Challenge: challenge,
+	}
+	suite, err := suiteForSigner(holderSigner)
+	if err != nil {
+		return nil, err
+	}
+	if err := suite.Sign(response, holderSigner); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// VerifyPossession checks that the response proves control of the expected DID to the verifier
+// with the audience DID: the challenge must be for the audience, unexpired, and signed by a key of
+// the audience; the response must be signed by a key of the expected DID that is authorized to
+// make proofs. Both DIDs are resolved with the resolver, and must be active. Returns
+// ErrHolderMismatch, ErrAudienceMismatch or ErrPossessionExpired if the response is for another
+// DID or verifier, or too late.
+func VerifyPossession(ctx context.Context, response *PossessionResponse, resolver Resolver, expectedDID, audienceDID string, opts ...PossessionOption) error {
+	options := possessionOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if !Equal(response.Holder, expectedDID) {
+		return ErrHolderMismatch
+	}
+	challenge := response.Challenge
+	if !Equal(challenge.Audience, audienceDID) {
+		return ErrAudienceMismatch
+	}
+	expires, err := util.ParseRFC3339Lenient(challenge.Expires)
+	if err != nil {
+		return errors.Wrapf(err, "invalid possession challenge expiry<%s>", challenge.Expires)
+	}
+	if !options.now().Before(expires) {
+		return ErrPossessionExpired
+	}
+
+	if err := verifyProofOfDID(ctx, resolver, &challenge, challenge.Audience); err != nil {
+		return errors.Wrap(err, "possession challenge")
+	}
+	if err := verifyProofOfDID(ctx, resolver, response, response.Holder); err != nil {
+		if errcode.CodeOf(err) == errcode.KeyNotFound {
+			return ErrHolderMismatch
+		}
+		return errors.Wrap(err, "possession response")
+	}
+	return nil
+}
+
+// verifyProofOfDID verifies the proof on the provable against the current keys of the DID.
+func verifyProofOfDID(ctx context.Context, resolver Resolver, provable proof.Provable, did string) error {
+	p := provable.GetProof()
+	if p.IsEmpty() {
+		return errcode.New(errcode.MalformedProof, "proof cannot be empty")
+	}
+	if !Equal(ExtractDIDFromKeyRef(p.GetVerificationMethod()), did) {
+		return errcode.Errorf(errcode.KeyNotFound, "proof key<%s> does not belong to DID<%s>", p.GetVerificationMethod(), did)
+	}
+	doc, err := resolveActive(ctx, resolver, did)
+	if err != nil {
+		return err
+	}
+	return VerifyProofForOperation(provable, doc.UnsignedDIDDoc, ProofOperation)
+}
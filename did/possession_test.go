@@ -0,0 +1,102 @@
+package did
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestPossession(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	newIdentity := func(t *testing.T) (*DIDDoc, proof.Signer) {
+		doc, key := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		require.NoError(t, registry.Put(*doc))
+		signer, err := proof.NewEd25519Signer(key, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		return doc, signer
+	}
+	verifierDoc, verifierSigner := newIdentity(t)
+	holderDoc, holderSigner := newIdentity(t)
+	otherDoc, otherSigner := newIdentity(t)
+
+	challenge, err := NewPossessionChallenge(verifierDoc.ID, time.Minute, verifierSigner)
+	require.NoError(t, err)
+	response, err := RespondToChallenge(*challenge, holderSigner)
+	require.NoError(t, err)
+	assert.Equal(t, holderDoc.ID, response.Holder)
+
+	t.Run("Verify", func(t *testing.T) {
+		data, err := json.Marshal(response)
+		require.NoError(t, err)
+		var decoded PossessionResponse
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.NoError(t, VerifyPossession(ctx, &decoded, registry, holderDoc.ID, verifierDoc.ID))
+	})
+
+	t.Run("Other audience", func(t *testing.T) {
+		assert.Equal(t, ErrAudienceMismatch, VerifyPossession(ctx, response, registry, holderDoc.ID, otherDoc.ID))
+
+		// The audience is covered by both proofs.
+		tampered := *response
+		tampered.Challenge.Audience = otherDoc.ID
+		assert.Error(t, VerifyPossession(ctx, &tampered, registry, holderDoc.ID, otherDoc.ID))
+
+		// A challenge that the audience did not sign.
+		forged, err := NewPossessionChallenge(otherDoc.ID, time.Minute, otherSigner)
+		require.NoError(t, err)
+		forged.Audience = verifierDoc.ID
+		forgedResponse, err := RespondToChallenge(*forged, holderSigner)
+		require.NoError(t, err)
+		err = VerifyPossession(ctx, forgedResponse, registry, holderDoc.ID, verifierDoc.ID)
+		assert.Error(t, err)
+		assert.NotEqual(t, ErrAudienceMismatch, err)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		late := WithPossessionClock(func() time.Time { return time.Now().Add(time.Hour) })
+		assert.Equal(t, ErrPossessionExpired, VerifyPossession(ctx, response, registry, holderDoc.ID, verifierDoc.ID, late))
+		early := WithPossessionClock(func() time.Time { return time.Now().Add(30 * time.Second) })
+		assert.NoError(t, VerifyPossession(ctx, response, registry, holderDoc.ID, verifierDoc.ID, early))
+
+		tampered := *response
+		tampered.Challenge.Expires = "2999-01-01T00:00:00Z"
+		assert.Error(t, VerifyPossession(ctx, &tampered, registry, holderDoc.ID, verifierDoc.ID, late))
+	})
+
+	t.Run("Other holder", func(t *testing.T) {
+		assert.Equal(t, ErrHolderMismatch, VerifyPossession(ctx, response, registry, otherDoc.ID, verifierDoc.ID))
+
+		// Signed by another DID, and presented as the expected DID's.
+		otherResponse, err := RespondToChallenge(*challenge, otherSigner)
+		require.NoError(t, err)
+		otherResponse.Holder = holderDoc.ID
+		assert.Equal(t, ErrHolderMismatch, VerifyPossession(ctx, otherResponse, registry, holderDoc.ID, verifierDoc.ID))
+
+		// Signed with a key that is not in the holder's DID Document.
+		_, key := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		unknownSigner, err := proof.NewEd25519Signer(key, GenerateKeyID(holderDoc.ID, "unknown"))
+		require.NoError(t, err)
+		unknownResponse, err := RespondToChallenge(*challenge, unknownSigner)
+		require.NoError(t, err)
+		err = VerifyPossession(ctx, unknownResponse, registry, holderDoc.ID, verifierDoc.ID)
+		assert.Equal(t, ErrHolderMismatch, err)
+	})
+
+	t.Run("Invalid challenges", func(t *testing.T) {
+		_, err := NewPossessionChallenge(verifierDoc.ID, time.Minute, holderSigner)
+		assert.Error(t, err)
+		_, err = NewPossessionChallenge(verifierDoc.ID, 0, verifierSigner)
+		assert.Error(t, err)
+		unsigned := *challenge
+		unsigned.Proof = nil
+		_, err = RespondToChallenge(unsigned, holderSigner)
+		assert.Error(t, err)
+	})
+}
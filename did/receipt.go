@@ -0,0 +1,189 @@
+package did
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ReceiptKind is the kind of operation that a Receipt records.
+type ReceiptKind string
+
+const (
+	// DeactivationReceipt records the deactivation of a DID (see DeactivateDIDDoc). The target is
+	// the DID, and its fingerprint is the Fingerprint of the DID Document that was deactivated.
+	DeactivationReceipt ReceiptKind = "Deactivation"
+	// RevocationReceipt records the revocation of a credential. The target is the credential ID,
+	// and its fingerprint is the fingerprint of the credential.
+	RevocationReceipt ReceiptKind = "Revocation"
+)
+
+// ErrReceiptMismatch is returned by VerifyReceipt when the receipt does not match the target of
+// the operation, e.g. because the operation never happened.
+var ErrReceiptMismatch = errcode.New(errcode.SignatureInvalid, "receipt does not match the target of the operation")
+
+// Receipt is a portable, signed record of an operation, such as a deactivation, that the subject
+// of the operation can hold to prove when and by whom it happened.
+type Receipt struct {
+	Kind ReceiptKind `json:"kind"`
+	// TargetID is the ID of the target of the operation, e.g. the deactivated DID.
+	TargetID string `json:"target"`
+	// TargetFingerprint is the fingerprint of the target before the operation.
+	TargetFingerprint string `json:"targetFingerprint"`
+	// Actor is the key reference of the key that made the operation, which signs the receipt.
+	Actor string `json:"actor"`
+	// Timestamp is the RFC 3339 time of the operation.
+	Timestamp string       `json:"timestamp"`
+	Proof     *proof.Proof `json:"proof,omitempty"`
+}
+
+func (r *Receipt) GetProof() *proof.Proof {
+	return r.Proof
+}
+
+func (r *Receipt) SetProof(p *proof.Proof) {
+	r.Proof = p
+}
+
+// ReceiptOption makes an operation emit a Receipt.
+type ReceiptOption func(*receiptOptions)
+
+type receiptOptions struct {
+	receipt *Receipt
+}
+
+// WithReceipt makes the operation sign a Receipt of itself with the actor's key, and store it in
+// the receipt.
+func WithReceipt(receipt *Receipt) ReceiptOption {
+	return func(o *receiptOptions) {
+		o.receipt = receipt
+	}
+}
+
+// SignReceipt returns a receipt of the operation on the target at the time, signed by the actor,
+// the signer's key.
+func SignReceipt(kind ReceiptKind, targetID, targetFingerprint string, timestamp time.Time, signer proof.Signer) (*Receipt, error) {
+	receipt := &Receipt{
+		Kind:              kind,
+		TargetID:          targetID,
+		TargetFingerprint: targetFingerprint,
+		Actor:             signer.ID(),
+		Timestamp:         util.FormatCanonicalTime(timestamp),
+	}
+	if err := receipt.validate(); err != nil {
+		return nil, err
+	}
+	suite, err := suiteForSigner(signer)
+	if err != nil {
+		return nil, err
+	}
+	if err := suite.Sign(receipt, signer); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// VerifyReceipt checks that the receipt is signed by its actor, with a key that was authorized to
+// make proofs in the actor's DID Document at the time of the operation. The DID Document is
+// resolved as of that time if the resolver supports versioned resolution, and the current version
+// is used otherwise. For a deactivation, the DID must be deactivated, and the DID Document that it
+// replaced, which is resolved as the version before the deactivation from resolvers that number
+// their versions, such as MemoryRegistry, must have the receipt's fingerprint; ErrReceiptMismatch
+// is returned otherwise. The fingerprint of a revocation is not checked, since the credential is
+// needed for it.
+func VerifyReceipt(ctx context.Context, r Receipt, resolver Resolver) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	timestamp, err := util.ParseRFC3339Lenient(r.Timestamp)
+	if err != nil {
+		return errors.Wrapf(err, "invalid receipt timestamp<%s>", r.Timestamp)
+	}
+	if r.Proof.IsEmpty() {
+		return errcode.Errorf(errcode.MalformedProof, "receipt of target<%s> does not have a proof", r.TargetID)
+	}
+	if r.Proof.GetVerificationMethod() != r.Actor {
+		return errcode.Errorf(errcode.SignatureInvalid, "receipt is not signed by its actor<%s>", r.Actor)
+	}
+
+	actorDID := ExtractDIDFromKeyRef(r.Actor)
+	result, err := resolveAsOf(ctx, resolver, actorDID, timestamp)
+	if err != nil {
+		return err
+	}
+	if r.Kind == DeactivationReceipt {
+		if result, err = resolveDeactivated(ctx, resolver, result, r); err != nil {
+			return err
+		}
+	} else if result.DocumentMetadata.Deactivated {
+		return errors.Wrapf(ErrDeactivated, "receipt actor<%s>", actorDID)
+	}
+	return VerifyProofForOperation(&r, result.DIDDoc.UnsignedDIDDoc, ProofOperation)
+}
+
+// validate checks that the receipt has a known kind, a target and a fingerprint, and that a
+// deactivation is made by a key of the deactivated DID.
+func (r Receipt) validate() error {
+	switch r.Kind {
+	case DeactivationReceipt, RevocationReceipt:
+	default:
+		return errors.Errorf("unknown receipt kind<%s>", r.Kind)
+	}
+	if r.TargetID == "" || r.TargetFingerprint == "" {
+		return errors.New("receipt target and fingerprint are required")
+	}
+	if _, _, err := ParseKeyRef(r.Actor); err != nil {
+		return errors.Wrap(err, "invalid receipt actor")
+	}
+	if r.Kind == DeactivationReceipt && !Equal(ExtractDIDFromKeyRef(r.Actor), r.TargetID) {
+		return errors.Errorf("deactivation of DID<%s> cannot be made by actor<%s>", r.TargetID, r.Actor)
+	}
+	return nil
+}
+
+// resolveAsOf resolves the version of the DID Document that was current at the time, or the
+// current version if the resolver does not support versioned resolution.
+func resolveAsOf(ctx context.Context, resolver Resolver, did string, at time.Time) (*ResolutionResult, error) {
+	result, err := ResolveWithOptions(ctx, resolver, did, ResolutionOptions{VersionTime: at, AcceptDeactivated: true})
+	if errors.Cause(err) == ErrVersionNotSupported {
+		return ResolveWithOptions(ctx, resolver, did, ResolutionOptions{AcceptDeactivated: true})
+	}
+	return result, err
+}
+
+// resolveDeactivated checks that the receipt's DID is deactivated, and returns the version of its
+// DID Document that the deactivation replaced, given the version that was current at the time of
+// the deactivation, which must have the receipt's fingerprint.
+func resolveDeactivated(ctx context.Context, resolver Resolver, result *ResolutionResult, r Receipt) (*ResolutionResult, error) {
+	current, err := ResolveWithOptions(ctx, resolver, r.TargetID, ResolutionOptions{AcceptDeactivated: true})
+	if err != nil {
+		return nil, err
+	}
+	if !current.DocumentMetadata.Deactivated {
+		return nil, errors.Wrapf(ErrReceiptMismatch, "DID<%s> is not deactivated", r.TargetID)
+	}
+	if result.DocumentMetadata.Deactivated {
+		version, err := strconv.Atoi(result.DocumentMetadata.VersionID)
+		if err != nil || version < 2 {
+			return nil, errors.Wrapf(ErrVersionNotSupported, "DID<%s> before its deactivation", r.TargetID)
+		}
+		previous := ResolutionOptions{VersionID: strconv.Itoa(version - 1), AcceptDeactivated: true}
+		if result, err = ResolveWithOptions(ctx, resolver, r.TargetID, previous); err != nil {
+			return nil, err
+		}
+	}
+	fingerprint, err := Fingerprint(*result.DIDDoc)
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint != r.TargetFingerprint {
+		return nil, errors.Wrapf(ErrReceiptMismatch, "DID<%s> fingerprint", r.TargetID)
+	}
+	return result, nil
+}
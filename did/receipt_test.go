@@ -0,0 +1,82 @@
+package did
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestReceipt(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	require.NoError(t, registry.Put(*doc))
+	signer, err := proof.NewEd25519Signer(privateKey, doc.PublicKey[0].ID)
+	require.NoError(t, err)
+	fingerprint, err := Fingerprint(*doc)
+	require.NoError(t, err)
+
+	// A receipt of an operation that has not happened yet.
+	early, err := SignReceipt(DeactivationReceipt, doc.ID, fingerprint, time.Now(), signer)
+	require.NoError(t, err)
+	assert.Equal(t, ErrReceiptMismatch, errors.Cause(VerifyReceipt(ctx, *early, registry)))
+
+	var receipt Receipt
+	deactivated, err := DeactivateDIDDoc(*doc, privateKey, WithReceipt(&receipt))
+	require.NoError(t, err)
+	require.NoError(t, registry.Deactivate(*deactivated))
+	assert.Equal(t, DeactivationReceipt, receipt.Kind)
+	assert.Equal(t, doc.ID, receipt.TargetID)
+	assert.Equal(t, fingerprint, receipt.TargetFingerprint)
+	assert.Equal(t, signer.ID(), receipt.Actor)
+	assert.Equal(t, deactivated.DeactivatedAt, receipt.Timestamp)
+
+	data, err := json.Marshal(receipt)
+	require.NoError(t, err)
+	var decoded Receipt
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, receipt, decoded)
+	require.NoError(t, VerifyReceipt(ctx, decoded, registry))
+
+	t.Run("Tampered", func(t *testing.T) {
+		tampered := decoded
+		tampered.Timestamp = "2020-01-01T00:00:00Z"
+		assert.Error(t, VerifyReceipt(ctx, tampered, registry))
+		tampered = decoded
+		tampered.Kind = RevocationReceipt
+		assert.Error(t, VerifyReceipt(ctx, tampered, registry))
+		tampered = decoded
+		tampered.Actor = doc.ID + "#other"
+		assert.Error(t, VerifyReceipt(ctx, tampered, registry))
+	})
+
+	t.Run("Operation that never happened", func(t *testing.T) {
+		other, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		otherFingerprint, err := Fingerprint(*other)
+		require.NoError(t, err)
+		forged, err := SignReceipt(DeactivationReceipt, doc.ID, otherFingerprint, time.Now(), signer)
+		require.NoError(t, err)
+		assert.Equal(t, ErrReceiptMismatch, errors.Cause(VerifyReceipt(ctx, *forged, registry)))
+	})
+
+	t.Run("Non-holder actor", func(t *testing.T) {
+		other, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		require.NoError(t, registry.Put(*other))
+		otherSigner, err := proof.NewEd25519Signer(otherKey, other.PublicKey[0].ID)
+		require.NoError(t, err)
+		_, err = SignReceipt(DeactivationReceipt, doc.ID, fingerprint, time.Now(), otherSigner)
+		assert.Error(t, err)
+	})
+
+	t.Run("Unversioned resolver", func(t *testing.T) {
+		resolver := ResolverFunc(registry.Resolve)
+		assert.Equal(t, ErrVersionNotSupported, errors.Cause(VerifyReceipt(ctx, decoded, resolver)))
+	})
+}
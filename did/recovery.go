@@ -0,0 +1,146 @@
+package did
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ErrRecoveryKeyNotPermitted is returned when a recovery key is used for anything other than
+// rotating the keys of its DID Document or deactivating it.
+var ErrRecoveryKeyNotPermitted = errcode.New(errcode.SignatureInvalid, "recovery key can only authorize key rotation and deactivation")
+
+// Operation is what a proof made with a DID's key is authorizing. It determines whether the
+// document's recovery key may be used.
+type Operation int
+
+const (
+	// ProofOperation is any proof other than a change to the signer's DID Document, such as a
+	// credential proof. Recovery keys are not permitted.
+	ProofOperation Operation = iota
+	// UpdateOperation is an update of the signer's DID Document that keeps its keys, such as a
+	// change to its services. Recovery keys are not permitted.
+	UpdateOperation
+	// DeactivateOperation is the deactivation of the signer's DID.
+	DeactivateOperation
+	// RotateOperation is an update of the signer's DID Document that adds, removes or replaces any
+	// of its keys, or changes its recovery key.
+	RotateOperation
+)
+
+// recoveryPermitted returns true if the recovery key may authorize the operation.
+func (op Operation) recoveryPermitted() bool {
+	return op == RotateOperation || op == DeactivateOperation
+}
+
+// UpdateOperationFor returns the operation that replacing the current version of a DID Document
+// with the updated one authorizes: DeactivateOperation if the updated version is deactivated,
+// RotateOperation if its keys differ from those of the current version (see RotateOperation), and
+// otherwise UpdateOperation. Keys are compared after normalization (see KeyDef.Normalize).
+func UpdateOperationFor(current, updated UnsignedDIDDoc) Operation {
+	if updated.IsDeactivated() {
+		return DeactivateOperation
+	}
+	if len(current.PublicKey) != len(updated.PublicKey) {
+		return RotateOperation
+	}
+	for _, key := range updated.PublicKey {
+		currentKey, err := current.ResolveKeyRef(key.ID)
+		if err != nil || !sameKey(*currentKey, key) || current.IsRecoveryKey(key.ID) != updated.IsRecoveryKey(key.ID) {
+			return RotateOperation
+		}
+	}
+	return UpdateOperation
+}
+
+// sameKey returns true if both key definitions are valid and define the same public key.
+func sameKey(a, b KeyDef) bool {
+	normalizedA, err := a.Normalize()
+	if err != nil {
+		return false
+	}
+	normalizedB, err := b.Normalize()
+	if err != nil {
+		return false
+	}
+	return normalizedA.Type == normalizedB.Type && normalizedA.PublicKeyBase58 == normalizedB.PublicKeyBase58
+}
+
+// IsRecoveryKey returns true if the key reference resolves to the document's recovery key.
+func (u *UnsignedDIDDoc) IsRecoveryKey(ref string) bool {
+	keyDef, err := u.ResolveKeyRef(ref)
+	if err != nil {
+		return false
+	}
+	for _, recoveryRef := range u.Recovery {
+		if recoveryKey, err := u.ResolveKeyRef(recoveryRef); err == nil && recoveryKey.ID == keyDef.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizedKey returns the public key referenced by the key reference (see ResolveKeyRef),
+// provided that the key may be used for the operation. ErrRecoveryKeyNotPermitted is returned if
+// the key is the document's recovery key and the operation is neither a key rotation nor a
+// deactivation.
+func (u *UnsignedDIDDoc) AuthorizedKey(ref string, op Operation) (*KeyDef, error) {
+	keyDef, err := u.ResolveKeyRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if !op.recoveryPermitted() && u.IsRecoveryKey(ref) {
+		return nil, errors.Wrap(ErrRecoveryKeyNotPermitted, ref)
+	}
+	return keyDef, nil
+}
+
+// VerifyProofForOperation verifies the proof on the provable using a key from the signing DID
+// Document, which must be permitted to authorize the operation (see AuthorizedKey).
+func VerifyProofForOperation(provable proof.Provable, signingDoc UnsignedDIDDoc, op Operation) error {
+	p := provable.GetProof()
+	if p.IsEmpty() {
+		return errcode.New(errcode.MalformedProof, "proof cannot be empty")
+	}
+	keyDef, err := signingDoc.AuthorizedKey(p.GetVerificationMethod(), op)
+	if err != nil {
+		return err
+	}
+	verifier, err := AsVerifier(*keyDef)
+	if err != nil {
+		return err
+	}
+	suite, err := proof.SignatureSuites().GetSuiteForProof(p)
+	if err != nil {
+		return err
+	}
+	return suite.Verify(provable, verifier)
+}
+
+// UpdateDIDDoc signs an updated version of a DID Document. The signer's key must be from the
+// current version of the document. It may be the recovery key if the update rotates the keys (see
+// UpdateOperationFor), so that the keys can be rotated if the other keys are lost. The updated
+// document must have the same DID and pass Validate.
+func UpdateDIDDoc(current DIDDoc, updated UnsignedDIDDoc, signer proof.Signer, sigType proof.SignatureType) (*DIDDoc, error) {
+	if !Equal(current.ID, updated.ID) {
+		return nil, fmt.Errorf("updated DID<%s> does not match DID<%s>", updated.ID, current.ID)
+	}
+	keyDef, err := current.AuthorizedKey(signer.ID(), UpdateOperationFor(current.UnsignedDIDDoc, updated))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignerPublicKey(*keyDef, signer); err != nil {
+		return nil, err
+	}
+	doc := DIDDoc{UnsignedDIDDoc: updated}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	if err := signWithSuite(&doc, signer, sigType); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
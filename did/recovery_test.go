@@ -0,0 +1,171 @@
+package did
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestRecoveryKey(t *testing.T) {
+	newDoc := func(t *testing.T) (*DIDDoc, []ed25519.PrivateKey) {
+		doc, privateKeys, err := GenerateDIDDocWithKeys([]KeyMaterial{
+			{PrivateKey: issuerPrivKey, Purposes: []KeyPurpose{AuthenticationPurpose, AssertionMethodPurpose}},
+			{Purposes: []KeyPurpose{RecoveryPurpose}},
+		}, 0, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		return doc, privateKeys
+	}
+	doc, privateKeys := newDoc(t)
+	recoveryRef := doc.ID + "#key-2"
+	recoverySigner, err := proof.NewEd25519Signer(privateKeys[1], recoveryRef)
+	require.NoError(t, err)
+
+	t.Run("Generated", func(t *testing.T) {
+		assert.Equal(t, []string{recoveryRef}, doc.Recovery)
+		assert.True(t, doc.IsRecoveryKey(recoveryRef))
+		assert.True(t, doc.IsRecoveryKey("#key-2"))
+		assert.False(t, doc.IsRecoveryKey(doc.ID+"#key-1"))
+		assert.NoError(t, doc.Validate())
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		invalid := map[string]func(d *DIDDoc){
+			"More than one":    func(d *DIDDoc) { d.Recovery = append(d.Recovery, d.ID+"#key-1") },
+			"Initial key":      func(d *DIDDoc) { d.Recovery = []string{d.ID + "#key-1"} },
+			"Missing key":      func(d *DIDDoc) { d.Recovery = []string{d.ID + "#key-3"} },
+			"Also assertion":   func(d *DIDDoc) { d.AssertionMethod = append(d.AssertionMethod, recoveryRef) },
+			"Also authn (rel)": func(d *DIDDoc) { d.Authentication = append(d.Authentication, "#key-2") },
+		}
+		for name, mutate := range invalid {
+			d, _ := newDoc(t)
+			mutate(d)
+			assert.Error(t, d.Validate(), name)
+		}
+	})
+
+	t.Run("Not permitted for proofs", func(t *testing.T) {
+		for _, op := range []Operation{ProofOperation, UpdateOperation} {
+			_, err := doc.AuthorizedKey(recoveryRef, op)
+			assert.True(t, errors.Is(err, ErrRecoveryKeyNotPermitted))
+		}
+		for _, op := range []Operation{RotateOperation, DeactivateOperation} {
+			keyDef, err := doc.AuthorizedKey(recoveryRef, op)
+			require.NoError(t, err)
+			assert.Equal(t, recoveryRef, keyDef.ID)
+		}
+
+		other, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		signed := DIDDoc{UnsignedDIDDoc: other.UnsignedDIDDoc}
+		suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+		require.NoError(t, err)
+		require.NoError(t, suite.Sign(&signed, recoverySigner))
+
+		err = VerifyProofForOperation(&signed, doc.UnsignedDIDDoc, ProofOperation)
+		assert.True(t, errors.Is(err, ErrRecoveryKeyNotPermitted))
+		assert.NoError(t, VerifyProofForOperation(&signed, doc.UnsignedDIDDoc, RotateOperation))
+	})
+
+	t.Run("Not resolved as a verifier", func(t *testing.T) {
+		registry := NewMemoryRegistry()
+		require.NoError(t, registry.Put(*doc))
+		verifiers := AsVerifierResolver(registry)
+		_, err := verifiers.ResolveVerifier(context.Background(), recoveryRef)
+		assert.True(t, errors.Is(err, ErrRecoveryKeyNotPermitted))
+		_, err = verifiers.ResolveVerifier(context.Background(), doc.ID+"#key-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rotate with recovery key", func(t *testing.T) {
+		_, newKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		updated := doc.UnsignedDIDDoc
+		updated.PublicKey = append([]KeyDef(nil), doc.PublicKey...)
+		updated.PublicKey[0].PublicKeyBase58 = base58.Encode(newKey.Public().(ed25519.PublicKey))
+
+		rotated, err := UpdateDIDDoc(*doc, updated, recoverySigner, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		assert.Equal(t, recoveryRef, rotated.Proof.GetVerificationMethod())
+		assert.NoError(t, VerifyDIDDocProof(*rotated, doc.UnsignedDIDDoc))
+
+		registry := NewMemoryRegistry()
+		require.NoError(t, registry.Put(*doc))
+		assert.NoError(t, registry.Put(*rotated))
+	})
+
+	t.Run("Regular update with recovery key", func(t *testing.T) {
+		updated := doc.UnsignedDIDDoc
+		updated.AlsoKnownAs = []string{"did:web:example.com"}
+		assert.Equal(t, UpdateOperation, UpdateOperationFor(doc.UnsignedDIDDoc, updated))
+		_, err := UpdateDIDDoc(*doc, updated, recoverySigner, proof.JCSEdSignatureType)
+		assert.True(t, errors.Is(err, ErrRecoveryKeyNotPermitted))
+
+		// A registry rejects the update even if it is signed without UpdateDIDDoc.
+		signed := DIDDoc{UnsignedDIDDoc: updated}
+		require.NoError(t, signWithSuite(&signed, recoverySigner, proof.JCSEdSignatureType))
+		registry := NewMemoryRegistry()
+		require.NoError(t, registry.Put(*doc))
+		err = registry.Put(signed)
+		assert.True(t, errors.Is(err, ErrRecoveryKeyNotPermitted))
+		_, err = VerifyUpdateChain([]DIDDoc{*doc, signed})
+		assert.True(t, errors.Is(err, ErrRecoveryKeyNotPermitted))
+
+		// A new document cannot be signed by its recovery key.
+		selfSigned := DIDDoc{UnsignedDIDDoc: doc.UnsignedDIDDoc}
+		require.NoError(t, signWithSuite(&selfSigned, recoverySigner, proof.JCSEdSignatureType))
+		err = NewMemoryRegistry().Put(selfSigned)
+		assert.True(t, errors.Is(err, ErrRecoveryKeyNotPermitted))
+	})
+
+	t.Run("Update operation", func(t *testing.T) {
+		assert.Equal(t, UpdateOperation, UpdateOperationFor(doc.UnsignedDIDDoc, doc.UnsignedDIDDoc))
+
+		rotated := doc.Clone()
+		rotated.PublicKey[0].PublicKeyBase58 = base58.Encode(make([]byte, ed25519.PublicKeySize))
+		assert.Equal(t, RotateOperation, UpdateOperationFor(doc.UnsignedDIDDoc, rotated.UnsignedDIDDoc))
+
+		removed := doc.Clone()
+		removed.PublicKey = removed.PublicKey[:1]
+		removed.Recovery = nil
+		assert.Equal(t, RotateOperation, UpdateOperationFor(doc.UnsignedDIDDoc, removed.UnsignedDIDDoc))
+
+		noRecovery := doc.Clone()
+		noRecovery.Recovery = nil
+		assert.Equal(t, RotateOperation, UpdateOperationFor(doc.UnsignedDIDDoc, noRecovery.UnsignedDIDDoc))
+
+		// The same key in another encoding is not a rotation.
+		reencoded := doc.Clone()
+		publicKey, err := reencoded.PublicKey[0].GetDecodedPublicKey()
+		require.NoError(t, err)
+		reencoded.PublicKey[0].PublicKeyBase58 = ""
+		reencoded.PublicKey[0].PublicKeyHex = hex.EncodeToString(publicKey)
+		assert.Equal(t, UpdateOperation, UpdateOperationFor(doc.UnsignedDIDDoc, reencoded.UnsignedDIDDoc))
+
+		deactivated := DIDDoc{UnsignedDIDDoc: UnsignedDIDDoc{ID: doc.ID}}
+		assert.Equal(t, DeactivateOperation, UpdateOperationFor(doc.UnsignedDIDDoc, deactivated.UnsignedDIDDoc))
+	})
+
+	t.Run("Update rejects foreign signer or DID", func(t *testing.T) {
+		other, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		otherSigner, err := proof.NewEd25519Signer(otherKey, other.PublicKey[0].ID)
+		require.NoError(t, err)
+		_, err = UpdateDIDDoc(*doc, doc.UnsignedDIDDoc, otherSigner, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+		_, err = UpdateDIDDoc(*doc, other.UnsignedDIDDoc, recoverySigner, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Deactivate with recovery key", func(t *testing.T) {
+		deactivated, err := DeactivateDIDDoc(*doc, privateKeys[1])
+		require.NoError(t, err)
+		assert.Equal(t, recoveryRef, deactivated.Proof.GetVerificationMethod())
+		assert.NoError(t, VerifyDIDDocProof(*deactivated, doc.UnsignedDIDDoc))
+	})
+}
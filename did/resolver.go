@@ -0,0 +1,275 @@
+package did
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+var (
+	// ErrMethodNotSupported is returned when resolving a DID whose method has no registered Resolver.
+	ErrMethodNotSupported = errcode.New(errcode.ResolutionFailed, "DID method not supported")
+	// ErrNotFound is returned when resolving a DID that does not exist.
+	ErrNotFound = errcode.New(errcode.ResolutionFailed, "DID not found")
+	// ErrDeactivated is matched by DeactivatedError.
+	ErrDeactivated = errcode.New(errcode.Deactivated, "DID has been deactivated")
+	// ErrResolverRequired is returned by VerifierForKeyRef when the key cannot be recovered from
+	// the key reference alone.
+	ErrResolverRequired = errcode.New(errcode.ResolutionFailed, "key reference requires a resolver")
+)
+
+// DeactivatedError is returned when a deactivated DID is used where an active DID is required,
+// such as for verifying a proof. It carries the resolution result of the deactivated DID, and
+// matches ErrDeactivated (see errors.Is).
+type DeactivatedError struct {
+	Result *ResolutionResult
+}
+
+func (e DeactivatedError) Error() string {
+	return fmt.Sprintf("DID<%s> has been deactivated", e.Result.DIDDoc.ID)
+}
+
+func (e DeactivatedError) Is(target error) bool {
+	return target == ErrDeactivated
+}
+
+// ErrorCode returns errcode.Deactivated (see errcode.CodeOf).
+func (e DeactivatedError) ErrorCode() errcode.Code {
+	return errcode.Deactivated
+}
+
+// Resolver resolves a DID into its DID Document.
+type Resolver interface {
+	Resolve(ctx context.Context, did string) (*ResolutionResult, error)
+}
+
+// ResolutionResult is the outcome of resolving a DID. Resolvers return ErrNotFound if the DID does
+// not exist. Deactivated DIDs are resolved, and reported as such in the DocumentMetadata.
+type ResolutionResult struct {
+	DIDDoc           *DIDDoc
+	DocumentMetadata DocumentMetadata
+
+	// index is set by resolvers that reuse results, such as CachingResolver, to speed up key
+	// lookups and verification.
+	index *DocIndex
+}
+
+// clone returns a copy of the result whose DID Document can be modified without affecting the
+// original. The key index is shared, since it holds its own copy of the document.
+func (r *ResolutionResult) clone() *ResolutionResult {
+	if r == nil {
+		return nil
+	}
+	clone := *r
+	if r.DIDDoc != nil {
+		doc := r.DIDDoc.Clone()
+		clone.DIDDoc = &doc
+	}
+	return &clone
+}
+
+// DocumentMetadata describes the resolved DID Document, rather than the DID subject.
+type DocumentMetadata struct {
+	// Deactivated is true if the DID has been deactivated.
+	Deactivated bool
+	// Status is the status of the document (see UnsignedDIDDoc.Status).
+	Status DocStatus
+	// VersionID identifies the version of the document, if the DID method supports versioning.
+	VersionID string
+	// Created is the time at which the DID was created, if known.
+	Created time.Time
+	// Updated is the time at which the document was last updated, if known. For ledger and did:web
+	// documents this is the time their proof was created.
+	Updated time.Time
+	// Retrieved is the time at which the document was resolved.
+	Retrieved time.Time
+}
+
+// newDocumentMetadata returns the metadata of a document that was resolved from a source that
+// does not keep track of versions.
+func newDocumentMetadata(doc *DIDDoc) DocumentMetadata {
+	metadata := DocumentMetadata{
+		Deactivated: doc.IsDeactivated(),
+		Status:      doc.Status(),
+		Retrieved:   time.Now().UTC(),
+	}
+	if !doc.Proof.IsEmpty() {
+		if created, err := doc.Proof.CreatedTime(); err == nil {
+			metadata.Updated = created.UTC()
+		}
+	}
+	return metadata
+}
+
+// ResolverFunc adapts an ordinary function into a Resolver.
+type ResolverFunc func(ctx context.Context, did string) (*ResolutionResult, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	return f(ctx, did)
+}
+
+// KeyResolver resolves did:key DIDs by expanding the key in the DID. No network access is needed.
+type KeyResolver struct{}
+
+func (KeyResolver) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	doc, err := ResolveDIDKey(did)
+	if err != nil {
+		return nil, err
+	}
+	return &ResolutionResult{DIDDoc: doc, DocumentMetadata: DocumentMetadata{Status: StatusActive, Retrieved: time.Now().UTC()}}, nil
+}
+
+// DIDDocLookup fetches a DID Document, typically from the ledger. It should return a nil document
+// if the DID does not exist.
+type DIDDocLookup func(ctx context.Context, did string) (*DIDDoc, error)
+
+// NewWorkResolver returns a Resolver for did:work DIDs that is backed by the caller's ledger client.
+// DID Documents are reported as deactivated according to their status (see UnsignedDIDDoc.Status).
+// Documents that exceed DefaultLimits are rejected.
+func NewWorkResolver(lookup DIDDocLookup) Resolver {
+	return NewWorkResolverWithLimits(lookup, DefaultLimits)
+}
+
+// NewWorkResolverWithLimits is the same as NewWorkResolver, except that documents are checked
+// against the given limits (see DIDDoc.CheckLimits).
+func NewWorkResolverWithLimits(lookup DIDDocLookup, limits Limits) Resolver {
+	return ResolverFunc(func(ctx context.Context, did string) (*ResolutionResult, error) {
+		if !strings.HasPrefix(did, IssuerDIDMethod) {
+			return nil, errcode.Errorf(errcode.DIDMalformed, "DID<%s> format not supported", did)
+		}
+		doc, err := lookup(ctx, did)
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			return nil, errors.Wrapf(ErrNotFound, "DID<%s>", did)
+		}
+		if err := doc.CheckLimits(limits); err != nil {
+			return nil, err
+		}
+		return &ResolutionResult{DIDDoc: doc, DocumentMetadata: newDocumentMetadata(doc)}, nil
+	})
+}
+
+// MethodRegistry dispatches DID resolution to the Resolver registered for the DID's method.
+// It is safe for concurrent use.
+type MethodRegistry struct {
+	mu        sync.RWMutex
+	resolvers map[string]Resolver
+}
+
+// NewMethodRegistry returns a registry with the built-in did:key resolver registered. Resolvers
+// for did:work (see NewWorkResolver) and did:web (see NewWebResolver) must be registered by the
+// caller, as they require a ledger client and an HTTP client respectively.
+func NewMethodRegistry() *MethodRegistry {
+	r := &MethodRegistry{resolvers: make(map[string]Resolver)}
+	r.Register(didMethod(KeyDIDMethod), KeyResolver{})
+	return r
+}
+
+// Register sets the resolver for a DID method. The method is the name only, e.g. "web" for
+// did:web DIDs. A previously registered resolver for the method is replaced.
+func (r *MethodRegistry) Register(method string, resolver Resolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.resolvers == nil {
+		r.resolvers = make(map[string]Resolver)
+	}
+	r.resolvers[method] = resolver
+}
+
+// Resolve resolves the DID with the resolver registered for its method. ErrMethodNotSupported is
+// returned if no resolver has been registered.
+func (r *MethodRegistry) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	return r.ResolveWithOptions(ctx, did, ResolutionOptions{AcceptDeactivated: true})
+}
+
+// ResolveWithOptions resolves the DID with the resolver registered for its method (see
+// ResolveWithOptions).
+func (r *MethodRegistry) ResolveWithOptions(ctx context.Context, did string, opts ResolutionOptions) (*ResolutionResult, error) {
+	if err := ValidateDIDSyntax(did); err != nil {
+		return nil, err
+	}
+	method := didMethod(did)
+	r.mu.RLock()
+	resolver, ok := r.resolvers[method]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, errors.Wrap(ErrMethodNotSupported, method)
+	}
+	return ResolveWithOptions(ctx, resolver, did, opts)
+}
+
+// ResolveVerifier resolves the DID in the key reference and returns a Verifier for the referenced
+// key. This allows the registry to be used directly with proof.VerifyWithResolver. DID Key
+// references are served by VerifierForKeyRef without resolving the DID.
+func (r *MethodRegistry) ResolveVerifier(ctx context.Context, keyRef string) (proof.Verifier, error) {
+	return resolveVerifierWithFastPath(ctx, r, keyRef)
+}
+
+// AsVerifierResolver adapts any Resolver for use with proof.VerifyWithResolver. As with
+// MethodRegistry, DID Key references are served by VerifierForKeyRef without calling the resolver.
+func AsVerifierResolver(resolver Resolver) proof.VerifierResolver {
+	return verifierResolver{resolver}
+}
+
+type verifierResolver struct {
+	Resolver
+}
+
+func (v verifierResolver) ResolveVerifier(ctx context.Context, keyRef string) (proof.Verifier, error) {
+	return resolveVerifierWithFastPath(ctx, v.Resolver, keyRef)
+}
+
+// resolveVerifierWithFastPath builds the Verifier straight from the key reference when possible,
+// and otherwise falls back to resolving the DID.
+func resolveVerifierWithFastPath(ctx context.Context, resolver Resolver, keyRef string) (proof.Verifier, error) {
+	verifier, err := VerifierForKeyRef(keyRef)
+	if !errors.Is(err, ErrResolverRequired) {
+		return verifier, err
+	}
+	return resolveVerifier(ctx, resolver, keyRef)
+}
+
+// resolveVerifier resolves the current version of the DID, or the version that was current when
+// the proof was created if requested (see proof.AsOfProofCreated).
+func resolveVerifier(ctx context.Context, resolver Resolver, keyRef string) (proof.Verifier, error) {
+	opts := ResolutionOptions{AcceptDeactivated: true}
+	if asOf, ok := proof.ResolveAsOf(ctx); ok {
+		opts.VersionTime = asOf
+	}
+	result, err := ResolveWithOptions(ctx, resolver, ExtractDIDFromKeyRef(keyRef), opts)
+	if err != nil {
+		return nil, err
+	}
+	if result.DocumentMetadata.Deactivated && !proof.HistoricalAllowed(ctx) {
+		return nil, DeactivatedError{Result: result}
+	}
+	if result.index != nil {
+		return result.index.AuthorizedVerifier(keyRef, ProofOperation)
+	}
+	keyDef, err := result.DIDDoc.AuthorizedKey(keyRef, ProofOperation)
+	if err != nil {
+		return nil, err
+	}
+	return AsVerifier(*keyDef)
+}
+
+// didMethod returns the method name of a DID, e.g. "key" for "did:key:z6Mk...".
+func didMethod(did string) string {
+	parts := strings.SplitN(did, ":", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
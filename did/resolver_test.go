@@ -0,0 +1,156 @@
+package did
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestMethodRegistry(t *testing.T) {
+	workDoc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	deactivated, err := DeactivateDIDDoc(*workDoc, privateKey)
+	require.NoError(t, err)
+
+	ledgerDocs := map[string]*DIDDoc{workDoc.ID: workDoc}
+	registry := NewMethodRegistry()
+	registry.Register("work", NewWorkResolver(func(ctx context.Context, did string) (*DIDDoc, error) {
+		return ledgerDocs[did], nil
+	}))
+
+	t.Run("did:key is built in", func(t *testing.T) {
+		result, err := NewMethodRegistry().Resolve(context.Background(), didKeyVectors[0].did)
+		require.NoError(t, err)
+		assert.Equal(t, didKeyVectors[0].did, result.DIDDoc.ID)
+		assert.False(t, result.DocumentMetadata.Deactivated)
+	})
+
+	t.Run("did:work via ledger lookup", func(t *testing.T) {
+		result, err := registry.Resolve(context.Background(), workDoc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, workDoc, result.DIDDoc)
+		assert.False(t, result.DocumentMetadata.Deactivated)
+
+		_, err = registry.Resolve(context.Background(), "did:work:unknown")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Deactivated did:work", func(t *testing.T) {
+		ledgerDocs[workDoc.ID] = deactivated
+		defer func() { ledgerDocs[workDoc.ID] = workDoc }()
+
+		result, err := registry.Resolve(context.Background(), workDoc.ID)
+		require.NoError(t, err)
+		assert.True(t, result.DocumentMetadata.Deactivated)
+		assert.Equal(t, StatusDeactivated, result.DocumentMetadata.Status)
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		signed := workDoc.Clone()
+		signed.Proof.Created = "2020-06-01T12:00:00Z"
+		ledgerDocs[workDoc.ID] = &signed
+		defer func() { ledgerDocs[workDoc.ID] = workDoc }()
+
+		result, err := registry.Resolve(context.Background(), workDoc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusActive, result.DocumentMetadata.Status)
+		assert.Equal(t, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC), result.DocumentMetadata.Updated)
+		assert.True(t, result.DocumentMetadata.Created.IsZero())
+		assert.False(t, result.DocumentMetadata.Retrieved.IsZero())
+
+		result, err = registry.Resolve(context.Background(), didKeyVectors[0].did)
+		require.NoError(t, err)
+		assert.Equal(t, StatusActive, result.DocumentMetadata.Status)
+		assert.True(t, result.DocumentMetadata.Updated.IsZero())
+	})
+
+	t.Run("Unknown method", func(t *testing.T) {
+		_, err := registry.Resolve(context.Background(), "did:example:123")
+		assert.True(t, errors.Is(err, ErrMethodNotSupported))
+
+		_, err = registry.Resolve(context.Background(), "not-a-did")
+		assert.Error(t, err)
+	})
+
+	t.Run("Lookup errors are returned", func(t *testing.T) {
+		lookupErr := errors.New("ledger unavailable")
+		r := NewMethodRegistry()
+		r.Register("work", NewWorkResolver(func(ctx context.Context, did string) (*DIDDoc, error) {
+			return nil, lookupErr
+		}))
+		_, err := r.Resolve(context.Background(), workDoc.ID)
+		assert.Equal(t, lookupErr, err)
+	})
+}
+
+func TestVerifyWithResolver(t *testing.T) {
+	workDoc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	registry := NewMethodRegistry()
+	registry.Register("work", NewWorkResolver(func(ctx context.Context, did string) (*DIDDoc, error) {
+		if did == workDoc.ID {
+			return workDoc, nil
+		}
+		return nil, nil
+	}))
+
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+	sign := func(keyID string) *proof.GenericProvable {
+		signer, err := proof.NewEd25519Signer(privateKey, keyID)
+		require.NoError(t, err)
+		provable := &proof.GenericProvable{JSONData: `{"hello":"world"}`}
+		require.NoError(t, suite.Sign(provable, signer))
+		return provable
+	}
+
+	t.Run("Registry resolves did:work keys", func(t *testing.T) {
+		provable := sign(workDoc.PublicKey[0].ID)
+		assert.NoError(t, proof.VerifyWithResolver(context.Background(), provable, registry))
+	})
+
+	t.Run("Adapter resolves did:key keys", func(t *testing.T) {
+		didKey := GenerateDIDKey(privateKey.Public().(ed25519.PublicKey))
+		doc, err := ResolveDIDKey(didKey)
+		require.NoError(t, err)
+		provable := sign(doc.PublicKey[0].ID)
+		assert.NoError(t, proof.VerifyWithResolver(context.Background(), provable, AsVerifierResolver(KeyResolver{})))
+	})
+
+	t.Run("Unknown key", func(t *testing.T) {
+		provable := sign(GenerateKeyID(workDoc.ID, "key-2"))
+		assert.Error(t, proof.VerifyWithResolver(context.Background(), provable, registry))
+	})
+
+	t.Run("Unsupported method", func(t *testing.T) {
+		provable := sign("did:example:123#key-1")
+		assert.Error(t, proof.VerifyWithResolver(context.Background(), provable, registry))
+	})
+
+	t.Run("Missing proof", func(t *testing.T) {
+		provable := &proof.GenericProvable{JSONData: `{"hello":"world"}`}
+		assert.Error(t, proof.VerifyWithResolver(context.Background(), provable, registry))
+	})
+
+	t.Run("Deactivated DID", func(t *testing.T) {
+		// A resolver that reports the last active version of a deactivated DID.
+		historical := AsVerifierResolver(ResolverFunc(func(ctx context.Context, did string) (*ResolutionResult, error) {
+			return &ResolutionResult{DIDDoc: workDoc, DocumentMetadata: DocumentMetadata{Deactivated: true}}, nil
+		}))
+		provable := sign(workDoc.PublicKey[0].ID)
+
+		err := proof.VerifyWithResolver(context.Background(), provable, historical)
+		assert.True(t, errors.Is(err, ErrDeactivated))
+		assert.NoError(t, proof.VerifyWithResolver(context.Background(), provable, historical, proof.AllowHistorical()))
+
+		_, err = historical.ResolveVerifier(context.Background(), workDoc.PublicKey[0].ID)
+		var deactivatedErr DeactivatedError
+		require.True(t, errors.As(err, &deactivatedErr))
+		assert.Equal(t, workDoc, deactivatedErr.Result.DIDDoc)
+	})
+}
@@ -0,0 +1,68 @@
+package did
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// GenerateDIDFromSeed deterministically derives an Ed25519 key pair from the seed and returns the
+// corresponding did:work DID along with the private key. The seed must be exactly
+// ed25519.SeedSize (32) bytes; shorter seeds are rejected rather than padded, as padding would
+// silently reduce the entropy of the key.
+func GenerateDIDFromSeed(seed []byte) (did string, priv ed25519.PrivateKey, err error) {
+	if priv, err = util.PrivateKeyFromSeed(seed); err != nil {
+		return "", nil, err
+	}
+	return GenerateDID(priv.Public().(ed25519.PublicKey)), priv, nil
+}
+
+// GenerateDIDDocFromSeed deterministically generates a self-signed DID Document from the seed.
+// The proof's created timestamp is the given time, and its nonce is derived from the DID, so
+// the same seed and time always produce the same document. Intended for tests and provisioning
+// tooling; DID Documents for real identities should use random keys and nonces.
+func GenerateDIDDocFromSeed(seed []byte, created time.Time) (*DIDDoc, ed25519.PrivateKey, error) {
+	id, privateKey, err := GenerateDIDFromSeed(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	signingKeyRef, err := NewKeyID(id, InitialKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc := DIDDoc{
+		UnsignedDIDDoc: UnsignedDIDDoc{
+			ID: id,
+			PublicKey: []KeyDef{{
+				ID:              signingKeyRef,
+				Type:            proof.Ed25519KeyType,
+				Controller:      id,
+				PublicKeyBase58: base58.Encode(privateKey.Public().(ed25519.PublicKey)),
+			}},
+			Authentication: []string{signingKeyRef},
+		},
+	}
+
+	signer, err := proof.NewEd25519Signer(privateKey, signingKeyRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := uuid.NewSHA1(uuid.NameSpaceURL, []byte(id)).String()
+	err = proof.SignWithOptions(suite, &doc, signer,
+		proof.WithClock(func() time.Time { return created }),
+		proof.WithNonce(func() string { return nonce }))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &doc, privateKey, nil
+}
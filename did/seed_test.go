@@ -0,0 +1,72 @@
+package did
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+const seedDIDDocJSON = `{"id":"did:work:6sYe1y3zXhmyrBkgHgAgaq","publicKey":[{"id":"did:work:6sYe1y3zXhmyrBkgHgAgaq#key-1","type":"Ed25519VerificationKey2018","controller":"did:work:6sYe1y3zXhmyrBkgHgAgaq","publicKeyBase58":"4CcKDtU1JNGi8U4D8Rv9CHzfmF7xzaxEAPFA54eQjRHF"}],"authentication":["did:work:6sYe1y3zXhmyrBkgHgAgaq#key-1"],"service":null,"proof":{"created":"2020-01-01T00:00:00Z","verificationMethod":"did:work:6sYe1y3zXhmyrBkgHgAgaq#key-1","nonce":"0a1186be-a78d-5279-8a08-2a66a5bbe612","signatureValue":"478rvDuDXNhfsMaBejFTCN8Vpp9jzFWANsQifE1YsZAKSCZtYwupWSjgNrMAgU6Ep1aMcNF4vUJzWAF1qWoqTfCe","type":"JcsEd25519Signature2020"}}`
+
+func TestGenerateDIDFromSeed(t *testing.T) {
+	t.Run("Known seed", func(t *testing.T) {
+		did, privateKey, err := GenerateDIDFromSeed(keySeed)
+		require.NoError(t, err)
+		assert.Equal(t, "did:work:6sYe1y3zXhmyrBkgHgAgaq", did)
+		assert.Equal(t, issuerPrivKey, privateKey)
+	})
+
+	t.Run("Invalid seed length", func(t *testing.T) {
+		for _, seed := range [][]byte{nil, keySeed[:16], keySeed[:31], append(keySeed, 0)} {
+			_, _, err := GenerateDIDFromSeed(seed)
+			assert.Error(t, err, len(seed))
+		}
+	})
+}
+
+func TestGenerateDIDDocFromSeed(t *testing.T) {
+	created := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Known seed", func(t *testing.T) {
+		doc, privateKey, err := GenerateDIDDocFromSeed(keySeed, created)
+		require.NoError(t, err)
+		assert.Equal(t, issuerPrivKey, privateKey)
+
+		docBytes, err := json.Marshal(doc)
+		require.NoError(t, err)
+		assert.Equal(t, seedDIDDocJSON, string(docBytes))
+
+		assert.NoError(t, doc.Validate())
+		assert.NoError(t, VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc))
+	})
+
+	t.Run("Reproducible", func(t *testing.T) {
+		first, _, err := GenerateDIDDocFromSeed(keySeed, created)
+		require.NoError(t, err)
+		second, _, err := GenerateDIDDocFromSeed(keySeed, created)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+
+		later, _, err := GenerateDIDDocFromSeed(keySeed, created.Add(time.Hour))
+		require.NoError(t, err)
+		assert.NotEqual(t, first.Proof, later.Proof)
+	})
+
+	t.Run("Different seeds", func(t *testing.T) {
+		otherSeed := []byte("abcdefghijklmnopqrstuvwxyz123456")
+		doc, _, err := GenerateDIDDocFromSeed(otherSeed, created)
+		require.NoError(t, err)
+		assert.NotEqual(t, "did:work:6sYe1y3zXhmyrBkgHgAgaq", doc.ID)
+		assert.Equal(t, proof.JCSEdSignatureType, doc.Proof.Type)
+	})
+
+	t.Run("Invalid seed", func(t *testing.T) {
+		_, _, err := GenerateDIDDocFromSeed(keySeed[:31], created)
+		assert.Error(t, err)
+	})
+}
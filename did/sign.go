@@ -0,0 +1,92 @@
+package did
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// SignDIDDoc signs the DID Document with the signer, which must be one of the document's own
+// public keys. When the signer exposes its private key (i.e. Ed25519Signer and Secp256K1Signer),
+// the derived public key must match the published key, so that the proof is verifiable.
+// Refuses to sign a document that fails Validate.
+//
+// The V2 suite is used for the signature type, except for signature types that only have a V1
+// suite (e.g. EcdsaSecp256k1Signature2019).
+func SignDIDDoc(unsigned UnsignedDIDDoc, signer proof.Signer, sigType proof.SignatureType) (*DIDDoc, error) {
+	return signUnsignedDIDDoc(unsigned, signer, func(doc *DIDDoc) error {
+		return signWithSuite(doc, signer, sigType)
+	})
+}
+
+// SignDIDDocAgreed signs the DID Document like SignDIDDoc, with the signature suite and proof model
+// version agreed with a peer (see proof.Negotiate).
+func SignDIDDocAgreed(unsigned UnsignedDIDDoc, signer proof.Signer, agreed proof.Agreed) (*DIDDoc, error) {
+	return signUnsignedDIDDoc(unsigned, signer, func(doc *DIDDoc) error {
+		return agreed.Sign(doc, signer)
+	})
+}
+
+// signUnsignedDIDDoc checks that the document is valid and that the signer is one of its keys, and signs
+// it with the sign function.
+func signUnsignedDIDDoc(unsigned UnsignedDIDDoc, signer proof.Signer, sign func(doc *DIDDoc) error) (*DIDDoc, error) {
+	doc := DIDDoc{UnsignedDIDDoc: unsigned}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	keyDef := doc.GetPublicKey(signer.ID())
+	if keyDef == nil {
+		return nil, fmt.Errorf("signer key is not in the DID Document: %s", signer.ID())
+	}
+	if err := checkSignerPublicKey(*keyDef, signer); err != nil {
+		return nil, err
+	}
+
+	if err := sign(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// signWithSuite signs the document with the V2 suite for the signature type, falling back to
+// the V1 suite for signature types that only have one.
+func signWithSuite(doc *DIDDoc, signer proof.Signer, sigType proof.SignatureType) error {
+	suite, err := proof.SignatureSuites().GetSuite(sigType, proof.V2)
+	if err != nil {
+		var errV1 error
+		if suite, errV1 = proof.SignatureSuites().GetSuite(sigType, proof.V1); errV1 != nil {
+			return err
+		}
+	}
+	return suite.Sign(doc, signer)
+}
+
+// checkSignerPublicKey returns an error if the signer's public key is known and does not match
+// the key definition.
+func checkSignerPublicKey(keyDef KeyDef, signer proof.Signer) error {
+	var publicKey []byte
+	switch s := signer.(type) {
+	case *proof.Ed25519Signer:
+		publicKey = s.PrivateKey.Public().(ed25519.PublicKey)
+	case *proof.Secp256K1Signer:
+		publicKey = s.PrivateKey.PubKey().SerializeCompressed()
+	default:
+		return nil
+	}
+	normalized, err := keyDef.Normalize()
+	if err != nil {
+		return err
+	}
+	expected, err := base58.Decode(normalized.PublicKeyBase58)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, publicKey) {
+		return fmt.Errorf("signer private key does not match public key: %s", keyDef.ID)
+	}
+	return nil
+}
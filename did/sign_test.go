@@ -0,0 +1,103 @@
+package did
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestSignDIDDoc(t *testing.T) {
+	_, recoveryKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	unsigned := UnsignedDIDDoc{
+		ID: testWorkDID,
+		PublicKey: []KeyDef{
+			{ID: testWorkDID + "#key-1", Type: proof.Ed25519KeyType, Controller: testWorkDID, PublicKeyBase58: base58.Encode(issuerPubKey)},
+			{ID: testWorkDID + "#key-2", Type: proof.Ed25519KeyType, Controller: testWorkDID, PublicKeyBase58: base58.Encode(recoveryKey.Public().(ed25519.PublicKey))},
+		},
+	}
+
+	t.Run("Any key in the document", func(t *testing.T) {
+		for i, privateKey := range []ed25519.PrivateKey{issuerPrivKey, recoveryKey} {
+			for _, sigType := range []proof.SignatureType{proof.JCSEdSignatureType, proof.WorkEdSignatureType, proof.Ed25519SignatureType} {
+				signer, err := proof.NewEd25519Signer(privateKey, unsigned.PublicKey[i].ID)
+				require.NoError(t, err)
+				doc, err := SignDIDDoc(unsigned, signer, sigType)
+				require.NoError(t, err)
+				assert.Equal(t, sigType, doc.Proof.Type)
+				assert.Equal(t, proof.V2, doc.Proof.ModelVersion())
+				assert.Equal(t, unsigned.PublicKey[i].ID, doc.Proof.GetVerificationMethod())
+				assert.NoError(t, VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc))
+			}
+		}
+	})
+
+	t.Run("secp256k1 uses the V1 suite", func(t *testing.T) {
+		privateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		secp256k1Doc := unsigned
+		secp256k1Doc.PublicKey = []KeyDef{{
+			ID:              testWorkDID + "#key-1",
+			Type:            proof.EcdsaSecp256k1KeyType,
+			Controller:      testWorkDID,
+			PublicKeyBase58: base58.Encode(privateKey.PubKey().SerializeUncompressed()),
+		}}
+		signer, err := proof.NewSecp256k1Signer(privateKey.ToECDSA(), secp256k1Doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		doc, err := SignDIDDoc(secp256k1Doc, signer, proof.EcdsaSecp256k1SignatureType)
+		require.NoError(t, err)
+		assert.Equal(t, proof.V1, doc.Proof.ModelVersion())
+		assert.NoError(t, VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc))
+
+		otherKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		signer, err = proof.NewSecp256k1Signer(otherKey.ToECDSA(), secp256k1Doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		_, err = SignDIDDoc(secp256k1Doc, signer, proof.EcdsaSecp256k1SignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Signer key not in document", func(t *testing.T) {
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, testWorkDID+"#key-3")
+		require.NoError(t, err)
+		_, err = SignDIDDoc(unsigned, signer, proof.JCSEdSignatureType)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "signer key is not in the DID Document")
+	})
+
+	t.Run("Private key does not match published key", func(t *testing.T) {
+		signer, err := proof.NewEd25519Signer(recoveryKey, unsigned.PublicKey[0].ID)
+		require.NoError(t, err)
+		_, err = SignDIDDoc(unsigned, signer, proof.JCSEdSignatureType)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match public key")
+	})
+
+	t.Run("Invalid document", func(t *testing.T) {
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, unsigned.PublicKey[0].ID)
+		require.NoError(t, err)
+
+		duplicateKeys := unsigned
+		duplicateKeys.PublicKey = []KeyDef{unsigned.PublicKey[0], unsigned.PublicKey[0]}
+		_, err = SignDIDDoc(duplicateKeys, signer, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+
+		danglingAuthentication := unsigned
+		danglingAuthentication.Authentication = []string{testWorkDID + "#key-9"}
+		_, err = SignDIDDoc(danglingAuthentication, signer, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Unsupported signature type", func(t *testing.T) {
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, unsigned.PublicKey[0].ID)
+		require.NoError(t, err)
+		_, err = SignDIDDoc(unsigned, signer, "UnknownSignature2020")
+		assert.Error(t, err)
+	})
+}
@@ -5,10 +5,11 @@ import (
 	"golang.org/x/crypto/ed25519"
 
 	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
 )
 
 func GenerateDIDDocWithContext(keyType proof.KeyType, signatureType proof.SignatureType) (*DIDDoc, ed25519.PrivateKey) {
-	publicKey, privateKey, _ := ed25519.GenerateKey(nil)
+	publicKey, privateKey, _ := ed25519.GenerateKey(util.RandReader())
 	id := GenerateDID(publicKey)
 	signingKeyRef := GenerateKeyID(id, InitialKey)
 
@@ -34,7 +35,7 @@ func GenerateDIDDocWithContext(keyType proof.KeyType, signatureType proof.Signat
 }
 
 func GenerateDIDDoc(keyType proof.KeyType, signatureType proof.SignatureType) (*DIDDoc, ed25519.PrivateKey) {
-	publicKey, privateKey, _ := ed25519.GenerateKey(nil)
+	publicKey, privateKey, _ := ed25519.GenerateKey(util.RandReader())
 
 	id := GenerateDID(publicKey)
 	signingKeyRef := GenerateKeyID(id, InitialKey)
@@ -0,0 +1,143 @@
+package did
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// TrustedSet is a pinned set of DID Documents, such as the documents of trusted issuers, that is
+// used to verify proofs without resolving DIDs. It implements both Resolver and
+// proof.VerifierResolver, so proofs can be verified with proof.VerifyWithResolver(ctx, provable,
+// set). The keys of each document are indexed (see DocIndex). It is safe for concurrent use.
+type TrustedSet struct {
+	mu   sync.RWMutex
+	docs map[string]*ResolutionResult
+}
+
+// NewTrustedSet returns a set of the DID Documents. Each document must pass Validate and be
+// self-signed.
+func NewTrustedSet(docs ...DIDDoc) (*TrustedSet, error) {
+	s := &TrustedSet{docs: make(map[string]*ResolutionResult, len(docs))}
+	for _, doc := range docs {
+		if err := s.Add(doc); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add adds a DID Document to the set. The document must pass Validate and be self-signed. Use
+// Replace to replace a document that is already in the set.
+func (s *TrustedSet) Add(doc DIDDoc) error {
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	if err := VerifyDIDDocProof(doc, doc.UnsignedDIDDoc); err != nil {
+		return errors.Wrapf(err, "DID<%s> must be self-signed", doc.ID)
+	}
+	key, err := Normalize(doc.ID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.docs[key]; exists {
+		return errors.Errorf("DID<%s> is already in the trusted set", doc.ID)
+	}
+	s.docs[key] = newTrustedResult(doc)
+	return nil
+}
+
+// Replace replaces a DID Document in the set with a new version, e.g. after a key rotation. As on
+// the ledger, the new version must be signed by a key from the version in the set that may
+// authorize the change (see UpdateOperationFor). Keys that are not in the new version can no longer
+// be used to verify proofs.
+func (s *TrustedSet) Replace(doc DIDDoc) error {
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	key, err := Normalize(doc.ID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, exists := s.docs[key]
+	if !exists {
+		return errors.Wrapf(ErrNotFound, "DID<%s>", doc.ID)
+	}
+	op := UpdateOperationFor(current.DIDDoc.UnsignedDIDDoc, doc.UnsignedDIDDoc)
+	if err := VerifyDIDDocProofForOperation(doc, current.DIDDoc.UnsignedDIDDoc, op); err != nil {
+		return errors.Wrapf(err, "DID<%s> must be signed by a key from the trusted version", doc.ID)
+	}
+	s.docs[key] = newTrustedResult(doc)
+	return nil
+}
+
+// Remove removes the DID Document from the set, if it is in the set.
+func (s *TrustedSet) Remove(did string) {
+	key, err := Normalize(did)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.docs, key)
+}
+
+// DIDs returns the DIDs in the set, in order.
+func (s *TrustedSet) DIDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	dids := make([]string, 0, len(s.docs))
+	for _, result := range s.docs {
+		dids = append(dids, result.DIDDoc.ID)
+	}
+	sort.Strings(dids)
+	return dids
+}
+
+// Resolve returns the DID Document in the set, or ErrNotFound if the DID is not in the set.
+func (s *TrustedSet) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	key, err := Normalize(did)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	result, exists := s.docs[key]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, errors.Wrapf(ErrNotFound, "DID<%s> is not trusted", did)
+	}
+	return result, nil
+}
+
+// ResolveVerifier returns a Verifier for the referenced key, if the key is in one of the DID
+// Documents in the set. Unlike MethodRegistry, DID Key references are not verified directly: a
+// DID Key is only trusted if its document has been added to the set.
+func (s *TrustedSet) ResolveVerifier(ctx context.Context, keyRef string) (proof.Verifier, error) {
+	return resolveVerifier(ctx, s, keyRef)
+}
+
+func newTrustedResult(doc DIDDoc) *ResolutionResult {
+	clone := doc.Clone()
+	return &ResolutionResult{
+		DIDDoc: &clone,
+		DocumentMetadata: DocumentMetadata{
+			Status:    clone.Status(),
+			Retrieved: time.Now().UTC(),
+		},
+		index: NewDocIndex(clone),
+	}
+}
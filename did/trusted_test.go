@@ -0,0 +1,122 @@
+package did
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestTrustedSet(t *testing.T) {
+	ctx := context.Background()
+	issuerDoc, issuerKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	otherDoc, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	set, err := NewTrustedSet(*issuerDoc)
+	require.NoError(t, err)
+
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+	sign := func(key ed25519.PrivateKey, keyID string) *proof.GenericProvable {
+		signer, err := proof.NewEd25519Signer(key, keyID)
+		require.NoError(t, err)
+		provable := &proof.GenericProvable{JSONData: "claim"}
+		require.NoError(t, suite.Sign(provable, signer))
+		return provable
+	}
+
+	t.Run("Verifies proofs from trusted documents", func(t *testing.T) {
+		provable := sign(issuerKey, issuerDoc.PublicKey[0].ID)
+		assert.NoError(t, proof.VerifyWithResolver(ctx, provable, set))
+
+		untrusted := sign(otherKey, otherDoc.PublicKey[0].ID)
+		err := proof.VerifyWithResolver(ctx, untrusted, set)
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Added after construction", func(t *testing.T) {
+		set, err := NewTrustedSet(*issuerDoc)
+		require.NoError(t, err)
+		provable := sign(otherKey, otherDoc.PublicKey[0].ID)
+		assert.Error(t, proof.VerifyWithResolver(ctx, provable, set))
+
+		require.NoError(t, set.Add(*otherDoc))
+		assert.NoError(t, proof.VerifyWithResolver(ctx, provable, set))
+		assert.Equal(t, 2, len(set.DIDs()))
+		assert.Error(t, set.Add(*otherDoc))
+	})
+
+	t.Run("Rejects documents that are not self-signed", func(t *testing.T) {
+		forged := otherDoc.Clone()
+		signDIDDoc(t, &forged, issuerKey, forged.PublicKey[0].ID)
+		_, err := NewTrustedSet(*issuerDoc, forged)
+		assert.Error(t, err)
+
+		unsigned := otherDoc.Clone()
+		unsigned.Proof = nil
+		set, err := NewTrustedSet()
+		require.NoError(t, err)
+		assert.Error(t, set.Add(unsigned))
+		assert.Empty(t, set.DIDs())
+	})
+
+	t.Run("Replaced key", func(t *testing.T) {
+		set, err := NewTrustedSet(*issuerDoc)
+		require.NoError(t, err)
+		oldProvable := sign(issuerKey, issuerDoc.PublicKey[0].ID)
+
+		newPublicKey, newPrivateKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		rotated := issuerDoc.Clone()
+		rotated.PublicKey = []KeyDef{{
+			ID:              GenerateKeyID(issuerDoc.ID, "key-2"),
+			Type:            proof.Ed25519KeyType,
+			Controller:      issuerDoc.ID,
+			PublicKeyBase58: base58.Encode(newPublicKey),
+		}}
+
+		// The rotation must be authorized by the trusted version.
+		signDIDDoc(t, &rotated, newPrivateKey, rotated.PublicKey[0].ID)
+		assert.Error(t, set.Replace(rotated))
+		assert.True(t, errors.Is(set.Replace(*otherDoc), ErrNotFound))
+
+		signDIDDoc(t, &rotated, issuerKey, issuerDoc.PublicKey[0].ID)
+		require.NoError(t, set.Replace(rotated))
+
+		assert.Error(t, proof.VerifyWithResolver(ctx, oldProvable, set))
+		newProvable := sign(newPrivateKey, rotated.PublicKey[0].ID)
+		assert.NoError(t, proof.VerifyWithResolver(ctx, newProvable, set))
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		set, err := NewTrustedSet(*issuerDoc, *otherDoc)
+		require.NoError(t, err)
+		set.Remove(otherDoc.ID)
+		assert.Equal(t, []string{issuerDoc.ID}, set.DIDs())
+		_, err = set.Resolve(ctx, otherDoc.ID)
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Concurrent use", func(t *testing.T) {
+		set, err := NewTrustedSet(*issuerDoc)
+		require.NoError(t, err)
+		provable := sign(issuerKey, issuerDoc.PublicKey[0].ID)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, proof.VerifyWithResolver(ctx, provable, set))
+				_ = set.Add(*otherDoc)
+				_ = set.DIDs()
+			}()
+		}
+		wg.Wait()
+	})
+}
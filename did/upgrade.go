@@ -0,0 +1,119 @@
+package did
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// UpgradeProof re-signs a DID Document whose proof uses the legacy V1 format (i.e. "creator") with
+// the V2 suite of the same signature type, without changing the document's content. The existing
+// proof must verify, and the signer must be authorized to update the document (see
+// AuthorizedKey). The fingerprint of the replaced proof is recorded in the PreviousProof property,
+// which is covered by the new proof, so that the upgrade can be audited.
+func UpgradeProof(doc DIDDoc, signer proof.Signer) (*DIDDoc, error) {
+	if doc.Proof.IsEmpty() {
+		return nil, errors.New("did doc proof cannot be empty")
+	}
+	if doc.Proof.ModelVersion() != proof.V1 {
+		return nil, fmt.Errorf("DID<%s> proof is already V2", doc.ID)
+	}
+	suite, err := proof.SignatureSuites().GetSuite(doc.Proof.Type, proof.V2)
+	if err != nil {
+		return nil, errors.Wrapf(err, "DID<%s> cannot be upgraded", doc.ID)
+	}
+	if err := checkResign(doc, signer); err != nil {
+		return nil, err
+	}
+
+	previousProof, err := json.Marshal(doc.Proof)
+	if err != nil {
+		return nil, err
+	}
+	upgraded := DIDDoc{UnsignedDIDDoc: doc.Clone().UnsignedDIDDoc}
+	if upgraded.PreviousProof, err = fingerprint(previousProof); err != nil {
+		return nil, err
+	}
+	if err := suite.Sign(&upgraded, signer); err != nil {
+		return nil, err
+	}
+	return &upgraded, nil
+}
+
+// ResignDoc re-signs a DID Document with the V2 suite of another signature type, without changing
+// the document's content. The existing proof must verify, and the signer must be authorized to
+// update the document (see AuthorizedKey). proof.ErrIncorrectKeyType is returned if the suite
+// does not support the signer's key type.
+func ResignDoc(doc DIDDoc, signer proof.Signer, newType proof.SignatureType) (*DIDDoc, error) {
+	suite, err := proof.SignatureSuites().GetSuite(newType, proof.V2)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResign(doc, signer); err != nil {
+		return nil, err
+	}
+	resigned := DIDDoc{UnsignedDIDDoc: doc.Clone().UnsignedDIDDoc}
+	if err := suite.Sign(&resigned, signer); err != nil {
+		return nil, err
+	}
+	return &resigned, nil
+}
+
+// checkResign returns an error unless the document's self-signature verifies and the signer is
+// authorized to update the document.
+func checkResign(doc DIDDoc, signer proof.Signer) error {
+	if doc.Proof.IsEmpty() {
+		return errors.New("did doc proof cannot be empty")
+	}
+	if err := VerifyDIDDocProof(doc, doc.UnsignedDIDDoc); err != nil {
+		return errors.Wrapf(err, "DID<%s> existing proof does not verify", doc.ID)
+	}
+	keyDef, err := doc.AuthorizedKey(signer.ID(), UpdateOperation)
+	if err != nil {
+		return errors.Wrapf(err, "signer key is not in the DID Document: %s", signer.ID())
+	}
+	return checkSignerPublicKey(*keyDef, signer)
+}
+
+// UpgradeResult is the outcome of re-signing one DID Document with UpgradeProofs or ResignDocs.
+type UpgradeResult struct {
+	// ID is the DID of the document.
+	ID string
+	// Doc is the re-signed document, if re-signing succeeded.
+	Doc *DIDDoc
+	// Err is the reason the document was not re-signed.
+	Err error
+}
+
+// UpgradeProofs upgrades the proofs of the DID Documents with UpgradeProof, using the signer
+// returned by getSigner for each document. A failure to upgrade one document does not stop the
+// others from being upgraded. The results are in input order.
+func UpgradeProofs(docs []DIDDoc, getSigner func(doc DIDDoc) (proof.Signer, error)) []UpgradeResult {
+	return resignAll(docs, getSigner, UpgradeProof)
+}
+
+// ResignDocs re-signs the DID Documents with ResignDoc, using the signer returned by getSigner for
+// each document. A failure to re-sign one document does not stop the others from being re-signed.
+// The results are in input order.
+func ResignDocs(docs []DIDDoc, getSigner func(doc DIDDoc) (proof.Signer, error), newType proof.SignatureType) []UpgradeResult {
+	return resignAll(docs, getSigner, func(doc DIDDoc, signer proof.Signer) (*DIDDoc, error) {
+		return ResignDoc(doc, signer, newType)
+	})
+}
+
+func resignAll(docs []DIDDoc, getSigner func(doc DIDDoc) (proof.Signer, error), resign func(DIDDoc, proof.Signer) (*DIDDoc, error)) []UpgradeResult {
+	results := make([]UpgradeResult, len(docs))
+	for i, doc := range docs {
+		results[i].ID = doc.ID
+		signer, err := getSigner(doc)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Doc, results[i].Err = resign(doc, signer)
+	}
+	return results
+}
@@ -0,0 +1,168 @@
+package did
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+// generateV1DIDDoc generates a DID Document signed with the V1 suite for the signature type.
+func generateV1DIDDoc(t *testing.T, sigType proof.SignatureType) (*DIDDoc, ed25519.PrivateKey) {
+	doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, sigType)
+	signer, err := proof.NewEd25519Signer(privateKey, doc.PublicKey[0].ID)
+	require.NoError(t, err)
+	suite, err := proof.SignatureSuites().GetSuite(sigType, proof.V1)
+	require.NoError(t, err)
+	doc.Proof = nil
+	require.NoError(t, suite.Sign(doc, signer))
+	require.Equal(t, proof.V1, doc.Proof.ModelVersion())
+	return doc, privateKey
+}
+
+func TestUpgradeProof(t *testing.T) {
+	for _, sigType := range []proof.SignatureType{proof.Ed25519SignatureType, proof.WorkEdSignatureType} {
+		t.Run(string(sigType), func(t *testing.T) {
+			doc, privateKey := generateV1DIDDoc(t, sigType)
+			signer, err := proof.NewEd25519Signer(privateKey, doc.PublicKey[0].ID)
+			require.NoError(t, err)
+
+			upgraded, err := UpgradeProof(*doc, signer)
+			require.NoError(t, err)
+			assert.Equal(t, proof.V2, upgraded.Proof.ModelVersion())
+			assert.Equal(t, sigType, upgraded.Proof.Type)
+			assert.Equal(t, doc.PublicKey[0].ID, upgraded.Proof.VerificationMethod)
+			assert.NoError(t, VerifyDIDDocProof(*upgraded, upgraded.UnsignedDIDDoc))
+			assert.True(t, ContentEquals(*doc, *upgraded))
+
+			assert.NotEmpty(t, upgraded.PreviousProof)
+
+			// The record of the previous proof is covered by the new proof.
+			tampered := *upgraded
+			tampered.PreviousProof = "z" + tampered.PreviousProof[2:]
+			assert.Error(t, VerifyDIDDocProof(tampered, tampered.UnsignedDIDDoc))
+
+			_, err = UpgradeProof(*upgraded, signer)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("Existing proof must verify", func(t *testing.T) {
+		doc, privateKey := generateV1DIDDoc(t, proof.Ed25519SignatureType)
+		signer, err := proof.NewEd25519Signer(privateKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		doc.Service = []ServiceDef{{ID: doc.ID + "#service-1", Type: "Service", ServiceEndpoint: "https://example.com"}}
+		_, err = UpgradeProof(*doc, signer)
+		assert.Error(t, err)
+	})
+
+	t.Run("Signer must be a key in the document", func(t *testing.T) {
+		doc, _ := generateV1DIDDoc(t, proof.Ed25519SignatureType)
+		signer, err := proof.NewEd25519Signer(issuerPrivKey, doc.ID+"#key-2")
+		require.NoError(t, err)
+		_, err = UpgradeProof(*doc, signer)
+		assert.Error(t, err)
+
+		signer, err = proof.NewEd25519Signer(issuerPrivKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		_, err = UpgradeProof(*doc, signer)
+		assert.Error(t, err)
+	})
+}
+
+func TestUpgradeProofs(t *testing.T) {
+	first, firstKey := generateV1DIDDoc(t, proof.Ed25519SignatureType)
+	second, secondKey := generateV1DIDDoc(t, proof.WorkEdSignatureType)
+	unknown, _ := generateV1DIDDoc(t, proof.Ed25519SignatureType)
+	keys := map[string]ed25519.PrivateKey{first.ID: firstKey, second.ID: secondKey}
+
+	results := UpgradeProofs([]DIDDoc{*first, *unknown, *second}, func(doc DIDDoc) (proof.Signer, error) {
+		key, ok := keys[doc.ID]
+		if !ok {
+			return nil, errors.New("no key")
+		}
+		return proof.NewEd25519Signer(key, doc.PublicKey[0].ID)
+	})
+	require.Len(t, results, 3)
+	for i, doc := range []*DIDDoc{first, unknown, second} {
+		assert.Equal(t, doc.ID, results[i].ID)
+	}
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, VerifyDIDDocProof(*results[0].Doc, results[0].Doc.UnsignedDIDDoc))
+	assert.Error(t, results[1].Err)
+	assert.Nil(t, results[1].Doc)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, proof.V2, results[2].Doc.Proof.ModelVersion())
+}
+
+func TestResignDoc(t *testing.T) {
+	doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.WorkEdSignatureType)
+	signer, err := proof.NewEd25519Signer(privateKey, doc.PublicKey[0].ID)
+	require.NoError(t, err)
+
+	t.Run("WorkEd25519Signature2020 to JcsEd25519Signature2020", func(t *testing.T) {
+		resigned, err := ResignDoc(*doc, signer, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		assert.Equal(t, proof.JCSEdSignatureType, resigned.Proof.Type)
+		assert.Equal(t, proof.V2, resigned.Proof.ModelVersion())
+		assert.Equal(t, doc.UnsignedDIDDoc, resigned.UnsignedDIDDoc)
+		assert.NoError(t, VerifyDIDDocProof(*resigned, resigned.UnsignedDIDDoc))
+		assert.Equal(t, proof.WorkEdSignatureType, doc.Proof.Type)
+	})
+
+	t.Run("Existing proof must verify", func(t *testing.T) {
+		tampered := doc.Clone()
+		tampered.Service = []ServiceDef{{ID: doc.ID + "#service-1", Type: "Service", ServiceEndpoint: "https://example.com"}}
+		_, err := ResignDoc(tampered, signer, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Signer must be authorized", func(t *testing.T) {
+		other, err := proof.NewEd25519Signer(issuerPrivKey, doc.ID+"#key-2")
+		require.NoError(t, err)
+		_, err = ResignDoc(*doc, other, proof.JCSEdSignatureType)
+		assert.Error(t, err)
+	})
+
+	t.Run("Suite must support the signer's key type", func(t *testing.T) {
+		privateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		keyDef := KeyDef{
+			ID:              testWorkDID + "#key-1",
+			Type:            proof.EcdsaSecp256k1KeyType,
+			Controller:      testWorkDID,
+			PublicKeyBase58: base58.Encode(privateKey.PubKey().SerializeCompressed()),
+		}
+		secp256k1Signer, err := AsSigner(keyDef, privateKey.Serialize())
+		require.NoError(t, err)
+		secp256k1Doc, err := SignDIDDoc(UnsignedDIDDoc{ID: testWorkDID, PublicKey: []KeyDef{keyDef}}, secp256k1Signer, proof.EcdsaSecp256k1SignatureType)
+		require.NoError(t, err)
+
+		_, err = ResignDoc(*secp256k1Doc, secp256k1Signer, proof.JCSEdSignatureType)
+		assert.True(t, errors.Is(err, proof.ErrIncorrectKeyType))
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		other, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.WorkEdSignatureType)
+		keys := map[string]ed25519.PrivateKey{doc.ID: privateKey, other.ID: otherKey}
+		tampered := other.Clone()
+		tampered.Authentication = []string{other.PublicKey[0].ID}
+
+		results := ResignDocs([]DIDDoc{*doc, tampered, *other}, func(doc DIDDoc) (proof.Signer, error) {
+			return proof.NewEd25519Signer(keys[doc.ID], doc.PublicKey[0].ID)
+		}, proof.JCSEdSignatureType)
+		require.Len(t, results, 3)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, proof.JCSEdSignatureType, results[0].Doc.Proof.Type)
+		assert.Error(t, results[1].Err)
+		assert.Nil(t, results[1].Doc)
+		assert.NoError(t, results[2].Err)
+		assert.Equal(t, other.ID, results[2].ID)
+	})
+}
@@ -0,0 +1,283 @@
+package did
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// Limits bounds the size of DID Documents accepted by validation and resolution. A zero field
+// means that the corresponding property is not limited.
+type Limits struct {
+	// MaxKeys is the maximum number of public keys and key agreement keys, combined.
+	MaxKeys int
+	// MaxServices is the maximum number of service endpoints.
+	MaxServices int
+	// MaxSize is the maximum size of the JSON serialized document, in bytes.
+	MaxSize int
+}
+
+var (
+	// DefaultLimits are the limits applied by Validate and by the resolvers in this package.
+	DefaultLimits = Limits{MaxKeys: 100, MaxServices: 100, MaxSize: 256 << 10}
+
+	// Unlimited disables all limits.
+	Unlimited = Limits{}
+)
+
+// Validate statically checks that the DID Document is well formed. The document must have a valid
+// DID (see ValidateDID), every key must have a unique ID, a type and a decodable public key, and every authentication,
+// assertionMethod and recovery reference must point to one of the document's public keys. There
+// can be at most one recovery key, which cannot be key-1 or have another verification relationship. The keys of did:work
+// documents must belong to the document's DID. The proof, if any, is not verified.
+// A document with a deactivated status must not have any keys or services. The document must
+// also be within DefaultLimits; use ValidateWithLimits for other limits.
+func (d *DIDDoc) Validate() error {
+	return d.ValidateWithLimits(DefaultLimits)
+}
+
+// ValidateWithLimits is the same as Validate, except that the document must be within the given
+// limits rather than DefaultLimits.
+func (d *DIDDoc) ValidateWithLimits(limits Limits) error {
+	if d.IsEmpty() {
+		return errors.New("did doc empty or nil")
+	}
+	if err := ValidateDID(d.ID); err != nil {
+		return err
+	}
+	if err := d.CheckLimits(limits); err != nil {
+		return err
+	}
+	switch d.DIDStatus {
+	case "", StatusActive:
+	case StatusDeactivated:
+		if len(d.PublicKey) > 0 || len(d.Authentication) > 0 || len(d.KeyAgreement) > 0 || len(d.Service) > 0 || len(d.Recovery) > 0 {
+			return fmt.Errorf("DID<%s> is deactivated but has keys or services", d.ID)
+		}
+	default:
+		return fmt.Errorf("DID<%s> has unknown status: %s", d.ID, d.DIDStatus)
+	}
+	isWorkDID := strings.HasPrefix(d.ID, IssuerDIDMethod)
+
+	for _, keys := range [][]KeyDef{d.PublicKey, d.KeyAgreement} {
+		for _, key := range keys {
+			if err := key.validate(); err != nil {
+				return err
+			}
+			if isWorkDID && !strings.HasPrefix(key.ID, d.ID+"#") {
+				return fmt.Errorf("key ID<%s> does not belong to DID<%s>", key.ID, d.ID)
+			}
+		}
+	}
+
+	if err := d.validateAlsoKnownAs(); err != nil {
+		return err
+	}
+
+	for _, refs := range [][]string{d.Authentication, d.AssertionMethod, d.Recovery} {
+		for _, ref := range refs {
+			if d.GetPublicKey(ref) == nil {
+				return errcode.Errorf(errcode.KeyNotFound, "key reference not found in did doc: %s", ref)
+			}
+		}
+	}
+	if err := d.validateRecovery(); err != nil {
+		return err
+	}
+	return d.checkProofKeyRef()
+}
+
+// ErrForeignKeyReference is returned when the proof on a DID Document references a key of another
+// DID, rather than one of the document's own keys.
+var ErrForeignKeyReference = errcode.New(errcode.KeyNotFound, "proof key reference does not belong to the DID Document")
+
+// checkProofKeyRef returns ErrForeignKeyReference if the proof on the document references a key
+// of another DID. Documents without a proof are not checked.
+func (d *DIDDoc) checkProofKeyRef() error {
+	return d.checkProofKeyRefOwner(d.ID)
+}
+
+// checkProofKeyRefOwner returns ErrForeignKeyReference if the proof on the document references a
+// key of a DID other than the owner. Relative key references are relative to the document.
+func (d *DIDDoc) checkProofKeyRefOwner(owner string) error {
+	if d.Proof.IsEmpty() {
+		return nil
+	}
+	ref := d.Proof.GetVerificationMethod()
+	did, _, err := d.qualifyKeyRef(ref)
+	if err != nil {
+		return err
+	}
+	if did != owner && !Equal(did, owner) {
+		return errors.Wrapf(ErrForeignKeyReference, "key<%s> DID<%s>", ref, owner)
+	}
+	return nil
+}
+
+// validateRecovery checks that there is at most one recovery key, that it is not the initial key,
+// and that it has no other verification relationship.
+func (d *DIDDoc) validateRecovery() error {
+	if len(d.Recovery) == 0 {
+		return nil
+	}
+	if len(d.Recovery) > 1 {
+		return fmt.Errorf("DID<%s> cannot have more than one recovery key", d.ID)
+	}
+	ref := d.Recovery[0]
+	if _, fragment, err := d.qualifyKeyRef(ref); err != nil || fragment == InitialKey {
+		return fmt.Errorf("recovery key<%s> must differ from %s", ref, InitialKey)
+	}
+	for _, refs := range [][]string{d.Authentication, d.AssertionMethod} {
+		for _, other := range refs {
+			if d.qualifyID(other) == d.qualifyID(ref) {
+				return fmt.Errorf("recovery key<%s> cannot have other verification relationships", ref)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckLimits checks that the DID Document is within the given limits, and that its key IDs and
+// service IDs are unique. Relative IDs are resolved against the document's DID before comparison,
+// so "key-1", "#key-1" and "<DID>#key-1" are the same ID. Unlike Validate, the keys themselves
+// are not checked.
+func (d *DIDDoc) CheckLimits(limits Limits) error {
+	if d == nil {
+		return errors.New("did doc empty or nil")
+	}
+	numKeys := len(d.PublicKey) + len(d.KeyAgreement)
+	if limits.MaxKeys > 0 && numKeys > limits.MaxKeys {
+		return fmt.Errorf("DID<%s> has %d keys, exceeding the limit of %d", d.ID, numKeys, limits.MaxKeys)
+	}
+	if limits.MaxServices > 0 && len(d.Service) > limits.MaxServices {
+		return fmt.Errorf("DID<%s> has %d services, exceeding the limit of %d", d.ID, len(d.Service), limits.MaxServices)
+	}
+	if limits.MaxSize > 0 {
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		if len(b) > limits.MaxSize {
+			return fmt.Errorf("DID<%s> document exceeds %d bytes", d.ID, limits.MaxSize)
+		}
+	}
+
+	keyIDs := make(map[string]bool)
+	for _, keys := range [][]KeyDef{d.PublicKey, d.KeyAgreement} {
+		for _, key := range keys {
+			id := d.qualifyID(key.ID)
+			if keyIDs[id] {
+				return fmt.Errorf("duplicate key ID: %s", key.ID)
+			}
+			keyIDs[id] = true
+		}
+	}
+	serviceIDs := make(map[string]bool)
+	for _, service := range d.Service {
+		id := d.qualifyID(service.ID)
+		if serviceIDs[id] {
+			return fmt.Errorf("duplicate service ID: %s", service.ID)
+		}
+		serviceIDs[id] = true
+	}
+	return nil
+}
+
+// ParseDIDDoc is a strict alternative to json.Unmarshal for untrusted DID Documents. The size
+// limit is checked before the document is decoded, and the decoded document must pass
+// ValidateWithLimits.
+func ParseDIDDoc(data []byte, limits Limits) (*DIDDoc, error) {
+	if limits.MaxSize > 0 && len(data) > limits.MaxSize {
+		return nil, fmt.Errorf("DID Document exceeds %d bytes", limits.MaxSize)
+	}
+	var doc DIDDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errcode.Wrap(errcode.DIDMalformed, err, "invalid DID Document")
+	}
+	if err := doc.ValidateWithLimits(limits); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// qualifyID returns the fully qualified form of a key or service ID, normalizing the DID. IDs
+// that cannot be qualified are returned unchanged.
+func (d *DIDDoc) qualifyID(id string) string {
+	did, fragment, err := d.qualifyKeyRef(id)
+	if err != nil {
+		return id
+	}
+	if normalized, err := Normalize(did); err == nil {
+		did = normalized
+	}
+	return GenerateKeyID(did, fragment)
+}
+
+// ValidateDID checks the syntax of the DID, and then applies the validation rules of the DID's
+// method for the methods that this package supports: did:work and did:key.
+func ValidateDID(did string) error {
+	if err := ValidateDIDSyntax(did); err != nil {
+		return err
+	}
+	switch didMethod(did) {
+	case didMethod(IssuerDIDMethod):
+		return ValidateWorkDID(did)
+	case didMethod(KeyDIDMethod):
+		_, _, err := ExtractPublicKeyFromDIDKey(did)
+		return err
+	}
+	return nil
+}
+
+// ValidateDIDSyntax checks that the DID has the form "did:<method>:<method-specific-id>", where
+// the method name consists of lowercase letters and digits.
+func ValidateDIDSyntax(did string) error {
+	parts := strings.SplitN(did, ":", 3)
+	if len(parts) != 3 || parts[0] != "did" || parts[1] == "" || parts[2] == "" {
+		return errcode.Errorf(errcode.DIDMalformed, "invalid DID: %s", did)
+	}
+	for _, c := range parts[1] {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return errcode.Errorf(errcode.DIDMalformed, "invalid DID method: %s", did)
+		}
+	}
+	return nil
+}
+
+// ValidateWorkDID checks that the DID is a did:work DID whose identifier is the base58 encoding of
+// exactly 16 bytes, as generated by GenerateDID.
+func ValidateWorkDID(did string) error {
+	if !strings.HasPrefix(did, IssuerDIDMethod) {
+		return errcode.Errorf(errcode.DIDMalformed, "DID<%s> is not a did:work DID", did)
+	}
+	id := strings.TrimPrefix(did, IssuerDIDMethod)
+	decoded, err := base58.Decode(id)
+	if err != nil || id == "" {
+		return errcode.Errorf(errcode.DIDMalformed, "DID<%s> is not base58 encoded", did)
+	}
+	if len(decoded) != workDIDLength {
+		return errcode.Errorf(errcode.DIDMalformed, "DID<%s> must encode %d bytes, found %d", did, workDIDLength, len(decoded))
+	}
+	return nil
+}
+
+// workDIDLength is the number of public key bytes encoded in a did:work identifier.
+const workDIDLength = 16
+
+func (k *KeyDef) validate() error {
+	if k.ID == "" {
+		return errors.New("key ID cannot be empty")
+	}
+	if k.Type == "" {
+		return fmt.Errorf("key type cannot be empty: %s", k.ID)
+	}
+	if _, _, err := k.decodeAll(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package did
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestDIDDocValidate(t *testing.T) {
+	newDoc := func() *DIDDoc {
+		doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		doc.Authentication = []string{doc.PublicKey[0].ID}
+		return doc
+	}
+
+	t.Run("Valid doc", func(t *testing.T) {
+		assert.NoError(t, newDoc().Validate())
+	})
+
+	t.Run("Resolved did:key doc", func(t *testing.T) {
+		doc, err := ResolveDIDKey(didKeyVectors[0].did)
+		require.NoError(t, err)
+		assert.NoError(t, doc.Validate())
+	})
+
+	t.Run("Empty doc", func(t *testing.T) {
+		var doc *DIDDoc
+		assert.Error(t, doc.Validate())
+		assert.Error(t, (&DIDDoc{}).Validate())
+	})
+
+	t.Run("Invalid DID", func(t *testing.T) {
+		doc := newDoc()
+		doc.ID = "work:123"
+		assert.Error(t, doc.Validate())
+	})
+
+	t.Run("Duplicate key ID", func(t *testing.T) {
+		doc := newDoc()
+		doc.PublicKey = append(doc.PublicKey, doc.PublicKey[0])
+		assert.Error(t, doc.Validate())
+	})
+
+	t.Run("Missing key type", func(t *testing.T) {
+		doc := newDoc()
+		doc.PublicKey[0].Type = ""
+		assert.Error(t, doc.Validate())
+	})
+
+	t.Run("Undecodable public key", func(t *testing.T) {
+		doc := newDoc()
+		doc.PublicKey[0].PublicKeyBase58 = "0OIl"
+		assert.Error(t, doc.Validate())
+	})
+
+	t.Run("Key from another DID", func(t *testing.T) {
+		doc := newDoc()
+		other, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		doc.PublicKey = append(doc.PublicKey, other.PublicKey[0])
+		assert.Error(t, doc.Validate())
+	})
+
+	t.Run("Dangling authentication reference", func(t *testing.T) {
+		doc := newDoc()
+		doc.Authentication = []string{doc.ID + "#missing"}
+		assert.Error(t, doc.Validate())
+	})
+}
+
+func TestDIDDocLimits(t *testing.T) {
+	newDoc := func() *DIDDoc {
+		doc, err := ResolveDIDKey(didKeyVectors[0].did)
+		require.NoError(t, err)
+		doc.Service = []ServiceDef{{ID: doc.ID + "#svc-1", Type: "Example", ServiceEndpoint: "https://example.com"}}
+		return doc
+	}
+
+	t.Run("Relative duplicate key ID", func(t *testing.T) {
+		doc := newDoc()
+		key := doc.PublicKey[0]
+		key.ID = key.ID[len(doc.ID):]
+		doc.PublicKey = append(doc.PublicKey, key)
+		err := doc.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate key ID")
+	})
+
+	t.Run("Duplicate service ID", func(t *testing.T) {
+		doc := newDoc()
+		doc.Service = append(doc.Service, ServiceDef{ID: "svc-1", Type: "Other", ServiceEndpoint: "https://example.org"})
+		err := doc.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate service ID")
+		assert.Error(t, doc.ValidateWithLimits(Unlimited))
+	})
+
+	t.Run("Limits exceeded", func(t *testing.T) {
+		doc := newDoc()
+		assert.NoError(t, doc.ValidateWithLimits(Limits{MaxKeys: 2, MaxServices: 1}))
+		assert.Error(t, doc.ValidateWithLimits(Limits{MaxKeys: 1}))
+		assert.Error(t, doc.ValidateWithLimits(Limits{MaxServices: 0, MaxSize: 100}))
+
+		doc.Service = nil
+		for i := 0; i <= DefaultLimits.MaxServices; i++ {
+			doc.Service = append(doc.Service, ServiceDef{ID: fmt.Sprintf("#svc-%d", i), Type: "Example"})
+		}
+		assert.Error(t, doc.Validate())
+		assert.NoError(t, doc.ValidateWithLimits(Unlimited))
+	})
+
+	t.Run("Parse", func(t *testing.T) {
+		b, err := json.Marshal(newDoc())
+		require.NoError(t, err)
+		doc, err := ParseDIDDoc(b, DefaultLimits)
+		require.NoError(t, err)
+		assert.Equal(t, newDoc().ID, doc.ID)
+
+		_, err = ParseDIDDoc(b, Limits{MaxSize: len(b) - 1})
+		assert.Error(t, err)
+		_, err = ParseDIDDoc([]byte(`{"id": "did:work:123"}`), DefaultLimits)
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateWorkDID(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	valid := GenerateDID(publicKey)
+
+	tests := []struct {
+		name  string
+		did   string
+		valid bool
+	}{
+		{"generated", valid, true},
+		{"fixture", "did:work:NozwAq71nnDdNimgqmktei", true},
+		{"truncated", valid[:len(valid)-4], false},
+		{"32 byte id", IssuerDIDMethod + base58.Encode(publicKey), false},
+		{"empty id", IssuerDIDMethod, false},
+		{"invalid base58", "did:work:NozwAq71nnDdNimgqmkte0", false},
+		{"leading whitespace", " " + valid, false},
+		{"trailing whitespace", valid + " ", false},
+		{"uppercase method", "did:WORK:NozwAq71nnDdNimgqmktei", false},
+		{"mixed case method", "did:Work:NozwAq71nnDdNimgqmktei", false},
+		{"uppercase scheme", "DID:work:NozwAq71nnDdNimgqmktei", false},
+		{"other method", "did:example:NozwAq71nnDdNimgqmktei", false},
+		{"key reference", valid + "#" + InitialKey, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.valid {
+				assert.NoError(t, ValidateWorkDID(test.did))
+				assert.NoError(t, ValidateDID(test.did))
+			} else {
+				assert.Error(t, ValidateWorkDID(test.did))
+			}
+		})
+	}
+}
+
+func TestValidateDID(t *testing.T) {
+	assert.NoError(t, ValidateDID("did:work:NozwAq71nnDdNimgqmktei"))
+	assert.NoError(t, ValidateDID(didKeyVectors[0].did))
+	assert.NoError(t, ValidateDID("did:web:example.com"))
+
+	assert.Error(t, ValidateDID("did:work:NozwAq71nnDdNim"))
+	assert.Error(t, ValidateDID("did:key:z12345678"))
+	assert.Error(t, ValidateDID("did:Work:NozwAq71nnDdNimgqmktei"))
+	assert.Error(t, ValidateDID("work:NozwAq71nnDdNimgqmktei"))
+}
+
+func TestForeignKeyReference(t *testing.T) {
+	doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	otherDoc, otherKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+
+	t.Run("Self-signed", func(t *testing.T) {
+		assert.NoError(t, doc.Validate())
+		assert.NoError(t, VerifyDIDDocProof(*doc, doc.UnsignedDIDDoc))
+
+		relative := DIDDoc{UnsignedDIDDoc: doc.UnsignedDIDDoc}
+		signer, err := proof.NewEd25519Signer(privateKey, "#"+InitialKey)
+		require.NoError(t, err)
+		require.NoError(t, suite.Sign(&relative, signer))
+		assert.NoError(t, relative.Validate())
+		assert.NoError(t, VerifyDIDDocProof(relative, relative.UnsignedDIDDoc))
+	})
+
+	t.Run("Signed with a controller's key", func(t *testing.T) {
+		// Documents cannot list controllers yet, so only the signing document's own check applies:
+		// the proof verifies against the controller's document, but is not structurally valid.
+		signed := DIDDoc{UnsignedDIDDoc: doc.UnsignedDIDDoc}
+		signer, err := proof.NewEd25519Signer(otherKey, otherDoc.PublicKey[0].ID)
+		require.NoError(t, err)
+		require.NoError(t, SignAsAdmin(&signed, signer, *otherDoc))
+		assert.NoError(t, VerifyDIDDocProof(signed, otherDoc.UnsignedDIDDoc))
+		assert.True(t, errors.Is(signed.Validate(), ErrForeignKeyReference))
+	})
+
+	t.Run("Signed with an unrelated DID's key", func(t *testing.T) {
+		// The unrelated key has the same fragment as the document's own key.
+		signed := DIDDoc{UnsignedDIDDoc: doc.UnsignedDIDDoc}
+		signer, err := proof.NewEd25519Signer(otherKey, otherDoc.PublicKey[0].ID)
+		require.NoError(t, err)
+		require.NoError(t, suite.Sign(&signed, signer))
+
+		assert.True(t, errors.Is(signed.Validate(), ErrForeignKeyReference))
+		assert.True(t, errors.Is(VerifyDIDDocProof(signed, signed.UnsignedDIDDoc), ErrForeignKeyReference))
+		_, err = GetProofCreatorKeyDef(signed)
+		assert.True(t, errors.Is(err, ErrForeignKeyReference))
+
+		// The signature is valid for the unrelated key, so verification with an externally
+		// supplied verifier would pass.
+		verifier, err := AsVerifier(otherDoc.PublicKey[0])
+		require.NoError(t, err)
+		assert.NoError(t, suite.Verify(&signed, verifier))
+	})
+}
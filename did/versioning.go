@@ -0,0 +1,96 @@
+package did
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ErrVersionNotSupported is returned when a specific version of a DID Document is requested from
+// a resolver that only resolves the current version.
+var ErrVersionNotSupported = errcode.New(errcode.ResolutionFailed, "resolver does not support versioned resolution")
+
+// ResolutionOptions select the version of a DID Document to resolve, as the versionId and
+// versionTime DID URL parameters do (see ParseResolutionOptions).
+type ResolutionOptions struct {
+	// VersionID selects a version by its DocumentMetadata.VersionID.
+	VersionID string
+	// VersionTime selects the version that was current at the given time.
+	VersionTime time.Time
+	// AcceptDeactivated returns deactivated documents, as Resolve does. Otherwise a deactivated
+	// document fails with DeactivatedError.
+	AcceptDeactivated bool
+}
+
+func (o ResolutionOptions) versioned() bool {
+	return o.VersionID != "" || !o.VersionTime.IsZero()
+}
+
+// OptionsResolver is implemented by resolvers that can resolve past versions of DID Documents,
+// such as MemoryRegistry. Resolve must be the same as ResolveWithOptions with only
+// AcceptDeactivated set.
+type OptionsResolver interface {
+	Resolver
+	ResolveWithOptions(ctx context.Context, did string, opts ResolutionOptions) (*ResolutionResult, error)
+}
+
+// ResolveWithOptions resolves the DID with the given options. If the resolver is not an
+// OptionsResolver, only the current version can be resolved, and requesting any other version
+// fails with ErrVersionNotSupported.
+func ResolveWithOptions(ctx context.Context, resolver Resolver, did string, opts ResolutionOptions) (*ResolutionResult, error) {
+	if opts.VersionID != "" && !opts.VersionTime.IsZero() {
+		return nil, fmt.Errorf("DID<%s> cannot be resolved by both version ID and version time", did)
+	}
+	var result *ResolutionResult
+	var err error
+	if r, ok := resolver.(OptionsResolver); ok {
+		result, err = r.ResolveWithOptions(ctx, did, opts)
+	} else if opts.versioned() {
+		return nil, errors.Wrapf(ErrVersionNotSupported, "DID<%s>", did)
+	} else {
+		result, err = resolver.Resolve(ctx, did)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.DocumentMetadata.Deactivated && !opts.AcceptDeactivated {
+		return nil, DeactivatedError{Result: result}
+	}
+	return result, nil
+}
+
+// ParseResolutionOptions splits a DID URL into its DID and the resolution options given by its
+// versionId and versionTime query parameters, e.g.
+// "did:work:abc?versionTime=2020-01-01T00:00:00Z". The path and fragment are discarded, and other
+// query parameters are ignored. The version time must be in RFC 3339 format.
+func ParseResolutionOptions(didURL string) (did string, opts ResolutionOptions, err error) {
+	did = didURL
+	if i := strings.Index(did, "#"); i >= 0 {
+		did = did[:i]
+	}
+	var query string
+	if i := strings.Index(did, "?"); i >= 0 {
+		did, query = did[:i], did[i+1:]
+	}
+	if i := strings.Index(did, "/"); i >= 0 {
+		did = did[:i]
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return "", ResolutionOptions{}, fmt.Errorf("invalid DID URL<%s>: %v", didURL, err)
+	}
+	opts.VersionID = params.Get("versionId")
+	if versionTime := params.Get("versionTime"); versionTime != "" {
+		if opts.VersionTime, err = util.ParseRFC3339Lenient(versionTime); err != nil {
+			return "", ResolutionOptions{}, fmt.Errorf("invalid versionTime in DID URL<%s>: %v", didURL, err)
+		}
+	}
+	return did, opts, nil
+}
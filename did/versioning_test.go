@@ -0,0 +1,190 @@
+package did
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestParseResolutionOptions(t *testing.T) {
+	did, opts, err := ParseResolutionOptions(testWorkDID + "?versionId=2&foo=bar#key-1")
+	require.NoError(t, err)
+	assert.Equal(t, testWorkDID, did)
+	assert.Equal(t, ResolutionOptions{VersionID: "2"}, opts)
+
+	did, opts, err = ParseResolutionOptions(testWorkDID + "/path?versionTime=2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, testWorkDID, did)
+	assert.True(t, opts.VersionTime.Equal(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)))
+
+	did, opts, err = ParseResolutionOptions(testWorkDID)
+	require.NoError(t, err)
+	assert.Equal(t, testWorkDID, did)
+	assert.Equal(t, ResolutionOptions{}, opts)
+
+	_, _, err = ParseResolutionOptions(testWorkDID + "?versionTime=yesterday")
+	assert.Error(t, err)
+}
+
+// signDIDDocAt is the same as signDIDDoc, except that the proof is created at the given time.
+func signDIDDocAt(t *testing.T, doc *DIDDoc, key ed25519.PrivateKey, keyID string, created time.Time) {
+	signer, err := proof.NewEd25519Signer(key, keyID)
+	require.NoError(t, err)
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+	doc.Proof = nil
+	require.NoError(t, proof.SignWithOptions(suite, doc, signer, proof.WithClock(func() time.Time { return created })))
+}
+
+func TestVersionedResolution(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	registry := NewMemoryRegistry()
+	// Versions are stored some time after their proofs are created, which does not change the
+	// time from which they are current.
+	registry.now = func() time.Time { return start.Add(24 * time.Hour) }
+
+	// Version 1 is created at the start, the key is rotated an hour later, and the DID is
+	// deactivated an hour after that.
+	doc, privateKey := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	signDIDDocAt(t, doc, privateKey, doc.PublicKey[0].ID, start)
+	require.NoError(t, registry.Put(*doc))
+
+	// A proof created by the first key, half an hour in.
+	signer, err := proof.NewEd25519Signer(privateKey, doc.PublicKey[0].ID)
+	require.NoError(t, err)
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+	provable := &proof.GenericProvable{JSONData: `{"hello":"world"}`}
+	require.NoError(t, proof.SignWithOptions(suite, provable, signer,
+		proof.WithClock(func() time.Time { return start.Add(30 * time.Minute) })))
+
+	newPublicKey, newPrivateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	rotated := doc.Clone()
+	rotated.PublicKey = []KeyDef{{
+		ID:              GenerateKeyID(doc.ID, "key-2"),
+		Type:            proof.Ed25519KeyType,
+		Controller:      doc.ID,
+		PublicKeyBase58: base58.Encode(newPublicKey),
+	}}
+	signDIDDocAt(t, &rotated, privateKey, doc.PublicKey[0].ID, start.Add(time.Hour))
+	require.NoError(t, registry.Put(rotated))
+
+	// A proof created by the new key, a second after the rotation.
+	newSigner, err := proof.NewEd25519Signer(newPrivateKey, rotated.PublicKey[0].ID)
+	require.NoError(t, err)
+	rotatedProvable := &proof.GenericProvable{JSONData: `{"hello":"again"}`}
+	require.NoError(t, proof.SignWithOptions(suite, rotatedProvable, newSigner,
+		proof.WithClock(func() time.Time { return start.Add(time.Hour + time.Second) })))
+
+	t.Run("Version ID", func(t *testing.T) {
+		result, err := registry.ResolveWithOptions(ctx, doc.ID, ResolutionOptions{VersionID: "1"})
+		require.NoError(t, err)
+		assert.Equal(t, doc, result.DIDDoc)
+		assert.Equal(t, "1", result.DocumentMetadata.VersionID)
+		assert.Equal(t, start, result.DocumentMetadata.Updated)
+
+		result, err = registry.ResolveWithOptions(ctx, doc.ID, ResolutionOptions{VersionID: "2"})
+		require.NoError(t, err)
+		assert.Equal(t, &rotated, result.DIDDoc)
+		assert.Equal(t, start, result.DocumentMetadata.Created)
+
+		for _, versionID := range []string{"0", "3", "latest"} {
+			_, err = registry.ResolveWithOptions(ctx, doc.ID, ResolutionOptions{VersionID: versionID})
+			assert.True(t, errors.Is(err, ErrNotFound), versionID)
+		}
+	})
+
+	t.Run("Version time", func(t *testing.T) {
+		result, err := registry.ResolveWithOptions(ctx, doc.ID, ResolutionOptions{VersionTime: start.Add(59 * time.Minute)})
+		require.NoError(t, err)
+		assert.Equal(t, "1", result.DocumentMetadata.VersionID)
+
+		result, err = registry.ResolveWithOptions(ctx, doc.ID, ResolutionOptions{VersionTime: start.Add(time.Hour)})
+		require.NoError(t, err)
+		assert.Equal(t, "2", result.DocumentMetadata.VersionID)
+		assert.Equal(t, start.Add(time.Hour), result.DocumentMetadata.Updated)
+
+		_, err = registry.ResolveWithOptions(ctx, doc.ID, ResolutionOptions{VersionTime: start.Add(-time.Second)})
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Verify as of proof creation", func(t *testing.T) {
+		// The signing key has been rotated out of the current version.
+		assert.Error(t, proof.VerifyWithResolver(ctx, provable, AsVerifierResolver(registry)))
+		assert.NoError(t, proof.VerifyWithResolver(ctx, provable, AsVerifierResolver(registry), proof.AsOfProofCreated()))
+
+		// The new key was current when its proof was created, though the version was stored later.
+		assert.NoError(t, proof.VerifyWithResolver(ctx, rotatedProvable, AsVerifierResolver(registry), proof.AsOfProofCreated()))
+
+		cache := NewCachingResolver(registry, CacheOptions{})
+		assert.NoError(t, proof.VerifyWithResolver(ctx, provable, AsVerifierResolver(cache), proof.AsOfProofCreated()))
+
+		// Resolvers that only know the current version cannot verify as of a past time.
+		current := ResolverFunc(registry.Resolve)
+		err := proof.VerifyWithResolver(ctx, provable, AsVerifierResolver(current), proof.AsOfProofCreated())
+		assert.True(t, errors.Is(err, ErrVersionNotSupported))
+	})
+
+	t.Run("Caching resolver caches each version", func(t *testing.T) {
+		cache := NewCachingResolver(registry, CacheOptions{})
+		for i := 0; i < 2; i++ {
+			v1, err := cache.ResolveWithOptions(ctx, doc.ID, ResolutionOptions{VersionID: "1"})
+			require.NoError(t, err)
+			assert.Equal(t, doc, v1.DIDDoc)
+			current, err := cache.Resolve(ctx, doc.ID)
+			require.NoError(t, err)
+			assert.Equal(t, &rotated, current.DIDDoc)
+		}
+		assert.Equal(t, uint64(2), cache.Misses())
+		assert.Equal(t, uint64(2), cache.Hits())
+		assert.Equal(t, 2, cache.Len())
+
+		cache.Invalidate(doc.ID)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("Versions are in creation order", func(t *testing.T) {
+		backdated := rotated.Clone()
+		signDIDDocAt(t, &backdated, newPrivateKey, rotated.PublicKey[0].ID, start.Add(30*time.Minute))
+		assert.Error(t, registry.Put(backdated))
+	})
+
+	t.Run("Deactivated versions", func(t *testing.T) {
+		deactivated, err := DeactivateDIDDocGeneric(newSigner, proof.JCSEdSignatureType, doc.ID,
+			proof.WithClock(func() time.Time { return start.Add(2 * time.Hour) }))
+		require.NoError(t, err)
+		require.NoError(t, registry.Deactivate(*deactivated))
+
+		result, err := registry.Resolve(ctx, doc.ID)
+		require.NoError(t, err)
+		assert.True(t, result.DocumentMetadata.Deactivated)
+
+		_, err = ResolveWithOptions(ctx, registry, doc.ID, ResolutionOptions{})
+		var deactivatedErr DeactivatedError
+		require.True(t, errors.As(err, &deactivatedErr))
+		assert.Equal(t, "3", deactivatedErr.Result.DocumentMetadata.VersionID)
+
+		// Earlier versions are still active.
+		result, err = ResolveWithOptions(ctx, registry, doc.ID, ResolutionOptions{VersionID: "2"})
+		require.NoError(t, err)
+		assert.False(t, result.DocumentMetadata.Deactivated)
+		assert.NoError(t, proof.VerifyWithResolver(ctx, provable, AsVerifierResolver(registry), proof.AsOfProofCreated()))
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		_, err := ResolveWithOptions(ctx, registry, doc.ID, ResolutionOptions{VersionID: "1", VersionTime: start})
+		assert.Error(t, err)
+		_, err = ResolveWithOptions(ctx, KeyResolver{}, doc.ID, ResolutionOptions{VersionID: "1"})
+		assert.True(t, errors.Is(err, ErrVersionNotSupported))
+	})
+}
@@ -0,0 +1,174 @@
+package did
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+const (
+	WebDIDMethod = "did:web:"
+
+	// DefaultWebResponseLimit is the maximum size of a did:web DID Document, in bytes.
+	DefaultWebResponseLimit int64 = 1 << 20
+
+	wellKnownDIDPath = "/.well-known"
+	didDocumentFile  = "/did.json"
+)
+
+// WebResolver resolves did:web DIDs by fetching the DID Document from the domain named in the DID.
+// See https://w3c-ccg.github.io/did-method-web
+type WebResolver struct {
+	// Client is used to fetch DID Documents. Callers should configure a timeout. If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+	// MaxResponseSize limits the size of fetched DID Documents, in bytes. If zero or negative,
+	// DefaultWebResponseLimit is used.
+	MaxResponseSize int64
+	// Limits is applied to fetched DID Documents. If nil, DefaultLimits is used.
+	Limits *Limits
+}
+
+// NewWebResolver returns a WebResolver that fetches DID Documents using the given client.
+// If the client is nil, http.DefaultClient is used.
+func NewWebResolver(client *http.Client) *WebResolver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebResolver{Client: client, MaxResponseSize: DefaultWebResponseLimit}
+}
+
+// Resolve fetches and validates the DID Document for a did:web DID. The fetched document must
+// have the requested DID as its ID.
+func (r *WebResolver) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	docURL, err := WebDIDToURL(did)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/did+json, application/json")
+
+	// The client may follow redirects, which must not downgrade the connection: a redirect to a
+	// plain HTTP URL is refused before it is followed.
+	base := r.Client
+	if base == nil {
+		base = http.DefaultClient
+	}
+	client := *base
+	client.CheckRedirect = httpsRedirectsOnly(base.CheckRedirect)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errcode.Wrapf(errcode.ResolutionFailed, err, "unable to fetch DID Document for DID<%s>", did)
+	}
+	defer resp.Body.Close()
+
+	// Backstop for clients whose transport bypasses CheckRedirect.
+	if resp.Request != nil && resp.Request.URL.Scheme != "https" {
+		return nil, errcode.Errorf(errcode.ResolutionFailed, "DID Document for DID<%s> must be fetched over HTTPS", did)
+	}
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, errors.Wrapf(ErrNotFound, "DID<%s>", did)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errcode.Errorf(errcode.ResolutionFailed, "unable to fetch DID Document for DID<%s>: status %d", did, resp.StatusCode)
+	}
+
+	limit := r.MaxResponseSize
+	if limit <= 0 {
+		limit = DefaultWebResponseLimit
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, errcode.Wrapf(errcode.ResolutionFailed, err, "unable to read DID Document for DID<%s>", did)
+	}
+	if int64(len(body)) > limit {
+		return nil, errcode.Errorf(errcode.ResolutionFailed, "DID Document for DID<%s> exceeds %d bytes", did, limit)
+	}
+
+	limits := DefaultLimits
+	if r.Limits != nil {
+		limits = *r.Limits
+	}
+	var doc DIDDoc
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, errcode.Wrapf(errcode.DIDMalformed, err, "invalid DID Document for DID<%s>", did)
+	}
+	if doc.ID != did {
+		return nil, errcode.Errorf(errcode.ResolutionFailed, "DID Document ID<%s> does not match DID<%s>", doc.ID, did)
+	}
+	if err := doc.ValidateWithLimits(limits); err != nil {
+		return nil, err
+	}
+	return &ResolutionResult{DIDDoc: &doc, DocumentMetadata: newDocumentMetadata(&doc)}, nil
+}
+
+// maxWebRedirects is the number of redirects that are followed when the client does not have a
+// redirect policy, as for http.Client.
+const maxWebRedirects = 10
+
+// httpsRedirectsOnly wraps the redirect policy of a client so that redirects to anything but HTTPS
+// are refused. A nil policy follows up to maxWebRedirects redirects.
+func httpsRedirectsOnly(next func(req *http.Request, via []*http.Request) error) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" {
+			return errors.Errorf("redirect to URL<%s> is not HTTPS", req.URL.Redacted())
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= maxWebRedirects {
+			return errors.Errorf("stopped after %d redirects", maxWebRedirects)
+		}
+		return nil
+	}
+}
+
+// WebDIDToURL translates a did:web DID into the HTTPS URL of its DID Document.
+//
+//	did:web:example.com              -> https://example.com/.well-known/did.json
+//	did:web:example.com%3A8443       -> https://example.com:8443/.well-known/did.json
+//	did:web:example.com:user:alice   -> https://example.com/user/alice/did.json
+func WebDIDToURL(did string) (string, error) {
+	if !strings.HasPrefix(did, WebDIDMethod) {
+		return "", errcode.Errorf(errcode.DIDMalformed, "DID<%s> format not supported", did)
+	}
+	segments := strings.Split(strings.TrimPrefix(did, WebDIDMethod), ":")
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil || decoded == "" || strings.ContainsAny(decoded, "/?#@\\") {
+			return "", errcode.Errorf(errcode.DIDMalformed, "DID<%s> format not supported", did)
+		}
+		segments[i] = decoded
+	}
+
+	host := segments[0]
+	if _, err := url.Parse("https://" + host); err != nil {
+		return "", errcode.Errorf(errcode.DIDMalformed, "DID<%s> format not supported", did)
+	}
+
+	path := wellKnownDIDPath
+	if len(segments) > 1 {
+		escaped := make([]string, len(segments)-1)
+		for i, segment := range segments[1:] {
+			if segment == "." || segment == ".." {
+				return "", errcode.Errorf(errcode.DIDMalformed, "DID<%s> format not supported", did)
+			}
+			escaped[i] = url.PathEscape(segment)
+		}
+		path = "/" + strings.Join(escaped, "/")
+	}
+	return "https://" + host + path + didDocumentFile, nil
+}
@@ -0,0 +1,231 @@
+package did
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestWebDIDToURL(t *testing.T) {
+	for did, expected := range map[string]string{
+		"did:web:w3c-ccg.github.io":                 "https://w3c-ccg.github.io/.well-known/did.json",
+		"did:web:w3c-ccg.github.io:user:alice":      "https://w3c-ccg.github.io/user/alice/did.json",
+		"did:web:example.com%3A3000":                "https://example.com:3000/.well-known/did.json",
+		"did:web:example.com%3A3000:a%20b:did-docs": "https://example.com:3000/a%20b/did-docs/did.json",
+	} {
+		actual, err := WebDIDToURL(did)
+		assert.NoError(t, err, did)
+		assert.Equal(t, expected, actual, did)
+	}
+
+	for _, bad := range []string{
+		"did:work:6sYe1y3zXhmyrBkgHgAgaq",
+		"did:web:",
+		"did:web:example.com::alice",
+		"did:web:example.com%2Fpath",
+		"did:web:attacker.com%40example.com",
+		"did:web:example.com:..:admin",
+		"did:web:example.com%zz",
+	} {
+		_, err := WebDIDToURL(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestWebResolver(t *testing.T) {
+	docs := make(map[string][]byte)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	baseDID := WebDIDMethod + strings.Replace(serverURL.Host, ":", "%3A", 1)
+
+	publish := func(path string, doc interface{}) {
+		body, err := json.Marshal(doc)
+		require.NoError(t, err)
+		docs[path] = body
+	}
+	newWebDoc := func(id string) *DIDDoc {
+		doc, _ := GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		doc.ID = id
+		doc.PublicKey[0].ID = GenerateKeyID(id, InitialKey)
+		doc.PublicKey[0].Controller = id
+		doc.Authentication = []string{doc.PublicKey[0].ID}
+		doc.Proof = nil
+		return doc
+	}
+
+	resolver := NewWebResolver(server.Client())
+
+	t.Run("Well-known DID", func(t *testing.T) {
+		doc := newWebDoc(baseDID)
+		publish("/.well-known/did.json", doc)
+
+		result, err := resolver.Resolve(context.Background(), baseDID)
+		require.NoError(t, err)
+		assert.Equal(t, doc, result.DIDDoc)
+		assert.False(t, result.DocumentMetadata.Deactivated)
+		assert.False(t, result.DocumentMetadata.Retrieved.IsZero())
+	})
+
+	t.Run("Zero value resolver", func(t *testing.T) {
+		// The zero value uses http.DefaultClient, which must trust the test server.
+		defaultClient := http.DefaultClient
+		http.DefaultClient = server.Client()
+		defer func() { http.DefaultClient = defaultClient }()
+
+		doc := newWebDoc(baseDID)
+		publish("/.well-known/did.json", doc)
+		result, err := (&WebResolver{}).Resolve(context.Background(), baseDID)
+		require.NoError(t, err)
+		assert.Equal(t, doc, result.DIDDoc)
+
+		// DefaultWebResponseLimit applies.
+		did := baseDID + ":users:large"
+		large := newWebDoc(did)
+		large.SchemaContext = strings.Repeat("a", int(DefaultWebResponseLimit))
+		publish("/users/large/did.json", large)
+		_, err = (&WebResolver{}).Resolve(context.Background(), did)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds")
+	})
+
+	t.Run("Path DID", func(t *testing.T) {
+		did := baseDID + ":users:alice"
+		doc := newWebDoc(did)
+		publish("/users/alice/did.json", doc)
+
+		result, err := resolver.Resolve(context.Background(), did)
+		require.NoError(t, err)
+		assert.Equal(t, doc, result.DIDDoc)
+	})
+
+	t.Run("ID mismatch", func(t *testing.T) {
+		// A host serving a document for another DID must not be able to impersonate it.
+		did := baseDID + ":users:mallory"
+		publish("/users/mallory/did.json", newWebDoc(baseDID+":users:alice"))
+
+		_, err := resolver.Resolve(context.Background(), did)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match")
+	})
+
+	t.Run("Invalid document", func(t *testing.T) {
+		did := baseDID + ":users:invalid"
+		doc := newWebDoc(did)
+		doc.Authentication = []string{did + "#missing"}
+		publish("/users/invalid/did.json", doc)
+
+		_, err := resolver.Resolve(context.Background(), did)
+		assert.Error(t, err)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		_, err := resolver.Resolve(context.Background(), baseDID+":users:nobody")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Response too large", func(t *testing.T) {
+		did := baseDID + ":users:large"
+		doc := newWebDoc(did)
+		doc.Service = []ServiceDef{{ID: did + "#padding", Type: "Padding", ServiceEndpoint: strings.Repeat("a", 2048)}}
+		publish("/users/large/did.json", doc)
+
+		limited := NewWebResolver(server.Client())
+		limited.MaxResponseSize = 1024
+		_, err := limited.Resolve(context.Background(), did)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds")
+	})
+
+	t.Run("Plain HTTP is not used", func(t *testing.T) {
+		// The resolver always speaks TLS, so a plain HTTP server cannot serve documents.
+		plain := httptest.NewServer(server.Config.Handler)
+		defer plain.Close()
+		plainURL, err := url.Parse(plain.URL)
+		require.NoError(t, err)
+
+		_, err = NewWebResolver(plain.Client()).Resolve(context.Background(), WebDIDMethod+strings.Replace(plainURL.Host, ":", "%3A", 1))
+		assert.Error(t, err)
+	})
+
+	t.Run("Redirect to plain HTTP is not followed", func(t *testing.T) {
+		var plainRequests int32
+		plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&plainRequests, 1)
+			server.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer plain.Close()
+		publish("/downgrade/did.json", newWebDoc(baseDID+":downgrade"))
+		redirecting := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, plain.URL+r.URL.Path, http.StatusFound)
+		}))
+		defer redirecting.Close()
+		redirectingURL, err := url.Parse(redirecting.URL)
+		require.NoError(t, err)
+
+		redirectingDID := WebDIDMethod + strings.Replace(redirectingURL.Host, ":", "%3A", 1) + ":downgrade"
+		_, err = NewWebResolver(redirecting.Client()).Resolve(context.Background(), redirectingDID)
+		assert.Error(t, err)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&plainRequests))
+	})
+
+	t.Run("Redirect to HTTPS is followed", func(t *testing.T) {
+		redirecting := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, server.URL+r.URL.Path, http.StatusFound)
+		}))
+		defer redirecting.Close()
+		redirectingURL, err := url.Parse(redirecting.URL)
+		require.NoError(t, err)
+		redirectingDID := WebDIDMethod + strings.Replace(redirectingURL.Host, ":", "%3A", 1) + ":moved"
+		publish("/moved/did.json", newWebDoc(redirectingDID))
+
+		result, err := resolver.Resolve(context.Background(), redirectingDID)
+		require.NoError(t, err)
+		assert.Equal(t, redirectingDID, result.DIDDoc.ID)
+	})
+
+	t.Run("Context cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := resolver.Resolve(ctx, baseDID)
+		assert.Error(t, err)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		slow := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		defer slow.Close()
+		slowURL, err := url.Parse(slow.URL)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = NewWebResolver(slow.Client()).Resolve(ctx, WebDIDMethod+strings.Replace(slowURL.Host, ":", "%3A", 1))
+		assert.Error(t, err)
+	})
+}
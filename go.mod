@@ -1,17 +1,14 @@
 module github.com/workdaycredentials/ledger-common
 
-go 1.13
+go 1.18
 
 require (
 	github.com/aws/aws-sdk-go v1.30.15
 	github.com/btcsuite/btcd v0.20.1-beta
 	github.com/cyberphone/json-canonicalization v0.0.0-20200417180520-cd6247b5f11e
-	github.com/go-playground/locales v0.13.0 // indirect
-	github.com/go-playground/universal-translator v0.16.0 // indirect
 	github.com/gobuffalo/packr v1.30.1
-	github.com/google/uuid v1.1.1
+	github.com/google/uuid v1.2.0
 	github.com/hashicorp/go-version v1.2.0
-	github.com/leodido/go-urn v1.2.0 // indirect
 	github.com/magefile/mage v1.9.0
 	github.com/mr-tron/base58 v1.1.3
 	github.com/pkg/errors v0.9.1
@@ -19,10 +16,25 @@ require (
 	github.com/stretchr/testify v1.5.1
 	github.com/xeipuuv/gojsonschema v1.2.0
 	golang.org/x/crypto v0.0.0-20200427165652-729f1e841bcc
-	golang.org/x/net v0.0.0-20200226121028-0de0cce0169b // indirect
+	gopkg.in/go-playground/validator.v9 v9.31.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-playground/locales v0.13.0 // indirect
+	github.com/go-playground/universal-translator v0.16.0 // indirect
+	github.com/gobuffalo/envy v1.7.0 // indirect
+	github.com/gobuffalo/packd v0.3.0 // indirect
+	github.com/jmespath/go-jmespath v0.3.0 // indirect
+	github.com/joho/godotenv v1.3.0 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
+	github.com/leodido/go-urn v1.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/rogpeppe/go-internal v1.3.0 // indirect
+	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
+	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
 	golang.org/x/sys v0.0.0-20200406155108-e3b113bbe6a4 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
-	gopkg.in/go-playground/validator.v9 v9.31.0
 	gopkg.in/yaml.v2 v2.2.7 // indirect
 )
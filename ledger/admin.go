@@ -0,0 +1,123 @@
+package ledger
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+var (
+	// ErrMissingAdminProof is returned by VerifyAdminOperation when a privileged operation is not
+	// countersigned by the admin.
+	ErrMissingAdminProof = errcode.New(errcode.MalformedProof, "privileged operation does not have an admin proof")
+	// ErrMissingSubjectProof is returned by VerifyAdminOperation when a privileged operation is
+	// only signed by the admin, and not by the subject DID.
+	ErrMissingSubjectProof = errcode.New(errcode.MalformedProof, "privileged operation does not have a subject proof")
+	// ErrNotAdminKey is returned by VerifyAdminOperation when the countersignature is not made
+	// with a key of the admin DID Document (see did.AdminDIDKey).
+	ErrNotAdminKey = errcode.New(errcode.SignatureInvalid, "privileged operation is not countersigned with an admin key")
+)
+
+// AdminOperation is an Operation that is privileged, such as replacing the admin DID stored under
+// did.AdminDIDKey or force-deactivating a DID, and so must be countersigned by the admin. It
+// carries a proof set: the proof of the subject DID, and the proof of a key from the admin DID
+// Document, each made over the operation without proofs.
+type AdminOperation struct {
+	Operation
+	Proofs []*proof.Proof `json:"proof"`
+}
+
+// CountersignOperation countersigns the operation, which must already be signed by the subject
+// DID (see BuildCreateOperation, BuildUpdateOperation and BuildDeactivateOperation), with a key
+// of the admin DID Document (see did.SignAsAdmin).
+func CountersignOperation(op *Operation, adminSigner proof.Signer, adminDoc did.DIDDoc) (*AdminOperation, error) {
+	if op.Proof.IsEmpty() {
+		return nil, ErrMissingSubjectProof
+	}
+	unsigned := *op
+	unsigned.Proof = nil
+	if err := did.SignAsAdmin(&unsigned, adminSigner, adminDoc); err != nil {
+		return nil, err
+	}
+	admin := unsigned
+	admin.Proof = nil
+	return &AdminOperation{Operation: admin, Proofs: []*proof.Proof{op.Proof, unsigned.Proof}}, nil
+}
+
+// SubjectOperation returns the operation with only the subject's proof, e.g. to check it against
+// the previous operation with VerifyOperation. It is nil if there is no subject proof.
+func (a *AdminOperation) SubjectOperation() *Operation {
+	subjectProof, _ := a.splitProofs()
+	if subjectProof == nil {
+		return nil
+	}
+	op := a.Operation
+	op.Proof = subjectProof
+	return &op
+}
+
+// splitProofs returns the first proof made with a key of the subject DID, and the other proof.
+// Either is nil if missing.
+func (a *AdminOperation) splitProofs() (subjectProof, adminProof *proof.Proof) {
+	for _, p := range a.Proofs {
+		if p.IsEmpty() {
+			continue
+		}
+		if subjectProof == nil && did.Equal(did.ExtractDIDFromKeyRef(p.GetVerificationMethod()), a.DID) {
+			subjectProof = p
+		} else if adminProof == nil {
+			adminProof = p
+		}
+	}
+	return subjectProof, adminProof
+}
+
+// VerifyAdminOperation checks both proofs of the privileged operation: the subject's proof must be
+// made with a key of the subject DID Document that may authorize the operation (see
+// VerifyOperation), and the admin's proof with a key of the admin DID Document. Returns
+// ErrMissingSubjectProof, ErrMissingAdminProof or ErrNotAdminKey if a proof is missing or the
+// countersignature is not made with an admin key. The sequence number and previous version hash
+// are not checked; use VerifyOperation with SubjectOperation for that.
+func VerifyAdminOperation(op *AdminOperation, subjectDoc, adminDoc did.DIDDoc) error {
+	if len(op.Proofs) > 2 {
+		return errcode.Errorf(errcode.MalformedProof, "privileged operation has %d proofs, expected a subject and an admin proof", len(op.Proofs))
+	}
+	subjectProof, adminProof := op.splitProofs()
+	if subjectProof == nil {
+		return ErrMissingSubjectProof
+	}
+	if adminProof == nil {
+		return ErrMissingAdminProof
+	}
+	authorized, err := did.IsAdminAuthorized(adminDoc, adminProof)
+	if err != nil {
+		return err
+	}
+	if !authorized {
+		return errors.Wrapf(ErrNotAdminKey, "key<%s>", adminProof.GetVerificationMethod())
+	}
+
+	if !did.Equal(subjectDoc.ID, op.DID) {
+		return errors.Errorf("subject DID<%s> does not match operation DID<%s>", subjectDoc.ID, op.DID)
+	}
+	didOp := did.DeactivateOperation
+	if op.Kind != DeactivateOperation {
+		doc, err := op.Document()
+		if err != nil {
+			return err
+		}
+		didOp = did.UpdateOperationFor(subjectDoc.UnsignedDIDDoc, doc.UnsignedDIDDoc)
+	}
+	signed := op.Operation
+	signed.Proof = subjectProof
+	if err := did.VerifyProofForOperation(&signed, subjectDoc.UnsignedDIDDoc, didOp); err != nil {
+		return errors.Wrap(err, "subject proof")
+	}
+	signed.Proof = adminProof
+	if err := did.VerifyProofForOperation(&signed, adminDoc.UnsignedDIDDoc, did.ProofOperation); err != nil {
+		return errors.Wrap(err, "admin proof")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package ledger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+)
+
+func TestAdminOperation(t *testing.T) {
+	newIdentity := func(t *testing.T) (*did.DIDDoc, proof.Signer) {
+		doc, privKey := did.GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		signer, err := proof.NewEd25519Signer(privKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		return doc, signer
+	}
+	adminDoc, adminSigner := newIdentity(t)
+	subjectDoc, subjectSigner := newIdentity(t)
+	otherDoc, otherSigner := newIdentity(t)
+
+	create, err := BuildCreateOperation(*subjectDoc, subjectSigner)
+	require.NoError(t, err)
+	deactivated, err := did.DeactivateDIDDocGeneric(subjectSigner, proof.JCSEdSignatureType, subjectDoc.ID)
+	require.NoError(t, err)
+	deactivate, err := BuildDeactivateOperation(create, *deactivated, subjectSigner)
+	require.NoError(t, err)
+
+	t.Run("Countersigned", func(t *testing.T) {
+		op, err := CountersignOperation(deactivate, adminSigner, *adminDoc)
+		require.NoError(t, err)
+		require.Len(t, op.Proofs, 2)
+		assert.Nil(t, op.Operation.Proof)
+
+		data, err := json.Marshal(op)
+		require.NoError(t, err)
+		var decoded AdminOperation
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Len(t, decoded.Proofs, 2)
+		assert.NoError(t, VerifyAdminOperation(&decoded, *subjectDoc, *adminDoc))
+		assert.NoError(t, VerifyOperation(decoded.SubjectOperation(), create))
+
+		// The proofs cover the operation.
+		decoded.Sequence++
+		assert.Error(t, VerifyAdminOperation(&decoded, *subjectDoc, *adminDoc))
+
+		// The admin of another ledger.
+		assert.Equal(t, ErrNotAdminKey, errors.Cause(VerifyAdminOperation(op, *subjectDoc, *otherDoc)))
+		assert.Error(t, VerifyAdminOperation(op, *otherDoc, *adminDoc))
+	})
+
+	t.Run("Missing admin proof", func(t *testing.T) {
+		op := &AdminOperation{Operation: *deactivate, Proofs: []*proof.Proof{deactivate.Proof}}
+		op.Operation.Proof = nil
+		assert.Equal(t, ErrMissingAdminProof, VerifyAdminOperation(op, *subjectDoc, *adminDoc))
+
+		unsigned := *deactivate
+		unsigned.Proof = nil
+		_, err := CountersignOperation(&unsigned, adminSigner, *adminDoc)
+		assert.Equal(t, ErrMissingSubjectProof, err)
+	})
+
+	t.Run("Admin proof from a non-admin key", func(t *testing.T) {
+		_, err := CountersignOperation(deactivate, otherSigner, *adminDoc)
+		assert.Error(t, err)
+
+		unsigned := *deactivate
+		unsigned.Proof = nil
+		suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+		require.NoError(t, err)
+		require.NoError(t, suite.Sign(&unsigned, otherSigner))
+		op := &AdminOperation{Operation: unsigned, Proofs: []*proof.Proof{deactivate.Proof, unsigned.Proof}}
+		op.Operation.Proof = nil
+		assert.Equal(t, ErrNotAdminKey, errors.Cause(VerifyAdminOperation(op, *subjectDoc, *adminDoc)))
+	})
+
+	t.Run("Admin proof without subject proof", func(t *testing.T) {
+		countersigned, err := CountersignOperation(deactivate, adminSigner, *adminDoc)
+		require.NoError(t, err)
+		op := &AdminOperation{Operation: countersigned.Operation, Proofs: countersigned.Proofs[1:]}
+		assert.Equal(t, ErrMissingSubjectProof, VerifyAdminOperation(op, *subjectDoc, *adminDoc))
+		assert.Nil(t, op.SubjectOperation())
+	})
+}
@@ -3,6 +3,7 @@ package ledger
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"golang.org/x/crypto/ed25519"
@@ -16,6 +17,9 @@ import (
 	"github.com/workdaycredentials/ledger-common/util"
 )
 
+// ServiceIDPrefix is the prefix of the fragment of service IDs generated by GenerateLedgerDIDDoc.
+const ServiceIDPrefix = "service-"
+
 type GenerateDIDDocInput struct {
 	// DID is a decentralized identifier in the format of "did:work:<id>".
 	DID string `validate:"required"`
@@ -30,6 +34,9 @@ type GenerateDIDDocInput struct {
 	// PublicKeys is a map of KeyID to Ed25519 public keys. These keys will be listed in the DID
 	// Document's publicKeys field.
 	PublicKeys map[string]ed25519.PublicKey `validate:"required"`
+	// KeyType is an optional verification method type for the PublicKeys. Defaults to the
+	// Signer's key type. Ed25519VerificationKey2020 keys are published as publicKeyMultibase.
+	KeyType proof.KeyType
 	// Issuer is an optional DID who controls the SigningKey. This is intended to be used by
 	// Issuers that create a different DID Document per schema type.  Specifying the Issuer here
 	// creates a linkage between the identities.
@@ -38,8 +45,29 @@ type GenerateDIDDocInput struct {
 	//
 	// Workday uses a "schema" service endpoint to specify which schema an identity will issue
 	// credentials against. This service endpoint is not strictly necessary, but may be useful
-	// for Issuers managing multiple identities.
+	// for Issuers managing multiple identities. Services without an ID are given a random one,
+	// e.g. "did:work:abc#service-Lg7PzkLqGwNtBXGC5UtE3f".
 	Services []did.ServiceDef
+	// Context is an optional JSON-LD @context for the DID Document, e.g. did.DIDContext. Workday
+	// does not use JSON-LD, but some relying parties require the property.
+	Context []string
+	// RecoveryKey is the optional key ID of the recovery key, which must be one of the PublicKeys
+	// other than did.InitialKey. The recovery key can authorize updates and deactivation of the
+	// DID Document, but not credential proofs.
+	RecoveryKey string
+	// AlsoKnownAs are optional other identifiers of the DID subject, such as a did:web DID.
+	AlsoKnownAs []string
+	// ProofVersion is the optional proof model version of the signatures. Defaults to V2, or V1
+	// for signature types that only have a V1 suite.
+	ProofVersion proof.ModelVersion
+}
+
+// WithAgreed returns a copy of the input that signs with the signature suite and proof model
+// version agreed with a peer (see proof.Negotiate).
+func (g GenerateDIDDocInput) WithAgreed(agreed proof.Agreed) GenerateDIDDocInput {
+	g.SignatureType = agreed.SignatureType
+	g.ProofVersion = agreed.ProofVersion
+	return g
 }
 
 // GenerateLedgerDIDDoc generates DID Document based on the current state of the input.
@@ -48,37 +76,75 @@ func (g GenerateDIDDocInput) GenerateLedgerDIDDoc() (*DIDDoc, error) {
 		return nil, err
 	}
 
+	if _, _, err := did.ParseKeyRef(g.FullyQualifiedKeyRef); err != nil {
+		return nil, err
+	}
+
 	var didPubKeys []did.KeyDef
 	if g.Issuer == "" {
 		g.Issuer = g.DID
 	}
+	if g.KeyType == "" {
+		g.KeyType = g.Signer.Type()
+	}
 	for k, v := range g.PublicKeys {
+		keyID, err := did.NewKeyID(g.DID, k)
+		if err != nil {
+			return nil, err
+		}
 		keyEntry := did.KeyDef{
-			ID:              did.GenerateKeyID(g.DID, k),
-			Type:            g.Signer.Type(),
-			Controller:      g.Issuer,
-			PublicKeyBase58: base58.Encode(v),
+			ID:         keyID,
+			Type:       g.KeyType,
+			Controller: g.Issuer,
+		}
+		if g.KeyType == proof.Ed25519VerificationKey2020KeyType {
+			if keyEntry.PublicKeyMultibase, err = did.EncodePublicKeyMultibase(v, g.KeyType); err != nil {
+				return nil, err
+			}
+		} else {
+			keyEntry.PublicKeyBase58 = base58.Encode(v)
 		}
 		didPubKeys = append(didPubKeys, keyEntry)
 	}
 
-	doc := did.DIDDoc{
-		UnsignedDIDDoc: did.UnsignedDIDDoc{
-			ID:        g.DID,
-			PublicKey: didPubKeys,
-			Service:   g.Services,
-		},
+	var services []did.ServiceDef
+	for _, service := range g.Services {
+		if service.ID == "" {
+			service.ID = g.DID + "#" + util.NewID(ServiceIDPrefix)
+		}
+		services = append(services, service)
+	}
+
+	var recovery []string
+	if g.RecoveryKey != "" {
+		if _, ok := g.PublicKeys[g.RecoveryKey]; !ok {
+			return nil, fmt.Errorf("recovery key<%s> must be one of the public keys", g.RecoveryKey)
+		}
+		recovery = []string{did.GenerateKeyID(g.DID, g.RecoveryKey)}
 	}
 
-	proofVersion := proof.V2
-	if g.SignatureType == proof.EcdsaSecp256k1SignatureType {
-		proofVersion = proof.V1
+	proofVersion := g.ProofVersion
+	if proofVersion == 0 {
+		proofVersion = proof.V2
+		if g.SignatureType == proof.EcdsaSecp256k1SignatureType {
+			proofVersion = proof.V1
+		}
 	}
-	suite, err := proof.SignatureSuites().GetSuite(g.SignatureType, proofVersion)
+	agreed := proof.Agreed{SignatureType: g.SignatureType, ProofVersion: proofVersion}
+	suite, err := agreed.Suite()
 	if err != nil {
 		return nil, err
 	}
-	if err = suite.Sign(&doc, g.Signer); err != nil {
+
+	doc, err := did.SignDIDDocAgreed(did.UnsignedDIDDoc{
+		Context:     g.Context,
+		ID:          g.DID,
+		PublicKey:   didPubKeys,
+		Service:     services,
+		AlsoKnownAs: g.AlsoKnownAs,
+		Recovery:    recovery,
+	}, g.Signer, agreed)
+	if err != nil {
 		logrus.WithError(err).Error("could not sign did doc")
 		return nil, err
 	}
@@ -91,7 +157,7 @@ func (g GenerateDIDDocInput) GenerateLedgerDIDDoc() (*DIDDoc, error) {
 			Author:       doc.PublicKey[0].Controller,
 			Authored:     time.Now().UTC().Format(time.RFC3339),
 		},
-		DIDDoc: &doc,
+		DIDDoc: doc,
 	}
 	if err = suite.Sign(&ledgerDoc, g.Signer); err != nil {
 		logrus.WithError(err).Error("could not sign ledger did doc")
@@ -107,6 +173,7 @@ func GenerateB64EncodedEd25519DIDDoc(b64EncodedPrivKey string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	defer util.Zeroize(decodeKeyBytes)
 	seedKey := ed25519.PrivateKey(decodeKeyBytes)
 	pubKey := seedKey.Public().(ed25519.PublicKey)
 
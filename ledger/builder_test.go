@@ -4,14 +4,17 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"golang.org/x/crypto/ed25519"
 
 	"github.com/mr-tron/base58"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/workdaycredentials/ledger-common/did"
 	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
 )
 
 var (
@@ -45,6 +48,89 @@ func TestVerifyDIDDocProof(t *testing.T) {
 	assert.NoError(t, suite.Verify(ledgerDoc, verifier))
 }
 
+func TestGenerateLedgerDIDDocWithRecoveryKey(t *testing.T) {
+	id := did.GenerateDID(issuerPubKey)
+	keyRef := did.GenerateKeyID(id, did.InitialKey)
+	signer, err := proof.NewEd25519Signer(issuerPrivKey, keyRef)
+	assert.NoError(t, err)
+	recoveryKey, _, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	input := GenerateDIDDocInput{
+		DID:                  id,
+		FullyQualifiedKeyRef: keyRef,
+		Signer:               signer,
+		SignatureType:        proof.JCSEdSignatureType,
+		PublicKeys:           map[string]ed25519.PublicKey{did.InitialKey: issuerPubKey, "recovery": recoveryKey},
+		Issuer:               id,
+		RecoveryKey:          "recovery",
+	}
+	ledgerDoc, err := input.GenerateLedgerDIDDoc()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{id + "#recovery"}, ledgerDoc.DIDDoc.Recovery)
+
+	input.RecoveryKey = did.InitialKey
+	_, err = input.GenerateLedgerDIDDoc()
+	assert.Error(t, err)
+
+	input.RecoveryKey = "missing"
+	_, err = input.GenerateLedgerDIDDoc()
+	assert.Error(t, err)
+}
+
+func TestGenerateLedgerDIDDocWithAlias(t *testing.T) {
+	id := did.GenerateDID(issuerPubKey)
+	keyRef := did.GenerateKeyID(id, did.InitialKey)
+	signer, err := proof.NewEd25519Signer(issuerPrivKey, keyRef)
+	assert.NoError(t, err)
+	input := GenerateDIDDocInput{
+		DID:                  id,
+		FullyQualifiedKeyRef: keyRef,
+		Signer:               signer,
+		SignatureType:        proof.JCSEdSignatureType,
+		PublicKeys:           map[string]ed25519.PublicKey{did.InitialKey: issuerPubKey},
+		Issuer:               id,
+		AlsoKnownAs:          []string{"did:web:example.com"},
+	}
+	ledgerDoc, err := input.GenerateLedgerDIDDoc()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"did:web:example.com"}, ledgerDoc.DIDDoc.AlsoKnownAs)
+	assert.NoError(t, ledgerDoc.ValidateProof())
+
+	input.AlsoKnownAs = []string{"example.com"}
+	_, err = input.GenerateLedgerDIDDoc()
+	assert.Error(t, err)
+}
+
+func TestGenerateLedgerDIDDocMalformedKeyRefs(t *testing.T) {
+	id := did.GenerateDID(issuerPubKey)
+	keyRef := did.GenerateKeyID(id, did.InitialKey)
+	signer, err := proof.NewEd25519Signer(issuerPrivKey, keyRef)
+	assert.NoError(t, err)
+	input := GenerateDIDDocInput{
+		DID:                  id,
+		FullyQualifiedKeyRef: keyRef,
+		Signer:               signer,
+		SignatureType:        proof.JCSEdSignatureType,
+		PublicKeys:           map[string]ed25519.PublicKey{did.InitialKey: issuerPubKey},
+		Issuer:               id,
+	}
+
+	missingFragment := input
+	missingFragment.FullyQualifiedKeyRef = id
+	_, err = missingFragment.GenerateLedgerDIDDoc()
+	assert.Error(t, err)
+
+	emptyKeyName := input
+	emptyKeyName.PublicKeys = map[string]ed25519.PublicKey{"": issuerPubKey}
+	_, err = emptyKeyName.GenerateLedgerDIDDoc()
+	assert.Error(t, err)
+
+	invalidDID := input
+	invalidDID.DID = "did:work:short"
+	_, err = invalidDID.GenerateLedgerDIDDoc()
+	assert.Error(t, err)
+}
+
 func TestED25519GenerateB64EncodedDIDDoc(t *testing.T) {
 	b64EncPrivKey := base64.StdEncoding.EncodeToString(issuerPrivKey)
 	b64didDoc, _ := GenerateB64EncodedEd25519DIDDoc(b64EncPrivKey)
@@ -117,6 +203,41 @@ func TestGenerateDIDDocForIssuerWithServices(t *testing.T) {
 	verifyDIDDoc(t, *didDoc.DIDDoc, issuerPubKey)
 }
 
+func TestGenerateDIDDocWithServiceIDs(t *testing.T) {
+	id := did.GenerateDID(issuerPubKey)
+	keyRef := did.GenerateKeyID(id, did.InitialKey)
+	signer, err := proof.NewEd25519Signer(issuerPrivKey, keyRef)
+	assert.NoError(t, err)
+	services := []did.ServiceDef{
+		{Type: "schema", ServiceEndpoint: "schemaID"},
+		{ID: id + "#hub", Type: "hub", ServiceEndpoint: "https://example.com"},
+		{Type: "schema", ServiceEndpoint: "otherSchemaID"},
+	}
+	input := GenerateDIDDocInput{
+		DID:                  id,
+		FullyQualifiedKeyRef: keyRef,
+		Signer:               signer,
+		SignatureType:        proof.JCSEdSignatureType,
+		PublicKeys:           map[string]ed25519.PublicKey{did.InitialKey: issuerPubKey},
+		Issuer:               id,
+		Services:             services,
+	}
+
+	didDoc, err := input.GenerateLedgerDIDDoc()
+	assert.NoError(t, err)
+	assert.Len(t, didDoc.Service, 3)
+	assert.Equal(t, id+"#hub", didDoc.Service[1].ID)
+	for _, i := range []int{0, 2} {
+		assert.Equal(t, services[i].ServiceEndpoint, didDoc.Service[i].ServiceEndpoint)
+		assert.NoError(t, util.ValidateID(id+"#"+ServiceIDPrefix, didDoc.Service[i].ID))
+	}
+	assert.NotEqual(t, didDoc.Service[0].ID, didDoc.Service[2].ID)
+	assert.NoError(t, didDoc.DIDDoc.Validate())
+	// The input is not modified.
+	assert.Empty(t, services[0].ID)
+	verifyDIDDoc(t, *didDoc.DIDDoc, issuerPubKey)
+}
+
 func TestGenerateDIDDocForKeys(t *testing.T) {
 	id := did.GenerateDID(issuerPubKey)
 	keyRef := did.GenerateKeyID(id, did.InitialKey)
@@ -142,6 +263,63 @@ func TestGenerateDIDDocForKeys(t *testing.T) {
 	verifyLedgerDIDDoc(t, *ledgerDoc, issuerPubKey)
 }
 
+func TestGenerateDIDDocWithMultibaseKeys(t *testing.T) {
+	id := did.GenerateDID(issuerPubKey)
+	keyRef := did.GenerateKeyID(id, did.InitialKey)
+	signer, err := proof.NewEd25519Signer(issuerPrivKey, keyRef)
+	assert.NoError(t, err)
+	ledgerDoc, err := GenerateDIDDocInput{
+		DID:                  id,
+		FullyQualifiedKeyRef: keyRef,
+		Signer:               signer,
+		SignatureType:        proof.JCSEdSignatureType,
+		PublicKeys:           map[string]ed25519.PublicKey{did.InitialKey: issuerPubKey},
+		KeyType:              proof.Ed25519VerificationKey2020KeyType,
+		Issuer:               id,
+	}.GenerateLedgerDIDDoc()
+	assert.NoError(t, err)
+
+	keyDef := ledgerDoc.PublicKey[0]
+	assert.Equal(t, proof.Ed25519VerificationKey2020KeyType, keyDef.Type)
+	assert.Empty(t, keyDef.PublicKeyBase58)
+	decoded, keyType, err := did.DecodePublicKeyMultibase(keyDef.PublicKeyMultibase)
+	assert.NoError(t, err)
+	assert.Equal(t, proof.Ed25519KeyType, keyType)
+	assert.Equal(t, []byte(issuerPubKey), decoded)
+	assert.NoError(t, ledgerDoc.DIDDoc.Validate())
+
+	verifier, err := did.AsVerifier(keyDef)
+	assert.NoError(t, err)
+	suite, err := proof.SignatureSuites().GetSuiteForProof(ledgerDoc.GetProof())
+	assert.NoError(t, err)
+	assert.NoError(t, suite.Verify(ledgerDoc, verifier))
+}
+
+func TestGenerateDIDDocWithContext(t *testing.T) {
+	id := did.GenerateDID(issuerPubKey)
+	keyRef := did.GenerateKeyID(id, did.InitialKey)
+	signer, err := proof.NewEd25519Signer(issuerPrivKey, keyRef)
+	assert.NoError(t, err)
+	ledgerDoc, err := GenerateDIDDocInput{
+		DID:                  id,
+		FullyQualifiedKeyRef: keyRef,
+		Signer:               signer,
+		SignatureType:        proof.JCSEdSignatureType,
+		PublicKeys:           map[string]ed25519.PublicKey{did.InitialKey: issuerPubKey},
+		Issuer:               id,
+		Context:              []string{did.DIDContext},
+	}.GenerateLedgerDIDDoc()
+	assert.NoError(t, err)
+
+	docBytes, err := json.Marshal(ledgerDoc.DIDDoc)
+	assert.NoError(t, err)
+	assert.Contains(t, string(docBytes), `"@context":["https://www.w3.org/ns/did/v1"]`)
+
+	var parsed did.DIDDoc
+	assert.NoError(t, json.Unmarshal(docBytes, &parsed))
+	assert.NoError(t, did.VerifyDIDDocProof(parsed, parsed.UnsignedDIDDoc))
+}
+
 func TestGenerateKeyDIDDoc(t *testing.T) {
 	id := did.GenerateDIDKey(issuerPubKey)
 	keyRef := did.GenerateKeyID(id, did.InitialKey)
@@ -190,6 +368,16 @@ func TestGenerateDeactivatedDIDDoc(t *testing.T) {
 	assert.Equal(t, 0, len(deactivatedDIDDoc.DIDDoc.PublicKey))
 
 	assert.Empty(t, deactivatedDIDDoc.DIDDoc.SchemaContext)
+
+	now := time.Date(2020, time.January, 1, 12, 30, 0, 0, time.UTC)
+	deactivatedDIDDoc, err = GenerateDeactivatedDIDDoc(signer, suite, id, proof.WithClock(func() time.Time { return now }))
+	require.NoError(t, err)
+	assert.Equal(t, "2020-01-01T12:30:00Z", deactivatedDIDDoc.DIDDoc.DeactivatedAt)
+	assert.Equal(t, deactivatedDIDDoc.DIDDoc.DeactivatedAt, deactivatedDIDDoc.DIDDoc.Proof.Created)
+	assert.Equal(t, deactivatedDIDDoc.DIDDoc.DeactivatedAt, deactivatedDIDDoc.Metadata.Authored)
+	assert.Equal(t, deactivatedDIDDoc.DIDDoc.DeactivatedAt, deactivatedDIDDoc.GetProof().Created)
+	assert.NoError(t, suite.Verify(deactivatedDIDDoc.DIDDoc, &proof.Ed25519Verifier{PubKey: issuerPubKey}))
+	assert.NoError(t, suite.Verify(deactivatedDIDDoc, &proof.Ed25519Verifier{PubKey: issuerPubKey}))
 }
 
 func verifyLedgerDIDDoc(t *testing.T, ledgerDoc DIDDoc, key ed25519.PublicKey) {
@@ -219,3 +407,26 @@ func verifyDIDDoc(t *testing.T, didDoc did.DIDDoc, key ed25519.PublicKey) {
 	pubK1Bytes, _ := base58.Decode(didDoc.PublicKey[0].PublicKeyBase58)
 	assert.Equal(t, didDoc.ID, "did:work:"+base58.Encode(pubK1Bytes[:16]))
 }
+
+func TestGenerateDIDDocWithAgreed(t *testing.T) {
+	id := did.GenerateDID(issuerPubKey)
+	keyRef := did.GenerateKeyID(id, did.InitialKey)
+	signer, err := proof.NewEd25519Signer(issuerPrivKey, keyRef)
+	assert.NoError(t, err)
+	input := GenerateDIDDocInput{
+		DID:                  id,
+		FullyQualifiedKeyRef: keyRef,
+		Signer:               signer,
+		PublicKeys:           map[string]ed25519.PublicKey{did.InitialKey: issuerPubKey},
+		Issuer:               id,
+	}.WithAgreed(proof.Agreed{SignatureType: proof.WorkEdSignatureType, ProofVersion: proof.V1})
+
+	didDoc, err := input.GenerateLedgerDIDDoc()
+	assert.NoError(t, err)
+	for _, p := range []*proof.Proof{didDoc.DIDDoc.Proof, didDoc.Metadata.Proof} {
+		assert.Equal(t, proof.WorkEdSignatureType, p.Type)
+		assert.Equal(t, keyRef, p.Creator)
+		assert.Empty(t, p.VerificationMethod)
+	}
+	verifyDIDDoc(t, *didDoc.DIDDoc, issuerPubKey)
+}
@@ -21,6 +21,7 @@ func GenerateB64EncodedEd25519DeactivatedDIDDoc(b64EncodedPrivKey, b64EncDID str
 	if err != nil {
 		return "", err
 	}
+	defer util.Zeroize(decodeKeyBytes)
 	signingKey := ed25519.PrivateKey(decodeKeyBytes)
 
 	decodeDIDBytes, err := b64Encoding.DecodeString(b64EncDID)
@@ -29,7 +30,10 @@ func GenerateB64EncodedEd25519DeactivatedDIDDoc(b64EncodedPrivKey, b64EncDID str
 	}
 	id := string(decodeDIDBytes)
 
-	keyID := didpkg.GenerateKeyID(id, didpkg.InitialKey)
+	keyID, err := didpkg.NewKeyID(id, didpkg.InitialKey)
+	if err != nil {
+		return "", err
+	}
 	signer, err := proof.NewEd25519Signer(signingKey, keyID)
 	if err != nil {
 		return "", err
@@ -50,26 +54,35 @@ func GenerateB64EncodedEd25519DeactivatedDIDDoc(b64EncodedPrivKey, b64EncDID str
 	return b64EncDIDDoc, err
 }
 
-// GenerateDeactivatedDIDDoc creates a deactivated DID Document.
+// GenerateDeactivatedDIDDoc creates a deactivated DID Document with an explicit deactivated status.
+// The DID Document is made by did.DeactivateDIDDocGeneric with the suite's signature type, and the
+// options are applied to both proofs: the deactivation time, the authored time and the created
+// time of both proofs are taken from the clock of proof.WithClock if given.
 // Returns an error if the Signer fails to generate the digital signature.
-func GenerateDeactivatedDIDDoc(signer proof.Signer, suite proof.SignatureSuite, did string) (*DIDDoc, error) {
-	doc := &didpkg.DIDDoc{UnsignedDIDDoc: didpkg.UnsignedDIDDoc{ID: did}}
-	fullyQualifiedKeyRef := didpkg.GenerateKeyID(did, didpkg.InitialKey)
-	if err := suite.Sign(doc, signer); err != nil {
+func GenerateDeactivatedDIDDoc(signer proof.Signer, suite proof.SignatureSuite, did string, opts ...proof.ProofOption) (*DIDDoc, error) {
+	options := proof.ProofOptions{Now: time.Now}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	deactivatedAt := options.Now()
+	opts = append(opts, proof.WithClock(func() time.Time { return deactivatedAt }))
+	doc, err := didpkg.DeactivateDIDDocGeneric(signer, suite.Type(), did, opts...)
+	if err != nil {
 		return nil, err
 	}
+	fullyQualifiedKeyRef := didpkg.GenerateKeyID(did, didpkg.InitialKey)
 
 	ledgerDoc := DIDDoc{
 		Metadata: &Metadata{
 			Type:         util.DIDDocTypeReference_v1_0,
 			ModelVersion: util.Version_1_0,
 			ID:           doc.ID,
-			Authored:     time.Now().UTC().Format(time.RFC3339),
+			Authored:     doc.DeactivatedAt,
 			Author:       didpkg.ExtractDIDFromKeyRef(fullyQualifiedKeyRef),
 		},
 		DIDDoc: doc,
 	}
 
-	err := suite.Sign(&ledgerDoc, signer)
+	err = proof.SignWithOptions(suite, &ledgerDoc, signer, opts...)
 	return &ledgerDoc, err
 }
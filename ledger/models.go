@@ -1,15 +1,16 @@
 package ledger
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"regexp"
 
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
 	"github.com/workdaycredentials/ledger-common/did"
 	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
 	"github.com/workdaycredentials/ledger-common/util/canonical"
 )
 
@@ -43,6 +44,10 @@ func (m *Metadata) SetProof(p *proof.Proof) {
 	m.Proof = p
 }
 
+func (m *Metadata) GetID() string {
+	return m.ID
+}
+
 // A unification of Provable and HasLedgerMetadata types as a utility to aid
 // in the signing of objects that have ledger metadata
 type HasLedgerMetadataProvable interface {
@@ -63,6 +68,28 @@ type DIDDoc struct {
 	*did.DIDDoc `json:"didDoc"`
 }
 
+// didDocFields has the same fields as DIDDoc, without the JSON methods promoted from did.DIDDoc.
+type didDocFields struct {
+	*Metadata
+	DIDDoc *did.DIDDoc `json:"didDoc"`
+}
+
+// MarshalJSON is required because DIDDoc would otherwise be marshaled by the promoted did.DIDDoc
+// method, without the ledger metadata.
+func (d DIDDoc) MarshalJSON() ([]byte, error) {
+	return json.Marshal(didDocFields{Metadata: d.Metadata, DIDDoc: d.DIDDoc})
+}
+
+func (d *DIDDoc) UnmarshalJSON(data []byte) error {
+	var fields didDocFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	d.Metadata = fields.Metadata
+	d.DIDDoc = fields.DIDDoc
+	return nil
+}
+
 func (d *DIDDoc) GetProof() *proof.Proof {
 	return d.Metadata.Proof
 }
@@ -71,6 +98,11 @@ func (d *DIDDoc) SetProof(p *proof.Proof) {
 	d.Metadata.Proof = p
 }
 
+// GetID returns the ID of the metadata, which is the DID of the DID Document.
+func (d *DIDDoc) GetID() string {
+	return d.Metadata.ID
+}
+
 func (d *DIDDoc) IsEmpty() bool {
 	if d == nil {
 		return true
@@ -205,7 +237,7 @@ func (j JSONSchemaMap) ToJSON() string {
 }
 
 func GenerateSchemaID(author, version string) string {
-	return fmt.Sprintf("%s;id=%s;version=%s", author, uuid.New().String(), version)
+	return fmt.Sprintf("%s;id=%s;version=%s", author, util.NewUUID().String(), version)
 }
 
 // Object for a credential that has not been signed
@@ -0,0 +1,247 @@
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// OperationKind is the kind of change that an Operation makes to a DID on the ledger.
+type OperationKind string
+
+const (
+	CreateOperation     OperationKind = "create"
+	UpdateOperation     OperationKind = "update"
+	DeactivateOperation OperationKind = "deactivate"
+)
+
+var (
+	// ErrOperationReplay is returned by VerifyOperation when the operation's sequence number is not
+	// greater than that of the previous operation, e.g. because it is replayed.
+	ErrOperationReplay = errcode.New(errcode.Replay, "operation sequence number has already been used")
+	// ErrPreviousVersionMismatch is returned by VerifyOperation when the operation is not based on
+	// the previous version of the DID Document.
+	ErrPreviousVersionMismatch = errors.New("operation is not based on the previous version of the DID Document")
+	// ErrOperationFork is returned by VerifyOperationChain when two operations have the same
+	// sequence number, i.e. they are competing changes to the same version of the DID Document.
+	ErrOperationFork = errcode.New(errcode.Replay, "operation history is forked")
+)
+
+// Operation is the signed envelope in which a DID Document is written to the ledger. The payload
+// is the signed DID Document itself; the operation's proof authorizes the write (see
+// VerifyOperation). Operations form a chain: each one after the create operation refers to the
+// document written by the previous one, so that a ledger node can reject replayed and competing
+// operations from the previous operation alone.
+type Operation struct {
+	Kind OperationKind `json:"kind"`
+	// DID is the DID that the operation creates, updates or deactivates.
+	DID     string          `json:"did"`
+	Payload json.RawMessage `json:"payload"`
+	// Sequence is the operation's number in the DID's history. It is 0 for the create operation,
+	// and one more than the previous operation's for every later operation.
+	Sequence uint64 `json:"sequence"`
+	// PreviousVersionHash is the fingerprint of the signed DID Document written by the previous
+	// operation (see did.FingerprintWithProof). It is empty for the create operation.
+	PreviousVersionHash string `json:"previousVersionHash,omitempty"`
+	// Created is the RFC 3339 time that the operation was built.
+	Created string       `json:"created"`
+	Proof   *proof.Proof `json:"proof,omitempty"`
+}
+
+func (o *Operation) GetProof() *proof.Proof {
+	return o.Proof
+}
+
+func (o *Operation) SetProof(p *proof.Proof) {
+	o.Proof = p
+}
+
+// GetID returns the DID with the operation's sequence number, e.g. "did:work:abcd?sequence=1",
+// which identifies the operation in the DID's history unless the history is forked.
+func (o *Operation) GetID() string {
+	return fmt.Sprintf("%s?sequence=%d", o.DID, o.Sequence)
+}
+
+// Document decodes the payload.
+func (o *Operation) Document() (*did.DIDDoc, error) {
+	var doc did.DIDDoc
+	if err := json.Unmarshal(o.Payload, &doc); err != nil {
+		return nil, errors.Wrap(err, "invalid operation payload")
+	}
+	return &doc, nil
+}
+
+// BuildCreateOperation returns an operation that writes a new DID Document. The signer must be a
+// key of the document itself.
+func BuildCreateOperation(doc did.DIDDoc, signer proof.Signer) (*Operation, error) {
+	return buildOperation(CreateOperation, nil, doc, signer)
+}
+
+// BuildUpdateOperation returns an operation that replaces the document written by the previous
+// operation with the updated one. The signer must be a key of the previous version, which may be
+// its recovery key or a key of a controller that the previous version lists.
+func BuildUpdateOperation(previous *Operation, updated did.DIDDoc, signer proof.Signer) (*Operation, error) {
+	return buildOperation(UpdateOperation, previous, updated, signer)
+}
+
+// BuildDeactivateOperation returns an operation that deactivates a DID, with a deactivated DID
+// Document (see did.DeactivateDIDDocGeneric). The signer must be a key of the previous version,
+// as for updates.
+func BuildDeactivateOperation(previous *Operation, deactivated did.DIDDoc, signer proof.Signer) (*Operation, error) {
+	return buildOperation(DeactivateOperation, previous, deactivated, signer)
+}
+
+// buildOperation signs the operation with the preferred signature suite for the signer's key (see
+// proof.Capabilities), and verifies it, so that operations that the ledger would reject are not
+// built.
+func buildOperation(kind OperationKind, previous *Operation, doc did.DIDDoc, signer proof.Signer) (*Operation, error) {
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	op := &Operation{
+		Kind:    kind,
+		DID:     doc.ID,
+		Payload: payload,
+		Created: util.FormatCanonicalTime(time.Now()),
+	}
+	if previous != nil {
+		previousDoc, err := previous.Document()
+		if err != nil {
+			return nil, err
+		}
+		if op.PreviousVersionHash, err = did.FingerprintWithProof(*previousDoc); err != nil {
+			return nil, err
+		}
+		op.Sequence = previous.Sequence + 1
+	}
+	capabilities := proof.Capabilities()
+	agreed, err := proof.Negotiate(capabilities.ForKeyType(signer.Type()), capabilities)
+	if err != nil {
+		return nil, err
+	}
+	if err := agreed.Sign(op, signer); err != nil {
+		return nil, err
+	}
+	if err := VerifyOperation(op, previous); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// VerifyOperation checks that the operation may be applied after the previous operation on the
+// DID, which is nil for a create operation. A create operation must have sequence number 0, no
+// previous version hash, and be signed by a key of the new document. Any other operation must
+// have the next sequence number, the fingerprint of the previous operation's document, and be
+// signed by a key of that document; this may be its recovery key, or the key of a controller that
+// it lists. Returns ErrOperationReplay for an old sequence number, and ErrPreviousVersionMismatch
+// if the operation is based on another version. The previous operation itself, and the payload's
+// own proof, are not verified.
+func VerifyOperation(op *Operation, previous *Operation) error {
+	if err := op.checkSequence(previous); err != nil {
+		return err
+	}
+	if _, err := util.ParseRFC3339Lenient(op.Created); err != nil {
+		return errors.Wrapf(err, "invalid operation created time<%s>", op.Created)
+	}
+	if op.Proof.IsEmpty() {
+		return errcode.Errorf(errcode.MalformedProof, "operation on DID<%s> does not have a proof", op.DID)
+	}
+	doc, err := op.Document()
+	if err != nil {
+		return err
+	}
+	if !did.Equal(doc.ID, op.DID) {
+		return errors.Errorf("payload DID<%s> does not match operation DID<%s>", doc.ID, op.DID)
+	}
+
+	switch op.Kind {
+	case CreateOperation:
+		if previous != nil {
+			return errors.Errorf("DID<%s> already exists", op.DID)
+		}
+		if doc.IsDeactivated() {
+			return errors.Errorf("cannot create deactivated DID<%s>", op.DID)
+		}
+		if !did.Equal(did.ExtractDIDFromKeyRef(op.Proof.GetVerificationMethod()), op.DID) {
+			return errcode.Errorf(errcode.SignatureInvalid, "create operation for DID<%s> is not signed by a key of the new document", op.DID)
+		}
+		return did.VerifyProofForOperation(op, doc.UnsignedDIDDoc, did.UpdateOperation)
+	case UpdateOperation, DeactivateOperation:
+		if previous == nil {
+			return errors.Errorf("DID<%s> does not exist", op.DID)
+		}
+		previousDoc, err := previous.Document()
+		if err != nil {
+			return err
+		}
+		if !did.Equal(previousDoc.ID, op.DID) {
+			return errors.Errorf("previous DID<%s> does not match operation DID<%s>", previousDoc.ID, op.DID)
+		}
+		hash, err := did.FingerprintWithProof(*previousDoc)
+		if err != nil {
+			return err
+		}
+		if op.PreviousVersionHash != hash {
+			return errors.Wrapf(ErrPreviousVersionMismatch, "previous version hash<%s> of DID<%s>", op.PreviousVersionHash, op.DID)
+		}
+		if previousDoc.IsDeactivated() {
+			return errors.Wrapf(did.ErrDeactivated, "DID<%s>", op.DID)
+		}
+		if doc.IsDeactivated() != (op.Kind == DeactivateOperation) {
+			return errors.Errorf("payload of %s operation on DID<%s> has status<%s>", op.Kind, op.DID, doc.Status())
+		}
+		didOp := did.UpdateOperationFor(previousDoc.UnsignedDIDDoc, doc.UnsignedDIDDoc)
+		return did.VerifyProofForOperation(op, previousDoc.UnsignedDIDDoc, didOp)
+	default:
+		return errors.Errorf("unknown operation kind<%s>", op.Kind)
+	}
+}
+
+// checkSequence checks the sequence number of the operation against the previous operation.
+func (o *Operation) checkSequence(previous *Operation) error {
+	if previous == nil {
+		if o.Sequence != 0 || o.PreviousVersionHash != "" {
+			return errors.Errorf("first operation on DID<%s> must have sequence number 0 and no previous version hash", o.DID)
+		}
+		return nil
+	}
+	switch {
+	case o.Sequence <= previous.Sequence:
+		return errors.Wrapf(ErrOperationReplay, "sequence<%d> of DID<%s>", o.Sequence, o.DID)
+	case o.Sequence != previous.Sequence+1:
+		return errors.Errorf("sequence<%d> of DID<%s> does not follow sequence<%d>", o.Sequence, o.DID, previous.Sequence)
+	}
+	return nil
+}
+
+// VerifyOperationChain verifies the full history of a DID, in order, starting with its create
+// operation (see VerifyOperation), and returns the current DID Document. Returns ErrOperationFork
+// if two operations have the same sequence number.
+func VerifyOperationChain(ops []Operation) (*did.DIDDoc, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("operation chain is empty")
+	}
+	sequences := make(map[uint64]int, len(ops))
+	for i, op := range ops {
+		if j, ok := sequences[op.Sequence]; ok {
+			return nil, errors.Wrapf(ErrOperationFork, "operations<%d> and <%d> of DID<%s> have sequence<%d>", j, i, op.DID, op.Sequence)
+		}
+		sequences[op.Sequence] = i
+	}
+	var previous *Operation
+	for i := range ops {
+		if err := VerifyOperation(&ops[i], previous); err != nil {
+			return nil, errors.Wrapf(err, "operation<%d>", i)
+		}
+		previous = &ops[i]
+	}
+	return previous.Document()
+}
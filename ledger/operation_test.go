@@ -0,0 +1,233 @@
+package ledger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// The envelope types of this package that have an identifier of their own.
+var (
+	_ proof.Identifiable = (*Metadata)(nil)
+	_ proof.Identifiable = (*DIDDoc)(nil)
+	_ proof.Identifiable = (*Operation)(nil)
+)
+
+func TestOperation(t *testing.T) {
+	newIdentity := func(t *testing.T) (*did.DIDDoc, ed25519.PrivateKey, proof.Signer) {
+		doc, privKey := did.GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+		signer, err := proof.NewEd25519Signer(privKey, doc.PublicKey[0].ID)
+		require.NoError(t, err)
+		return doc, privKey, signer
+	}
+	doc, _, signer := newIdentity(t)
+	otherDoc, otherPriv, otherSigner := newIdentity(t)
+
+	// The update adds a second key, which can sign later operations.
+	newPub, newPriv, err := ed25519.GenerateKey(util.RandReader())
+	require.NoError(t, err)
+	newKeyRef := did.GenerateKeyID(doc.ID, "key-2")
+	newSigner, err := proof.NewEd25519Signer(newPriv, newKeyRef)
+	require.NoError(t, err)
+	unsigned := doc.UnsignedDIDDoc
+	unsigned.PublicKey = append([]did.KeyDef{}, doc.PublicKey...)
+	unsigned.PublicKey = append(unsigned.PublicKey, did.KeyDef{
+		ID:              newKeyRef,
+		Type:            proof.Ed25519KeyType,
+		Controller:      doc.ID,
+		PublicKeyBase58: base58.Encode(newPub),
+	})
+	updated, err := did.UpdateDIDDoc(*doc, unsigned, signer, proof.JCSEdSignatureType)
+	require.NoError(t, err)
+
+	create, err := BuildCreateOperation(*doc, signer)
+	require.NoError(t, err)
+
+	t.Run("Create", func(t *testing.T) {
+		assert.Equal(t, CreateOperation, create.Kind)
+		assert.Equal(t, doc.ID, create.DID)
+		id, ok := proof.IDOf(create)
+		assert.True(t, ok)
+		assert.Equal(t, doc.ID+"?sequence=0", id)
+		assert.Zero(t, create.Sequence)
+		assert.Empty(t, create.PreviousVersionHash)
+
+		data, err := json.Marshal(create)
+		require.NoError(t, err)
+		var decoded Operation
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.NoError(t, VerifyOperation(&decoded, nil))
+		payload, err := decoded.Document()
+		require.NoError(t, err)
+		assert.Equal(t, doc.ID, payload.ID)
+
+		assert.Error(t, VerifyOperation(&decoded, create))
+		decoded.Sequence = 1
+		assert.Error(t, VerifyOperation(&decoded, nil))
+
+		// Only a key of the new document may create it.
+		_, err = BuildCreateOperation(*doc, otherSigner)
+		assert.Error(t, err)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		op, err := BuildUpdateOperation(create, *updated, signer)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), op.Sequence)
+		hash, err := did.FingerprintWithProof(*doc)
+		require.NoError(t, err)
+		assert.Equal(t, hash, op.PreviousVersionHash)
+		assert.NoError(t, VerifyOperation(op, create))
+
+		// Replay of an old sequence number.
+		assert.Equal(t, ErrOperationReplay, errors.Cause(VerifyOperation(create, op)))
+		assert.Equal(t, ErrOperationReplay, errors.Cause(VerifyOperation(op, op)))
+		next, err := BuildUpdateOperation(op, *updated, newSigner)
+		require.NoError(t, err)
+		assert.NoError(t, VerifyOperation(next, op))
+		assert.Error(t, VerifyOperation(next, create))
+
+		// The new key is not in the previous version.
+		_, err = BuildUpdateOperation(create, *updated, newSigner)
+		assert.Error(t, err)
+
+		// A key of another DID.
+		_, err = BuildUpdateOperation(create, *updated, otherSigner)
+		assert.Error(t, err)
+		otherCreate, err := BuildCreateOperation(*otherDoc, otherSigner)
+		require.NoError(t, err)
+		_, err = BuildUpdateOperation(otherCreate, *updated, otherSigner)
+		assert.Error(t, err)
+
+		op, err = BuildUpdateOperation(create, *updated, signer)
+		require.NoError(t, err)
+		op.Kind = "rotate"
+		assert.Error(t, VerifyOperation(op, create))
+	})
+
+	t.Run("Previous version", func(t *testing.T) {
+		op, err := BuildUpdateOperation(create, *updated, signer)
+		require.NoError(t, err)
+
+		// The same sequence number, based on another version of the document.
+		resigned, err := did.UpdateDIDDoc(*doc, doc.UnsignedDIDDoc, signer, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		otherParent := *create
+		otherParent.Payload, err = json.Marshal(resigned)
+		require.NoError(t, err)
+		assert.Equal(t, ErrPreviousVersionMismatch, errors.Cause(VerifyOperation(op, &otherParent)))
+
+		op.PreviousVersionHash = ""
+		assert.Equal(t, ErrPreviousVersionMismatch, errors.Cause(VerifyOperation(op, create)))
+
+		op, err = BuildUpdateOperation(create, *updated, signer)
+		require.NoError(t, err)
+		op.Sequence = 2
+		assert.Error(t, VerifyOperation(op, create))
+	})
+
+	// The previous version lists a key of the controller DID, which may update the document.
+	t.Run("Controller", func(t *testing.T) {
+		controlled := doc.UnsignedDIDDoc
+		controlled.PublicKey = append([]did.KeyDef{}, doc.PublicKey...)
+		controllerKey := otherDoc.PublicKey[0]
+		controllerKey.ID = did.GenerateKeyID(doc.ID, "controller")
+		controllerKey.Controller = otherDoc.ID
+		controlled.PublicKey = append(controlled.PublicKey, controllerKey)
+		controlledDoc, err := did.UpdateDIDDoc(*doc, controlled, signer, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		previous, err := BuildUpdateOperation(create, *controlledDoc, signer)
+		require.NoError(t, err)
+		controllerSigner, err := proof.NewEd25519Signer(otherPriv, controllerKey.ID)
+		require.NoError(t, err)
+
+		op, err := BuildUpdateOperation(previous, *updated, controllerSigner)
+		require.NoError(t, err)
+		assert.NoError(t, VerifyOperation(op, previous))
+		_, err = BuildUpdateOperation(create, *updated, controllerSigner)
+		assert.Error(t, err)
+	})
+
+	t.Run("Deactivate", func(t *testing.T) {
+		update, err := BuildUpdateOperation(create, *updated, signer)
+		require.NoError(t, err)
+		deactivated, err := did.DeactivateDIDDocGeneric(signer, proof.JCSEdSignatureType, doc.ID)
+		require.NoError(t, err)
+		op, err := BuildDeactivateOperation(update, *deactivated, newSigner)
+		require.NoError(t, err)
+		assert.NoError(t, VerifyOperation(op, update))
+
+		// A deactivated DID cannot be changed.
+		_, err = BuildUpdateOperation(op, *updated, signer)
+		assert.Error(t, err)
+
+		// The payload of a deactivation must be deactivated, and of an update must not be.
+		_, err = BuildDeactivateOperation(create, *updated, signer)
+		assert.Error(t, err)
+		_, err = BuildUpdateOperation(create, *deactivated, signer)
+		assert.Error(t, err)
+		_, err = BuildCreateOperation(*deactivated, signer)
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyOperationChain(t *testing.T) {
+	doc, privKey := did.GenerateDIDDoc(proof.Ed25519KeyType, proof.JCSEdSignatureType)
+	signer, err := proof.NewEd25519Signer(privKey, doc.PublicKey[0].ID)
+	require.NoError(t, err)
+	update := func(t *testing.T, previous *Operation, service string) *Operation {
+		previousDoc, err := previous.Document()
+		require.NoError(t, err)
+		unsigned := previousDoc.UnsignedDIDDoc
+		unsigned.Service = []did.ServiceDef{{ID: doc.ID + "#" + service, Type: "Service", ServiceEndpoint: "https://" + service + ".example.com"}}
+		updated, err := did.UpdateDIDDoc(*previousDoc, unsigned, signer, proof.JCSEdSignatureType)
+		require.NoError(t, err)
+		op, err := BuildUpdateOperation(previous, *updated, signer)
+		require.NoError(t, err)
+		return op
+	}
+
+	create, err := BuildCreateOperation(*doc, signer)
+	require.NoError(t, err)
+	first := update(t, create, "first")
+	second := update(t, first, "second")
+
+	current, err := VerifyOperationChain([]Operation{*create, *first, *second})
+	require.NoError(t, err)
+	assert.Equal(t, "https://second.example.com", current.Service[0].ServiceEndpoint)
+
+	_, err = VerifyOperationChain(nil)
+	assert.Error(t, err)
+	_, err = VerifyOperationChain([]Operation{*first, *second})
+	assert.Error(t, err)
+	_, err = VerifyOperationChain([]Operation{*create, *second})
+	assert.Error(t, err)
+
+	// Two competing updates off the same parent.
+	competing := update(t, first, "competing")
+	assert.NoError(t, VerifyOperation(competing, first))
+	_, err = VerifyOperationChain([]Operation{*create, *first, *second, *competing})
+	assert.Equal(t, ErrOperationFork, errors.Cause(err))
+	_, err = VerifyOperationChain([]Operation{*create, *first, *competing, *second})
+	assert.Equal(t, ErrOperationFork, errors.Cause(err))
+
+	// A replayed operation.
+	_, err = VerifyOperationChain([]Operation{*create, *first, *first})
+	assert.Equal(t, ErrOperationFork, errors.Cause(err))
+
+	// Built on the competing branch: the chain must follow one history.
+	afterCompeting := update(t, competing, "after")
+	_, err = VerifyOperationChain([]Operation{*create, *first, *second, *afterCompeting})
+	assert.Equal(t, ErrPreviousVersionMismatch, errors.Cause(err))
+	_, err = VerifyOperationChain([]Operation{*create, *first, *competing, *afterCompeting})
+	assert.NoError(t, err)
+}
@@ -3,14 +3,15 @@ package revocation
 import (
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
 	"crypto/sha512"
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"golang.org/x/crypto/pbkdf2"
 
 	"github.com/workdaycredentials/ledger-common/ledger"
+	"github.com/workdaycredentials/ledger-common/util"
 	"github.com/workdaycredentials/ledger-common/util/canonical"
 )
 
@@ -58,11 +59,11 @@ func UnblindRevocation(bits []byte, credentialID string, r *ledger.Revocation) (
 // output = salt + nonce + ciphertext.
 func Blind(password string, payload []byte) ([]byte, error) {
 	salt := make([]byte, pbkdfSaltSize)
-	if _, err := rand.Read(salt); err != nil {
+	if _, err := io.ReadFull(util.RandReader(), salt); err != nil {
 		return nil, err
 	}
 	nonce := make([]byte, gcmStandardNonceSize)
-	if _, err := rand.Read(nonce); err != nil {
+	if _, err := io.ReadFull(util.RandReader(), nonce); err != nil {
 		return nil, err
 	}
 	key := pbkdf2.Key([]byte(password), salt, pbkdfIterations, aes256KeySize, sha512.New)
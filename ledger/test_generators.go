@@ -12,7 +12,7 @@ import (
 )
 
 func GenerateLedgerDIDDoc(keyType proof.KeyType, signatureType proof.SignatureType) (*DIDDoc, ed25519.PrivateKey) {
-	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	publicKey, privateKey, err := ed25519.GenerateKey(util.RandReader())
 	if err != nil {
 		panic(err)
 	}
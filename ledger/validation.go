@@ -7,7 +7,6 @@ import (
 	"reflect"
 	"regexp"
 
-	"github.com/mr-tron/base58"
 	"github.com/sirupsen/logrus"
 
 	"github.com/workdaycredentials/ledger-common/did"
@@ -81,22 +80,17 @@ func (d DIDDoc) ValidateDeactivated() error {
 		return fmt.Errorf("deactivated DID Doc cannot contain services")
 	}
 
+	if d.DIDDoc.DIDStatus != "" && d.DIDDoc.DIDStatus != did.StatusDeactivated {
+		return fmt.Errorf("deactivated DID Doc cannot have status: %s", d.DIDDoc.DIDStatus)
+	}
+
 	return nil
 }
 
-func ValidateDID(did string) error {
-	// The DID is generated as a base58 encoding of the lower 16 bytes of the public key
-	// and can be variable length, probably between 22-25 characters. For now we can say
-	// that it's at least 16 characters.
-	// TODO In the future we should base58 decode and ensure that the length is 16 bytes.
-	isValid, err := regexp.MatchString(`^did:work:\w{16}`, did)
-	if err != nil {
-		return err
-	}
-	if !isValid {
-		return fmt.Errorf("invalid DID")
-	}
-	return nil
+// ValidateDID checks that the DID is a well formed did:work DID, which is the only DID method
+// that can be registered on the ledger.
+func ValidateDID(id string) error {
+	return did.ValidateWorkDID(id)
 }
 
 func (d DIDDoc) ValidateMetadata() error {
@@ -130,7 +124,7 @@ func (d DIDDoc) ValidateProof() error {
 		return suite.Verify(&d, verifier)
 	}
 
-	decodedPublicKey, err := base58.Decode(keyDef.PublicKeyBase58)
+	decodedPublicKey, err := keyDef.GetDecodedPublicKey()
 	if err != nil {
 		return err
 	}
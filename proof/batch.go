@@ -0,0 +1,108 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// BatchItem is a provable whose proof is verified by VerifyBatch.
+type BatchItem struct {
+	Provable Provable
+	// GetVerifier returns the Verifier for the proof. It is called by the worker that verifies the
+	// item, so that expensive key lookups and checks also run in parallel. Returning an error
+	// skips signature verification for the item.
+	GetVerifier func() (Verifier, error)
+}
+
+// BatchItemError is the error of a batch item whose provable has an identifier (see IDOf). It
+// unwraps to the item's error.
+type BatchItemError struct {
+	ID  string
+	Err error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("document<%s>: %s", e.ID, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the item's error (see github.com/pkg/errors.Cause).
+func (e *BatchItemError) Cause() error {
+	return e.Err
+}
+
+// VerifyBatch verifies the proofs of the items concurrently, using up to workers goroutines, and
+// returns the result for each item in input order. If workers is not positive, the number of CPUs
+// is used. Once the context is cancelled, the remaining items are not verified and their result
+// is the context's error. The error of an item whose provable has an identifier is a
+// *BatchItemError with the identifier.
+func VerifyBatch(ctx context.Context, items []BatchItem, workers int) []error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	results := make([]error, len(items))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[i] = withBatchItemID(items[i].Provable, err)
+					continue
+				}
+				results[i] = withBatchItemID(items[i].Provable, verifyBatchItem(items[i]))
+			}
+		}()
+	}
+
+	for i := range items {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// withBatchItemID wraps the error in a *BatchItemError if the provable has an identifier.
+func withBatchItemID(provable Provable, err error) error {
+	if err == nil || provable == nil {
+		return err
+	}
+	if id, ok := IDOf(provable); ok {
+		return &BatchItemError{ID: id, Err: err}
+	}
+	return err
+}
+
+func verifyBatchItem(item BatchItem) error {
+	if item.Provable == nil || item.GetVerifier == nil {
+		return errors.New("batch item must have a provable and a verifier")
+	}
+	verifier, err := item.GetVerifier()
+	if err != nil {
+		return err
+	}
+	p := item.Provable.GetProof()
+	if p.IsEmpty() {
+		return errcode.New(errcode.MalformedProof, "proof cannot be empty")
+	}
+	suite, err := SignatureSuites().GetSuiteForProof(p)
+	if err != nil {
+		return err
+	}
+	return suite.Verify(item.Provable, verifier)
+}
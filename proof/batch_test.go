@@ -0,0 +1,72 @@
+package proof
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyBatch(t *testing.T) {
+	signer, err := NewEd25519Signer(privKey, "key-ref")
+	require.NoError(t, err)
+	suite, err := SignatureSuites().GetSuite(JCSEdSignatureType, V2)
+	require.NoError(t, err)
+	verifier := func() (Verifier, error) { return &Ed25519Verifier{PubKey: pubKey}, nil }
+
+	items := make([]BatchItem, 20)
+	for i := range items {
+		provable := &GenericProvable{JSONData: fmt.Sprintf("data-%d", i)}
+		require.NoError(t, suite.Sign(provable, signer))
+		items[i] = BatchItem{Provable: provable, GetVerifier: verifier}
+	}
+	lookupErr := errors.New("lookup failed")
+	items[3].GetVerifier = func() (Verifier, error) { return nil, lookupErr }
+	items[7].Provable.(*GenericProvable).JSONData = "tampered"
+	items[11].Provable.SetProof(nil)
+
+	t.Run("Results in input order", func(t *testing.T) {
+		for _, workers := range []int{0, 1, 4, 100} {
+			results := VerifyBatch(context.Background(), items, workers)
+			require.Len(t, results, len(items))
+			for i, err := range results {
+				switch i {
+				case 3:
+					assert.Equal(t, lookupErr, err)
+				case 7, 11:
+					assert.Error(t, err, i)
+				default:
+					assert.NoError(t, err, i)
+				}
+			}
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for _, err := range VerifyBatch(ctx, items, 2) {
+			assert.Equal(t, context.Canceled, err)
+		}
+	})
+
+	t.Run("Identified items", func(t *testing.T) {
+		doc, err := ParseDocument([]byte(`{"id":"urn:uuid:1","a":"x"}`))
+		require.NoError(t, err)
+		require.NoError(t, suite.Sign(doc, signer))
+		doc.properties["a"] = json.RawMessage(`"tampered"`)
+		results := VerifyBatch(context.Background(), []BatchItem{{Provable: doc, GetVerifier: verifier}}, 1)
+		itemErr, ok := results[0].(*BatchItemError)
+		require.True(t, ok, results[0])
+		assert.Equal(t, "urn:uuid:1", itemErr.ID)
+		assert.Error(t, itemErr.Err)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.Empty(t, VerifyBatch(context.Background(), nil, 0))
+	})
+}
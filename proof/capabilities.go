@@ -0,0 +1,176 @@
+package proof
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// Base58SignatureEncoding is the encoding of the signatureValue of proofs: base58 with the
+// Bitcoin alphabet, without a multibase prefix.
+const Base58SignatureEncoding = "base58btc"
+
+// ErrNoCommonSuite is returned by Negotiate when the peers have no signature suite in common.
+var ErrNoCommonSuite = errcode.New(errcode.UnsupportedSuite, "no common signature suite")
+
+// CapabilitySet describes the signature suites and proof features that a version of this library
+// supports, so that peers on different versions can agree on how to sign the documents that they
+// exchange (see Negotiate). It is serializable, so that it can be exchanged, e.g. in a discovery
+// endpoint. Features that a version does not know, such as proof purposes or proof sets, are
+// simply absent.
+type CapabilitySet struct {
+	// Suites lists the supported signature suites, in order of preference.
+	Suites []SuiteCapability `json:"suites"`
+	// SignatureEncodings lists the supported encodings of the proof's signatureValue, e.g.
+	// Base58SignatureEncoding.
+	SignatureEncodings []string `json:"signatureEncodings"`
+	// ProofFields lists the JSON properties of proofs that are understood.
+	ProofFields []string `json:"proofFields"`
+}
+
+// SuiteCapability describes a supported signature type.
+type SuiteCapability struct {
+	Type SignatureType `json:"type"`
+	// KeyType is the type of key that signs with the suite.
+	KeyType KeyType `json:"keyType"`
+	// Versions lists the supported proof model versions, highest first.
+	Versions []ModelVersion `json:"versions"`
+}
+
+// Agreed is the result of Negotiate: how to sign documents for a peer.
+type Agreed struct {
+	SignatureType     SignatureType `json:"signatureType"`
+	ProofVersion      ModelVersion  `json:"proofVersion"`
+	SignatureEncoding string        `json:"signatureEncoding"`
+	// ProofFields lists the proof properties that both peers understand.
+	ProofFields []string `json:"proofFields"`
+}
+
+// Capabilities returns the capabilities of this version of the library. The signature suites are
+// listed in the order of SignatureTypes.
+func Capabilities() CapabilitySet {
+	capabilities := CapabilitySet{
+		SignatureEncodings: []string{Base58SignatureEncoding},
+		ProofFields:        proofFields(),
+	}
+	factory := SignatureSuites()
+	for _, sigType := range SignatureTypes {
+		capability := SuiteCapability{Type: sigType}
+		for _, version := range []ModelVersion{V2, V1} {
+			if suite, err := factory.GetSuite(sigType, version); err == nil {
+				capability.KeyType = suiteKeyType(suite)
+				capability.Versions = append(capability.Versions, version)
+			}
+		}
+		capabilities.Suites = append(capabilities.Suites, capability)
+	}
+	return capabilities
+}
+
+// ForKeyType returns the capabilities restricted to the signature suites for the key type, e.g.
+// to negotiate a suite for a particular signer.
+func (c CapabilitySet) ForKeyType(keyType KeyType) CapabilitySet {
+	filtered := c
+	filtered.Suites = nil
+	for _, suite := range c.Suites {
+		if suite.KeyType == keyType {
+			filtered.Suites = append(filtered.Suites, suite)
+		}
+	}
+	return filtered
+}
+
+// Negotiate picks the best signature suite that both the local and the remote peer support for
+// signing: the first of the local suites, in order of preference, that the remote peer also
+// supports, with the highest proof model version that both support. For example, a peer that only
+// supports V1 proofs is sent V1 proofs. Returns ErrNoCommonSuite if the peers have no suite in
+// common.
+func Negotiate(local, remote CapabilitySet) (Agreed, error) {
+	encodings := intersect(local.SignatureEncodings, remote.SignatureEncodings)
+	if len(encodings) == 0 {
+		return Agreed{}, errcode.New(errcode.UnsupportedSuite, "no common signature encoding")
+	}
+	for _, localSuite := range local.Suites {
+		for _, remoteSuite := range remote.Suites {
+			if localSuite.Type != remoteSuite.Type {
+				continue
+			}
+			for _, version := range localSuite.Versions {
+				if containsVersion(remoteSuite.Versions, version) {
+					return Agreed{
+						SignatureType:     localSuite.Type,
+						ProofVersion:      version,
+						SignatureEncoding: encodings[0],
+						ProofFields:       intersect(local.ProofFields, remote.ProofFields),
+					}, nil
+				}
+			}
+		}
+	}
+	return Agreed{}, ErrNoCommonSuite
+}
+
+// Suite returns the signature suite for the agreed signature type and proof model version.
+func (a Agreed) Suite() (SignatureSuite, error) {
+	return SignatureSuites().GetSuite(a.SignatureType, a.ProofVersion)
+}
+
+// CredentialSuite returns the signature suite for Verifiable Credential proofs for the agreed
+// signature type and proof model version (see SignatureSuiteFactory.GetSuiteForCredentials).
+func (a Agreed) CredentialSuite() (SignatureSuite, error) {
+	return SignatureSuites().GetSuiteForCredentials(a.SignatureType, a.ProofVersion)
+}
+
+// Sign signs the provable with the agreed signature suite.
+func (a Agreed) Sign(provable Provable, signer Signer, opts ...ProofOption) error {
+	suite, err := a.Suite()
+	if err != nil {
+		return err
+	}
+	return SignWithOptions(suite, provable, signer, opts...)
+}
+
+// suiteKeyType returns the type of key that signs with the suite.
+func suiteKeyType(suite SignatureSuite) KeyType {
+	switch s := suite.(type) {
+	case *LDSignatureSuite:
+		return s.KeyType
+	case *compositeSignatureSuite:
+		return suiteKeyType(s.main)
+	}
+	return ""
+}
+
+// proofFields returns the JSON property names of Proof.
+func proofFields() []string {
+	var fields []string
+	t := reflect.TypeOf(Proof{})
+	for i := 0; i < t.NumField(); i++ {
+		fields = append(fields, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return fields
+}
+
+// intersect returns the elements of a that are also in b, in the order of a.
+func intersect(a, b []string) []string {
+	var common []string
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				common = append(common, x)
+				break
+			}
+		}
+	}
+	return common
+}
+
+func containsVersion(versions []ModelVersion, version ModelVersion) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package proof
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+func TestCapabilities(t *testing.T) {
+	capabilities := Capabilities()
+	assert.Equal(t, []SuiteCapability{
+		{Type: JCSEdSignatureType, KeyType: Ed25519KeyType, Versions: []ModelVersion{V2}},
+		{Type: WorkEdSignatureType, KeyType: Ed25519KeyType, Versions: []ModelVersion{V2, V1}},
+		{Type: Ed25519SignatureType, KeyType: Ed25519KeyType, Versions: []ModelVersion{V2, V1}},
+		{Type: EcdsaSecp256k1SignatureType, KeyType: EcdsaSecp256k1KeyType, Versions: []ModelVersion{V1}},
+	}, capabilities.Suites)
+	assert.Equal(t, []string{Base58SignatureEncoding}, capabilities.SignatureEncodings)
+	assert.Contains(t, capabilities.ProofFields, "signatureValue")
+	assert.Contains(t, capabilities.ProofFields, "creator")
+	assert.Contains(t, capabilities.ProofFields, "verificationMethod")
+
+	data, err := json.Marshal(capabilities)
+	require.NoError(t, err)
+	var decoded CapabilitySet
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, capabilities, decoded)
+
+	ed25519Capabilities := capabilities.ForKeyType(Ed25519KeyType)
+	assert.Equal(t, capabilities.Suites[:3], ed25519Capabilities.Suites)
+	secp256k1Capabilities := capabilities.ForKeyType(EcdsaSecp256k1KeyType)
+	assert.Equal(t, capabilities.Suites[3:], secp256k1Capabilities.Suites)
+	assert.Len(t, capabilities.Suites, 4)
+}
+
+func TestNegotiate(t *testing.T) {
+	local := Capabilities()
+
+	t.Run("Same version", func(t *testing.T) {
+		agreed, err := Negotiate(local, local)
+		require.NoError(t, err)
+		assert.Equal(t, Agreed{
+			SignatureType:     JCSEdSignatureType,
+			ProofVersion:      V2,
+			SignatureEncoding: Base58SignatureEncoding,
+			ProofFields:       local.ProofFields,
+		}, agreed)
+	})
+
+	// An old peer that predates V2 proofs, JCS and the nonce-less proof fields.
+	oldPeer := CapabilitySet{
+		Suites: []SuiteCapability{
+			{Type: WorkEdSignatureType, KeyType: Ed25519KeyType, Versions: []ModelVersion{V1}},
+			{Type: Ed25519SignatureType, KeyType: Ed25519KeyType, Versions: []ModelVersion{V1}},
+			{Type: EcdsaSecp256k1SignatureType, KeyType: EcdsaSecp256k1KeyType, Versions: []ModelVersion{V1}},
+		},
+		SignatureEncodings: []string{Base58SignatureEncoding},
+		ProofFields:        []string{"created", "creator", "nonce", "signatureValue", "type"},
+	}
+
+	t.Run("Old peer degrades to V1", func(t *testing.T) {
+		agreed, err := Negotiate(local, oldPeer)
+		require.NoError(t, err)
+		assert.Equal(t, Agreed{
+			SignatureType:     WorkEdSignatureType,
+			ProofVersion:      V1,
+			SignatureEncoding: Base58SignatureEncoding,
+			ProofFields:       []string{"created", "creator", "nonce", "signatureValue", "type"},
+		}, agreed)
+
+		// A local preference for the Ed25519 suite is honored.
+		preferEd25519 := local
+		preferEd25519.Suites = []SuiteCapability{local.Suites[2], local.Suites[1]}
+		agreed, err = Negotiate(preferEd25519, oldPeer)
+		require.NoError(t, err)
+		assert.Equal(t, Ed25519SignatureType, agreed.SignatureType)
+		assert.Equal(t, V1, agreed.ProofVersion)
+
+		// The old peer is able to verify the proof.
+		signer, err := NewEd25519Signer(privKey, "key-1")
+		require.NoError(t, err)
+		provable := &GenericProvable{JSONData: "data"}
+		require.NoError(t, agreed.Sign(provable, signer))
+		p := provable.GetProof()
+		assert.Equal(t, "key-1", p.Creator)
+		assert.Empty(t, p.VerificationMethod)
+		suite, err := agreed.Suite()
+		require.NoError(t, err)
+		assert.NoError(t, suite.Verify(provable, &Ed25519Verifier{PubKey: pubKey}))
+	})
+
+	t.Run("No common suite", func(t *testing.T) {
+		_, err := Negotiate(local.ForKeyType(EcdsaSecp256k1KeyType), local.ForKeyType(Ed25519KeyType))
+		assert.Equal(t, ErrNoCommonSuite, err)
+
+		v2Only := local.ForKeyType(Ed25519KeyType)
+		v2Only.Suites = v2Only.Suites[:1]
+		_, err = Negotiate(v2Only, oldPeer)
+		assert.Equal(t, ErrNoCommonSuite, err)
+	})
+
+	t.Run("No common signature encoding", func(t *testing.T) {
+		remote := local
+		remote.SignatureEncodings = []string{"base64url"}
+		_, err := Negotiate(local, remote)
+		assert.EqualError(t, err, "no common signature encoding")
+		assert.Equal(t, errcode.UnsupportedSuite, errcode.CodeOf(err))
+	})
+}
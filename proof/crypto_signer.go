@@ -0,0 +1,89 @@
+package proof
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// CryptoSigner adapts a crypto.Signer, such as a key held in an HSM or a remote key management
+// service, to a Signer. Ed25519, secp256k1 and P-256 keys are supported. Ed25519 and secp256k1 keys
+// produce the same signatures as Ed25519Signer and Secp256K1Signer respectively.
+// Intended to be constructed via the `NewCryptoSigner` method
+type CryptoSigner struct {
+	// The fully qualified key id (e.g. did:work:abcd#key-1)
+	KeyID   string
+	Signer  crypto.Signer
+	keyType KeyType
+}
+
+// NewCryptoSigner is used to build a signer from a crypto.Signer with validations. The key type is
+// determined from the signer's public key.
+func NewCryptoSigner(signer crypto.Signer, keyID string) (Signer, error) {
+	if signer == nil {
+		return nil, errors.New("must have valid signer")
+	}
+	if keyID == "" {
+		return nil, errors.New("must have valid key ID")
+	}
+	var keyType KeyType
+	switch pub := signer.Public().(type) {
+	case ed25519.PublicKey:
+		keyType = Ed25519KeyType
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case btcec.S256():
+			keyType = EcdsaSecp256k1KeyType
+		case elliptic.P256():
+			keyType = EcdsaSecp256r1KeyType
+		default:
+			return nil, errors.New("unsupported ECDSA curve")
+		}
+	default:
+		return nil, errors.New("unsupported public key type")
+	}
+	return &CryptoSigner{KeyID: keyID, Signer: signer, keyType: keyType}, nil
+}
+
+func (s *CryptoSigner) ID() string {
+	return s.KeyID
+}
+
+func (s *CryptoSigner) Type() KeyType {
+	return s.keyType
+}
+
+// Zeroize wipes the private key of the underlying crypto.Signer if it is an in-memory Ed25519 or
+// ECDSA key, or implements util.Zeroizer. Keys held elsewhere, e.g. in an HSM, are unaffected.
+func (s *CryptoSigner) Zeroize() {
+	switch key := s.Signer.(type) {
+	case ed25519.PrivateKey:
+		util.Zeroize(key)
+	case *ecdsa.PrivateKey:
+		util.ZeroizeBigInt(key.D)
+	case util.Zeroizer:
+		key.Zeroize()
+	}
+}
+
+// Public returns the public key of the underlying crypto.Signer.
+func (s *CryptoSigner) Public() crypto.PublicKey {
+	return s.Signer.Public()
+}
+
+// Sign signs the payload. Ed25519 signatures are over the payload itself, and secp256k1 and P-256
+// signatures are DER encoded over the SHA-256 digest of the payload.
+func (s *CryptoSigner) Sign(toSign []byte) ([]byte, error) {
+	if s.keyType == EcdsaSecp256k1KeyType || s.keyType == EcdsaSecp256r1KeyType {
+		hash := sha256.Sum256(toSign)
+		return s.Signer.Sign(util.RandReader(), hash[:], crypto.SHA256)
+	}
+	return s.Signer.Sign(util.RandReader(), toSign, crypto.Hash(0))
+}
@@ -0,0 +1,131 @@
+package proof
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestCryptoSigner(t *testing.T) {
+	message := []byte("payload")
+
+	t.Run("Ed25519", func(t *testing.T) {
+		publicKey, privateKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		signer, err := NewCryptoSigner(privateKey, "did:work:abc#key-1")
+		require.NoError(t, err)
+		assert.Equal(t, Ed25519KeyType, signer.Type())
+		assert.Equal(t, "did:work:abc#key-1", signer.ID())
+
+		signature, err := signer.Sign(message)
+		require.NoError(t, err)
+		valid, err := (&Ed25519Verifier{PubKey: publicKey}).Verify(message, signature)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("secp256k1", func(t *testing.T) {
+		privateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		signer, err := NewCryptoSigner(privateKey.ToECDSA(), "did:work:abc#key-1")
+		require.NoError(t, err)
+		assert.Equal(t, EcdsaSecp256k1KeyType, signer.Type())
+
+		signature, err := signer.Sign(message)
+		require.NoError(t, err)
+		verifier := &Secp256K1Verifier{PublicKey: privateKey.PubKey().SerializeCompressed()}
+		valid, err := verifier.Verify(message, signature)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = verifier.Verify([]byte("other payload"), signature)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("P-256", func(t *testing.T) {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		signer, err := NewCryptoSigner(privateKey, "did:work:abc#key-1")
+		require.NoError(t, err)
+		assert.Equal(t, EcdsaSecp256r1KeyType, signer.Type())
+
+		signature, err := signer.Sign(message)
+		require.NoError(t, err)
+		verifier := &P256Verifier{PublicKey: elliptic.MarshalCompressed(elliptic.P256(), privateKey.X, privateKey.Y)}
+		valid, err := verifier.Verify(message, signature)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = verifier.Verify([]byte("other payload"), signature)
+		require.NoError(t, err)
+		assert.False(t, valid)
+
+		_, err = (&P256Verifier{PublicKey: []byte{0x02, 0x01}}).Verify(message, signature)
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid signers", func(t *testing.T) {
+		_, privateKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		_, err = NewCryptoSigner(privateKey, "")
+		assert.Error(t, err)
+		_, err = NewCryptoSigner(nil, "did:work:abc#key-1")
+		assert.Error(t, err)
+
+		p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+		_, err = NewCryptoSigner(p384Key, "did:work:abc#key-1")
+		assert.Error(t, err)
+	})
+}
+
+func TestSignerZeroize(t *testing.T) {
+	newEd25519Key := func() ed25519.PrivateKey {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		return privateKey
+	}
+	newSecp256k1Key := func() *ecdsa.PrivateKey {
+		privateKey, err := ecdsa.GenerateKey(btcec.S256(), rand.Reader)
+		require.NoError(t, err)
+		return privateKey
+	}
+
+	ed25519Key := newEd25519Key()
+	ed25519Signer, err := NewEd25519Signer(ed25519Key, "key-1")
+	require.NoError(t, err)
+
+	secp256k1Key := newSecp256k1Key()
+	secp256k1Signer, err := NewSecp256k1Signer(secp256k1Key, "key-1")
+	require.NoError(t, err)
+
+	cryptoEd25519Key := newEd25519Key()
+	cryptoEd25519Signer, err := NewCryptoSigner(cryptoEd25519Key, "key-1")
+	require.NoError(t, err)
+
+	cryptoSecp256k1Key := newSecp256k1Key()
+	cryptoSecp256k1Signer, err := NewCryptoSigner(cryptoSecp256k1Key, "key-1")
+	require.NoError(t, err)
+
+	for _, signer := range []Signer{ed25519Signer, secp256k1Signer, cryptoEd25519Signer, cryptoSecp256k1Signer} {
+		zeroizer, ok := signer.(util.Zeroizer)
+		require.True(t, ok, "%T", signer)
+		zeroizer.Zeroize()
+		// Zeroizing twice is safe.
+		zeroizer.Zeroize()
+	}
+
+	assert.Equal(t, make(ed25519.PrivateKey, ed25519.PrivateKeySize), ed25519Key)
+	assert.Equal(t, make(ed25519.PrivateKey, ed25519.PrivateKeySize), cryptoEd25519Key)
+	assert.Equal(t, 0, secp256k1Key.D.Sign())
+	assert.Equal(t, 0, cryptoSecp256k1Key.D.Sign())
+}
@@ -0,0 +1,60 @@
+package proof
+
+import (
+	"encoding/json"
+)
+
+// Document is a JSON object with an embedded "proof" property, e.g. a DID Document or a
+// credential, that can be signed and verified without knowing its type. The other properties are
+// kept exactly as they are written.
+type Document struct {
+	properties map[string]json.RawMessage
+	proof      *Proof
+}
+
+// ParseDocument parses a JSON object, and its proof if it has one.
+func ParseDocument(data []byte) (*Document, error) {
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return nil, err
+	}
+	doc := &Document{properties: properties}
+	if raw, ok := properties["proof"]; ok {
+		p, err := DecodeProof(raw)
+		if err != nil {
+			return nil, err
+		}
+		doc.proof = p
+		delete(properties, "proof")
+	}
+	return doc, nil
+}
+
+func (d *Document) GetProof() *Proof {
+	return d.proof
+}
+
+func (d *Document) SetProof(p *Proof) {
+	d.proof = p
+}
+
+// GetID returns the "id" property of the document, or an empty string if it does not have a
+// string ID.
+func (d *Document) GetID() string {
+	var id string
+	if raw, ok := d.properties["id"]; ok {
+		_ = json.Unmarshal(raw, &id)
+	}
+	return id
+}
+
+func (d *Document) MarshalJSON() ([]byte, error) {
+	properties := make(map[string]interface{}, len(d.properties)+1)
+	for k, v := range d.properties {
+		properties[k] = v
+	}
+	if d.proof != nil {
+		properties["proof"] = d.proof
+	}
+	return json.Marshal(properties)
+}
@@ -0,0 +1,50 @@
+package proof
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+func TestDocument(t *testing.T) {
+	doc, err := ParseDocument([]byte(`{"b":1,"a":"x","proof":{"type":"JcsEd25519Signature2020","nonce":"n"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, &Proof{Type: JCSEdSignatureType, Nonce: "n"}, doc.GetProof())
+
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":"x","b":1,"proof":{"type":"JcsEd25519Signature2020","nonce":"n"}}`, string(data))
+
+	doc.SetProof(nil)
+	data, err = json.Marshal(doc)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":"x","b":1}`, string(data))
+
+	_, err = ParseDocument([]byte(`["not", "an", "object"]`))
+	assert.Error(t, err)
+	_, err = ParseDocument([]byte(`{"proof":"invalid"}`))
+	assert.Equal(t, errcode.MalformedProof, errcode.CodeOf(err))
+}
+
+func TestIDOf(t *testing.T) {
+	doc, err := ParseDocument([]byte(`{"id":"urn:uuid:1","a":"x"}`))
+	require.NoError(t, err)
+	id, ok := IDOf(doc)
+	assert.True(t, ok)
+	assert.Equal(t, "urn:uuid:1", id)
+
+	for _, data := range []string{`{"a":"x"}`, `{"id":""}`, `{"id":1}`} {
+		doc, err := ParseDocument([]byte(data))
+		require.NoError(t, err)
+		_, ok := IDOf(doc)
+		assert.False(t, ok, data)
+	}
+
+	// Provables without an identifier.
+	_, ok = IDOf(&GenericProvable{JSONData: "x"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,264 @@
+package proof
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+const (
+	// EnvelopeKeyAlgorithm is the key management algorithm of EncryptedEnvelope: the content key is
+	// wrapped for each recipient with AES-256-GCM, under a key derived with HKDF-SHA256 from an
+	// X25519 agreement (ECDH-ES) between an ephemeral key and the recipient's key agreement key.
+	EnvelopeKeyAlgorithm = "ECDH-ES+A256GCMKW"
+	// EnvelopeContentAlgorithm is the content encryption algorithm of EncryptedEnvelope.
+	EnvelopeContentAlgorithm = "A256GCM"
+)
+
+// envelopeKeySize is the size of the content key and of the key wrapping keys.
+const envelopeKeySize = 32
+
+var (
+	// ErrNotRecipient is returned by Decrypt when the key is not a recipient of the envelope.
+	ErrNotRecipient = errors.New("key is not a recipient of the envelope")
+	// ErrDecryptionFailed is returned by Decrypt when the envelope cannot be decrypted with the key,
+	// e.g. because the envelope has been tampered with.
+	ErrDecryptionFailed = errors.New("envelope cannot be decrypted")
+)
+
+// Recipient is a recipient of an EncryptedEnvelope.
+type Recipient struct {
+	// KeyRef is the reference of the recipient's key agreement key, e.g. the keyAgreement key of
+	// a DID Document.
+	KeyRef string
+	// PublicKey is the X25519 public key (see X25519KeyType).
+	PublicKey []byte
+}
+
+// EncryptedEnvelope is a payload encrypted to one or more recipients (see Encrypt), so that only
+// the controllers of the recipients' key agreement keys can read it. Binary values are base64url
+// encoded. The envelope is not signed: anyone who knows the recipients' public keys can make one,
+// so the payload should be signed (see SignMessage) if the sender must be authenticated.
+type EncryptedEnvelope struct {
+	Algorithm  string              `json:"alg"`
+	Encryption string              `json:"enc"`
+	Recipients []EnvelopeRecipient `json:"recipients"`
+	Nonce      string              `json:"iv"`
+	// Ciphertext is the encrypted payload followed by the authentication tag.
+	Ciphertext string `json:"ciphertext"`
+}
+
+// EnvelopeRecipient is the content key of an EncryptedEnvelope, wrapped for one recipient.
+type EnvelopeRecipient struct {
+	KeyRef string `json:"kid"`
+	// EphemeralKey is the sender's ephemeral X25519 public key for the recipient.
+	EphemeralKey string `json:"epk"`
+	Nonce        string `json:"iv"`
+	EncryptedKey string `json:"encryptedKey"`
+}
+
+// Encrypt encrypts the payload with a random content key, and wraps the content key for each
+// recipient with a new ephemeral key (see EnvelopeKeyAlgorithm). The recipients' key references
+// are bound to their wrapped keys, and the whole recipient list is authenticated as additional
+// data of the payload, so that recipients cannot be swapped, added or removed.
+func Encrypt(payload []byte, recipients ...Recipient) (*EncryptedEnvelope, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("envelope must have a recipient")
+	}
+	cek, err := randomBytes(envelopeKeySize)
+	if err != nil {
+		return nil, err
+	}
+	env := &EncryptedEnvelope{
+		Algorithm:  EnvelopeKeyAlgorithm,
+		Encryption: EnvelopeContentAlgorithm,
+		Recipients: make([]EnvelopeRecipient, 0, len(recipients)),
+	}
+	seen := make(map[string]bool, len(recipients))
+	for _, recipient := range recipients {
+		if recipient.KeyRef == "" || seen[recipient.KeyRef] {
+			return nil, errors.Errorf("envelope recipient key<%s> must be set and unique", recipient.KeyRef)
+		}
+		seen[recipient.KeyRef] = true
+		wrapped, err := wrapContentKey(cek, recipient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "recipient<%s>", recipient.KeyRef)
+		}
+		env.Recipients = append(env.Recipients, *wrapped)
+	}
+
+	nonce, ciphertext, err := sealAESGCM(cek, payload, env.additionalData())
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = util.B64URLEncode(nonce)
+	env.Ciphertext = util.B64URLEncode(ciphertext)
+	return env, nil
+}
+
+// Decrypt decrypts the envelope with the private X25519 key of the recipient's key reference.
+// Returns ErrNotRecipient if the key reference is not a recipient, and ErrDecryptionFailed if
+// the envelope does not decrypt with the key, e.g. because it has been tampered with.
+func Decrypt(env *EncryptedEnvelope, keyRef string, privateKey []byte) ([]byte, error) {
+	if env.Algorithm != EnvelopeKeyAlgorithm || env.Encryption != EnvelopeContentAlgorithm {
+		return nil, errors.Errorf("unsupported envelope algorithms<%s, %s>", env.Algorithm, env.Encryption)
+	}
+	var recipient *EnvelopeRecipient
+	for i := range env.Recipients {
+		if env.Recipients[i].KeyRef == keyRef {
+			recipient = &env.Recipients[i]
+			break
+		}
+	}
+	if recipient == nil {
+		return nil, errors.Wrapf(ErrNotRecipient, "key<%s>", keyRef)
+	}
+	cek, err := unwrapContentKey(*recipient, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := util.B64URLDecode(env.Nonce)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid envelope nonce")
+	}
+	ciphertext, err := util.B64URLDecode(env.Ciphertext)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid envelope ciphertext")
+	}
+	return openAESGCM(cek, nonce, ciphertext, env.additionalData())
+}
+
+// additionalData returns the authenticated data of the payload: the algorithms and the recipients
+// of the envelope, in order.
+func (e EncryptedEnvelope) additionalData() []byte {
+	data := []byte(e.Algorithm + "." + e.Encryption)
+	for _, r := range e.Recipients {
+		data = append(data, '.')
+		data = append(data, util.B64URLEncode([]byte(r.KeyRef))+"~"+r.EphemeralKey+"~"+r.Nonce+"~"+r.EncryptedKey...)
+	}
+	return data
+}
+
+// wrapContentKey wraps the content key for the recipient with a new ephemeral key.
+func wrapContentKey(cek []byte, recipient Recipient) (*EnvelopeRecipient, error) {
+	ephemeralPrivate, err := randomBytes(curve25519.ScalarSize)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPublic, err := curve25519.X25519(ephemeralPrivate, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := keyWrappingKey(ephemeralPrivate, recipient.PublicKey, ephemeralPublic, recipient.PublicKey, recipient.KeyRef)
+	if err != nil {
+		return nil, err
+	}
+	nonce, encryptedKey, err := sealAESGCM(kek, cek, []byte(recipient.KeyRef))
+	if err != nil {
+		return nil, err
+	}
+	return &EnvelopeRecipient{
+		KeyRef:       recipient.KeyRef,
+		EphemeralKey: util.B64URLEncode(ephemeralPublic),
+		Nonce:        util.B64URLEncode(nonce),
+		EncryptedKey: util.B64URLEncode(encryptedKey),
+	}, nil
+}
+
+// unwrapContentKey unwraps the recipient's content key with the recipient's private key.
+func unwrapContentKey(recipient EnvelopeRecipient, privateKey []byte) ([]byte, error) {
+	ephemeralPublic, err := util.B64URLDecode(recipient.EphemeralKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid envelope ephemeral key")
+	}
+	nonce, err := util.B64URLDecode(recipient.Nonce)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid envelope key nonce")
+	}
+	encryptedKey, err := util.B64URLDecode(recipient.EncryptedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid envelope encrypted key")
+	}
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid X25519 private key")
+	}
+	kek, err := keyWrappingKey(privateKey, ephemeralPublic, ephemeralPublic, publicKey, recipient.KeyRef)
+	if err != nil {
+		return nil, err
+	}
+	return openAESGCM(kek, nonce, encryptedKey, []byte(recipient.KeyRef))
+}
+
+// keyWrappingKey derives the key wrapping key of a recipient from the X25519 agreement of the
+// private key and the peer's public key. The ephemeral and recipient public keys are the HKDF
+// salt, and the algorithm and the recipient's key reference are the HKDF info, so that the key is
+// bound to the recipient.
+func keyWrappingKey(privateKey, peerPublic, ephemeralPublic, recipientPublic []byte, keyRef string) ([]byte, error) {
+	if len(peerPublic) != curve25519.PointSize {
+		return nil, errors.Errorf("X25519 public key must have %d bytes", curve25519.PointSize)
+	}
+	shared, err := curve25519.X25519(privateKey, peerPublic)
+	if err != nil {
+		return nil, errors.Wrap(err, "X25519 key agreement failed")
+	}
+	salt := append(append([]byte{}, ephemeralPublic...), recipientPublic...)
+	info := []byte(EnvelopeKeyAlgorithm + "." + keyRef)
+	kek := make([]byte, envelopeKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, info), kek); err != nil {
+		return nil, err
+	}
+	return kek, nil
+}
+
+// sealAESGCM encrypts the plaintext with AES-GCM under a random nonce.
+func sealAESGCM(key, plaintext, additionalData []byte) (nonce, ciphertext []byte, err error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if nonce, err = randomBytes(aead.NonceSize()); err != nil {
+		return nil, nil, err
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, additionalData), nil
+}
+
+// openAESGCM decrypts the ciphertext with AES-GCM. Returns ErrDecryptionFailed if the ciphertext
+// or the additional data are not authentic.
+func openAESGCM(key, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(util.RandReader(), b); err != nil {
+		return nil, errors.Wrap(err, "failed to generate random bytes")
+	}
+	return b, nil
+}
@@ -0,0 +1,170 @@
+package proof
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/curve25519"
+
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// X25519 keys of RFC 7748 Section 6.1.
+var (
+	alicePrivate = mustHex("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	alicePublic  = mustHex("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")
+	bobPrivate   = mustHex("5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+	bobPublic    = mustHex("de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f")
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// hashReader is a deterministic source of randomness: the SHA-256 of a counter.
+type hashReader struct {
+	counter byte
+	buf     []byte
+}
+
+func (r *hashReader) Read(p []byte) (int, error) {
+	for len(r.buf) < len(p) {
+		digest := sha256.Sum256([]byte{r.counter})
+		r.counter++
+		r.buf = append(r.buf, digest[:]...)
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func TestEncryptedEnvelope(t *testing.T) {
+	payload := []byte(`{"salary":123456}`)
+	alice := Recipient{KeyRef: "did:work:alice#key-x25519", PublicKey: alicePublic}
+	bob := Recipient{KeyRef: "did:work:bob#key-x25519", PublicKey: bobPublic}
+
+	t.Run("Key agreement", func(t *testing.T) {
+		shared := mustHex("4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742")
+		public, err := curve25519.X25519(alicePrivate, curve25519.Basepoint)
+		require.NoError(t, err)
+		assert.Equal(t, alicePublic, public)
+		agreed, err := curve25519.X25519(alicePrivate, bobPublic)
+		require.NoError(t, err)
+		assert.Equal(t, shared, agreed)
+		agreed, err = curve25519.X25519(bobPrivate, alicePublic)
+		require.NoError(t, err)
+		assert.Equal(t, shared, agreed)
+	})
+
+	t.Run("Known answer", func(t *testing.T) {
+		restore := util.SetRandReader(&hashReader{})
+		env, err := Encrypt(payload, bob)
+		restore()
+		require.NoError(t, err)
+		data, err := json.Marshal(env)
+		require.NoError(t, err)
+		assert.JSONEq(t, knownEnvelope, string(data))
+
+		var known EncryptedEnvelope
+		require.NoError(t, json.Unmarshal([]byte(knownEnvelope), &known))
+		decrypted, err := Decrypt(&known, bob.KeyRef, bobPrivate)
+		require.NoError(t, err)
+		assert.Equal(t, payload, decrypted)
+	})
+
+	t.Run("Multiple recipients", func(t *testing.T) {
+		env, err := Encrypt(payload, alice, bob)
+		require.NoError(t, err)
+		require.Len(t, env.Recipients, 2)
+		assert.NotEqual(t, env.Recipients[0].EphemeralKey, env.Recipients[1].EphemeralKey)
+
+		for _, r := range []struct {
+			keyRef     string
+			privateKey []byte
+		}{{alice.KeyRef, alicePrivate}, {bob.KeyRef, bobPrivate}} {
+			decrypted, err := Decrypt(env, r.keyRef, r.privateKey)
+			require.NoError(t, err, r.keyRef)
+			assert.Equal(t, payload, decrypted)
+		}
+
+		// The wrong key for a recipient.
+		_, err = Decrypt(env, alice.KeyRef, bobPrivate)
+		assert.Equal(t, ErrDecryptionFailed, errors.Cause(err))
+		_, err = Decrypt(env, "did:work:carol#key-x25519", bobPrivate)
+		assert.Equal(t, ErrNotRecipient, errors.Cause(err))
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		encrypt := func() *EncryptedEnvelope {
+			env, err := Encrypt(payload, alice, bob)
+			require.NoError(t, err)
+			return env
+		}
+
+		// The recipient key reference is bound to the wrapped key.
+		env := encrypt()
+		env.Recipients[1].KeyRef = "did:work:mallory#key-x25519"
+		_, err := Decrypt(env, env.Recipients[1].KeyRef, bobPrivate)
+		assert.Equal(t, ErrDecryptionFailed, errors.Cause(err))
+
+		// The recipient list is authenticated with the payload: another recipient cannot be
+		// removed, or have its key reference changed.
+		env = encrypt()
+		env.Recipients = env.Recipients[1:]
+		_, err = Decrypt(env, bob.KeyRef, bobPrivate)
+		assert.Equal(t, ErrDecryptionFailed, errors.Cause(err))
+
+		env = encrypt()
+		env.Recipients[0].KeyRef = "did:work:mallory#key-x25519"
+		_, err = Decrypt(env, bob.KeyRef, bobPrivate)
+		assert.Equal(t, ErrDecryptionFailed, errors.Cause(err))
+
+		env = encrypt()
+		ciphertext, err := util.B64URLDecode(env.Ciphertext)
+		require.NoError(t, err)
+		ciphertext[0] ^= 1
+		env.Ciphertext = util.B64URLEncode(ciphertext)
+		_, err = Decrypt(env, bob.KeyRef, bobPrivate)
+		assert.Equal(t, ErrDecryptionFailed, errors.Cause(err))
+
+		env = encrypt()
+		env.Encryption = "A128GCM"
+		_, err = Decrypt(env, bob.KeyRef, bobPrivate)
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid recipients", func(t *testing.T) {
+		_, err := Encrypt(payload)
+		assert.Error(t, err)
+		_, err = Encrypt(payload, bob, bob)
+		assert.Error(t, err)
+		_, err = Encrypt(payload, Recipient{KeyRef: bob.KeyRef, PublicKey: bobPublic[:16]})
+		assert.Error(t, err)
+	})
+}
+
+// knownEnvelope is the envelope of the payload for Bob, made with randomness from hashReader. It
+// must never be updated: envelopes made with it must stay decryptable.
+const knownEnvelope = `{
+  "alg": "ECDH-ES+A256GCMKW",
+  "enc": "A256GCM",
+  "recipients": [
+    {
+      "kid": "did:work:bob#key-x25519",
+      "epk": "H77Pzt5WNqQGYAvjq4uN2MCH-IUJEO8Ivl5kulzl9QM",
+      "iv": "28G0yQD_5I1XW12l",
+      "encryptedKey": "CXHYWE-l7vAKBJTfL4ar8antmRx9VJG6yjV-c0kTram4XBU9BQNawzo2-3axwuT7"
+    }
+  ],
+  "iv": "xjgEASX2XbD-PiRJ",
+  "ciphertext": "payB-QGj_9Vgz3MMVp-AqQttySrtJWkGm353kp7a2STp"
+}`
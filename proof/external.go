@@ -0,0 +1,235 @@
+package proof
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// Ed25519Signature2018Type is the Linked Data signature suite used by Hyperledger Aries (e.g.
+// ACA-Py): an Ed25519 detached JWS over the URDNA2015 canonical form of the document and proof.
+// It is only verified through VerifyExternal, never used for signing.
+// See https://w3c-ccg.github.io/lds-ed25519-2018
+const Ed25519Signature2018Type SignatureType = "Ed25519Signature2018"
+
+// ProofStyle is the way an externally produced document carries its proof (see DetectProofStyle).
+type ProofStyle string
+
+const (
+	// NativeProofStyle is a proof with a signatureValue, as made by the suites of SignatureSuites.
+	NativeProofStyle ProofStyle = "native"
+	// DetachedJWSProofStyle is a Linked Data proof with a detached JWS (RFC 7797) in its jws
+	// property, as made by Aries stacks.
+	DetachedJWSProofStyle ProofStyle = "detachedJWS"
+)
+
+var (
+	// ErrUnsupportedProofStyle is returned by VerifyExternal for external proofs that are out of
+	// scope. The only styles in scope are NativeProofStyle and Ed25519Signature2018 proofs in
+	// DetachedJWSProofStyle. The error message names the style that was found, e.g. proofValue
+	// proofs (Ed25519Signature2020, BbsBlsSignature2020, Data Integrity), JWT credentials,
+	// documents with several proofs, and JWS proofs of other suites (JsonWebSignature2020,
+	// EcdsaSecp256k1Signature2019) or with an attached payload.
+	ErrUnsupportedProofStyle = errcode.New(errcode.UnsupportedSuite, "unsupported external proof style")
+
+	// ErrRDFCanonicalizerRequired is returned by VerifyExternal for Linked Data proofs when no
+	// RDFCanonicalizer was given (see WithRDFCanonicalizer). This module does not implement
+	// JSON-LD expansion or URDNA2015 itself, so Aries proofs cannot be verified without one.
+	ErrRDFCanonicalizerRequired = errcode.New(errcode.UnsupportedSuite, "RDF canonicalizer required for Linked Data proofs")
+)
+
+// RDFCanonicalizer converts a JSON-LD document to the canonical N-Quads of its RDF dataset using
+// URDNA2015, e.g. by wrapping an established JSON-LD processor, such as json-gold, with a document
+// loader for the credential and security contexts. It is needed to verify Linked Data proofs made
+// by other stacks. VerifyExternal trusts the canonicalizer to conform to URDNA2015: interop with
+// ACA-Py depends on it and is not tested in this module.
+type RDFCanonicalizer interface {
+	CanonicalizeRDF(document []byte) ([]byte, error)
+}
+
+// WithRDFCanonicalizer sets the canonicalizer that VerifyExternal uses for Linked Data proofs.
+func WithRDFCanonicalizer(canonicalizer RDFCanonicalizer) VerifyOption {
+	return func(o *verifyOptions) {
+		o.rdfCanonicalizer = canonicalizer
+	}
+}
+
+// detachedJWSHeader is the protected header of a detached JWS with an unencoded payload
+// (RFC 7797).
+type detachedJWSHeader struct {
+	JWSHeader
+	B64  *bool    `json:"b64,omitempty"`
+	Crit []string `json:"crit,omitempty"`
+}
+
+// DetectProofStyle returns the style of the proof of a JSON document. ErrUnsupportedProofStyle is
+// returned for any style that VerifyExternal cannot verify.
+func DetectProofStyle(data []byte) (ProofStyle, error) {
+	_, p, err := splitExternalProof(data)
+	if err != nil {
+		return "", err
+	}
+	return proofStyle(p)
+}
+
+// VerifyExternal verifies a JSON document signed by another stack, using the resolver to find the
+// key referenced by the proof's verification method. Native proofs are verified as by
+// VerifyWithResolver. Ed25519Signature2018 proofs with a detached JWS, as made by Aries stacks,
+// need an RDFCanonicalizer (see WithRDFCanonicalizer).
+//
+// DID Key verification methods are served without resolution when the resolver has a fast path,
+// e.g. did.AsVerifierResolver; other methods, such as did:sov, are left to the resolver. As with
+// VerifyWithResolver, the proof purpose is not checked.
+func VerifyExternal(ctx context.Context, data []byte, resolver VerifierResolver, opts ...VerifyOption) error {
+	properties, p, err := splitExternalProof(data)
+	if err != nil {
+		return err
+	}
+	style, err := proofStyle(p)
+	if err != nil {
+		return err
+	}
+	if style == NativeProofStyle {
+		doc, err := ParseDocument(data)
+		if err != nil {
+			return errcode.Wrap(errcode.MalformedProof, err, "invalid document")
+		}
+		return VerifyWithResolver(ctx, doc, resolver, opts...)
+	}
+
+	var options verifyOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.rdfCanonicalizer == nil {
+		return errors.Wrapf(ErrRDFCanonicalizerRequired, "proof type<%s>", Ed25519Signature2018Type)
+	}
+	return verifyDetachedJWS(ctx, properties, p, resolver, options)
+}
+
+// splitExternalProof parses a JSON document into its properties, without the proof, and the
+// properties of its proof.
+func splitExternalProof(data []byte) (properties, p map[string]json.RawMessage, err error) {
+	var token string
+	if json.Unmarshal(data, &token) == nil {
+		return nil, nil, errors.Wrap(ErrUnsupportedProofStyle, "JWT credentials are out of scope")
+	}
+	if err := util.UnmarshalUseNumber(data, &properties); err != nil {
+		return nil, nil, errcode.Wrap(errcode.MalformedProof, err, "invalid document")
+	}
+	raw, ok := properties["proof"]
+	if !ok {
+		return nil, nil, errcode.New(errcode.MalformedProof, "proof cannot be empty")
+	}
+	delete(properties, "proof")
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		return nil, nil, errors.Wrap(ErrUnsupportedProofStyle, "documents with several proofs are out of scope")
+	}
+	if err := util.UnmarshalUseNumber(raw, &p); err != nil {
+		return nil, nil, errcode.Wrap(errcode.MalformedProof, err, "invalid proof")
+	}
+	return properties, p, nil
+}
+
+// proofStyle returns the style of the proof, given its properties.
+func proofStyle(p map[string]json.RawMessage) (ProofStyle, error) {
+	var proofType SignatureType
+	_ = json.Unmarshal(p["type"], &proofType)
+	switch {
+	case p["proofValue"] != nil:
+		return "", errors.Wrapf(ErrUnsupportedProofStyle, "proofValue proofs of type<%s> are out of scope", proofType)
+	case p["jws"] != nil:
+		if proofType != Ed25519Signature2018Type {
+			return "", errors.Wrapf(ErrUnsupportedProofStyle, "JWS proofs of type<%s> are out of scope", proofType)
+		}
+		return DetachedJWSProofStyle, nil
+	case p["signatureValue"] != nil:
+		return NativeProofStyle, nil
+	}
+	return "", errors.Wrapf(ErrUnsupportedProofStyle, "proof of type<%s> has no signature", proofType)
+}
+
+// verifyDetachedJWS verifies an Ed25519Signature2018 proof. The JWS payload is the SHA-256 digest
+// of the canonical proof options, i.e. the proof without its jws and with the document's
+// @context, followed by the SHA-256 digest of the canonical document without its proof.
+func verifyDetachedJWS(ctx context.Context, properties, p map[string]json.RawMessage, resolver VerifierResolver, options verifyOptions) error {
+	var token, verificationMethod string
+	if err := json.Unmarshal(p["jws"], &token); err != nil {
+		return errcode.Wrap(errcode.MalformedProof, err, "invalid proof jws")
+	}
+	_ = json.Unmarshal(p["verificationMethod"], &verificationMethod)
+	if verificationMethod == "" {
+		return errcode.New(errcode.MalformedProof, "proof does not have a verification method")
+	}
+	jws, err := parseDetachedJWS(token)
+	if err != nil {
+		return err
+	}
+
+	proofOptions := make(map[string]json.RawMessage, len(p))
+	for k, v := range p {
+		proofOptions[k] = v
+	}
+	delete(proofOptions, "jws")
+	if ldContext, ok := properties["@context"]; ok {
+		proofOptions["@context"] = ldContext
+	}
+	var payload []byte
+	for _, v := range []map[string]json.RawMessage{proofOptions, properties} {
+		document, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		canonical, err := options.rdfCanonicalizer.CanonicalizeRDF(document)
+		if err != nil {
+			return errcode.Wrap(errcode.MalformedProof, err, "unable to canonicalize document")
+		}
+		digest := sha256.Sum256(canonical)
+		payload = append(payload, digest[:]...)
+	}
+	jws.signingInput += string(payload)
+
+	if options.historical {
+		ctx = context.WithValue(ctx, historicalKey{}, true)
+	}
+	verifier, err := resolver.ResolveVerifier(ctx, verificationMethod)
+	if err != nil {
+		return errcode.Wrapf(errcode.ResolutionFailed, err, "unable to resolve verification method<%s>", verificationMethod)
+	}
+	return jws.Verify(verifier)
+}
+
+// parseDetachedJWS parses a detached JWS with an unencoded payload, i.e. "<header>..<signature>"
+// where the header sets b64 to false and marks it as critical. The returned JWS's signing input
+// lacks the payload.
+func parseDetachedJWS(token string) (*JWS, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errcode.New(errcode.MalformedProof, "JWS must have three parts")
+	}
+	if parts[1] != "" {
+		return nil, errors.Wrap(ErrUnsupportedProofStyle, "JWS proofs with an attached payload are out of scope")
+	}
+	headerBytes, err := util.B64URLDecode(parts[0])
+	if err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid JWS header")
+	}
+	signature, err := util.B64URLDecode(parts[2])
+	if err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid JWS signature")
+	}
+	var header detachedJWSHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid JWS header")
+	}
+	if header.B64 == nil || *header.B64 || len(header.Crit) != 1 || header.Crit[0] != "b64" {
+		return nil, errors.Wrap(ErrUnsupportedProofStyle, "JWS proofs must have an unencoded payload (b64 false, crit [b64])")
+	}
+	return &JWS{Header: header.JWSHeader, signingInput: parts[0] + ".", signature: signature}, nil
+}
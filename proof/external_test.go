@@ -0,0 +1,157 @@
+package proof
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// jcsCanonicalizer stands in for a URDNA2015 canonicalizer: the routing and JWS handling of
+// VerifyExternal do not depend on the canonical form.
+type jcsCanonicalizer struct{}
+
+func (jcsCanonicalizer) CanonicalizeRDF(document []byte) ([]byte, error) {
+	return util.CanonicalMarshalRaw(document)
+}
+
+type keyResolver map[string]Verifier
+
+func (r keyResolver) ResolveVerifier(_ context.Context, keyRef string) (Verifier, error) {
+	if verifier, ok := r[keyRef]; ok {
+		return verifier, nil
+	}
+	return nil, errcode.Errorf(errcode.KeyNotFound, "key<%s> not found", keyRef)
+}
+
+// signDetachedJWS adds an Ed25519Signature2018 proof to the document the way Aries stacks do,
+// with jcsCanonicalizer in place of URDNA2015.
+func signDetachedJWS(t *testing.T, document map[string]interface{}, signer Signer) []byte {
+	proofOptions := map[string]interface{}{
+		"@context":           document["@context"],
+		"type":               Ed25519Signature2018Type,
+		"created":            "2021-05-07T08:50:17Z",
+		"proofPurpose":       "assertionMethod",
+		"verificationMethod": signer.ID(),
+	}
+	var payload []byte
+	for _, v := range []interface{}{proofOptions, document} {
+		canonical, err := util.CanonicalMarshal(v)
+		require.NoError(t, err)
+		digest := sha256.Sum256(canonical)
+		payload = append(payload, digest[:]...)
+	}
+	header := util.B64URLEncode([]byte(`{"alg":"EdDSA","b64":false,"crit":["b64"]}`))
+	signature, err := signer.Sign(append([]byte(header+"."), payload...))
+	require.NoError(t, err)
+
+	delete(proofOptions, "@context")
+	proofOptions["jws"] = header + ".." + util.B64URLEncode(signature)
+	document["proof"] = proofOptions
+	data, err := json.Marshal(document)
+	require.NoError(t, err)
+	return data
+}
+
+func ariesCredential() map[string]interface{} {
+	return map[string]interface{}{
+		"@context":          []string{"https://www.w3.org/2018/credentials/v1"},
+		"type":              []string{"VerifiableCredential"},
+		"issuer":            "did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp",
+		"issuanceDate":      "2021-05-07T08:50:17Z",
+		"credentialSubject": map[string]interface{}{"id": "did:example:holder", "degree": "BSc"},
+	}
+}
+
+func TestVerifyExternal(t *testing.T) {
+	ctx := context.Background()
+	keyRef := "did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp#z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp"
+	signer, err := NewEd25519Signer(privKey, keyRef)
+	require.NoError(t, err)
+	resolver := keyResolver{keyRef: &Ed25519Verifier{PubKey: pubKey}}
+	canonicalizer := WithRDFCanonicalizer(jcsCanonicalizer{})
+
+	t.Run("detached JWS", func(t *testing.T) {
+		signed := signDetachedJWS(t, ariesCredential(), signer)
+		style, err := DetectProofStyle(signed)
+		require.NoError(t, err)
+		assert.Equal(t, DetachedJWSProofStyle, style)
+		assert.NoError(t, VerifyExternal(ctx, signed, resolver, canonicalizer))
+
+		err = VerifyExternal(ctx, signed, resolver)
+		assert.True(t, errors.Is(err, ErrRDFCanonicalizerRequired))
+		assert.Equal(t, errcode.UnsupportedSuite, errcode.CodeOf(err))
+
+		err = VerifyExternal(ctx, signed, keyResolver{}, canonicalizer)
+		assert.Equal(t, errcode.KeyNotFound, errcode.CodeOf(err))
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		credential := ariesCredential()
+		signed := signDetachedJWS(t, credential, signer)
+		var tampered map[string]interface{}
+		require.NoError(t, json.Unmarshal(signed, &tampered))
+		tampered["credentialSubject"].(map[string]interface{})["degree"] = "PhD"
+		data, err := json.Marshal(tampered)
+		require.NoError(t, err)
+		err = VerifyExternal(ctx, data, resolver, canonicalizer)
+		assert.Equal(t, errcode.SignatureInvalid, errcode.CodeOf(err))
+
+		require.NoError(t, json.Unmarshal(signed, &tampered))
+		tampered["proof"].(map[string]interface{})["created"] = "2022-01-01T00:00:00Z"
+		data, err = json.Marshal(tampered)
+		require.NoError(t, err)
+		err = VerifyExternal(ctx, data, resolver, canonicalizer)
+		assert.Equal(t, errcode.SignatureInvalid, errcode.CodeOf(err))
+	})
+
+	t.Run("native", func(t *testing.T) {
+		suite, err := SignatureSuites().GetSuite(JCSEdSignatureType, V2)
+		require.NoError(t, err)
+		doc, err := ParseDocument([]byte(`{"id":"doc-1","amount":12345678901234567890}`))
+		require.NoError(t, err)
+		require.NoError(t, suite.Sign(doc, signer))
+		signed, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		style, err := DetectProofStyle(signed)
+		require.NoError(t, err)
+		assert.Equal(t, NativeProofStyle, style)
+		assert.NoError(t, VerifyExternal(ctx, signed, resolver))
+	})
+}
+
+// TestVerifyExternalOutOfScope checks that every proof style that is out of scope is refused
+// with ErrUnsupportedProofStyle, before any key is resolved.
+func TestVerifyExternalOutOfScope(t *testing.T) {
+	header := util.B64URLEncode([]byte(`{"alg":"EdDSA","b64":false,"crit":["b64"]}`))
+	encodedHeader := util.B64URLEncode([]byte(`{"alg":"EdDSA"}`))
+	for _, test := range []struct {
+		name string
+		doc  string
+	}{
+		{name: "JWT", doc: `"eyJhbGciOiJFZERTQSJ9.e30.c2ln"`},
+		{name: "several proofs", doc: `{"proof":[{"type":"Ed25519Signature2018","jws":"a..b"}]}`},
+		{name: "proofValue", doc: `{"proof":{"type":"Ed25519Signature2020","proofValue":"z3FXQ"}}`},
+		{name: "BBS+", doc: `{"proof":{"type":"BbsBlsSignature2020","proofValue":"kTTbA"}}`},
+		{name: "JsonWebSignature2020", doc: `{"proof":{"type":"JsonWebSignature2020","jws":"` + header + `..c2ln"}}`},
+		{name: "attached payload", doc: `{"proof":{"type":"Ed25519Signature2018","verificationMethod":"did:sov:abc#key-1","jws":"` + header + `.e30.c2ln"}}`},
+		{name: "encoded payload", doc: `{"proof":{"type":"Ed25519Signature2018","verificationMethod":"did:sov:abc#key-1","jws":"` + encodedHeader + `..c2ln"}}`},
+		{name: "no signature", doc: `{"proof":{"type":"Ed25519Signature2018"}}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyExternal(context.Background(), []byte(test.doc), errorResolver{err: assert.AnError}, WithRDFCanonicalizer(jcsCanonicalizer{}))
+			assert.True(t, errors.Is(err, ErrUnsupportedProofStyle))
+		})
+	}
+
+	_, err := DetectProofStyle([]byte(`{"id":"doc-1"}`))
+	assert.Equal(t, errcode.MalformedProof, errcode.CodeOf(err))
+}
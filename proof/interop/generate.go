@@ -0,0 +1,322 @@
+package interop
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/google/uuid"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/proof/prooftest"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// canonicalizationInputs are the inputs of the canonicalization fixtures. Most are the test
+// vectors of RFC 8785.
+var canonicalizationInputs = []CanonicalizationFixture{
+	{
+		Name: "RFC 8785 sample",
+		Input: `{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`,
+	},
+	{
+		Name: "Property sorting by UTF-16 code units",
+		Input: `{
+  "€": "Euro Sign",
+  "\r": "Carriage Return",
+  "דּ": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "😀": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "ö": "Latin Small Letter O With Diaeresis"
+}`,
+	},
+	{
+		Name:  "Nested objects and arrays",
+		Input: `{"b": [3, 1, {"z": {}, "y": []}], "a": {"d": null, "c": "x"}}`,
+	},
+	{
+		Name:  "Numbers",
+		Input: `[0, -0, 1.0, 100, 1e21, 1e-7, 123e-2, -5e-324, 9007199254740993, 1.7976931348623157e308]`,
+	},
+	{
+		// U+2028 is escaped by json.Marshal, but not by JCS.
+		Name:  "String escapes",
+		Input: `["\u0000\u001f", "\t\b\f", "<script>&</script>", "é\u2028", "\"\\\/"]`,
+	},
+	{
+		Name:  "Whitespace and literals",
+		Input: " \n{ \"t\" : true ,\t\"f\" : false , \"n\" : null }\r\n",
+	},
+	{
+		Name:  "Truncated input",
+		Input: `{"a":`,
+	},
+	{
+		Name:  "Trailing data",
+		Input: `{"a":1} {"b":2}`,
+	},
+}
+
+// unsigned is the document that is signed by the signature fixtures, with properties that
+// exercise canonicalization.
+const unsigned = `{
+  "id": "urn:uuid:6a4c5e0f-1e3b-4f2a-9d6e-2c8b7a1f0e3d",
+  "type": ["InteropFixture"],
+  "claims": {
+    "name": "Zoë Åström",
+    "amount": 1.50,
+    "count": 100,
+    "small": 1e-7,
+    "nested": {"b": [3, 1, 2], "a": null},
+    "escapes": "€\n\"/<>&"
+  }
+}`
+
+// didKeySeeds are the prooftest seeds of the DID Key fixtures of each key type.
+var didKeySeeds = []byte{1, 2}
+
+// suite identifies a signature suite of proof.SignatureSuites.
+type suite struct {
+	signatureType proof.SignatureType
+	version       proof.ModelVersion
+	credential    bool
+}
+
+func (s suite) name() string {
+	name := fmt.Sprintf("%s-v%d", s.signatureType, s.version)
+	if s.credential {
+		name += "-credential"
+	}
+	return name
+}
+
+// suites returns every signature suite of proof.SignatureSuites.
+func suites() []suite {
+	var all []suite
+	factory := proof.SignatureSuites()
+	for _, sigType := range proof.SignatureTypes {
+		for _, version := range []proof.ModelVersion{proof.V1, proof.V2} {
+			if _, err := factory.GetSuite(sigType, version); err == nil {
+				all = append(all, suite{signatureType: sigType, version: version})
+			}
+			if _, err := factory.GetSuiteForCredentials(sigType, version); err == nil {
+				all = append(all, suite{signatureType: sigType, version: version, credential: true})
+			}
+		}
+	}
+	return all
+}
+
+// GenerateFixtures generates the fixture files from their inputs, keys and the prooftest clock,
+// keyed by file name in the fixtures directory. The files are indented JSON, newline terminated,
+// with fixtures in a stable order, so that a fixture that changes shows up as a small diff.
+//
+// Generation is deterministic, except for the signatures of suites that are not (see
+// SignatureFixture.Deterministic): the embedded signature is kept for as long as it verifies and
+// the rest of its fixture is unchanged, so that regenerating does not churn it.
+//
+// The files are checked in and embedded, and must only be regenerated on purpose, with
+// cmd/genvectors, since a changed fixture means that existing signatures no longer verify.
+func GenerateFixtures() (map[string][]byte, error) {
+	canonicalization := make([]CanonicalizationFixture, 0, len(canonicalizationInputs))
+	for _, input := range canonicalizationInputs {
+		if output, err := util.CanonicalMarshalRaw([]byte(input.Input)); err == nil {
+			input.Output = string(output)
+		}
+		canonicalization = append(canonicalization, input)
+	}
+
+	// Embedded fixtures that cannot be read are regenerated rather than kept.
+	existing, _ := SignatureFixtures()
+	previous := make(map[string]SignatureFixture, len(existing))
+	for _, fixture := range existing {
+		previous[fixture.Name] = fixture
+	}
+	var signatures []SignatureFixture
+	for _, s := range suites() {
+		fixture, err := newSignatureFixture(s)
+		if err != nil {
+			return nil, fmt.Errorf("signature fixture<%s>: %w", s.name(), err)
+		}
+		if prev, ok := previous[fixture.Name]; ok && !fixture.Deterministic && sameUnsignedFixture(prev, fixture) {
+			fixture = prev
+		}
+		signatures = append(signatures, fixture)
+	}
+
+	didKeys, err := newDIDKeyFixtures()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for name, v := range map[string]interface{}{
+		canonicalizationFile: canonicalization,
+		signaturesFile:       signatures,
+		didKeysFile:          didKeys,
+	} {
+		data, err := encodeFixtures(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files[path.Base(name)] = data
+	}
+	return files, nil
+}
+
+// newSignatureFixture signs the unsigned document with the suite. The key is derived from the
+// fixture's name, and the nonce from its key ID.
+func newSignatureFixture(s suite) (SignatureFixture, error) {
+	seed := sha256.Sum256([]byte("interop/" + s.name()))
+	fixture := SignatureFixture{
+		Name:             s.name(),
+		SignatureType:    s.signatureType,
+		ProofVersion:     s.version,
+		Credential:       s.credential,
+		KeyType:          proof.Ed25519KeyType,
+		PrivateKeyBase58: base58.Encode(seed[:]),
+		Created:          util.FormatCanonicalTime(prooftest.Created),
+		Unsigned:         json.RawMessage(unsigned),
+		Deterministic:    true,
+	}
+	if s.signatureType == proof.EcdsaSecp256k1SignatureType {
+		privateKey, publicKey := btcec.PrivKeyFromBytes(btcec.S256(), seed[:])
+		didKey, err := did.GenerateDIDKeySecp256k1(privateKey.PubKey().ToECDSA())
+		if err != nil {
+			return fixture, err
+		}
+		fixture.KeyType = proof.EcdsaSecp256k1KeyType
+		fixture.KeyID = did.GenerateKeyID(didKey, didKey[len(did.KeyDIDMethod):])
+		fixture.PublicKeyBase58 = base58.Encode(publicKey.SerializeCompressed())
+		fixture.Deterministic = false
+	} else {
+		publicKey := ed25519.NewKeyFromSeed(seed[:]).Public().(ed25519.PublicKey)
+		fixture.KeyID = did.GenerateKeyID(did.GenerateDID(publicKey), did.InitialKey)
+		fixture.PublicKeyBase58 = base58.Encode(publicKey)
+	}
+	fixture.Nonce = uuid.NewSHA1(uuid.NameSpaceURL, []byte(fixture.KeyID)).String()
+
+	signer, err := fixtureSigner(fixture)
+	if err != nil {
+		return fixture, err
+	}
+	recorder := &recordingSigner{Signer: signer}
+	if fixture.Signed, err = sign(fixture, recorder); err != nil {
+		return fixture, err
+	}
+	fixture.SigningInput = string(recorder.input)
+	return fixture, nil
+}
+
+// sameUnsignedFixture returns true if the fixtures differ by their signature value only, and the
+// previous fixture still verifies.
+func sameUnsignedFixture(previous, fixture SignatureFixture) bool {
+	if (Native{}).Verify(previous, previous.Signed) != nil {
+		return false
+	}
+	a, err := json.Marshal(previous)
+	if err != nil {
+		return false
+	}
+	b, err := json.Marshal(fixture)
+	if err != nil {
+		return false
+	}
+	diff, err := util.JSONDiff(a, b)
+	if err != nil {
+		return false
+	}
+	for _, d := range diff {
+		if !strings.HasPrefix(d, "$.signed.proof.signatureValue:") {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingSigner records the payload that it signs.
+type recordingSigner struct {
+	proof.Signer
+	input []byte
+}
+
+func (s *recordingSigner) Sign(toSign []byte) ([]byte, error) {
+	s.input = toSign
+	return s.Signer.Sign(toSign)
+}
+
+// newDIDKeyFixtures derives a DID Key for each supported key type and seed. Ed25519 keys are the
+// keys of prooftest.DeterministicKey, and ECDSA keys use the same SHA-256 digest of the seed as
+// their private scalar. Fixtures are sorted by name.
+func newDIDKeyFixtures() ([]DIDKeyFixture, error) {
+	var fixtures []DIDKeyFixture
+	for _, seed := range didKeySeeds {
+		digest := sha256.Sum256([]byte{seed})
+
+		edKey := prooftest.DeterministicKey(seed).Public().(ed25519.PublicKey)
+		edDID := did.GenerateDIDKey(edKey)
+		agreementKey, err := util.Ed25519PublicToX25519(edKey)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, newDIDKeyFixture(proof.Ed25519KeyType, seed, edKey, edDID))
+		fixtures[len(fixtures)-1].KeyAgreementBase58 = base58.Encode(agreementKey)
+
+		_, secpKey := btcec.PrivKeyFromBytes(btcec.S256(), digest[:])
+		secpDID, err := did.GenerateDIDKeySecp256k1(secpKey.ToECDSA())
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, newDIDKeyFixture(proof.EcdsaSecp256k1KeyType, seed, secpKey.SerializeCompressed(), secpDID))
+
+		curve := elliptic.P256()
+		p256Key := &ecdsa.PublicKey{Curve: curve}
+		p256Key.X, p256Key.Y = curve.ScalarBaseMult(new(big.Int).SetBytes(digest[:]).Bytes())
+		p256DID, err := did.GenerateDIDKeyP256(p256Key)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, newDIDKeyFixture(proof.EcdsaSecp256r1KeyType, seed, elliptic.MarshalCompressed(curve, p256Key.X, p256Key.Y), p256DID))
+	}
+	sort.Slice(fixtures, func(i, j int) bool { return fixtures[i].Name < fixtures[j].Name })
+	return fixtures, nil
+}
+
+func newDIDKeyFixture(keyType proof.KeyType, seed byte, publicKey []byte, didKey string) DIDKeyFixture {
+	return DIDKeyFixture{
+		Name:            fmt.Sprintf("%s-seed-%d", keyType, seed),
+		KeyType:         keyType,
+		PublicKeyBase58: base58.Encode(publicKey),
+		DID:             didKey,
+		KeyID:           did.GenerateKeyID(didKey, strings.TrimPrefix(didKey, did.KeyDIDMethod)),
+	}
+}
+
+// encodeFixtures encodes fixtures as indented JSON, without escaping HTML characters so that the
+// files show inputs as they are. The output ends with a newline.
+func encodeFixtures(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,346 @@
+// Package interop is a conformance suite for implementations of the signature suites of the proof
+// package in other languages, e.g. Java or JavaScript. The suite is a corpus of fixtures, embedded
+// in this package and also available as plain JSON files in the fixtures directory:
+//
+//   - canonicalization.json lists JSON inputs and their JCS (RFC 8785) canonical form;
+//   - signatures.json lists a document signed with every signature suite, along with the key, the
+//     proof's created time and nonce, and the exact bytes that were signed;
+//   - didkeys.json lists DID Keys of every supported key type, along with their public keys.
+//
+// An implementation is checked by wrapping it in an InteropAdapter and calling RunInteropSuite,
+// e.g. from a Go test that calls out to the other implementation. VerifyFixtures checks this
+// module against the fixtures, and can be called at startup as a self-test.
+//
+// The fixtures are golden: existing signatures are only verifiable for as long as the bytes stay
+// the same, so a fixture must never be updated to match a new implementation. They are generated
+// by GenerateFixtures, through cmd/genvectors, and a test fails if they are out of date.
+package interop
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+const (
+	canonicalizationFile = "fixtures/canonicalization.json"
+	signaturesFile       = "fixtures/signatures.json"
+	didKeysFile          = "fixtures/didkeys.json"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// CanonicalizationFixture is a JSON input and its JCS canonical form.
+type CanonicalizationFixture struct {
+	Name string `json:"name"`
+	// Input is the JSON to canonicalize. It is a string, rather than embedded JSON, so that the
+	// original formatting, escapes and number representations are preserved.
+	Input string `json:"input"`
+	// Output is the canonical form of the input, or empty if the input is not valid JSON and
+	// canonicalization must fail.
+	Output string `json:"output,omitempty"`
+}
+
+// SignatureFixture is a document signed with a signature suite, along with everything needed to
+// reproduce the signature.
+type SignatureFixture struct {
+	Name          string              `json:"name"`
+	SignatureType proof.SignatureType `json:"signatureType"`
+	ProofVersion  proof.ModelVersion  `json:"proofVersion"`
+	// Credential is true if the document is signed with the suite for Verifiable Credential proofs
+	// (see proof.SignatureSuiteFactory.GetSuiteForCredentials), which base64 encodes the
+	// canonical JSON before signing.
+	Credential bool          `json:"credential"`
+	KeyType    proof.KeyType `json:"keyType"`
+	KeyID      string        `json:"keyId"`
+	// PrivateKeyBase58 is the Ed25519 seed or the secp256k1 private scalar, 32 bytes in both cases.
+	PrivateKeyBase58 string `json:"privateKeyBase58"`
+	// PublicKeyBase58 is the Ed25519 public key, or the compressed secp256k1 public key.
+	PublicKeyBase58 string `json:"publicKeyBase58"`
+	Created         string `json:"created"`
+	Nonce           string `json:"nonce"`
+	// Unsigned is the document without a proof.
+	Unsigned json.RawMessage `json:"unsigned"`
+	// SigningInput is the exact payload that was signed, for debugging signature mismatches.
+	SigningInput string `json:"signingInput"`
+	// Signed is the document with its proof.
+	Signed json.RawMessage `json:"signed"`
+	// Deterministic is true if signing the unsigned document with the fixture's key, created time
+	// and nonce always yields the same signature. ECDSA signatures are randomized, so secp256k1
+	// signatures can only be checked by verifying them.
+	Deterministic bool `json:"deterministic"`
+}
+
+// DIDKeyFixture is a DID Key and the public key that it encodes.
+type DIDKeyFixture struct {
+	Name    string        `json:"name"`
+	KeyType proof.KeyType `json:"keyType"`
+	// PublicKeyBase58 is the Ed25519 public key, or the compressed secp256k1 or P-256 public key.
+	PublicKeyBase58 string `json:"publicKeyBase58"`
+	DID             string `json:"did"`
+	// KeyID is the reference of the DID's verification method.
+	KeyID string `json:"keyId"`
+	// KeyAgreementBase58 is the X25519 key agreement key that is derived from an Ed25519 public
+	// key, and is empty for other key types.
+	KeyAgreementBase58 string `json:"keyAgreementBase58,omitempty"`
+}
+
+// CanonicalizationFixtures returns the embedded canonicalization fixtures.
+func CanonicalizationFixtures() ([]CanonicalizationFixture, error) {
+	var f []CanonicalizationFixture
+	return f, readFixtures(canonicalizationFile, &f)
+}
+
+// SignatureFixtures returns the embedded signature fixtures.
+func SignatureFixtures() ([]SignatureFixture, error) {
+	var f []SignatureFixture
+	return f, readFixtures(signaturesFile, &f)
+}
+
+// DIDKeyFixtures returns the embedded DID Key fixtures.
+func DIDKeyFixtures() ([]DIDKeyFixture, error) {
+	var f []DIDKeyFixture
+	return f, readFixtures(didKeysFile, &f)
+}
+
+func readFixtures(name string, v interface{}) error {
+	data, err := fixtures.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// InteropAdapter wraps an implementation of the signature suites for the conformance suite.
+type InteropAdapter interface {
+	// Canonicalize returns the JCS canonical form of the JSON input, or an error if the input is
+	// not valid JSON.
+	Canonicalize(input []byte) ([]byte, error)
+	// Sign signs the fixture's unsigned document with the fixture's signature suite, key, created
+	// time and nonce, and returns the signed document.
+	Sign(fixture SignatureFixture) ([]byte, error)
+	// Verify verifies the signed document with the fixture's signature suite and public key.
+	Verify(fixture SignatureFixture, signed []byte) error
+}
+
+// RunInteropSuite checks the implementation against every fixture, each in its own subtest. For
+// each canonicalization fixture, the output must match exactly. For each signature fixture:
+//
+//   - signing must reproduce the fixture's signed document, except for the signature value of
+//     non-deterministic suites, and this module must verify the result;
+//   - the fixture's signed document must verify;
+//   - the signed document must not verify once a property is added.
+func RunInteropSuite(t *testing.T, impl InteropAdapter) {
+	check(impl, func(name string, f func() error) {
+		t.Run(name, func(t *testing.T) {
+			if err := f(); err != nil {
+				t.Error(err)
+			}
+		})
+	})
+}
+
+// VerifyFixtures checks this module against every fixture, as RunInteropSuite does, and returns
+// the first failure. It can be called at startup to make sure that the module works as expected
+// on the platform, e.g. that canonicalization has not changed through a dependency upgrade.
+func VerifyFixtures() error {
+	var failure error
+	check(Native{}, func(name string, f func() error) {
+		if failure != nil {
+			return
+		}
+		if err := f(); err != nil {
+			failure = fmt.Errorf("%s: %w", name, err)
+		}
+	})
+	return failure
+}
+
+// check runs the conformance checks through run, which is given the name of each check.
+func check(impl InteropAdapter, run func(name string, f func() error)) {
+	canonicalization, err := CanonicalizationFixtures()
+	if err != nil {
+		run("fixtures", func() error { return err })
+		return
+	}
+	signatures, err := SignatureFixtures()
+	if err != nil {
+		run("fixtures", func() error { return err })
+		return
+	}
+
+	for _, fixture := range canonicalization {
+		fixture := fixture
+		run("canonicalization/"+fixture.Name, func() error { return checkCanonicalization(impl, fixture) })
+	}
+	for _, fixture := range signatures {
+		fixture := fixture
+		run("sign/"+fixture.Name, func() error { return checkSign(impl, fixture) })
+		run("verify/"+fixture.Name, func() error { return checkVerify(impl, fixture) })
+	}
+}
+
+func checkCanonicalization(impl InteropAdapter, fixture CanonicalizationFixture) error {
+	output, err := impl.Canonicalize([]byte(fixture.Input))
+	if fixture.Output == "" {
+		if err == nil {
+			return fmt.Errorf("invalid input was canonicalized to %s", output)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if string(output) != fixture.Output {
+		return fmt.Errorf("canonical form %s != %s", output, fixture.Output)
+	}
+	return nil
+}
+
+func checkSign(impl InteropAdapter, fixture SignatureFixture) error {
+	signed, err := impl.Sign(fixture)
+	if err != nil {
+		return err
+	}
+	diff, err := util.JSONDiff(signed, fixture.Signed)
+	if err != nil {
+		return err
+	}
+	for _, d := range diff {
+		if !fixture.Deterministic && strings.HasPrefix(d, "$.proof.signatureValue:") {
+			continue
+		}
+		return fmt.Errorf("signed document does not match the fixture: %s", d)
+	}
+	if err := (Native{}).Verify(fixture, signed); err != nil {
+		return fmt.Errorf("signed document does not verify: %w", err)
+	}
+	return nil
+}
+
+func checkVerify(impl InteropAdapter, fixture SignatureFixture) error {
+	if err := impl.Verify(fixture, fixture.Signed); err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := util.UnmarshalUseNumber(fixture.Signed, &doc); err != nil {
+		return err
+	}
+	doc["tampered"] = true
+	tampered, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := impl.Verify(fixture, tampered); err == nil {
+		return fmt.Errorf("tampered document verified")
+	}
+	return nil
+}
+
+// Native is the InteropAdapter for this module.
+type Native struct{}
+
+// Canonicalize canonicalizes the input with util.CanonicalMarshalRaw.
+func (Native) Canonicalize(input []byte) ([]byte, error) {
+	return util.CanonicalMarshalRaw(input)
+}
+
+// Sign signs the fixture's unsigned document with the proof package.
+func (Native) Sign(fixture SignatureFixture) ([]byte, error) {
+	signer, err := fixtureSigner(fixture)
+	if err != nil {
+		return nil, err
+	}
+	return sign(fixture, signer)
+}
+
+// sign signs the fixture's unsigned document with the signer.
+func sign(fixture SignatureFixture, signer proof.Signer) ([]byte, error) {
+	doc, err := proof.ParseDocument(fixture.Unsigned)
+	if err != nil {
+		return nil, err
+	}
+	suite, err := fixtureSuite(fixture)
+	if err != nil {
+		return nil, err
+	}
+	created, err := time.Parse(time.RFC3339, fixture.Created)
+	if err != nil {
+		return nil, err
+	}
+	opts := []proof.ProofOption{
+		proof.WithClock(func() time.Time { return created }),
+		proof.WithNonce(func() string { return fixture.Nonce }),
+	}
+	if err := proof.SignWithOptions(suite, doc, signer, opts...); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// Verify verifies the signed document with the proof package.
+func (Native) Verify(fixture SignatureFixture, signed []byte) error {
+	doc, err := proof.ParseDocument(signed)
+	if err != nil {
+		return err
+	}
+	suite, err := fixtureSuite(fixture)
+	if err != nil {
+		return err
+	}
+	verifier, err := fixtureVerifier(fixture)
+	if err != nil {
+		return err
+	}
+	return suite.Verify(doc, verifier)
+}
+
+func fixtureSuite(fixture SignatureFixture) (proof.SignatureSuite, error) {
+	if fixture.Credential {
+		return proof.SignatureSuites().GetSuiteForCredentials(fixture.SignatureType, fixture.ProofVersion)
+	}
+	return proof.SignatureSuites().GetSuite(fixture.SignatureType, fixture.ProofVersion)
+}
+
+func fixtureSigner(fixture SignatureFixture) (proof.Signer, error) {
+	privateKey, err := base58.Decode(fixture.PrivateKeyBase58)
+	if err != nil {
+		return nil, err
+	}
+	switch fixture.KeyType {
+	case proof.Ed25519KeyType:
+		if len(privateKey) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid Ed25519 seed length: %d", len(privateKey))
+		}
+		return proof.NewEd25519Signer(ed25519.NewKeyFromSeed(privateKey), fixture.KeyID)
+	case proof.EcdsaSecp256k1KeyType:
+		key, _ := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+		return proof.NewSecp256k1Signer(key.ToECDSA(), fixture.KeyID)
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", fixture.KeyType)
+}
+
+func fixtureVerifier(fixture SignatureFixture) (proof.Verifier, error) {
+	publicKey, err := base58.Decode(fixture.PublicKeyBase58)
+	if err != nil {
+		return nil, err
+	}
+	switch fixture.KeyType {
+	case proof.Ed25519KeyType:
+		return &proof.Ed25519Verifier{PubKey: publicKey}, nil
+	case proof.EcdsaSecp256k1KeyType:
+		return &proof.Secp256K1Verifier{PublicKey: publicKey}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", fixture.KeyType)
+}
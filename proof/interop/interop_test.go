@@ -0,0 +1,172 @@
+package interop
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/proof/prooftest"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestRunInteropSuite(t *testing.T) {
+	RunInteropSuite(t, Native{})
+}
+
+func TestVerifyFixtures(t *testing.T) {
+	assert.NoError(t, VerifyFixtures())
+}
+
+// TestFixturesComplete checks that every signature suite has a fixture.
+func TestFixturesComplete(t *testing.T) {
+	fixtures, err := SignatureFixtures()
+	require.NoError(t, err)
+	names := make(map[string]bool)
+	for _, fixture := range fixtures {
+		s := suite{signatureType: fixture.SignatureType, version: fixture.ProofVersion, credential: fixture.Credential}
+		assert.Equal(t, s.name(), fixture.Name)
+		names[fixture.Name] = true
+	}
+	for _, s := range suites() {
+		assert.True(t, names[s.name()], "missing fixture for %s, run go run ./cmd/genvectors -update", s.name())
+	}
+
+	canonicalization, err := CanonicalizationFixtures()
+	require.NoError(t, err)
+	assert.Len(t, canonicalization, len(canonicalizationInputs))
+}
+
+// TestFixturesUpToDate checks that the embedded fixtures are those generated from the current
+// inputs, so that a change to an input or to signing cannot go unnoticed.
+func TestFixturesUpToDate(t *testing.T) {
+	generated, err := GenerateFixtures()
+	require.NoError(t, err)
+	entries, err := fixtures.ReadDir("fixtures")
+	require.NoError(t, err)
+	var embedded []string
+	for _, entry := range entries {
+		embedded = append(embedded, entry.Name())
+	}
+	var names []string
+	for name := range generated {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, names, embedded)
+	for _, name := range names {
+		want, err := fixtures.ReadFile("fixtures/" + name)
+		if assert.NoError(t, err, "missing fixtures/%s, run go run ./cmd/genvectors -update", name) {
+			assert.Equal(t, string(want), string(generated[name]), "fixtures/%s is out of date, run go run ./cmd/genvectors -update and review the diff", name)
+		}
+	}
+}
+
+// TestDIDKeyFixtures checks the DID Key fixtures against the did package.
+func TestDIDKeyFixtures(t *testing.T) {
+	fixtures, err := DIDKeyFixtures()
+	require.NoError(t, err)
+	keyTypes := make(map[proof.KeyType]bool)
+	for _, fixture := range fixtures {
+		t.Run(fixture.Name, func(t *testing.T) {
+			keyTypes[fixture.KeyType] = true
+			publicKey, keyType, err := did.ExtractPublicKeyFromDIDKey(fixture.DID)
+			require.NoError(t, err)
+			assert.Equal(t, fixture.KeyType, keyType)
+			assert.Equal(t, fixture.PublicKeyBase58, base58.Encode(publicKey))
+
+			doc, err := did.ResolveDIDKey(fixture.DID)
+			require.NoError(t, err)
+			assert.Equal(t, fixture.KeyID, doc.PublicKey[0].ID)
+			// The proof package does not have a P-256 signature suite, so P-256 keys have no verifier.
+			if fixture.KeyType != proof.EcdsaSecp256r1KeyType {
+				_, err = did.VerifierForKeyRef(fixture.KeyID)
+				assert.NoError(t, err)
+			}
+
+			if fixture.KeyType == proof.Ed25519KeyType {
+				agreementKey, err := did.EncryptionKeyForDID(fixture.DID)
+				require.NoError(t, err)
+				assert.Equal(t, fixture.KeyAgreementBase58, base58.Encode(agreementKey))
+			} else {
+				assert.Empty(t, fixture.KeyAgreementBase58)
+			}
+		})
+	}
+	assert.Len(t, keyTypes, 3)
+}
+
+// brokenAdapter canonicalizes with json.Marshal, ignores the fixture's created time, and does not
+// verify signatures.
+type brokenAdapter struct{}
+
+func (brokenAdapter) Canonicalize(input []byte) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(input, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func (brokenAdapter) Sign(fixture SignatureFixture) ([]byte, error) {
+	fixture.Created = util.FormatCanonicalTime(prooftest.Created.AddDate(1, 0, 0))
+	return Native{}.Sign(fixture)
+}
+
+func (brokenAdapter) Verify(SignatureFixture, []byte) error {
+	return nil
+}
+
+func TestCheckFailures(t *testing.T) {
+	failures := make(map[string]error)
+	check(brokenAdapter{}, func(name string, f func() error) {
+		if err := f(); err != nil {
+			failures[name] = err
+		}
+	})
+
+	// json.Marshal sorts properties by UTF-8 bytes rather than UTF-16 code units, formats some
+	// numbers differently, and escapes HTML characters and line separators, but is otherwise
+	// canonical. Invalid inputs are rejected.
+	var canonicalizationFailures []string
+	for name := range failures {
+		if strings.HasPrefix(name, "canonicalization/") {
+			canonicalizationFailures = append(canonicalizationFailures, name)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"canonicalization/Property sorting by UTF-16 code units",
+		"canonicalization/Numbers",
+		"canonicalization/String escapes",
+	}, canonicalizationFailures)
+	for _, s := range suites() {
+		if assert.Contains(t, failures, "sign/"+s.name()) {
+			assert.Contains(t, failures["sign/"+s.name()].Error(), "$.proof.created")
+		}
+		if assert.Contains(t, failures, "verify/"+s.name()) {
+			assert.EqualError(t, failures["verify/"+s.name()], "tampered document verified")
+		}
+	}
+}
+
+// TestNormalizeFixtures checks that signed documents still verify after normalization for
+// storage, but not after the lossy normalization for display.
+func TestNormalizeFixtures(t *testing.T) {
+	fixtures, err := SignatureFixtures()
+	require.NoError(t, err)
+	for _, fixture := range fixtures {
+		t.Run(fixture.Name, func(t *testing.T) {
+			normalized, err := util.NormalizeJSON(fixture.Signed, util.StorageNormalizeOptions)
+			require.NoError(t, err)
+			assert.NoError(t, Native{}.Verify(fixture, normalized))
+
+			display, err := util.NormalizeJSON(fixture.Signed, util.DisplayNormalizeOptions)
+			require.NoError(t, err)
+			assert.Error(t, Native{}.Verify(fixture, display))
+		})
+	}
+}
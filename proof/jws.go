@@ -0,0 +1,201 @@
+package proof
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// JWS algorithms of the key types that can sign a JWS (see JWSAlgorithm).
+const (
+	// EdDSAAlgorithm is the JWS algorithm of Ed25519 keys (RFC 8037).
+	EdDSAAlgorithm = "EdDSA"
+	// ES256KAlgorithm is the JWS algorithm of secp256k1 keys (RFC 8812).
+	ES256KAlgorithm = "ES256K"
+	// ES256Algorithm is the JWS algorithm of P-256 keys (RFC 7518).
+	ES256Algorithm = "ES256"
+)
+
+// es256kComponentSize is the size of each of R and S in an ES256K or ES256 signature.
+const es256kComponentSize = 32
+
+// JWSHeader is the protected header of a JWS.
+type JWSHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	// Kid is the key reference of the signing key, e.g. "did:work:abcd#key-1".
+	Kid string `json:"kid,omitempty"`
+}
+
+// JWS is a parsed JWS in compact serialization (RFC 7515 Section 7.1). Its signature is not
+// checked until Verify is called.
+type JWS struct {
+	Header  JWSHeader
+	Payload []byte
+
+	signingInput string
+	signature    []byte
+}
+
+// JWSAlgorithm returns the JWS algorithm for signatures of the key type: EdDSA for Ed25519 keys,
+// ES256K for secp256k1 keys and ES256 for P-256 keys.
+func JWSAlgorithm(keyType KeyType) (string, error) {
+	switch keyType {
+	case Ed25519KeyType, Ed25519VerificationKey2020KeyType, WorkEdKeyType:
+		return EdDSAAlgorithm, nil
+	case EcdsaSecp256k1KeyType:
+		return ES256KAlgorithm, nil
+	case EcdsaSecp256r1KeyType:
+		return ES256Algorithm, nil
+	}
+	return "", errcode.Errorf(errcode.UnsupportedSuite, "no JWS algorithm for key type<%s>", keyType)
+}
+
+// SignJWS signs the payload with the signer's key, and returns the JWS in compact serialization.
+// The header's algorithm follows the signer's key type (see JWSAlgorithm), and its key ID is the
+// signer's ID.
+func SignJWS(payload []byte, typ string, signer Signer) (string, error) {
+	alg, err := JWSAlgorithm(signer.Type())
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(JWSHeader{Alg: alg, Typ: typ, Kid: signer.ID()})
+	if err != nil {
+		return "", err
+	}
+	signingInput := util.B64URLEncode(header) + "." + util.B64URLEncode(payload)
+	signature, err := signer.Sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	switch alg {
+	case ES256KAlgorithm:
+		if signature, err = derToES256K(signature); err != nil {
+			return "", err
+		}
+	case ES256Algorithm:
+		if signature, err = derToES256(signature); err != nil {
+			return "", err
+		}
+	}
+	return signingInput + "." + util.B64URLEncode(signature), nil
+}
+
+// ParseJWS parses a JWS in compact serialization.
+func ParseJWS(token string) (*JWS, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errcode.New(errcode.MalformedProof, "JWS must have three parts")
+	}
+	header, err := util.B64URLDecode(parts[0])
+	if err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid JWS header")
+	}
+	payload, err := util.B64URLDecode(parts[1])
+	if err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid JWS payload")
+	}
+	signature, err := util.B64URLDecode(parts[2])
+	if err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid JWS signature")
+	}
+	jws := &JWS{Payload: payload, signingInput: parts[0] + "." + parts[1], signature: signature}
+	if err := json.Unmarshal(header, &jws.Header); err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid JWS header")
+	}
+	return jws, nil
+}
+
+// Verify checks the signature of the JWS with the verifier. The header's algorithm must be the
+// algorithm of the verifier's key type, so that a signature cannot be checked with another
+// algorithm than the key's.
+func (j *JWS) Verify(verifier Verifier) error {
+	alg, err := JWSAlgorithm(verifier.Type())
+	if err != nil {
+		return err
+	}
+	if j.Header.Alg != alg {
+		return errcode.Errorf(errcode.UnsupportedSuite, "JWS algorithm<%s> does not match key type<%s>", j.Header.Alg, verifier.Type())
+	}
+	signature := j.signature
+	if alg == ES256KAlgorithm || alg == ES256Algorithm {
+		if len(signature) != 2*es256kComponentSize {
+			return errcode.Errorf(errcode.MalformedProof, "invalid %s signature length<%d>", alg, len(signature))
+		}
+		r := new(big.Int).SetBytes(signature[:es256kComponentSize])
+		s := new(big.Int).SetBytes(signature[es256kComponentSize:])
+		if alg == ES256KAlgorithm {
+			signature = (&btcec.Signature{R: r, S: s}).Serialize()
+		} else if signature, err = asn1.Marshal(ecdsaSignature{R: r, S: s}); err != nil {
+			return err
+		}
+	}
+	valid, err := verifier.Verify([]byte(j.signingInput), signature)
+	if err != nil {
+		return errcode.Wrap(errcode.SignatureInvalid, err, "JWS signature")
+	}
+	if !valid {
+		return errcode.New(errcode.SignatureInvalid, "JWS signature is invalid")
+	}
+	return nil
+}
+
+// VerifyJWSWithResolver verifies the JWS with the key that its kid header references, using the
+// resolver to find it. The options apply as for VerifyWithResolver.
+func VerifyJWSWithResolver(ctx context.Context, jws *JWS, resolver VerifierResolver, opts ...VerifyOption) error {
+	var options verifyOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.historical {
+		ctx = context.WithValue(ctx, historicalKey{}, true)
+	}
+	if jws.Header.Kid == "" {
+		return errcode.New(errcode.MalformedProof, "JWS does not have a kid header")
+	}
+	verifier, err := resolver.ResolveVerifier(ctx, jws.Header.Kid)
+	if err != nil {
+		return err
+	}
+	return jws.Verify(verifier)
+}
+
+// derToES256K converts a DER encoded ECDSA signature, as made by the secp256k1 signers, to the
+// fixed size R || S form of JWS.
+func derToES256K(der []byte) ([]byte, error) {
+	signature, err := btcec.ParseDERSignature(der, btcec.S256())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid secp256k1 signature")
+	}
+	jws := make([]byte, 2*es256kComponentSize)
+	signature.R.FillBytes(jws[:es256kComponentSize])
+	signature.S.FillBytes(jws[es256kComponentSize:])
+	return jws, nil
+}
+
+// ecdsaSignature is the ASN.1 structure of a DER encoded ECDSA signature.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// derToES256 converts a DER encoded ECDSA signature, as made by the P-256 signers, to the fixed
+// size R || S form of JWS.
+func derToES256(der []byte) ([]byte, error) {
+	var signature ecdsaSignature
+	rest, err := asn1.Unmarshal(der, &signature)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("invalid P-256 signature")
+	}
+	jws := make([]byte, 2*es256kComponentSize)
+	signature.R.FillBytes(jws[:es256kComponentSize])
+	signature.S.FillBytes(jws[es256kComponentSize:])
+	return jws, nil
+}
@@ -0,0 +1,100 @@
+package proof
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+func TestJWS(t *testing.T) {
+	edPub, edPriv, err := ed25519.GenerateKey(util.RandReader())
+	require.NoError(t, err)
+	edSigner, err := NewEd25519Signer(edPriv, "did:work:ed#key-1")
+	require.NoError(t, err)
+	edVerifier := &Ed25519Verifier{PubKey: edPub}
+
+	secpKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	secpSigner, err := NewSecp256k1Signer(secpKey.ToECDSA(), "did:work:secp#key-1")
+	require.NoError(t, err)
+	secpVerifier := &Secp256K1Verifier{PublicKey: secpKey.PubKey().SerializeCompressed()}
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), util.RandReader())
+	require.NoError(t, err)
+	p256Signer, err := NewCryptoSigner(p256Key, "did:work:p256#key-1")
+	require.NoError(t, err)
+	p256Verifier := &P256Verifier{PublicKey: elliptic.MarshalCompressed(elliptic.P256(), p256Key.X, p256Key.Y)}
+
+	payload := []byte(`{"iss":"did:work:abcd"}`)
+	for _, test := range []struct {
+		name     string
+		signer   Signer
+		verifier Verifier
+		other    Verifier
+		alg      string
+		sigSize  int
+	}{
+		{name: "EdDSA", signer: edSigner, verifier: edVerifier, other: secpVerifier, alg: EdDSAAlgorithm, sigSize: ed25519.SignatureSize},
+		{name: "ES256K", signer: secpSigner, verifier: secpVerifier, other: edVerifier, alg: ES256KAlgorithm, sigSize: 64},
+		{name: "ES256", signer: p256Signer, verifier: p256Verifier, other: secpVerifier, alg: ES256Algorithm, sigSize: 64},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := SignJWS(payload, "JWT", test.signer)
+			require.NoError(t, err)
+			jws, err := ParseJWS(token)
+			require.NoError(t, err)
+			assert.Equal(t, JWSHeader{Alg: test.alg, Typ: "JWT", Kid: test.signer.ID()}, jws.Header)
+			assert.Equal(t, payload, jws.Payload)
+			assert.Len(t, jws.signature, test.sigSize)
+			assert.NoError(t, jws.Verify(test.verifier))
+
+			// The algorithm must match the verifier's key type.
+			assert.Error(t, jws.Verify(test.other))
+
+			parts := strings.Split(token, ".")
+			tampered, err := ParseJWS(parts[0] + "." + util.B64URLEncode([]byte(`{"iss":"did:work:efgh"}`)) + "." + parts[2])
+			require.NoError(t, err)
+			assert.Error(t, tampered.Verify(test.verifier))
+		})
+	}
+
+	t.Run("Resolver", func(t *testing.T) {
+		token, err := SignJWS(payload, "JWT", edSigner)
+		require.NoError(t, err)
+		jws, err := ParseJWS(token)
+		require.NoError(t, err)
+		assert.NoError(t, VerifyJWSWithResolver(context.Background(), jws, keyResolver{edSigner.ID(): edVerifier}))
+		assert.Error(t, VerifyJWSWithResolver(context.Background(), jws, keyResolver{edSigner.ID(): secpVerifier}))
+		assert.Error(t, VerifyJWSWithResolver(context.Background(), jws, keyResolver{}))
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, token := range []string{"", "a.b", "a.b.c.d", "!.e30.AA", "e30.!.AA", "e30.e30.!", "bm90IGpzb24.e30.AA"} {
+			_, err := ParseJWS(token)
+			assert.Error(t, err, token)
+		}
+		_, err := JWSAlgorithm(X25519KeyType)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewVerifyPolicy(t *testing.T) {
+	policy := NewVerifyPolicy()
+	assert.NotNil(t, policy.Now)
+	assert.Zero(t, policy.ClockSkew)
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	policy = NewVerifyPolicy(WithVerifyClock(func() time.Time { return now }), WithClockSkew(time.Minute), AllowHistorical())
+	assert.Equal(t, now, policy.Now())
+	assert.Equal(t, time.Minute, policy.ClockSkew)
+}
@@ -1,16 +1,16 @@
 package proof
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"time"
 
-	jcs "github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
-	"github.com/google/uuid"
 	"github.com/mr-tron/base58"
 
 	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
 )
 
 // LDSignatureSuite is a SignatureSuite based on the Linked-Data Signatures specification.
@@ -35,17 +35,28 @@ func (s LDSignatureSuite) Type() SignatureType {
 // Returns an error if the provable object already contains a Proof or if any error is
 // encountered when generating the digital signature.
 func (s LDSignatureSuite) Sign(provable Provable, signer Signer) error {
+	return s.SignWithOptions(provable, signer)
+}
+
+// SignWithOptions is the same as Sign, except that the options can override the created timestamp
+// and nonce that the ProofFactory generates, and provide a scratch buffer for the payload.
+func (s LDSignatureSuite) SignWithOptions(provable Provable, signer Signer, opts ...ProofOption) error {
 	if provable.GetProof() != nil {
 		return fmt.Errorf("attempt to overwrite existing proof")
 	}
 	if signer.Type() != s.KeyType {
-		return fmt.Errorf("incorrect key type")
+		return ErrIncorrectKeyType
 	}
 
 	p := s.ProofFactory.Create(signer, s.SignatureType)
+	var options ProofOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.apply(p)
 	provable.SetProof(p)
 
-	jsonBytes, err := s.encode(provable)
+	jsonBytes, err := s.encode(provable, options.Buffer)
 	if err != nil {
 		provable.SetProof(nil)
 		return err
@@ -61,26 +72,49 @@ func (s LDSignatureSuite) Sign(provable Provable, signer Signer) error {
 	return nil
 }
 
-// encode transforms the provable object into a canonical byte array that can be signed over.
-func (s *LDSignatureSuite) encode(provable Provable) ([]byte, error) {
-	jsonBytes, err := s.Marshaler.Marshal(provable)
+// encode transforms the provable object into a canonical byte array that can be signed over. If
+// the scratch buffer is not nil, the stages that support it (see bufferMarshaler, bufferDigest and
+// bufferAppender) write to the buffer rather than allocate, so the returned bytes are only valid
+// until the buffer is next used.
+func (s *LDSignatureSuite) encode(provable Provable, scratch *bytes.Buffer) ([]byte, error) {
+	var jsonBytes []byte
+	var err error
+	if m, ok := s.Marshaler.(bufferMarshaler); ok && scratch != nil {
+		jsonBytes, err = m.MarshalTo(scratch, provable)
+	} else {
+		jsonBytes, err = s.Marshaler.Marshal(provable)
+	}
 	if err != nil {
 		return nil, err
 	}
-	if s.Canonicalizer != nil {
-		jsonBytes, err = s.Canonicalizer.Canonicalize(jsonBytes)
-		if err != nil {
-			return nil, err
-		}
+	if s.Canonicalizer == nil {
+		// The JSON may be in the scratch buffer, which the remaining stages cannot reuse.
+		scratch = nil
+	} else if jsonBytes, err = s.Canonicalizer.Canonicalize(jsonBytes); err != nil {
+		return nil, err
+	}
+	if scratch != nil {
+		scratch.Reset()
 	}
+
 	if s.MessageDigest != nil {
-		jsonBytes, err = s.MessageDigest.Digest(jsonBytes)
-		if err != nil {
+		if d, ok := s.MessageDigest.(bufferDigest); ok && scratch != nil {
+			d.DigestTo(scratch, jsonBytes)
+			jsonBytes = scratch.Bytes()
+		} else if jsonBytes, err = s.MessageDigest.Digest(jsonBytes); err != nil {
 			return nil, err
 		}
 	}
 	if s.OptionsAppender != nil {
-		jsonBytes = s.OptionsAppender.Append(jsonBytes, provable.GetProof())
+		if a, ok := s.OptionsAppender.(bufferAppender); ok && scratch != nil {
+			if scratch.Len() == 0 {
+				scratch.Write(jsonBytes)
+			}
+			a.AppendTo(scratch, provable.GetProof())
+			jsonBytes = scratch.Bytes()
+		} else {
+			jsonBytes = s.OptionsAppender.Append(jsonBytes, provable.GetProof())
+		}
 	}
 	return jsonBytes, nil
 }
@@ -90,18 +124,18 @@ func (s *LDSignatureSuite) encode(provable Provable) ([]byte, error) {
 func (s LDSignatureSuite) Verify(provable Provable, verifier Verifier) error {
 	p := provable.GetProof()
 	if p.IsEmpty() {
-		return fmt.Errorf("missing proof")
+		return errcode.New(errcode.MalformedProof, "missing proof")
 	}
 	signatureB58 := p.SignatureValue
 	signature, err := base58.Decode(signatureB58)
 	if err != nil {
-		return err
+		return errcode.Wrap(errcode.MalformedProof, err, "")
 	}
-	jsonBytes, err := s.encode(provable)
+	jsonBytes, err := s.encode(provable, nil)
 	if success, err := verifier.Verify(jsonBytes, signature); err != nil {
-		return err
+		return errcode.Wrap(errcode.SignatureInvalid, err, "")
 	} else if !success {
-		return fmt.Errorf("signature verification failed")
+		return errcode.New(errcode.SignatureInvalid, "signature verification failed")
 	}
 	return nil
 }
@@ -118,8 +152,8 @@ type proofFactoryV1 struct {
 
 func (f *proofFactoryV1) Create(signer Signer, signatureType SignatureType) *Proof {
 	return &Proof{
-		Created: time.Now().UTC().Format(time.RFC3339),
-		Nonce:   uuid.New().String(),
+		Created: util.FormatCanonicalTime(time.Now()),
+		Nonce:   util.NewUUID().String(),
 		Creator: signer.ID(),
 		Type:    signatureType,
 	}
@@ -132,8 +166,8 @@ type proofFactoryV2 struct {
 
 func (f *proofFactoryV2) Create(signer Signer, signatureType SignatureType) *Proof {
 	return &Proof{
-		Created:            time.Now().UTC().Format(time.RFC3339),
-		Nonce:              uuid.New().String(),
+		Created:            util.FormatCanonicalTime(time.Now()),
+		Nonce:              util.NewUUID().String(),
 		VerificationMethod: signer.ID(),
 		Type:               signatureType,
 	}
@@ -148,6 +182,24 @@ type Marshaler interface {
 	Marshal(provable Provable) ([]byte, error)
 }
 
+// bufferMarshaler is implemented by Marshalers that can marshal into a scratch buffer (see
+// ProofOptions.Buffer).
+type bufferMarshaler interface {
+	// MarshalTo is the same as Marshal, except that the JSON is written to the buffer, which is
+	// reset first.
+	MarshalTo(buf *bytes.Buffer, provable Provable) ([]byte, error)
+}
+
+// marshalTo writes the JSON encoding of the value to the buffer, as json.Marshal returns it.
+func marshalTo(buf *bytes.Buffer, v interface{}) ([]byte, error) {
+	buf.Reset()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	// Unlike json.Marshal, the Encoder terminates the JSON with a newline.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
 // EmbeddedProofMarshaler transforms the Provable into JSON, and leaves an embedded Proof sans the
 // signature value. This will effectively pass the Proof Options (metadata) into the signing
 // algorithm as part of the canonicalized JSON payload.
@@ -161,6 +213,14 @@ func (m *EmbeddedProofMarshaler) Marshal(provable Provable) ([]byte, error) {
 	return json.Marshal(provable)
 }
 
+func (m *EmbeddedProofMarshaler) MarshalTo(buf *bytes.Buffer, provable Provable) ([]byte, error) {
+	p := provable.GetProof()
+	signatureB58 := p.SignatureValue
+	p.SignatureValue = ""
+	defer func() { p.SignatureValue = signatureB58 }()
+	return marshalTo(buf, provable)
+}
+
 // WithoutProofMarshaler transforms the Provable into JSON, and strips the proof.
 type WithoutProofMarshaler struct{}
 
@@ -171,16 +231,24 @@ func (m *WithoutProofMarshaler) Marshal(provable Provable) ([]byte, error) {
 	return json.Marshal(provable)
 }
 
+func (m *WithoutProofMarshaler) MarshalTo(buf *bytes.Buffer, provable Provable) ([]byte, error) {
+	p := provable.GetProof()
+	provable.SetProof(nil)
+	defer func() { provable.SetProof(p) }()
+	return marshalTo(buf, provable)
+}
+
 // Canonicalizer transforms a JSON byte array into its canonical form.
 type Canonicalizer interface {
 	Canonicalize(jsonBytes []byte) ([]byte, error)
 }
 
 // JCSCanonicalizer transforms a JSON byte array using the JSON Canonicalization Scheme algorithm.
+// See util.CanonicalMarshalRaw.
 type JCSCanonicalizer struct{}
 
 func (c *JCSCanonicalizer) Canonicalize(jsonBytes []byte) ([]byte, error) {
-	return jcs.Transform(jsonBytes)
+	return util.CanonicalMarshalRaw(jsonBytes)
 }
 
 // MessageDigest transforms a byte array into a more compact byte array using a hashing digest
@@ -193,6 +261,13 @@ type MessageDigest interface {
 	Digest(data []byte) ([]byte, error)
 }
 
+// bufferDigest is implemented by MessageDigests that can write the digest to a scratch buffer (see
+// ProofOptions.Buffer).
+type bufferDigest interface {
+	// DigestTo writes the digest of the data to the buffer.
+	DigestTo(buf *bytes.Buffer, data []byte)
+}
+
 // Base64Encoder base64 encodes the payload. This is only included to be compatible with the
 // existing proof signatures on verifiable credentials. There's no benefit to base64 encoding a
 // byte array that represents utf-8 characters, since little- and big-endianness does not apply.
@@ -203,11 +278,24 @@ func (e *Base64Encoder) Digest(data []byte) ([]byte, error) {
 	return []byte(encoded), nil
 }
 
+func (e *Base64Encoder) DigestTo(buf *bytes.Buffer, data []byte) {
+	encoder := base64.NewEncoder(base64.StdEncoding, buf)
+	_, _ = encoder.Write(data)
+	_ = encoder.Close()
+}
+
 // OptionsAppender appends the proof options (metadata) to the payload before signing or verifying.
 type OptionsAppender interface {
 	Append(data []byte, proof *Proof) []byte
 }
 
+// bufferAppender is implemented by OptionsAppenders that can append to a scratch buffer (see
+// ProofOptions.Buffer).
+type bufferAppender interface {
+	// AppendTo appends the proof options to the payload in the buffer.
+	AppendTo(buf *bytes.Buffer, proof *Proof)
+}
+
 // NonceAppender appends ".<nonce>" to the payload before signing or verifying.
 // The nonce adds randomness in order to prevent a replay attack. Workday's earlier signature
 // algorithms only included this field and did not sign over the other proof metadata fields.
@@ -216,3 +304,8 @@ type NonceAppender struct{}
 func (n *NonceAppender) Append(data []byte, proof *Proof) []byte {
 	return util.AddNonceToDoc(data, proof.Nonce)
 }
+
+func (n *NonceAppender) AppendTo(buf *bytes.Buffer, proof *Proof) {
+	buf.WriteByte('.')
+	buf.WriteString(proof.Nonce)
+}
@@ -0,0 +1,61 @@
+package proof
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// SignedMessage is a typed payload with a proof, for messages that need no envelope of their own.
+// It marshals to {"payload": ..., "proof": ...}: the payload is nested rather than flattened into
+// the envelope, so that payloads of any JSON type can be signed, and a payload's own "proof"
+// property cannot collide with the envelope's.
+type SignedMessage[T any] struct {
+	Payload T      `json:"payload"`
+	Proof   *Proof `json:"proof,omitempty"`
+}
+
+func (m *SignedMessage[T]) GetProof() *Proof {
+	return m.Proof
+}
+
+func (m *SignedMessage[T]) SetProof(p *Proof) {
+	m.Proof = p
+}
+
+// SignMessage signs the payload with the newest proof model version of the signature type.
+func SignMessage[T any](payload T, signer Signer, sigType SignatureType) (*SignedMessage[T], error) {
+	suite, err := SignatureSuites().GetSuite(sigType, V2)
+	if err != nil {
+		if suite, err = SignatureSuites().GetSuite(sigType, V1); err != nil {
+			return nil, err
+		}
+	}
+	msg := &SignedMessage[T]{Payload: payload}
+	if err := suite.Sign(msg, signer); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// VerifyMessage decodes the signed message and verifies its proof with the verifier. Numbers in
+// payload values decoded into interface{} are kept as json.Number (see util.UnmarshalUseNumber),
+// so that the payload marshals back to what was signed.
+func VerifyMessage[T any](data []byte, verifier Verifier) (*SignedMessage[T], error) {
+	var msg SignedMessage[T]
+	if err := util.UnmarshalUseNumber(data, &msg); err != nil {
+		return nil, errors.Wrap(err, "invalid signed message")
+	}
+	if msg.Proof.IsEmpty() {
+		return nil, errcode.New(errcode.MalformedProof, "signed message does not have a proof")
+	}
+	suite, err := SignatureSuites().GetSuiteForProof(msg.Proof)
+	if err != nil {
+		return nil, err
+	}
+	if err := suite.Verify(&msg, verifier); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
@@ -0,0 +1,87 @@
+package proof
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+type messageTestAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type messageTestPayload struct {
+	Name    string                 `json:"name"`
+	Address messageTestAddress     `json:"address"`
+	Tags    map[string]interface{} `json:"tags"`
+}
+
+func TestSignedMessage(t *testing.T) {
+	signer, err := NewEd25519Signer(privKey, "key-1")
+	require.NoError(t, err)
+	verifier := &Ed25519Verifier{PubKey: pubKey}
+
+	payload := messageTestPayload{
+		Name:    "Workday",
+		Address: messageTestAddress{Street: "6110 Stoneridge Mall Rd", City: "Pleasanton"},
+		Tags: map[string]interface{}{
+			"id":     json.Number("123456789012345678901"),
+			"nested": map[string]interface{}{"b": true, "a": []interface{}{"x", "y"}},
+		},
+	}
+
+	for _, sigType := range []SignatureType{JCSEdSignatureType, Ed25519SignatureType} {
+		t.Run(string(sigType), func(t *testing.T) {
+			msg, err := SignMessage(payload, signer, sigType)
+			require.NoError(t, err)
+			data, err := json.Marshal(msg)
+			require.NoError(t, err)
+
+			verified, err := VerifyMessage[messageTestPayload](data, verifier)
+			require.NoError(t, err)
+			assert.Equal(t, payload, verified.Payload)
+
+			// The payload is nested under "payload".
+			var raw map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(data, &raw))
+			assert.Contains(t, raw, "payload")
+			assert.Contains(t, raw, "proof")
+
+			// Untyped payloads verify too.
+			_, err = VerifyMessage[map[string]interface{}](data, verifier)
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("Tampered", func(t *testing.T) {
+		msg, err := SignMessage(payload, signer, JCSEdSignatureType)
+		require.NoError(t, err)
+		msg.Payload.Address.City = "Dublin"
+		data, err := json.Marshal(msg)
+		require.NoError(t, err)
+		_, err = VerifyMessage[messageTestPayload](data, verifier)
+		assert.Error(t, err)
+	})
+
+	t.Run("Without proof", func(t *testing.T) {
+		_, err := VerifyMessage[messageTestPayload]([]byte(`{"payload":{"name":"Workday"}}`), verifier)
+		assert.Equal(t, errcode.MalformedProof, errcode.CodeOf(err))
+		_, err = VerifyMessage[messageTestPayload]([]byte(`not json`), verifier)
+		assert.Error(t, err)
+	})
+
+	t.Run("Scalar payload", func(t *testing.T) {
+		msg, err := SignMessage("hello", signer, JCSEdSignatureType)
+		require.NoError(t, err)
+		data, err := json.Marshal(msg)
+		require.NoError(t, err)
+		verified, err := VerifyMessage[string](data, verifier)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", verified.Payload)
+	})
+}
@@ -3,14 +3,23 @@ package proof
 import (
 	"crypto"
 	"crypto/rand"
-	"errors"
+	"encoding/json"
 	"reflect"
+	"time"
 
+	"github.com/pkg/errors"
 	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
 )
 
 var (
 	EmptyProof = Proof{}
+
+	// ErrIncorrectKeyType is returned when signing with a signature suite that does not support
+	// the signer's key type.
+	ErrIncorrectKeyType = errcode.New(errcode.UnsupportedSuite, "incorrect key type")
 )
 
 type (
@@ -29,9 +38,23 @@ const (
 	Ed25519KeyType     KeyType       = "Ed25519VerificationKey2018"
 	JCSEdSignatureType SignatureType = "JcsEd25519Signature2020"
 
+	// Ed25519VerificationKey2020KeyType is the 2020 revision of the Ed25519 key type, which
+	// carries the key as publicKeyMultibase rather than publicKeyBase58.
+	Ed25519VerificationKey2020KeyType KeyType = "Ed25519VerificationKey2020"
+
+	// X25519KeyType is used for key agreement (encryption) keys, never for signatures.
+	X25519KeyType KeyType = "X25519KeyAgreementKey2019"
+
 	EcdsaSecp256k1KeyType       KeyType       = "EcdsaSecp256k1VerificationKey2019"
 	EcdsaSecp256k1SignatureType SignatureType = "EcdsaSecp256k1Signature2019"
 
+	// JSONWebKey2020KeyType is used for keys published as a JWK (publicKeyJwk). The algorithm is
+	// determined by the JWK's key type and curve.
+	JSONWebKey2020KeyType KeyType = "JsonWebKey2020"
+
+	// EcdsaSecp256r1KeyType is used for NIST P-256 keys, such as those held in mobile secure enclaves.
+	EcdsaSecp256r1KeyType KeyType = "EcdsaSecp256r1VerificationKey2019"
+
 	// Deprecated: Do not create more keys of this type. The system can still use these keys
 	// for support of existing DID Documents.
 	WorkEdKeyType KeyType = "WorkEd25519VerificationKey2020"
@@ -48,6 +71,17 @@ const (
 	V2 ModelVersion = 2
 )
 
+// SignatureTypes lists every signature type that SignatureSuites supports, in any proof model
+// version, in order of preference for Negotiate. New signature types must be added here, so that
+// they are advertised by Capabilities and covered by the interop fixtures (see the interop
+// package).
+var SignatureTypes = []SignatureType{
+	JCSEdSignatureType,
+	WorkEdSignatureType,
+	Ed25519SignatureType,
+	EcdsaSecp256k1SignatureType,
+}
+
 // Proof represents a verifiable digital signature.
 type Proof struct {
 	// Created is the datetime (RFC3339) when the signature was generated.
@@ -72,6 +106,40 @@ func (p *Proof) IsEmpty() bool {
 	return reflect.DeepEqual(p, &Proof{})
 }
 
+// Clone returns a copy of the proof, or nil if the proof is nil.
+func (p *Proof) Clone() *Proof {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	return &c
+}
+
+// DecodeProof reads a JSON proof. Like json.Unmarshal, unknown properties are ignored.
+func DecodeProof(data []byte) (*Proof, error) {
+	var p Proof
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid proof")
+	}
+	return &p, nil
+}
+
+// DecodeProofStrict reads a JSON proof, rejecting unknown properties with a
+// util.UnknownFieldError (see util.StrictUnmarshal). Use it for proofs from untrusted sources,
+// where a property we do not recognize must not be silently dropped.
+func DecodeProofStrict(data []byte) (*Proof, error) {
+	var p Proof
+	if err := util.StrictUnmarshal(data, &p); err != nil {
+		return nil, errcode.Wrap(errcode.MalformedProof, err, "invalid proof")
+	}
+	return &p, nil
+}
+
+// CreatedTime parses the proof's created timestamp (see util.ParseRFC3339Lenient).
+func (p *Proof) CreatedTime() (time.Time, error) {
+	return util.ParseRFC3339Lenient(p.Created)
+}
+
 func (p *Proof) ModelVersion() ModelVersion {
 	if p.Creator != "" {
 		return V1
@@ -94,6 +162,42 @@ type Provable interface {
 	SetProof(p *Proof)
 }
 
+// Identifiable is implemented by documents that have an identifier of their own, e.g. the DID of a
+// DID Document or the ID of a credential, for logging, deduplication and status checks.
+type Identifiable interface {
+	GetID() string
+}
+
+// IDOf returns the identifier of the provable if it is Identifiable. The bool is false for
+// provables without an identifier, and for identifiable provables whose identifier is empty.
+func IDOf(p Provable) (string, bool) {
+	identifiable, ok := p.(Identifiable)
+	if !ok {
+		return "", false
+	}
+	id := identifiable.GetID()
+	return id, id != ""
+}
+
+// CopyProvable returns a deep copy of the provable, including its proof, with the same concrete
+// type. The provable must be a non-nil pointer. The copy is made through JSON (see
+// util.DeepCopyJSON), so it contains exactly what is signed, and a copy of a signed provable
+// verifies the same way as the original.
+func CopyProvable(provable Provable) (Provable, error) {
+	value := reflect.ValueOf(provable)
+	if provable == nil || value.Kind() != reflect.Ptr || value.IsNil() {
+		return nil, errors.New("provable must be a non-nil pointer")
+	}
+	c, ok := reflect.New(value.Elem().Type()).Interface().(Provable)
+	if !ok {
+		return nil, errors.New("provable must be a non-nil pointer")
+	}
+	if err := util.DeepCopyJSON(provable, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
 // Signer can generate digital signatures using a particular signing algorithm.
 // This is basically a wrapper around a private key.
 //
@@ -154,6 +258,12 @@ func (s *Ed25519Signer) Sign(toSign []byte) ([]byte, error) {
 	return s.PrivateKey.Sign(rand.Reader, toSign, crypto.Hash(0))
 }
 
+// Zeroize wipes the private key. The key is shared with the caller of NewEd25519Signer, so it is
+// wiped there too. The signer must not be used afterwards.
+func (s *Ed25519Signer) Zeroize() {
+	util.Zeroize(s.PrivateKey)
+}
+
 func (s *Ed25519Signer) Type() KeyType {
 	return Ed25519KeyType
 }
@@ -0,0 +1,76 @@
+package proof
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// ProofOptions overrides the values that a ProofFactory would otherwise generate when signing.
+// This is primarily useful for producing reproducible proofs, e.g. for test fixtures.
+type ProofOptions struct {
+	// Now returns the time used for the proof's created timestamp.
+	Now func() time.Time
+	// Nonce returns the proof's nonce.
+	Nonce func() string
+	// Buffer is a scratch buffer for the JSON and the payload that are signed, so that signing many
+	// documents with one buffer, e.g. one per worker of a batch, does not allocate them anew for
+	// each signature. The buffer must not be used concurrently, and the signer must not retain the
+	// payload after signing it.
+	Buffer *bytes.Buffer
+}
+
+// ProofOption configures ProofOptions.
+type ProofOption func(*ProofOptions)
+
+// WithClock sets the clock used for the proof's created timestamp.
+func WithClock(now func() time.Time) ProofOption {
+	return func(o *ProofOptions) {
+		o.Now = now
+	}
+}
+
+// WithNonce sets the function used to generate the proof's nonce.
+func WithNonce(nonce func() string) ProofOption {
+	return func(o *ProofOptions) {
+		o.Nonce = nonce
+	}
+}
+
+// WithBuffer sets the scratch buffer used to encode the payload that is signed (see
+// ProofOptions.Buffer).
+func WithBuffer(buf *bytes.Buffer) ProofOption {
+	return func(o *ProofOptions) {
+		o.Buffer = buf
+	}
+}
+
+// optionsSigner is implemented by signature suites that can apply ProofOptions.
+type optionsSigner interface {
+	SignWithOptions(provable Provable, signer Signer, opts ...ProofOption) error
+}
+
+// SignWithOptions signs the provable with the suite, applying the options to the created proof.
+// Returns an error if options are given and the suite does not support them.
+func SignWithOptions(suite SignatureSuite, provable Provable, signer Signer, opts ...ProofOption) error {
+	if s, ok := suite.(optionsSigner); ok {
+		return s.SignWithOptions(provable, signer, opts...)
+	}
+	if len(opts) > 0 {
+		return errcode.New(errcode.UnsupportedSuite, "signature suite does not support proof options")
+	}
+	return suite.Sign(provable, signer)
+}
+
+// apply overrides the generated values of the proof. Values that the ProofFactory did not set
+// are left empty.
+func (o ProofOptions) apply(p *Proof) {
+	if o.Now != nil && p.Created != "" {
+		p.Created = util.FormatCanonicalTime(o.Now())
+	}
+	if o.Nonce != nil && p.Nonce != "" {
+		p.Nonce = o.Nonce()
+	}
+}
@@ -0,0 +1,174 @@
+// Package prooftest provides deterministic signers, verifiers, clocks, nonces and randomness for
+// tests of code that signs with the proof package, and assertions on proofs. The helpers plug
+// into the injectable options of the proof package (proof.WithClock, proof.WithNonce and
+// util.SetRandReader), so that signed documents are byte-for-byte reproducible and can be
+// compared with golden files.
+//
+// Keys are derived from the SHA-256 digest of the seed, as did/didtest.NewKeyPair does, so that
+// DeterministicSigner(1) has the same key as didtest.NewKeyPair(t, []byte{1}).
+package prooftest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// Created is the proof creation time of DeterministicOptions.
+var Created = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// KeyID returns the key reference of the seed's key, e.g. "did:test:01#key-1". The DID does not
+// resolve; verify proofs with DeterministicVerifier.
+func KeyID(seed byte) string {
+	return fmt.Sprintf("did:test:%02x#key-1", seed)
+}
+
+// DeterministicKey returns the Ed25519 private key of the seed.
+func DeterministicKey(seed byte) ed25519.PrivateKey {
+	digest := sha256.Sum256([]byte{seed})
+	defer util.Zeroize(digest[:])
+	return ed25519.NewKeyFromSeed(digest[:])
+}
+
+// DeterministicSigner returns an Ed25519 signer for the seed's key, with KeyID(seed) as its key
+// reference. Ed25519 signatures are deterministic, so signing the same document with the same
+// proof options always yields the same proof.
+func DeterministicSigner(seed byte) *proof.Ed25519Signer {
+	return &proof.Ed25519Signer{KeyID: KeyID(seed), PrivateKey: DeterministicKey(seed)}
+}
+
+// DeterministicVerifier returns the verifier of the signatures of DeterministicSigner(seed).
+func DeterministicVerifier(seed byte) proof.Verifier {
+	return &proof.Ed25519Verifier{PubKey: DeterministicKey(seed).Public().(ed25519.PublicKey)}
+}
+
+// FixedClock returns a clock that always returns the time, for proof.WithClock and the clock
+// options of other packages.
+func FixedClock(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+// SequentialNonceGenerator returns a nonce generator for proof.WithNonce that returns UUIDs
+// numbered from 1, e.g. "00000000-0000-4000-8000-000000000001". It is safe for concurrent use.
+func SequentialNonceGenerator() func() string {
+	var counter uint64
+	return func() string {
+		return fmt.Sprintf("00000000-0000-4000-8000-%012d", atomic.AddUint64(&counter, 1))
+	}
+}
+
+// DeterministicOptions returns the proof options of reproducible proofs: a FixedClock at Created
+// and a SequentialNonceGenerator.
+func DeterministicOptions() []proof.ProofOption {
+	return []proof.ProofOption{
+		proof.WithClock(FixedClock(Created)),
+		proof.WithNonce(SequentialNonceGenerator()),
+	}
+}
+
+// DeterministicRand returns a deterministic source of randomness: the SHA-256 digests of the seed
+// and a counter.
+func DeterministicRand(seed byte) io.Reader {
+	return &hashReader{seed: seed}
+}
+
+// UseDeterministicRand makes util.RandReader return DeterministicRand(seed) until the end of the
+// test, e.g. for IDs and key generation. Tests that use it must not run in parallel with tests
+// that depend on randomness.
+func UseDeterministicRand(t testing.TB, seed byte) {
+	restore := util.SetRandReader(DeterministicRand(seed))
+	t.Cleanup(restore)
+}
+
+type hashReader struct {
+	seed    byte
+	counter uint64
+	buf     []byte
+}
+
+func (r *hashReader) Read(p []byte) (int, error) {
+	for len(r.buf) < len(p) {
+		block := sha256.Sum256([]byte(fmt.Sprintf("%d.%d", r.seed, r.counter)))
+		r.counter++
+		r.buf = append(r.buf, block[:]...)
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// AssertVerifies asserts that the proof of the provable verifies with the verifier, using the
+// signature suite of the proof. Returns whether the assertion succeeded.
+func AssertVerifies(t testing.TB, provable proof.Provable, verifier proof.Verifier) bool {
+	t.Helper()
+	p := provable.GetProof()
+	if p.IsEmpty() {
+		t.Errorf("provable does not have a proof")
+		return false
+	}
+	suite, err := proof.SignatureSuites().GetSuiteForProof(p)
+	if err != nil {
+		t.Errorf("no signature suite for proof: %v", err)
+		return false
+	}
+	if err := suite.Verify(provable, verifier); err != nil {
+		t.Errorf("proof does not verify: %v", err)
+		return false
+	}
+	return true
+}
+
+// AssertProofEquals asserts that the proofs are equal, and lists the differing properties
+// otherwise. Returns whether the assertion succeeded.
+func AssertProofEquals(t testing.TB, want, got *proof.Proof) bool {
+	t.Helper()
+	return AssertJSONEquals(t, want, got)
+}
+
+// AssertJSONEquals asserts that the values marshal to logically equal JSON (see util.JSONDiff),
+// e.g. to compare a signed document with a golden file, and lists the differences by path
+// otherwise. Values of type []byte and json.RawMessage are compared as JSON text. Returns whether
+// the assertion succeeded.
+func AssertJSONEquals(t testing.TB, want, got interface{}) bool {
+	t.Helper()
+	wantJSON, err := toJSON(want)
+	if err != nil {
+		t.Errorf("invalid expected value: %v", err)
+		return false
+	}
+	gotJSON, err := toJSON(got)
+	if err != nil {
+		t.Errorf("invalid actual value: %v", err)
+		return false
+	}
+	diff, err := util.JSONDiff(wantJSON, gotJSON)
+	if err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	if len(diff) > 0 {
+		t.Errorf("JSON differs (a is want, b is got):\n\t%s", strings.Join(diff, "\n\t"))
+		return false
+	}
+	return true
+}
+
+func toJSON(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case json.RawMessage:
+		return v, nil
+	}
+	return json.Marshal(v)
+}
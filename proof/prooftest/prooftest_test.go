@@ -0,0 +1,196 @@
+package prooftest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/workdaycredentials/ledger-common/did/didtest"
+	"github.com/workdaycredentials/ledger-common/proof"
+	"github.com/workdaycredentials/ledger-common/util"
+)
+
+// recordingT records the failures of assertions that are expected to fail.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// signedDocument signs a document with the seed's key and deterministic options.
+func signedDocument(t *testing.T, seed byte) *proof.Document {
+	doc, err := proof.ParseDocument([]byte(`{"id":"urn:uuid:1","claims":{"b":[1,2],"a":"x"}}`))
+	require.NoError(t, err)
+	suite, err := proof.SignatureSuites().GetSuite(proof.JCSEdSignatureType, proof.V2)
+	require.NoError(t, err)
+	require.NoError(t, proof.SignWithOptions(suite, doc, DeterministicSigner(seed), DeterministicOptions()...))
+	return doc
+}
+
+func TestDeterministicSigner(t *testing.T) {
+	signer := DeterministicSigner(1)
+	assert.Equal(t, "did:test:01#key-1", signer.ID())
+	assert.Equal(t, proof.Ed25519KeyType, signer.Type())
+	assert.Equal(t, DeterministicKey(1), signer.PrivateKey)
+	assert.NotEqual(t, DeterministicKey(1), DeterministicKey(2))
+
+	_, privateKey := didtest.NewKeyPair(t, []byte{1})
+	assert.Equal(t, privateKey, DeterministicKey(1))
+
+	data := []byte("data")
+	signature, err := signer.Sign(data)
+	require.NoError(t, err)
+	ok, err := DeterministicVerifier(1).Verify(data, signature)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = DeterministicVerifier(2).Verify(data, signature)
+	assert.False(t, ok)
+}
+
+func TestFixedClock(t *testing.T) {
+	now := time.Date(2021, time.March, 4, 5, 6, 7, 0, time.UTC)
+	clock := FixedClock(now)
+	assert.Equal(t, now, clock())
+	assert.Equal(t, now, clock())
+}
+
+func TestSequentialNonceGenerator(t *testing.T) {
+	nonce := SequentialNonceGenerator()
+	assert.Equal(t, "00000000-0000-4000-8000-000000000001", nonce())
+	assert.Equal(t, "00000000-0000-4000-8000-000000000002", nonce())
+	assert.Equal(t, "00000000-0000-4000-8000-000000000001", SequentialNonceGenerator()())
+
+	// Nonces are unique across goroutines.
+	nonce = SequentialNonceGenerator()
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				n := nonce()
+				mu.Lock()
+				seen[n] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, 1000)
+}
+
+func TestDeterministicRand(t *testing.T) {
+	read := func(r io.Reader, n int) []byte {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		require.NoError(t, err)
+		return b
+	}
+	assert.Equal(t, read(DeterministicRand(1), 100), read(DeterministicRand(1), 100))
+	assert.NotEqual(t, read(DeterministicRand(1), 32), read(DeterministicRand(2), 32))
+
+	t.Run("Use", func(t *testing.T) {
+		UseDeterministicRand(t, 1)
+		first := util.NewURNUUID()
+		UseDeterministicRand(t, 1)
+		assert.Equal(t, first, util.NewURNUUID())
+	})
+	// The reader is restored at the end of the test.
+	assert.NotEqual(t, util.NewURNUUID(), util.NewURNUUID())
+}
+
+func TestGoldenDocument(t *testing.T) {
+	doc := signedDocument(t, 1)
+	AssertVerifies(t, doc, DeterministicVerifier(1))
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	AssertJSONEquals(t, []byte(goldenDocument), data)
+
+	// The same options always yield the same document.
+	again, err := json.Marshal(signedDocument(t, 1))
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+}
+
+func TestAssertions(t *testing.T) {
+	doc := signedDocument(t, 1)
+
+	t.Run("AssertVerifies", func(t *testing.T) {
+		assert.True(t, AssertVerifies(t, doc, DeterministicVerifier(1)))
+
+		recorder := &recordingT{TB: t}
+		assert.False(t, AssertVerifies(recorder, doc, DeterministicVerifier(2)))
+		unsigned, err := proof.ParseDocument([]byte(`{}`))
+		require.NoError(t, err)
+		assert.False(t, AssertVerifies(recorder, unsigned, DeterministicVerifier(1)))
+		assert.Len(t, recorder.errors, 2)
+	})
+
+	t.Run("AssertProofEquals", func(t *testing.T) {
+		want := doc.GetProof()
+		assert.True(t, AssertProofEquals(t, want, signedDocument(t, 1).GetProof()))
+
+		recorder := &recordingT{TB: t}
+		assert.False(t, AssertProofEquals(recorder, want, signedDocument(t, 2).GetProof()))
+		require.Len(t, recorder.errors, 1)
+		assert.Contains(t, recorder.errors[0], "$.signatureValue:")
+		assert.Contains(t, recorder.errors[0], "$.verificationMethod: \"did:test:01#key-1\" != \"did:test:02#key-1\"")
+	})
+
+	t.Run("AssertJSONEquals", func(t *testing.T) {
+		assert.True(t, AssertJSONEquals(t, []byte(`{"a":1.0,"b":[1]}`), json.RawMessage(`{"b":[1],"a":1}`)))
+		assert.True(t, AssertJSONEquals(t, map[string]int{"a": 1}, []byte(`{"a":1}`)))
+
+		recorder := &recordingT{TB: t}
+		assert.False(t, AssertJSONEquals(recorder, []byte(`{"a":1,"b":2}`), []byte(`{"a":2}`)))
+		assert.False(t, AssertJSONEquals(recorder, []byte(`not json`), []byte(`{}`)))
+		require.Len(t, recorder.errors, 2)
+		assert.True(t, strings.Contains(recorder.errors[0], "$.a: 1 != 2"), recorder.errors[0])
+		assert.True(t, strings.Contains(recorder.errors[0], "$.b: missing from b"), recorder.errors[0])
+	})
+}
+
+func TestWithResolver(t *testing.T) {
+	// Deterministic verifiers plug into proof.VerifyWithResolver.
+	doc := signedDocument(t, 1)
+	resolver := verifierResolver{KeyID(1): DeterministicVerifier(1)}
+	assert.NoError(t, proof.VerifyWithResolver(context.Background(), doc, resolver))
+}
+
+type verifierResolver map[string]proof.Verifier
+
+func (r verifierResolver) ResolveVerifier(_ context.Context, keyRef string) (proof.Verifier, error) {
+	verifier, ok := r[keyRef]
+	if !ok {
+		return nil, fmt.Errorf("unknown key<%s>", keyRef)
+	}
+	return verifier, nil
+}
+
+// goldenDocument is the document of signedDocument for seed 1.
+const goldenDocument = `{
+  "id": "urn:uuid:1",
+  "claims": {"b": [1, 2], "a": "x"},
+  "proof": {
+    "type": "JcsEd25519Signature2020",
+    "created": "2020-01-01T00:00:00Z",
+    "verificationMethod": "did:test:01#key-1",
+    "nonce": "00000000-0000-4000-8000-000000000001",
+    "signatureValue": "41j2xg3q73JF68XQ4jv66pKPneCQEKUSWGADi3cWuagq5zTzov51i6BGNYP2pTSws5kPCaciLk21cvZ2i3YJTeQe"
+  }
+}`
@@ -0,0 +1,134 @@
+package proof
+
+import (
+	"context"
+	"time"
+
+	"github.com/workdaycredentials/ledger-common/util/errcode"
+)
+
+// VerifierResolver looks up the public key referenced by a proof's verification method (e.g. by
+// resolving the DID in the key reference) and returns a Verifier for it.
+type VerifierResolver interface {
+	ResolveVerifier(ctx context.Context, verificationMethod string) (Verifier, error)
+}
+
+// VerifyOption configures VerifyWithResolver.
+type VerifyOption func(*verifyOptions)
+
+type verifyOptions struct {
+	historical       bool
+	asOfCreated      bool
+	rdfCanonicalizer RDFCanonicalizer
+	now              func() time.Time
+	skew             time.Duration
+}
+
+type historicalKey struct{}
+
+type asOfKey struct{}
+
+// AllowHistorical allows proofs to be verified with keys from deactivated DID Documents, e.g. to
+// check a signature that was created before the DID was deactivated. By default such keys are
+// refused.
+func AllowHistorical() VerifyOption {
+	return func(o *verifyOptions) {
+		o.historical = true
+	}
+}
+
+// HistoricalAllowed returns true if the context passed to VerifierResolver.ResolveVerifier allows
+// keys from deactivated DID Documents (see AllowHistorical). Otherwise, resolvers must refuse
+// keys from deactivated documents.
+func HistoricalAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(historicalKey{}).(bool)
+	return allowed
+}
+
+// AsOfProofCreated resolves the verification method as it was when the proof was created, so that
+// proofs can still be verified after their key has been rotated out of the DID Document. The
+// proof must have a created time. Resolvers that cannot resolve past versions fail.
+func AsOfProofCreated() VerifyOption {
+	return func(o *verifyOptions) {
+		o.asOfCreated = true
+	}
+}
+
+// ResolveAsOf returns the time as of which the context passed to VerifierResolver.ResolveVerifier
+// asks for the verification method to be resolved (see AsOfProofCreated). The bool is false if
+// the current version should be used.
+func ResolveAsOf(ctx context.Context) (time.Time, bool) {
+	asOf, ok := ctx.Value(asOfKey{}).(time.Time)
+	return asOf, ok
+}
+
+// WithVerifyClock sets the clock that the time checks of verification are made against, such as
+// the validity window of a credential (see NewVerifyPolicy). The default is time.Now.
+func WithVerifyClock(now func() time.Time) VerifyOption {
+	return func(o *verifyOptions) {
+		o.now = now
+	}
+}
+
+// WithClockSkew tolerates clocks that are out of sync by up to the skew in the time checks of
+// verification: a validity window is widened by the skew on both sides. The default is no skew.
+func WithClockSkew(skew time.Duration) VerifyOption {
+	return func(o *verifyOptions) {
+		o.skew = skew
+	}
+}
+
+// VerifyPolicy is the time policy set by VerifyOptions, for packages that check the validity of
+// what they verify, e.g. the validity window of a credential.
+type VerifyPolicy struct {
+	// Now returns the current time (see WithVerifyClock).
+	Now func() time.Time
+	// ClockSkew is the tolerated clock skew (see WithClockSkew).
+	ClockSkew time.Duration
+}
+
+// NewVerifyPolicy returns the time policy set by the options.
+func NewVerifyPolicy(opts ...VerifyOption) VerifyPolicy {
+	options := verifyOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return VerifyPolicy{Now: options.now, ClockSkew: options.skew}
+}
+
+// VerifyWithResolver verifies the proof on the provable, using the resolver to find the key
+// referenced by the proof's verification method.
+func VerifyWithResolver(ctx context.Context, provable Provable, resolver VerifierResolver, opts ...VerifyOption) error {
+	var options verifyOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.historical {
+		ctx = context.WithValue(ctx, historicalKey{}, true)
+	}
+
+	p := provable.GetProof()
+	if p.IsEmpty() {
+		return errcode.New(errcode.MalformedProof, "proof cannot be empty")
+	}
+	verificationMethod := p.GetVerificationMethod()
+	if verificationMethod == "" {
+		return errcode.New(errcode.MalformedProof, "proof does not have a verification method")
+	}
+	if options.asOfCreated {
+		created, err := p.CreatedTime()
+		if err != nil {
+			return errcode.Wrap(errcode.MalformedProof, err, "proof does not have a valid created time")
+		}
+		ctx = context.WithValue(ctx, asOfKey{}, created)
+	}
+	verifier, err := resolver.ResolveVerifier(ctx, verificationMethod)
+	if err != nil {
+		return errcode.Wrapf(errcode.ResolutionFailed, err, "unable to resolve verification method<%s>", verificationMethod)
+	}
+	suite, err := SignatureSuites().GetSuiteForProof(p)
+	if err != nil {
+		return err
+	}
+	return suite.Verify(provable, verifier)
+}
@@ -2,8 +2,10 @@ package proof
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 
 	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/btcsuite/btcd/btcec"
@@ -43,6 +45,51 @@ func (s SecP256K1KMSSigner) Sign(toSign []byte) ([]byte, error) {
 	return signOutput.Signature, nil
 }
 
+// Secp256K1Signer signs with an in-memory secp256k1 private key. Signatures are DER encoded
+// over the SHA-256 digest of the payload, which is what Secp256K1Verifier expects.
+// Intended to be constructed via the `NewSecp256k1Signer` method
+type Secp256K1Signer struct {
+	// The fully qualified key id (e.g. did:work:abcd#key-1)
+	KeyID      string
+	PrivateKey *btcec.PrivateKey
+}
+
+// NewSecp256k1Signer is used to build a secp256k1 signer with validations
+func NewSecp256k1Signer(key *ecdsa.PrivateKey, keyID string) (Signer, error) {
+	if key == nil || key.Curve != btcec.S256() {
+		return nil, errors.New("must have valid secp256k1 private key")
+	}
+	if keyID == "" {
+		return nil, errors.New("must have valid key ID")
+	}
+	return &Secp256K1Signer{KeyID: keyID, PrivateKey: (*btcec.PrivateKey)(key)}, nil
+}
+
+func (s *Secp256K1Signer) ID() string {
+	return s.KeyID
+}
+
+// Zeroize wipes the private key scalar. The key is shared with the caller of NewSecp256k1Signer,
+// so it is wiped there too. The signer must not be used afterwards.
+func (s *Secp256K1Signer) Zeroize() {
+	if s.PrivateKey != nil {
+		util.ZeroizeBigInt(s.PrivateKey.D)
+	}
+}
+
+func (s *Secp256K1Signer) Sign(toSign []byte) ([]byte, error) {
+	hash := sha256.Sum256(toSign)
+	signature, err := s.PrivateKey.Sign(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return signature.Serialize(), nil
+}
+
+func (s *Secp256K1Signer) Type() KeyType {
+	return EcdsaSecp256k1KeyType
+}
+
 type Secp256K1Verifier struct {
 	PublicKey []byte
 }
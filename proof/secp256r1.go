@@ -0,0 +1,42 @@
+package proof
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+)
+
+// P256Verifier verifies signatures of NIST P-256 keys, e.g. the keys of P-256 DID Keys. Like
+// Secp256K1Verifier, it expects DER encoded signatures over the SHA-256 digest of the payload;
+// JWS verifies ES256 signatures by converting them to DER first.
+type P256Verifier struct {
+	// PublicKey is the SEC1 encoded public key, either compressed or uncompressed.
+	PublicKey []byte
+}
+
+func (v *P256Verifier) Type() KeyType {
+	return EcdsaSecp256r1KeyType
+}
+
+func (v *P256Verifier) Verify(data, signature []byte) (bool, error) {
+	publicKey, err := parseP256PublicKey(v.PublicKey)
+	if err != nil {
+		return false, err
+	}
+	hash := sha256.Sum256(data)
+	return ecdsa.VerifyASN1(publicKey, hash[:], signature), nil
+}
+
+// parseP256PublicKey decodes a compressed or uncompressed SEC1 encoded P-256 public key.
+func parseP256PublicKey(encoded []byte) (*ecdsa.PublicKey, error) {
+	curve := elliptic.P256()
+	x, y := elliptic.UnmarshalCompressed(curve, encoded)
+	if x == nil {
+		x, y = elliptic.Unmarshal(curve, encoded)
+	}
+	if x == nil {
+		return nil, errors.New("invalid P-256 public key")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
@@ -1,7 +1,7 @@
 package proof
 
 import (
-	"fmt"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
 )
 
 // SignatureSuite is a set of algorithms that specify how to sign and verify provable objects.
@@ -55,6 +55,10 @@ func (s *compositeSignatureSuite) Sign(provable Provable, signer Signer) error {
 	return s.main.Sign(provable, signer)
 }
 
+func (s *compositeSignatureSuite) SignWithOptions(provable Provable, signer Signer, opts ...ProofOption) error {
+	return SignWithOptions(s.main, provable, signer, opts...)
+}
+
 func (s *compositeSignatureSuite) Verify(provable Provable, verifier Verifier) error {
 	if err := s.main.Verify(provable, verifier); err != nil {
 		return s.backup.Verify(provable, verifier)
@@ -107,7 +111,7 @@ func (s *signatureSuites) GetSuite(signatureType SignatureType, modelVersion Mod
 		suite = s.getSuiteV2(signatureType)
 	}
 	if suite == nil {
-		err = fmt.Errorf("unsupported signature type: %s:%d", signatureType, modelVersion)
+		err = errcode.Errorf(errcode.UnsupportedSuite, "unsupported signature type: %s:%d", signatureType, modelVersion)
 	}
 	return
 }
@@ -146,7 +150,7 @@ func (s *signatureSuites) GetSuiteForCredentials(signatureType SignatureType, ve
 		suite = s.getSuiteV2Cred(signatureType)
 	}
 	if suite == nil {
-		err = fmt.Errorf("unsupported signature type")
+		err = errcode.New(errcode.UnsupportedSuite, "unsupported signature type")
 	}
 	return
 }
@@ -162,7 +166,7 @@ func (s *signatureSuites) GetSuiteForCredentialsProof(proof *Proof) (suite Signa
 		suite = s.getSuiteV2Cred(proof.Type)
 	}
 	if suite == nil {
-		err = fmt.Errorf("unsupported signature type")
+		err = errcode.New(errcode.UnsupportedSuite, "unsupported signature type")
 	}
 	return
 }
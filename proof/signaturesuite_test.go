@@ -1,13 +1,20 @@
 package proof
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/ed25519"
+
+	"github.com/workdaycredentials/ledger-common/util"
+	"github.com/workdaycredentials/ledger-common/util/errcode"
 )
 
 type provableTestData struct {
@@ -111,6 +118,47 @@ func TestSignAndVerify(t *testing.T) {
 	})
 }
 
+func TestSignWithOptions(t *testing.T) {
+	privKey := ed25519.NewKeyFromSeed(seed)
+	pubKey := privKey.Public().(ed25519.PublicKey)
+	signer, err := NewEd25519Signer(privKey, "key-1")
+	assert.NoError(t, err)
+	verifier := &Ed25519Verifier{PubKey: pubKey}
+
+	created := time.Date(2020, time.May, 1, 12, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+	opts := []ProofOption{
+		WithClock(func() time.Time { return created }),
+		WithNonce(func() string { return "fixed-nonce" }),
+	}
+
+	suites := map[string]SignatureSuite{
+		"JCS":    jcsEd25519SignatureSuite,
+		"WorkV1": workSignatureSuiteV1,
+		"WorkV2": workSignatureSuiteV2,
+	}
+	for name, suite := range suites {
+		t.Run(name, func(t *testing.T) {
+			first := provableTestData{A: "hello"}
+			assert.NoError(t, SignWithOptions(suite, &first, signer, opts...))
+			assert.Equal(t, "2020-05-01T17:00:00Z", first.Proof.Created)
+			assert.Equal(t, "fixed-nonce", first.Proof.Nonce)
+			assert.NoError(t, suite.Verify(&first, verifier))
+
+			// Ed25519 signatures are deterministic, so the whole proof is reproducible.
+			second := provableTestData{A: "hello"}
+			assert.NoError(t, SignWithOptions(suite, &second, signer, opts...))
+			assert.Equal(t, first.Proof, second.Proof)
+		})
+	}
+
+	t.Run("Without options", func(t *testing.T) {
+		provable := provableTestData{A: "hello"}
+		assert.NoError(t, SignWithOptions(jcsEd25519SignatureSuite, &provable, signer))
+		assert.NotEqual(t, "fixed-nonce", provable.Proof.Nonce)
+		assert.NoError(t, jcsEd25519SignatureSuite.Verify(&provable, verifier))
+	})
+}
+
 func TestVerify(t *testing.T) {
 	privKey := ed25519.NewKeyFromSeed(seed)
 	pubKey := privKey.Public().(ed25519.PublicKey)
@@ -241,3 +289,288 @@ func TestCreateAndVerifyJCSEd25519Proof(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestSuitesSignCanonicalMarshal(t *testing.T) {
+	signer, err := NewEd25519Signer(privKey, "key-1")
+	assert.NoError(t, err)
+	provable := provableTestData{A: "hello", B: "<world>"}
+	assert.NoError(t, SignWithOptions(jcsEd25519SignatureSuite, &provable, signer,
+		WithClock(func() time.Time { return time.Date(2020, time.June, 5, 1, 12, 15, 0, time.UTC) }),
+		WithNonce(func() string { return "015b5f58-ba8d-4da5-b278-b4a095e09e9c" })))
+
+	signed, err := jcsEd25519SignatureSuite.encode(&provable, nil)
+	assert.NoError(t, err)
+
+	unsigned := provable
+	unsignedProof := *provable.Proof
+	unsignedProof.SignatureValue = ""
+	unsigned.Proof = &unsignedProof
+	expected, err := util.CanonicalMarshal(unsigned)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(signed))
+}
+
+func TestSignWithDeterministicRandomness(t *testing.T) {
+	signer, err := NewEd25519Signer(privKey, "key-1")
+	assert.NoError(t, err)
+	clock := WithClock(func() time.Time { return time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC) })
+
+	sign := func() *Proof {
+		restore := util.SetRandReader(bytes.NewReader(bytes.Repeat([]byte{0x42}, 16)))
+		defer restore()
+		provable := provableTestData{A: "hello"}
+		assert.NoError(t, SignWithOptions(jcsEd25519SignatureSuite, &provable, signer, clock))
+		return provable.Proof
+	}
+	first := sign()
+	assert.Equal(t, "42424242-4242-4242-8242-424242424242", first.Nonce)
+	assert.Equal(t, first, sign())
+}
+
+func TestSignWithBuffer(t *testing.T) {
+	signer, err := NewEd25519Signer(privKey, "key-1")
+	assert.NoError(t, err)
+	clock := WithClock(func() time.Time { return time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC) })
+	nonce := WithNonce(func() string { return "015b5f58-ba8d-4da5-b278-b4a095e09e9c" })
+
+	factory := SignatureSuites()
+	var buf bytes.Buffer
+	for _, sigType := range []SignatureType{JCSEdSignatureType, WorkEdSignatureType, Ed25519SignatureType} {
+		for _, version := range []ModelVersion{V1, V2} {
+			var suites []SignatureSuite
+			if suite, err := factory.GetSuite(sigType, version); err == nil {
+				suites = append(suites, suite)
+			}
+			if suite, err := factory.GetSuiteForCredentials(sigType, version); err == nil {
+				suites = append(suites, suite)
+			}
+			for _, suite := range suites {
+				// The buffer is shared by every signature, as it is by the documents of a batch.
+				for _, b := range []string{"<world>", "a longer value than the previous document had"} {
+					expected := provableTestData{A: "hello", B: b}
+					assert.NoError(t, SignWithOptions(suite, &expected, signer, clock, nonce))
+
+					buffered := provableTestData{A: "hello", B: b}
+					assert.NoError(t, SignWithOptions(suite, &buffered, signer, clock, nonce, WithBuffer(&buf)))
+					assert.Equal(t, expected, buffered, "%s v%d", sigType, version)
+				}
+			}
+		}
+	}
+}
+
+func TestCopyProvable(t *testing.T) {
+	signer, err := NewEd25519Signer(privKey, "key-1")
+	assert.NoError(t, err)
+	verifier := &Ed25519Verifier{PubKey: pubKey}
+
+	original := &provableTestData{A: "hello", B: "world"}
+	assert.NoError(t, jcsEd25519SignatureSuite.Sign(original, signer))
+
+	copied, err := CopyProvable(original)
+	assert.NoError(t, err)
+	assert.Equal(t, original, copied)
+	assert.NoError(t, jcsEd25519SignatureSuite.Verify(copied, verifier))
+
+	copied.(*provableTestData).A = "changed"
+	copied.GetProof().Nonce = "changed"
+	assert.Equal(t, "hello", original.A)
+	assert.NotEqual(t, "changed", original.Proof.Nonce)
+	assert.NoError(t, jcsEd25519SignatureSuite.Verify(original, verifier))
+	assert.Error(t, jcsEd25519SignatureSuite.Verify(copied, verifier))
+
+	generic := &GenericProvable{JSONData: `{"amount":12345678901234567890}`, Proof: original.Proof.Clone()}
+	copiedGeneric, err := CopyProvable(generic)
+	assert.NoError(t, err)
+	assert.Equal(t, generic, copiedGeneric)
+
+	_, err = CopyProvable(nil)
+	assert.Error(t, err)
+	_, err = CopyProvable((*provableTestData)(nil))
+	assert.Error(t, err)
+}
+
+func TestProofClone(t *testing.T) {
+	var nilProof *Proof
+	assert.Nil(t, nilProof.Clone())
+
+	p := &Proof{Created: "2020-01-01T00:00:00Z", Nonce: "nonce", Type: JCSEdSignatureType}
+	c := p.Clone()
+	assert.Equal(t, p, c)
+	c.Nonce = "changed"
+	assert.Equal(t, "nonce", p.Nonce)
+}
+
+func TestProofCreatedTime(t *testing.T) {
+	p := &Proof{Created: "2020-06-05T03:12:15.5+02:00"}
+	created, err := p.CreatedTime()
+	assert.NoError(t, err)
+	assert.True(t, time.Date(2020, time.June, 5, 1, 12, 15, 500000000, time.UTC).Equal(created))
+
+	p.Created = "Fri, 05 Jun 2020 01:12:15 GMT"
+	_, err = p.CreatedTime()
+	assert.Error(t, err)
+}
+
+func TestDecodeProof(t *testing.T) {
+	data := []byte(`{"created":"2020-01-01T00:00:00Z","nonce":"nonce","type":"JcsEd25519Signature2020","challenge":"abc"}`)
+	expected := &Proof{Created: "2020-01-01T00:00:00Z", Nonce: "nonce", Type: JCSEdSignatureType}
+
+	p, err := DecodeProof(data)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, p)
+
+	_, err = DecodeProofStrict(data)
+	var unknownField util.UnknownFieldError
+	assert.True(t, errors.As(err, &unknownField), err)
+	assert.Equal(t, util.UnknownFieldError{Field: "challenge", Path: "$"}, unknownField)
+	assert.EqualError(t, err, `invalid proof: unknown field "challenge" at $`)
+
+	p, err = DecodeProofStrict([]byte(`{"created":"2020-01-01T00:00:00Z","nonce":"nonce","type":"JcsEd25519Signature2020"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, p)
+
+	// A property in the wrong case would override the modeled property in json.Unmarshal.
+	_, err = DecodeProofStrict([]byte(`{"nonce":"nonce","Nonce":"smuggled"}`))
+	assert.EqualError(t, err, `invalid proof: unknown field "Nonce" at $`)
+}
+
+// plainSuite hides the SignWithOptions method of the suite it wraps.
+type plainSuite struct {
+	SignatureSuite
+}
+
+type errorResolver struct {
+	err error
+}
+
+func (r errorResolver) ResolveVerifier(context.Context, string) (Verifier, error) {
+	return nil, r.err
+}
+
+// TestErrorCodes checks that the primary failure modes of the exported functions have stable
+// error codes.
+func TestErrorCodes(t *testing.T) {
+	signer, err := NewEd25519Signer(privKey, "did:work:abc#key-1")
+	assert.NoError(t, err)
+	verifier := &Ed25519Verifier{PubKey: pubKey}
+	suite, err := SignatureSuites().GetSuite(JCSEdSignatureType, V2)
+	assert.NoError(t, err)
+	signed := &provableTestData{A: "a"}
+	assert.NoError(t, suite.Sign(signed, signer))
+	withProof := func(modify func(*Proof)) *provableTestData {
+		p := *signed.Proof
+		modify(&p)
+		return &provableTestData{A: signed.A, Proof: &p}
+	}
+
+	tests := []struct {
+		name string
+		err  func() error
+		code errcode.Code
+	}{
+		{
+			name: "GetSuite",
+			err: func() error {
+				_, err := SignatureSuites().GetSuite("Unknown", V2)
+				return err
+			},
+			code: errcode.UnsupportedSuite,
+		},
+		{
+			name: "GetSuiteForCredentials",
+			err: func() error {
+				_, err := SignatureSuites().GetSuiteForCredentials("Unknown", V2)
+				return err
+			},
+			code: errcode.UnsupportedSuite,
+		},
+		{
+			name: "Sign with incorrect key type",
+			err: func() error {
+				secpSuite, err := SignatureSuites().GetSuite(EcdsaSecp256k1SignatureType, V1)
+				if err != nil {
+					return err
+				}
+				return secpSuite.Sign(&provableTestData{A: "a"}, signer)
+			},
+			code: errcode.UnsupportedSuite,
+		},
+		{
+			name: "SignWithOptions",
+			err: func() error {
+				return SignWithOptions(plainSuite{suite}, &provableTestData{A: "a"}, signer, WithNonce(func() string { return "nonce" }))
+			},
+			code: errcode.UnsupportedSuite,
+		},
+		{
+			name: "DecodeProof",
+			err: func() error {
+				_, err := DecodeProof([]byte(`{"nonce":`))
+				return err
+			},
+			code: errcode.MalformedProof,
+		},
+		{
+			name: "DecodeProofStrict",
+			err: func() error {
+				_, err := DecodeProofStrict([]byte(`{"challenge":"abc"}`))
+				return err
+			},
+			code: errcode.MalformedProof,
+		},
+		{
+			name: "Verify missing proof",
+			err:  func() error { return suite.Verify(&provableTestData{A: "a"}, verifier) },
+			code: errcode.MalformedProof,
+		},
+		{
+			name: "Verify malformed signature",
+			err:  func() error { return suite.Verify(withProof(func(p *Proof) { p.SignatureValue = "0OIl" }), verifier) },
+			code: errcode.MalformedProof,
+		},
+		{
+			name: "Verify invalid signature",
+			err:  func() error { return suite.Verify(&provableTestData{A: "b", Proof: signed.Proof}, verifier) },
+			code: errcode.SignatureInvalid,
+		},
+		{
+			name: "VerifyWithResolver missing verification method",
+			err: func() error {
+				provable := withProof(func(p *Proof) { p.VerificationMethod = "" })
+				return VerifyWithResolver(context.Background(), provable, errorResolver{})
+			},
+			code: errcode.MalformedProof,
+		},
+		{
+			name: "VerifyWithResolver resolution failure",
+			err: func() error {
+				return VerifyWithResolver(context.Background(), signed, errorResolver{errors.New("unavailable")})
+			},
+			code: errcode.ResolutionFailed,
+		},
+		{
+			name: "VerifyWithResolver keeps the specific code",
+			err: func() error {
+				resolver := errorResolver{errcode.New(errcode.KeyNotFound, "key not found")}
+				return VerifyWithResolver(context.Background(), signed, resolver)
+			},
+			code: errcode.KeyNotFound,
+		},
+		{
+			name: "VerifyBatch",
+			err: func() error {
+				getVerifier := func() (Verifier, error) { return verifier, nil }
+				return VerifyBatch(context.Background(), []BatchItem{{Provable: &provableTestData{A: "a"}, GetVerifier: getVerifier}}, 1)[0]
+			},
+			code: errcode.MalformedProof,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.err()
+			assert.Error(t, err)
+			assert.Equal(t, test.code, errcode.CodeOf(err), err)
+		})
+	}
+}
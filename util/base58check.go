@@ -0,0 +1,106 @@
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+)
+
+// base58CheckChecksumSize is the size of the Base58Check checksum: the first four bytes of the
+// double SHA-256 of the version byte and payload.
+const base58CheckChecksumSize = 4
+
+var (
+	// ErrInvalidBase58 is returned when a string contains characters outside of the base58
+	// (Bitcoin) alphabet.
+	ErrInvalidBase58 = errors.New("invalid base58")
+
+	// ErrBase58Checksum is returned when a Base58Check string does not match its checksum, e.g.
+	// because it was mistyped.
+	ErrBase58Checksum = errors.New("invalid Base58Check checksum")
+)
+
+type base58Options struct {
+	check   bool
+	version byte
+}
+
+// Base58Option configures the base58 key encoding helpers, e.g. PublicKeyToBase58.
+type Base58Option func(*base58Options)
+
+// WithBase58Check selects Base58Check instead of plain base58, with the given version byte (see
+// Base58CheckEncode).
+func WithBase58Check(version byte) Base58Option {
+	return func(o *base58Options) {
+		o.check = true
+		o.version = version
+	}
+}
+
+// Base58CheckEncode encodes the payload as Base58Check, as used by Bitcoin addresses and WIF keys:
+// the base58 encoding of the version byte, the payload, and a 4 byte checksum.
+func Base58CheckEncode(version byte, payload []byte) string {
+	data := make([]byte, 0, 1+len(payload)+base58CheckChecksumSize)
+	data = append(data, version)
+	data = append(data, payload...)
+	data = append(data, base58CheckChecksum(data)...)
+	return base58.Encode(data)
+}
+
+// Base58CheckDecode decodes a Base58Check string into its version byte and payload. Returns
+// ErrInvalidBase58 if the string is not base58, and ErrBase58Checksum if the checksum does not
+// match.
+func Base58CheckDecode(s string) (version byte, payload []byte, err error) {
+	data, err := base58.Decode(s)
+	if err != nil {
+		return 0, nil, errors.Wrapf(ErrInvalidBase58, "%v", err)
+	}
+	if len(data) < 1+base58CheckChecksumSize {
+		return 0, nil, errors.Wrapf(ErrBase58Checksum, "%d bytes is too short", len(data))
+	}
+	checksumStart := len(data) - base58CheckChecksumSize
+	if !bytes.Equal(data[checksumStart:], base58CheckChecksum(data[:checksumStart])) {
+		return 0, nil, ErrBase58Checksum
+	}
+	return data[0], data[1:checksumStart], nil
+}
+
+func base58CheckChecksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:base58CheckChecksumSize]
+}
+
+// encodeBase58 encodes the data as base58, or as Base58Check if selected by the options.
+func encodeBase58(data []byte, opts []Base58Option) string {
+	var options base58Options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.check {
+		return Base58CheckEncode(options.version, data)
+	}
+	return base58.Encode(data)
+}
+
+// decodeBase58 decodes base58, or Base58Check with the expected version byte if selected by the
+// options.
+func decodeBase58(s string, opts []Base58Option) ([]byte, error) {
+	var options base58Options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if !options.check {
+		return base58.Decode(s)
+	}
+	version, payload, err := Base58CheckDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	if version != options.version {
+		return nil, errors.Errorf("unexpected Base58Check version: %#02x, expected %#02x", version, options.version)
+	}
+	return payload, nil
+}
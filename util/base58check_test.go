@@ -0,0 +1,69 @@
+package util
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase58Check(t *testing.T) {
+	// Bitcoin test vectors.
+	vectors := []struct {
+		name    string
+		version byte
+		payload string
+		encoded string
+	}{
+		{
+			// The address of the genesis block coinbase.
+			name:    "P2PKH address",
+			version: 0x00,
+			payload: "62e907b15cbf27d5425399ebf6f0fb50ebb88f18",
+			encoded: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		},
+		{
+			// The example from https://en.bitcoin.it/wiki/Wallet_import_format
+			name:    "WIF private key",
+			version: 0x80,
+			payload: "0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d",
+			encoded: "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ",
+		},
+		{
+			name:    "Empty payload",
+			version: 0x00,
+			payload: "",
+			encoded: "1Wh4bh",
+		},
+	}
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			payload, err := hex.DecodeString(v.payload)
+			require.NoError(t, err)
+			assert.Equal(t, v.encoded, Base58CheckEncode(v.version, payload))
+
+			version, decoded, err := Base58CheckDecode(v.encoded)
+			require.NoError(t, err)
+			assert.Equal(t, v.version, version)
+			assert.Equal(t, v.payload, hex.EncodeToString(decoded))
+		})
+	}
+
+	t.Run("Bad checksum", func(t *testing.T) {
+		// The last character of the genesis address is changed.
+		_, _, err := Base58CheckDecode("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb")
+		assert.True(t, errors.Is(err, ErrBase58Checksum))
+		_, _, err = Base58CheckDecode("1111")
+		assert.True(t, errors.Is(err, ErrBase58Checksum))
+	})
+
+	t.Run("Bad alphabet", func(t *testing.T) {
+		// "0" and "l" are not in the alphabet.
+		for _, s := range []string{"0A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNl"} {
+			_, _, err := Base58CheckDecode(s)
+			assert.True(t, errors.Is(err, ErrInvalidBase58), s)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// B64URLEncode encodes the data as unpadded base64url (RFC 4648 Section 5), the canonical form
+// used by JWK and JWS (RFC 7515 Section 2).
+func B64URLEncode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// B64URLDecode decodes base64url, with or without padding. Padded input must be fully and
+// correctly padded, so that a value is never silently truncated, and the standard base64
+// alphabet, line breaks and non-zero trailing bits are rejected.
+func B64URLDecode(s string) ([]byte, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return nil, fmt.Errorf("invalid base64url: line breaks are not allowed")
+	}
+	encoding := base64.RawURLEncoding
+	if strings.HasSuffix(s, "=") {
+		encoding = base64.URLEncoding
+	}
+	data, err := encoding.Strict().DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64url: %v", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestB64URLDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []byte
+		err      bool
+	}{
+		{name: "Empty", input: "", expected: []byte{}},
+		{name: "Unpadded", input: "-_8", expected: []byte{0xfb, 0xff}},
+		{name: "Padded", input: "-_8=", expected: []byte{0xfb, 0xff}},
+		{name: "Unpadded one byte remainder", input: "AQ", expected: []byte{0x01}},
+		{name: "Padded one byte remainder", input: "AQ==", expected: []byte{0x01}},
+		{name: "No padding needed", input: "AQID", expected: []byte{0x01, 0x02, 0x03}},
+		{name: "Partial padding", input: "AQ=", err: true},
+		{name: "Excess padding", input: "AQID=", err: true},
+		{name: "Padding in the middle", input: "AQ==AQ", err: true},
+		{name: "Standard alphabet", input: "+/8", err: true},
+		{name: "Non-zero trailing bits", input: "AR", err: true},
+		{name: "Impossible length", input: "AQIDB", err: true},
+		{name: "Line break", input: "AQ\nID", err: true},
+		{name: "Whitespace", input: "AQ ID", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := B64URLDecode(test.input)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, decoded)
+		})
+	}
+}
+
+func TestB64URLEncode(t *testing.T) {
+	assert.Equal(t, "", B64URLEncode(nil))
+	assert.Equal(t, "AQ", B64URLEncode([]byte{0x01}))
+	assert.Equal(t, "-_8", B64URLEncode([]byte{0xfb, 0xff}))
+	assert.Equal(t, "AQID", B64URLEncode([]byte{0x01, 0x02, 0x03}))
+}
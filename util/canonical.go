@@ -0,0 +1,46 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	jcs "github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+)
+
+// CanonicalMarshal marshals the value to JSON and canonicalizes it using the JSON
+// Canonicalization Scheme (JCS, RFC 8785). These are exactly the bytes that the JCS signature
+// suites sign (before any digest), so it can be used to hash documents or to debug signature
+// mismatches. The output must not change, as that would invalidate existing signatures.
+func CanonicalMarshal(v interface{}) ([]byte, error) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalMarshalRaw(jsonBytes)
+}
+
+// CanonicalMarshalRaw canonicalizes JSON that has already been marshaled, e.g. a json.RawMessage
+// received over the wire, using JCS. It is the same as CanonicalMarshal for the parsed value.
+func CanonicalMarshalRaw(jsonBytes json.RawMessage) ([]byte, error) {
+	return jcs.Transform(jsonBytes)
+}
+
+// UnmarshalUseNumber unmarshals the JSON into v like json.Unmarshal, except that numbers decoded
+// into interface{} values (e.g. credential subjects) are kept as json.Number rather than float64.
+// Marshaling the value again emits each number in its original lexical form, so large integers
+// such as 64-bit IDs and ledger sequence numbers survive the round trip, and signatures over
+// non-canonical JSON still verify. CanonicalMarshal then applies the RFC 8785 number rules to the
+// original number, exactly as an external JCS implementation would. Decode every document that
+// may be re-marshaled for signing or verification with it.
+func UnmarshalUseNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
@@ -1,16 +1,11 @@
 package canonical
 
 import (
-	"encoding/json"
-
-	jcs "github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/workdaycredentials/ledger-common/util"
 )
 
 // Marshal wraps json.Marshal and calls the JSON Canonicalization Scheme (JCS) canonicalizer.
+// It is the same as util.CanonicalMarshal.
 func Marshal(input interface{}) ([]byte, error) {
-	bytes, err := json.Marshal(input)
-	if err != nil {
-		return nil, err
-	}
-	return jcs.Transform(bytes)
+	return util.CanonicalMarshal(input)
 }
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The expected bytes are golden: signatures over canonical JSON are only verifiable for as long
+// as the output stays the same, so these must never be updated to match a new implementation.
+func TestCanonicalMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{
+			name: "Struct fields are sorted",
+			input: struct {
+				B string `json:"b"`
+				A string `json:"a"`
+			}{B: "world", A: "hello"},
+			expected: `{"a":"hello","b":"world"}`,
+		},
+		{
+			name: "Map keys are sorted by UTF-16 code units",
+			input: map[string]interface{}{
+				"\u20ac":       "Euro Sign",
+				"\r":           "Carriage Return",
+				"\ufb33":       "Hebrew Letter Dalet With Dagesh",
+				"1":            "One",
+				"\U0001f600":   "Emoji: Grinning Face",
+				"\u0080":       "Control",
+				"\u00f6":       "Latin Small Letter O With Diaeresis",
+				"nested":       map[string]interface{}{"z": []interface{}{3, 1, 2}, "a": nil},
+				"emptyObject":  map[string]interface{}{},
+				"emptyArray":   []interface{}{},
+				"trueAndFalse": []bool{true, false},
+			},
+			expected: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"emptyArray\":[],\"emptyObject\":{}," +
+				"\"nested\":{\"a\":null,\"z\":[3,1,2]},\"trueAndFalse\":[true,false]," +
+				"\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\"," +
+				"\"\u20ac\":\"Euro Sign\",\"\U0001f600\":\"Emoji: Grinning Face\"," +
+				"\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+		{
+			name:     "Numbers",
+			input:    []interface{}{333333333.33333329, 1e30, 4.50, 2e-3, 0.000000000000000000000000001, -0.0, 100, 1e21},
+			expected: `[333333333.3333333,1e+30,4.5,0.002,1e-27,0,100,1e+21]`,
+		},
+		{
+			name:     "String escapes",
+			input:    []string{"\u20ac$\u000f\nA'B\"\\\\\"/", "<script>&</script>"},
+			expected: "[\"\u20ac$\\u000f\\nA'B\\\"\\\\\\\\\\\"/\",\"<script>&</script>\"]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			canonical, err := CanonicalMarshal(test.input)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(canonical))
+
+			// Canonicalizing already marshaled JSON gives the same bytes, whatever its formatting.
+			indented, err := json.MarshalIndent(test.input, "", "  ")
+			require.NoError(t, err)
+			raw, err := CanonicalMarshalRaw(indented)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(raw))
+		})
+	}
+
+	t.Run("Invalid input", func(t *testing.T) {
+		_, err := CanonicalMarshal(func() {})
+		assert.Error(t, err)
+		_, err = CanonicalMarshalRaw(json.RawMessage(`{"a":`))
+		assert.Error(t, err)
+	})
+}
+
+func TestUnmarshalUseNumber(t *testing.T) {
+	tests := []struct {
+		number    string
+		canonical string
+	}{
+		{number: "9223372036854775807", canonical: "9223372036854776000"},
+		{number: "1234567890123456789", canonical: "1234567890123456800"},
+		{number: "9007199254740993", canonical: "9007199254740992"},
+		{number: "1.5E-7", canonical: "1.5e-7"},
+		{number: "1E+30", canonical: "1e+30"},
+		{number: "123e-2", canonical: "1.23"},
+		{number: "1.0", canonical: "1"},
+	}
+	for _, test := range tests {
+		t.Run(test.number, func(t *testing.T) {
+			input := `{"b":[` + test.number + `],"a":` + test.number + `}`
+			var value interface{}
+			require.NoError(t, UnmarshalUseNumber([]byte(input), &value))
+
+			// Marshaling again emits the original number.
+			jsonBytes, err := json.Marshal(value)
+			require.NoError(t, err)
+			assert.Equal(t, `{"a":`+test.number+`,"b":[`+test.number+`]}`, string(jsonBytes))
+
+			// Canonicalization applies the RFC 8785 number rules to the original number.
+			canonical, err := CanonicalMarshal(value)
+			require.NoError(t, err)
+			assert.Equal(t, `{"a":`+test.canonical+`,"b":[`+test.canonical+`]}`, string(canonical))
+			raw, err := CanonicalMarshalRaw([]byte(input))
+			require.NoError(t, err)
+			assert.Equal(t, canonical, raw)
+		})
+	}
+
+	var value interface{}
+	assert.Error(t, UnmarshalUseNumber([]byte(`{"a":1} {}`), &value))
+	assert.Error(t, UnmarshalUseNumber([]byte(`{"a":`), &value))
+}
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+
+	"github.com/workdaycredentials/ledger-common/util/multibase"
+)
+
+// multihashCodes are the multihash codes of the supported digest algorithms.
+// See https://github.com/multiformats/multicodec
+var multihashCodes = map[crypto.Hash]byte{
+	crypto.SHA256: 0x12,
+	crypto.SHA512: 0x13,
+}
+
+type digestOptions struct {
+	hash crypto.Hash
+}
+
+// DigestOption configures the digest helpers, e.g. DigestCanonical.
+type DigestOption func(*digestOptions)
+
+// WithDigestAlgorithm selects the digest algorithm. Only crypto.SHA256 (the default) and
+// crypto.SHA512 are supported.
+func WithDigestAlgorithm(hash crypto.Hash) DigestOption {
+	return func(o *digestOptions) {
+		o.hash = hash
+	}
+}
+
+// DigestCanonical returns the digest of the canonical JSON of the value (see CanonicalMarshal).
+// SHA-256 is used unless another algorithm is selected with WithDigestAlgorithm.
+func DigestCanonical(v interface{}, opts ...DigestOption) ([]byte, error) {
+	_, digest, err := digestCanonical(v, opts)
+	return digest, err
+}
+
+// DigestCanonicalMultibase returns the digest of the canonical JSON of the value as a multihash,
+// encoded as base58btc multibase, e.g. "zQm..." for SHA-256. This is the format of DID Document
+// fingerprints.
+func DigestCanonicalMultibase(v interface{}, opts ...DigestOption) (string, error) {
+	hash, digest, err := digestCanonical(v, opts)
+	if err != nil {
+		return "", err
+	}
+	multihash := append([]byte{multihashCodes[hash], byte(len(digest))}, digest...)
+	return multibase.Encode(multibase.Base58BTC, multihash)
+}
+
+// DigestCanonicalBase58 returns the digest of the canonical JSON of the value, base58 encoded
+// without a multihash prefix.
+func DigestCanonicalBase58(v interface{}, opts ...DigestOption) (string, error) {
+	digest, err := DigestCanonical(v, opts...)
+	if err != nil {
+		return "", err
+	}
+	return base58.Encode(digest), nil
+}
+
+func digestCanonical(v interface{}, opts []DigestOption) (crypto.Hash, []byte, error) {
+	options := digestOptions{hash: crypto.SHA256}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	canonical, err := CanonicalMarshal(v)
+	if err != nil {
+		return 0, nil, err
+	}
+	switch options.hash {
+	case crypto.SHA256:
+		digest := sha256.Sum256(canonical)
+		return options.hash, digest[:], nil
+	case crypto.SHA512:
+		digest := sha512.Sum512(canonical)
+		return options.hash, digest[:], nil
+	}
+	return 0, nil, fmt.Errorf("unsupported digest algorithm: %v", options.hash)
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The expected digests are golden: fingerprints and other commitments that have already been
+// recorded must keep matching.
+func TestDigestCanonical(t *testing.T) {
+	// Both fixtures canonicalize to {"a":"hello","b":[1,2,3]}.
+	fixture := struct {
+		B []int  `json:"b"`
+		A string `json:"a"`
+	}{B: []int{1, 2, 3}, A: "hello"}
+	raw := json.RawMessage(`{ "b": [1, 2, 3], "a": "hello" }`)
+
+	for _, v := range []interface{}{fixture, raw} {
+		digest, err := DigestCanonical(v)
+		require.NoError(t, err)
+		assert.Equal(t, "e1b1a4b815a4a21e6fae887c171ef55159441f619f3f4d0ff958a4d1310ba203", hex.EncodeToString(digest))
+
+		digest, err = DigestCanonical(v, WithDigestAlgorithm(crypto.SHA512))
+		require.NoError(t, err)
+		assert.Equal(t, "d6fbec4c5ea0e5482f6352b357367f353d86b91c39b842a127faadea863dae29"+
+			"8b81e365b5ad860bae4907e1f95d4e9462eab610aaa70b11f9d3d3bf5d8ba8e5", hex.EncodeToString(digest))
+
+		encoded, err := DigestCanonicalBase58(v)
+		require.NoError(t, err)
+		assert.Equal(t, "GC1qmHNR2UEAepeyu7po18d1vciXQNFJtjhJaBm86iaE", encoded)
+
+		encoded, err = DigestCanonicalMultibase(v)
+		require.NoError(t, err)
+		assert.Equal(t, "zQmdXfYsRMvoThoFU59wKYGfJM1S6a841ozy3iAGMASJQeE", encoded)
+
+		encoded, err = DigestCanonicalMultibase(v, WithDigestAlgorithm(crypto.SHA512))
+		require.NoError(t, err)
+		assert.Equal(t, "z8Vx5qBcqnkcAM9YPEgK7cW9ERm1Nf5tZxSmF3bjaazH48HdCrorP2qEnGt9dXsovKeJtSsSSvHqmkgDtfgXq8SrFs2", encoded)
+	}
+
+	_, err := DigestCanonical(fixture, WithDigestAlgorithm(crypto.MD5))
+	assert.Error(t, err)
+	_, err = DigestCanonicalMultibase(json.RawMessage(`{"a":`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedKeyEncoding is returned when an elliptic curve public key is not in one of the
+// supported encodings (see ECKeyEncoding), or is not a secp256k1 key.
+var ErrUnsupportedKeyEncoding = errors.New("unsupported EC public key encoding")
+
+// ECKeyEncoding is an encoding of an elliptic curve public key.
+type ECKeyEncoding int
+
+const (
+	// SPKIEncoding is a DER encoded SubjectPublicKeyInfo, as exported by openssl. The point is
+	// uncompressed.
+	SPKIEncoding ECKeyEncoding = iota + 1
+	// CompressedEncoding is a 33 byte compressed SEC1 point, as exported by btcec.
+	CompressedEncoding
+	// UncompressedEncoding is a 65 byte uncompressed SEC1 point.
+	UncompressedEncoding
+)
+
+const (
+	asn1SequenceTag     byte = 0x30
+	sec1CompressedEven  byte = 0x02
+	sec1CompressedOdd   byte = 0x03
+	sec1UncompressedTag byte = 0x04
+)
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// ParseECPublicKey parses a secp256k1 public key in any of the supported encodings, and returns
+// the encoding that was detected. P-256 keys are not yet supported.
+func ParseECPublicKey(key []byte) (*ecdsa.PublicKey, ECKeyEncoding, error) {
+	switch {
+	case len(key) == btcec.PubKeyBytesLenCompressed && (key[0] == sec1CompressedEven || key[0] == sec1CompressedOdd):
+		publicKey, err := parseSecp256k1Point(key)
+		return publicKey, CompressedEncoding, err
+	case len(key) == btcec.PubKeyBytesLenUncompressed && key[0] == sec1UncompressedTag:
+		publicKey, err := parseSecp256k1Point(key)
+		return publicKey, UncompressedEncoding, err
+	case len(key) > 0 && key[0] == asn1SequenceTag:
+		publicKey, err := parseSPKI(key)
+		return publicKey, SPKIEncoding, err
+	}
+	return nil, 0, errors.Wrapf(ErrUnsupportedKeyEncoding, "%d byte key", len(key))
+}
+
+// ParseECPublicKeyBase58 is the same as ParseECPublicKey for a base58 encoded key, or a
+// Base58Check encoded key with the version byte selected with WithBase58Check.
+func ParseECPublicKeyBase58(encodedBase58 string, opts ...Base58Option) (*ecdsa.PublicKey, ECKeyEncoding, error) {
+	key, err := decodeBase58(encodedBase58, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ParseECPublicKey(key)
+}
+
+// EncodeECPublicKey encodes a secp256k1 public key. It is the inverse of ParseECPublicKey.
+func EncodeECPublicKey(key *ecdsa.PublicKey, encoding ECKeyEncoding) ([]byte, error) {
+	if key == nil || key.Curve != btcec.S256() {
+		return nil, errors.Wrap(ErrUnsupportedKeyEncoding, "key is not a secp256k1 key")
+	}
+	publicKey := (*btcec.PublicKey)(key)
+	switch encoding {
+	case CompressedEncoding:
+		return publicKey.SerializeCompressed(), nil
+	case UncompressedEncoding:
+		return publicKey.SerializeUncompressed(), nil
+	case SPKIEncoding:
+		curve, err := asn1.Marshal(oidCurveSecp256k1)
+		if err != nil {
+			return nil, err
+		}
+		point := publicKey.SerializeUncompressed()
+		return asn1.Marshal(subjectPublicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyECDSA, Parameters: asn1.RawValue{FullBytes: curve}},
+			PublicKey: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+		})
+	}
+	return nil, errors.Wrapf(ErrUnsupportedKeyEncoding, "encoding %d", encoding)
+}
+
+func parseSPKI(der []byte) (*ecdsa.PublicKey, error) {
+	var spki subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil {
+		return nil, errors.Wrap(ErrUnsupportedKeyEncoding, "invalid SubjectPublicKeyInfo")
+	}
+	if len(rest) > 0 {
+		return nil, errors.Wrap(ErrUnsupportedKeyEncoding, "trailing data after SubjectPublicKeyInfo")
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, errors.Wrapf(ErrUnsupportedKeyEncoding, "algorithm %s is not ECDSA", spki.Algorithm.Algorithm)
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &curve); err != nil {
+		return nil, errors.Wrap(ErrUnsupportedKeyEncoding, "invalid ECDSA parameters")
+	}
+	if !curve.Equal(oidCurveSecp256k1) {
+		return nil, errors.Wrapf(ErrUnsupportedKeyEncoding, "curve %s is not secp256k1", curve)
+	}
+	return parseSecp256k1Point(spki.PublicKey.RightAlign())
+}
+
+func parseSecp256k1Point(point []byte) (*ecdsa.PublicKey, error) {
+	publicKey, err := btcec.ParsePubKey(point, btcec.S256())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid secp256k1 public key")
+	}
+	return publicKey.ToECDSA(), nil
+}
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The same secp256k1 public key in each supported encoding. The SPKI form was exported by openssl.
+const (
+	secp256k1SPKIHex         = "3056301006072a8648ce3d020106052b8104000a03420004b2490e2f815694f4fa96f7cd45e9f44d4e9de8bb736c09d2b9366fd23e44cb55fd8e3f9307a91c924f10541ad2201d43f37c365bb0010272642e7ca898d0943b"
+	secp256k1CompressedHex   = "03b2490e2f815694f4fa96f7cd45e9f44d4e9de8bb736c09d2b9366fd23e44cb55"
+	secp256k1UncompressedHex = "04b2490e2f815694f4fa96f7cd45e9f44d4e9de8bb736c09d2b9366fd23e44cb55fd8e3f9307a91c924f10541ad2201d43f37c365bb0010272642e7ca898d0943b"
+)
+
+func TestParseECPublicKey(t *testing.T) {
+	fixtures := map[ECKeyEncoding]string{
+		SPKIEncoding:         secp256k1SPKIHex,
+		CompressedEncoding:   secp256k1CompressedHex,
+		UncompressedEncoding: secp256k1UncompressedHex,
+	}
+
+	var expected *ecdsa.PublicKey
+	for encoding, fixture := range fixtures {
+		key, err := hex.DecodeString(fixture)
+		require.NoError(t, err)
+
+		publicKey, detected, err := ParseECPublicKey(key)
+		require.NoError(t, err)
+		assert.Equal(t, encoding, detected)
+		if expected == nil {
+			expected = publicKey
+		}
+		assert.Equal(t, expected, publicKey)
+
+		// The key round trips through each encoding.
+		encoded, err := EncodeECPublicKey(publicKey, encoding)
+		require.NoError(t, err)
+		assert.Equal(t, key, encoded)
+
+		fromBase58, _, err := ParseECPublicKeyBase58(base58.Encode(key))
+		require.NoError(t, err)
+		assert.Equal(t, expected, fromBase58)
+
+		// The legacy function accepts every encoding.
+		extracted, err := ExtractPublicKeyFromBase58Der(base58.Encode(key))
+		require.NoError(t, err)
+		assert.Equal(t, secp256k1UncompressedHex, hex.EncodeToString(extracted))
+	}
+}
+
+func TestParseECPublicKeyBase58Check(t *testing.T) {
+	key, err := hex.DecodeString(secp256k1CompressedHex)
+	require.NoError(t, err)
+	expected, _, err := ParseECPublicKey(key)
+	require.NoError(t, err)
+
+	encoded := Base58CheckEncode(0x01, key)
+	publicKey, encoding, err := ParseECPublicKeyBase58(encoded, WithBase58Check(0x01))
+	require.NoError(t, err)
+	assert.Equal(t, CompressedEncoding, encoding)
+	assert.Equal(t, expected, publicKey)
+
+	_, _, err = ParseECPublicKeyBase58(encoded, WithBase58Check(0x02))
+	assert.EqualError(t, err, "unexpected Base58Check version: 0x01, expected 0x02")
+	_, _, err = ParseECPublicKeyBase58(base58.Encode(key), WithBase58Check(0x01))
+	assert.True(t, errors.Is(err, ErrBase58Checksum))
+}
+
+func TestParseECPublicKeyErrors(t *testing.T) {
+	spki, err := hex.DecodeString(secp256k1SPKIHex)
+	require.NoError(t, err)
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p256SPKI, err := x509.MarshalPKIXPublicKey(&p256Key.PublicKey)
+	require.NoError(t, err)
+
+	invalid := map[string][]byte{
+		"empty":              nil,
+		"truncated SPKI":     spki[:20],
+		"trailing data":      append(append([]byte{}, spki...), 0x00),
+		"P-256 SPKI":         p256SPKI,
+		"raw Ed25519 key":    make([]byte, 32),
+		"wrong point prefix": append([]byte{0x05}, spki[len(spki)-64:]...),
+	}
+	for name, key := range invalid {
+		_, _, err := ParseECPublicKey(key)
+		assert.True(t, errors.Is(err, ErrUnsupportedKeyEncoding), name)
+	}
+
+	// A well-formed point that is not on the curve.
+	_, _, err = ParseECPublicKey(append([]byte{0x02}, make([]byte, 32)...))
+	assert.Error(t, err)
+
+	_, err = EncodeECPublicKey(&p256Key.PublicKey, CompressedEncoding)
+	assert.True(t, errors.Is(err, ErrUnsupportedKeyEncoding))
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// GenerateEd25519KeyPair generates an Ed25519 key pair from a random seed read from the reader,
+// or from RandReader if the reader is nil. The seed is returned so that the key can be stored in
+// its compact form; see PrivateKeyFromSeed.
+func GenerateEd25519KeyPair(random io.Reader) (ed25519.PublicKey, ed25519.PrivateKey, []byte, error) {
+	if random == nil {
+		random = RandReader()
+	}
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(random, seed); err != nil {
+		return nil, nil, nil, err
+	}
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	return privateKey.Public().(ed25519.PublicKey), privateKey, seed, nil
+}
+
+// PrivateKeyFromSeed returns the Ed25519 private key for the seed, which must be exactly
+// ed25519.SeedSize (32) bytes.
+func PrivateKeyFromSeed(seed []byte) (ed25519.PrivateKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("seed must be %d bytes, found %d", ed25519.SeedSize, len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// PublicKeyToBase58 returns the base58 encoding of the Ed25519 public key, as used by
+// publicKeyBase58, or its Base58Check encoding if selected with WithBase58Check. The key must be
+// exactly ed25519.PublicKeySize (32) bytes.
+func PublicKeyToBase58(publicKey ed25519.PublicKey, opts ...Base58Option) (string, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("public key must be %d bytes, found %d", ed25519.PublicKeySize, len(publicKey))
+	}
+	return encodeBase58(publicKey, opts), nil
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestGenerateEd25519KeyPair(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, ed25519.SeedSize)
+
+	t.Run("Reader", func(t *testing.T) {
+		publicKey, privateKey, actualSeed, err := GenerateEd25519KeyPair(bytes.NewReader(seed))
+		require.NoError(t, err)
+		assert.Equal(t, seed, actualSeed)
+		assert.Equal(t, ed25519.NewKeyFromSeed(seed), privateKey)
+		assert.Equal(t, privateKey.Public(), publicKey)
+	})
+
+	t.Run("Injected randomness", func(t *testing.T) {
+		restore := SetRandReader(bytes.NewReader(seed))
+		defer restore()
+		_, privateKey, _, err := GenerateEd25519KeyPair(nil)
+		require.NoError(t, err)
+		assert.Equal(t, ed25519.NewKeyFromSeed(seed), privateKey)
+	})
+
+	t.Run("Short read", func(t *testing.T) {
+		_, _, _, err := GenerateEd25519KeyPair(bytes.NewReader(seed[:31]))
+		assert.Error(t, err)
+	})
+}
+
+func TestPrivateKeyFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, ed25519.SeedSize)
+	privateKey, err := PrivateKeyFromSeed(seed)
+	require.NoError(t, err)
+	assert.Equal(t, ed25519.NewKeyFromSeed(seed), privateKey)
+
+	for _, size := range []int{0, 31, 33, ed25519.PrivateKeySize} {
+		_, err := PrivateKeyFromSeed(make([]byte, size))
+		assert.Error(t, err, size)
+	}
+}
+
+func TestPublicKeyToBase58(t *testing.T) {
+	privateKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{0x42}, ed25519.SeedSize))
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	encoded, err := PublicKeyToBase58(publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, base58.Encode(publicKey), encoded)
+
+	encoded, err = PublicKeyToBase58(publicKey, WithBase58Check(0xed))
+	require.NoError(t, err)
+	version, payload, err := Base58CheckDecode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0xed), version)
+	assert.Equal(t, []byte(publicKey), payload)
+
+	_, err = PublicKeyToBase58(publicKey[:31])
+	assert.Error(t, err)
+	_, err = PublicKeyToBase58(ed25519.PublicKey(privateKey))
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+// Package errcode attaches stable, machine-readable codes to the errors returned by this module,
+// so that callers such as API layers can translate failures for their clients without matching
+// on error text, which may change between releases.
+//
+// Errors keep their messages and remain compatible with errors.Is and errors.As: the sentinel
+// errors of the proof and did packages are themselves coded errors, and wrapping an error with a
+// code keeps the wrapped error in the chain.
+package errcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code is a stable, machine-readable error code. Codes are part of the public API and must not
+// be renamed.
+type Code string
+
+const (
+	// MalformedProof means that a proof is missing, cannot be decoded, or lacks a required field.
+	MalformedProof Code = "MALFORMED_PROOF"
+	// UnsupportedSuite means that the signature type, proof options or key type are not
+	// supported.
+	UnsupportedSuite Code = "UNSUPPORTED_SUITE"
+	// SignatureInvalid means that a signature does not verify, or the key is not permitted to
+	// make it.
+	SignatureInvalid Code = "SIGNATURE_INVALID"
+	// KeyNotFound means that a key reference does not resolve to a key of the DID Document.
+	KeyNotFound Code = "KEY_NOT_FOUND"
+	// DIDMalformed means that a DID, DID URL or DID Document cannot be parsed.
+	DIDMalformed Code = "DID_MALFORMED"
+	// ResolutionFailed means that a DID could not be resolved, e.g. because it was not found.
+	ResolutionFailed Code = "RESOLUTION_FAILED"
+	// Deactivated means that the DID, or a delegation, has been deactivated or revoked.
+	Deactivated Code = "DEACTIVATED"
+	// Expired means that something is used outside of the time that it is valid.
+	Expired Code = "EXPIRED"
+	// Replay means that a proof nonce has been used before.
+	Replay Code = "REPLAY"
+)
+
+// Coder is implemented by errors that carry a code.
+type Coder interface {
+	ErrorCode() Code
+}
+
+// LedgerError is an error with a code, a message, and an optional cause.
+type LedgerError struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+// New returns an error with the given code and message. Use it for sentinel errors.
+func New(code Code, message string) error {
+	return &LedgerError{Code: code, Message: message}
+}
+
+// Errorf returns an error with the given code and a formatted message.
+func Errorf(code Code, format string, args ...interface{}) error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap returns an error with the given code that wraps err. The message is prefixed to the
+// message of err, as by github.com/pkg/errors.Wrap; it may be empty. Returns nil if err is nil.
+func Wrap(code Code, err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &LedgerError{Code: code, Message: message, Err: err}
+}
+
+// Wrapf returns an error with the given code that wraps err, with a formatted message. Returns
+// nil if err is nil.
+func Wrapf(code Code, err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return Wrap(code, err, fmt.Sprintf(format, args...))
+}
+
+func (e *LedgerError) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// ErrorCode returns the error's code.
+func (e *LedgerError) ErrorCode() Code {
+	return e.Code
+}
+
+// Unwrap returns the cause of the error, for errors.Is and errors.As.
+func (e *LedgerError) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of the error, or the empty string if no error in its chain has a code.
+// If several errors in the chain have a code, the innermost, most specific one is returned: for
+// example, a key that is not found while resolving a verification method is reported as
+// KeyNotFound rather than ResolutionFailed.
+func CodeOf(err error) Code {
+	var code Code
+	for err != nil {
+		if coder, ok := err.(Coder); ok {
+			code = coder.ErrorCode()
+		}
+		err = unwrap(err)
+	}
+	return code
+}
+
+// unwrap returns the next error in the chain, following both Unwrap and the Cause method of
+// github.com/pkg/errors.
+func unwrap(err error) error {
+	if next := errors.Unwrap(err); next != nil {
+		return next
+	}
+	if causer, ok := err.(interface{ Cause() error }); ok {
+		return causer.Cause()
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var errSentinel = New(ResolutionFailed, "DID not found")
+
+type codedError struct{}
+
+func (codedError) Error() string { return "deactivated" }
+
+func (codedError) ErrorCode() Code { return Deactivated }
+
+func TestCodeOf(t *testing.T) {
+	assert.Equal(t, Code(""), CodeOf(nil))
+	assert.Equal(t, Code(""), CodeOf(errors.New("plain")))
+	assert.Equal(t, ResolutionFailed, CodeOf(errSentinel))
+	assert.Equal(t, Deactivated, CodeOf(codedError{}))
+
+	// Codes are found through both standard and github.com/pkg/errors wrapping.
+	assert.Equal(t, ResolutionFailed, CodeOf(pkgerrors.Wrapf(errSentinel, "DID<%s>", "did:work:abc")))
+	assert.Equal(t, ResolutionFailed, CodeOf(fmt.Errorf("resolving: %w", errSentinel)))
+	assert.Equal(t, Deactivated, CodeOf(pkgerrors.WithMessage(codedError{}, "verifying")))
+
+	// The innermost code is the most specific.
+	wrapped := Wrap(ResolutionFailed, New(KeyNotFound, "key<#key-2> not found"), "unable to resolve")
+	assert.Equal(t, KeyNotFound, CodeOf(wrapped))
+}
+
+func TestLedgerError(t *testing.T) {
+	assert.EqualError(t, New(Expired, "delegation has expired"), "delegation has expired")
+	assert.EqualError(t, Errorf(KeyNotFound, "key<%s> not found", "#key-1"), "key<#key-1> not found")
+
+	wrapped := Wrapf(ResolutionFailed, errSentinel, "DID<%s>", "did:work:abc")
+	assert.EqualError(t, wrapped, "DID<did:work:abc>: DID not found")
+	assert.EqualError(t, Wrap(SignatureInvalid, errors.New("bad signature"), ""), "bad signature")
+	assert.Nil(t, Wrap(SignatureInvalid, nil, "message"))
+	assert.Nil(t, Wrapf(SignatureInvalid, nil, "message"))
+
+	// Sentinels still match after wrapping.
+	assert.True(t, errors.Is(wrapped, errSentinel))
+	assert.True(t, errors.Is(pkgerrors.Wrap(wrapped, "context"), errSentinel))
+	var ledgerErr *LedgerError
+	assert.True(t, errors.As(pkgerrors.Wrap(wrapped, "context"), &ledgerErr))
+	assert.Equal(t, ResolutionFailed, ledgerErr.Code)
+}
@@ -0,0 +1,159 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// JSONEquals compares two JSON documents for logical equality. Property order, insignificant
+// whitespace and string escapes (e.g. "\u00e9" and "é") are ignored, but array order is not.
+// Numbers are compared by their exact decimal value, so 1, 1.0 and 1e0 are equal, while large
+// integers that would round to the same float64 (e.g. 9007199254740993 and 9007199254740992) are
+// not. Returns an error if either document is not valid JSON.
+func JSONEquals(a, b []byte) (bool, error) {
+	diff, err := JSONDiff(a, b)
+	if err != nil {
+		return false, err
+	}
+	return len(diff) == 0, nil
+}
+
+// JSONDiff compares two JSON documents like JSONEquals, and returns the differences between them,
+// one per path, for use in test failure messages, e.g.
+//
+//	$.proof.created: "2020-01-01T00:00:00Z" != "2020-01-02T00:00:00Z"
+//	$.service: missing from b
+//
+// Returns an empty list if the documents are equal.
+func JSONDiff(a, b []byte) ([]string, error) {
+	valueA, err := decodeJSONNumbers(a)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON in a: %v", err)
+	}
+	valueB, err := decodeJSONNumbers(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON in b: %v", err)
+	}
+	var diff []string
+	jsonDiff("$", valueA, valueB, &diff)
+	return diff, nil
+}
+
+// decodeJSONNumbers decodes a single JSON value, keeping numbers as json.Number.
+func decodeJSONNumbers(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+func jsonDiff(path string, a, b interface{}, diff *[]string) {
+	switch valueA := a.(type) {
+	case map[string]interface{}:
+		valueB, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(valueA)+len(valueB))
+		for k := range valueA {
+			keys = append(keys, k)
+		}
+		for k := range valueB {
+			if _, ok := valueA[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			elemA, inA := valueA[k]
+			elemB, inB := valueB[k]
+			elemPath := path + "." + k
+			switch {
+			case !inA:
+				*diff = append(*diff, elemPath+": missing from a")
+			case !inB:
+				*diff = append(*diff, elemPath+": missing from b")
+			default:
+				jsonDiff(elemPath, elemA, elemB, diff)
+			}
+		}
+		return
+	case []interface{}:
+		valueB, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		if len(valueA) != len(valueB) {
+			*diff = append(*diff, fmt.Sprintf("%s: array length %d != %d", path, len(valueA), len(valueB)))
+			return
+		}
+		for i := range valueA {
+			jsonDiff(fmt.Sprintf("%s[%d]", path, i), valueA[i], valueB[i], diff)
+		}
+		return
+	case json.Number:
+		if valueB, ok := b.(json.Number); ok && normalizeJSONNumber(valueA) == normalizeJSONNumber(valueB) {
+			return
+		}
+	default:
+		if a == b {
+			return
+		}
+	}
+	*diff = append(*diff, fmt.Sprintf("%s: %s != %s", path, formatJSONValue(a), formatJSONValue(b)))
+}
+
+// normalizeJSONNumber returns an exact, canonical representation of a JSON number: its sign, its
+// significant digits and the exponent of the last digit, e.g. "-1.50" becomes "-15e-1". Zero is
+// always "0". The exponent is computed with a big.Int, so any valid JSON number is supported.
+func normalizeJSONNumber(n json.Number) string {
+	s := string(n)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	exponent := new(big.Int)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if _, ok := exponent.SetString(strings.TrimPrefix(s[i+1:], "+"), 10); !ok {
+			return string(n)
+		}
+		s = s[:i]
+	}
+	digits := s
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits = s[:i] + s[i+1:]
+		exponent.Sub(exponent, big.NewInt(int64(len(s)-i-1)))
+	}
+	digits = strings.TrimLeft(digits, "0")
+	trimmed := strings.TrimRight(digits, "0")
+	if trimmed == "" {
+		return "0"
+	}
+	exponent.Add(exponent, big.NewInt(int64(len(digits)-len(trimmed))))
+	return sign + trimmed + "e" + exponent.String()
+}
+
+// formatJSONValue formats a decoded value for JSONDiff, abbreviating objects and arrays.
+func formatJSONValue(v interface{}) string {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("object with %d properties", len(value))
+	case []interface{}:
+		return fmt.Sprintf("array of length %d", len(value))
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONEquals(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     string
+		b     string
+		equal bool
+	}{
+		{name: "Property order", a: `{"a":1,"b":"x"}`, b: `{ "b" : "x",` + "\n" + `"a" : 1 }`, equal: true},
+		{name: "Array order", a: `[1,2]`, b: `[2,1]`, equal: false},
+		{name: "Integer and decimal", a: `{"n":1}`, b: `{"n":1.0}`, equal: true},
+		{name: "Exponent", a: `{"n":100}`, b: `{"n":1E+2}`, equal: true},
+		{name: "Negative zero", a: `0`, b: `-0.0`, equal: true},
+		{name: "Different numbers", a: `{"n":1}`, b: `{"n":1.5}`, equal: false},
+		{name: "Large integers", a: `9007199254740993`, b: `9007199254740992`, equal: false},
+		{name: "Large equal integers", a: `123456789012345678901234567890`, b: `1234567890123456789012345678900e-1`, equal: true},
+		{name: "Unicode escapes", a: `{"name":"\u00e9\ud83d\ude00"}`, b: `{"name":"é😀"}`, equal: true},
+		{name: "Number and string", a: `{"n":1}`, b: `{"n":"1"}`, equal: false},
+		{name: "Null and missing", a: `{"n":null}`, b: `{}`, equal: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			equal, err := JSONEquals([]byte(test.a), []byte(test.b))
+			require.NoError(t, err)
+			assert.Equal(t, test.equal, equal)
+
+			equal, err = JSONEquals([]byte(test.b), []byte(test.a))
+			require.NoError(t, err)
+			assert.Equal(t, test.equal, equal)
+		})
+	}
+
+	_, err := JSONEquals([]byte(`{"a":`), []byte(`{}`))
+	assert.Error(t, err)
+
+	_, err = JSONEquals([]byte(`{}`), []byte(`{} {}`))
+	assert.Error(t, err)
+}
+
+func TestJSONDiff(t *testing.T) {
+	a := `{"id":"1","proof":{"created":"2020-01-01T00:00:00Z"},"list":[1,2],"service":[],"n":1.0}`
+	b := `{"id":"1","proof":{"created":"2020-01-02T00:00:00Z"},"list":[1,2,3],"extra":{"x":true},"n":1}`
+	diff, err := JSONDiff([]byte(a), []byte(b))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"$.extra: missing from a",
+		"$.list: array length 2 != 3",
+		`$.proof.created: "2020-01-01T00:00:00Z" != "2020-01-02T00:00:00Z"`,
+		"$.service: missing from b",
+	}, diff)
+
+	diff, err = JSONDiff([]byte(`[{"a":1},"x"]`), []byte(`["x",{"a":1}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`$[0]: object with 1 properties != "x"`,
+		`$[1]: "x" != object with 1 properties`,
+	}, diff)
+
+	diff, err = JSONDiff([]byte(a), []byte(a))
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+}
@@ -0,0 +1,68 @@
+// Package multibase encodes and decodes self-describing base encoded values, as described by the
+// multibase specification (https://github.com/multiformats/multibase). The first character of an
+// encoded value names the base, e.g. "z" for base58btc.
+package multibase
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/mr-tron/base58"
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedBase is returned when a value is not encoded in one of the supported bases.
+var ErrUnsupportedBase = errors.New("unsupported multibase encoding")
+
+// Base is a multibase encoding, identified by its prefix character.
+type Base byte
+
+const (
+	// Base58BTC is base58 with the bitcoin alphabet. It is used by did:key and publicKeyMultibase.
+	Base58BTC Base = 'z'
+	// Base64URL is unpadded base64 with the URL and filename safe alphabet (RFC 4648).
+	Base64URL Base = 'u'
+	// Base16 is lowercase hexadecimal.
+	Base16 Base = 'f'
+)
+
+// Encode encodes the data in the given base, prefixed by the base's character.
+func Encode(base Base, data []byte) (string, error) {
+	var encoded string
+	switch base {
+	case Base58BTC:
+		encoded = base58.Encode(data)
+	case Base64URL:
+		encoded = base64.RawURLEncoding.EncodeToString(data)
+	case Base16:
+		encoded = hex.EncodeToString(data)
+	default:
+		return "", errors.Wrapf(ErrUnsupportedBase, "prefix %q", rune(base))
+	}
+	return string(base) + encoded, nil
+}
+
+// Decode decodes a multibase value, and returns the base it was encoded in. Errors for unknown
+// prefixes wrap ErrUnsupportedBase and name the prefix.
+func Decode(s string) (Base, []byte, error) {
+	if s == "" {
+		return 0, nil, errors.New("empty multibase value")
+	}
+	base, encoded := Base(s[0]), s[1:]
+	var data []byte
+	var err error
+	switch base {
+	case Base58BTC:
+		data, err = base58.Decode(encoded)
+	case Base64URL:
+		data, err = base64.RawURLEncoding.DecodeString(encoded)
+	case Base16:
+		data, err = hex.DecodeString(encoded)
+	default:
+		return 0, nil, errors.Wrapf(ErrUnsupportedBase, "prefix %q", rune(s[0]))
+	}
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "invalid multibase value with prefix %q", rune(base))
+	}
+	return base, data, nil
+}
@@ -0,0 +1,56 @@
+package multibase
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from the multibase specification.
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		input    string
+		base     Base
+		expected string
+	}{
+		{input: "yes mani !", base: Base16, expected: "f796573206d616e692021"},
+		{input: "yes mani !", base: Base58BTC, expected: "z7paNL19xttacUY"},
+		{input: "yes mani !", base: Base64URL, expected: "ueWVzIG1hbmkgIQ"},
+		{input: "\x00yes mani !", base: Base16, expected: "f00796573206d616e692021"},
+		{input: "\x00yes mani !", base: Base58BTC, expected: "z17paNL19xttacUY"},
+		{input: "\x00\x00yes mani !", base: Base58BTC, expected: "z117paNL19xttacUY"},
+		{input: "Decentralize everything!!", base: Base64URL, expected: "uRGVjZW50cmFsaXplIGV2ZXJ5dGhpbmchIQ"},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			encoded, err := Encode(test.base, []byte(test.input))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, encoded)
+
+			base, decoded, err := Decode(test.expected)
+			require.NoError(t, err)
+			assert.Equal(t, test.base, base)
+			assert.Equal(t, test.input, string(decoded))
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, _, err := Decode("mZGVjZW50cmFsaXpl")
+	assert.True(t, errors.Is(err, ErrUnsupportedBase))
+	assert.Contains(t, err.Error(), `prefix 'm'`)
+
+	_, err = Encode('m', []byte("data"))
+	assert.True(t, errors.Is(err, ErrUnsupportedBase))
+
+	_, _, err = Decode("")
+	assert.Error(t, err)
+
+	_, _, err = Decode("z0OIl")
+	assert.Error(t, err)
+
+	_, _, err = Decode("fzz")
+	assert.Error(t, err)
+}
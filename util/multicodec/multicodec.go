@@ -0,0 +1,101 @@
+// Package multicodec reads and writes values prefixed by an unsigned varint multicodec, as used by
+// did:key and publicKeyMultibase (https://github.com/multiformats/multicodec).
+package multicodec
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ErrMalformed is returned when a value does not start with a valid unsigned varint.
+var ErrMalformed = errors.New("malformed multicodec value")
+
+// Codec is a multicodec code.
+type Codec uint64
+
+// Public key codecs.
+const (
+	Ed25519Pub   Codec = 0xed
+	X25519Pub    Codec = 0xec
+	Secp256k1Pub Codec = 0xe7
+	// P256Pub does not fit in a single byte, so unlike the other key codecs it is always written as
+	// two bytes (0x80, 0x24).
+	P256Pub Codec = 0x1200
+)
+
+// maxUvarintLen is the maximum length of an unsigned varint allowed by the multiformats
+// specification (https://github.com/multiformats/unsigned-varint).
+const maxUvarintLen = 9
+
+// ed25519PublicKeySize is the size of a raw Ed25519 public key.
+const ed25519PublicKeySize = 32
+
+// keyTypes maps the public key codecs to the names of their base58 key types (see proof.KeyType).
+var keyTypes = map[Codec]string{
+	Ed25519Pub:   "Ed25519VerificationKey2018",
+	X25519Pub:    "X25519KeyAgreementKey2019",
+	Secp256k1Pub: "EcdsaSecp256k1VerificationKey2019",
+	P256Pub:      "EcdsaSecp256r1VerificationKey2019",
+}
+
+// PutUvarint returns the data prefixed by the codec, written as an unsigned varint.
+func PutUvarint(codec Codec, data []byte) []byte {
+	prefix := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(data))
+	n := binary.PutUvarint(prefix, uint64(codec))
+	return append(prefix[:n], data...)
+}
+
+// Uvarint splits a value into its codec and the data that follows it. The varint must be
+// minimally encoded and at most 9 bytes long, and must be followed by at least one byte of data;
+// errors wrap ErrMalformed. Uvarint never panics, whatever the input.
+//
+// If legacyEd25519 is set, a 33 byte value that starts with the single byte 0xed is read as an
+// Ed25519 public key, as did:work once wrote DID Keys (see did.GenerateLegacyDIDKey), rather than
+// as the varint 0xed 0x01 would be.
+func Uvarint(value []byte, legacyEd25519 bool) (Codec, []byte, error) {
+	if legacyEd25519 && len(value) == ed25519PublicKeySize+1 && value[0] == byte(Ed25519Pub) {
+		return Ed25519Pub, value[1:], nil
+	}
+	codec, n := binary.Uvarint(value)
+	switch {
+	case n == 0:
+		return 0, nil, errors.Wrap(ErrMalformed, "truncated varint")
+	case n < 0 || n > maxUvarintLen:
+		return 0, nil, errors.Wrap(ErrMalformed, "varint overflow")
+	case n > 1 && value[n-1] == 0:
+		return 0, nil, errors.Wrap(ErrMalformed, "varint is not minimally encoded")
+	case n == len(value):
+		return 0, nil, errors.Wrapf(ErrMalformed, "no data after codec 0x%x", codec)
+	}
+	return Codec(codec), value[n:], nil
+}
+
+// KeyTypeForCodec returns the name of the base58 key type (see proof.KeyType) for a public key
+// codec, or false if the codec is not a supported public key codec.
+func KeyTypeForCodec(codec Codec) (string, bool) {
+	keyType, ok := keyTypes[codec]
+	return keyType, ok
+}
+
+// PublicKeyCodecs returns the supported public key codecs, in ascending order.
+func PublicKeyCodecs() []Codec {
+	codecs := make([]Codec, 0, len(keyTypes))
+	for codec := range keyTypes {
+		codecs = append(codecs, codec)
+	}
+	sort.Slice(codecs, func(i, j int) bool { return codecs[i] < codecs[j] })
+	return codecs
+}
+
+// CodecForKeyType returns the public key codec for the name of a base58 key type (see
+// proof.KeyType), or false if the key type has no supported codec.
+func CodecForKeyType(keyType string) (Codec, bool) {
+	for codec, name := range keyTypes {
+		if name == keyType {
+			return codec, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,104 @@
+package multicodec
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutUvarint(t *testing.T) {
+	key := bytes.Repeat([]byte{0x01}, 32)
+	tests := []struct {
+		codec  Codec
+		prefix []byte
+	}{
+		{codec: Ed25519Pub, prefix: []byte{0xed, 0x01}},
+		{codec: X25519Pub, prefix: []byte{0xec, 0x01}},
+		{codec: Secp256k1Pub, prefix: []byte{0xe7, 0x01}},
+		{codec: P256Pub, prefix: []byte{0x80, 0x24}},
+	}
+	for _, test := range tests {
+		encoded := PutUvarint(test.codec, key)
+		assert.Equal(t, append(append([]byte{}, test.prefix...), key...), encoded)
+
+		codec, data, err := Uvarint(encoded, false)
+		require.NoError(t, err)
+		assert.Equal(t, test.codec, codec)
+		assert.Equal(t, key, data)
+	}
+}
+
+func TestUvarint(t *testing.T) {
+	key := bytes.Repeat([]byte{0x01}, 32)
+	legacy := append([]byte{0xed}, key...)
+
+	t.Run("Legacy Ed25519 prefix", func(t *testing.T) {
+		codec, data, err := Uvarint(legacy, true)
+		require.NoError(t, err)
+		assert.Equal(t, Ed25519Pub, codec)
+		assert.Equal(t, key, data)
+
+		// Without the flag, 0xed 0x01 is read as the varint 0xed.
+		codec, data, err = Uvarint(legacy, false)
+		require.NoError(t, err)
+		assert.Equal(t, Ed25519Pub, codec)
+		assert.Len(t, data, 31)
+	})
+
+	for name, value := range map[string][]byte{
+		"Empty":              nil,
+		"Truncated":          {0x80},
+		"Codec only":         {0xed, 0x01},
+		"Not minimal":        {0xed, 0x81, 0x00, 0x01},
+		"Too long":           {0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01, 0x01},
+		"Overflow":           bytes.Repeat([]byte{0xff}, 12),
+		"Legacy without key": {0xed},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Uvarint(value, true)
+			assert.True(t, errors.Is(err, ErrMalformed), err)
+		})
+	}
+}
+
+// TestUvarintRandomInput checks that arbitrary and truncated input never panics.
+func TestUvarintRandomInput(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		value := make([]byte, random.Intn(40))
+		random.Read(value)
+		if len(value) > 0 && random.Intn(2) == 0 {
+			value[0] |= 0x80
+		}
+		for _, legacy := range []bool{true, false} {
+			for end := 0; end <= len(value); end++ {
+				codec, data, err := Uvarint(value[:end], legacy)
+				if err == nil {
+					assert.NotEmpty(t, data)
+				}
+				if err == nil && !legacy {
+					assert.Equal(t, value[:end], PutUvarint(codec, data), "%x", value[:end])
+				}
+			}
+		}
+	}
+}
+
+func TestKeyTypes(t *testing.T) {
+	assert.Equal(t, []Codec{Secp256k1Pub, X25519Pub, Ed25519Pub, P256Pub}, PublicKeyCodecs())
+	for _, codec := range PublicKeyCodecs() {
+		keyType, ok := KeyTypeForCodec(codec)
+		require.True(t, ok)
+		actual, ok := CodecForKeyType(keyType)
+		require.True(t, ok)
+		assert.Equal(t, codec, actual)
+	}
+	_, ok := KeyTypeForCodec(0x12)
+	assert.False(t, ok)
+	_, ok = CodecForKeyType("JsonWebKey2020")
+	assert.False(t, ok)
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// NormalizeOptions configures NormalizeJSON.
+type NormalizeOptions struct {
+	// RemoveNulls removes object members whose value is null.
+	RemoveNulls bool
+	// RemoveEmptyStrings removes object members whose value is the empty string.
+	RemoveEmptyStrings bool
+	// RemoveEmptyCollections removes object members whose value is an empty array or object,
+	// including objects that are empty once their own members have been removed.
+	RemoveEmptyCollections bool
+	// Indent indents the output with the given string for each level, e.g. for display. The
+	// output is compact if empty.
+	Indent string
+}
+
+var (
+	// StorageNormalizeOptions only sorts object members and removes insignificant whitespace, so
+	// that the proofs of normalized documents still verify. Use it before hashing or persisting
+	// signed documents. It is the zero value of NormalizeOptions.
+	StorageNormalizeOptions = NormalizeOptions{}
+
+	// DisplayNormalizeOptions removes null and empty members, and indents the output, for display
+	// or diffing. The output is lossy: removing members changes what is signed, so proofs of
+	// documents normalized with these options no longer verify.
+	DisplayNormalizeOptions = NormalizeOptions{
+		RemoveNulls:            true,
+		RemoveEmptyStrings:     true,
+		RemoveEmptyCollections: true,
+		Indent:                 "  ",
+	}
+)
+
+// NormalizeJSON returns "the bytes that matter" of a JSON document: object members are sorted by
+// name, insignificant whitespace is removed, and, depending on the options, empty members are
+// removed. Proofs are kept intact. Numbers are kept exactly as they are written (see
+// UnmarshalUseNumber), and strings are re-encoded without escaping HTML characters, so with
+// StorageNormalizeOptions the document keeps its values and signatures over its canonical form
+// still verify. Array elements are never removed, since their positions are significant.
+//
+// Unlike CanonicalMarshalRaw, the output is not JCS: numbers are not reformatted, and members are
+// sorted by their UTF-8 bytes rather than UTF-16 code units.
+func NormalizeJSON(data []byte, opts NormalizeOptions) ([]byte, error) {
+	var value interface{}
+	if err := UnmarshalUseNumber(data, &value); err != nil {
+		return nil, err
+	}
+	value = opts.prune(value)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", opts.Indent)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// prune removes the members that the options exclude from decoded JSON objects.
+func (o NormalizeOptions) prune(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range value {
+			elem = o.prune(elem)
+			if o.removes(elem) {
+				delete(value, k)
+				continue
+			}
+			value[k] = elem
+		}
+	case []interface{}:
+		for i, elem := range value {
+			value[i] = o.prune(elem)
+		}
+	}
+	return v
+}
+
+// removes returns true if an object member with the value is removed.
+func (o NormalizeOptions) removes(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return o.RemoveNulls
+	case string:
+		return o.RemoveEmptyStrings && value == ""
+	case map[string]interface{}:
+		return o.RemoveEmptyCollections && len(value) == 0
+	case []interface{}:
+		return o.RemoveEmptyCollections && len(value) == 0
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeJSON(t *testing.T) {
+	input := `{
+  "b": {"z": null, "y": "", "x": [], "w": {"v": null}},
+  "a": [null, "", {}, 1.50, 12345678901234567890],
+  "html": "<a href=\"x\">&</a>",
+  "escaped": "\u00e9",
+  "proof": {"nonce": "n", "signatureValue": "s"}
+}`
+
+	t.Run("Storage", func(t *testing.T) {
+		normalized, err := NormalizeJSON([]byte(input), StorageNormalizeOptions)
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":[null,"",{},1.50,12345678901234567890],"b":{"w":{"v":null},"x":[],"y":"","z":null},`+
+			`"escaped":"é","html":"<a href=\"x\">&</a>","proof":{"nonce":"n","signatureValue":"s"}}`, string(normalized))
+
+		// Normalization is idempotent, and the document keeps its values.
+		again, err := NormalizeJSON(normalized, StorageNormalizeOptions)
+		require.NoError(t, err)
+		assert.Equal(t, normalized, again)
+		equal, err := JSONEquals([]byte(input), normalized)
+		require.NoError(t, err)
+		assert.True(t, equal)
+	})
+
+	t.Run("Display", func(t *testing.T) {
+		normalized, err := NormalizeJSON([]byte(input), DisplayNormalizeOptions)
+		require.NoError(t, err)
+		assert.Equal(t, `{
+  "a": [
+    null,
+    "",
+    {},
+    1.50,
+    12345678901234567890
+  ],
+  "escaped": "é",
+  "html": "<a href=\"x\">&</a>",
+  "proof": {
+    "nonce": "n",
+    "signatureValue": "s"
+  }
+}`, string(normalized))
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		tests := []struct {
+			opts     NormalizeOptions
+			expected string
+		}{
+			{opts: NormalizeOptions{RemoveNulls: true}, expected: `{"a":"","b":[],"c":{}}`},
+			{opts: NormalizeOptions{RemoveEmptyStrings: true}, expected: `{"b":[],"c":{"d":null},"e":null}`},
+			{opts: NormalizeOptions{RemoveEmptyCollections: true}, expected: `{"a":"","c":{"d":null},"e":null}`},
+		}
+		for _, test := range tests {
+			normalized, err := NormalizeJSON([]byte(`{"e":null,"c":{"d":null},"b":[],"a":""}`), test.opts)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(normalized))
+		}
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		_, err := NormalizeJSON([]byte(`{"a":`), StorageNormalizeOptions)
+		assert.Error(t, err)
+		_, err = NormalizeJSON([]byte(`{} {}`), StorageNormalizeOptions)
+		assert.Error(t, err)
+	})
+}
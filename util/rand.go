@@ -0,0 +1,115 @@
+package util
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mr-tron/base58"
+)
+
+// Randomness is consumed by the following operations, all of which read from RandReader:
+//
+//   - proof nonces, when a proof is created without the proof.WithNonce option;
+//   - key generation: did.GenerateDIDDocWithKeys (for keys without a private key),
+//     did.GenerateDIDDoc, ledger.GenerateLedgerDIDDoc and the didtest helpers with a nil seed;
+//   - ECDSA signatures made by proof.CryptoSigner. Note that crypto/ecdsa mixes the private key and
+//     digest into the randomness, and may consume an extra byte at random, so ECDSA signatures are
+//     not reproducible even with a deterministic reader. Ed25519 signatures are always
+//     deterministic and consume no randomness;
+//   - IDs generated with NewUUID, NewURNUUID and NewID, e.g. schema, proof request, credential
+//     and service IDs;
+//   - the salt and nonce of revocation.Blind;
+//   - the content keys, ephemeral keys and nonces of proof.Encrypt.
+//
+// Secp256k1 signatures made by proof.Secp256k1Signer are computed by btcec, and do not read from
+// RandReader.
+var (
+	randMu     sync.RWMutex
+	randReader io.Reader = rand.Reader
+)
+
+// RandReader returns the source of randomness used by the packages in this module. It defaults
+// to crypto/rand.Reader.
+func RandReader() io.Reader {
+	randMu.RLock()
+	defer randMu.RUnlock()
+	return randReader
+}
+
+// SetRandReader replaces the source of randomness used by the packages in this module, e.g. with
+// an approved DRBG. A nil reader restores crypto/rand.Reader. The returned function restores the
+// previous reader, which is useful in tests: combined with a deterministic reader and the
+// proof.WithClock option, Ed25519 signing is fully reproducible.
+func SetRandReader(r io.Reader) (restore func()) {
+	if r == nil {
+		r = rand.Reader
+	}
+	randMu.Lock()
+	defer randMu.Unlock()
+	previous := randReader
+	randReader = r
+	return func() { SetRandReader(previous) }
+}
+
+// NewUUID returns a random (version 4) UUID read from RandReader. Like uuid.New, it panics if the
+// reader fails.
+func NewUUID() uuid.UUID {
+	return uuid.Must(uuid.NewRandomFromReader(RandReader()))
+}
+
+// URNUUIDPrefix is the prefix of UUID URNs (RFC 4122), e.g. "urn:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+const URNUUIDPrefix = "urn:uuid:"
+
+// idLength is the number of random bytes in an ID generated by NewID.
+const idLength = 16
+
+// NewURNUUID returns a random UUID read from RandReader as a URN, which is a valid URI, e.g. for
+// the ID of a Verifiable Credential. Like NewUUID, it panics if the reader fails.
+func NewURNUUID() string {
+	return URNUUIDPrefix + NewUUID().String()
+}
+
+// ValidateURNUUID checks that the string is a UUID URN, as returned by NewURNUUID. Any version of
+// UUID is accepted, but the UUID must be in its canonical, hyphenated form.
+func ValidateURNUUID(s string) error {
+	if !strings.HasPrefix(s, URNUUIDPrefix) {
+		return fmt.Errorf("ID<%s> is not a UUID URN", s)
+	}
+	id := strings.TrimPrefix(s, URNUUIDPrefix)
+	if _, err := uuid.Parse(id); err != nil || len(id) != 36 {
+		return fmt.Errorf("ID<%s> is not a UUID URN", s)
+	}
+	return nil
+}
+
+// NewID returns the prefix followed by the base58 encoding of 16 bytes read from RandReader, e.g.
+// NewID("service-") returns "service-Lg7PzkLqGwNtBXGC5UtE3f". The encoding contains only
+// alphanumeric characters, so the ID is safe to use in URIs and as a DID URL fragment. It panics
+// if the reader fails.
+func NewID(prefix string) string {
+	b := make([]byte, idLength)
+	if _, err := io.ReadFull(RandReader(), b); err != nil {
+		panic(err)
+	}
+	return prefix + base58.Encode(b)
+}
+
+// ValidateID checks that the ID is the prefix followed by the base58 encoding of 16 bytes, as
+// returned by NewID.
+func ValidateID(prefix, id string) error {
+	if !strings.HasPrefix(id, prefix) {
+		return fmt.Errorf("ID<%s> does not have prefix<%s>", id, prefix)
+	}
+	b, err := base58.Decode(strings.TrimPrefix(id, prefix))
+	if err != nil {
+		return fmt.Errorf("ID<%s> is not base58 encoded: %v", id, err)
+	}
+	if len(b) != idLength {
+		return fmt.Errorf("ID<%s> has %d bytes, expected %d", id, len(b), idLength)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRandReader(t *testing.T) {
+	deterministic := func() *bytes.Reader { return bytes.NewReader(bytes.Repeat([]byte{0x42}, 32)) }
+
+	restore := SetRandReader(deterministic())
+	first := NewUUID()
+	SetRandReader(deterministic())
+	second := NewUUID()
+	restore()
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, uuid.Version(4), first.Version())
+	assert.Equal(t, rand.Reader, RandReader())
+
+	// An exhausted reader cannot produce a UUID.
+	restore = SetRandReader(bytes.NewReader(nil))
+	assert.Panics(t, func() { NewUUID() })
+	restore()
+
+	restore = SetRandReader(nil)
+	assert.Equal(t, rand.Reader, RandReader())
+	restore()
+}
+
+func TestNewID(t *testing.T) {
+	deterministic := func() *bytes.Reader { return bytes.NewReader(bytes.Repeat([]byte{0x42}, 32)) }
+
+	restore := SetRandReader(deterministic())
+	id := NewID("service-")
+	SetRandReader(deterministic())
+	assert.Equal(t, id, NewID("service-"))
+	SetRandReader(deterministic())
+	urn := NewURNUUID()
+	restore()
+
+	assert.Equal(t, "service-9BYzAer6QtbStWaJLKusZT", id)
+	assert.NoError(t, ValidateID("service-", id))
+	assert.Equal(t, "urn:uuid:42424242-4242-4242-8242-424242424242", urn)
+	assert.NoError(t, ValidateURNUUID(urn))
+
+	// Random IDs do not collide.
+	assert.NotEqual(t, NewID("service-"), NewID("service-"))
+	assert.NotEqual(t, NewURNUUID(), NewURNUUID())
+
+	// An exhausted reader cannot produce an ID.
+	restore = SetRandReader(bytes.NewReader(make([]byte, 15)))
+	assert.Panics(t, func() { NewID("") })
+	restore()
+
+	assert.EqualError(t, ValidateID("service-", "key-"+id[len("service-"):]), "ID<key-9BYzAer6QtbStWaJLKusZT> does not have prefix<service->")
+	assert.EqualError(t, ValidateID("service-", "service-abc"), "ID<service-abc> has 3 bytes, expected 16")
+	for _, invalid := range []string{"service-0OIl", "service-"} {
+		err := ValidateID("service-", invalid)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "ID<"+invalid+"> is not base58 encoded")
+		}
+	}
+
+	for _, invalid := range []string{
+		"42424242-4242-4242-8242-424242424242",
+		"urn:uuid:",
+		"urn:uuid:not-a-uuid",
+		"urn:uuid:{42424242-4242-4242-8242-424242424242}",
+		"urn:uuid:42424242424242428242424242424242",
+	} {
+		assert.EqualError(t, ValidateURNUUID(invalid), "ID<"+invalid+"> is not a UUID URN")
+	}
+}
@@ -0,0 +1,128 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnknownFieldError is returned by StrictUnmarshal when the JSON contains a property that does not
+// map to a field of the target type.
+type UnknownFieldError struct {
+	// Field is the name of the unknown property.
+	Field string
+	// Path is the JSON path of the object containing the property, e.g. "$.publicKey[1]".
+	Path string
+}
+
+func (e UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q at %s", e.Field, e.Path)
+}
+
+// StrictUnmarshal unmarshals the JSON into v, like json.Unmarshal, but rejects properties that do
+// not map to a field of the target type, at any depth, with an UnknownFieldError. Unlike
+// json.Unmarshal, property names must match the field names exactly, so that a property cannot
+// be smuggled past a check by changing its case (e.g. "ID" for "id"). Use it for security
+// sensitive inputs, where a property we do not recognize must not be silently dropped or
+// preserved for downstream systems.
+//
+// Structs are checked against their JSON field names even if they implement json.Unmarshaler
+// (e.g. did.DIDDoc, which would otherwise preserve unknown properties as extras). Values of other
+// types that implement json.Unmarshaler, and values decoded into interface{} or maps, are not
+// checked.
+func StrictUnmarshal(data []byte, v interface{}) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if err := checkUnknownFields("$", value, reflect.TypeOf(v)); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// checkUnknownFields checks the decoded JSON value against the type it will be unmarshaled into.
+func checkUnknownFields(path string, value interface{}, t reflect.Type) error {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		fields := jsonFieldTypes(t)
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fieldType, ok := fields[name]
+			if !ok {
+				return UnknownFieldError{Field: name, Path: path}
+			}
+			if err := checkUnknownFields(path+"."+name, v[name], fieldType); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		if reflect.PtrTo(t).Implements(unmarshalerType) {
+			return nil
+		}
+		for i, elem := range v {
+			if err := checkUnknownFields(fmt.Sprintf("%s[%d]", path, i), elem, t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFieldTypes returns the types of the struct's fields by JSON property name, including the
+// fields of untagged embedded structs.
+func jsonFieldTypes(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for embedded, embeddedType := range jsonFieldTypes(fieldType) {
+				if _, ok := fields[embedded]; !ok {
+					fields[embedded] = embeddedType
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			// Unexported fields are not unmarshaled.
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type strictTestBase struct {
+	ID string `json:"id"`
+}
+
+type strictTestItem struct {
+	Name  string                 `json:"name"`
+	Value json.RawMessage        `json:"value,omitempty"`
+	Any   map[string]interface{} `json:"any,omitempty"`
+}
+
+type strictTestDoc struct {
+	strictTestBase
+	Items    []strictTestItem `json:"items"`
+	Nested   *strictTestItem  `json:"nested,omitempty"`
+	Hidden   string           `json:"-"`
+	Untagged string
+}
+
+func TestStrictUnmarshal(t *testing.T) {
+	data := `{"id":"1","items":[{"name":"a","value":{"x":1}},{"name":"b","any":{"y":2}}],"nested":{"name":"c"},"Untagged":"u"}`
+	var doc strictTestDoc
+	require.NoError(t, StrictUnmarshal([]byte(data), &doc))
+	assert.Equal(t, "1", doc.ID)
+	assert.Len(t, doc.Items, 2)
+	assert.Equal(t, "c", doc.Nested.Name)
+	assert.Equal(t, "u", doc.Untagged)
+
+	tests := []struct {
+		name     string
+		data     string
+		expected UnknownFieldError
+		message  string
+	}{
+		{
+			name:     "Top level",
+			data:     `{"id":"1","extra":true}`,
+			expected: UnknownFieldError{Field: "extra", Path: "$"},
+			message:  `unknown field "extra" at $`,
+		},
+		{
+			name:     "Array element",
+			data:     `{"items":[{"name":"a"},{"name":"b","extra":true}]}`,
+			expected: UnknownFieldError{Field: "extra", Path: "$.items[1]"},
+			message:  `unknown field "extra" at $.items[1]`,
+		},
+		{
+			name:     "Pointer",
+			data:     `{"nested":{"name":"c","extra":true}}`,
+			expected: UnknownFieldError{Field: "extra", Path: "$.nested"},
+			message:  `unknown field "extra" at $.nested`,
+		},
+		{
+			name:     "Ignored field",
+			data:     `{"Hidden":"h"}`,
+			expected: UnknownFieldError{Field: "Hidden", Path: "$"},
+			message:  `unknown field "Hidden" at $`,
+		},
+		{
+			name:     "Wrong case",
+			data:     `{"ID":"1"}`,
+			expected: UnknownFieldError{Field: "ID", Path: "$"},
+			message:  `unknown field "ID" at $`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var doc strictTestDoc
+			assert.NoError(t, json.Unmarshal([]byte(test.data), &doc))
+
+			err := StrictUnmarshal([]byte(test.data), &doc)
+			var unknownField UnknownFieldError
+			require.True(t, errors.As(err, &unknownField), err)
+			assert.Equal(t, test.expected, unknownField)
+			assert.EqualError(t, err, test.message)
+		})
+	}
+
+	assert.Error(t, StrictUnmarshal([]byte(`{"id":`), &doc))
+	assert.Error(t, StrictUnmarshal([]byte(`{"id":1}`), &doc))
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseRFC3339Lenient parses an RFC 3339 timestamp with second or sub-second precision, and
+// either a "Z" or a numeric UTC offset, e.g. "2020-01-01T00:00:00Z", "2020-01-01T00:00:00.123Z"
+// or "2020-01-01T01:00:00+01:00". The lowercase "t" and "z" separators allowed by RFC 3339 are
+// also accepted. Other formats, such as RFC 1123 or Unix timestamps, are rejected.
+func ParseRFC3339Lenient(s string) (time.Time, error) {
+	normalized := []byte(s)
+	if len(normalized) > 10 && normalized[10] == 't' {
+		normalized[10] = 'T'
+	}
+	if n := len(normalized); n > 0 && normalized[n-1] == 'z' {
+		normalized[n-1] = 'Z'
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(normalized))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid RFC 3339 timestamp<%s>", s)
+	}
+	return t, nil
+}
+
+// FormatCanonicalTime formats the time as the signature suites have always written timestamps:
+// RFC 3339 in UTC, truncated to the second, with a "Z" suffix, e.g. "2020-01-01T00:00:00Z".
+func FormatCanonicalTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRFC3339Lenient(t *testing.T) {
+	instant := time.Date(2020, time.June, 5, 1, 12, 15, 0, time.UTC)
+	accepted := map[string]time.Time{
+		"2020-06-05T01:12:15Z":             instant,
+		"2020-06-05t01:12:15z":             instant,
+		"2020-06-05T01:12:15.5Z":           instant.Add(500 * time.Millisecond),
+		"2020-06-05T01:12:15.123456789Z":   instant.Add(123456789 * time.Nanosecond),
+		"2020-06-05T01:12:15+00:00":        instant,
+		"2020-06-05T03:12:15+02:00":        instant,
+		"2020-06-04T20:12:15-05:00":        instant,
+		"2020-06-05T06:42:15.250+05:30":    instant.Add(250 * time.Millisecond),
+		"2020-06-05T01:12:15.000000000Z":   instant,
+		"2020-06-04T21:12:15.000001-04:00": instant.Add(time.Microsecond),
+	}
+	for input, expected := range accepted {
+		t.Run(input, func(t *testing.T) {
+			parsed, err := ParseRFC3339Lenient(input)
+			require.NoError(t, err)
+			assert.True(t, expected.Equal(parsed), parsed.String())
+		})
+	}
+
+	for _, rejected := range []string{
+		"",
+		"Fri, 05 Jun 2020 01:12:15 GMT",   // RFC 1123
+		"Fri, 05 Jun 2020 01:12:15 +0000", // RFC 1123 with numeric zone
+		"1591319535",                      // Unix seconds
+		"1591319535000",                   // Unix milliseconds
+		"2020-06-05",                      // date only
+		"2020-06-05T01:12:15",             // no offset
+		"2020-06-05T01:12Z",               // no seconds
+		"2020-06-05 01:12:15Z",            // space separator
+		"2020-06-05T01:12:15+0200",        // offset without colon
+		"2020-06-05T01:12:15.Z",           // empty fraction
+		"2020-13-05T01:12:15Z",            // month out of range
+	} {
+		t.Run(rejected, func(t *testing.T) {
+			_, err := ParseRFC3339Lenient(rejected)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFormatCanonicalTime(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	assert.Equal(t, "2020-06-05T01:12:15Z", FormatCanonicalTime(time.Date(2020, time.June, 4, 20, 12, 15, 999999999, est)))
+	assert.Equal(t, "0001-01-01T00:00:00Z", FormatCanonicalTime(time.Time{}))
+
+	// Formatting and parsing round trips at second precision.
+	parsed, err := ParseRFC3339Lenient("2020-06-05T03:12:15.75+02:00")
+	require.NoError(t, err)
+	assert.Equal(t, "2020-06-05T01:12:15Z", FormatCanonicalTime(parsed))
+}
@@ -10,11 +10,6 @@ import (
 	"github.com/mr-tron/base58"
 )
 
-const (
-	offsetSeq        uint8 = 1
-	offsetSeqAndSize uint8 = 2
-)
-
 type Emptyable interface {
 	IsEmpty() bool
 }
@@ -48,6 +43,24 @@ func DeepCopy(from interface{}, to interface{}) error {
 	return nil
 }
 
+// DeepCopyJSON makes a deep copy from "from" into "to", which must be a pointer, through JSON.
+// Unlike DeepCopy, numbers decoded into interface{} values are kept as json.Number, so that they
+// are not rounded through float64, and json.RawMessage values are copied byte for byte (aside
+// from insignificant whitespace). Unexported fields and fields that are not marshaled are not
+// copied.
+func DeepCopyJSON(from interface{}, to interface{}) error {
+	if to == nil || reflect.TypeOf(to).Kind() != reflect.Ptr {
+		return errors.New("to must be a ptr type")
+	}
+	b, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	return decoder.Decode(to)
+}
+
 // IsPtrOrSlice returns true if the object is either a pointer or a slice.
 func IsPtrOrSlice(unknown interface{}) bool {
 	if unknownType := reflect.TypeOf(unknown); unknownType.Kind() != reflect.Ptr && unknownType.Elem().Kind() != reflect.Slice {
@@ -57,16 +70,9 @@ func IsPtrOrSlice(unknown interface{}) bool {
 }
 
 // JSONBytesEqual compares the JSON in two byte slices for deep equality, ignoring whitespace
-// and other non-semantically meaningful formatting differences.
+// and other non-semantically meaningful formatting differences. It is the same as JSONEquals.
 func JSONBytesEqual(a, b []byte) (bool, error) {
-	var j1, j2 interface{}
-	if err := json.Unmarshal(a, &j1); err != nil {
-		return false, err
-	}
-	if err := json.Unmarshal(b, &j2); err != nil {
-		return false, err
-	}
-	return reflect.DeepEqual(j2, j1), nil
+	return JSONEquals(a, b)
 }
 
 // Base64ToBase58 converts a base64 encoded string into a base58 encoded string.
@@ -85,34 +91,15 @@ func Base58ToBase64(encodedBase58 string) (string, error) {
 	return base64Encoded, err
 }
 
-// ExtractPublicKeyFromBase58Der extracts a public key from a base58 encoded
-// Distinguished Encoding Rules (DER) formatted string.
+// ExtractPublicKeyFromBase58Der extracts a secp256k1 public key from a base58 encoded key, and
+// returns it as an uncompressed SEC1 point. Despite the name, any encoding accepted by
+// ParseECPublicKey is supported.
 func ExtractPublicKeyFromBase58Der(encodedBase58 string) ([]byte, error) {
-	der, err := base58.Decode(encodedBase58)
+	publicKey, _, err := ParseECPublicKeyBase58(encodedBase58)
 	if err != nil {
 		return nil, err
 	}
-
-	return extractPublicKey(der)
-}
-
-// DER format is [seq:size:MAIN]
-// MAIN format is [seq:size:TYPE:seq:size:KEY]
-// seq and size are one byte
-func extractPublicKey(der []byte) ([]byte, error) {
-	main := der[offsetSeqAndSize:]
-	keyTypeLength := main[offsetSeq]
-
-	var keyStartingOffset uint8 = offsetSeqAndSize + keyTypeLength
-	keyLength := main[keyStartingOffset+offsetSeq]
-
-	key := main[keyStartingOffset+offsetSeqAndSize : keyStartingOffset+offsetSeqAndSize+keyLength]
-
-	if key[0] == 0 {
-		return key[1:], nil
-	}
-
-	return key, nil
+	return EncodeECPublicKey(publicKey, UncompressedEncoding)
 }
 
 func AddNonceToDoc(unsignedDoc []byte, nonce string) []byte {
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeepCopyJSON(t *testing.T) {
+	type document struct {
+		Created time.Time              `json:"created"`
+		Amount  interface{}            `json:"amount"`
+		Raw     json.RawMessage        `json:"raw"`
+		Nested  map[string]interface{} `json:"nested"`
+		Tags    []string               `json:"tags"`
+	}
+	original := document{
+		Created: time.Date(2020, time.January, 1, 12, 30, 0, 123456789, time.UTC),
+		Amount:  json.Number("12345678901234567890"),
+		Raw:     json.RawMessage(`{"b":1.50,"a":[1e3]}`),
+		Nested:  map[string]interface{}{"x": map[string]interface{}{"y": "z"}},
+		Tags:    []string{"a", "b"},
+	}
+
+	var copied document
+	require.NoError(t, DeepCopyJSON(&original, &copied))
+	assert.Equal(t, original, copied)
+	assert.True(t, original.Created.Equal(copied.Created))
+
+	// Numbers are not rounded through float64, and raw JSON is kept as is.
+	assert.Equal(t, json.Number("12345678901234567890"), copied.Amount)
+	assert.Equal(t, `{"b":1.50,"a":[1e3]}`, string(copied.Raw))
+
+	copied.Nested["x"].(map[string]interface{})["y"] = "changed"
+	copied.Tags[0] = "changed"
+	copied.Raw[2] = 'c'
+	assert.Equal(t, "z", original.Nested["x"].(map[string]interface{})["y"])
+	assert.Equal(t, "a", original.Tags[0])
+	assert.Equal(t, `{"b":1.50,"a":[1e3]}`, string(original.Raw))
+
+	assert.Error(t, DeepCopyJSON(&original, copied))
+	assert.Error(t, DeepCopyJSON(&original, nil))
+	assert.Error(t, DeepCopyJSON(func() {}, &copied))
+}
+
+func TestDeepCopyNumbers(t *testing.T) {
+	original := map[string]interface{}{"amount": json.Number("1.50")}
+	var copied map[string]interface{}
+	require.NoError(t, DeepCopy(&original, &copied))
+	// DeepCopy decodes numbers as float64, as json.Unmarshal does; see DeepCopyJSON.
+	assert.Equal(t, 1.5, copied["amount"])
+}
@@ -0,0 +1,154 @@
+package util
+
+import (
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// X25519KeySize is the size of X25519 public and private keys.
+const X25519KeySize = 32
+
+var (
+	// curve25519P is the prime 2^255 - 19 that defines the field for both Curve25519 and Ed25519.
+	curve25519P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+	// edwards25519D is the constant d = -121665/121666 of the twisted Edwards curve
+	// -x^2 + y^2 = 1 + d*x^2*y^2.
+	edwards25519D = func() *big.Int {
+		d := new(big.Int).ModInverse(big.NewInt(121666), curve25519P)
+		d.Mul(d, big.NewInt(-121665))
+		return d.Mod(d, curve25519P)
+	}()
+
+	errInvalidEd25519PublicKey  = errors.New("invalid Ed25519 public key")
+	errLowOrderEd25519PublicKey = errors.New("invalid Ed25519 public key: low order point")
+)
+
+// Ed25519PublicToX25519 converts an Ed25519 public key into the equivalent X25519 public key,
+// using the birational map from the twisted Edwards curve to Montgomery form: u = (1 + y) / (1 - y).
+// This allows a single Ed25519 key pair to be used for both signatures and key agreement, as done
+// by the DID Key Method. The key must be a valid, canonically encoded point on the curve that is
+// not of low order; low order points would make the shared secret predictable.
+func Ed25519PublicToX25519(publicKey ed25519.PublicKey) ([]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(publicKey))
+	}
+	x, y, err := decodeEdwardsPoint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if isLowOrder(x, y) {
+		return nil, errLowOrderEd25519PublicKey
+	}
+
+	// y != 1, as (0, 1) is the identity, which is of low order.
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, curve25519P)
+	denominator.ModInverse(denominator, curve25519P)
+	u := new(big.Int).Add(big.NewInt(1), y)
+	u.Mul(u, denominator)
+	u.Mod(u, curve25519P)
+	return littleEndian(u), nil
+}
+
+// Ed25519PrivateToX25519 converts an Ed25519 private key into the equivalent X25519 private key,
+// whose public key is the result of Ed25519PublicToX25519 for the Ed25519 public key. The X25519
+// key is the clamped first half of the SHA-512 digest of the Ed25519 seed, which is also the
+// Ed25519 signing scalar, so it must be protected like the Ed25519 private key, and zeroized after
+// use.
+func Ed25519PrivateToX25519(privateKey ed25519.PrivateKey) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 private key length: %d", len(privateKey))
+	}
+	digest := sha512.Sum512(privateKey.Seed())
+	defer Zeroize(digest[:])
+	x25519Key := make([]byte, X25519KeySize)
+	copy(x25519Key, digest[:X25519KeySize])
+	x25519Key[0] &= 248
+	x25519Key[31] &= 127
+	x25519Key[31] |= 64
+	return x25519Key, nil
+}
+
+// decodeEdwardsPoint decodes an Ed25519 public key as defined by RFC 8032, section 5.1.3: the
+// little-endian y coordinate, with the sign of x in the most significant bit.
+func decodeEdwardsPoint(publicKey []byte) (x, y *big.Int, err error) {
+	yBytes := make([]byte, len(publicKey))
+	for i := range publicKey {
+		yBytes[len(publicKey)-1-i] = publicKey[i]
+	}
+	sign := uint(yBytes[0] >> 7)
+	yBytes[0] &= 0x7f
+	y = new(big.Int).SetBytes(yBytes)
+	if y.Cmp(curve25519P) >= 0 {
+		return nil, nil, errInvalidEd25519PublicKey
+	}
+
+	// x^2 = (y^2 - 1) / (d*y^2 + 1)
+	ySquared := new(big.Int).Mul(y, y)
+	numerator := new(big.Int).Sub(ySquared, big.NewInt(1))
+	denominator := new(big.Int).Mul(edwards25519D, ySquared)
+	denominator.Add(denominator, big.NewInt(1))
+	denominator.ModInverse(denominator.Mod(denominator, curve25519P), curve25519P)
+	xSquared := numerator.Mul(numerator, denominator)
+	xSquared.Mod(xSquared, curve25519P)
+
+	x = new(big.Int).ModSqrt(xSquared, curve25519P)
+	if x == nil {
+		return nil, nil, errInvalidEd25519PublicKey
+	}
+	if x.Sign() == 0 && sign == 1 {
+		return nil, nil, errInvalidEd25519PublicKey
+	}
+	if x.Bit(0) != sign {
+		x.Sub(curve25519P, x)
+	}
+	return x, y, nil
+}
+
+// isLowOrder returns true if the point is in the small subgroup of order 8, i.e. if multiplying it
+// by the cofactor 8 gives the identity.
+func isLowOrder(x, y *big.Int) bool {
+	for i := 0; i < 3; i++ {
+		x, y = edwardsAdd(x, y, x, y)
+	}
+	return x.Sign() == 0 && y.Cmp(big.NewInt(1)) == 0
+}
+
+// edwardsAdd adds two points with the complete addition law of the twisted Edwards curve.
+func edwardsAdd(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	x1y2 := new(big.Int).Mul(x1, y2)
+	y1x2 := new(big.Int).Mul(y1, x2)
+	x1x2 := new(big.Int).Mul(x1, x2)
+	y1y2 := new(big.Int).Mul(y1, y2)
+	dxxyy := new(big.Int).Mul(x1x2, y1y2)
+	dxxyy.Mul(dxxyy, edwards25519D)
+	dxxyy.Mod(dxxyy, curve25519P)
+
+	xDenominator := new(big.Int).Add(big.NewInt(1), dxxyy)
+	xDenominator.ModInverse(xDenominator.Mod(xDenominator, curve25519P), curve25519P)
+	x3 := x1y2.Add(x1y2, y1x2)
+	x3.Mul(x3, xDenominator)
+	x3.Mod(x3, curve25519P)
+
+	yDenominator := new(big.Int).Sub(big.NewInt(1), dxxyy)
+	yDenominator.ModInverse(yDenominator.Mod(yDenominator, curve25519P), curve25519P)
+	y3 := y1y2.Add(y1y2, x1x2)
+	y3.Mul(y3, yDenominator)
+	y3.Mod(y3, curve25519P)
+	return x3, y3
+}
+
+// littleEndian encodes the field element as 32 little-endian bytes.
+func littleEndian(n *big.Int) []byte {
+	b := n.Bytes()
+	le := make([]byte, X25519KeySize)
+	for i := range b {
+		le[i] = b[len(b)-1-i]
+	}
+	return le
+}
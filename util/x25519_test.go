@@ -0,0 +1,100 @@
+package util
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/ed25519"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestEd25519ToX25519(t *testing.T) {
+	// Known answers from libsodium's ed25519_convert test.
+	t.Run("libsodium", func(t *testing.T) {
+		seed := decodeHex(t, "421151a459faeade3d247115f94aedae42318124095afabe4d1451a559faedee")
+		privateKey := ed25519.NewKeyFromSeed(seed)
+		assert.Equal(t, decodeHex(t, "b5076a8474a832daee4dd5b4040983b6623b5f344aca57d4d6ee4baf3f259e6e"), []byte(privateKey.Public().(ed25519.PublicKey)))
+
+		x25519Public, err := Ed25519PublicToX25519(privateKey.Public().(ed25519.PublicKey))
+		require.NoError(t, err)
+		assert.Equal(t, decodeHex(t, "f1814f0e8ff1043d8a44d25babff3cedcae6c22c3edaa48f857ae70de2baae50"), x25519Public)
+
+		x25519Private, err := Ed25519PrivateToX25519(privateKey)
+		require.NoError(t, err)
+		assert.Equal(t, decodeHex(t, "8052030376d47112be7f73ed7a019293dd12ad910b654455798b4667d73de166"), x25519Private)
+	})
+
+	// The converted keys of the RFC 8032 test vectors form X25519 (RFC 7748) key pairs, and agree
+	// on a shared secret.
+	t.Run("RFC 8032 keys", func(t *testing.T) {
+		seeds := []string{
+			"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+			"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+			"c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+		}
+		var privateKeys, publicKeys [][]byte
+		for _, seed := range seeds {
+			privateKey := ed25519.NewKeyFromSeed(decodeHex(t, seed))
+			x25519Private, err := Ed25519PrivateToX25519(privateKey)
+			require.NoError(t, err)
+			x25519Public, err := Ed25519PublicToX25519(privateKey.Public().(ed25519.PublicKey))
+			require.NoError(t, err)
+
+			derived, err := curve25519.X25519(x25519Private, curve25519.Basepoint)
+			require.NoError(t, err)
+			assert.Equal(t, derived, x25519Public)
+			privateKeys = append(privateKeys, x25519Private)
+			publicKeys = append(publicKeys, x25519Public)
+		}
+
+		secret, err := curve25519.X25519(privateKeys[0], publicKeys[1])
+		require.NoError(t, err)
+		other, err := curve25519.X25519(privateKeys[1], publicKeys[0])
+		require.NoError(t, err)
+		assert.Equal(t, secret, other)
+	})
+
+	// The encodings of the points of order 1, 2, 4 and 8, from libsodium's blacklist.
+	t.Run("Low order points", func(t *testing.T) {
+		for _, point := range []string{
+			"0100000000000000000000000000000000000000000000000000000000000000",
+			"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"0000000000000000000000000000000000000000000000000000000000000080",
+			"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a",
+			"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac03fa",
+			"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05",
+			"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc85",
+		} {
+			_, err := Ed25519PublicToX25519(decodeHex(t, point))
+			assert.EqualError(t, err, "invalid Ed25519 public key: low order point", point)
+		}
+	})
+
+	t.Run("Invalid public keys", func(t *testing.T) {
+		for _, point := range []string{
+			// y = p is not canonical.
+			"edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+			// y = 2 is not on the curve.
+			"0200000000000000000000000000000000000000000000000000000000000000",
+			// x = 0 with the sign bit set is not canonical.
+			"0100000000000000000000000000000000000000000000000000000000000080",
+		} {
+			_, err := Ed25519PublicToX25519(decodeHex(t, point))
+			assert.EqualError(t, err, "invalid Ed25519 public key", point)
+		}
+
+		_, err := Ed25519PublicToX25519(make([]byte, 31))
+		assert.EqualError(t, err, "invalid Ed25519 public key length: 31")
+		_, err = Ed25519PrivateToX25519(make([]byte, 32))
+		assert.EqualError(t, err, "invalid Ed25519 private key length: 32")
+	})
+}
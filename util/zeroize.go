@@ -0,0 +1,37 @@
+package util
+
+import (
+	"math/big"
+	"runtime"
+)
+
+// Zeroizer is implemented by signers and other holders of key material that can wipe it from
+// memory, e.g. proof.Ed25519Signer. Once zeroized, the holder must not be used again.
+type Zeroizer interface {
+	Zeroize()
+}
+
+// Zeroize overwrites the buffer with zeros, e.g. to wipe a private key or seed once it is no
+// longer needed. The buffer is kept alive until the writes are done, so that the compiler cannot
+// elide them; this is best effort, as copies made elsewhere (e.g. by the garbage collector moving
+// a stack) cannot be wiped. Zeroizing an empty or already zeroized buffer is safe.
+func Zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
+
+// ZeroizeBigInt overwrites the words of the integer with zeros and sets it to zero, e.g. to wipe
+// the scalar of an ECDSA private key. A nil integer is ignored.
+func ZeroizeBigInt(n *big.Int) {
+	if n == nil {
+		return
+	}
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	runtime.KeepAlive(words)
+	n.SetInt64(0)
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZeroize(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 64)
+	seed := key[:32]
+	Zeroize(seed)
+	assert.Equal(t, make([]byte, 32), seed)
+	assert.Equal(t, bytes.Repeat([]byte{0x42}, 32), key[32:])
+
+	Zeroize(key)
+	assert.Equal(t, make([]byte, 64), key)
+
+	// Double zeroize and empty buffers are safe.
+	Zeroize(key)
+	assert.Equal(t, make([]byte, 64), key)
+	Zeroize(nil)
+	Zeroize([]byte{})
+}
+
+func TestZeroizeBigInt(t *testing.T) {
+	n, ok := new(big.Int).SetString("123456789012345678901234567890123456789012345678901234567890", 10)
+	assert.True(t, ok)
+	words := n.Bits()
+
+	ZeroizeBigInt(n)
+	assert.Equal(t, 0, n.Sign())
+	for _, word := range words {
+		assert.Zero(t, word)
+	}
+
+	ZeroizeBigInt(n)
+	assert.Equal(t, 0, n.Sign())
+	ZeroizeBigInt(nil)
+}